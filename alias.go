@@ -0,0 +1,172 @@
+package dynamap
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// AttributeAlias renames a table attribute, enabling gradual attribute migrations
+// without downtime. Items are read using either name, while writes emit the new name.
+//
+// A typical migration first writes both names, backfills existing items, then stops
+// writing the original name:
+//
+//	table.Aliases = []dynamap.AttributeAlias{
+//		{Name: dynamap.AttributeNameRefSortKey, Alias: "ref_sk", WriteBoth: true},
+//	}
+//
+// The hash and sort key attributes cannot be renamed, since the table key schema is fixed.
+type AttributeAlias struct {
+	Name      string // The original attribute name, such as [AttributeNameRefSortKey]
+	Alias     string // The new attribute name
+	WriteBoth bool   // If true, writes emit both names and expressions use the original name
+}
+
+// aliasItem renames aliased attributes of item in place.
+func (mo MarshalOptions) aliasItem(item Item) Item {
+	for _, alias := range mo.Aliases {
+		value, ok := item[alias.Name]
+		if !ok {
+			continue
+		}
+		item[alias.Alias] = value
+		if !alias.WriteBoth {
+			delete(item, alias.Name)
+		}
+	}
+
+	return item
+}
+
+// unaliasItem returns a copy of item with aliased attributes and renamed key attributes
+// restored to their original names. The new name takes precedence when an item contains
+// both.
+func (mo MarshalOptions) unaliasItem(item Item) Item {
+	item = mo.KeyNames.canonicalItem(item)
+	if len(mo.Aliases) == 0 {
+		return item
+	}
+
+	out := make(Item, len(item))
+	for name, value := range item {
+		out[name] = value
+	}
+
+	for _, alias := range mo.Aliases {
+		if value, ok := item[alias.Alias]; ok {
+			out[alias.Name] = value
+			delete(out, alias.Alias)
+		}
+	}
+
+	return out
+}
+
+// aliasNames returns a copy of expression attribute names with aliased attributes renamed,
+// unless the alias also writes the original name. Only top-level attributes are aliased,
+// so the expires segment of data.expires keeps its name; see [renamePaths].
+func (mo MarshalOptions) aliasNames(names map[string]string, expressions ...**string) map[string]string {
+	return renamePaths(names, mo.aliasName, expressions...)
+}
+
+// aliasName returns the name written to items for the attribute name.
+func (mo MarshalOptions) aliasName(name string) string {
+	for _, alias := range mo.Aliases {
+		if name == alias.Name && !alias.WriteBoth {
+			return alias.Alias
+		}
+	}
+	return name
+}
+
+// pathPlaceholderPattern matches the expression attribute name placeholders of an expression,
+// with the dot preceding those that are nested path segments.
+var pathPlaceholderPattern = regexp.MustCompile(`\.?#[0-9A-Za-z_]+`)
+
+// renamePaths returns a copy of expression attribute names with the names of top-level
+// attributes renamed by rename. Placeholders used only as nested path segments in
+// expressions keep their name. A placeholder used both ways is renamed, and its nested
+// occurrences are given a new placeholder holding the original name; each expression is
+// replaced with the rewritten one, leaving the original string unmodified. Placeholders
+// not found in expressions are renamed.
+func renamePaths(names map[string]string, rename func(string) string, expressions ...**string) map[string]string {
+	if names == nil {
+		return nil
+	}
+
+	top, nested := make(map[string]bool), make(map[string]bool)
+	for _, expr := range expressions {
+		if *expr == nil {
+			continue
+		}
+		for _, match := range pathPlaceholderPattern.FindAllString(**expr, -1) {
+			if placeholder, ok := strings.CutPrefix(match, "."); ok {
+				nested[placeholder] = true
+			} else {
+				top[match] = true
+			}
+		}
+	}
+
+	renamed := maps.Clone(names)
+	split := make(map[string]string)
+	for _, placeholder := range slices.Sorted(maps.Keys(names)) {
+		name := names[placeholder]
+		to := rename(name)
+		if to == name || (nested[placeholder] && !top[placeholder]) {
+			continue
+		}
+		renamed[placeholder] = to
+
+		if nested[placeholder] {
+			replacement := placeholder + "_"
+			for _, taken := renamed[replacement]; taken; _, taken = renamed[replacement] {
+				replacement += "_"
+			}
+			renamed[replacement] = name
+			split[placeholder] = replacement
+		}
+	}
+
+	if len(split) > 0 {
+		for _, expr := range expressions {
+			if *expr == nil {
+				continue
+			}
+			rewritten := pathPlaceholderPattern.ReplaceAllStringFunc(**expr, func(match string) string {
+				if replacement, ok := split[strings.TrimPrefix(match, ".")]; ok && strings.HasPrefix(match, ".") {
+					return "." + replacement
+				}
+				return match
+			})
+			*expr = &rewritten
+		}
+	}
+
+	return renamed
+}
+
+// attributeNames returns the names written to items for the attribute name.
+func (mo MarshalOptions) attributeNames(name string) []string {
+	names := []string{name}
+	for _, alias := range mo.Aliases {
+		if alias.Name == name {
+			names = append(names, alias.Alias)
+		}
+	}
+	return names
+}
+
+// validateAliases returns an error if an alias renames a key attribute.
+func (mo MarshalOptions) validateAliases() error {
+	for _, alias := range mo.Aliases {
+		switch alias.Name {
+		case AttributeNameSource, AttributeNameTarget:
+			return fmt.Errorf("key attribute %s cannot be aliased", alias.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package dynamap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for attribute aliases
+
+func TestAttributeAliases(t *testing.T) {
+	product := &Product{ID: "P1", Category: "electronics"}
+
+	t.Run("writes new name", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}}
+
+		putInput, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		if _, ok := putInput.Item[AttributeNameRefSortKey]; ok {
+			t.Error("Expected original attribute to be removed")
+		}
+		if v := putInput.Item["ref_sk"].(*types.AttributeValueMemberS).Value; v != "electronics" {
+			t.Errorf("Expected ref_sk 'electronics', got %s", v)
+		}
+	})
+
+	t.Run("writes both names", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk", WriteBoth: true}}
+
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		for _, request := range batches[0].RequestItems["test-table"] {
+			item := request.PutRequest.Item
+			original, ok := item[AttributeNameRefSortKey]
+			if !ok {
+				continue
+			}
+			if aliased, ok := item["ref_sk"]; !ok || aliased != original {
+				t.Errorf("Expected ref_sk to match %s, got %v", AttributeNameRefSortKey, item["ref_sk"])
+			}
+		}
+	})
+
+	t.Run("reads either name", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameData, Alias: "payload"}}
+
+		putInput, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		var aliased Product
+		if _, err := UnmarshalSelf(putInput.Item, &aliased, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal aliased item: %v", err)
+		}
+		if aliased.Category != "electronics" {
+			t.Errorf("Expected category 'electronics', got %s", aliased.Category)
+		}
+
+		legacyInput, _ := NewTable("test-table").MarshalPut(product)
+		var legacy Product
+		if _, err := UnmarshalSelf(legacyInput.Item, &legacy, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal legacy item: %v", err)
+		}
+		if legacy.Category != "electronics" {
+			t.Errorf("Expected category 'electronics', got %s", legacy.Category)
+		}
+
+		if _, ok := putInput.Item["payload"]; !ok {
+			t.Error("Expected item not to be modified by unmarshal")
+		}
+	})
+
+	t.Run("renames expression attributes", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}}
+
+		queryInput, err := table.MarshalQuery(&QueryList{
+			Label:         "product",
+			RefSortFilter: expression.Key(AttributeNameRefSortKey).BeginsWith("elec"),
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		found := false
+		for _, name := range queryInput.ExpressionAttributeNames {
+			if name == AttributeNameRefSortKey {
+				t.Error("Expected original attribute name to be replaced")
+			}
+			found = found || name == "ref_sk"
+		}
+		if !found {
+			t.Errorf("Expected ref_sk in expression names, got %v", queryInput.ExpressionAttributeNames)
+		}
+	})
+
+	t.Run("keeps nested names", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameExpires, Alias: "ttl"}}
+
+		queryInput, err := table.MarshalQuery(&QueryList{
+			Label: "product",
+			ConditionFilter: DataAttribute(AttributeNameExpires).AttributeExists().
+				And(expression.Name(AttributeNameExpires).AttributeExists()),
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		filter := resolveNames(*queryInput.FilterExpression, queryInput.ExpressionAttributeNames)
+		if want := "(attribute_exists (data.expires)) AND (attribute_exists (ttl))"; filter != want {
+			t.Errorf("Expected filter %q, got %q", want, filter)
+		}
+	})
+
+	t.Run("key attributes cannot be aliased", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Aliases = []AttributeAlias{{Name: AttributeNameSource, Alias: "pk"}}
+
+		if _, err := table.MarshalPut(product); err == nil {
+			t.Error("Expected error when aliasing a key attribute")
+		}
+	})
+}
+
+// resolveNames replaces the expression attribute name placeholders of expr with their
+// names.
+func resolveNames(expr string, names map[string]string) string {
+	return pathPlaceholderPattern.ReplaceAllStringFunc(expr, func(match string) string {
+		if placeholder, ok := strings.CutPrefix(match, "."); ok {
+			return "." + names[placeholder]
+		}
+		return names[match]
+	})
+}
@@ -0,0 +1,143 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Association is a many-to-many relationship between two entity types, such as users and
+// groups, stored as a pair of edges: one named Name in each left partition, and its
+// mirror named Inverse in each right partition. Both edges are written and deleted in
+// the same transaction.
+//
+// Example:
+//
+//	memberships := dynamap.NewAssociation(table, "groups", "members")
+//	err := memberships.Link(ctx, client, user, group)
+//
+//	// Query the groups of the user, or the members of the group
+//	input, err := table.MarshalQuery(memberships.Targets(user))
+//	input, err = table.MarshalQuery(memberships.Sources(group))
+type Association struct {
+	Table   *Table // The table holding the entities
+	Name    string // The relationship name from left to right entities
+	Inverse string // The relationship name from right to left entities
+}
+
+// NewAssociation creates a new [Association] with the relationship names of each direction.
+func NewAssociation(table *Table, name, inverse string) *Association {
+	return &Association{Table: table, Name: name, Inverse: inverse}
+}
+
+// edgeOptions returns the options used to attach and detach both directions.
+func (a *Association) edgeOptions(opts []func(*MarshalOptions)) func(*EdgeOptions) {
+	return func(eo *EdgeOptions) {
+		eo.Transact = true
+		eo.Inverse = a.Inverse
+		eo.MarshalOptions = opts
+	}
+}
+
+// MarshalLink marshals transactions that write the association between left and each of
+// rights in both directions.
+func (a *Association) MarshalLink(left Marshaler, rights []Marshaler, opts ...func(*MarshalOptions)) ([]*dynamodb.TransactWriteItemsInput, error) {
+	return a.Table.MarshalTransactAttach(left, a.Name, rights, a.edgeOptions(opts))
+}
+
+// MarshalUnlink marshals transactions that delete the association between left and each
+// of rights in both directions.
+func (a *Association) MarshalUnlink(left Marshaler, rights []Marshaler, opts ...func(*MarshalOptions)) ([]*dynamodb.TransactWriteItemsInput, error) {
+	return a.Table.MarshalTransactDetach(left, a.Name, rights, a.edgeOptions(opts))
+}
+
+// Link writes the association between left and each of rights using [Association.MarshalLink].
+func (a *Association) Link(ctx context.Context, client DynamoDBClient, left Marshaler, rights ...Marshaler) error {
+	transactions, err := a.MarshalLink(left, rights)
+	if err != nil {
+		return err
+	}
+	return transactWriteAll(ctx, client, transactions)
+}
+
+// Unlink deletes the association between left and each of rights using [Association.MarshalUnlink].
+func (a *Association) Unlink(ctx context.Context, client DynamoDBClient, left Marshaler, rights ...Marshaler) error {
+	transactions, err := a.MarshalUnlink(left, rights)
+	if err != nil {
+		return err
+	}
+	return transactWriteAll(ctx, client, transactions)
+}
+
+// Targets returns a query for the right entities associated with left, such as the
+// groups of a user.
+func (a *Association) Targets(left Marshaler) *QueryAssociation {
+	return &QueryAssociation{Source: left, Name: a.Name}
+}
+
+// Sources returns a query for the left entities associated with right, such as the
+// members of a group.
+func (a *Association) Sources(right Marshaler) *QueryAssociation {
+	return &QueryAssociation{Source: right, Name: a.Inverse}
+}
+
+// QueryAssociation is a QueryMarshaler that searches the ref index for the relationships
+// named Name from the source entity. Unmarshal the results with [UnmarshalList] into a
+// slice of [Ref], or load the associated entities with [Table.ExpandRefs].
+type QueryAssociation struct {
+	Source          Marshaler                      // The source entity
+	Name            string                         // The relationship name
+	RefSortFilter   expression.KeyConditionBuilder // Optional filters on the ref sort key
+	ConditionFilter expression.ConditionBuilder    // Optional filters on the relationship
+	Limit           int                            // Maximum number of items to return
+	StartKey        Item                           // Exclusive start key for pagination
+	SortDescending  bool                           // If true, scans backward
+}
+
+// MarshalQuery implements QueryMarshaler for QueryAssociation.
+func (q *QueryAssociation) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	sourceOpts := *opts
+	sourceOpts.SkipRefs = true
+
+	if err := q.Source.MarshalSelf(&sourceOpts); err != nil {
+		return nil, fmt.Errorf("failed to marshal source: %w", err)
+	}
+
+	keyCondition := expression.Key(opts.refIndexHashKey()).Equal(expression.Value(sourceOpts.refLabel(q.Name)))
+	if q.RefSortFilter.IsSet() {
+		keyCondition = keyCondition.And(q.RefSortFilter)
+	}
+
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+	if q.ConditionFilter.IsSet() {
+		builder = builder.WithFilter(q.ConditionFilter)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ScanIndexForward:          aws.Bool(!q.SortDescending),
+		ExclusiveStartKey:         q.StartKey,
+	}
+
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(int32(q.Limit))
+	}
+
+	return input, nil
+}
+
+// UseIndex implements QueryMarshaler for QueryAssociation.
+func (q *QueryAssociation) UseIndex(t *Table) string {
+	return t.RefIndexName
+}
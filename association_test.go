@@ -0,0 +1,89 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type user struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (u *user) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("user", u.ID)
+	return nil
+}
+
+type group struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (g *group) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("group", g.ID)
+	return nil
+}
+
+// Tests for associations
+
+func TestAssociation(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	memberships := NewAssociation(table, "groups", "members")
+	client := newMockDynamoDBClient()
+
+	alice := &user{ID: "U1"}
+	admins, staff := &group{ID: "G1"}, &group{ID: "G2"}
+
+	t.Run("link", func(t *testing.T) {
+		transactions, err := memberships.MarshalLink(alice, SliceOf(admins, staff))
+		if err != nil {
+			t.Fatalf("Failed to marshal link: %v", err)
+		}
+		if len(transactions) != 1 || len(transactions[0].TransactItems) != 4 {
+			t.Fatalf("Expected 1 transaction with 4 puts, got %v", transactions)
+		}
+
+		if err := memberships.Link(ctx, client, alice, admins, staff); err != nil {
+			t.Fatalf("Failed to link: %v", err)
+		}
+		for _, key := range []string{"user#U1#group#G1", "group#G1#user#U1", "group#G2#user#U1"} {
+			if _, ok := client.items[key]; !ok {
+				t.Errorf("Expected item %s", key)
+			}
+		}
+	})
+
+	t.Run("unlink", func(t *testing.T) {
+		if err := memberships.Unlink(ctx, client, alice, admins); err != nil {
+			t.Fatalf("Failed to unlink: %v", err)
+		}
+		for _, key := range []string{"user#U1#group#G1", "group#G1#user#U1"} {
+			if _, ok := client.items[key]; ok {
+				t.Errorf("Expected item %s to be deleted", key)
+			}
+		}
+		if len(client.items) != 2 {
+			t.Errorf("Expected 2 items, got %d", len(client.items))
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		for query, label := range map[*QueryAssociation]string{
+			memberships.Targets(alice): "user/U1/groups",
+			memberships.Sources(staff): "group/G2/members",
+		} {
+			input, err := table.MarshalQuery(query)
+			if err != nil {
+				t.Fatalf("Failed to marshal query: %v", err)
+			}
+			if *input.IndexName != "ref-index" {
+				t.Errorf("Expected ref-index, got %s", *input.IndexName)
+			}
+			if value := input.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value; value != label {
+				t.Errorf("Expected label %s, got %s", label, value)
+			}
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// AttributeNameActor is the attribute that identifies who made the last change to an item.
+	AttributeNameActor = "actor_id"
+	// AttributeNameRequestID is the attribute that identifies the request that made the
+	// last change to an item.
+	AttributeNameRequestID = "request_id"
+	// AttributeNameReason is the attribute that describes why an item was last changed.
+	AttributeNameReason = "reason"
+)
+
+// Audit describes who made a change, in which request, and why.
+type Audit struct {
+	ActorID   string // Identity of who made the change, such as a user or service id
+	RequestID string // The request that made the change, such as a trace id
+	Reason    string // Why the change was made
+}
+
+// auditKey is the context key of the request [Audit].
+type auditKey struct{}
+
+// WithAudit returns a copy of ctx carrying the audit, which [NewAuditInterceptor] writes
+// to every item changed with the context. Set it once per request, such as in an HTTP
+// middleware:
+//
+//	ctx = dynamap.WithAudit(r.Context(), dynamap.Audit{
+//		ActorID:   user.ID,
+//		RequestID: r.Header.Get("X-Request-Id"),
+//	})
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// AuditFromContext returns the audit carried by ctx, if any.
+func AuditFromContext(ctx context.Context) (Audit, bool) {
+	audit, ok := ctx.Value(auditKey{}).(Audit)
+	return audit, ok
+}
+
+// WrittenBy creates a condition that filters for relationships last changed by the actor.
+func WrittenBy(actorID string) expression.ConditionBuilder {
+	return expression.Name(AttributeNameActor).Equal(expression.Value(actorID))
+}
+
+// WithRequestID creates a condition that filters for relationships last changed by the request.
+func WithRequestID(requestID string) expression.ConditionBuilder {
+	return expression.Name(AttributeNameRequestID).Equal(expression.Value(requestID))
+}
+
+// auditUpdate sets the audit attributes of the options in the update.
+func (mo MarshalOptions) auditUpdate(update expression.UpdateBuilder) expression.UpdateBuilder {
+	attributes := mo.audit().attributes()
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		update = update.Set(expression.Name(name), expression.Value(attributes[name]))
+	}
+	return update
+}
+
+// audit returns the audit attributes of the options.
+func (mo MarshalOptions) audit() Audit {
+	return Audit{ActorID: mo.ActorID, RequestID: mo.RequestID, Reason: mo.Reason}
+}
+
+// attributes returns the audit attribute values that are set, by attribute name.
+func (a Audit) attributes() map[string]string {
+	attributes := make(map[string]string, 3)
+	for name, value := range map[string]string{
+		AttributeNameActor:     a.ActorID,
+		AttributeNameRequestID: a.RequestID,
+		AttributeNameReason:    a.Reason,
+	} {
+		if value != "" {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
+
+// auditInterceptor writes the audit of the request context to the items of the table.
+type auditInterceptor struct {
+	table *Table
+}
+
+// NewAuditInterceptor returns an [Interceptor] that writes the [Audit] carried by the
+// request context to every item put or updated in the table, including items written in
+// batches and transactions. Audit attributes already set by the request, such as with
+// [MarshalOptions.ActorID], are left as is.
+//
+// Example:
+//
+//	table.Interceptors = append(table.Interceptors, dynamap.NewAuditInterceptor(table))
+//
+//	ctx = dynamap.WithAudit(ctx, dynamap.Audit{ActorID: "user-1", Reason: "support ticket 42"})
+//	err := table.Put(ctx, client, order)
+func NewAuditInterceptor(table *Table) Interceptor {
+	return auditInterceptor{table: table}
+}
+
+// Before implements [Interceptor]. The input is copied before the audit is added.
+func (a auditInterceptor) Before(ctx context.Context, req *Request) error {
+	audit, ok := AuditFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	mo := NewMarshalOptions(a.table.MarshalOptions)
+	attributes := make(Item)
+	for name, value := range audit.attributes() {
+		attributes[mo.aliasName(name)] = &types.AttributeValueMemberS{Value: value}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	switch input := req.Input.(type) {
+	case *dynamodb.PutItemInput:
+		if aws.ToString(input.TableName) == a.table.TableName {
+			audited := *input
+			audited.Item = auditItem(input.Item, attributes)
+			req.Input = &audited
+		}
+	case *dynamodb.UpdateItemInput:
+		if aws.ToString(input.TableName) == a.table.TableName {
+			audited := *input
+			audited.UpdateExpression, audited.ExpressionAttributeNames, audited.ExpressionAttributeValues =
+				auditUpdateExpression(input.UpdateExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, attributes)
+			req.Input = &audited
+		}
+	case *dynamodb.BatchWriteItemInput:
+		requests, ok := input.RequestItems[a.table.TableName]
+		if !ok {
+			return nil
+		}
+		audited := *input
+		audited.RequestItems = maps.Clone(input.RequestItems)
+		audited.RequestItems[a.table.TableName] = make([]types.WriteRequest, len(requests))
+		for i, request := range requests {
+			if request.PutRequest != nil {
+				request.PutRequest = &types.PutRequest{Item: auditItem(request.PutRequest.Item, attributes)}
+			}
+			audited.RequestItems[a.table.TableName][i] = request
+		}
+		req.Input = &audited
+	case *dynamodb.TransactWriteItemsInput:
+		audited := *input
+		audited.TransactItems = make([]types.TransactWriteItem, len(input.TransactItems))
+		for i, action := range input.TransactItems {
+			if action.Put != nil && aws.ToString(action.Put.TableName) == a.table.TableName {
+				put := *action.Put
+				put.Item = auditItem(put.Item, attributes)
+				action.Put = &put
+			}
+			if action.Update != nil && aws.ToString(action.Update.TableName) == a.table.TableName {
+				update := *action.Update
+				update.UpdateExpression, update.ExpressionAttributeNames, update.ExpressionAttributeValues =
+					auditUpdateExpression(action.Update.UpdateExpression, action.Update.ExpressionAttributeNames, action.Update.ExpressionAttributeValues, attributes)
+				action.Update = &update
+			}
+			audited.TransactItems[i] = action
+		}
+		req.Input = &audited
+	}
+
+	return nil
+}
+
+// After implements [Interceptor].
+func (a auditInterceptor) After(ctx context.Context, req *Request) {}
+
+// auditItem returns a copy of item with the audit attributes it does not already have.
+func auditItem(item Item, attributes Item) Item {
+	audited := maps.Clone(item)
+	for name, value := range attributes {
+		if _, ok := audited[name]; !ok {
+			audited[name] = value
+		}
+	}
+	return audited
+}
+
+// setClause matches the SET clause of an update expression.
+var setClause = regexp.MustCompile(`(?i)(^|\s)SET\s+`)
+
+// auditUpdateExpression returns copies of the update expression and its attribute names
+// and values that also set the audit attributes the update does not already set.
+func auditUpdateExpression(update *string, names map[string]string, values map[string]types.AttributeValue, attributes Item) (*string, map[string]string, map[string]types.AttributeValue) {
+	names, values = maps.Clone(names), maps.Clone(values)
+	if names == nil {
+		names = make(map[string]string)
+	}
+	if values == nil {
+		values = make(map[string]types.AttributeValue)
+	}
+
+	var actions []string
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		if slices.Contains(slices.Collect(maps.Values(names)), name) {
+			continue // The update already changes the attribute
+		}
+		names["#dynamap_"+name] = name
+		values[":dynamap_"+name] = attributes[name]
+		actions = append(actions, "#dynamap_"+name+" = :dynamap_"+name)
+	}
+	if len(actions) == 0 {
+		return update, names, values
+	}
+
+	expr := aws.ToString(update)
+	set := strings.Join(actions, ", ")
+	if loc := setClause.FindStringIndex(expr); loc != nil {
+		expr = expr[:loc[1]] + set + ", " + expr[loc[1]:]
+	} else {
+		expr = strings.TrimSpace("SET " + set + " " + expr)
+	}
+	return aws.String(expr), names, values
+}
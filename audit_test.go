@@ -0,0 +1,197 @@
+package dynamap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for audit attributes
+
+func TestAuditAttributes(t *testing.T) {
+	table := NewTable("test-table")
+	audit := func(mo *MarshalOptions) {
+		mo.ActorID = "user-1"
+		mo.RequestID = "req-1"
+	}
+
+	t.Run("puts write audit attributes", func(t *testing.T) {
+		input, err := table.MarshalPut(&Product{ID: "P1"}, audit)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if StringValue(input.Item[AttributeNameActor]) != "user-1" || StringValue(input.Item[AttributeNameRequestID]) != "req-1" {
+			t.Errorf("Expected audit attributes, got %v", input.Item)
+		}
+		if _, ok := input.Item[AttributeNameReason]; ok {
+			t.Error("Expected no reason attribute")
+		}
+
+		rel, err := UnmarshalSelf(input.Item, &Product{}, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if rel.ActorID != "user-1" || rel.RequestID != "req-1" {
+			t.Errorf("Expected audit fields, got %+v", rel)
+		}
+	})
+
+	t.Run("items without audit have no attributes", func(t *testing.T) {
+		input, err := table.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if _, ok := input.Item[AttributeNameActor]; ok {
+			t.Errorf("Expected no actor attribute, got %v", input.Item)
+		}
+	})
+
+	t.Run("refs write audit attributes", func(t *testing.T) {
+		order := &Order{ID: "O1", Products: []Product{{ID: "P1"}}}
+		batches, err := table.MarshalBatch(order, audit)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		for _, request := range batches[0].RequestItems["test-table"] {
+			if StringValue(request.PutRequest.Item[AttributeNameActor]) != "user-1" {
+				t.Errorf("Expected the actor on every item, got %v", request.PutRequest.Item)
+			}
+		}
+	})
+
+	t.Run("updates set audit attributes", func(t *testing.T) {
+		input, err := table.MarshalUpdate(&Product{ID: "P1"}, Increment("stock", 1), audit)
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		var found int
+		for _, name := range input.ExpressionAttributeNames {
+			if name == AttributeNameActor || name == AttributeNameRequestID {
+				found++
+			}
+		}
+		if found != 2 {
+			t.Errorf("Expected the actor and request id to be set, got %v", input.ExpressionAttributeNames)
+		}
+	})
+
+	t.Run("filters by actor", func(t *testing.T) {
+		expr, err := expression.NewBuilder().WithFilter(WrittenBy("user-1")).Build()
+		if err != nil {
+			t.Fatalf("Failed to build expression: %v", err)
+		}
+		if expr.Names()["#0"] != AttributeNameActor || StringValue(expr.Values()[":0"]) != "user-1" {
+			t.Errorf("Expected an actor filter, got %v %v", expr.Names(), expr.Values())
+		}
+	})
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	ctx := WithAudit(context.Background(), Audit{ActorID: "user-1", Reason: "ticket 42"})
+
+	newTable := func() (*Table, *[]any) {
+		var inputs []any
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{
+			NewAuditInterceptor(table),
+			InterceptorFuncs{BeforeFunc: func(ctx context.Context, req *Request) error {
+				inputs = append(inputs, req.Input)
+				return nil
+			}},
+		}
+		return table, &inputs
+	}
+
+	t.Run("puts", func(t *testing.T) {
+		table, _ := newTable()
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		item := client.items["product#P1#product#P1"]
+		if StringValue(item[AttributeNameActor]) != "user-1" || StringValue(item[AttributeNameReason]) != "ticket 42" {
+			t.Errorf("Expected the context audit, got %v", item)
+		}
+	})
+
+	t.Run("options take precedence", func(t *testing.T) {
+		table, _ := newTable()
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, &Product{ID: "P1"}, func(mo *MarshalOptions) { mo.ActorID = "system" }); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if actor := StringValue(client.items["product#P1#product#P1"][AttributeNameActor]); actor != "system" {
+			t.Errorf("Expected the option actor, got %q", actor)
+		}
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		table, _ := newTable()
+		client := newMockDynamoDBClient()
+		if err := table.Attach(ctx, client, &Order{ID: "O1"}, "products", SliceOf(&Product{ID: "P1"})); err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		for key, item := range client.items {
+			if StringValue(item[AttributeNameActor]) != "user-1" {
+				t.Errorf("Expected the context audit on %s, got %v", key, item)
+			}
+		}
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		table, inputs := newTable()
+		if _, err := table.Update(ctx, newMockDynamoDBClient(), &Product{ID: "P1"}, Increment("stock", 1)); err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+		input := (*inputs)[0].(*dynamodb.UpdateItemInput)
+		if !strings.Contains(aws.ToString(input.UpdateExpression), "SET #dynamap_actor_id = :dynamap_actor_id, #dynamap_reason = :dynamap_reason, ") {
+			t.Errorf("Expected the audit to be set, got %q", aws.ToString(input.UpdateExpression))
+		}
+	})
+
+	t.Run("without audit", func(t *testing.T) {
+		table, inputs := newTable()
+		if err := table.Put(context.Background(), newMockDynamoDBClient(), &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if _, ok := (*inputs)[0].(*dynamodb.PutItemInput).Item[AttributeNameActor]; ok {
+			t.Error("Expected no actor attribute")
+		}
+	})
+}
+
+func TestAuditUpdateExpression(t *testing.T) {
+	attributes := Item{AttributeNameActor: &types.AttributeValueMemberS{Value: "user-1"}}
+
+	tests := []struct {
+		name     string
+		update   string
+		names    map[string]string
+		expected string
+	}{
+		{"set clause", "SET #0 = :0", map[string]string{"#0": "stock"}, "SET #dynamap_actor_id = :dynamap_actor_id, #0 = :0"},
+		{"later set clause", "ADD #0 :0\nSET #1 = :1", map[string]string{"#0": "stock", "#1": "name"}, "ADD #0 :0\nSET #dynamap_actor_id = :dynamap_actor_id, #1 = :1"},
+		{"no set clause", "REMOVE #0", map[string]string{"#0": "stock"}, "SET #dynamap_actor_id = :dynamap_actor_id REMOVE #0"},
+		{"already set", "SET #0 = :0", map[string]string{"#0": AttributeNameActor}, "SET #0 = :0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, names, _ := auditUpdateExpression(aws.String(tt.update), tt.names, nil, attributes)
+			if aws.ToString(update) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, aws.ToString(update))
+			}
+			if tt.name != "already set" && names["#dynamap_actor_id"] != AttributeNameActor {
+				t.Errorf("Expected the actor name placeholder, got %v", names)
+			}
+			if _, ok := tt.names["#dynamap_actor_id"]; ok {
+				t.Error("Expected the names not to be modified")
+			}
+		})
+	}
+}
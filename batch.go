@@ -0,0 +1,419 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// MaxBatchGetSize is the maximum number of keys allowed in a DynamoDB batch get operation.
+	MaxBatchGetSize = 100
+)
+
+var (
+	// batchRetryDelay is the initial delay before unprocessed batch items are retried.
+	batchRetryDelay = 50 * time.Millisecond
+	// maxBatchAttempts is the maximum number of attempts made for a single batch request.
+	maxBatchAttempts = 8
+)
+
+// MarshalBatchGet marshals the input entities into batch get item requests. The self
+// relationship key of each entity is requested; duplicate keys are requested once. Since
+// there is a limit on how many keys can be contained in a single input, the requests are
+// chunked in sizes of 100 or less.
+func (t *Table) MarshalBatchGet(entities ...Marshaler) ([]*dynamodb.BatchGetItemInput, error) {
+	keys, _, err := t.batchGetKeys(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := t.chunkBatchGet(keys)
+	for _, batch := range batches {
+		t.logMarshal(batch)
+	}
+
+	return batches, nil
+}
+
+// chunkBatchGet splits keys into batch get item requests of 100 keys or less.
+func (t *Table) chunkBatchGet(keys []Item) []*dynamodb.BatchGetItemInput {
+	var batches []*dynamodb.BatchGetItemInput
+
+	for i := 0; i < len(keys); i += MaxBatchGetSize {
+		end := i + MaxBatchGetSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		batch := &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				t.TableName: {Keys: keys[i:end]},
+			},
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// BatchGet loads the self relationship of each entity using batch get item requests,
+// retrying any unprocessed keys, and unmarshals the results back into the provided
+// entities via [UnmarshalSelf].
+//
+// The returned relationships are in the same order as entities. Entities that were not
+// found are left untouched and their corresponding relationship is the zero value.
+func (t *Table) BatchGet(ctx context.Context, client DynamoDBClient, entities ...Marshaler) ([]Relationship, error) {
+	client = t.client(client)
+	keys, indexes, err := t.batchGetKeys(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships := make([]Relationship, len(entities))
+
+	for _, batch := range t.chunkBatchGet(keys) {
+		items, err := batchGetAll(ctx, client, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items[t.TableName] {
+			source, target, err := UnmarshalTableKey(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+			}
+
+			for _, i := range indexes[source+"\x00"+target] {
+				rel, err := UnmarshalSelf(item, entities[i], t.MarshalOptions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unmarshal item %d: %w", i, err)
+				}
+				relationships[i] = rel
+			}
+		}
+	}
+
+	return relationships, nil
+}
+
+// Exists returns true if the self relationship of the entity exists. Only the key of the
+// item is read. If [Table.FilterExpired] is set, expired items do not exist.
+func (t *Table) Exists(ctx context.Context, client DynamoDBClient, in Marshaler, opts ...func(*MarshalOptions)) (bool, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalGet(in, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+	input.ProjectionExpression, input.ExpressionAttributeNames = marshalOpts.existsProjection()
+
+	output, err := client.GetItem(ctx, input)
+	if err != nil {
+		return false, fmt.Errorf("failed to get item: %w", classify(err))
+	}
+
+	return t.exists(output.Item, marshalOpts), nil
+}
+
+// MultiExists returns whether the self relationship of each entity exists, in the same
+// order as entities, using batch get item requests that read only the item keys. If
+// [Table.FilterExpired] is set, expired items do not exist.
+//
+// Example:
+//
+//	found, err := table.MultiExists(ctx, client, &Product{ID: "P1"}, &Product{ID: "P2"})
+//	if !found[1] {
+//		// P2 does not exist
+//	}
+func (t *Table) MultiExists(ctx context.Context, client DynamoDBClient, entities ...Marshaler) ([]bool, error) {
+	client = t.client(client)
+	keys, indexes, err := t.batchGetKeys(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := t.existingKeys(ctx, client, keys, NewMarshalOptions(t.MarshalOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]bool, len(entities))
+	for id, positions := range indexes {
+		if existing[id] {
+			for _, i := range positions {
+				found[i] = true
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// existingKeys reads the given item keys with batch get item requests that project only
+// the keys, and returns the set of the keys found, as "source\x00target". If
+// [Table.FilterExpired] is set, expired items are not found.
+func (t *Table) existingKeys(ctx context.Context, client DynamoDBClient, keys []Item, mo MarshalOptions) (map[string]bool, error) {
+	existing := make(map[string]bool)
+
+	for _, batch := range t.chunkBatchGet(keys) {
+		request := batch.RequestItems[t.TableName]
+		request.ProjectionExpression, request.ExpressionAttributeNames = mo.existsProjection()
+		batch.RequestItems[t.TableName] = request
+
+		items, err := batchGetAll(ctx, client, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items[t.TableName] {
+			if !t.exists(item, mo) {
+				continue
+			}
+
+			source, target, err := UnmarshalTableKey(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+			}
+			existing[source+"\x00"+target] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// existsProjection returns the projection of the attributes read by existence checks: the
+// table key and the expiration.
+func (mo MarshalOptions) existsProjection() (*string, map[string]string) {
+	names := map[string]string{
+		"#hk":      AttributeNameSource,
+		"#sk":      AttributeNameTarget,
+		"#expires": mo.aliasName(AttributeNameExpires),
+	}
+	return aws.String("#hk, #sk, #expires"), names
+}
+
+// exists returns true if item was found and, if the table filters expired items, has not
+// expired.
+func (t *Table) exists(item Item, mo MarshalOptions) bool {
+	if len(item) == 0 {
+		return false
+	}
+	return !t.FilterExpired || !isExpired(item, mo.aliasName(AttributeNameExpires), mo.Tick())
+}
+
+// getSelfItems loads the self relationship items of the entities with the given source
+// keys using batch get requests, keyed by source key. Entities that do not exist are
+// omitted. Chunked items are loaded by querying their partition, and offloaded data is
+// retrieved from the table's blob store.
+func (t *Table) getSelfItems(ctx context.Context, client DynamoDBClient, sources []string) (map[string]Item, error) {
+	var (
+		keys  []Item
+		seen  = make(map[string]bool)
+		items = make(map[string]Item)
+	)
+
+	for _, source := range sources {
+		if !seen[source] {
+			keys = append(keys, Relationship{Source: source, Target: source}.itemKey())
+			seen[source] = true
+		}
+	}
+
+	for _, batch := range t.chunkBatchGet(keys) {
+		responses, err := batchGetAll(ctx, client, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range responses[t.TableName] {
+			source, _, err := UnmarshalTableKey(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+			}
+
+			if _, ok := item[AttributeNameChunks]; ok {
+				partition, err := t.queryPartition(ctx, client, source)
+				if err != nil {
+					return nil, err
+				}
+				if item, err = selfItem(partition); err != nil {
+					return nil, err
+				}
+			} else {
+				hydrated, err := t.HydrateItems(ctx, []Item{item})
+				if err != nil {
+					return nil, err
+				}
+				item = hydrated[0]
+			}
+
+			if item != nil {
+				items[source] = item
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// batchGetKeys returns the unique self keys of entities, along with the positions of
+// each entity grouped by key.
+func (t *Table) batchGetKeys(entities []Marshaler) ([]Item, map[string][]int, error) {
+	var (
+		keys    []Item
+		indexes = make(map[string][]int)
+	)
+
+	for i, entity := range entities {
+		marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+			t.MarshalOptions(mo)
+			mo.SkipRefs = true // Only need self relationship for key
+		})
+
+		if err := entity.MarshalSelf(&marshalOpts); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal self: %w", err)
+		}
+
+		id := marshalOpts.sourceKey() + "\x00" + marshalOpts.targetKey()
+		if _, ok := indexes[id]; !ok {
+			keys = append(keys, marshalOpts.itemKey())
+		}
+		indexes[id] = append(indexes[id], i)
+	}
+
+	return keys, indexes, nil
+}
+
+// batchGetAll executes the batch get request, retrying unprocessed keys with an
+// exponential backoff until all keys are processed.
+func batchGetAll(ctx context.Context, client DynamoDBClient, input *dynamodb.BatchGetItemInput) (map[string][]Item, error) {
+	responses := make(map[string][]Item)
+
+	for attempt := 0; len(input.RequestItems) > 0; attempt++ {
+		if attempt >= maxBatchAttempts {
+			return nil, fmt.Errorf("failed to process batch get after %d attempts", attempt)
+		}
+
+		if err := waitRetry(ctx, attempt); err != nil {
+			return nil, err
+		}
+
+		output, err := batchGetItem(ctx, client, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch get items: %w", classify(err))
+		}
+
+		for table, items := range output.Responses {
+			responses[table] = append(responses[table], items...)
+		}
+
+		input = &dynamodb.BatchGetItemInput{
+			RequestItems: output.UnprocessedKeys,
+		}
+	}
+
+	return responses, nil
+}
+
+// PutItems writes raw items to the table with batch writes of up to 25 items, retrying
+// unprocessed items. Items are written as is, so they should already carry the table's
+// keys and attribute aliases, such as items read from the table or an export.
+func (t *Table) PutItems(ctx context.Context, client DynamoDBClient, items []Item) error {
+	client = t.client(client)
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	return batchWriteAll(ctx, client, t.chunkBatchWrite(requests))
+}
+
+// DeleteItems deletes the items with the keys of items from the table with batch writes of
+// up to 25 items, retrying unprocessed items. Only the hash and sort key attributes of
+// each item are used.
+func (t *Table) DeleteItems(ctx context.Context, client DynamoDBClient, items []Item) error {
+	client = t.client(client)
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		key := Item{
+			AttributeNameSource: item[AttributeNameSource],
+			AttributeNameTarget: item[AttributeNameTarget],
+		}
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+	}
+	return batchWriteAll(ctx, client, t.chunkBatchWrite(requests))
+}
+
+// chunkBatchWrite splits requests into batch write item requests of 25 requests or less.
+func (t *Table) chunkBatchWrite(requests []types.WriteRequest) []*dynamodb.BatchWriteItemInput {
+	var batches []*dynamodb.BatchWriteItemInput
+
+	for i := 0; i < len(requests); i += MaxBatchSize {
+		end := i + MaxBatchSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		batch := &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				t.TableName: requests[i:end],
+			},
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// batchWriteAll executes each of the batch write requests, retrying unprocessed items
+// with an exponential backoff until all items are processed.
+func batchWriteAll(ctx context.Context, client DynamoDBClient, batches []*dynamodb.BatchWriteItemInput) error {
+	for _, input := range batches {
+		for attempt := 0; len(input.RequestItems) > 0; attempt++ {
+			if attempt >= maxBatchAttempts {
+				return fmt.Errorf("failed to process batch write after %d attempts", attempt)
+			}
+
+			if err := waitRetry(ctx, attempt); err != nil {
+				return err
+			}
+
+			output, err := client.BatchWriteItem(ctx, input)
+			if err != nil {
+				return fmt.Errorf("failed to batch write items: %w", classify(err))
+			}
+
+			input = &dynamodb.BatchWriteItemInput{
+				RequestItems: output.UnprocessedItems,
+			}
+		}
+	}
+
+	return nil
+}
+
+// waitRetry blocks for an exponentially increasing delay based on attempt. The first
+// attempt does not wait.
+func waitRetry(ctx context.Context, attempt int) error {
+	if attempt == 0 {
+		return nil
+	}
+
+	delay := batchRetryDelay << (attempt - 1)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
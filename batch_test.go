@@ -0,0 +1,385 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for batch get operations
+
+func TestTableMarshalBatchGet(t *testing.T) {
+	table := NewTable("test-table")
+
+	t.Run("single batch", func(t *testing.T) {
+		batches, err := table.MarshalBatchGet(
+			&Product{ID: "P1"},
+			&Product{ID: "P2"},
+		)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch get: %v", err)
+		}
+
+		if len(batches) != 1 {
+			t.Fatalf("Expected 1 batch, got %d", len(batches))
+		}
+
+		keys := batches[0].RequestItems["test-table"].Keys
+		if len(keys) != 2 {
+			t.Fatalf("Expected 2 keys, got %d", len(keys))
+		}
+
+		hk := keys[0]["hk"].(*types.AttributeValueMemberS).Value
+		if hk != "product#P1" {
+			t.Errorf("Expected hk 'product#P1', got %s", hk)
+		}
+	})
+
+	t.Run("chunks large requests", func(t *testing.T) {
+		var products []*Product
+		for i := 0; i < 250; i++ {
+			products = append(products, &Product{ID: fmt.Sprintf("P%d", i)})
+		}
+
+		batches, err := table.MarshalBatchGet(SliceOf(products...)...)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch get: %v", err)
+		}
+
+		if len(batches) != 3 {
+			t.Fatalf("Expected 3 batches, got %d", len(batches))
+		}
+
+		if n := len(batches[2].RequestItems["test-table"].Keys); n != 50 {
+			t.Errorf("Expected 50 keys in last batch, got %d", n)
+		}
+	})
+
+	t.Run("duplicate keys are requested once", func(t *testing.T) {
+		batches, err := table.MarshalBatchGet(&Product{ID: "P1"}, &Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch get: %v", err)
+		}
+
+		if n := len(batches[0].RequestItems["test-table"].Keys); n != 1 {
+			t.Errorf("Expected 1 key, got %d", n)
+		}
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		_, err := table.MarshalBatchGet(&errorEntity{})
+		if err == nil {
+			t.Error("Expected error from MarshalBatchGet")
+		}
+	})
+}
+
+// unprocessedClient returns the first key of each request as unprocessed
+// a fixed number of times.
+type unprocessedClient struct {
+	*mockDynamoDBClient
+	failures int
+	calls    int
+}
+
+func (c *unprocessedClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.calls++
+	if c.failures == 0 {
+		return c.mockDynamoDBClient.BatchGetItem(ctx, params, optFns...)
+	}
+
+	c.failures--
+	processed := make(map[string]types.KeysAndAttributes)
+	unprocessed := make(map[string]types.KeysAndAttributes)
+	for table, request := range params.RequestItems {
+		unprocessed[table] = types.KeysAndAttributes{Keys: request.Keys[:1]}
+		processed[table] = types.KeysAndAttributes{Keys: request.Keys[1:]}
+	}
+
+	output, err := c.mockDynamoDBClient.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: processed}, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	output.UnprocessedKeys = unprocessed
+	return output, nil
+}
+
+func TestTableBatchGet(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+
+	defer func(delay time.Duration) { batchRetryDelay = delay }(batchRetryDelay)
+	batchRetryDelay = time.Millisecond
+
+	seed := func(t *testing.T, client DynamoDBClient, products ...*Product) {
+		for _, product := range products {
+			putInput, err := table.MarshalPut(product)
+			if err != nil {
+				t.Fatalf("Failed to marshal put: %v", err)
+			}
+			if _, err := client.PutItem(ctx, putInput); err != nil {
+				t.Fatalf("Failed to put item: %v", err)
+			}
+		}
+	}
+
+	t.Run("unmarshals results in order", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		seed(t, client,
+			&Product{ID: "P1", Category: "electronics"},
+			&Product{ID: "P2", Category: "books"},
+		)
+
+		products := []*Product{{ID: "P2"}, {ID: "P3"}, {ID: "P1"}}
+		relationships, err := table.BatchGet(ctx, client, SliceOf(products...)...)
+		if err != nil {
+			t.Fatalf("Failed to batch get: %v", err)
+		}
+
+		if len(relationships) != 3 {
+			t.Fatalf("Expected 3 relationships, got %d", len(relationships))
+		}
+		if products[0].Category != "books" {
+			t.Errorf("Expected category 'books', got %s", products[0].Category)
+		}
+		if products[2].Category != "electronics" {
+			t.Errorf("Expected category 'electronics', got %s", products[2].Category)
+		}
+		if relationships[1].Source != "" {
+			t.Errorf("Expected zero relationship for missing entity, got %s", relationships[1].Source)
+		}
+		if relationships[2].Source != "product#P1" {
+			t.Errorf("Expected source 'product#P1', got %s", relationships[2].Source)
+		}
+	})
+
+	t.Run("retries unprocessed keys", func(t *testing.T) {
+		client := &unprocessedClient{mockDynamoDBClient: newMockDynamoDBClient(), failures: 2}
+		seed(t, client,
+			&Product{ID: "P1", Category: "electronics"},
+			&Product{ID: "P2", Category: "books"},
+		)
+
+		products := []*Product{{ID: "P1"}, {ID: "P2"}}
+		_, err := table.BatchGet(ctx, client, SliceOf(products...)...)
+		if err != nil {
+			t.Fatalf("Failed to batch get: %v", err)
+		}
+
+		if client.calls != 3 {
+			t.Errorf("Expected 3 calls, got %d", client.calls)
+		}
+		if products[0].Category != "electronics" || products[1].Category != "books" {
+			t.Errorf("Expected all products to be loaded, got %+v", products)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		client := &unprocessedClient{mockDynamoDBClient: newMockDynamoDBClient(), failures: maxBatchAttempts}
+		seed(t, client, &Product{ID: "P1"})
+
+		_, err := table.BatchGet(ctx, client, &Product{ID: "P1"})
+		if err == nil {
+			t.Error("Expected error after max attempts")
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		client := &unprocessedClient{mockDynamoDBClient: newMockDynamoDBClient(), failures: 1}
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := table.BatchGet(ctx, client, &Product{ID: "P1"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestExists(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newMockDynamoDBClient()
+
+	if err := table.Put(ctx, client, &Product{ID: "P1", Category: "books"}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+	if err := table.Put(ctx, client, &Product{ID: "P2"}, func(mo *MarshalOptions) {
+		mo.Created = time.Now().Add(-2 * time.Hour)
+		mo.TimeToLive = time.Hour
+	}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+
+	t.Run("single entity", func(t *testing.T) {
+		if found, err := table.Exists(ctx, client, &Product{ID: "P1"}); err != nil || !found {
+			t.Errorf("Expected P1 to exist, got %v, %v", found, err)
+		}
+		if found, err := table.Exists(ctx, client, &Product{ID: "P3"}); err != nil || found {
+			t.Errorf("Expected P3 not to exist, got %v, %v", found, err)
+		}
+	})
+
+	t.Run("many entities", func(t *testing.T) {
+		found, err := table.MultiExists(ctx, client, &Product{ID: "P3"}, &Product{ID: "P1"}, &Product{ID: "P1"}, &Product{ID: "P2"})
+		if err != nil {
+			t.Fatalf("Failed to check existence: %v", err)
+		}
+		if want := []bool{false, true, true, true}; !slices.Equal(found, want) {
+			t.Errorf("Expected %v, got %v", want, found)
+		}
+	})
+
+	t.Run("filter expired", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.FilterExpired = true
+
+		if found, err := table.Exists(ctx, client, &Product{ID: "P2"}); err != nil || found {
+			t.Errorf("Expected expired P2 not to exist, got %v, %v", found, err)
+		}
+		found, err := table.MultiExists(ctx, client, &Product{ID: "P1"}, &Product{ID: "P2"})
+		if err != nil {
+			t.Fatalf("Failed to check existence: %v", err)
+		}
+		if want := []bool{true, false}; !slices.Equal(found, want) {
+			t.Errorf("Expected %v, got %v", want, found)
+		}
+	})
+}
+
+// wishlist requires its saved products to exist, and suggests others that may not.
+type wishlist struct {
+	ID        string    `dynamodbav:"id"`
+	Saved     []Product `dynamodbav:"-"`
+	Suggested []Product `dynamodbav:"-"`
+}
+
+func (w *wishlist) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("wishlist", w.ID)
+	return nil
+}
+
+func (w *wishlist) MarshalRefs(ctx *RelationshipContext) error {
+	for i := range w.Saved {
+		ctx.AddOneWithOptions("saved", &w.Saved[i], func(ro *RefOptions) {
+			ro.RequireTarget = true
+		})
+	}
+	for i := range w.Suggested {
+		ctx.AddOne("suggested", &w.Suggested[i])
+	}
+	return nil
+}
+
+func TestRequireTarget(t *testing.T) {
+	table := NewTable("test-table")
+	list := &wishlist{
+		ID:        "W1",
+		Saved:     []Product{{ID: "P1"}, {ID: "P2"}},
+		Suggested: []Product{{ID: "P3"}},
+	}
+
+	t.Run("transact write", func(t *testing.T) {
+		input, err := table.MarshalTransactWrite(list)
+		if err != nil {
+			t.Fatalf("Failed to marshal transact write: %v", err)
+		}
+		if len(input.TransactItems) != 6 {
+			t.Fatalf("Expected 4 puts and 2 condition checks, got %d actions", len(input.TransactItems))
+		}
+
+		var checked []string
+		for _, action := range input.TransactItems {
+			if action.ConditionCheck == nil {
+				continue
+			}
+			source, target, err := UnmarshalTableKey(action.ConditionCheck.Key)
+			if err != nil || source != target {
+				t.Errorf("Expected the self key of the target, got %s, %s, %v", source, target, err)
+			}
+			if expr := *action.ConditionCheck.ConditionExpression; expr != "attribute_exists (#0)" {
+				t.Errorf("Expected attribute_exists condition, got %s", expr)
+			}
+			checked = append(checked, source)
+		}
+		if want := []string{"product#P1", "product#P2"}; !slices.Equal(checked, want) {
+			t.Errorf("Expected checks of %v, got %v", want, checked)
+		}
+	})
+
+	t.Run("batch write", func(t *testing.T) {
+		batches, err := table.MarshalBatch(list)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		if n := len(batches[0].RequestItems["test-table"]); n != 4 {
+			t.Errorf("Expected 4 put requests, got %d", n)
+		}
+	})
+
+	t.Run("failed check", func(t *testing.T) {
+		input, err := table.MarshalTransactWrite(list)
+		if err != nil {
+			t.Fatalf("Failed to marshal transact write: %v", err)
+		}
+
+		reasons := make([]types.CancellationReason, len(input.TransactItems))
+		reasons[5].Code = aws.String("ConditionalCheckFailed")
+		canceled := &types.TransactionCanceledException{CancellationReasons: reasons}
+
+		if key, ok := failedConditionCheck(input, canceled); !ok || key != "product#P2" {
+			t.Errorf("Expected failed check of product#P2, got %s, %v", key, ok)
+		}
+	})
+}
+
+func TestPutItems(t *testing.T) {
+	table := NewTable("test-table")
+	client := newMockDynamoDBClient()
+
+	var items []Item
+	for i := range 30 {
+		input, err := table.MarshalPut(&Product{ID: fmt.Sprintf("P%d", i)})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		items = append(items, input.Item)
+	}
+
+	if err := table.PutItems(context.Background(), client, items); err != nil {
+		t.Fatalf("Failed to put items: %v", err)
+	}
+	if len(client.items) != 30 {
+		t.Errorf("Expected 30 items, got %d", len(client.items))
+	}
+}
+
+func TestDeleteItems(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newMockDynamoDBClient()
+	for _, id := range []string{"P1", "P2"} {
+		if err := table.Put(ctx, client, &Product{ID: id}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+	}
+
+	input, err := table.MarshalPut(&Product{ID: "P1"})
+	if err != nil {
+		t.Fatalf("Failed to marshal put: %v", err)
+	}
+	if err := table.DeleteItems(ctx, client, []Item{input.Item}); err != nil {
+		t.Fatalf("Failed to delete items: %v", err)
+	}
+	if _, ok := client.items["product#P1#product#P1"]; ok || len(client.items) != 1 {
+		t.Errorf("Expected only P1 to be deleted, got %d items", len(client.items))
+	}
+}
@@ -0,0 +1,123 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// AttributeNameBlob is the attribute that stores the blob store key of data that
+	// was offloaded from the item.
+	AttributeNameBlob = "blob"
+	// DefaultBlobThreshold is the data size in bytes above which data is offloaded to
+	// the table's blob store when no threshold is configured.
+	DefaultBlobThreshold = 350 * 1024
+)
+
+// ErrBlobNotLoaded is returned when an item whose data was offloaded to a blob store is
+// unmarshaled before being hydrated with [Table.HydrateItems].
+var ErrBlobNotLoaded = newError(ErrValidation, "item data is stored in the blob store")
+
+// BlobStore stores entity data that is too large to keep in DynamoDB. The dynamaps3
+// package provides a BlobStore backed by an S3 bucket.
+type BlobStore interface {
+	// PutBlob stores data under key, replacing any existing blob.
+	PutBlob(ctx context.Context, key string, data []byte) error
+	// GetBlob retrieves the data stored under key.
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+	// DeleteBlob removes the data stored under key.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// OffloadItem moves the data attribute of item to the table's blob store if it exceeds
+// the table's BlobThreshold. The returned item records the blob key in place of the data;
+// item is not modified. Items are returned unchanged if the table has no blob store.
+//
+// Blobs are keyed by the item's table key, so re-saving an entity replaces its blob. Blobs
+// are not removed when an item is deleted or shrinks below the threshold.
+func (t *Table) OffloadItem(ctx context.Context, item Item) (Item, error) {
+	data, ok := item[AttributeNameData]
+	if t.BlobStore == nil || !ok {
+		return item, nil
+	}
+
+	payload, err := encodeAttributeValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := t.BlobThreshold
+	if threshold <= 0 {
+		threshold = DefaultBlobThreshold
+	}
+
+	if len(payload) <= threshold {
+		return item, nil
+	}
+
+	key, err := blobKey(item)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.BlobStore.PutBlob(ctx, key, payload); err != nil {
+		return nil, fmt.Errorf("failed to put blob: %w", err)
+	}
+
+	item = maps.Clone(item)
+	delete(item, AttributeNameData)
+	item[AttributeNameBlob] = &types.AttributeValueMemberS{Value: key}
+	return item, nil
+}
+
+// HydrateItems returns a copy of items with offloaded data retrieved from the table's blob
+// store, ready to be unmarshaled. Items without offloaded data are returned as is.
+func (t *Table) HydrateItems(ctx context.Context, items []Item) ([]Item, error) {
+	result := make([]Item, len(items))
+	for i, item := range items {
+		result[i] = item
+
+		attr, ok := item[AttributeNameBlob]
+		if !ok {
+			continue
+		}
+
+		if t.BlobStore == nil {
+			return nil, fmt.Errorf("%w; table has no blob store", ErrBlobNotLoaded)
+		}
+
+		var key string
+		if err := attributevalue.Unmarshal(attr, &key); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal blob key: %w", err)
+		}
+
+		payload, err := t.BlobStore.GetBlob(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blob: %w", err)
+		}
+
+		data, err := decodeAttributeValue(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = maps.Clone(item)
+		result[i][AttributeNameData] = data
+		delete(result[i], AttributeNameBlob)
+	}
+
+	return result, nil
+}
+
+// blobKey returns the blob store key for an item.
+func blobKey(item Item) (string, error) {
+	source, target, err := UnmarshalTableKey(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal table key: %w", err)
+	}
+	return source + "/" + target, nil
+}
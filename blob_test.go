@@ -0,0 +1,109 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// memoryBlobStore is an in-memory BlobStore for testing.
+type memoryBlobStore map[string][]byte
+
+func (m memoryBlobStore) PutBlob(ctx context.Context, key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m memoryBlobStore) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return data, nil
+}
+
+func (m memoryBlobStore) DeleteBlob(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+// Tests for blob storage
+
+func TestTableBlobStore(t *testing.T) {
+	ctx := context.Background()
+	large := &Product{ID: "P1", Category: strings.Repeat("electronics", 20)}
+
+	t.Run("offloads large data", func(t *testing.T) {
+		store := memoryBlobStore{}
+		client := newMockDynamoDBClient()
+		table := NewTable("test-table")
+		table.BlobStore = store
+		table.BlobThreshold = 64
+
+		if err := table.Put(ctx, client, large); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+
+		item := client.items["product#P1#product#P1"]
+		if _, ok := item["data"]; ok {
+			t.Error("Expected data to be offloaded")
+		}
+		if _, ok := store["product#P1/product#P1"]; !ok {
+			t.Fatal("Expected blob for product")
+		}
+
+		var out Product
+		if _, err := UnmarshalSelf(item, &out); !errors.Is(err, ErrBlobNotLoaded) {
+			t.Errorf("Expected ErrBlobNotLoaded, got %v", err)
+		}
+
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &out); err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		if out.Category != large.Category {
+			t.Errorf("Expected hydrated category, got %s", out.Category)
+		}
+	})
+
+	t.Run("keeps small data", func(t *testing.T) {
+		store := memoryBlobStore{}
+		client := newMockDynamoDBClient()
+		table := NewTable("test-table")
+		table.BlobStore = store
+
+		if err := table.Put(ctx, client, large); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if len(store) != 0 {
+			t.Errorf("Expected no blobs, got %d", len(store))
+		}
+		if _, ok := client.items["product#P1#product#P1"]["data"]; !ok {
+			t.Error("Expected data in item")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var out Product
+		_, err := NewTable("test-table").Get(ctx, newMockDynamoDBClient(), &Product{ID: "P1"}, &out)
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("missing blob store", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.BlobStore = memoryBlobStore{}
+		table.BlobThreshold = 64
+
+		input, _ := table.MarshalPut(large)
+		item, err := table.OffloadItem(ctx, input.Item)
+		if err != nil {
+			t.Fatalf("Failed to offload: %v", err)
+		}
+
+		if _, err := NewTable("test-table").HydrateItems(ctx, []Item{item}); !errors.Is(err, ErrBlobNotLoaded) {
+			t.Errorf("Expected ErrBlobNotLoaded, got %v", err)
+		}
+	})
+}
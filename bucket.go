@@ -0,0 +1,182 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// BucketPeriod is the span of time covered by each bucket of a [TimeBucketedLabel]. Its
+// value is the time layout used to format the bucket suffix.
+type BucketPeriod string
+
+const (
+	BucketHourly  BucketPeriod = "2006-01-02T15" // One bucket per hour, such as "order/2025-06-01T13"
+	BucketDaily   BucketPeriod = "2006-01-02"    // One bucket per day, such as "order/2025-06-01"
+	BucketMonthly BucketPeriod = "2006-01"       // One bucket per month, such as "order/2025-06"
+	BucketYearly  BucketPeriod = "2006"          // One bucket per year, such as "order/2025"
+)
+
+// TimeBucketedLabel spreads the self relationships of a high-volume entity type across
+// several labels on the ref index, one per period of the creation time, so that no single
+// label becomes a hot partition. Configure it per entity label with [Table.LabelBuckets]
+// and query a date range of buckets with [Table.QueryBuckets].
+//
+// Example:
+//
+//	table.LabelBuckets = dynamap.LabelBuckets{
+//		"order": {Period: dynamap.BucketMonthly},
+//	}
+type TimeBucketedLabel struct {
+	Period BucketPeriod // The span of each bucket. Default is BucketMonthly.
+}
+
+// layout returns the time layout of the bucket suffix.
+func (b TimeBucketedLabel) layout() string {
+	if b.Period == "" {
+		return string(BucketMonthly)
+	}
+	return string(b.Period)
+}
+
+// truncate returns the start of the bucket containing t.
+func (b TimeBucketedLabel) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch BucketPeriod(b.layout()) {
+	case BucketHourly:
+		return t.Truncate(time.Hour)
+	case BucketDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case BucketYearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the bucket following the bucket starting at t.
+func (b TimeBucketedLabel) next(t time.Time) time.Time {
+	switch BucketPeriod(b.layout()) {
+	case BucketHourly:
+		return t.Add(time.Hour)
+	case BucketDaily:
+		return t.AddDate(0, 0, 1)
+	case BucketYearly:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 1, 0)
+	}
+}
+
+// Bucket returns the suffix of the bucket containing t, such as "2025-06".
+func (b TimeBucketedLabel) Bucket(t time.Time) string {
+	return t.UTC().Format(b.layout())
+}
+
+// Buckets returns the suffixes of the buckets overlapping the range from start to end,
+// inclusive, in chronological order.
+func (b TimeBucketedLabel) Buckets(start, end time.Time) []string {
+	var buckets []string
+	for t := b.truncate(start); !t.After(end.UTC()); t = b.next(t) {
+		buckets = append(buckets, b.Bucket(t))
+	}
+	return buckets
+}
+
+// LabelBuckets maps self relationship labels to their time bucketing strategy.
+type LabelBuckets map[string]TimeBucketedLabel
+
+// bucketLabel returns the label of the bucket of the self relationship label containing t,
+// or the label unchanged if it is not bucketed.
+func (mo MarshalOptions) bucketLabel(label string, t time.Time) string {
+	bucket, ok := mo.LabelBuckets[label]
+	if !ok {
+		return label
+	}
+	return label + mo.LabelDelimiter + bucket.Bucket(t)
+}
+
+// selfLabel returns the label written to the self relationship of the source entity
+// created at t.
+func (mo MarshalOptions) selfLabel(label, source string, t time.Time) string {
+	return mo.tenantLabel(mo.shardLabel(label, mo.bucketLabel(label, t), source))
+}
+
+// bucketStartKey is the attribute of a [Table.QueryBuckets] start key that holds the
+// label of the bucket to resume from.
+const bucketStartKey = "#bucket"
+
+// QueryBuckets executes the query against the buckets of the time bucketed label q.Label
+// overlapping the range from start to end, one bucket at a time in chronological order, or
+// reverse chronological order if q.SortDescending is set. The results of each bucket are
+// in ref sort key order. If the label is also sharded, the shards of each bucket are
+// queried concurrently and merged like [Table.QueryShards]. Buckets are read until q.Limit
+// items are collected, and each bucket or shard reads at most the number of items still
+// needed. If the label is not bucketed, the query is executed against the label as-is.
+// Unmarshal the results with [UnmarshalList].
+//
+// The returned start key holds the bucket to resume from and the position of its shards,
+// and is nil once every bucket is exhausted. Pass it as q.StartKey, with the same range,
+// to fetch the next page.
+//
+// Example:
+//
+//	query := &dynamap.QueryList{Label: "order", Limit: 50}
+//	items, startKey, err := table.QueryBuckets(ctx, client, query, start, end)
+func (t *Table) QueryBuckets(ctx context.Context, client DynamoDBClient, q *QueryList, start, end time.Time, opts ...func(*MarshalOptions)) ([]Item, Item, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	buckets := []string{q.Label}
+	if bucket, ok := marshalOpts.LabelBuckets[q.Label]; ok {
+		buckets = nil
+		for _, suffix := range bucket.Buckets(start, end) {
+			buckets = append(buckets, q.Label+marshalOpts.LabelDelimiter+suffix)
+		}
+	}
+	if q.SortDescending {
+		slices.Reverse(buckets)
+	}
+
+	first := 0
+	if position, ok := q.StartKey[bucketStartKey].(*types.AttributeValueMemberS); ok {
+		if first = slices.Index(buckets, position.Value); first < 0 {
+			return nil, nil, fmt.Errorf("%w: bucket %s is not in the range", ErrCursorMismatch, position.Value)
+		}
+	}
+
+	var items []Item
+	for i := first; i < len(buckets); i++ {
+		bucketQuery := *q
+		bucketQuery.StartKey = nil
+		if i == first {
+			bucketQuery.StartKey = q.StartKey
+		}
+		if q.Limit > 0 {
+			bucketQuery.Limit = q.Limit - len(items)
+		}
+
+		page, startKey, err := t.queryMerged(ctx, client, &bucketQuery, marshalOpts.shardLabels(q.Label, buckets[i]), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, page...)
+
+		switch {
+		case startKey != nil:
+			startKey[bucketStartKey] = &types.AttributeValueMemberS{Value: buckets[i]}
+			return items, startKey, nil
+		case q.Limit > 0 && len(items) >= q.Limit && i+1 < len(buckets):
+			return items, Item{bucketStartKey: &types.AttributeValueMemberS{Value: buckets[i+1]}}, nil
+		}
+	}
+
+	return items, nil, nil
+}
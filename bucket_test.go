@@ -0,0 +1,217 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// labelClient answers ref index queries by label, which may be executed concurrently.
+type labelClient struct {
+	*mockDynamoDBClient
+	mu      sync.Mutex
+	queried []string
+}
+
+func (c *labelClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	label := params.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value
+	c.queried = append(c.queried, label)
+
+	var items []Item
+	for _, item := range c.items {
+		if l, _ := item["label"].(*types.AttributeValueMemberS); l != nil && l.Value == label {
+			items = append(items, item)
+		}
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+type shipment struct {
+	ID      string    `dynamodbav:"id"`
+	Created time.Time `dynamodbav:"-"`
+}
+
+func (s *shipment) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("shipment", s.ID)
+	opts.Created = s.Created
+	opts.RefSortKey = s.Created.Format(time.RFC3339)
+	return nil
+}
+
+// Tests for time bucketed labels
+
+func TestTimeBucketedLabelBuckets(t *testing.T) {
+	start := time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period BucketPeriod
+		want   []string
+	}{
+		{BucketMonthly, []string{"2024-11", "2024-12", "2025-01", "2025-02"}},
+		{BucketYearly, []string{"2024", "2025"}},
+		{"", []string{"2024-11", "2024-12", "2025-01", "2025-02"}},
+	}
+
+	for _, tt := range tests {
+		got := TimeBucketedLabel{Period: tt.period}.Buckets(start, end)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Buckets(%q) = %v, want %v", tt.period, got, tt.want)
+		}
+	}
+
+	daily := TimeBucketedLabel{Period: BucketDaily}.Buckets(start, start.Add(36*time.Hour))
+	if !slices.Equal(daily, []string{"2024-11-15", "2024-11-16"}) {
+		t.Errorf("Unexpected daily buckets: %v", daily)
+	}
+}
+
+func TestQueryBuckets(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.LabelBuckets = LabelBuckets{"shipment": {Period: BucketMonthly}}
+	client := &labelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	shipments := []*shipment{
+		{ID: "S3", Created: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "S1", Created: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{ID: "S2", Created: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: "S0", Created: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, s := range shipments {
+		if err := table.Put(ctx, client, s); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+	}
+
+	t.Run("bucketed label", func(t *testing.T) {
+		item := client.items["shipment#S2#shipment#S2"]
+		if label := item["label"].(*types.AttributeValueMemberS).Value; label != "shipment/2025-02" {
+			t.Errorf("Expected label 'shipment/2025-02', got %q", label)
+		}
+	})
+
+	ids := func(t *testing.T, items []Item) []string {
+		var out []shipment
+		if _, err := UnmarshalList(items, &out); err != nil {
+			t.Fatalf("Failed to unmarshal list: %v", err)
+		}
+		var ids []string
+		for _, s := range out {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("fan out", func(t *testing.T) {
+		client.queried = nil
+		items, startKey, err := table.QueryBuckets(ctx, client, &QueryList{Label: "shipment"}, start, end)
+		if err != nil {
+			t.Fatalf("Failed to query buckets: %v", err)
+		}
+		if startKey != nil {
+			t.Errorf("Expected no start key, got %v", startKey)
+		}
+
+		want := []string{"shipment/2025-01", "shipment/2025-02", "shipment/2025-03"}
+		if !slices.Equal(client.queried, want) {
+			t.Errorf("Expected queried buckets %v, got %v", want, client.queried)
+		}
+		if got := ids(t, items); !slices.Equal(got, []string{"S1", "S2", "S3"}) {
+			t.Errorf("Expected shipments in order, got %v", got)
+		}
+	})
+
+	t.Run("descending with limit", func(t *testing.T) {
+		client.queried = nil
+		items, startKey, err := table.QueryBuckets(ctx, client, &QueryList{Label: "shipment", SortDescending: true, Limit: 2}, start, end)
+		if err != nil {
+			t.Fatalf("Failed to query buckets: %v", err)
+		}
+		if got := ids(t, items); !slices.Equal(got, []string{"S3", "S2"}) {
+			t.Errorf("Expected latest shipments, got %v", got)
+		}
+		if want := []string{"shipment/2025-03", "shipment/2025-02"}; !slices.Equal(client.queried, want) {
+			t.Errorf("Expected queried buckets %v, got %v", want, client.queried)
+		}
+
+		// The next page resumes from the first bucket that was not read
+		items, startKey, err = table.QueryBuckets(ctx, client, &QueryList{Label: "shipment", SortDescending: true, Limit: 2, StartKey: startKey}, start, end)
+		if err != nil {
+			t.Fatalf("Failed to query buckets: %v", err)
+		}
+		if got := ids(t, items); !slices.Equal(got, []string{"S1"}) || startKey != nil {
+			t.Errorf("Expected the oldest shipment and no start key, got %v and %v", got, startKey)
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		paged := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		daily := NewTable("test-table")
+		daily.LabelBuckets = LabelBuckets{"shipment": {Period: BucketDaily}}
+		for _, s := range []*shipment{{ID: "D0", Created: start}, {ID: "D1", Created: start.Add(time.Hour)}, {ID: "D2", Created: start.Add(48 * time.Hour)}} {
+			if err := daily.Put(ctx, paged, s); err != nil {
+				t.Fatalf("Failed to put shipment: %v", err)
+			}
+		}
+
+		var (
+			got   []string
+			query = &QueryList{Label: "shipment", Limit: 2}
+		)
+		for page := 0; ; page++ {
+			if page > 10 {
+				t.Fatal("Expected the buckets to be exhausted")
+			}
+
+			paged.read = 0
+			items, startKey, err := daily.QueryBuckets(ctx, paged, query, start, start.AddDate(1, 0, 0))
+			if err != nil {
+				t.Fatalf("Failed to query buckets: %v", err)
+			}
+			if paged.read > 2 {
+				t.Errorf("Expected at most 2 items read per page, got %d", paged.read)
+			}
+			got = append(got, ids(t, items)...)
+
+			if startKey == nil {
+				break
+			}
+			query.StartKey = startKey
+		}
+
+		if want := []string{"D0", "D1", "D2"}; !slices.Equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+
+		if _, _, err := daily.QueryBuckets(ctx, paged, &QueryList{Label: "shipment", StartKey: Item{bucketStartKey: &types.AttributeValueMemberS{Value: "shipment/2020-01-01"}}}, start, start); !errors.Is(err, ErrCursorMismatch) {
+			t.Errorf("Expected ErrCursorMismatch, got %v", err)
+		}
+	})
+
+	t.Run("registry accepts bucketed label", func(t *testing.T) {
+		table.Registry = NewRegistry(EntitySchema{Prefix: "shipment"})
+		defer func() { table.Registry = nil }()
+
+		if err := table.Put(ctx, client, shipments[0]); err != nil {
+			t.Errorf("Expected bucketed label to validate, got %v", err)
+		}
+
+		table.Registry = NewRegistry(EntitySchema{Prefix: "shipment", Labels: []string{"parcel"}})
+		if err := table.Put(ctx, client, shipments[0]); !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("Expected ErrInvalidLabel, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,455 @@
+package dynamap
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DefaultCacheTTL is the default time cached reads are kept by a [CacheInterceptor].
+const DefaultCacheTTL = 5 * time.Minute
+
+// CacheKeyPrefix prefixes the keys written by a [CacheInterceptor], namespacing them in
+// shared caches.
+const CacheKeyPrefix = "dynamap:"
+
+// Cache stores encoded values by key. It is implemented by [LRUCache], and by remote
+// caches such as Redis using [CacheFuncs].
+type Cache interface {
+	// Get returns the value stored at key, and false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value at key for the ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheFuncs adapts functions to a [Cache], such as the commands of a Redis client.
+//
+// Example:
+//
+//	cache := dynamap.CacheFuncs{
+//		GetFunc: func(ctx context.Context, key string) ([]byte, bool, error) {
+//			value, err := rdb.Get(ctx, key).Bytes()
+//			if errors.Is(err, redis.Nil) {
+//				return nil, false, nil
+//			}
+//			return value, err == nil, err
+//		},
+//		SetFunc: func(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//			return rdb.Set(ctx, key, value, ttl).Err()
+//		},
+//	}
+type CacheFuncs struct {
+	GetFunc func(ctx context.Context, key string) ([]byte, bool, error)
+	SetFunc func(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Get calls GetFunc.
+func (f CacheFuncs) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return f.GetFunc(ctx, key)
+}
+
+// Set calls SetFunc.
+func (f CacheFuncs) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return f.SetFunc(ctx, key, value, ttl)
+}
+
+// LRUCache is an in-memory [Cache] that evicts the least recently used values once it
+// holds Capacity values. It is safe for concurrent use.
+type LRUCache struct {
+	Capacity int   // Maximum number of values held
+	Clock    Clock // Clock used to expire values. Default is DefaultClock.
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// lruEntry is a value held by an [LRUCache].
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache creates a new [LRUCache] holding at most capacity values.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{Capacity: capacity, Clock: DefaultClock}
+}
+
+// now returns the current time of the cache clock.
+func (c *LRUCache) now() time.Time {
+	if c.Clock == nil {
+		return DefaultClock()
+	}
+	return c.Clock()
+}
+
+// Get implements [Cache].
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set implements [Cache]. A zero ttl keeps the value until it is evicted.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.order = list.New()
+	}
+
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.Capacity > 0 && c.order.Len() > c.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	return nil
+}
+
+// CacheInterceptor is an [Interceptor] that caches get item requests and entity queries,
+// such as those of [Table.Get] and [Table.LoadEntity], by entity key. Any write to an
+// entity partition, including batch and transact writes, invalidates the cached reads
+// of that entity. Consistent reads and index queries bypass the cache.
+//
+// Invalidation is scoped to the table's own writes: reads are not invalidated by writes
+// from other processes until their TTL expires, unless the processes share the cache.
+// Cache read failures fall back to DynamoDB, while invalidation failures are returned
+// with the result of the write.
+//
+// Example:
+//
+//	table.Interceptors = append(table.Interceptors, dynamap.NewCacheInterceptor(dynamap.NewLRUCache(10000), time.Minute))
+type CacheInterceptor struct {
+	Cache Cache         // The cache holding reads
+	TTL   time.Duration // Time reads are cached. Default is DefaultCacheTTL.
+
+	pending sync.Map // Cache keys of the reads that missed, by request
+}
+
+// NewCacheInterceptor creates a new [CacheInterceptor] caching reads for ttl.
+func NewCacheInterceptor(cache Cache, ttl time.Duration) *CacheInterceptor {
+	return &CacheInterceptor{Cache: cache, TTL: ttl}
+}
+
+// cachedOutput is the encoded output of a cached read.
+type cachedOutput struct {
+	Items   []Item
+	LastKey Item
+}
+
+// Before implements [Interceptor] by answering reads from the cache.
+func (c *CacheInterceptor) Before(ctx context.Context, req *Request) error {
+	key, ok := c.readKey(ctx, req.Input)
+	if !ok {
+		return nil
+	}
+
+	value, found, err := c.Cache.Get(ctx, key)
+	if err != nil || !found {
+		req.Cache = CacheMiss
+		c.pending.Store(req, key)
+		return nil
+	}
+
+	var cached cachedOutput
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&cached); err != nil {
+		req.Cache = CacheMiss
+		c.pending.Store(req, key)
+		return nil
+	}
+
+	switch req.Operation {
+	case OperationGet:
+		output := &dynamodb.GetItemOutput{}
+		if len(cached.Items) > 0 {
+			output.Item = cached.Items[0]
+		}
+		req.Output = output
+	case OperationQuery:
+		req.Output = &dynamodb.QueryOutput{
+			Items:            cached.Items,
+			Count:            int32(len(cached.Items)),
+			LastEvaluatedKey: cached.LastKey,
+		}
+	}
+
+	req.Cache = CacheHit
+	return nil
+}
+
+// After implements [Interceptor] by caching the reads that missed, and invalidating the
+// cached reads of written entities.
+func (c *CacheInterceptor) After(ctx context.Context, req *Request) {
+	if key, ok := c.pending.LoadAndDelete(req); ok {
+		if req.Err == nil {
+			c.store(ctx, key.(string), req.Output)
+		}
+		return
+	}
+
+	for _, partition := range writtenPartitions(req.Input) {
+		if err := c.invalidate(ctx, partition.table, partition.key); err != nil {
+			req.Err = errors.Join(req.Err, fmt.Errorf("failed to invalidate cache: %w", err))
+			return
+		}
+	}
+}
+
+// ttl returns the time reads are cached.
+func (c *CacheInterceptor) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultCacheTTL
+	}
+	return c.TTL
+}
+
+// store caches the output of a read.
+func (c *CacheInterceptor) store(ctx context.Context, key string, output any) {
+	var cached cachedOutput
+	switch out := output.(type) {
+	case *dynamodb.GetItemOutput:
+		if out.Item != nil {
+			cached.Items = []Item{out.Item}
+		}
+	case *dynamodb.QueryOutput:
+		cached.Items, cached.LastKey = out.Items, out.LastEvaluatedKey
+	default:
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cached); err == nil {
+		_ = c.Cache.Set(ctx, key, buf.Bytes(), c.ttl())
+	}
+}
+
+// readKey returns the cache key of a cacheable read: the entity partition, its current
+// generation and a fingerprint of the request.
+func (c *CacheInterceptor) readKey(ctx context.Context, input any) (string, bool) {
+	var table, partition string
+	switch in := input.(type) {
+	case *dynamodb.GetItemInput:
+		if aws.ToBool(in.ConsistentRead) {
+			return "", false
+		}
+		table, partition = aws.ToString(in.TableName), StringValue(in.Key[AttributeNameSource])
+	case *dynamodb.QueryInput:
+		if in.IndexName != nil || aws.ToBool(in.ConsistentRead) {
+			return "", false
+		}
+		table, partition = aws.ToString(in.TableName), queryPartitionKey(in)
+	default:
+		return "", false
+	}
+
+	if partition == "" {
+		return "", false
+	}
+
+	fingerprint, err := cacheFingerprint(input)
+	if err != nil {
+		return "", false
+	}
+
+	generation, err := c.generation(ctx, table, partition)
+	if err != nil {
+		return "", false
+	}
+
+	return CacheKeyPrefix + table + "/" + partition + "/" + generation + "/" + fingerprint, true
+}
+
+// generationKey returns the cache key holding the generation of an entity partition.
+func generationKey(table, partition string) string {
+	return CacheKeyPrefix + table + "/" + partition
+}
+
+// generation returns the current generation of an entity partition, starting a new one
+// if it has none. Cached reads are keyed by generation, so that starting a new
+// generation invalidates them all; a fresh generation is never reused, even if the
+// previous one was evicted.
+func (c *CacheInterceptor) generation(ctx context.Context, table, partition string) (string, error) {
+	value, found, err := c.Cache.Get(ctx, generationKey(table, partition))
+	if err != nil {
+		return "", err
+	} else if found {
+		return string(value), nil
+	}
+
+	return c.newGeneration(ctx, table, partition)
+}
+
+// invalidate starts a new generation of an entity partition.
+func (c *CacheInterceptor) invalidate(ctx context.Context, table, partition string) error {
+	_, err := c.newGeneration(ctx, table, partition)
+	return err
+}
+
+// newGeneration stores a new random generation for an entity partition.
+func (c *CacheInterceptor) newGeneration(ctx context.Context, table, partition string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	generation := hex.EncodeToString(b)
+	if err := c.Cache.Set(ctx, generationKey(table, partition), []byte(generation), c.ttl()); err != nil {
+		return "", err
+	}
+
+	return generation, nil
+}
+
+// cacheFingerprint returns a digest of the request. Attribute values are decoded first,
+// since maps of attribute values have no stable encoding.
+func cacheFingerprint(input any) (string, error) {
+	var fields any
+	switch in := input.(type) {
+	case *dynamodb.GetItemInput:
+		key, err := decodeAttributes(in.Key)
+		if err != nil {
+			return "", err
+		}
+		fields = []any{key, in.ProjectionExpression, in.ExpressionAttributeNames}
+	case *dynamodb.QueryInput:
+		values, err := decodeAttributes(in.ExpressionAttributeValues)
+		if err != nil {
+			return "", err
+		}
+		start, err := decodeAttributes(in.ExclusiveStartKey)
+		if err != nil {
+			return "", err
+		}
+		fields = []any{
+			in.KeyConditionExpression, in.FilterExpression, in.ProjectionExpression,
+			in.ExpressionAttributeNames, values, start, in.Limit, in.ScanIndexForward, in.Select,
+		}
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// decodeAttributes decodes attribute values into their Go values.
+func decodeAttributes(item Item) (map[string]any, error) {
+	if item == nil {
+		return nil, nil
+	}
+	var out map[string]any
+	err := attributevalue.UnmarshalMap(item, &out)
+	return out, err
+}
+
+// keyEquality matches equality conditions in key condition expressions.
+var keyEquality = regexp.MustCompile(`(#\w+) = (:\w+)`)
+
+// queryPartitionKey returns the hash key value of a query on the table, or an empty
+// string if it cannot be determined.
+func queryPartitionKey(in *dynamodb.QueryInput) string {
+	for _, match := range keyEquality.FindAllStringSubmatch(aws.ToString(in.KeyConditionExpression), -1) {
+		if in.ExpressionAttributeNames[match[1]] == AttributeNameSource {
+			return StringValue(in.ExpressionAttributeValues[match[2]])
+		}
+	}
+	return ""
+}
+
+// cachePartition is an entity partition written by a request.
+type cachePartition struct {
+	table string
+	key   string
+}
+
+// writtenPartitions returns the entity partitions written by a write request.
+func writtenPartitions(input any) []cachePartition {
+	var partitions []cachePartition
+	add := func(table *string, item Item) {
+		partition := cachePartition{table: aws.ToString(table), key: StringValue(item[AttributeNameSource])}
+		if partition.key != "" && !slices.Contains(partitions, partition) {
+			partitions = append(partitions, partition)
+		}
+	}
+
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		add(in.TableName, in.Item)
+	case *dynamodb.UpdateItemInput:
+		add(in.TableName, in.Key)
+	case *dynamodb.DeleteItemInput:
+		add(in.TableName, in.Key)
+	case *dynamodb.BatchWriteItemInput:
+		for table, requests := range in.RequestItems {
+			for _, request := range requests {
+				if request.PutRequest != nil {
+					add(aws.String(table), request.PutRequest.Item)
+				} else if request.DeleteRequest != nil {
+					add(aws.String(table), request.DeleteRequest.Key)
+				}
+			}
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		for _, action := range in.TransactItems {
+			switch {
+			case action.Put != nil:
+				add(action.Put.TableName, action.Put.Item)
+			case action.Update != nil:
+				add(action.Update.TableName, action.Update.Key)
+			case action.Delete != nil:
+				add(action.Delete.TableName, action.Delete.Key)
+			}
+		}
+	}
+
+	return partitions
+}
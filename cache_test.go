@@ -0,0 +1,153 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// countingClient counts the get item requests it answers.
+type countingClient struct {
+	*partitionClient
+	gets int
+}
+
+func (c *countingClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.gets++
+	return c.partitionClient.GetItem(ctx, params, optFns...)
+}
+
+// Tests for the cache layer
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewLRUCache(2)
+	cache.Clock = func() time.Time { return now }
+
+	_ = cache.Set(ctx, "a", []byte("1"), 0)
+	_ = cache.Set(ctx, "b", []byte("2"), time.Minute)
+	if _, found, _ := cache.Get(ctx, "a"); !found {
+		t.Fatal("Expected a to be cached")
+	}
+
+	// b is the least recently used value
+	_ = cache.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, found, _ := cache.Get(ctx, "b"); found {
+		t.Error("Expected b to be evicted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, found, _ := cache.Get(ctx, "c"); found {
+		t.Error("Expected c to expire")
+	}
+	if value, found, _ := cache.Get(ctx, "a"); !found || string(value) != "1" {
+		t.Errorf("Expected a to be kept, got %q", value)
+	}
+}
+
+func TestCacheInterceptor(t *testing.T) {
+	ctx := context.Background()
+
+	newTable := func() (*Table, *countingClient) {
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{NewCacheInterceptor(NewLRUCache(100), time.Minute)}
+		return table, &countingClient{partitionClient: newPartitionClient()}
+	}
+
+	t.Run("get", func(t *testing.T) {
+		table, client := newTable()
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "widgets"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		for range 3 {
+			var got Product
+			if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &got); err != nil {
+				t.Fatalf("Failed to get product: %v", err)
+			}
+			if got.Category != "widgets" {
+				t.Errorf("Expected product, got %+v", got)
+			}
+		}
+		if client.gets != 1 {
+			t.Errorf("Expected 1 get from DynamoDB, got %d", client.gets)
+		}
+
+		// Consistent reads bypass the cache
+		input, _ := table.MarshalGet(&Product{ID: "P1"})
+		input.ConsistentRead = aws.Bool(true)
+		if _, err := table.client(client).GetItem(ctx, input); err != nil {
+			t.Fatalf("Failed to get item: %v", err)
+		}
+		if client.gets != 2 {
+			t.Errorf("Expected the consistent read to bypass the cache, got %d gets", client.gets)
+		}
+	})
+
+	t.Run("write invalidates", func(t *testing.T) {
+		table, client := newTable()
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "widgets"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		var got Product
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &got); err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "gadgets"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &got); err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if got.Category != "gadgets" {
+			t.Errorf("Expected updated product, got %+v", got)
+		}
+		if client.gets != 2 {
+			t.Errorf("Expected 2 gets from DynamoDB, got %d", client.gets)
+		}
+	})
+
+	t.Run("entity query", func(t *testing.T) {
+		table, client := newTable()
+		order := &Order{ID: "O1", Products: []Product{{ID: "P1"}}}
+		batches, err := table.MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal order: %v", err)
+		}
+		for _, batch := range batches {
+			if _, err := table.client(client).BatchWriteItem(ctx, batch); err != nil {
+				t.Fatalf("Failed to write order: %v", err)
+			}
+		}
+
+		load := func() {
+			var got Order
+			if _, err := table.LoadEntity(ctx, client, &QueryEntity{Source: &Order{ID: "O1"}}, &got); err != nil {
+				t.Fatalf("Failed to load order: %v", err)
+			}
+			if len(got.Products) != 1 {
+				t.Errorf("Expected 1 product, got %+v", got)
+			}
+		}
+
+		load()
+		load()
+		if len(client.queried) != 1 {
+			t.Errorf("Expected 1 query, got %v", client.queried)
+		}
+
+		// Writing to the partition invalidates the cached query
+		if err := table.Put(ctx, client, &Order{ID: "O1"}); err != nil {
+			t.Fatalf("Failed to put order: %v", err)
+		}
+		load()
+		if len(client.queried) != 2 {
+			t.Errorf("Expected 2 queries, got %v", client.queried)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+// Package cdc provides change data capture for dynamap tables.
+//
+// A [Processor] consumes batches of DynamoDB stream records, groups them by source entity,
+// coalesces repeated changes to the same item, and reports entity-level changes to
+// handlers with unmarshaled entities:
+//
+//	processor := cdc.NewProcessor(table).
+//		Register("order", func() any { return &Order{} })
+//
+//	processor.OnEntityCreated = func(ctx context.Context, change cdc.EntityChange) error {
+//		order := change.Entity.(*Order)
+//		return notify(ctx, order)
+//	}
+//
+//	processor.OnRefRemoved = func(ctx context.Context, change cdc.RefChange) error {
+//		log.Printf("%s removed from %s", change.Event.Relationship.Target, change.Name)
+//		return nil
+//	}
+//
+//	err := processor.ProcessRecords(ctx, records)
+package cdc
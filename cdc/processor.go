@@ -0,0 +1,270 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// EntityChange describes a change to an entity's self relationship.
+type EntityChange struct {
+	Type      dynamap.ChangeType  // The kind of change
+	Prefix    string              // The entity prefix
+	ID        string              // The entity id
+	Entity    any                 // The entity after the change; nil for deletions or unregistered prefixes
+	OldEntity any                 // The entity before the change; nil for creations or unregistered prefixes
+	Event     dynamap.ChangeEvent // The coalesced change event
+}
+
+// RefChange describes a change to a relationship between two entities.
+type RefChange struct {
+	Type     dynamap.ChangeType  // The kind of change
+	Name     string              // The relationship name
+	SourceID string              // The id of the source entity
+	TargetID string              // The id of the target entity
+	Event    dynamap.ChangeEvent // The coalesced change event
+}
+
+// Processor turns batches of stream records into entity and relationship changes. Set the
+// handler fields to receive changes; nil handlers are skipped.
+//
+// Records are grouped by source entity in the order each entity first appears. Within a
+// group, creations and updates are reported before changes to the entity's refs, and
+// deletions after them. An item changed several times in a batch is reported once, as
+// the net change from its first old image to its last new image; an item created and
+// deleted within the same batch is not reported at all.
+type Processor struct {
+	OnEntityCreated func(ctx context.Context, change EntityChange) error
+	OnEntityUpdated func(ctx context.Context, change EntityChange) error
+	OnEntityDeleted func(ctx context.Context, change EntityChange) error
+	OnRefAdded      func(ctx context.Context, change RefChange) error
+	OnRefUpdated    func(ctx context.Context, change RefChange) error
+	OnRefRemoved    func(ctx context.Context, change RefChange) error
+
+	table    *dynamap.Table
+	entities map[string]func() any
+}
+
+// NewProcessor creates a new [Processor] that decodes records using the table's options.
+func NewProcessor(table *dynamap.Table) *Processor {
+	return &Processor{
+		table:    table,
+		entities: make(map[string]func() any),
+	}
+}
+
+// Register sets the factory used to create entities with the given prefix. Entities are
+// unmarshaled into the value returned by factory, which should be a pointer. Changes to
+// unregistered entities are reported without entities.
+func (p *Processor) Register(prefix string, factory func() any) *Processor {
+	p.entities[prefix] = factory
+	return p
+}
+
+// ProcessRecords decodes records read from the DynamoDB Streams API and processes them.
+func (p *Processor) ProcessRecords(ctx context.Context, records []streamtypes.Record) error {
+	events := make([]dynamap.ChangeEvent, 0, len(records))
+	for _, record := range records {
+		event, err := dynamap.UnmarshalRecord(record, p.table.MarshalOptions)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal record: %w", err)
+		}
+		events = append(events, event)
+	}
+	return p.Process(ctx, events)
+}
+
+// Process groups and coalesces the events, then invokes the handlers. Processing stops at
+// the first handler error.
+func (p *Processor) Process(ctx context.Context, events []dynamap.ChangeEvent) error {
+	groups, err := p.group(events)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		if err := p.processGroup(ctx, g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// group holds the coalesced changes to the items of one source entity, in order.
+type group struct {
+	targets []string
+	changes map[string]dynamap.ChangeEvent
+}
+
+// group collects events by source entity and coalesces repeated changes to an item.
+func (p *Processor) group(events []dynamap.ChangeEvent) ([]*group, error) {
+	var (
+		groups []*group
+		index  = make(map[string]*group)
+	)
+
+	for _, event := range events {
+		rel := event.Relationship
+		g, ok := index[rel.Source]
+		if !ok {
+			g = &group{changes: make(map[string]dynamap.ChangeEvent)}
+			index[rel.Source] = g
+			groups = append(groups, g)
+		}
+
+		prev, ok := g.changes[rel.Target]
+		if !ok {
+			g.targets = append(g.targets, rel.Target)
+			g.changes[rel.Target] = event
+			continue
+		}
+
+		if len(prev.OldImage) == 0 && len(event.NewImage) == 0 {
+			g.changes[rel.Target] = dynamap.ChangeEvent{} // created and deleted within the batch
+			continue
+		}
+
+		merged, err := dynamap.UnmarshalStreamRecord(prev.OldImage, event.NewImage, p.table.MarshalOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to coalesce changes: %w", err)
+		}
+		g.changes[rel.Target] = merged
+	}
+
+	return groups, nil
+}
+
+func (p *Processor) processGroup(ctx context.Context, g *group) error {
+	var (
+		self    *dynamap.ChangeEvent
+		refs    []dynamap.ChangeEvent
+		related []dynamap.Item // current images of the entity's other items
+	)
+
+	for _, target := range g.targets {
+		event := g.changes[target]
+		if event.OldImage == nil && event.NewImage == nil {
+			continue
+		}
+
+		switch {
+		case event.IsSelf():
+			self = &event
+			continue
+		case event.IsRef():
+			refs = append(refs, event)
+		}
+
+		if event.NewImage != nil {
+			related = append(related, event.NewImage)
+		}
+	}
+
+	if self != nil && self.Type != dynamap.ChangeRemove {
+		if err := p.entityChanged(ctx, *self, related); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range refs {
+		if err := p.refChanged(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	if self != nil && self.Type == dynamap.ChangeRemove {
+		if err := p.entityChanged(ctx, *self, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Processor) entityChanged(ctx context.Context, event dynamap.ChangeEvent, related []dynamap.Item) error {
+	handler := p.OnEntityUpdated
+	switch event.Type {
+	case dynamap.ChangeInsert:
+		handler = p.OnEntityCreated
+	case dynamap.ChangeRemove:
+		handler = p.OnEntityDeleted
+	}
+
+	if handler == nil {
+		return nil
+	}
+
+	prefix, id, err := dynamap.ParseKey(event.Relationship.Source, p.table.MarshalOptions)
+	if err != nil {
+		return fmt.Errorf("failed to parse entity key: %w", err)
+	}
+
+	change := EntityChange{Type: event.Type, Prefix: prefix, ID: id, Event: event}
+	if factory, ok := p.entities[prefix]; ok {
+		if event.NewImage != nil {
+			items := append([]dynamap.Item{event.NewImage}, related...)
+			if change.Entity, err = p.unmarshal(ctx, factory(), items); err != nil {
+				return fmt.Errorf("failed to unmarshal entity %s: %w", event.Relationship.Source, err)
+			}
+		}
+		if event.OldImage != nil {
+			if change.OldEntity, err = p.unmarshal(ctx, factory(), []dynamap.Item{event.OldImage}); err != nil {
+				return fmt.Errorf("failed to unmarshal old entity %s: %w", event.Relationship.Source, err)
+			}
+		}
+	}
+
+	return handler(ctx, change)
+}
+
+// unmarshal unmarshals the entity from items, the first of which is its self relationship.
+// Refs are only unmarshaled if the entity implements [dynamap.RefUnmarshaler].
+func (p *Processor) unmarshal(ctx context.Context, entity any, items []dynamap.Item) (any, error) {
+	items, err := p.table.HydrateItems(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	if unmarshaler, ok := entity.(dynamap.RefUnmarshaler); ok {
+		_, err = dynamap.UnmarshalEntity(items, unmarshaler, p.table.MarshalOptions)
+	} else {
+		_, err = dynamap.UnmarshalSelf(items[0], entity, p.table.MarshalOptions)
+	}
+
+	return entity, err
+}
+
+func (p *Processor) refChanged(ctx context.Context, event dynamap.ChangeEvent) error {
+	handler := p.OnRefUpdated
+	switch event.Type {
+	case dynamap.ChangeInsert:
+		handler = p.OnRefAdded
+	case dynamap.ChangeRemove:
+		handler = p.OnRefRemoved
+	}
+
+	if handler == nil {
+		return nil
+	}
+
+	_, sourceID, name, err := dynamap.ParseLabel(event.Relationship.Label, p.table.MarshalOptions)
+	if err != nil {
+		return fmt.Errorf("failed to parse ref label: %w", err)
+	}
+
+	targetID, err := event.Relationship.EntityID(p.table.MarshalOptions)
+	if err != nil {
+		return fmt.Errorf("failed to parse ref key: %w", err)
+	}
+
+	return handler(ctx, RefChange{
+		Type:     event.Type,
+		Name:     name,
+		SourceID: sourceID,
+		TargetID: targetID,
+		Event:    event,
+	})
+}
@@ -0,0 +1,208 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nisimpson/dynamap"
+)
+
+type order struct {
+	ID       string   `dynamodbav:"id"`
+	Status   string   `dynamodbav:"status"`
+	Products []string `dynamodbav:"-"`
+}
+
+func (o *order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	return nil
+}
+
+func (o *order) MarshalRefs(ctx *dynamap.RelationshipContext) error {
+	for _, id := range o.Products {
+		ctx.AddOne("products", &product{ID: id})
+	}
+	return nil
+}
+
+func (o *order) UnmarshalSelf(rel *dynamap.Relationship) error { return nil }
+
+func (o *order) UnmarshalRef(name string, id string, ref *dynamap.Relationship) error {
+	o.Products = append(o.Products, id)
+	return nil
+}
+
+type product struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (p *product) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("product", p.ID)
+	return nil
+}
+
+// recorder collects the changes reported by a processor.
+type recorder struct {
+	entities []EntityChange
+	refs     []RefChange
+}
+
+func newTestProcessor(table *dynamap.Table) (*Processor, *recorder) {
+	r := &recorder{}
+	onEntity := func(ctx context.Context, change EntityChange) error {
+		r.entities = append(r.entities, change)
+		return nil
+	}
+	onRef := func(ctx context.Context, change RefChange) error {
+		r.refs = append(r.refs, change)
+		return nil
+	}
+
+	p := NewProcessor(table).Register("order", func() any { return &order{} })
+	p.OnEntityCreated, p.OnEntityUpdated, p.OnEntityDeleted = onEntity, onEntity, onEntity
+	p.OnRefAdded, p.OnRefUpdated, p.OnRefRemoved = onRef, onRef, onRef
+	return p, r
+}
+
+func marshalItems(t *testing.T, table *dynamap.Table, in dynamap.RefMarshaler) []dynamap.Item {
+	t.Helper()
+	batches, err := table.MarshalBatch(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+
+	var items []dynamap.Item
+	for _, request := range batches[0].RequestItems[table.TableName] {
+		items = append(items, request.PutRequest.Item)
+	}
+	return items
+}
+
+func changeEvent(t *testing.T, table *dynamap.Table, oldImage, newImage dynamap.Item) dynamap.ChangeEvent {
+	t.Helper()
+	event, err := dynamap.UnmarshalStreamRecord(oldImage, newImage, table.MarshalOptions)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal stream record: %v", err)
+	}
+	return event
+}
+
+func TestProcessor(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+	created := marshalItems(t, table, &order{ID: "O1", Status: "new", Products: []string{"P1", "P2"}})
+	updated := marshalItems(t, table, &order{ID: "O1", Status: "paid"})
+
+	t.Run("created with refs", func(t *testing.T) {
+		p, r := newTestProcessor(table)
+		events := []dynamap.ChangeEvent{
+			changeEvent(t, table, nil, created[1]),
+			changeEvent(t, table, nil, created[0]),
+			changeEvent(t, table, nil, created[2]),
+		}
+
+		if err := p.Process(ctx, events); err != nil {
+			t.Fatalf("Failed to process: %v", err)
+		}
+
+		if len(r.entities) != 1 {
+			t.Fatalf("Expected 1 entity change, got %d", len(r.entities))
+		}
+		change := r.entities[0]
+		if change.Type != dynamap.ChangeInsert || change.ID != "O1" || change.OldEntity != nil {
+			t.Errorf("Expected order insert, got %+v", change)
+		}
+		if o := change.Entity.(*order); o.Status != "new" || len(o.Products) != 2 {
+			t.Errorf("Expected order with 2 products, got %+v", o)
+		}
+
+		if len(r.refs) != 2 {
+			t.Fatalf("Expected 2 ref changes, got %d", len(r.refs))
+		}
+		if ref := r.refs[0]; ref.Name != "products" || ref.SourceID != "O1" || ref.TargetID != "P1" {
+			t.Errorf("Expected products ref to P1, got %+v", ref)
+		}
+	})
+
+	t.Run("coalesces changes", func(t *testing.T) {
+		p, r := newTestProcessor(table)
+		events := []dynamap.ChangeEvent{
+			changeEvent(t, table, nil, created[0]),
+			changeEvent(t, table, created[0], updated[0]),
+			changeEvent(t, table, nil, created[1]),
+			changeEvent(t, table, created[1], nil),
+		}
+
+		if err := p.Process(ctx, events); err != nil {
+			t.Fatalf("Failed to process: %v", err)
+		}
+
+		if len(r.entities) != 1 || r.entities[0].Type != dynamap.ChangeInsert {
+			t.Fatalf("Expected a single insert, got %+v", r.entities)
+		}
+		if o := r.entities[0].Entity.(*order); o.Status != "paid" {
+			t.Errorf("Expected latest status 'paid', got %s", o.Status)
+		}
+		if len(r.refs) != 0 {
+			t.Errorf("Expected no ref changes, got %d", len(r.refs))
+		}
+	})
+
+	t.Run("deleted after refs", func(t *testing.T) {
+		p, r := newTestProcessor(table)
+		var calls []string
+		p.OnEntityDeleted = func(ctx context.Context, change EntityChange) error {
+			calls = append(calls, "entity")
+			if change.Entity != nil || change.OldEntity == nil {
+				t.Errorf("Expected only old entity, got %+v", change)
+			}
+			return nil
+		}
+		p.OnRefRemoved = func(ctx context.Context, change RefChange) error {
+			calls = append(calls, "ref")
+			return nil
+		}
+
+		events := []dynamap.ChangeEvent{
+			changeEvent(t, table, created[0], nil),
+			changeEvent(t, table, created[1], nil),
+		}
+		if err := p.Process(ctx, events); err != nil {
+			t.Fatalf("Failed to process: %v", err)
+		}
+
+		if len(calls) != 2 || calls[0] != "ref" || calls[1] != "entity" {
+			t.Errorf("Expected ref removal before entity deletion, got %v", calls)
+		}
+		if len(r.entities) != 0 {
+			t.Errorf("Expected no recorded entity changes, got %d", len(r.entities))
+		}
+	})
+
+	t.Run("unregistered entity", func(t *testing.T) {
+		p, r := newTestProcessor(table)
+		input, err := table.MarshalPut(&product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		if err := p.Process(ctx, []dynamap.ChangeEvent{changeEvent(t, table, nil, input.Item)}); err != nil {
+			t.Fatalf("Failed to process: %v", err)
+		}
+		if len(r.entities) != 1 || r.entities[0].Entity != nil || r.entities[0].Prefix != "product" {
+			t.Errorf("Expected product change without entity, got %+v", r.entities)
+		}
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		p, _ := newTestProcessor(table)
+		errHandler := errors.New("handler failed")
+		p.OnEntityUpdated = func(ctx context.Context, change EntityChange) error { return errHandler }
+
+		err := p.Process(ctx, []dynamap.ChangeEvent{changeEvent(t, table, created[0], updated[0])})
+		if !errors.Is(err, errHandler) {
+			t.Errorf("Expected handler error, got %v", err)
+		}
+	})
+}
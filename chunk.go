@@ -0,0 +1,202 @@
+package dynamap
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// AttributeNameChunks is the attribute that stores the number of chunks an oversized
+	// item was split into.
+	AttributeNameChunks = "chunks"
+	// ChunkSuffix is appended to the sort key of continuation items, followed by the
+	// chunk number starting at 2.
+	ChunkSuffix = "#chunk"
+)
+
+// ErrChunkedItem is returned when an item that was split into chunks is unmarshaled
+// without its continuation items, or written with a request that only holds one item.
+var ErrChunkedItem = newError(ErrValidation, "item is split into chunks")
+
+// chunkItem splits the data attribute of item across continuation items if it exceeds
+// the chunk size. The first item keeps the original keys and records the number of chunks;
+// each continuation item shares its partition and has no label, so it is not indexed.
+//
+// Continuation items left over from a previously larger entity are ignored when reading.
+func (mo MarshalOptions) chunkItem(item Item) ([]Item, error) {
+	data, ok := item[AttributeNameData]
+	if !ok || mo.ChunkSize <= 0 {
+		return []Item{item}, nil
+	}
+
+	payload, err := encodeAttributeValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) <= mo.ChunkSize {
+		return []Item{item}, nil
+	}
+
+	var (
+		chunks = (len(payload) + mo.ChunkSize - 1) / mo.ChunkSize
+		items  = make([]Item, 0, chunks)
+		source = item[AttributeNameSource]
+		target = item[AttributeNameTarget].(*types.AttributeValueMemberS).Value
+	)
+
+	for i := range chunks {
+		part := payload[i*mo.ChunkSize : min((i+1)*mo.ChunkSize, len(payload))]
+		if i == 0 {
+			item[AttributeNameData] = &types.AttributeValueMemberB{Value: part}
+			item[AttributeNameChunks] = &types.AttributeValueMemberN{Value: strconv.Itoa(chunks)}
+			items = append(items, item)
+			continue
+		}
+
+		items = append(items, Item{
+			AttributeNameSource: source,
+			AttributeNameTarget: &types.AttributeValueMemberS{Value: chunkKey(target, i+1)},
+			AttributeNameData:   &types.AttributeValueMemberB{Value: part},
+		})
+	}
+
+	return items, nil
+}
+
+// assembleChunks merges the continuation items in items back into the items they were
+// split from. Continuation items and revisions are removed from the result; items is not
+// modified. An item is a continuation item only if its sort key is the sort key of an item
+// in the same partition followed by [ChunkSuffix] and a chunk number, and that item has a
+// chunk count or the continuation item has no label, as left over from a previously larger
+// entity. Other items are returned as they are, whatever their sort keys contain.
+func assembleChunks(items []Item) ([]Item, error) {
+	var (
+		result   = make([]Item, 0, len(items))
+		segments = make(map[string][]byte)
+		chunked  = make(map[string]int)
+	)
+
+	keys := make([][2]string, len(items))
+	for i, item := range items {
+		source, target, err := UnmarshalTableKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+		keys[i] = [2]string{source, target}
+
+		chunks := 0
+		if _, ok := item[AttributeNameChunks]; ok {
+			if err := attributevalue.Unmarshal(item[AttributeNameChunks], &chunks); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal chunk count: %w", err)
+			}
+		}
+		chunked[source+"\x00"+target] = chunks
+	}
+
+	for i, item := range items {
+		source, target := keys[i][0], keys[i][1]
+
+		// Revisions are history rather than part of the entity
+		if isRevisionKey(source, target) {
+			continue
+		}
+
+		if parent, n, ok := parseChunkKey(target); ok {
+			chunks, found := chunked[source+"\x00"+parent]
+			_, labeled := item[AttributeNameLabel]
+			if found && (n <= chunks || !labeled) {
+				if b, ok := item[AttributeNameData].(*types.AttributeValueMemberB); ok && n <= chunks {
+					segments[source+"\x00"+target] = b.Value
+				}
+				continue
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	for i, item := range result {
+		if _, ok := item[AttributeNameChunks]; !ok {
+			continue
+		}
+
+		assembled, err := assembleItem(item, segments)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = assembled
+	}
+
+	return result, nil
+}
+
+// assembleItem returns a copy of a chunked item with its data attribute reassembled from
+// the segments, keyed by the source and target of each continuation item.
+func assembleItem(item Item, segments map[string][]byte) (Item, error) {
+	var chunks int
+	if err := attributevalue.Unmarshal(item[AttributeNameChunks], &chunks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chunk count: %w", err)
+	}
+
+	source, target, err := UnmarshalTableKey(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+	}
+
+	first, ok := item[AttributeNameData].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s has no chunk data", ErrChunkedItem, source, target)
+	}
+
+	payload := append([]byte(nil), first.Value...)
+	for i := 2; i <= chunks; i++ {
+		segment, ok := segments[source+"\x00"+chunkKey(target, i)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s is missing chunk %d of %d", ErrChunkedItem, source, target, i, chunks)
+		}
+		payload = append(payload, segment...)
+	}
+
+	data, err := decodeAttributeValue(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	item = maps.Clone(item)
+	item[AttributeNameData] = data
+	delete(item, AttributeNameChunks)
+	return item, nil
+}
+
+// chunkKey returns the sort key of a continuation item.
+func chunkKey(target string, n int) string {
+	return target + ChunkSuffix + strconv.Itoa(n)
+}
+
+// parseChunkKey returns the sort key of the item a continuation item with the sort key
+// target was split from, and its chunk number. It returns false if target does not end in
+// [ChunkSuffix] followed by a chunk number of 2 or more.
+func parseChunkKey(target string) (string, int, bool) {
+	i := strings.LastIndex(target, ChunkSuffix)
+	if i < 0 {
+		return "", 0, false
+	}
+
+	digits := target[i+len(ChunkSuffix):]
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 2 || digits != strconv.Itoa(n) {
+		return "", 0, false
+	}
+
+	return target[:i], n, true
+}
@@ -0,0 +1,162 @@
+package dynamap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for chunked items
+
+func TestChunkedItems(t *testing.T) {
+	order := &Order{ID: "O1", PurchasedBy: strings.Repeat("john", 100), Products: []Product{{ID: "P1"}}}
+
+	batchItems := func(t *testing.T, table *Table) []Item {
+		t.Helper()
+		batches, err := table.MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		var items []Item
+		for _, request := range batches[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+		return items
+	}
+
+	t.Run("splits data", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.ChunkSize = 128
+
+		items := batchItems(t, table)
+		if len(items) < 4 {
+			t.Fatalf("Expected self, ref and continuation items, got %d", len(items))
+		}
+
+		if _, ok := items[0]["chunks"].(*types.AttributeValueMemberN); !ok {
+			t.Error("Expected chunk count on self item")
+		}
+		if sk := items[1]["sk"].(*types.AttributeValueMemberS).Value; sk != "order#O1#chunk2" {
+			t.Errorf("Expected sk 'order#O1#chunk2', got %s", sk)
+		}
+		if _, ok := items[1]["label"]; ok {
+			t.Error("Expected continuation item without label")
+		}
+	})
+
+	t.Run("small data", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.ChunkSize = 1 << 20
+
+		if items := batchItems(t, table); len(items) != 2 {
+			t.Errorf("Expected 2 items, got %d", len(items))
+		}
+	})
+
+	for name, codec := range map[string]DataCodec{"plain": nil, "gzip": GzipDataCodec{}} {
+		t.Run("reassembles "+name, func(t *testing.T) {
+			table := NewTable("test-table")
+			table.ChunkSize = 64
+			table.DataCodec = codec
+
+			var out Order
+			rels, err := UnmarshalEntity(batchItems(t, table), &out, table.MarshalOptions)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal entity: %v", err)
+			}
+			if out.PurchasedBy != order.PurchasedBy || len(out.Products) != 1 {
+				t.Errorf("Expected reassembled order, got %+v", out)
+			}
+			if len(rels) != 2 {
+				t.Errorf("Expected 2 relationships, got %d", len(rels))
+			}
+		})
+	}
+
+	t.Run("missing chunk", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.ChunkSize = 128
+
+		items := batchItems(t, table)
+		items = append(items[:1], items[2:]...)
+
+		var out Order
+		if _, err := UnmarshalEntity(items, &out, table.MarshalOptions); !errors.Is(err, ErrChunkedItem) {
+			t.Errorf("Expected ErrChunkedItem, got %v", err)
+		}
+	})
+
+	t.Run("unmarshal self", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.ChunkSize = 128
+
+		var out Order
+		if _, err := UnmarshalSelf(batchItems(t, table)[0], &out, table.MarshalOptions); !errors.Is(err, ErrChunkedItem) {
+			t.Errorf("Expected ErrChunkedItem, got %v", err)
+		}
+	})
+
+	t.Run("put", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.ChunkSize = 128
+
+		if _, err := table.MarshalPut(order); !errors.Is(err, ErrChunkedItem) {
+			t.Errorf("Expected ErrChunkedItem, got %v", err)
+		}
+	})
+
+	t.Run("keeps refs with chunk suffix", func(t *testing.T) {
+		table := NewTable("test-table")
+		order := &Order{ID: "O1", Products: []Product{{ID: "chunky-bacon"}, {ID: "chunk2"}}}
+		batches, err := table.MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		var items []Item
+		for _, request := range batches[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		var out Order
+		if _, err := UnmarshalEntity(items, &out, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(out.Products) != 2 {
+			t.Errorf("Expected 2 products, got %+v", out.Products)
+		}
+	})
+
+	t.Run("error category", func(t *testing.T) {
+		if !errors.Is(ErrChunkedItem, ErrValidation) {
+			t.Error("Expected ErrChunkedItem to match ErrValidation")
+		}
+	})
+}
+
+func TestParseChunkKey(t *testing.T) {
+	tests := []struct {
+		target string
+		parent string
+		n      int
+		ok     bool
+	}{
+		{target: "order#O1#chunk2", parent: "order#O1", n: 2, ok: true},
+		{target: "order#O1#chunk12", parent: "order#O1", n: 12, ok: true},
+		{target: "product#chunky-bacon"},
+		{target: "order#O1#chunk"},
+		{target: "order#O1#chunk1"},
+		{target: "order#O1#chunk02"},
+		{target: "order#O1#chunk2x"},
+	}
+
+	for _, tt := range tests {
+		parent, n, ok := parseChunkKey(tt.target)
+		if parent != tt.parent || n != tt.n || ok != tt.ok {
+			t.Errorf("parseChunkKey(%q) = %q, %d, %v", tt.target, parent, n, ok)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Client binds a [Table] to a DynamoDB client, running every request through its
+// interceptors. Client implements [DynamoDBClient], so it may be passed to any table
+// method or helper; the table's own interceptors run around the client's.
+//
+// Example:
+//
+//	client := dynamap.NewClient(table, dynamodb.NewFromConfig(cfg), tenantGuard, metrics)
+//	err := client.Put(ctx, order)
+//	_, err = client.Get(ctx, &Order{ID: "O1"}, &order)
+type Client struct {
+	Table        *Table         // The table holding the entities
+	DynamoDB     DynamoDBClient // The client executing the requests
+	Interceptors []Interceptor  // Interceptors run around every request, in order
+}
+
+var (
+	_ DynamoDBClient      = (*Client)(nil)
+	_ BatchGetClient      = (*Client)(nil)
+	_ TransactWriteClient = (*Client)(nil)
+)
+
+// NewClient creates a new [Client] over the table.
+func NewClient(table *Table, client DynamoDBClient, interceptors ...Interceptor) *Client {
+	return &Client{Table: table, DynamoDB: client, Interceptors: interceptors}
+}
+
+// Put writes the entity using [Table.Put].
+func (c *Client) Put(ctx context.Context, in Marshaler, opts ...func(*MarshalOptions)) error {
+	return c.Table.Put(ctx, c, in, opts...)
+}
+
+// Get loads the entity into out using [Table.Get].
+func (c *Client) Get(ctx context.Context, in Marshaler, out any, opts ...func(*MarshalOptions)) (Relationship, error) {
+	return c.Table.Get(ctx, c, in, out, opts...)
+}
+
+// GetAs loads the entity into a new value of T using [Table.Get], so callers need not
+// declare the value beforehand. If T is a pointer type, it points to a new zero value.
+//
+// Example:
+//
+//	product, _, err := dynamap.GetAs[Product](ctx, client, &Product{ID: "P1"})
+func GetAs[T any](ctx context.Context, c *Client, in Marshaler, opts ...func(*MarshalOptions)) (T, Relationship, error) {
+	out := newValue[T]()
+	target := any(&out)
+	if reflect.TypeFor[T]().Kind() == reflect.Pointer {
+		target = out
+	}
+	rel, err := c.Get(ctx, in, target, opts...)
+	return out, rel, err
+}
+
+// Update updates the entity using [Table.Update].
+func (c *Client) Update(ctx context.Context, in Marshaler, updater Updater, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemOutput, error) {
+	return c.Table.Update(ctx, c, in, updater, opts...)
+}
+
+// Delete deletes the self relationship of the entity using [Table.MarshalDelete].
+func (c *Client) Delete(ctx context.Context, in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.DeleteItemOutput, error) {
+	opts = contextOptions(ctx, opts)
+	input, err := c.Table.MarshalDelete(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.Table.client(c).DeleteItem(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete item: %w", classify(err))
+	}
+
+	return output, nil
+}
+
+// QueryPage executes a single page of the query using [Table.MarshalQuery], returning
+// the items and the start key of the next page, or nil if the results are exhausted.
+func (c *Client) QueryPage(ctx context.Context, in QueryMarshaler, opts ...func(*MarshalOptions)) ([]Item, Item, error) {
+	opts = contextOptions(ctx, opts)
+	input, err := c.Table.MarshalQuery(in, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := c.Table.client(c).Query(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query: %w", classify(err))
+	}
+
+	return output.Items, output.LastEvaluatedKey, nil
+}
+
+// BatchGet loads the entities using [Table.BatchGet].
+func (c *Client) BatchGet(ctx context.Context, entities ...Marshaler) ([]Relationship, error) {
+	return c.Table.BatchGet(ctx, c, entities...)
+}
+
+// Exists checks whether the entity exists using [Table.Exists].
+func (c *Client) Exists(ctx context.Context, in Marshaler, opts ...func(*MarshalOptions)) (bool, error) {
+	return c.Table.Exists(ctx, c, in, opts...)
+}
+
+// MultiExists checks whether each of the entities exists using [Table.MultiExists].
+func (c *Client) MultiExists(ctx context.Context, entities ...Marshaler) ([]bool, error) {
+	return c.Table.MultiExists(ctx, c, entities...)
+}
+
+// chain returns the client running requests through the client interceptors, and
+// translating them to the key names of the table.
+func (c *Client) chain() *interceptClient {
+	interceptors := c.Interceptors
+	if c.Table.KeyNames.IsSet() {
+		interceptors = append(interceptors[:len(interceptors):len(interceptors)], keyNamesInterceptor{table: c.Table})
+	}
+	return &interceptClient{next: c.DynamoDB, interceptors: interceptors}
+}
+
+func (c *Client) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return c.chain().PutItem(ctx, params, optFns...)
+}
+
+func (c *Client) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return c.chain().GetItem(ctx, params, optFns...)
+}
+
+func (c *Client) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return c.chain().Query(ctx, params, optFns...)
+}
+
+func (c *Client) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return c.chain().UpdateItem(ctx, params, optFns...)
+}
+
+func (c *Client) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return c.chain().DeleteItem(ctx, params, optFns...)
+}
+
+func (c *Client) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return c.chain().BatchGetItem(ctx, params, optFns...)
+}
+
+func (c *Client) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return c.chain().BatchWriteItem(ctx, params, optFns...)
+}
+
+func (c *Client) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return c.chain().TransactWriteItems(ctx, params, optFns...)
+}
@@ -0,0 +1,94 @@
+package dynamap
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// Tests for the client
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	var calls []string
+	table := NewTable("test-table")
+	table.Interceptors = []Interceptor{recordingInterceptor("table", &calls)}
+	client := NewClient(table, newMockDynamoDBClient(), recordingInterceptor("client", &calls))
+
+	if err := client.Put(ctx, &Product{ID: "P1", Category: "widgets"}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+
+	var got Product
+	if _, err := client.Get(ctx, &Product{ID: "P1"}, &got); err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if got.Category != "widgets" {
+		t.Errorf("Expected product, got %+v", got)
+	}
+
+	if _, err := client.Delete(ctx, &Product{ID: "P1"}); err != nil {
+		t.Fatalf("Failed to delete product: %v", err)
+	}
+	if _, err := client.Get(ctx, &Product{ID: "P1"}, &got); err != ErrItemNotFound {
+		t.Errorf("Expected ErrItemNotFound, got %v", err)
+	}
+
+	want := []string{"table before PutItem", "client before PutItem", "client after PutItem", "table after PutItem"}
+	if !slices.Equal(calls[:4], want) {
+		t.Errorf("Expected %v, got %v", want, calls[:4])
+	}
+	if len(calls) != 16 {
+		t.Errorf("Expected 4 intercepted requests, got %v", calls)
+	}
+}
+
+func TestGetAs(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(NewTable("test-table"), newMockDynamoDBClient())
+
+	if err := client.Put(ctx, &Product{ID: "P1", Category: "widgets"}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+
+	got, rel, err := GetAs[Product](ctx, client, &Product{ID: "P1"})
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if got.Category != "widgets" || rel.Source != "product#P1" {
+		t.Errorf("Expected product, got %+v and %+v", got, rel)
+	}
+
+	ptr, _, err := GetAs[*Product](ctx, client, &Product{ID: "P1"})
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if ptr == nil || ptr.Category != "widgets" {
+		t.Errorf("Expected product pointer, got %+v", ptr)
+	}
+
+	if _, _, err := GetAs[Product](ctx, client, &Product{ID: "P2"}); err != ErrItemNotFound {
+		t.Errorf("Expected ErrItemNotFound, got %v", err)
+	}
+}
+
+func TestNewTableWithDAX(t *testing.T) {
+	ctx := context.Background()
+	client := NewTableWithDAX("test-table", newMockDynamoDBClient())
+
+	if client.Table.TableName != "test-table" {
+		t.Errorf("Expected table name test-table, got %s", client.Table.TableName)
+	}
+	if err := client.Put(ctx, &Product{ID: "P1", Category: "widgets"}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+
+	var got Product
+	if _, err := client.Get(ctx, &Product{ID: "P1"}, &got); err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if got.Category != "widgets" {
+		t.Errorf("Expected product, got %+v", got)
+	}
+}
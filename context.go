@@ -0,0 +1,52 @@
+package dynamap
+
+import (
+	"context"
+	"slices"
+)
+
+// marshalOptionsKey is the context key of the default marshal options.
+type marshalOptionsKey struct{}
+
+// WithMarshalOptions returns a copy of ctx carrying default marshal options, such as the
+// tenant, actor or clock of a request. Table and [Client] methods accepting marshal
+// options apply them after the table configuration and before the options of the call,
+// so per-request settings need not be passed to every call. Options already carried by
+// ctx are applied first.
+//
+// Example:
+//
+//	ctx = dynamap.WithMarshalOptions(r.Context(), func(mo *dynamap.MarshalOptions) {
+//		mo.TenantID = tenant.ID
+//		mo.ActorID = user.ID
+//	})
+//	err := table.Put(ctx, client, order)
+func WithMarshalOptions(ctx context.Context, opts ...func(*MarshalOptions)) context.Context {
+	return context.WithValue(ctx, marshalOptionsKey{}, contextOptions(ctx, opts))
+}
+
+// MarshalOptionsFromContext returns the default marshal options carried by ctx, if any.
+func MarshalOptionsFromContext(ctx context.Context) []func(*MarshalOptions) {
+	opts, _ := ctx.Value(marshalOptionsKey{}).([]func(*MarshalOptions))
+	return opts
+}
+
+// contextOptions returns the default marshal options carried by ctx followed by opts.
+func contextOptions(ctx context.Context, opts []func(*MarshalOptions)) []func(*MarshalOptions) {
+	defaults := MarshalOptionsFromContext(ctx)
+	if len(defaults) == 0 {
+		return opts
+	}
+	return append(slices.Clip(defaults), opts...)
+}
+
+// contextEdgeOptions returns opts followed by an option applying the default marshal
+// options carried by ctx before the marshal options of the edges.
+func contextEdgeOptions(ctx context.Context, opts []func(*EdgeOptions)) []func(*EdgeOptions) {
+	if len(MarshalOptionsFromContext(ctx)) == 0 {
+		return opts
+	}
+	return append(slices.Clip(opts), func(eo *EdgeOptions) {
+		eo.MarshalOptions = contextOptions(ctx, eo.MarshalOptions)
+	})
+}
@@ -0,0 +1,91 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+)
+
+// Tests for context-scoped marshal options
+
+func TestWithMarshalOptions(t *testing.T) {
+	tenant := func(id string) func(*MarshalOptions) {
+		return func(mo *MarshalOptions) { mo.TenantID = id }
+	}
+
+	t.Run("carries options", func(t *testing.T) {
+		ctx := WithMarshalOptions(context.Background(), tenant("acme"))
+		ctx = WithMarshalOptions(ctx, func(mo *MarshalOptions) { mo.ActorID = "user-1" })
+
+		mo := NewMarshalOptions(MarshalOptionsFromContext(ctx)...)
+		if mo.TenantID != "acme" || mo.ActorID != "user-1" {
+			t.Errorf("Expected the tenant and actor, got %q and %q", mo.TenantID, mo.ActorID)
+		}
+		if opts := MarshalOptionsFromContext(context.Background()); opts != nil {
+			t.Errorf("Expected no options, got %d", len(opts))
+		}
+	})
+
+	t.Run("table methods apply options", func(t *testing.T) {
+		table := NewTable("test-table")
+		client := newMockDynamoDBClient()
+		ctx := WithMarshalOptions(context.Background(), tenant("acme"))
+
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if _, ok := client.items["acme|product#P1#acme|product#P1"]; !ok {
+			t.Fatalf("Expected the tenant scoped product, got %v", client.items)
+		}
+
+		var product Product
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &product); err != nil {
+			t.Errorf("Failed to get the tenant scoped product: %v", err)
+		}
+		if _, err := table.Get(context.Background(), client, &Product{ID: "P1"}, &product); err != ErrItemNotFound {
+			t.Errorf("Expected ErrItemNotFound without the tenant, got %v", err)
+		}
+	})
+
+	t.Run("call options take precedence", func(t *testing.T) {
+		table := NewTable("test-table")
+		client := newMockDynamoDBClient()
+		ctx := WithMarshalOptions(context.Background(), tenant("acme"))
+
+		if err := table.Put(ctx, client, &Product{ID: "P1"}, tenant("globex")); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if _, ok := client.items["globex|product#P1#globex|product#P1"]; !ok {
+			t.Errorf("Expected the call tenant, got %v", client.items)
+		}
+	})
+
+	t.Run("edges apply options", func(t *testing.T) {
+		table := NewTable("test-table")
+		client := newMockDynamoDBClient()
+		ctx := WithMarshalOptions(context.Background(), tenant("acme"))
+
+		if err := table.Attach(ctx, client, &Order{ID: "O1"}, "products", SliceOf(&Product{ID: "P1"})); err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		if _, ok := client.items["acme|order#O1#acme|product#P1"]; !ok {
+			t.Errorf("Expected the tenant scoped edge, got %v", client.items)
+		}
+	})
+
+	t.Run("client methods apply options", func(t *testing.T) {
+		table := NewTable("test-table")
+		mock := newMockDynamoDBClient()
+		client := NewClient(table, mock)
+		ctx := WithMarshalOptions(context.Background(), tenant("acme"))
+
+		if err := client.Put(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if _, err := client.Delete(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		if len(mock.items) != 0 {
+			t.Errorf("Expected the tenant scoped product to be deleted, got %v", mock.items)
+		}
+	})
+}
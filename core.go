@@ -6,7 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -15,7 +16,7 @@ import (
 )
 
 // ErrItemNotFound is returned when an item is not found in DynamoDB operations.
-var ErrItemNotFound = errors.New("item not found")
+var ErrItemNotFound = newError(ErrNotFound, "item not found")
 
 // Clock is a function type that returns the current time for dependency injection.
 type Clock func() time.Time
@@ -27,39 +28,145 @@ func DefaultClock() time.Time {
 
 // Table contains DynamoDB table configuration and marshal options.
 type Table struct {
-	TableName      string        // Main table name
-	RefIndexName   string        // Ref index name (maps to gsi1_sk attribute)
-	KeyDelimiter   string        // Delimiter for hash and sort keys. Default is '#'.
-	LabelDelimiter string        // Delimiter for label index hash keys. Default is '/'.
-	PaginationTTL  time.Duration // TTL for pagination cursors stored in table
+	TableName       string            // Main table name
+	RefIndexName    string            // Ref index name (maps to gsi1_sk attribute)
+	KeyDelimiter    string            // Delimiter for hash and sort keys. Default is '#'.
+	KeyCodec        KeyCodec          // Optional codec for hash and sort keys. Overrides KeyDelimiter.
+	LabelDelimiter  string            // Delimiter for label index hash keys. Default is '/'.
+	LabelCodec      LabelCodec        // Optional codec for relationship labels. Overrides LabelDelimiter.
+	PaginationTTL   time.Duration     // TTL for pagination cursors stored in table
+	SoftDeleteTTL   time.Duration     // TTL for soft deleted relationships. Zero keeps them indefinitely.
+	Registry        *Registry         // Optional entity schemas used to validate relationships
+	DataCodec       DataCodec         // Optional codec that encrypts or compresses the data attribute
+	ChunkSize       int               // Maximum encoded data size per item before splitting into chunks. Zero disables chunking.
+	BlobStore       BlobStore         // Optional store for data too large to keep in DynamoDB
+	BlobThreshold   int               // Data size above which data is offloaded to BlobStore. Zero uses DefaultBlobThreshold.
+	Aliases         []AttributeAlias  // Attribute renames applied when reading and writing items
+	Indexes         []SecondaryIndex  // Additional secondary indexes populated by entities
+	Counters        map[string]string // Source attributes counting the edges attached and detached, by relationship name
+	LabelBuckets    LabelBuckets      // Time bucketing of high-volume self relationship labels
+	LabelShards     LabelShards       // Write sharding of hot self relationship labels
+	TenantID        string            // Optional tenant that scopes all keys and labels
+	TenantDelimiter string            // Delimiter between the tenant and keys or labels. Default is '|'.
+	Interceptors    []Interceptor     // Interceptors run around every request executed by the table
+	Logger          Logger            // Optional logger of marshaled and executed requests
+	LogData         bool              // If true, logs include entity data and expression values instead of redacting them
+	Debug           io.Writer         // Optional writer of an explanation of every marshaled query and update; see Table.Explain
+	FilterExpired   bool              // If true, queries and gets skip items that expired but are not yet deleted by DynamoDB
+	History         bool              // If true, puts and updates also write an immutable revision of the entity
+	HistoryTTL      time.Duration     // TTL for revisions written in history mode. Zero keeps them indefinitely.
+	Clock           Clock             // Optional clock for timestamps, TTLs and expiry checks. Default is DefaultClock.
+	TimestampCodec  TimestampCodec    // Optional codec for the created, updated and deleted timestamps. Default is RFC 3339 strings.
+	KeyNames        KeyNames          // Names of the key attributes of the table. Default is the dynamap attribute names.
+	Validator       Validator         // Optional check of entities and updaters before they are written
+	OmitTimestamps  bool              // If true, the created and updated timestamps are not written
+
+	// RefIndexHashAttribute is an optional attribute holding a copy of the label, used as
+	// the partition key of the ref index instead of the label itself. Set it for tables
+	// whose index is keyed by a dedicated attribute, such as gsi1_pk.
+	RefIndexHashAttribute string
+
+	// RefNameIndex is the optional name of a secondary index declared in Indexes whose
+	// partition key holds the ref name of every edge, such as "order/products", so the
+	// edges of all entities of a type can be queried with [QueryRefs].
+	RefNameIndex string
+}
+
+// MarshalOptions applies the table configuration to mo. It can be passed as an option
+// to the unmarshal functions so items are read the same way the table writes them:
+//
+//	relationships, err := dynamap.UnmarshalEntity(items, &order, table.MarshalOptions)
+func (t *Table) MarshalOptions(mo *MarshalOptions) {
+	mo.KeyDelimiter = t.KeyDelimiter
+	mo.KeyCodec = t.KeyCodec
+	mo.LabelDelimiter = t.LabelDelimiter
+	mo.LabelCodec = t.LabelCodec
+	mo.Aliases = t.Aliases
+	mo.Registry = t.Registry
+	mo.DataCodec = t.DataCodec
+	mo.ChunkSize = t.ChunkSize
+	mo.Indexes = t.Indexes
+	mo.LabelBuckets = t.LabelBuckets
+	mo.LabelShards = t.LabelShards
+	mo.TenantID = t.TenantID
+	mo.TenantDelimiter = t.TenantDelimiter
+	mo.TimestampCodec = t.TimestampCodec
+	mo.KeyNames = t.KeyNames
+	mo.RefIndexHashAttribute = t.RefIndexHashAttribute
+	mo.RefNameIndex = t.RefNameIndex
+	mo.Validator = t.Validator
+	mo.OmitTimestamps = t.OmitTimestamps
+	if t.Clock != nil {
+		mo.Tick = t.Clock
+		mo.Created = t.Clock()
+		mo.Updated = mo.Created
+	}
 }
 
 // NewTable creates a new Table with default configuration.
 func NewTable(tableName string) *Table {
 	return &Table{
-		TableName:      tableName,
-		RefIndexName:   "ref-index",
-		KeyDelimiter:   "#",
-		LabelDelimiter: "/",
-		PaginationTTL:  24 * time.Hour,
+		TableName:       tableName,
+		RefIndexName:    "ref-index",
+		KeyDelimiter:    "#",
+		LabelDelimiter:  "/",
+		TenantDelimiter: "|",
+		PaginationTTL:   24 * time.Hour,
+		SoftDeleteTTL:   30 * 24 * time.Hour,
 	}
 }
 
 // MarshalOptions contains configuration options for marshaling entities to relationships.
 type MarshalOptions struct {
-	SourceID       string        // The entity source identifier
-	SourcePrefix   string        // The entity source prefix, usually the entity type
-	TargetID       string        // The entity target identifier
-	TargetPrefix   string        // The entity target prefix, usually the entity type
-	TimeToLive     time.Duration // The lifetime of the relationship
-	Label          string        // The relationship label
-	Created        time.Time     // Creation timestamp
-	Updated        time.Time     // Modification timestamp
-	RefSortKey     string        // String that uniquely identifies this relationship on the label index
-	Tick           Clock         // Function to get current time for timestamps
-	KeyDelimiter   string        // Delimiter to join id and prefix into hash and sort keys
-	LabelDelimiter string        // Delimiter to join label segments
-	SkipRefs       bool          // If true, relationships will not be marshaled.
+	SourceID         string              // The entity source identifier
+	SourcePrefix     string              // The entity source prefix, usually the entity type
+	TargetID         string              // The entity target identifier
+	TargetPrefix     string              // The entity target prefix, usually the entity type
+	TimeToLive       time.Duration       // The lifetime of the relationship
+	Label            string              // The relationship label
+	Created          time.Time           // Creation timestamp
+	Updated          time.Time           // Modification timestamp
+	RefSortKey       string              // String that uniquely identifies this relationship on the label index
+	Tick             Clock               // Function to get current time for timestamps
+	KeyDelimiter     string              // Delimiter to join id and prefix into hash and sort keys
+	KeyCodec         KeyCodec            // Optional codec for hash and sort keys. Overrides KeyDelimiter.
+	LabelDelimiter   string              // Delimiter to join label segments
+	LabelCodec       LabelCodec          // Optional codec for relationship labels. Overrides LabelDelimiter.
+	LenientLabels    bool                // If true, unrecognized labels are passed to a FallbackUnmarshaler
+	OrderRefs        bool                // If true, UnmarshalEntity applies relationships in ref sort key order
+	LenientItems     bool                // If true, items that fail to unmarshal are skipped and reported in an UnmarshalError
+	SkipRefs         bool                // If true, relationships will not be marshaled.
+	ReturnValues     types.ReturnValue   // Attributes returned by update and delete requests
+	Aliases          []AttributeAlias    // Attribute renames applied when reading and writing items
+	PreserveCreated  bool                // If true, updates set the creation timestamp only if missing
+	OmitTimestamps   bool                // If true, the created and updated timestamps are not written
+	SortKeyFunc      SortKeyFunc         // Optional ref sort key of each relationship added by the source
+	Registry         *Registry           // Optional entity schemas used to validate relationships
+	DataCodec        DataCodec           // Optional codec that encrypts or compresses the data attribute
+	ChunkSize        int                 // Maximum encoded data size per item before splitting into chunks
+	Indexes          []SecondaryIndex    // Secondary indexes declared by the table
+	SecondaryIndexes map[string]IndexKey // Keys of the entity on secondary indexes, by index name
+	SparseAttributes map[string]string   // Attributes written only when set, by attribute name
+	TransientFields  []string            // Self relationship data attributes never written to the table
+	WriteOnlyFields  []string            // Self relationship data attributes scrubbed when read from the table
+	LabelBuckets     LabelBuckets        // Time bucketing of self relationship labels
+	LabelShards      LabelShards         // Write sharding of self relationship labels
+	TenantID         string              // Optional tenant that scopes all keys and labels
+	TenantDelimiter  string              // Delimiter between the tenant and keys or labels
+	ActorID          string              // Optional identity of who made the change, written to every item
+	RequestID        string              // Optional request that made the change, written to every item
+	Reason           string              // Optional reason for the change, written to every item
+	TimestampCodec   TimestampCodec      // Optional codec for the created, updated and deleted timestamps
+	KeyNames         KeyNames            // Names of the key attributes of the table, read as the dynamap names
+	Validator        Validator           // Optional check of entities and updaters before they are written
+
+	// RefIndexHashAttribute is an optional attribute holding a copy of the label, used as
+	// the partition key of the ref index instead of the label.
+	RefIndexHashAttribute string
+
+	// RefNameIndex is the optional name of the secondary index keyed by the ref name of
+	// every edge.
+	RefNameIndex string
 }
 
 // WithSelfTarget configures the MarshalOptions for a self-referential relationship.
@@ -109,11 +216,11 @@ func (mo *MarshalOptions) apply(opts []func(*MarshalOptions)) {
 }
 
 func (mo MarshalOptions) sourceKey() string {
-	return mo.SourcePrefix + mo.KeyDelimiter + mo.SourceID
+	return mo.keyCodec().EncodeKey(mo.SourcePrefix, mo.SourceID)
 }
 
 func (mo MarshalOptions) targetKey() string {
-	return mo.TargetPrefix + mo.KeyDelimiter + mo.TargetID
+	return mo.keyCodec().EncodeKey(mo.TargetPrefix, mo.TargetID)
 }
 
 func (mo MarshalOptions) itemKey() Item {
@@ -124,18 +231,11 @@ func (mo MarshalOptions) itemKey() Item {
 }
 
 func (mo MarshalOptions) refLabel(name string) string {
-	// label format: <source_prefix>/<source_id>/<relationship_name>
-	return mo.SourcePrefix + mo.LabelDelimiter + mo.SourceID + mo.LabelDelimiter + name
+	return mo.labelCodec().EncodeLabel(mo.SourcePrefix, mo.SourceID, name)
 }
 
 func (mo MarshalOptions) splitLabel(rel Relationship) (prefix, id, name string, err error) {
-	parts := strings.Split(rel.Label, mo.LabelDelimiter)
-	if len(parts) == 1 {
-		return parts[0], "", "", nil
-	} else if len(parts) != 3 {
-		return "", "", "", fmt.Errorf("invalid label length; should be 1 or 3")
-	}
-	return parts[0], parts[1], parts[2], nil
+	return mo.labelCodec().DecodeLabel(rel.Label)
 }
 
 // NewMarshalOptions creates a new MarshalOptions instance with default settings
@@ -148,9 +248,10 @@ func (mo MarshalOptions) splitLabel(rel Relationship) (prefix, id, name string,
 // - Created/Updated: Set to current time via Tick()
 func NewMarshalOptions(opts ...func(*MarshalOptions)) MarshalOptions {
 	options := MarshalOptions{
-		Tick:           DefaultClock,
-		KeyDelimiter:   "#",
-		LabelDelimiter: "/",
+		Tick:            DefaultClock,
+		KeyDelimiter:    "#",
+		LabelDelimiter:  "/",
+		TenantDelimiter: "|",
 	}
 	options.Created = options.Tick()
 	options.Updated = options.Tick()
@@ -183,15 +284,28 @@ func NewMarshalOptions(opts ...func(*MarshalOptions)) MarshalOptions {
 //     label GSI
 //
 // Relationship also supports create/update timestamps and optional time-to-live attributes.
+// Timestamps and expirations that are not set are omitted from the stored item.
 type Relationship struct {
-	Source    string    `dynamodbav:"hk"`                // The source entity (prefix + id)
-	Target    string    `dynamodbav:"sk"`                // The target entity (prefix + id)
-	Label     string    `dynamodbav:"label"`             // The label, which identifies the type or relationship
-	CreatedAt time.Time `dynamodbav:"created_at"`        // creation timestamp
-	UpdatedAt time.Time `dynamodbav:"updated_at"`        // modification timestamp
-	Expires   time.Time `dynamodbav:"expires,unixtime"`  // time-to-live attribute
-	Data      any       `dynamodbav:"data,omitempty"`    // relationship data
-	GSI1SK    string    `dynamodbav:"gsi1_sk,omitempty"` // sort index for the ref index
+	Source           string              `dynamodbav:"hk"`                   // The source entity (prefix + id)
+	Target           string              `dynamodbav:"sk"`                   // The target entity (prefix + id)
+	Label            string              `dynamodbav:"label"`                // The label, which identifies the type or relationship
+	CreatedAt        time.Time           `dynamodbav:"created_at"`           // creation timestamp
+	UpdatedAt        time.Time           `dynamodbav:"updated_at"`           // modification timestamp
+	Expires          time.Time           `dynamodbav:"expires,unixtime"`     // time-to-live attribute
+	Data             any                 `dynamodbav:"data,omitempty"`       // relationship data
+	GSI1SK           string              `dynamodbav:"gsi1_sk,omitempty"`    // sort index for the ref index
+	DeletedAt        *time.Time          `dynamodbav:"deleted_at,omitempty"` // soft deletion timestamp
+	ActorID          string              `dynamodbav:"actor_id,omitempty"`   // who made the last change
+	RequestID        string              `dynamodbav:"request_id,omitempty"` // request that made the last change
+	Reason           string              `dynamodbav:"reason,omitempty"`     // reason for the last change
+	SecondaryIndexes map[string]IndexKey `dynamodbav:"-"`                    // keys written to secondary indexes
+	SparseAttributes map[string]string   `dynamodbav:"-"`                    // attributes written only when set
+	RequireTarget    bool                `dynamodbav:"-"`                    // target checked for existence by transactional writes
+}
+
+// Deleted returns true if the relationship has been soft deleted.
+func (r Relationship) Deleted() bool {
+	return r.DeletedAt != nil
 }
 
 const (
@@ -203,6 +317,7 @@ const (
 	AttributeNameExpires    = "expires"
 	AttributeNameData       = "data"
 	AttributeNameRefSortKey = "gsi1_sk"
+	AttributeNameDeleted    = "deleted_at"
 )
 
 // NewRelationship creates a new relationship instance with the provided data and options.
@@ -212,7 +327,10 @@ const (
 //   - Creates a relationship with source key, target key, and label from the provided options.
 //   - Stores the provided data in the relationship.
 //   - Sets an expiry time if a TimeToLive duration is specified.
+//   - Clears the timestamps if OmitTimestamps is set; the expiry time and label buckets
+//     are still derived from the creation time.
 //   - It sets the GSI1SK (reference sort key) from the provided options.
+//   - It sets the audit attributes from the provided options.
 //
 // The function returns a new [Relationship] instance that is configured with the provided options and data.
 func NewRelationship(data any, opts MarshalOptions) Relationship {
@@ -228,17 +346,28 @@ func NewRelationship(data any, opts MarshalOptions) Relationship {
 	rel := Relationship{
 		Source:    opts.sourceKey(),
 		Target:    opts.targetKey(),
-		Label:     opts.Label,
+		Label:     opts.selfLabel(opts.Label, opts.sourceKey(), opts.Created),
 		CreatedAt: opts.Created.UTC(),
 		UpdatedAt: opts.Updated.UTC(),
 		Data:      data, // Store the entity data in the self relationship
 		GSI1SK:    opts.RefSortKey,
+		// Secondary index keys and sparse attributes are written as separate item attributes
+		SecondaryIndexes: opts.SecondaryIndexes,
+		SparseAttributes: opts.SparseAttributes,
+		// Audit attributes are written only when set
+		ActorID:   opts.ActorID,
+		RequestID: opts.RequestID,
+		Reason:    opts.Reason,
 	}
 
 	if opts.TimeToLive > 0 {
 		rel.Expires = opts.Created.Add(opts.TimeToLive)
 	}
 
+	if opts.OmitTimestamps {
+		rel.CreatedAt, rel.UpdatedAt = time.Time{}, time.Time{}
+	}
+
 	return rel
 }
 
@@ -271,16 +400,79 @@ type Ref struct {
 	Name     string // Name is the name of the relationship (e.g. "products", "orders")
 	SourceID string // SourceID is the identifier of the source entity
 	TargetID string // TargetID is the identifier of the target entity
+	Snapshot any    `dynamodbav:",omitempty"` // Snapshot is the denormalized target data, if the target is a [Snapshotter]
+	Edge     any    `dynamodbav:",omitempty"` // Edge is the payload of the relationship itself, such as a quantity or role
 }
 
 // AddOne adds a "to-one" [Relationship] to the context.
 func (r *RelationshipContext) AddOne(name string, ref Marshaler) {
+	r.AddOneWithData(name, ref, nil)
+}
+
+// AddOneWithData adds a "to-one" [Relationship] to the context that carries the edge
+// payload data, such as a quantity, role, or since-date. The payload is stored in
+// [Ref.Edge]; use [UnmarshalEdge] to read it in [RefUnmarshaler.UnmarshalRef] and
+// [EdgeAttribute] to filter on its attributes.
+func (r *RelationshipContext) AddOneWithData(name string, ref Marshaler, edge any) {
+	r.AddOneWithOptions(name, ref, func(ro *RefOptions) {
+		ro.Edge = edge
+	})
+}
+
+// SortKeyFunc returns the ref sort key of the relationship named name, written to the
+// gsi1_sk attribute, from the options of its target after [Marshaler.MarshalSelf]. Set
+// [MarshalOptions.SortKeyFunc] in the source's MarshalSelf to order the edges of a label
+// by something other than the sort key inherited from the target, such as the target's
+// creation date. An empty key keeps the inherited one.
+//
+// Example:
+//
+//	func (o *Order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+//		opts.WithSelfTarget("order", o.ID)
+//		opts.SortKeyFunc = func(name string, target dynamap.MarshalOptions) string {
+//			if name == "products" {
+//				return target.Created.Format(time.RFC3339)
+//			}
+//			return ""
+//		}
+//		return nil
+//	}
+type SortKeyFunc func(name string, target MarshalOptions) string
+
+// RefOptions contains the options of a single relationship added with
+// [RelationshipContext.AddOneWithOptions]. The options are initialized with the values
+// the relationship would otherwise have, inherited from the source and the target.
+type RefOptions struct {
+	TimeToLive time.Duration // The lifetime of the relationship. Zero never expires.
+	Created    time.Time     // Creation timestamp
+	Updated    time.Time     // Modification timestamp
+	RefSortKey string        // Sort key of the relationship on the ref index
+	Edge       any           // Optional edge payload stored in Ref.Edge
+
+	// RequireTarget makes [Table.MarshalTransactWrite] check that the target's self
+	// relationship exists, so the transaction fails instead of writing a dangling edge.
+	// Batch writes cannot carry conditions and do not check the target.
+	RequireTarget bool
+}
+
+// AddOneWithOptions adds a "to-one" [Relationship] to the context with options that
+// override those inherited from the source and target, such as a time to live for a
+// temporary access grant whose source never expires.
+//
+// Example:
+//
+//	ctx.AddOneWithOptions("grants", user, func(ro *dynamap.RefOptions) {
+//		ro.TimeToLive = 24 * time.Hour
+//	})
+func (r *RelationshipContext) AddOneWithOptions(name string, ref Marshaler, opts ...func(*RefOptions)) {
 	if r.err != nil {
 		return // Don't continue if there's already an error
 	}
 
 	// Create options for the reference
 	refOpts := r.opts
+	refOpts.SecondaryIndexes = nil // refs are not written to secondary indexes
+	refOpts.SparseAttributes = nil
 
 	// Marshal the reference to get its target information
 	if err := ref.MarshalSelf(&refOpts); err != nil {
@@ -292,17 +484,41 @@ func (r *RelationshipContext) AddOne(name string, ref Marshaler) {
 	refOpts.SourceID = r.opts.SourceID
 	refOpts.SourcePrefix = r.opts.SourcePrefix
 
-	rel := NewRelationship(
-		Ref{
-			SourceID: r.opts.SourceID,
-			TargetID: refOpts.TargetID,
-			Name:     name,
-		},
-		refOpts,
-	)
+	if r.opts.SortKeyFunc != nil {
+		if key := r.opts.SortKeyFunc(name, refOpts); key != "" {
+			refOpts.RefSortKey = key
+		}
+	}
+
+	options := RefOptions{
+		TimeToLive: refOpts.TimeToLive,
+		Created:    refOpts.Created,
+		Updated:    refOpts.Updated,
+		RefSortKey: refOpts.RefSortKey,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	refOpts.TimeToLive = options.TimeToLive
+	refOpts.Created = options.Created
+	refOpts.Updated = options.Updated
+	refOpts.RefSortKey = options.RefSortKey
+
+	data := Ref{
+		SourceID: r.opts.SourceID,
+		TargetID: refOpts.TargetID,
+		Name:     name,
+		Edge:     options.Edge,
+	}
+	if snapshotter, ok := ref.(Snapshotter); ok {
+		data.Snapshot = snapshotter.Snapshot()
+	}
+
+	rel := NewRelationship(data, refOpts)
 
 	rel.Source = r.source
 	rel.Label = refOpts.refLabel(name)
+	rel.RequireTarget = options.RequireTarget
 	r.refs = append(r.refs, rel)
 }
 
@@ -316,6 +532,82 @@ func (r *RelationshipContext) AddMany(name string, refs []Marshaler) {
 	}
 }
 
+// AddManyWithOptions adds "to-many" [Relationship] items to the context, each with the
+// options of [RelationshipContext.AddOneWithOptions].
+func (r *RelationshipContext) AddManyWithOptions(name string, refs []Marshaler, opts ...func(*RefOptions)) {
+	for _, ref := range refs {
+		r.AddOneWithOptions(name, ref, opts...)
+		if r.err != nil {
+			return // Stop on first error
+		}
+	}
+}
+
+// AddOneInverse adds a "to-one" [Relationship] to the context, along with its mirrored
+// relationship named inverse from the target back to the source. The inverse item is
+// stored in the target partition, so both directions can be queried without a reverse
+// index.
+func (r *RelationshipContext) AddOneInverse(name, inverse string, ref Marshaler) {
+	r.AddOne(name, ref)
+	if r.err != nil {
+		return
+	}
+
+	rel, err := r.opts.inverseRef(r.refs[len(r.refs)-1], inverse)
+	if err != nil {
+		r.err = fmt.Errorf("failed to marshal inverse %s: %w", inverse, err)
+		return
+	}
+	r.refs = append(r.refs, rel)
+}
+
+// AddManyInverse adds "to-many" [Relationship] items to the context, along with their
+// mirrored relationships named inverse. See [RelationshipContext.AddOneInverse].
+func (r *RelationshipContext) AddManyInverse(name, inverse string, refs []Marshaler) {
+	for _, ref := range refs {
+		r.AddOneInverse(name, inverse, ref)
+		if r.err != nil {
+			return // Stop on first error
+		}
+	}
+}
+
+// AddManyOrdered adds "to-many" [Relationship] items to the context, assigning each one
+// the ref sort key of its position in refs. Ordered relationships are returned in position
+// order when querying the ref index by their label, or when unmarshaling with
+// [MarshalOptions.OrderRefs] set. Use [Table.Reorder] to rewrite the positions.
+func (r *RelationshipContext) AddManyOrdered(name string, refs []Marshaler) {
+	for i, ref := range refs {
+		r.AddOne(name, ref)
+		if r.err != nil {
+			return // Stop on first error
+		}
+		r.refs[len(r.refs)-1].GSI1SK = PositionKey(i)
+	}
+}
+
+// inverseRef returns the relationship named name that mirrors rel, from its target back
+// to the source described by the options.
+func (mo MarshalOptions) inverseRef(rel Relationship, name string) (Relationship, error) {
+	prefix, id, err := mo.keyCodec().DecodeKey(rel.Target)
+	if err != nil {
+		return rel, err
+	}
+
+	inverse := rel
+	inverse.Source, inverse.Target = rel.Target, rel.Source
+	inverse.Label = mo.labelCodec().EncodeLabel(prefix, id, name)
+	inverse.GSI1SK = mo.RefSortKey
+	inverse.RequireTarget = false
+	inverse.Data = Ref{
+		SourceID: id,
+		TargetID: mo.SourceID,
+		Name:     name,
+	}
+
+	return inverse, nil
+}
+
 // SliceOf is a convenience function for converting marshalers of a specific
 // type into a slice of [Marshaler].
 func SliceOf[T Marshaler](in ...T) []Marshaler {
@@ -329,16 +621,26 @@ func SliceOf[T Marshaler](in ...T) []Marshaler {
 // MarshalRelationships marshals the input into a list of relationships. The successful
 // result of this function will always contain at least one Relationship, which represents
 // the self relationship of the entity. If in is a RefMarshaler, then the result will contain
-// additional "to-one" and "to-many" relationships.
+// additional "to-one" and "to-many" relationships. If a [Registry] is set, each relationship
+// is validated against it. If a [Validator] is set, it is called with in before the
+// relationships are marshaled.
 func MarshalRelationships(in Marshaler, opts ...func(*MarshalOptions)) ([]Relationship, error) {
 	// Create default options
 	marshalOpts := NewMarshalOptions(opts...)
 
+	if err := beforeMarshal(in); err != nil {
+		return nil, err
+	}
+
 	// Marshal self relationship
 	if err := in.MarshalSelf(&marshalOpts); err != nil {
 		return nil, fmt.Errorf("failed to marshal self: %w", err)
 	}
 
+	if err := marshalOpts.validateEntity(in); err != nil {
+		return nil, err
+	}
+
 	self := NewRelationship(in, marshalOpts)
 	relationships := []Relationship{self}
 
@@ -360,6 +662,10 @@ func MarshalRelationships(in Marshaler, opts ...func(*MarshalOptions)) ([]Relati
 		relationships = append(relationships, ctx.refs...)
 	}
 
+	if err := marshalOpts.validate(relationships...); err != nil {
+		return nil, err
+	}
+
 	return relationships, nil
 }
 
@@ -384,28 +690,48 @@ type RefUnmarshaler interface {
 // UnmarshalSelf extracts the data out of item, unmarshals it to out, then
 // unmarshals the entire item to a [Relationship]. The item is assumed to
 // be a self-relationship.
-func UnmarshalSelf(item Item, out any) (Relationship, error) {
+func UnmarshalSelf(item Item, out any, opts ...func(*MarshalOptions)) (Relationship, error) {
 	var rel Relationship
+	if _, ok := item[AttributeNameChunks]; ok {
+		return rel, fmt.Errorf("%w; use UnmarshalEntity with all items in the partition", ErrChunkedItem)
+	}
+	if _, ok := item[AttributeNameBlob]; ok {
+		return rel, fmt.Errorf("%w; use Table.HydrateItems before unmarshaling", ErrBlobNotLoaded)
+	}
+	marshalOpts := NewMarshalOptions(opts...)
+	if len(opts) > 0 {
+		decoded, err := marshalOpts.unmarshalItem(item)
+		if err != nil {
+			return rel, err
+		}
+		item = decoded
+	}
+
 	if err := attributevalue.UnmarshalMap(item, &rel); err != nil {
 		return rel, fmt.Errorf("failed to unmarshal relationship: %w", err)
 	}
 
-	if data, ok := item[AttributeNameData]; !ok {
+	data, ok := item[AttributeNameData]
+	if !ok {
 		return rel, fmt.Errorf("data attribute not found")
-	} else if err := attributevalue.Unmarshal(data, &out); err != nil {
-		return rel, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
-	unmarshaler, ok := out.(Unmarshaler)
-	if !ok {
-		return rel, nil
+	// Write-only fields never leave the table
+	if rel.IsSelf() {
+		data = scrubData(data, marshalOpts.writeOnlyFields(out))
 	}
 
-	if err := unmarshaler.UnmarshalSelf(&rel); err != nil {
-		return rel, fmt.Errorf("failed to unmarshal self: %w", err)
+	if err := attributevalue.Unmarshal(data, &out); err != nil {
+		return rel, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
-	return rel, nil
+	if unmarshaler, ok := out.(Unmarshaler); ok {
+		if err := unmarshaler.UnmarshalSelf(&rel); err != nil {
+			return rel, fmt.Errorf("failed to unmarshal self: %w", err)
+		}
+	}
+
+	return rel, afterUnmarshal(out)
 }
 
 // UnmarshalTableKey extracts and unmarshals the source and target keys from a DynamoDB item.
@@ -429,76 +755,230 @@ func UnmarshalTableKey(item Item) (source, target string, err error) {
 	return source, target, err
 }
 
+// StringValue returns the value of a string attribute, such as the hash or sort key of
+// an item, or an empty string if the attribute is missing or not a string.
+func StringValue(value types.AttributeValue) string {
+	if s, ok := value.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+// UnmarshalRelationship unmarshals an item of any kind into its [Relationship], renaming
+// aliased attributes and decoding the data attribute with the options. The relationship
+// data is left as a generic value, such as a map[string]any for entity data.
+func UnmarshalRelationship(item Item, opts ...func(*MarshalOptions)) (Relationship, error) {
+	var rel Relationship
+	decoded, err := NewMarshalOptions(opts...).unmarshalItem(item)
+	if err != nil {
+		return rel, err
+	}
+	if err := attributevalue.UnmarshalMap(decoded, &rel); err != nil {
+		return rel, fmt.Errorf("failed to unmarshal relationship: %w", err)
+	}
+	return rel, nil
+}
+
 // UnmarshalEntity unmarshals data to out from each item in items, where:
 //   - self relationships are applied via [UnmarshalSelf], and
 //   - other relationships are applied via [RefUnmarshaler.UnmarshalRef].
 //
-// This function is usually called to extract results from a QueryEntity.
+// This function is usually called to extract results from a QueryEntity. If out implements
+// [SummaryUnmarshaler], it also receives an [EntitySummary] of the items. If a [Registry] is
+// set, each relationship is validated against it. If [MarshalOptions.LenientLabels] is set,
+// relationships with unrecognized labels are passed to [FallbackUnmarshaler.UnmarshalUnknown]
+// when out implements it, or skipped otherwise. If [MarshalOptions.OrderRefs] is set, the
+// relationships are applied in ref sort key order, such as the positions assigned by
+// [RelationshipContext.AddManyOrdered]. If [MarshalOptions.LenientItems] is set, items that
+// fail to unmarshal are skipped and reported in an [*UnmarshalError] returned along with the
+// relationships of the other items.
 func UnmarshalEntity(items []Item, out RefUnmarshaler, opts ...func(*MarshalOptions)) ([]Relationship, error) {
+	return unmarshalEntity(items, out, false, opts...)
+}
+
+func unmarshalEntity(items []Item, out RefUnmarshaler, truncated bool, opts ...func(*MarshalOptions)) ([]Relationship, error) {
 	if len(items) == 0 {
 		return nil, ErrItemNotFound
 	}
 
+	marshalOpts := NewMarshalOptions(opts...)
+	if marshalOpts.KeyNames.IsSet() {
+		items = renameItems(items, marshalOpts.KeyNames.inverse())
+	}
+
+	items, err := assembleChunks(items)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
-		marshalOpts   = NewMarshalOptions(opts...)
 		relationships []Relationship
+		skipped       UnmarshalError
 	)
 
+	if marshalOpts.OrderRefs {
+		items = marshalOpts.sortByRefSortKey(items)
+	}
+
 	for _, item := range items {
-		source, target, err := UnmarshalTableKey(item)
+		rel, ok, err := marshalOpts.unmarshalEntityItem(item, out, opts)
+		if err != nil && marshalOpts.LenientItems {
+			skipped.add(item, err)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if ok {
+			relationships = append(relationships, rel)
+		}
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+	if err := afterUnmarshal(out); err != nil {
+		return nil, err
+	}
+
+	if summarizer, ok := out.(SummaryUnmarshaler); ok {
+		summary := marshalOpts.summarize(relationships)
+		summary.Truncated = truncated
+		if err := summarizer.UnmarshalSummary(&summary); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal summary: %w", err)
 		}
+	}
 
-		// Check if this is a self relationship
-		if source == target {
-			if rel, err := UnmarshalSelf(item, &out); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal self: %w", err)
-			} else {
-				relationships = append(relationships, rel)
-			}
-		} else {
-			data := Ref{}
-			rel, err := UnmarshalSelf(item, &data)
-			if err != nil {
-				return nil, fmt.Errorf("failed to unmarshal relationship: %w", err)
-			}
+	return relationships, skipped.err()
+}
 
-			// Extract relationship name from label
-			// Format: "<source_prefix>/<source_id>/<relationship_name>"
-			_, id, name, err := marshalOpts.splitLabel(rel)
-			if err != nil {
-				return nil, fmt.Errorf("invalid label format: %s", rel.Label)
-			}
+// unmarshalEntityItem applies item to out as described by [UnmarshalEntity]. It returns
+// false if the item is not a relationship of the entity, such as an aggregate event.
+func (mo MarshalOptions) unmarshalEntityItem(item Item, out RefUnmarshaler, opts []func(*MarshalOptions)) (Relationship, bool, error) {
+	// Aggregate events and snapshots are read by UnmarshalAggregate
+	if mo.logPrefix(item) != "" {
+		return Relationship{}, false, nil
+	}
 
-			if err := out.UnmarshalRef(name, id, &rel); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal ref %s: %w", name, err)
-			}
+	source, target, err := UnmarshalTableKey(item)
+	if err != nil {
+		return Relationship{}, false, fmt.Errorf("failed to unmarshal table key: %w", err)
+	}
 
-			relationships = append(relationships, rel)
+	// Check if this is a self relationship
+	if source == target {
+		rel, err := UnmarshalSelf(item, &out, opts...)
+		if err != nil {
+			return rel, false, fmt.Errorf("failed to unmarshal self: %w", err)
 		}
+		if err := mo.validate(rel); err != nil {
+			return rel, false, err
+		}
+		return rel, true, nil
 	}
 
-	return relationships, nil
+	if rel, ok, err := mo.unmarshalUnknown(item, out); err != nil || ok {
+		return rel, ok, err
+	}
+
+	data := Ref{}
+	rel, err := UnmarshalSelf(item, &data, opts...)
+	if err != nil {
+		return rel, false, fmt.Errorf("failed to unmarshal relationship: %w", err)
+	}
+	if err := mo.validate(rel); err != nil {
+		return rel, false, err
+	}
+
+	// Extract relationship name from label
+	// Format: "<source_prefix>/<source_id>/<relationship_name>"
+	_, id, name, err := mo.splitLabel(rel)
+	if err != nil {
+		return rel, false, fmt.Errorf("invalid label format: %s", rel.Label)
+	}
+
+	if err := out.UnmarshalRef(name, id, &rel); err != nil {
+		return rel, false, fmt.Errorf("failed to unmarshal ref %s: %w", name, err)
+	}
+
+	return rel, true, nil
 }
 
 // UnmarshalList calls [UnmarshalSelf] on each item in items and stores the result in out.
-// This function is usually called to extract results from [QueryList].
-func UnmarshalList[T any](items []Item, out *[]T) ([]Relationship, error) {
-	var relationships []Relationship
+// This function is usually called to extract results from [QueryList]. If
+// [MarshalOptions.LenientItems] is set, items that fail to unmarshal are skipped and reported
+// in an [*UnmarshalError] returned along with the other results.
+func UnmarshalList[T any](items []Item, out *[]T, opts ...func(*MarshalOptions)) ([]Relationship, error) {
+	var (
+		relationships []Relationship
+		skipped       UnmarshalError
+		lenient       = NewMarshalOptions(opts...).LenientItems
+	)
 
 	for i, item := range items {
 		var value T
-		rel, err := UnmarshalSelf(item, &value)
-		if err != nil {
+		rel, err := UnmarshalSelf(item, &value, opts...)
+		if err != nil && lenient {
+			skipped.add(item, err)
+			continue
+		} else if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal item %d: %w", i, err)
 		}
 		*out = append(*out, value)
 		relationships = append(relationships, rel)
 	}
 
-	return relationships, nil
+	return relationships, skipped.err()
+}
+
+// UnmarshalStream calls fn with each item in items and its relationship, without building
+// a slice of the results. The relationship is unmarshaled with [UnmarshalLazy], so its data
+// is nil; fn decodes the data it needs from the item, such as with [UnmarshalSelf].
+// Iteration stops at the first error returned by fn, which is returned.
+//
+// Example:
+//
+//	err := dynamap.UnmarshalStream(result.Items, func(rel dynamap.Relationship, item dynamap.Item) error {
+//		var product Product
+//		if _, err := dynamap.UnmarshalSelf(item, &product, table.MarshalOptions); err != nil {
+//			return err
+//		}
+//		return encoder.Encode(product)
+//	}, table.MarshalOptions)
+func UnmarshalStream(items []Item, fn func(rel Relationship, item Item) error, opts ...func(*MarshalOptions)) error {
+	for i, item := range items {
+		lazy, err := UnmarshalLazy(item, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal item %d: %w", i, err)
+		}
+		if err := fn(lazy.Relationship, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalSeq returns an iterator that calls [UnmarshalSelf] on each item in items as it
+// is ranged over, yielding the unmarshaled value. If an item fails to unmarshal, the error
+// is yielded and iteration stops.
+//
+// Example:
+//
+//	for product, err := range dynamap.UnmarshalSeq[Product](result.Items, table.MarshalOptions) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(product.ID)
+//	}
+func UnmarshalSeq[T any](items []Item, opts ...func(*MarshalOptions)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for i, item := range items {
+			var value T
+			if _, err := UnmarshalSelf(item, &value, opts...); err != nil {
+				yield(value, fmt.Errorf("failed to unmarshal item %d: %w", i, err))
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
 }
 
 // DynamoDBClient interface for easier testing and connection management.
@@ -510,3 +990,52 @@ type DynamoDBClient interface {
 	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
 	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 }
+
+// ScanClient is the subset of the DynamoDB client used to scan tables.
+type ScanClient interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// MaintenanceClient is the subset of the DynamoDB client used by the table maintenance
+// utilities and the migrations package, which scan the table and write the items they
+// fix.
+type MaintenanceClient interface {
+	DynamoDBClient
+	ScanClient
+}
+
+// BatchGetClient is implemented by clients that support batch reads, such as the DynamoDB
+// client. [Table.BatchGet] requires the [DynamoDBClient] it is given to implement it.
+type BatchGetClient interface {
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+// TransactWriteClient is implemented by clients that support transactions, such as the
+// DynamoDB client. Transactional operations, such as transactional [Table.Attach] or
+// writes with [Table.History] set, require the [DynamoDBClient] they are given to
+// implement it.
+type TransactWriteClient interface {
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// ErrUnsupportedOperation is returned when a [DynamoDBClient] does not implement an
+// operation a request requires, such as [TransactWriteClient].
+var ErrUnsupportedOperation = newError(ErrValidation, "operation not supported by the client")
+
+// batchGetItem sends the batch get request if the client implements [BatchGetClient].
+func batchGetItem(ctx context.Context, client DynamoDBClient, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c, ok := client.(BatchGetClient)
+	if !ok {
+		return nil, fmt.Errorf("%w: BatchGetItem", ErrUnsupportedOperation)
+	}
+	return c.BatchGetItem(ctx, params, optFns...)
+}
+
+// transactWriteItems sends the transaction if the client implements [TransactWriteClient].
+func transactWriteItems(ctx context.Context, client DynamoDBClient, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c, ok := client.(TransactWriteClient)
+	if !ok {
+		return nil, fmt.Errorf("%w: TransactWriteItems", ErrUnsupportedOperation)
+	}
+	return c.TransactWriteItems(ctx, params, optFns...)
+}
@@ -1,6 +1,8 @@
 package dynamap
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -81,6 +83,9 @@ func TestNewTable(t *testing.T) {
 	if table.PaginationTTL != 24*time.Hour {
 		t.Errorf("Expected pagination TTL 24h, got %v", table.PaginationTTL)
 	}
+	if table.SoftDeleteTTL != 30*24*time.Hour {
+		t.Errorf("Expected soft delete TTL 30 days, got %v", table.SoftDeleteTTL)
+	}
 }
 
 func TestDefaultClock(t *testing.T) {
@@ -90,6 +95,65 @@ func TestDefaultClock(t *testing.T) {
 	}
 }
 
+func TestTableClock(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.Clock = func() time.Time { return now }
+
+	t.Run("puts", func(t *testing.T) {
+		input, err := table.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		rel, err := UnmarshalSelf(input.Item, &Product{})
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if !rel.CreatedAt.Equal(now) || !rel.UpdatedAt.Equal(now) {
+			t.Errorf("Expected timestamps at %v, got %v and %v", now, rel.CreatedAt, rel.UpdatedAt)
+		}
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		input, err := table.MarshalUpdate(&Product{ID: "P1"}, Increment("stock", 1))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		var found bool
+		for _, value := range input.ExpressionAttributeValues {
+			if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value == now.Format(time.RFC3339) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected updated_at at %v, got %v", now, input.ExpressionAttributeValues)
+		}
+	})
+
+	t.Run("pagination cursors", func(t *testing.T) {
+		ctx := context.Background()
+		client := newMockDynamoDBClient()
+		paginator := table.Paginator(client)
+
+		cursor, err := paginator.PageCursor(ctx, Item{"hk": &types.AttributeValueMemberS{Value: "product#P1"}})
+		if err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+		for _, item := range client.items {
+			var expires int64
+			if err := attributevalue.Unmarshal(item[AttributeNameExpires], &expires); err != nil || expires != now.Add(table.PaginationTTL).Unix() {
+				t.Errorf("Expected the cursor to expire after the pagination TTL, got %d", expires)
+			}
+		}
+
+		now = now.Add(table.PaginationTTL + time.Second)
+		key, err := paginator.StartKey(ctx, cursor)
+		if !errors.Is(err, ErrCursorExpired) || key != nil {
+			t.Errorf("Expected ErrCursorExpired for the expired cursor, got %v, %v", key, err)
+		}
+	})
+}
+
 func TestSliceOf(t *testing.T) {
 	products := []*Product{
 		{ID: "P1", Category: "electronics"},
@@ -333,6 +397,99 @@ func TestRelationshipContext(t *testing.T) {
 			t.Errorf("Expected 2 references, got %d", len(ctx.refs))
 		}
 	})
+
+	t.Run("AddOneInverse", func(t *testing.T) {
+		ctx.refs = nil // Reset
+		ctx.AddOneInverse("products", "orders", &Product{ID: "P1"})
+
+		if ctx.err != nil {
+			t.Fatalf("Unexpected error: %v", ctx.err)
+		}
+
+		if len(ctx.refs) != 2 {
+			t.Fatalf("Expected 2 references, got %d", len(ctx.refs))
+		}
+
+		inverse := ctx.refs[1]
+		if inverse.Source != "product#P1" || inverse.Target != "order#O1" {
+			t.Errorf("Expected inverse key product#P1/order#O1, got %s/%s", inverse.Source, inverse.Target)
+		}
+		if inverse.Label != "product/P1/orders" {
+			t.Errorf("Expected label 'product/P1/orders', got %s", inverse.Label)
+		}
+		if ref := inverse.Data.(Ref); ref.SourceID != "P1" || ref.TargetID != "O1" || ref.Name != "orders" {
+			t.Errorf("Expected mirrored ref data, got %+v", ref)
+		}
+	})
+
+	t.Run("AddOneWithOptions", func(t *testing.T) {
+		ctx.refs = nil // Reset
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		ctx.AddOneWithOptions("products", &Product{ID: "P1", Category: "books"}, func(ro *RefOptions) {
+			if ro.RefSortKey != "books" {
+				t.Errorf("Expected inherited ref sort key 'books', got %s", ro.RefSortKey)
+			}
+			ro.TimeToLive = time.Hour
+			ro.Created = created
+			ro.RefSortKey = "2025-01-01"
+			ro.Edge = orderLine{Quantity: 2}
+		})
+		ctx.AddOne("products", &Product{ID: "P2"})
+
+		if ctx.err != nil {
+			t.Fatalf("Unexpected error: %v", ctx.err)
+		}
+
+		ref := ctx.refs[0]
+		if !ref.Expires.Equal(created.Add(time.Hour)) {
+			t.Errorf("Expected expiry %v, got %v", created.Add(time.Hour), ref.Expires)
+		}
+		if !ref.CreatedAt.Equal(created) || ref.GSI1SK != "2025-01-01" {
+			t.Errorf("Expected overridden timestamp and ref sort key, got %v and %s", ref.CreatedAt, ref.GSI1SK)
+		}
+		if ref.Data.(Ref).Edge == nil {
+			t.Error("Expected edge payload")
+		}
+		if !ctx.refs[1].Expires.IsZero() {
+			t.Errorf("Expected other refs not to expire, got %v", ctx.refs[1].Expires)
+		}
+	})
+
+	t.Run("SortKeyFunc", func(t *testing.T) {
+		ctx.refs = nil // Reset
+		ctx.opts.SortKeyFunc = func(name string, target MarshalOptions) string {
+			if target.TargetID == "P2" {
+				return ""
+			}
+			return name + "#" + target.TargetID
+		}
+		defer func() { ctx.opts.SortKeyFunc = nil }()
+
+		ctx.AddMany("products", SliceOf(&Product{ID: "P1", Category: "books"}, &Product{ID: "P2", Category: "games"}))
+
+		if got := ctx.refs[0].GSI1SK; got != "products#P1" {
+			t.Errorf("Expected derived ref sort key 'products#P1', got %s", got)
+		}
+		if got := ctx.refs[1].GSI1SK; got != "games" {
+			t.Errorf("Expected inherited ref sort key 'games', got %s", got)
+		}
+	})
+
+	t.Run("AddManyWithOptions", func(t *testing.T) {
+		ctx.refs = nil // Reset
+		ctx.AddManyWithOptions("products", SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"}), func(ro *RefOptions) {
+			ro.TimeToLive = time.Minute
+		})
+
+		if len(ctx.refs) != 2 {
+			t.Fatalf("Expected 2 references, got %d", len(ctx.refs))
+		}
+		for _, ref := range ctx.refs {
+			if ref.Expires.IsZero() {
+				t.Errorf("Expected %s to expire", ref.Target)
+			}
+		}
+	})
 }
 
 func TestUnmarshalSelf(t *testing.T) {
@@ -447,6 +604,27 @@ func TestUnmarshalTableKey(t *testing.T) {
 	})
 }
 
+func TestUnmarshalRelationship(t *testing.T) {
+	table := NewTable("test-table")
+	table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}}
+
+	input, err := table.MarshalPut(&Product{ID: "P1", Category: "books"})
+	if err != nil {
+		t.Fatalf("Failed to marshal put: %v", err)
+	}
+
+	rel, err := UnmarshalRelationship(input.Item, table.MarshalOptions)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal relationship: %v", err)
+	}
+	if rel.Source != "product#P1" || rel.GSI1SK != "books" {
+		t.Errorf("Expected the aliased relationship, got %+v", rel)
+	}
+	if data, ok := rel.Data.(map[string]any); !ok || data["category"] != "books" {
+		t.Errorf("Expected generic entity data, got %#v", rel.Data)
+	}
+}
+
 func TestUnmarshalEntity(t *testing.T) {
 	t.Run("empty items", func(t *testing.T) {
 		var order Order
@@ -592,3 +770,71 @@ func TestUnmarshalList(t *testing.T) {
 		}
 	})
 }
+
+func TestUnmarshalStream(t *testing.T) {
+	table := NewTable("test-table")
+	var items []Item
+	for _, product := range []*Product{{ID: "P1", Category: "books"}, {ID: "P2", Category: "games"}} {
+		input, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		items = append(items, input.Item)
+	}
+
+	t.Run("callback", func(t *testing.T) {
+		var targets []string
+		err := UnmarshalStream(items, func(rel Relationship, item Item) error {
+			targets = append(targets, rel.Target)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to stream items: %v", err)
+		}
+		if len(targets) != 2 || targets[1] != "product#P2" {
+			t.Errorf("Expected both products, got %v", targets)
+		}
+	})
+
+	t.Run("callback error stops the stream", func(t *testing.T) {
+		var calls int
+		stop := errors.New("stop")
+		err := UnmarshalStream(items, func(rel Relationship, item Item) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("Expected the callback error after one call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var products []Product
+		for product, err := range UnmarshalSeq[Product](items, table.MarshalOptions) {
+			if err != nil {
+				t.Fatalf("Failed to unmarshal product: %v", err)
+			}
+			products = append(products, product)
+		}
+		if len(products) != 2 || products[0].Category != "books" {
+			t.Errorf("Expected both products, got %+v", products)
+		}
+
+		for range UnmarshalSeq[Product](items) {
+			break // Stopping early must not panic
+		}
+	})
+
+	t.Run("iterator error", func(t *testing.T) {
+		invalid := []Item{{"hk": &types.AttributeValueMemberS{Value: "product#P1"}}}
+		var errs int
+		for _, err := range UnmarshalSeq[Product](invalid) {
+			if err != nil {
+				errs++
+			}
+		}
+		if errs != 1 {
+			t.Errorf("Expected one error, got %d", errs)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// ErrNoCounter is returned when recounting a relationship without a configured counter.
+var ErrNoCounter = newError(ErrValidation, "no counter configured")
+
+// IncrementCounter creates an [Updater] that atomically adds n to the counter attribute of
+// the self relationship, such as the counters configured in [Table.Counters]. A missing
+// counter is treated as zero; use a negative n to decrement.
+func IncrementCounter(attribute string, n int) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Add(expression.Name(attribute), expression.Value(n))
+	})
+}
+
+// SetCounter creates an [Updater] that sets the counter attribute of the self relationship to n.
+func SetCounter(attribute string, n int) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Set(expression.Name(attribute), expression.Value(n))
+	})
+}
+
+// Recount repairs the counter configured in [Table.Counters] for the relationships named
+// name by counting the edges in the source partition and writing the result to the
+// source self relationship. The count is returned.
+func (t *Table) Recount(ctx context.Context, client DynamoDBClient, source Marshaler, name string, opts ...func(*MarshalOptions)) (int, error) {
+	client = t.client(client)
+	attribute, ok := t.Counters[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNoCounter, name)
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(contextOptions(ctx, opts)) // Update applies the context options itself
+		mo.SkipRefs = true
+	})
+	if err := source.MarshalSelf(&marshalOpts); err != nil {
+		return 0, fmt.Errorf("failed to marshal self: %w", err)
+	}
+
+	items, err := t.queryPartition(ctx, client, marshalOpts.sourceKey())
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, item := range items {
+		rel, err := UnmarshalSelf(item, &Ref{}, t.MarshalOptions)
+		if err != nil || rel.Source == rel.Target {
+			continue
+		}
+		if _, _, refName, err := marshalOpts.splitLabel(rel); err == nil && refName == name {
+			count++
+		}
+	}
+
+	if _, err := t.Update(ctx, client, source, UpdateWithCondition(SetCounter(attribute, count), itemExists()), opts...); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
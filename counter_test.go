@@ -0,0 +1,106 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// counterClient records update requests.
+type counterClient struct {
+	*partitionClient
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *counterClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, params)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// Tests for counters
+
+func TestCounters(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.Counters = map[string]string{"products": "product_count"}
+	order := &Order{ID: "O1"}
+
+	t.Run("attach maintains counter", func(t *testing.T) {
+		transactions, err := table.MarshalTransactAttach(order, "products", SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"}))
+		if err != nil {
+			t.Fatalf("Failed to marshal attach: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		update := actions[len(actions)-1].Update
+		if update == nil || !strings.HasPrefix(aws.ToString(update.UpdateExpression), "ADD") {
+			t.Fatalf("Expected counter update, got %+v", actions[len(actions)-1])
+		}
+		if name := update.ExpressionAttributeNames["#0"]; name != "product_count" {
+			t.Errorf("Expected product_count, got %s", name)
+		}
+	})
+
+	t.Run("attach uses transactions", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		if err := table.Attach(ctx, client, order, "products", SliceOf(&Product{ID: "P1"})); err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		if _, ok := client.items["order#O1#product#P1"]; !ok {
+			t.Error("Expected edge item")
+		}
+	})
+
+	t.Run("increment updater", func(t *testing.T) {
+		input, err := table.MarshalUpdate(order, IncrementCounter("product_count", -1))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.UpdateExpression), "ADD") {
+			t.Errorf("Expected ADD expression, got %s", aws.ToString(input.UpdateExpression))
+		}
+	})
+
+	t.Run("recount", func(t *testing.T) {
+		client := &counterClient{partitionClient: newPartitionClient()}
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}, {ID: "P3"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		if err := batchWriteAll(ctx, client, batches); err != nil {
+			t.Fatalf("Failed to write order: %v", err)
+		}
+
+		n, err := table.Recount(ctx, client, order, "products")
+		if err != nil {
+			t.Fatalf("Failed to recount: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("Expected count 3, got %d", n)
+		}
+
+		if len(client.updates) != 1 {
+			t.Fatalf("Expected 1 update, got %d", len(client.updates))
+		}
+		var found bool
+		for _, value := range client.updates[0].ExpressionAttributeValues {
+			if n, ok := value.(*types.AttributeValueMemberN); ok && n.Value == "3" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Expected counter to be set to 3")
+		}
+	})
+
+	t.Run("no counter", func(t *testing.T) {
+		if _, err := table.Recount(ctx, newMockDynamoDBClient(), order, "suppliers"); !errors.Is(err, ErrNoCounter) {
+			t.Errorf("Expected ErrNoCounter, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CursorStore stores the page cursors generated by a [TablePaginator]. Implementations
+// must be safe for concurrent use.
+type CursorStore interface {
+	// SaveCursor stores cursor, expiring it after ttl.
+	SaveCursor(ctx context.Context, cursor *PageCursor, ttl time.Duration) error
+	// LoadCursor returns the cursor with the id. [ErrCursorExpired] is returned if the
+	// cursor is unknown or has expired.
+	LoadCursor(ctx context.Context, id string) (*PageCursor, error)
+}
+
+// TableCursorStore implements [CursorStore] by storing cursors as self-relationships in a
+// dynamodb table. This is the default store of [Table.Paginator]; to keep cursors apart
+// from the data they page through, use a store backed by a separate table. Expired cursors
+// of the table are removed with [Table.PurgeExpiredCursors].
+type TableCursorStore struct {
+	Table  *Table         // table configuration of the cursors
+	Client DynamoDBClient // dynamodb client
+}
+
+// NewTableCursorStore returns a store keeping cursors in table.
+func NewTableCursorStore(table *Table, client DynamoDBClient) *TableCursorStore {
+	return &TableCursorStore{
+		Table:  table,
+		Client: table.client(client),
+	}
+}
+
+// SaveCursor implements CursorStore.
+func (s *TableCursorStore) SaveCursor(ctx context.Context, cursor *PageCursor, ttl time.Duration) error {
+	putInput, err := s.Table.MarshalPut(cursor, func(opts *MarshalOptions) {
+		opts.TimeToLive = ttl
+		opts.Registry = nil // Cursors are internal and not registered entities
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal page cursor: %w", err)
+	}
+
+	_, err = s.Table.client(s.Client).PutItem(ctx, putInput)
+	if err != nil {
+		return fmt.Errorf("failed to store page cursor: %w", classify(err))
+	}
+	return nil
+}
+
+// LoadCursor implements CursorStore.
+func (s *TableCursorStore) LoadCursor(ctx context.Context, id string) (*PageCursor, error) {
+	cursor := &PageCursor{Cursor: id}
+
+	getInput, err := s.Table.MarshalGet(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal get request: %w", err)
+	}
+
+	result, err := s.Table.client(s.Client).GetItem(ctx, getInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page cursor: %w", classify(err))
+	}
+
+	marshalOpts := NewMarshalOptions(s.Table.MarshalOptions)
+	if len(result.Item) == 0 || isExpired(result.Item, marshalOpts.aliasName(AttributeNameExpires), marshalOpts.Tick()) {
+		return nil, ErrCursorExpired
+	}
+
+	_, err = UnmarshalSelf(result.Item, cursor, s.Table.MarshalOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal page cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+// MemoryCursorStore implements [CursorStore] by keeping cursors in process memory. It suits
+// tests and single instance services; cursors are lost on restart and are not shared
+// between instances. Expired cursors are removed as they are loaded or by [MemoryCursorStore.Purge].
+//
+// The zero value is ready for use.
+type MemoryCursorStore struct {
+	// Clock returns the current time. Defaults to time.Now.
+	Clock func() time.Time
+
+	mu      sync.Mutex
+	cursors map[string]memoryCursor
+}
+
+// memoryCursor is a cursor kept by a MemoryCursorStore.
+type memoryCursor struct {
+	cursor  PageCursor
+	expires time.Time // zero if the cursor does not expire
+}
+
+// NewMemoryCursorStore returns an empty in-memory cursor store.
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{}
+}
+
+// SaveCursor implements CursorStore. A ttl of zero or less keeps the cursor until the
+// store is discarded.
+func (s *MemoryCursorStore) SaveCursor(ctx context.Context, cursor *PageCursor, ttl time.Duration) error {
+	stored := memoryCursor{cursor: *cursor}
+	if ttl > 0 {
+		stored.expires = s.now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursors == nil {
+		s.cursors = make(map[string]memoryCursor)
+	}
+	s.cursors[cursor.Cursor] = stored
+	return nil
+}
+
+// LoadCursor implements CursorStore.
+func (s *MemoryCursorStore) LoadCursor(ctx context.Context, id string) (*PageCursor, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.cursors[id]
+	if !ok {
+		return nil, ErrCursorExpired
+	}
+	if stored.expired(now) {
+		delete(s.cursors, id)
+		return nil, ErrCursorExpired
+	}
+	cursor := stored.cursor
+	return &cursor, nil
+}
+
+// Purge removes the expired cursors of the store, returning the number removed.
+func (s *MemoryCursorStore) Purge() int {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for id, stored := range s.cursors {
+		if stored.expired(now) {
+			delete(s.cursors, id)
+			n++
+		}
+	}
+	return n
+}
+
+// Len returns the number of cursors in the store, including expired cursors not yet purged.
+func (s *MemoryCursorStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cursors)
+}
+
+func (s *MemoryCursorStore) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock()
+	}
+	return time.Now()
+}
+
+func (c memoryCursor) expired(now time.Time) bool {
+	return !c.expires.IsZero() && !now.Before(c.expires)
+}
@@ -0,0 +1,187 @@
+package dynamap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DataCodec transforms the data attribute before it is written and after it is read.
+// Set [Table.DataCodec] to encrypt or compress entity data on the client; the data is
+// transparently decoded by [UnmarshalSelf], [UnmarshalList] and [UnmarshalEntity] when
+// the table options are provided.
+//
+// Encoded data is opaque to DynamoDB, so update and filter expressions cannot reference
+// fields within the data attribute.
+type DataCodec interface {
+	// Encode transforms the marshaled data attribute into its stored form.
+	Encode(data types.AttributeValue) (types.AttributeValue, error)
+	// Decode transforms the stored data attribute back into its marshaled form.
+	Decode(data types.AttributeValue) (types.AttributeValue, error)
+}
+
+// DataCodecChain is a [DataCodec] that encodes with each of its codecs in order, and
+// decodes in reverse order.
+//
+// Example:
+//
+//	table.DataCodec = dynamap.DataCodecChain{
+//		dynamap.GzipDataCodec{},
+//		dynamap.AEADDataCodec{AEAD: aead},
+//	}
+type DataCodecChain []DataCodec
+
+// Encode implements [DataCodec].
+func (c DataCodecChain) Encode(data types.AttributeValue) (types.AttributeValue, error) {
+	var err error
+	for _, codec := range c {
+		if data, err = codec.Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Decode implements [DataCodec].
+func (c DataCodecChain) Decode(data types.AttributeValue) (types.AttributeValue, error) {
+	var err error
+	for i := len(c) - 1; i >= 0; i-- {
+		if data, err = c[i].Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// AEADDataCodec is a [DataCodec] that encrypts the data attribute with an authenticated
+// cipher, such as AES-GCM. The stored data is a binary attribute containing the nonce
+// followed by the ciphertext. The item hash and sort keys are not bound to the ciphertext.
+//
+// Example:
+//
+//	block, err := aes.NewCipher(key) // key from KMS GenerateDataKey
+//	aead, err := cipher.NewGCM(block)
+//	table.DataCodec = dynamap.AEADDataCodec{AEAD: aead}
+type AEADDataCodec struct {
+	AEAD cipher.AEAD // The cipher used to seal and open the data
+}
+
+// Encode implements [DataCodec].
+func (c AEADDataCodec) Encode(data types.AttributeValue) (types.AttributeValue, error) {
+	plaintext, err := encodeAttributeValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, c.AEAD.NonceSize(), c.AEAD.NonceSize()+len(plaintext)+c.AEAD.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &types.AttributeValueMemberB{Value: c.AEAD.Seal(nonce, nonce, plaintext, nil)}, nil
+}
+
+// Decode implements [DataCodec].
+func (c AEADDataCodec) Decode(data types.AttributeValue) (types.AttributeValue, error) {
+	sealed, err := binaryValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < c.AEAD.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+
+	nonce, ciphertext := sealed[:c.AEAD.NonceSize()], sealed[c.AEAD.NonceSize():]
+	plaintext, err := c.AEAD.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return decodeAttributeValue(plaintext)
+}
+
+// GzipDataCodec is a [DataCodec] that compresses the data attribute. The stored data is
+// a binary attribute.
+type GzipDataCodec struct{}
+
+// Encode implements [DataCodec].
+func (GzipDataCodec) Encode(data types.AttributeValue) (types.AttributeValue, error) {
+	raw, err := encodeAttributeValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	writer := getGzipWriter(buf)
+	defer putGzipWriter(writer)
+	if _, err := writer.Write(raw); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+
+	return &types.AttributeValueMemberB{Value: bytes.Clone(buf.Bytes())}, nil
+}
+
+// Decode implements [DataCodec].
+func (GzipDataCodec) Decode(data types.AttributeValue) (types.AttributeValue, error) {
+	compressed, err := binaryValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+
+	return decodeAttributeValue(raw)
+}
+
+// attributeValueEnvelope wraps an attribute value so that it can be gob encoded.
+type attributeValueEnvelope struct {
+	Value types.AttributeValue
+}
+
+// encodeAttributeValue gob encodes an attribute value.
+func encodeAttributeValue(value types.AttributeValue) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := gob.NewEncoder(buf).Encode(attributeValueEnvelope{Value: value}); err != nil {
+		return nil, fmt.Errorf("failed to encode data: %w", err)
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// decodeAttributeValue decodes an attribute value encoded by [encodeAttributeValue].
+func decodeAttributeValue(data []byte) (types.AttributeValue, error) {
+	var envelope attributeValueEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+	return envelope.Value, nil
+}
+
+// binaryValue returns the bytes of a binary attribute value.
+func binaryValue(data types.AttributeValue) ([]byte, error) {
+	b, ok := data.(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, fmt.Errorf("expected binary data attribute, got %T", data)
+	}
+	return b.Value, nil
+}
@@ -0,0 +1,114 @@
+package dynamap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func newTestAEAD(t *testing.T, key string) cipher.AEAD {
+	t.Helper()
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("Failed to create GCM: %v", err)
+	}
+	return aead
+}
+
+// Tests for data codecs
+
+func TestDataCodec(t *testing.T) {
+	aead := newTestAEAD(t, "0123456789abcdef0123456789abcdef")
+	product := &Product{ID: "P1", Category: "electronics"}
+
+	codecs := map[string]DataCodec{
+		"aead":  AEADDataCodec{AEAD: aead},
+		"gzip":  GzipDataCodec{},
+		"chain": DataCodecChain{GzipDataCodec{}, AEADDataCodec{AEAD: aead}},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			table := NewTable("test-table")
+			table.DataCodec = codec
+
+			putInput, err := table.MarshalPut(product)
+			if err != nil {
+				t.Fatalf("Failed to marshal put: %v", err)
+			}
+
+			if _, ok := putInput.Item["data"].(*types.AttributeValueMemberB); !ok {
+				t.Fatalf("Expected binary data attribute, got %T", putInput.Item["data"])
+			}
+
+			var out Product
+			if _, err := UnmarshalSelf(putInput.Item, &out, table.MarshalOptions); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if out.Category != "electronics" {
+				t.Errorf("Expected category 'electronics', got %s", out.Category)
+			}
+			if _, ok := putInput.Item["data"].(*types.AttributeValueMemberB); !ok {
+				t.Error("Expected item not to be modified by unmarshal")
+			}
+		})
+	}
+
+	t.Run("entity with refs", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.DataCodec = AEADDataCodec{AEAD: aead}
+
+		batches, err := table.MarshalBatch(&Order{ID: "O1", PurchasedBy: "john", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		var items []Item
+		for _, request := range batches[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		var order Order
+		if _, err := UnmarshalEntity(items, &order, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if order.PurchasedBy != "john" || len(order.Products) != 1 {
+			t.Errorf("Expected decoded order, got %+v", order)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.DataCodec = AEADDataCodec{AEAD: aead}
+		putInput, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		table.DataCodec = AEADDataCodec{AEAD: newTestAEAD(t, "fedcba9876543210fedcba9876543210")}
+		var out Product
+		if _, err := UnmarshalSelf(putInput.Item, &out, table.MarshalOptions); err == nil {
+			t.Error("Expected error decrypting with the wrong key")
+		}
+	})
+
+	t.Run("unencoded data", func(t *testing.T) {
+		putInput, err := NewTable("test-table").MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		var out Product
+		if _, err := UnmarshalSelf(putInput.Item, &out, func(opts *MarshalOptions) {
+			opts.DataCodec = GzipDataCodec{}
+		}); err == nil {
+			t.Error("Expected error decoding non-binary data")
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package dynamap
+
+// NewTableWithDAX creates a table named tableName with default configuration, and a
+// [Client] executing its requests through a DynamoDB Accelerator (DAX) cluster.
+//
+// The DAX client from github.com/aws/aws-dax-go-v2/dax implements [DynamoDBClient], as it
+// mirrors the DynamoDB client signatures, including TransactWriteItems and per-request
+// option functions, which are passed through unchanged. Entities read with [Table.Get]
+// and [Table.BatchGet] are served from the DAX item cache, which writes through DAX keep
+// current. Query results, such as those of [Table.LoadEntity], are cached separately by
+// DAX and are not invalidated by writes, so pass a DynamoDB client to the table methods
+// for reads that must observe recent writes.
+//
+// Example:
+//
+//	daxClient, err := dax.New(dax.Config{
+//		Config:    client.Config{HostPorts: []string{"dax://my-cluster.abc123.dax-clusters.us-east-1.amazonaws.com"}},
+//		...
+//	})
+//	client := dynamap.NewTableWithDAX("my-table", daxClient)
+//	err = client.Put(ctx, product)
+//	_, err = client.Get(ctx, &Product{ID: "P1"}, &product)
+func NewTableWithDAX(tableName string, dax DynamoDBClient, interceptors ...Interceptor) *Client {
+	return NewClient(NewTable(tableName), dax, interceptors...)
+}
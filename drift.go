@@ -0,0 +1,159 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrSchemaMismatch is returned by [SchemaReport.Err] when a table does not match the
+// schema dynamap expects.
+var ErrSchemaMismatch = newError(ErrValidation, "table schema mismatch")
+
+// SchemaClient is the subset of the DynamoDB client used to validate table schemas.
+type SchemaClient interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
+// SchemaMismatch is a difference between a table and the schema dynamap expects.
+type SchemaMismatch struct {
+	Field    string // The mismatched part of the schema, such as "KeySchema" or "Index ref-index"
+	Expected string // The expected configuration
+	Actual   string // The actual configuration; empty if it is missing
+}
+
+// String describes the mismatch.
+func (m SchemaMismatch) String() string {
+	actual := m.Actual
+	if actual == "" {
+		actual = "missing"
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", m.Field, m.Expected, actual)
+}
+
+// SchemaReport lists the mismatches found by [Table.ValidateSchema].
+type SchemaReport struct {
+	TableName  string           // The validated table
+	Mismatches []SchemaMismatch // The mismatches found, if any
+}
+
+// OK returns true if the table matches the expected schema.
+func (r *SchemaReport) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Err returns an error wrapping [ErrSchemaMismatch] that lists the mismatches, or nil if
+// there are none.
+func (r *SchemaReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	details := make([]string, 0, len(r.Mismatches))
+	for _, mismatch := range r.Mismatches {
+		details = append(details, mismatch.String())
+	}
+	return fmt.Errorf("%w: %s: %s", ErrSchemaMismatch, r.TableName, strings.Join(details, "; "))
+}
+
+// add records a mismatch if expected and actual differ.
+func (r *SchemaReport) add(field, expected, actual string) {
+	if expected != actual {
+		r.Mismatches = append(r.Mismatches, SchemaMismatch{Field: field, Expected: expected, Actual: actual})
+	}
+}
+
+// ValidateSchema describes the table and verifies its key schema, key attribute types,
+// ref index and secondary indexes, time to live attribute and billing mode against the
+// schema of [Table.CreateTableInput], configured by opts. Mismatches, such as a ref index
+// keyed without gsi1_sk, are returned in the report; the error is only set if the table
+// cannot be described.
+//
+// Example:
+//
+//	report, err := table.ValidateSchema(ctx, client)
+//	if err != nil {
+//		return err
+//	}
+//	if err := report.Err(); err != nil {
+//		log.Fatal(err) // table schema mismatch: my-table: Index ref-index: expected label HASH, gsi1_sk RANGE, got label HASH
+//	}
+func (t *Table) ValidateSchema(ctx context.Context, client SchemaClient, opts ...func(*ProvisionOptions)) (*SchemaReport, error) {
+	options := newProvisionOptions(opts)
+	expected := t.CreateTableInput(opts...)
+
+	described, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(t.TableName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", t.TableName, err)
+	}
+	actual := described.Table
+
+	report := &SchemaReport{TableName: t.TableName}
+	report.add("KeySchema", formatKeySchema(expected.KeySchema), formatKeySchema(actual.KeySchema))
+
+	attributeTypes := make(map[string]types.ScalarAttributeType)
+	for _, definition := range actual.AttributeDefinitions {
+		attributeTypes[aws.ToString(definition.AttributeName)] = definition.AttributeType
+	}
+	for _, definition := range expected.AttributeDefinitions {
+		name := aws.ToString(definition.AttributeName)
+		if actualType, ok := attributeTypes[name]; ok {
+			report.add("Attribute "+name, string(definition.AttributeType), string(actualType))
+		}
+	}
+
+	for _, index := range expected.GlobalSecondaryIndexes {
+		field := "Index " + aws.ToString(index.IndexName)
+		found := false
+		for _, actualIndex := range actual.GlobalSecondaryIndexes {
+			if aws.ToString(actualIndex.IndexName) == aws.ToString(index.IndexName) {
+				found = true
+				report.add(field, formatKeySchema(index.KeySchema), formatKeySchema(actualIndex.KeySchema))
+				if actualIndex.Projection != nil {
+					report.add(field+" projection", string(index.Projection.ProjectionType), string(actualIndex.Projection.ProjectionType))
+				}
+			}
+		}
+		if !found {
+			report.add(field, formatKeySchema(index.KeySchema), "")
+		}
+	}
+
+	billingMode := types.BillingModeProvisioned
+	if actual.BillingModeSummary != nil {
+		billingMode = actual.BillingModeSummary.BillingMode
+	}
+	report.add("BillingMode", string(options.BillingMode), string(billingMode))
+
+	if !options.DisableTTL {
+		ttl, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(t.TableName)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe time to live of table %s: %w", t.TableName, err)
+		}
+
+		var attribute string
+		if description := ttl.TimeToLiveDescription; description != nil {
+			switch description.TimeToLiveStatus {
+			case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+				attribute = aws.ToString(description.AttributeName)
+			}
+		}
+		report.add("TimeToLive", NewMarshalOptions(t.MarshalOptions).aliasName(AttributeNameExpires), attribute)
+	}
+
+	return report, nil
+}
+
+// formatKeySchema describes a key schema, such as "hk HASH, sk RANGE".
+func formatKeySchema(schema []types.KeySchemaElement) string {
+	elements := make([]string, 0, len(schema))
+	for _, element := range schema {
+		elements = append(elements, aws.ToString(element.AttributeName)+" "+string(element.KeyType))
+	}
+	return strings.Join(elements, ", ")
+}
@@ -0,0 +1,89 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for schema validation
+
+func TestValidateSchema(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+
+	t.Run("matching schema", func(t *testing.T) {
+		client := newAdminClient()
+		if _, err := table.EnsureTable(ctx, client); err != nil {
+			t.Fatalf("Failed to ensure table: %v", err)
+		}
+
+		report, err := table.ValidateSchema(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to validate schema: %v", err)
+		}
+		if !report.OK() || report.Err() != nil {
+			t.Errorf("Expected no mismatches, got %v", report.Err())
+		}
+	})
+
+	t.Run("drifted schema", func(t *testing.T) {
+		client := newAdminClient()
+		input := table.CreateTableInput(func(po *ProvisionOptions) {
+			po.BillingMode = types.BillingModeProvisioned
+			po.ReadCapacityUnits = 5
+			po.WriteCapacityUnits = 5
+		})
+		input.GlobalSecondaryIndexes[0].KeySchema = input.GlobalSecondaryIndexes[0].KeySchema[:1]
+		client.tables["test-table"] = input
+
+		report, err := table.ValidateSchema(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to validate schema: %v", err)
+		}
+
+		fields := make(map[string]SchemaMismatch)
+		for _, mismatch := range report.Mismatches {
+			fields[mismatch.Field] = mismatch
+		}
+		if len(fields) != 3 {
+			t.Errorf("Expected 3 mismatches, got %v", report.Mismatches)
+		}
+		if ref := fields["Index "+table.RefIndexName]; ref.Expected != "label HASH, gsi1_sk RANGE" || ref.Actual != "label HASH" {
+			t.Errorf("Expected ref index mismatch, got %+v", ref)
+		}
+		if billing := fields["BillingMode"]; billing.Actual != string(types.BillingModeProvisioned) {
+			t.Errorf("Expected billing mode mismatch, got %+v", billing)
+		}
+		if ttl := fields["TimeToLive"]; ttl.Expected != AttributeNameExpires || ttl.Actual != "" {
+			t.Errorf("Expected time to live mismatch, got %+v", ttl)
+		}
+		if err := report.Err(); !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("Expected ErrSchemaMismatch, got %v", err)
+		}
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		if _, err := table.ValidateSchema(ctx, newAdminClient()); err == nil {
+			t.Error("Expected an error for a missing table")
+		}
+	})
+
+	t.Run("missing index", func(t *testing.T) {
+		client := newAdminClient()
+		client.tables["test-table"] = table.CreateTableInput()
+		client.ttl["test-table"] = AttributeNameExpires
+
+		indexed := NewTable("test-table")
+		indexed.Indexes = []SecondaryIndex{{Name: "by-email", PartitionKey: "email"}}
+		report, err := indexed.ValidateSchema(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to validate schema: %v", err)
+		}
+		if len(report.Mismatches) != 1 || report.Mismatches[0].Field != "Index by-email" || report.Mismatches[0].Actual != "" {
+			t.Errorf("Expected missing index mismatch, got %v", report.Mismatches)
+		}
+	})
+}
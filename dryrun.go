@@ -0,0 +1,329 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DryRunClient is a [DynamoDBClient] that records the writes it receives as a [Plan]
+// instead of executing them, to preview migrations or review changes before they reach
+// production. Reads are served by the Reader client, if any, which also provides the
+// current items so planned puts and deletes report the attributes they change.
+//
+// Conditions are recorded but not evaluated, and later reads do not see planned writes.
+// Writes that request [types.ReturnValueAllOld] return the current item.
+//
+// Example:
+//
+//	dryRun := dynamap.NewDryRunClient(client)
+//	if err := table.Put(ctx, dryRun, order); err != nil {
+//		return err
+//	}
+//	fmt.Print(dryRun.Plan())
+type DryRunClient struct {
+	Reader   DynamoDBClient // Optional client serving reads and the current items of planned writes
+	KeyNames KeyNames       // Names of the key attributes of the table, used to find the keys of planned puts
+
+	mu   sync.Mutex
+	plan Plan
+}
+
+// NewDryRunClient creates a new [DryRunClient] reading from reader, which may be nil.
+func NewDryRunClient(reader DynamoDBClient) *DryRunClient {
+	return &DryRunClient{Reader: reader}
+}
+
+// Plan is the list of writes recorded by a [DryRunClient], in the order they were received.
+type Plan []PlannedWrite
+
+// PlannedWrite is a write recorded by a [DryRunClient].
+type PlannedWrite struct {
+	Operation Operation         // OperationPut, OperationUpdate or OperationDelete
+	Table     string            // The table name
+	Key       Item              // The key of the written item
+	Item      Item              // The item written by a put
+	Update    string            // The update expression of an update, with names and values substituted
+	Condition string            // The condition expression, with names and values substituted
+	Changes   []AttributeChange // Attributes changed by a put or delete, compared to the current item
+}
+
+// AttributeChange is an attribute changed by a [PlannedWrite]. Old is nil for added
+// attributes, and New is nil for removed attributes.
+type AttributeChange struct {
+	Name string
+	Old  types.AttributeValue
+	New  types.AttributeValue
+}
+
+// Plan returns the writes recorded so far.
+func (c *DryRunClient) Plan() Plan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.plan)
+}
+
+// Reset discards the recorded writes.
+func (c *DryRunClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plan = nil
+}
+
+// PutItem records the put of the item.
+func (c *DryRunClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	write, current, err := c.planPut(ctx, aws.ToString(params.TableName), params.Item)
+	if err != nil {
+		return nil, err
+	}
+	write.Condition = renderExpression(aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	c.record(write)
+
+	output := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = current
+	}
+	return output, nil
+}
+
+// UpdateItem records the update of the item.
+func (c *DryRunClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	write := PlannedWrite{
+		Operation: OperationUpdate,
+		Table:     aws.ToString(params.TableName),
+		Key:       params.Key,
+		Update:    renderExpression(aws.ToString(params.UpdateExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues),
+		Condition: renderExpression(aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues),
+	}
+	c.record(write)
+
+	output := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		current, err := c.current(ctx, write.Table, write.Key)
+		if err != nil {
+			return nil, err
+		}
+		output.Attributes = current
+	}
+	return output, nil
+}
+
+// DeleteItem records the delete of the item.
+func (c *DryRunClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	write, current, err := c.planDelete(ctx, aws.ToString(params.TableName), params.Key)
+	if err != nil {
+		return nil, err
+	}
+	write.Condition = renderExpression(aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	c.record(write)
+
+	output := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = current
+	}
+	return output, nil
+}
+
+// BatchWriteItem records the puts and deletes of the batch. No items are left unprocessed.
+func (c *DryRunClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, table := range slices.Sorted(maps.Keys(params.RequestItems)) {
+		for _, request := range params.RequestItems[table] {
+			var (
+				write PlannedWrite
+				err   error
+			)
+			switch {
+			case request.PutRequest != nil:
+				write, _, err = c.planPut(ctx, table, request.PutRequest.Item)
+			case request.DeleteRequest != nil:
+				write, _, err = c.planDelete(ctx, table, request.DeleteRequest.Key)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			c.record(write)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// TransactWriteItems records the puts, updates and deletes of the transaction. Condition
+// checks are not recorded.
+func (c *DryRunClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var writes []PlannedWrite
+	for _, action := range params.TransactItems {
+		switch {
+		case action.Put != nil:
+			write, _, err := c.planPut(ctx, aws.ToString(action.Put.TableName), action.Put.Item)
+			if err != nil {
+				return nil, err
+			}
+			write.Condition = renderExpression(aws.ToString(action.Put.ConditionExpression), action.Put.ExpressionAttributeNames, action.Put.ExpressionAttributeValues)
+			writes = append(writes, write)
+		case action.Update != nil:
+			writes = append(writes, PlannedWrite{
+				Operation: OperationUpdate,
+				Table:     aws.ToString(action.Update.TableName),
+				Key:       action.Update.Key,
+				Update:    renderExpression(aws.ToString(action.Update.UpdateExpression), action.Update.ExpressionAttributeNames, action.Update.ExpressionAttributeValues),
+				Condition: renderExpression(aws.ToString(action.Update.ConditionExpression), action.Update.ExpressionAttributeNames, action.Update.ExpressionAttributeValues),
+			})
+		case action.Delete != nil:
+			write, _, err := c.planDelete(ctx, aws.ToString(action.Delete.TableName), action.Delete.Key)
+			if err != nil {
+				return nil, err
+			}
+			write.Condition = renderExpression(aws.ToString(action.Delete.ConditionExpression), action.Delete.ExpressionAttributeNames, action.Delete.ExpressionAttributeValues)
+			writes = append(writes, write)
+		}
+	}
+	c.record(writes...)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// GetItem reads the item from the Reader client, or returns no item.
+func (c *DryRunClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if c.Reader == nil {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return c.Reader.GetItem(ctx, params, optFns...)
+}
+
+// Query queries the Reader client, or returns no items.
+func (c *DryRunClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if c.Reader == nil {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	return c.Reader.Query(ctx, params, optFns...)
+}
+
+// BatchGetItem reads the items from the Reader client, or returns no items.
+func (c *DryRunClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if c.Reader == nil {
+		return &dynamodb.BatchGetItemOutput{}, nil
+	}
+	return batchGetItem(ctx, c.Reader, params, optFns...)
+}
+
+// record appends the writes to the plan.
+func (c *DryRunClient) record(writes ...PlannedWrite) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plan = append(c.plan, writes...)
+}
+
+// planPut returns the planned put of item and the item it replaces, if any.
+func (c *DryRunClient) planPut(ctx context.Context, table string, item Item) (PlannedWrite, Item, error) {
+	key := Item{}
+	for _, name := range []string{c.KeyNames.name(AttributeNameSource), c.KeyNames.name(AttributeNameTarget)} {
+		if value, ok := item[name]; ok {
+			key[name] = value
+		}
+	}
+
+	current, err := c.current(ctx, table, key)
+	if err != nil {
+		return PlannedWrite{}, nil, err
+	}
+	return PlannedWrite{
+		Operation: OperationPut,
+		Table:     table,
+		Key:       key,
+		Item:      item,
+		Changes:   diffItems(current, item),
+	}, current, nil
+}
+
+// planDelete returns the planned delete of the key and the item it deletes, if any.
+func (c *DryRunClient) planDelete(ctx context.Context, table string, key Item) (PlannedWrite, Item, error) {
+	current, err := c.current(ctx, table, key)
+	if err != nil {
+		return PlannedWrite{}, nil, err
+	}
+	return PlannedWrite{
+		Operation: OperationDelete,
+		Table:     table,
+		Key:       key,
+		Changes:   diffItems(current, nil),
+	}, current, nil
+}
+
+// current reads the current item with the key from the Reader client. It returns nil if
+// there is no reader or no item.
+func (c *DryRunClient) current(ctx context.Context, table string, key Item) (Item, error) {
+	if c.Reader == nil {
+		return nil, nil
+	}
+	output, err := c.Reader.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(table),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current item: %w", err)
+	}
+	return output.Item, nil
+}
+
+// diffItems returns the attributes that differ between the old and new items, in
+// attribute name order.
+func diffItems(old, new Item) []AttributeChange {
+	names := slices.Collect(maps.Keys(old))
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []AttributeChange
+	for _, name := range names {
+		if !reflect.DeepEqual(old[name], new[name]) {
+			changes = append(changes, AttributeChange{Name: name, Old: old[name], New: new[name]})
+		}
+	}
+	return changes
+}
+
+// String renders the plan, one write per paragraph, for review.
+func (p Plan) String() string {
+	if len(p) == 0 {
+		return "No writes planned.\n"
+	}
+
+	var b strings.Builder
+	for _, write := range p {
+		fmt.Fprintf(&b, "%s table %q key %s\n", write.Operation, write.Table, renderItem(write.Key))
+		if write.Update != "" {
+			for _, clause := range strings.Split(write.Update, "\n") {
+				if clause = strings.TrimSpace(clause); clause != "" {
+					fmt.Fprintf(&b, "  %s\n", clause)
+				}
+			}
+		}
+		if write.Condition != "" {
+			fmt.Fprintf(&b, "  if %s\n", write.Condition)
+		}
+		for _, change := range write.Changes {
+			switch {
+			case change.Old == nil:
+				fmt.Fprintf(&b, "  + %s: %s\n", change.Name, renderValue(change.New))
+			case change.New == nil:
+				fmt.Fprintf(&b, "  - %s: %s\n", change.Name, renderValue(change.Old))
+			default:
+				fmt.Fprintf(&b, "  ~ %s: %s -> %s\n", change.Name, renderValue(change.Old), renderValue(change.New))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package dynamap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestDryRunClient(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+
+	t.Run("records writes without executing them", func(t *testing.T) {
+		mockClient := newMockDynamoDBClient()
+		if err := table.Put(ctx, mockClient, &Product{ID: "P1", Category: "books"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		stored := mockClient.items["product#P1#product#P1"]
+
+		dryRun := NewDryRunClient(mockClient)
+		if err := table.Put(ctx, dryRun, &Product{ID: "P1", Category: "music"}); err != nil {
+			t.Fatalf("Failed to plan put: %v", err)
+		}
+		if _, err := table.Update(ctx, dryRun, &Product{ID: "P1"}, &testUpdater{}); err != nil {
+			t.Fatalf("Failed to plan update: %v", err)
+		}
+		if _, err := NewClient(table, dryRun).Delete(ctx, &Product{ID: "P2"}); err != nil {
+			t.Fatalf("Failed to plan delete: %v", err)
+		}
+
+		if len(mockClient.items) != 1 || mockClient.items["product#P1#product#P1"]["data"] != stored["data"] {
+			t.Errorf("Expected the stored items to be unchanged, got %v", mockClient.items)
+		}
+
+		plan := dryRun.Plan()
+		if len(plan) != 3 {
+			t.Fatalf("Expected 3 planned writes, got %d", len(plan))
+		}
+		if plan[0].Operation != OperationPut || plan[1].Operation != OperationUpdate || plan[2].Operation != OperationDelete {
+			t.Errorf("Unexpected operations: %s, %s, %s", plan[0].Operation, plan[1].Operation, plan[2].Operation)
+		}
+
+		var dataChange *AttributeChange
+		for i, change := range plan[0].Changes {
+			if change.Name == AttributeNameData {
+				dataChange = &plan[0].Changes[i]
+			}
+		}
+		if dataChange == nil || dataChange.Old == nil || dataChange.New == nil {
+			t.Errorf("Expected the data to change, got %+v", plan[0].Changes)
+		}
+		if !strings.Contains(plan[1].Update, `data.category = "updated"`) {
+			t.Errorf("Expected the rendered update, got %q", plan[1].Update)
+		}
+		if len(plan[2].Changes) != 0 {
+			t.Errorf("Expected no changes for a missing item, got %+v", plan[2].Changes)
+		}
+
+		rendered := plan.String()
+		for _, want := range []string{
+			`PutItem table "test-table" key hk="product#P1" sk="product#P1"`,
+			`~ data: {"category":"books","id":"P1"} -> {"category":"music","id":"P1"}`,
+			`UpdateItem table "test-table"`,
+			`DeleteItem table "test-table" key hk="product#P2" sk="product#P2"`,
+		} {
+			if !strings.Contains(rendered, want) {
+				t.Errorf("Expected %q in plan:\n%s", want, rendered)
+			}
+		}
+
+		dryRun.Reset()
+		if got := dryRun.Plan().String(); got != "No writes planned.\n" {
+			t.Errorf("Expected an empty plan, got %q", got)
+		}
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		dryRun := NewDryRunClient(nil)
+		order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+		batches, err := table.MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		for _, batch := range batches {
+			if _, err := dryRun.BatchWriteItem(ctx, batch); err != nil {
+				t.Fatalf("Failed to plan batch: %v", err)
+			}
+		}
+
+		plan := dryRun.Plan()
+		if len(plan) != 3 {
+			t.Fatalf("Expected the order and its product refs, got %d writes", len(plan))
+		}
+		for _, write := range plan {
+			if write.Operation != OperationPut || write.Key["hk"] == nil || write.Key["sk"] == nil {
+				t.Errorf("Expected a keyed put, got %+v", write)
+			}
+			for _, change := range write.Changes {
+				if change.Old != nil {
+					t.Errorf("Expected only added attributes without a reader, got %+v", change)
+				}
+			}
+		}
+	})
+
+	t.Run("key names", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.KeyNames = KeyNames{Source: "PK", Target: "SK"}
+
+		dryRun := NewDryRunClient(nil)
+		dryRun.KeyNames = table.KeyNames
+		if err := NewClient(table, dryRun).Put(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to plan put: %v", err)
+		}
+
+		key := dryRun.Plan()[0].Key
+		if pk, ok := key["PK"].(*types.AttributeValueMemberS); !ok || pk.Value != "product#P1" || len(key) != 2 {
+			t.Errorf("Expected the renamed key, got %v", key)
+		}
+	})
+}
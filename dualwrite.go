@@ -0,0 +1,377 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DualWriter is an [Interceptor] that mirrors every successful write to the primary
+// table onto a secondary table, for zero-downtime moves between tables or key schemes.
+// Items are translated to the secondary table's key codec, label codec, tenant, data
+// codec and attribute aliases.
+//
+// A move registers the dual writer on the primary table, copies the existing items with
+// [DualWriter.Backfill], switches reads to the secondary table, and finally removes the
+// dual writer:
+//
+//	dual := dynamap.NewDualWriter(table, next, client)
+//	table.Interceptors = append(table.Interceptors, dual)
+//	report, err := dual.Backfill(ctx, client)
+//
+// Puts and deletes are mirrored as such, without their conditions. Updates are mirrored
+// as puts of the updated item, which the dual writer requests when the update returns no
+// values; updates returning other values, and updates within transactions, are replayed
+// against the translated key.
+type DualWriter struct {
+	Primary   *Table                                             // The table whose writes are mirrored
+	Secondary *Table                                             // The table receiving the mirrored writes
+	Client    DynamoDBClient                                     // The client writing the secondary table
+	OnError   func(ctx context.Context, req *Request, err error) // Optional handler of failed mirror writes. If nil, failures are returned by the request.
+
+	requested sync.Map // Update requests whose new image was requested by the dual writer
+}
+
+// NewDualWriter creates a new [DualWriter] mirroring the writes of primary onto secondary.
+func NewDualWriter(primary, secondary *Table, client DynamoDBClient) *DualWriter {
+	return &DualWriter{Primary: primary, Secondary: secondary, Client: client}
+}
+
+// Before implements [Interceptor]. It requests the new image of updates returning no values.
+func (d *DualWriter) Before(ctx context.Context, req *Request) error {
+	if input, ok := req.Input.(*dynamodb.UpdateItemInput); ok {
+		if input.ReturnValues == "" || input.ReturnValues == types.ReturnValueNone {
+			updated := *input
+			updated.ReturnValues = types.ReturnValueAllNew
+			req.Input = &updated
+			d.requested.Store(req, true)
+		}
+	}
+	return nil
+}
+
+// After implements [Interceptor]. It mirrors the successful write onto the secondary table.
+func (d *DualWriter) After(ctx context.Context, req *Request) {
+	_, requested := d.requested.LoadAndDelete(req)
+	if req.Err != nil {
+		return
+	}
+
+	err := d.mirror(ctx, req, requested)
+	if err == nil {
+		return
+	}
+
+	err = fmt.Errorf("failed to mirror %s to %s: %w", req.Operation, d.Secondary.TableName, err)
+	if d.OnError != nil {
+		d.OnError(ctx, req, err)
+	} else {
+		req.Err = errors.Join(req.Err, err)
+	}
+}
+
+// mirror writes the request to the secondary table.
+func (d *DualWriter) mirror(ctx context.Context, req *Request, requested bool) error {
+	client := d.Secondary.client(d.Client)
+
+	switch input := req.Input.(type) {
+	case *dynamodb.PutItemInput:
+		if aws.ToString(input.TableName) != d.Primary.TableName {
+			return nil
+		}
+		return d.put(ctx, client, input.Item)
+	case *dynamodb.DeleteItemInput:
+		if aws.ToString(input.TableName) != d.Primary.TableName {
+			return nil
+		}
+		return d.delete(ctx, client, input.Key)
+	case *dynamodb.UpdateItemInput:
+		if aws.ToString(input.TableName) != d.Primary.TableName {
+			return nil
+		}
+		output := req.Output.(*dynamodb.UpdateItemOutput)
+		if requested || input.ReturnValues == types.ReturnValueAllNew {
+			item := output.Attributes
+			if requested {
+				// The caller asked for no values
+				trimmed := *output
+				trimmed.Attributes = nil
+				req.Output = &trimmed
+			}
+			return d.put(ctx, client, item)
+		}
+		return d.update(ctx, client, input)
+	case *dynamodb.BatchWriteItemInput:
+		return d.batchWrite(ctx, client, input, req.Output.(*dynamodb.BatchWriteItemOutput))
+	case *dynamodb.TransactWriteItemsInput:
+		return d.transactWrite(ctx, client, input)
+	}
+
+	return nil
+}
+
+// put writes the translated item to the secondary table.
+func (d *DualWriter) put(ctx context.Context, client DynamoDBClient, item Item) error {
+	translated, err := d.translate(item)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(d.Secondary.TableName), Item: translated})
+	return err
+}
+
+// delete deletes the item with the translated key from the secondary table.
+func (d *DualWriter) delete(ctx context.Context, client DynamoDBClient, key Item) error {
+	translated, err := d.translate(key)
+	if err != nil {
+		return err
+	}
+	_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String(d.Secondary.TableName), Key: translated})
+	return err
+}
+
+// update replays the update against the translated key of the secondary table.
+func (d *DualWriter) update(ctx context.Context, client DynamoDBClient, input *dynamodb.UpdateItemInput) error {
+	key, err := d.translate(input.Key)
+	if err != nil {
+		return err
+	}
+	replayed := *input
+	replayed.TableName = aws.String(d.Secondary.TableName)
+	replayed.Key = key
+	replayed.ReturnValues = types.ReturnValueNone
+	_, err = client.UpdateItem(ctx, &replayed)
+	return err
+}
+
+// batchWrite mirrors the processed requests of a batch write.
+func (d *DualWriter) batchWrite(ctx context.Context, client DynamoDBClient, input *dynamodb.BatchWriteItemInput, output *dynamodb.BatchWriteItemOutput) error {
+	var unprocessed []types.WriteRequest
+	if output != nil {
+		unprocessed = output.UnprocessedItems[d.Primary.TableName]
+	}
+
+	var requests []types.WriteRequest
+	for _, request := range input.RequestItems[d.Primary.TableName] {
+		if isUnprocessed(request, unprocessed) {
+			continue
+		}
+		if request.PutRequest != nil {
+			item, err := d.translate(request.PutRequest.Item)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+		if request.DeleteRequest != nil {
+			key, err := d.translate(request.DeleteRequest.Key)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+		}
+	}
+
+	return batchWriteAll(ctx, client, d.Secondary.chunkBatchWrite(requests))
+}
+
+// transactWrite mirrors the writes of a transaction, in a transaction.
+func (d *DualWriter) transactWrite(ctx context.Context, client DynamoDBClient, input *dynamodb.TransactWriteItemsInput) error {
+	var items []types.TransactWriteItem
+	for _, action := range input.TransactItems {
+		switch {
+		case action.Put != nil && aws.ToString(action.Put.TableName) == d.Primary.TableName:
+			item, err := d.translate(action.Put.Item)
+			if err != nil {
+				return err
+			}
+			items = append(items, types.TransactWriteItem{Put: &types.Put{TableName: aws.String(d.Secondary.TableName), Item: item}})
+		case action.Delete != nil && aws.ToString(action.Delete.TableName) == d.Primary.TableName:
+			key, err := d.translate(action.Delete.Key)
+			if err != nil {
+				return err
+			}
+			items = append(items, types.TransactWriteItem{Delete: &types.Delete{TableName: aws.String(d.Secondary.TableName), Key: key}})
+		case action.Update != nil && aws.ToString(action.Update.TableName) == d.Primary.TableName:
+			key, err := d.translate(action.Update.Key)
+			if err != nil {
+				return err
+			}
+			update := *action.Update
+			update.TableName = aws.String(d.Secondary.TableName)
+			update.Key = key
+			items = append(items, types.TransactWriteItem{Update: &update})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := transactWriteItems(ctx, client, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	return err
+}
+
+// translate converts an item or key written to the primary table into the item or key of
+// the secondary table.
+func (d *DualWriter) translate(item Item) (Item, error) {
+	return translateItem(NewMarshalOptions(d.Primary.MarshalOptions), NewMarshalOptions(d.Secondary.MarshalOptions), item)
+}
+
+// translateItem converts an item or key written with the from options into the item or key
+// written with the to options: keys, labels, ref index attributes, timestamps, data and
+// attribute aliases are encoded anew. Keys and labels that cannot be decoded are copied
+// as is.
+func translateItem(from, to MarshalOptions, item Item) (Item, error) {
+	decoded, err := from.unmarshalItem(item)
+	if err != nil {
+		return nil, err
+	}
+	decoded = maps.Clone(decoded)
+
+	for _, name := range []string{AttributeNameSource, AttributeNameTarget} {
+		if key, ok := decoded[name].(*types.AttributeValueMemberS); ok {
+			if prefix, id, err := from.keyCodec().DecodeKey(key.Value); err == nil {
+				decoded[name] = &types.AttributeValueMemberS{Value: to.keyCodec().EncodeKey(prefix, id)}
+			}
+		}
+	}
+
+	if label, ok := decoded[AttributeNameLabel].(*types.AttributeValueMemberS); ok {
+		if prefix, id, name, err := from.labelCodec().DecodeLabel(label.Value); err == nil {
+			if id == "" && name == "" {
+				decoded[AttributeNameLabel] = &types.AttributeValueMemberS{Value: to.tenantLabel(prefix)}
+			} else {
+				decoded[AttributeNameLabel] = &types.AttributeValueMemberS{Value: to.labelCodec().EncodeLabel(prefix, id, name)}
+				if index, err := from.index(from.RefNameIndex); err == nil {
+					delete(decoded, index.PartitionKey)
+				}
+			}
+		}
+	}
+
+	if from.RefIndexHashAttribute != "" {
+		delete(decoded, from.RefIndexHashAttribute)
+	}
+	to.refIndexHashItem(decoded)
+	if err := to.refNameItem(decoded); err != nil {
+		return nil, err
+	}
+
+	if data, ok := decoded[AttributeNameData]; ok && to.DataCodec != nil {
+		if decoded[AttributeNameData], err = to.DataCodec.Encode(data); err != nil {
+			return nil, fmt.Errorf("failed to encode data: %w", err)
+		}
+	}
+
+	if err := to.encodeTimestamps(decoded); err != nil {
+		return nil, err
+	}
+
+	return to.aliasItem(decoded), nil
+}
+
+// BackfillOptions configures [DualWriter.Backfill].
+type BackfillOptions struct {
+	Segment       int          // The segment scanned by this worker, for parallel backfills
+	TotalSegments int          // The number of parallel workers. Zero scans the whole table.
+	PageSize      int32        // Maximum number of items read per scan request. Zero uses the DynamoDB default.
+	RateLimiter   *RateLimiter // Optional limiter pacing the scan and the copies
+}
+
+// BackfillReport describes the items copied by [DualWriter.Backfill].
+type BackfillReport struct {
+	Scanned int // Number of items scanned from the primary table
+	Copied  int // Number of items written to the secondary table
+	Skipped int // Number of items already present in the secondary table
+}
+
+// Backfill scans the primary table with client and copies each item missing from the
+// secondary table. Items already present, such as items mirrored since the dual writer
+// was registered, are not overwritten, so the dual writer should be registered before
+// the backfill starts. Run workers with different [BackfillOptions.Segment] values to
+// backfill in parallel.
+func (d *DualWriter) Backfill(ctx context.Context, client ScanClient, opts ...func(*BackfillOptions)) (BackfillReport, error) {
+	var (
+		options BackfillOptions
+		report  BackfillReport
+		writer  = d.Client
+	)
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.RateLimiter != nil {
+		client = limitedScanClient{client: client, limiter: options.RateLimiter}
+		writer = options.RateLimiter.limitWrites(writer)
+	}
+	writer = d.Secondary.client(writer)
+
+	input := &dynamodb.ScanInput{TableName: aws.String(d.Primary.TableName)}
+	if options.TotalSegments > 0 {
+		input.Segment = aws.Int32(int32(options.Segment))
+		input.TotalSegments = aws.Int32(int32(options.TotalSegments))
+	}
+	if options.PageSize > 0 {
+		input.Limit = aws.Int32(options.PageSize)
+	}
+
+	for {
+		output, err := client.Scan(ctx, input)
+		if err != nil {
+			return report, fmt.Errorf("failed to scan table %s: %w", d.Primary.TableName, classify(err))
+		}
+
+		for _, item := range output.Items {
+			report.Scanned++
+			translated, err := d.translate(item)
+			if err != nil {
+				return report, err
+			}
+
+			_, err = writer.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:                aws.String(d.Secondary.TableName),
+				Item:                     translated,
+				ConditionExpression:      aws.String("attribute_not_exists(#hk)"),
+				ExpressionAttributeNames: map[string]string{"#hk": AttributeNameSource},
+			})
+			var conditionFailed *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionFailed) {
+				report.Skipped++
+				continue
+			} else if err != nil {
+				return report, fmt.Errorf("failed to copy item: %w", classify(err))
+			}
+			report.Copied++
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	return report, nil
+}
+
+// isUnprocessed returns true if the key of the request is among the unprocessed requests.
+func isUnprocessed(request types.WriteRequest, unprocessed []types.WriteRequest) bool {
+	key := func(request types.WriteRequest) Item {
+		if request.PutRequest != nil {
+			return request.PutRequest.Item
+		}
+		return request.DeleteRequest.Key
+	}
+	for _, other := range unprocessed {
+		a, b := key(request), key(other)
+		if StringValue(a[AttributeNameSource]) == StringValue(b[AttributeNameSource]) &&
+			StringValue(a[AttributeNameTarget]) == StringValue(b[AttributeNameTarget]) {
+			return true
+		}
+	}
+	return false
+}
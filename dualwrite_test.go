@@ -0,0 +1,154 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// secondaryClient honors the put conditions of backfills, and answers updates with a
+// new image carrying the updated flag.
+type secondaryClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *secondaryClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	key := StringValue(params.Item["hk"]) + "#" + StringValue(params.Item["sk"])
+	if _, exists := c.items[key]; exists && params.ConditionExpression != nil {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("exists")}
+	}
+	return c.mockDynamoDBClient.PutItem(ctx, params, optFns...)
+}
+
+func (c *secondaryClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	key := StringValue(params.Key["hk"]) + "#" + StringValue(params.Key["sk"])
+	item := maps.Clone(c.items[key])
+	item["updated"] = &types.AttributeValueMemberBOOL{Value: true}
+	c.items[key] = item
+
+	output := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllNew {
+		output.Attributes = item
+	}
+	return output, nil
+}
+
+func (c *secondaryClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	keys := slices.Sorted(maps.Keys(c.items))
+	output := &dynamodb.ScanOutput{}
+	for _, key := range keys {
+		output.Items = append(output.Items, c.items[key])
+	}
+	return output, nil
+}
+
+// Tests for dual writes
+
+func TestDualWriter(t *testing.T) {
+	ctx := context.Background()
+
+	newTables := func() (*Table, *Table, *secondaryClient, *secondaryClient) {
+		primary := NewTable("primary")
+		secondary := NewTable("secondary")
+		secondary.KeyDelimiter = ":"
+		secondary.LabelDelimiter = "|"
+
+		primaryClient := &secondaryClient{newMockDynamoDBClient()}
+		secondaryClient := &secondaryClient{newMockDynamoDBClient()}
+		primary.Interceptors = []Interceptor{NewDualWriter(primary, secondary, secondaryClient)}
+		return primary, secondary, primaryClient, secondaryClient
+	}
+
+	t.Run("mirrors writes", func(t *testing.T) {
+		primary, _, primaryClient, secondaryClient := newTables()
+
+		if err := primary.Put(ctx, primaryClient, &Product{ID: "P1", Category: "books"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		item, ok := secondaryClient.items["product:P1#product:P1"]
+		if !ok || StringValue(item[AttributeNameLabel]) != "product" {
+			t.Fatalf("Expected the translated product, got %v", secondaryClient.items)
+		}
+
+		if err := primary.Attach(ctx, primaryClient, &Order{ID: "O1"}, "products", []Marshaler{&Product{ID: "P1"}}); err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		ref, ok := secondaryClient.items["order:O1#product:P1"]
+		if !ok || StringValue(ref[AttributeNameLabel]) != "order|O1|products" {
+			t.Errorf("Expected the translated ref, got %v", secondaryClient.items)
+		}
+
+		output, err := primary.Update(ctx, primaryClient, &Product{ID: "P1"}, Increment("stock", 1))
+		if err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+		if output.Attributes != nil {
+			t.Errorf("Expected no attributes returned to the caller, got %v", output.Attributes)
+		}
+		if _, ok := secondaryClient.items["product:P1#product:P1"]["updated"]; !ok {
+			t.Errorf("Expected the updated product to be mirrored, got %v", secondaryClient.items["product:P1#product:P1"])
+		}
+
+		if err := primary.Detach(ctx, primaryClient, &Order{ID: "O1"}, "products", []Marshaler{&Product{ID: "P1"}}); err != nil {
+			t.Fatalf("Failed to detach: %v", err)
+		}
+		if _, ok := secondaryClient.items["order:O1#product:P1"]; ok {
+			t.Error("Expected the ref to be deleted from the secondary table")
+		}
+	})
+
+	t.Run("reports mirror failures", func(t *testing.T) {
+		primary, _, primaryClient, _ := newTables()
+		dual := primary.Interceptors[0].(*DualWriter)
+		dual.Client = &failingPutClient{newMockDynamoDBClient()}
+
+		err := primary.Put(ctx, primaryClient, &Product{ID: "P1"})
+		if err == nil {
+			t.Fatal("Expected the mirror failure to be returned")
+		}
+
+		var handled error
+		dual.OnError = func(ctx context.Context, req *Request, err error) { handled = err }
+		if err := primary.Put(ctx, primaryClient, &Product{ID: "P2"}); err != nil {
+			t.Fatalf("Expected the handler to receive the failure, got %v", err)
+		}
+		if handled == nil {
+			t.Error("Expected the handler to be called")
+		}
+	})
+
+	t.Run("backfills missing items", func(t *testing.T) {
+		primary, _, primaryClient, secondaryClient := newTables()
+		dual := primary.Interceptors[0].(*DualWriter)
+		primary.Interceptors = nil
+
+		for _, id := range []string{"P1", "P2", "P3"} {
+			if err := primary.Put(ctx, primaryClient, &Product{ID: id, Category: "books"}); err != nil {
+				t.Fatalf("Failed to put product: %v", err)
+			}
+		}
+		secondaryClient.items["product:P2#product:P2"] = Item{
+			"hk": &types.AttributeValueMemberS{Value: "product:P2"},
+			"sk": &types.AttributeValueMemberS{Value: "product:P2"},
+		}
+
+		report, err := dual.Backfill(ctx, primaryClient)
+		if err != nil {
+			t.Fatalf("Failed to backfill: %v", err)
+		}
+		if report.Scanned != 3 || report.Copied != 2 || report.Skipped != 1 {
+			t.Errorf("Expected 3 scanned, 2 copied and 1 skipped, got %+v", report)
+		}
+		if _, ok := secondaryClient.items["product:P3#product:P3"]; !ok {
+			t.Errorf("Expected P3 to be copied, got %v", secondaryClient.items)
+		}
+		if _, ok := secondaryClient.items["product:P2#product:P2"][AttributeNameLabel]; ok {
+			t.Error("Expected the existing P2 not to be overwritten")
+		}
+	})
+}
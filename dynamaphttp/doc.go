@@ -0,0 +1,22 @@
+// Package dynamaphttp serves the entities of a dynamap table over HTTP, as a quick way to
+// stand up admin and internal APIs.
+//
+// A [Handler] of an entity type provides net/http handlers to get, put, delete and list
+// entities, with cursor pagination and filters on the ref sort key of listed entities.
+// Entities are encoded as JSON, or as JSON:API documents with [FormatJSONAPI]:
+//
+//	products := dynamaphttp.NewHandler(client, "product", func(id string) *Product {
+//		return &Product{ID: id}
+//	})
+//	products.Register(mux, "/products")
+//
+// Register serves:
+//
+//	GET    /products       lists products; see [Handler.List] for the parameters
+//	GET    /products/{id}  gets a product
+//	PUT    /products/{id}  writes a product from the request body
+//	DELETE /products/{id}  deletes a product
+//
+// Errors are reported with the status matching their category, such as 404 for
+// [dynamap.ErrNotFound] and 400 for [dynamap.ErrValidation].
+package dynamaphttp
@@ -0,0 +1,431 @@
+package dynamaphttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/nisimpson/dynamap"
+	"github.com/nisimpson/dynamap/jsonapi"
+)
+
+const (
+	// DefaultLimit is the default number of entities listed per page.
+	DefaultLimit = 25
+	// DefaultMaxLimit is the default maximum number of entities listed per page.
+	DefaultMaxLimit = 100
+	// DefaultMaxBodyBytes is the default maximum size of request bodies.
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// Format is the encoding of request and response bodies.
+type Format string
+
+const (
+	// FormatJSON encodes entities as JSON objects, using their encoding/json field names.
+	FormatJSON Format = "json"
+	// FormatJSONAPI encodes entities as JSON:API documents created by the jsonapi package,
+	// whose attributes use the dynamodbav field names of the entities.
+	FormatJSONAPI Format = "jsonapi"
+)
+
+// Handler serves the entities with a prefix. T is the pointer type of the entity, such as
+// *Product. The exported fields may be changed before the handler serves requests.
+type Handler[T dynamap.Marshaler] struct {
+	Client       *dynamap.Client   // The client of the table holding the entities
+	Prefix       string            // The key prefix of the entities
+	Label        string            // The label of listed entities. Default is the first registered label, or Prefix.
+	New          func(id string) T // Creates an entity with id, holding only its key
+	Format       Format            // Default is FormatJSON
+	MaxLimit     int               // Maximum number of entities listed per page. Default is DefaultMaxLimit.
+	MaxBodyBytes int64             // Maximum size of request bodies. Default is DefaultMaxBodyBytes.
+	Paginator    dynamap.Paginator // Converts list cursors into start keys. Default is the table paginator.
+	BindCursors  bool              // If true, list cursors are rejected by lists with other parameters
+}
+
+// NewHandler creates a [Handler] of the entities with prefix. newEntity creates an entity
+// with an id, holding only its key.
+func NewHandler[T dynamap.Marshaler](client *dynamap.Client, prefix string, newEntity func(id string) T) *Handler[T] {
+	return &Handler[T]{
+		Client:       client,
+		Prefix:       prefix,
+		New:          newEntity,
+		Format:       FormatJSON,
+		MaxLimit:     DefaultMaxLimit,
+		MaxBodyBytes: DefaultMaxBodyBytes,
+		Paginator:    client.Table.Paginator(client),
+	}
+}
+
+// Register registers the handlers of the entities on mux under path, such as "/products".
+func (h *Handler[T]) Register(mux *http.ServeMux, path string) {
+	path = strings.TrimSuffix(path, "/")
+	mux.HandleFunc("GET "+path, h.List)
+	mux.HandleFunc("GET "+path+"/{id}", h.Get)
+	mux.HandleFunc("PUT "+path+"/{id}", h.Put)
+	mux.HandleFunc("DELETE "+path+"/{id}", h.Delete)
+}
+
+// Get writes the entity identified by the id path value.
+func (h *Handler[T]) Get(w http.ResponseWriter, r *http.Request) {
+	entity := h.New(r.PathValue("id"))
+	rel, err := h.Client.Get(r.Context(), entity, entity)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeEntity(w, http.StatusOK, entity, rel)
+}
+
+// Put writes the entity in the request body under the id path value, and writes it back.
+// The body may not change the key of the entity.
+func (h *Handler[T]) Put(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	entity := h.New(id)
+	if err := h.decode(w, r, entity); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	want, err := h.key(h.New(id))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if got, err := h.key(entity); err != nil {
+		h.writeError(w, err)
+		return
+	} else if got != want {
+		h.writeError(w, fmt.Errorf("%w: the body key %s does not match %s", dynamap.ErrValidation, got, want))
+		return
+	}
+
+	if err := h.Client.Put(r.Context(), entity); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if h.Format != FormatJSONAPI {
+		h.write(w, http.StatusOK, entity)
+		return
+	}
+	relationships, err := dynamap.MarshalRelationships(entity, h.Client.Table.MarshalOptions, func(mo *dynamap.MarshalOptions) {
+		mo.SkipRefs = true
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeEntity(w, http.StatusOK, entity, relationships[0])
+}
+
+// Delete deletes the entity identified by the id path value.
+func (h *Handler[T]) Delete(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.Client.Delete(r.Context(), h.New(r.PathValue("id"))); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List writes a page of the entities with the label of the handler. It accepts the query
+// parameters:
+//
+//	limit   number of entities per page, up to MaxLimit
+//	cursor  cursor of the page, returned by the previous page
+//	desc    if true, lists entities in descending ref sort key order
+//	prefix  only lists entities whose ref sort key starts with prefix
+//	from    only lists entities whose ref sort key is at least from
+//	to      only lists entities whose ref sort key is at most to
+//
+// The cursor of the next page is returned in the "cursor" member of JSON responses, and
+// in the meta of JSON:API documents. It is empty on the last page.
+func (h *Handler[T]) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query, err := h.listQuery(r)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if h.BindCursors {
+		if ctx, err = h.bindCursors(ctx, query); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	if query.StartKey, err = h.Paginator.StartKey(ctx, r.URL.Query().Get("cursor")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	items, lastKey, err := h.Client.QueryPage(ctx, query)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var (
+		entities      = make([]T, 0, len(items))
+		relationships = make([]dynamap.Relationship, 0, len(items))
+	)
+	for _, item := range items {
+		entity := h.New("")
+		rel, err := dynamap.UnmarshalSelf(item, entity, h.Client.Table.MarshalOptions)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		entities = append(entities, entity)
+		relationships = append(relationships, rel)
+	}
+
+	cursor, err := h.Paginator.PageCursor(ctx, lastKey)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if h.Format == FormatJSONAPI {
+		document, err := jsonapi.MarshalListDocument(relationships, h.Client.Table.MarshalOptions)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		if cursor != "" {
+			document.Meta = map[string]any{"cursor": cursor}
+		}
+		h.write(w, http.StatusOK, document)
+		return
+	}
+
+	h.write(w, http.StatusOK, listResponse[T]{Items: entities, Cursor: cursor})
+}
+
+// listResponse is the JSON response of [Handler.List].
+type listResponse[T any] struct {
+	Items  []T    `json:"items"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// listQuery returns the query of the list request parameters.
+func (h *Handler[T]) listQuery(r *http.Request) (*dynamap.QueryList, error) {
+	params := r.URL.Query()
+	query := &dynamap.QueryList{Label: h.label(), Limit: min(DefaultLimit, h.MaxLimit)}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%w: limit must be a positive integer", dynamap.ErrValidation)
+		}
+		query.Limit = min(n, h.MaxLimit)
+	}
+
+	if desc := params.Get("desc"); desc != "" {
+		descending, err := strconv.ParseBool(desc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: desc must be a boolean", dynamap.ErrValidation)
+		}
+		query.SortDescending = descending
+	}
+
+	var (
+		sortKey  = expression.Key(dynamap.AttributeNameRefSortKey)
+		prefix   = params.Get("prefix")
+		from, to = params.Get("from"), params.Get("to")
+	)
+	switch {
+	case prefix != "" && (from != "" || to != ""):
+		return nil, fmt.Errorf("%w: prefix cannot be combined with from or to", dynamap.ErrValidation)
+	case prefix != "":
+		query.RefSortFilter = dynamap.RefSortPrefix(prefix)
+	case from != "" && to != "":
+		query.RefSortFilter = sortKey.Between(expression.Value(from), expression.Value(to))
+	case from != "":
+		query.RefSortFilter = sortKey.GreaterThanEqual(expression.Value(from))
+	case to != "":
+		query.RefSortFilter = sortKey.LessThanEqual(expression.Value(to))
+	}
+
+	return query, nil
+}
+
+// bindCursors returns a copy of ctx binding list cursors to the fingerprint of query.
+func (h *Handler[T]) bindCursors(ctx context.Context, query *dynamap.QueryList) (context.Context, error) {
+	input, err := h.Client.Table.MarshalQuery(query, dynamap.MarshalOptionsFromContext(ctx)...)
+	if err != nil {
+		return ctx, err
+	}
+	fingerprint, err := dynamap.QueryFingerprint(input)
+	if err != nil {
+		return ctx, err
+	}
+	return dynamap.WithCursorFingerprint(ctx, fingerprint), nil
+}
+
+// label returns the label of listed entities.
+func (h *Handler[T]) label() string {
+	if h.Label != "" {
+		return h.Label
+	}
+	if registry := h.Client.Table.Registry; registry != nil {
+		if schema, ok := registry.Schema(h.Prefix); ok && len(schema.Labels) > 0 {
+			return schema.Labels[0]
+		}
+	}
+	return h.Prefix
+}
+
+// key returns the self key of entity.
+func (h *Handler[T]) key(entity T) (string, error) {
+	input, err := h.Client.Table.MarshalGet(entity)
+	if err != nil {
+		return "", err
+	}
+	source, target, err := dynamap.UnmarshalTableKey(input.Key)
+	if err != nil {
+		return "", err
+	}
+	return source + " " + target, nil
+}
+
+// decode decodes the request body into entity.
+func (h *Handler[T]) decode(w http.ResponseWriter, r *http.Request, entity T) error {
+	body := http.MaxBytesReader(w, r.Body, h.MaxBodyBytes)
+	decoder := json.NewDecoder(body)
+
+	if h.Format != FormatJSONAPI {
+		if err := decoder.Decode(entity); err != nil {
+			return fmt.Errorf("%w: invalid body: %w", dynamap.ErrValidation, err)
+		}
+		return nil
+	}
+
+	var document struct {
+		Data *jsonapi.Resource `json:"data"`
+	}
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("%w: invalid body: %w", dynamap.ErrValidation, err)
+	}
+	if document.Data == nil {
+		return fmt.Errorf("%w: the body has no primary data", dynamap.ErrValidation)
+	}
+	if document.Data.Type != "" && document.Data.Type != h.Prefix {
+		return fmt.Errorf("%w: expected a resource of type %s, got %s", dynamap.ErrValidation, h.Prefix, document.Data.Type)
+	}
+
+	attributes, err := attributevalue.MarshalMap(document.Data.Attributes)
+	if err != nil {
+		return fmt.Errorf("%w: invalid attributes: %w", dynamap.ErrValidation, err)
+	}
+	if err := attributevalue.UnmarshalMap(attributes, entity); err != nil {
+		return fmt.Errorf("%w: invalid attributes: %w", dynamap.ErrValidation, err)
+	}
+	return nil
+}
+
+// writeEntity writes the entity, or a JSON:API document of its self relationship.
+func (h *Handler[T]) writeEntity(w http.ResponseWriter, status int, entity T, rel dynamap.Relationship) {
+	if h.Format != FormatJSONAPI {
+		h.write(w, status, entity)
+		return
+	}
+
+	document, err := jsonapi.MarshalDocument([]dynamap.Relationship{rel}, h.Client.Table.MarshalOptions)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.write(w, status, document)
+}
+
+// write writes body as JSON with status.
+func (h *Handler[T]) write(w http.ResponseWriter, status int, body any) {
+	contentType := "application/json"
+	if h.Format == FormatJSONAPI {
+		contentType = "application/vnd.api+json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// errorResponse is the JSON response of errors.
+type errorResponse struct {
+	Error  string              `json:"error"`
+	Fields dynamap.FieldErrors `json:"fields,omitempty"`
+}
+
+// errorObject is a JSON:API error object.
+type errorObject struct {
+	Status string            `json:"status"`
+	Title  string            `json:"title"`
+	Detail string            `json:"detail"`
+	Source map[string]string `json:"source,omitempty"`
+}
+
+// writeError writes err with the status of its category.
+func (h *Handler[T]) writeError(w http.ResponseWriter, err error) {
+	status := Status(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = http.StatusText(status) // Internal errors are not exposed to callers
+	}
+
+	var fields dynamap.FieldErrors
+	errors.As(err, &fields)
+
+	if h.Format != FormatJSONAPI {
+		h.write(w, status, errorResponse{Error: message, Fields: fields})
+		return
+	}
+
+	var objects []errorObject
+	for _, field := range fields {
+		objects = append(objects, errorObject{
+			Status: strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: field.Message,
+			Source: map[string]string{"pointer": "/data/attributes/" + field.Field},
+		})
+	}
+	if len(objects) == 0 {
+		objects = append(objects, errorObject{Status: strconv.Itoa(status), Title: http.StatusText(status), Detail: message})
+	}
+	h.write(w, status, map[string]any{"errors": objects})
+}
+
+// Status returns the HTTP status of err by its category:
+//
+//	dynamap.ErrNotFound         404 Not Found
+//	dynamap.ErrValidation       400 Bad Request
+//	dynamap.ErrCursorExpired    400 Bad Request
+//	dynamap.ErrConditionFailed  409 Conflict
+//	dynamap.ErrThrottled        503 Service Unavailable
+//
+// Other errors are 500 Internal Server Error, and request bodies that are too large are
+// 413 Request Entity Too Large.
+func Status(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, dynamap.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dynamap.ErrValidation), errors.Is(err, dynamap.ErrCursorExpired):
+		return http.StatusBadRequest
+	case errors.Is(err, dynamap.ErrConditionFailed):
+		return http.StatusConflict
+	case errors.Is(err, dynamap.ErrThrottled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
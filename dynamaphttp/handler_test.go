@@ -0,0 +1,289 @@
+package dynamaphttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nisimpson/dynamap"
+)
+
+type product struct {
+	ID       string `json:"id" dynamodbav:"id"`
+	Category string `json:"category" dynamodbav:"category"`
+}
+
+func (p *product) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("product", p.ID)
+	opts.RefSortKey = p.Category
+	return nil
+}
+
+// memoryClient stores items in memory. Queries return the items with the queried label
+// in ref sort key order, one page at a time.
+type memoryClient struct {
+	dynamap.DynamoDBClient
+	items   map[string]dynamap.Item
+	queries []*dynamodb.QueryInput
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{items: make(map[string]dynamap.Item)}
+}
+
+func itemKey(item dynamap.Item) string {
+	return dynamap.StringValue(item["hk"]) + "|" + dynamap.StringValue(item["sk"])
+}
+
+func (c *memoryClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.items[itemKey(params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *memoryClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.items[itemKey(params.Key)]}, nil
+}
+
+func (c *memoryClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	delete(c.items, itemKey(params.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (c *memoryClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, params)
+
+	var labels []string
+	for _, value := range params.ExpressionAttributeValues {
+		labels = append(labels, dynamap.StringValue(value))
+	}
+
+	var items []dynamap.Item
+	for _, item := range c.items {
+		if slices.Contains(labels, dynamap.StringValue(item["label"])) {
+			items = append(items, item)
+		}
+	}
+	slices.SortFunc(items, func(a, b dynamap.Item) int {
+		return strings.Compare(dynamap.StringValue(a["gsi1_sk"]), dynamap.StringValue(b["gsi1_sk"]))
+	})
+
+	if params.ExclusiveStartKey != nil {
+		start := slices.IndexFunc(items, func(item dynamap.Item) bool {
+			return itemKey(item) == itemKey(params.ExclusiveStartKey)
+		})
+		items = items[start+1:]
+	}
+
+	output := &dynamodb.QueryOutput{Items: items}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		output.Items = items[:limit]
+		output.LastEvaluatedKey = dynamap.Item{"hk": items[limit-1]["hk"], "sk": items[limit-1]["sk"]}
+	}
+	return output, nil
+}
+
+func newServer(t *testing.T, format Format) (*httptest.Server, *memoryClient) {
+	t.Helper()
+	client := newMemoryClient()
+	table := dynamap.NewTable("test-table")
+	table.Validator = func(entity any) error {
+		if p, ok := entity.(*product); ok && p.Category == "" {
+			return dynamap.FieldErrors{{Field: "category", Message: "is required"}}
+		}
+		return nil
+	}
+
+	handler := NewHandler(dynamap.NewClient(table, client), "product", func(id string) *product {
+		return &product{ID: id}
+	})
+	handler.Format = format
+	handler.BindCursors = true
+
+	mux := http.NewServeMux()
+	handler.Register(mux, "/products")
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, client
+}
+
+func do(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("crud", func(t *testing.T) {
+		server, _ := newServer(t, FormatJSON)
+		url := server.URL + "/products/P1"
+
+		var got product
+		if status := do(t, http.MethodPut, url, `{"category":"books"}`, &got); status != http.StatusOK || got.ID != "P1" {
+			t.Fatalf("Expected the product to be written, got %d %+v", status, got)
+		}
+		if status := do(t, http.MethodGet, url, "", &got); status != http.StatusOK || got.Category != "books" {
+			t.Errorf("Expected the product, got %d %+v", status, got)
+		}
+		if status := do(t, http.MethodDelete, url, "", nil); status != http.StatusNoContent {
+			t.Errorf("Expected no content, got %d", status)
+		}
+
+		var failure errorResponse
+		if status := do(t, http.MethodGet, url, "", &failure); status != http.StatusNotFound || failure.Error == "" {
+			t.Errorf("Expected not found, got %d %+v", status, failure)
+		}
+	})
+
+	t.Run("invalid writes", func(t *testing.T) {
+		server, client := newServer(t, FormatJSON)
+
+		var failure errorResponse
+		if status := do(t, http.MethodPut, server.URL+"/products/P1", `{}`, &failure); status != http.StatusBadRequest || len(failure.Fields) != 1 {
+			t.Errorf("Expected the field errors, got %d %+v", status, failure)
+		}
+		if status := do(t, http.MethodPut, server.URL+"/products/P1", `{"id":"P2","category":"books"}`, nil); status != http.StatusBadRequest {
+			t.Errorf("Expected the key change to be rejected, got %d", status)
+		}
+		if status := do(t, http.MethodPut, server.URL+"/products/P1", `{`, nil); status != http.StatusBadRequest {
+			t.Errorf("Expected the invalid body to be rejected, got %d", status)
+		}
+		if len(client.items) != 0 {
+			t.Errorf("Expected nothing to be written, got %v", client.items)
+		}
+	})
+
+	t.Run("list pages", func(t *testing.T) {
+		server, _ := newServer(t, FormatJSON)
+		for _, id := range []string{"P1", "P2", "P3"} {
+			do(t, http.MethodPut, server.URL+"/products/"+id, `{"category":"books `+id+`"}`, nil)
+		}
+
+		var (
+			ids  []string
+			page listResponse[product]
+		)
+		url := server.URL + "/products?limit=2"
+		for {
+			if status := do(t, http.MethodGet, url, "", &page); status != http.StatusOK {
+				t.Fatalf("Expected a page, got %d", status)
+			}
+			for _, p := range page.Items {
+				ids = append(ids, p.ID)
+			}
+			if page.Cursor == "" {
+				break
+			}
+			if status := do(t, http.MethodGet, server.URL+"/products?prefix=books&cursor="+page.Cursor, "", nil); status != http.StatusBadRequest {
+				t.Errorf("Expected the cursor to be rejected by another list, got %d", status)
+			}
+			url = server.URL + "/products?limit=2&cursor=" + page.Cursor
+			page = listResponse[product]{}
+		}
+
+		if !slices.Equal(ids, []string{"P1", "P2", "P3"}) {
+			t.Errorf("Expected all products, got %v", ids)
+		}
+		if status := do(t, http.MethodGet, server.URL+"/products?cursor=unknown", "", nil); status != http.StatusBadRequest {
+			t.Errorf("Expected an unknown cursor to be rejected, got %d", status)
+		}
+	})
+
+	t.Run("list filters", func(t *testing.T) {
+		server, client := newServer(t, FormatJSON)
+
+		tests := []struct {
+			query string
+			want  string
+		}{
+			{"prefix=books", "begins_with"},
+			{"from=a&to=b", "BETWEEN"},
+			{"from=a", ">="},
+			{"to=b", "<="},
+		}
+		for _, tt := range tests {
+			if status := do(t, http.MethodGet, server.URL+"/products?"+tt.query, "", nil); status != http.StatusOK {
+				t.Fatalf("Expected a page for %s, got %d", tt.query, status)
+			}
+			condition := aws.ToString(client.queries[len(client.queries)-1].KeyConditionExpression)
+			if !strings.Contains(condition, tt.want) {
+				t.Errorf("Expected %s in the key condition of %s, got %s", tt.want, tt.query, condition)
+			}
+		}
+
+		for _, query := range []string{"limit=0", "desc=maybe", "prefix=a&from=b"} {
+			if status := do(t, http.MethodGet, server.URL+"/products?"+query, "", nil); status != http.StatusBadRequest {
+				t.Errorf("Expected %s to be rejected, got %d", query, status)
+			}
+		}
+	})
+
+	t.Run("json api", func(t *testing.T) {
+		server, _ := newServer(t, FormatJSONAPI)
+
+		body := `{"data":{"type":"product","attributes":{"category":"books"}}}`
+		if status := do(t, http.MethodPut, server.URL+"/products/P1", body, nil); status != http.StatusOK {
+			t.Fatalf("Expected the product to be written, got %d", status)
+		}
+
+		var document struct {
+			Data []struct {
+				Type       string         `json:"type"`
+				ID         string         `json:"id"`
+				Attributes map[string]any `json:"attributes"`
+			} `json:"data"`
+		}
+		if status := do(t, http.MethodGet, server.URL+"/products", "", &document); status != http.StatusOK {
+			t.Fatalf("Expected a page, got %d", status)
+		}
+		if len(document.Data) != 1 || document.Data[0].ID != "P1" || document.Data[0].Attributes["category"] != "books" {
+			t.Errorf("Expected the product resource, got %+v", document)
+		}
+
+		var failure struct {
+			Errors []errorObject `json:"errors"`
+		}
+		if status := do(t, http.MethodPut, server.URL+"/products/P2", `{"data":{"type":"product"}}`, &failure); status != http.StatusBadRequest ||
+			len(failure.Errors) != 1 || failure.Errors[0].Source["pointer"] != "/data/attributes/category" {
+			t.Errorf("Expected a field error object, got %d %+v", status, failure)
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{dynamap.ErrItemNotFound, http.StatusNotFound},
+		{dynamap.ErrInvalidKey, http.StatusBadRequest},
+		{dynamap.ErrCursorExpired, http.StatusBadRequest},
+		{&dynamap.ConditionFailedError{}, http.StatusConflict},
+		{dynamap.ErrThrottled, http.StatusServiceUnavailable},
+		{&http.MaxBytesError{Limit: 1}, http.StatusRequestEntityTooLarge},
+		{context.Canceled, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := Status(tt.err); got != tt.want {
+			t.Errorf("Status(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package dynamapio exports and imports the items of dynamap tables, for backups,
+// environment cloning and fixture generation from production samples.
+//
+// [Export] scans a table and writes its items as JSON Lines in DynamoDB JSON, or as a
+// JSON:API document that dynamock can seed test tables from:
+//
+//	count, err := dynamapio.Export(ctx, client, table, file, func(eo *dynamapio.ExportOptions) {
+//		eo.Format = dynamapio.FormatJSONAPI
+//		eo.Labels = []string{"order"}
+//	})
+//
+// [Import] writes the exported items back with batch writes, optionally paced by a
+// [dynamap.RateLimiter]:
+//
+//	count, err := dynamapio.Import(ctx, client, staging, file, func(o *dynamapio.ImportOptions) {
+//		o.RateLimiter = dynamap.NewRateLimiter(0, 100)
+//	})
+package dynamapio
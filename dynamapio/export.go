@@ -0,0 +1,138 @@
+package dynamapio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nisimpson/dynamap"
+)
+
+// Format is the encoding of exported items.
+type Format string
+
+const (
+	// FormatJSONLines writes one item per line in DynamoDB JSON, wrapped as {"Item":{...}}
+	// like DynamoDB table exports to S3. It is lossless and suited to backups.
+	FormatJSONLines Format = "jsonl"
+	// FormatJSONAPI writes a JSON:API document with one resource per entity, holding its
+	// data and the targets of its relationships. Timestamps, expirations, edge data and
+	// chunked or offloaded data are not kept; it is suited to test fixtures.
+	FormatJSONAPI Format = "jsonapi"
+)
+
+// ExportOptions configures [Export].
+type ExportOptions struct {
+	Format   Format   // Default is FormatJSONLines
+	Labels   []string // If set, only entities with these labels and their relationships are exported
+	PageSize int32    // Maximum number of items read per scan request. Zero uses the DynamoDB default.
+}
+
+// line is a line of a JSON Lines export.
+type line struct {
+	Item map[string]map[string]json.RawMessage `json:"Item"`
+}
+
+// Export scans every item of the table and writes it to w, returning the number of items
+// exported. Items are streamed as they are read in [FormatJSONLines]; [FormatJSONAPI]
+// buffers the entities until the scan completes.
+//
+// Example:
+//
+//	file, err := os.Create("backup.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//	defer file.Close()
+//	count, err := dynamapio.Export(ctx, client, table, file, func(eo *dynamapio.ExportOptions) {
+//		eo.Labels = []string{"order", "product"}
+//	})
+func Export(ctx context.Context, client dynamap.ScanClient, table *dynamap.Table, w io.Writer, opts ...func(*ExportOptions)) (int, error) {
+	options := ExportOptions{Format: FormatJSONLines}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var (
+		buffered = bufio.NewWriter(w)
+		document resources
+		count    int
+	)
+
+	input := &dynamodb.ScanInput{TableName: aws.String(table.TableName)}
+	if options.PageSize > 0 {
+		input.Limit = aws.Int32(options.PageSize)
+	}
+
+	for {
+		output, err := client.Scan(ctx, input)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan table %s: %w", table.TableName, err)
+		}
+
+		for _, item := range output.Items {
+			rel, err := dynamap.UnmarshalRelationship(item, table.MarshalOptions)
+			if err != nil {
+				return count, err
+			}
+			if !matchLabels(rel, options.Labels, table) {
+				continue
+			}
+
+			switch options.Format {
+			case FormatJSONLines:
+				encoded, err := encodeItem(item)
+				if err != nil {
+					return count, err
+				}
+				data, err := json.Marshal(map[string]any{"Item": encoded})
+				if err != nil {
+					return count, fmt.Errorf("failed to encode item: %w", err)
+				}
+				if _, err := buffered.Write(append(data, '\n')); err != nil {
+					return count, fmt.Errorf("failed to write item: %w", err)
+				}
+			case FormatJSONAPI:
+				if err := document.add(rel, table.MarshalOptions); err != nil {
+					return count, fmt.Errorf("failed to add item to document: %w", err)
+				}
+			default:
+				return count, fmt.Errorf("unsupported export format %q", options.Format)
+			}
+			count++
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	if options.Format == FormatJSONAPI {
+		encoder := json.NewEncoder(buffered)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document.document()); err != nil {
+			return count, fmt.Errorf("failed to write document: %w", err)
+		}
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write export: %w", err)
+	}
+	return count, nil
+}
+
+// matchLabels returns true if labels is empty, or the relationship label or the prefix
+// of its source entity is one of labels.
+func matchLabels(rel dynamap.Relationship, labels []string, table *dynamap.Table) bool {
+	if len(labels) == 0 || slices.Contains(labels, rel.Label) {
+		return true
+	}
+	prefix, _, _, err := dynamap.ParseLabel(rel.Label, table.MarshalOptions)
+	return err == nil && slices.Contains(labels, prefix)
+}
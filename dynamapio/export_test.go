@@ -0,0 +1,184 @@
+package dynamapio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+type order struct {
+	ID       string   `dynamodbav:"id"`
+	Status   string   `dynamodbav:"status"`
+	Products []string `dynamodbav:"-"`
+}
+
+func (o *order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	return nil
+}
+
+func (o *order) MarshalRefs(ctx *dynamap.RelationshipContext) error {
+	for _, id := range o.Products {
+		ctx.AddOne("products", &product{ID: id})
+	}
+	return nil
+}
+
+type product struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (p *product) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("product", p.ID)
+	return nil
+}
+
+// memoryClient stores items in memory, scanning them in key order one page at a time.
+type memoryClient struct {
+	dynamap.DynamoDBClient
+	items   map[string]dynamap.Item
+	batches int
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{items: make(map[string]dynamap.Item)}
+}
+
+func itemKey(item dynamap.Item) string {
+	return item["hk"].(*types.AttributeValueMemberS).Value + "|" + item["sk"].(*types.AttributeValueMemberS).Value
+}
+
+func (c *memoryClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.batches++
+	for _, requests := range params.RequestItems {
+		for _, request := range requests {
+			c.items[itemKey(request.PutRequest.Item)] = request.PutRequest.Item
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *memoryClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		start = sort.SearchStrings(keys, itemKey(params.ExclusiveStartKey)) + 1
+	}
+	end := len(keys)
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	output := &dynamodb.ScanOutput{}
+	for _, key := range keys[start:end] {
+		output.Items = append(output.Items, c.items[key])
+	}
+	if end < len(keys) {
+		output.LastEvaluatedKey = output.Items[len(output.Items)-1]
+	}
+	return output, nil
+}
+
+// seed writes the entities to a new memory client.
+func seed(t *testing.T, table *dynamap.Table, entities ...dynamap.RefMarshaler) *memoryClient {
+	t.Helper()
+	client := newMemoryClient()
+	for _, entity := range entities {
+		batches, err := table.MarshalBatch(entity)
+		if err != nil {
+			t.Fatalf("Failed to marshal entity: %v", err)
+		}
+		for _, batch := range batches {
+			if _, err := client.BatchWriteItem(context.Background(), batch); err != nil {
+				t.Fatalf("Failed to write batch: %v", err)
+			}
+		}
+	}
+	client.batches = 0
+	return client
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+	client := seed(t, table,
+		&order{ID: "O1", Status: "shipped", Products: []string{"P1", "P2"}},
+		&order{ID: "O2", Status: "pending"},
+	)
+	client.items["product#P1|product#P1"] = mustMarshalPut(t, table, &product{ID: "P1"})
+
+	t.Run("json lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := Export(ctx, client, table, &buf, func(eo *ExportOptions) { eo.PageSize = 2 })
+		if err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+		if count != 5 {
+			t.Errorf("Expected 5 items, got %d", count)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 5 || !strings.HasPrefix(lines[0], `{"Item":{`) {
+			t.Errorf("Expected 5 item lines, got %s", buf.String())
+		}
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := Export(ctx, client, table, &buf, func(eo *ExportOptions) { eo.Labels = []string{"product"} })
+		if err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 product item, got %d", count)
+		}
+	})
+
+	t.Run("json api", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := Export(ctx, client, table, &buf, func(eo *ExportOptions) {
+			eo.Format = FormatJSONAPI
+			eo.Labels = []string{"order"}
+		}); err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		var document []Resource
+		if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+			t.Fatalf("Failed to parse document: %v", err)
+		}
+		if len(document) != 2 {
+			t.Fatalf("Expected 2 orders, got %d", len(document))
+		}
+
+		o1 := document[0]
+		if o1.Type != "order" || o1.ID != "O1" || o1.Attributes["status"] != "shipped" {
+			t.Errorf("Unexpected resource %+v", o1)
+		}
+		if products := o1.Relationships["products"].Data; len(products) != 2 || products[1] != (ResourceIdentifier{Type: "product", ID: "P2"}) {
+			t.Errorf("Expected 2 products, got %+v", products)
+		}
+	})
+}
+
+func mustMarshalPut(t *testing.T, table *dynamap.Table, in dynamap.Marshaler) dynamap.Item {
+	t.Helper()
+	input, err := table.MarshalPut(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal put: %v", err)
+	}
+	return input.Item
+}
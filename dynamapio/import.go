@@ -0,0 +1,115 @@
+package dynamapio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nisimpson/dynamap"
+)
+
+// ImportOptions configures [Import].
+type ImportOptions struct {
+	Format      Format               // Default is FormatJSONLines
+	RateLimiter *dynamap.RateLimiter // Optional limiter pacing the writes, e.g. to leave capacity for production traffic
+	BatchSize   int                  // Number of items buffered between writes. Default is dynamap.MaxBatchSize.
+}
+
+// Import reads items exported by [Export] from r and writes them to the table with batch
+// writes, returning the number of items written. Items in [FormatJSONLines] are written
+// as is; entities in [FormatJSONAPI] are marshaled into their self relationships and
+// relationships with the table's options.
+//
+// Example:
+//
+//	file, err := os.Open("backup.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//	defer file.Close()
+//	count, err := dynamapio.Import(ctx, client, table, file, func(o *dynamapio.ImportOptions) {
+//		o.RateLimiter = dynamap.NewRateLimiter(0, 100)
+//	})
+func Import(ctx context.Context, client dynamap.DynamoDBClient, table *dynamap.Table, r io.Reader, opts ...func(*ImportOptions)) (int, error) {
+	options := ImportOptions{Format: FormatJSONLines, BatchSize: dynamap.MaxBatchSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.RateLimiter != nil {
+		client = dynamap.NewClient(table, client, options.RateLimiter)
+	}
+
+	var (
+		pending []dynamap.Item
+		count   int
+	)
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if err := table.PutItems(ctx, client, pending); err != nil {
+			return err
+		}
+		count += len(pending)
+		pending = pending[:0]
+		return nil
+	}
+
+	write := func(items ...dynamap.Item) error {
+		pending = append(pending, items...)
+		if len(pending) >= options.BatchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(r)
+	switch options.Format {
+	case FormatJSONLines:
+		for {
+			var l line
+			if err := decoder.Decode(&l); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return count, fmt.Errorf("failed to parse item %d: %w", count+len(pending)+1, err)
+			}
+
+			item, err := decodeItem(l.Item)
+			if err != nil {
+				return count, fmt.Errorf("failed to decode item %d: %w", count+len(pending)+1, err)
+			}
+			if err := write(item); err != nil {
+				return count, err
+			}
+		}
+	case FormatJSONAPI:
+		var document []*Resource
+		if err := decoder.Decode(&document); err != nil {
+			return count, fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+
+		for i, resource := range document {
+			batches, err := table.MarshalBatch(resource)
+			if err != nil {
+				return count, fmt.Errorf("failed to marshal resource at index %d: %w", i, err)
+			}
+			for _, batch := range batches {
+				for _, request := range batch.RequestItems[table.TableName] {
+					if err := write(request.PutRequest.Item); err != nil {
+						return count, err
+					}
+				}
+			}
+		}
+	default:
+		return count, fmt.Errorf("unsupported import format %q", options.Format)
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
@@ -0,0 +1,74 @@
+package dynamapio
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nisimpson/dynamap"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+
+	t.Run("round trip", func(t *testing.T) {
+		source := seed(t, table, &order{ID: "O1", Status: "shipped", Products: []string{"P1", "P2"}})
+
+		var buf bytes.Buffer
+		if _, err := Export(ctx, source, table, &buf); err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		target := newMemoryClient()
+		count, err := Import(ctx, target, table, &buf, func(o *ImportOptions) {
+			o.BatchSize = 2
+			o.RateLimiter = dynamap.NewRateLimiter(0, 1000)
+		})
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+		if count != 3 || target.batches != 2 {
+			t.Errorf("Expected 3 items in 2 batches, got %d in %d", count, target.batches)
+		}
+		if !maps.EqualFunc(source.items, target.items, func(a, b dynamap.Item) bool { return reflect.DeepEqual(a, b) }) {
+			t.Error("Expected imported items to equal the exported items")
+		}
+	})
+
+	t.Run("json api", func(t *testing.T) {
+		document := `[
+			{"type": "order", "id": "O1", "attributes": {"status": "shipped"},
+			 "relationships": {"products": {"data": [{"type": "product", "id": "P1"}]}, "customer": {"data": {"type": "customer", "id": "C1"}}}},
+			{"type": "product", "id": "P1"}
+		]`
+
+		target := newMemoryClient()
+		count, err := Import(ctx, target, table, strings.NewReader(document), func(o *ImportOptions) { o.Format = FormatJSONAPI })
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+		if count != 4 {
+			t.Errorf("Expected 4 items, got %d", count)
+		}
+
+		var o struct {
+			Status string `dynamodbav:"status"`
+		}
+		if _, err := dynamap.UnmarshalSelf(target.items["order#O1|order#O1"], &o); err != nil || o.Status != "shipped" {
+			t.Errorf("Expected the order data, got %+v, %v", o, err)
+		}
+		if _, ok := target.items["order#O1|customer#C1"]; !ok {
+			t.Error("Expected the single customer relationship")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := Import(ctx, newMemoryClient(), table, strings.NewReader(`{"Item":{"hk":{"X":"1"}}}`)); err == nil {
+			t.Error("Expected an error for an invalid attribute type")
+		}
+	})
+}
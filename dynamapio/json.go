@@ -0,0 +1,160 @@
+package dynamapio
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// MarshalItem encodes an item as DynamoDB JSON, in which every attribute value is an
+// object keyed by its type, such as {"hk":{"S":"order#O1"}}. This is the format of the
+// DynamoDB console and of table exports to S3.
+func MarshalItem(item dynamap.Item) ([]byte, error) {
+	encoded, err := encodeItem(item)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalItem decodes an item from DynamoDB JSON.
+func UnmarshalItem(data []byte) (dynamap.Item, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+	return decodeItem(raw)
+}
+
+// encodeItem encodes each attribute of item as DynamoDB JSON.
+func encodeItem(item dynamap.Item) (map[string]any, error) {
+	encoded := make(map[string]any, len(item))
+	for name, value := range item {
+		v, err := encodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attribute %s: %w", name, err)
+		}
+		encoded[name] = v
+	}
+	return encoded, nil
+}
+
+// encodeValue encodes an attribute value as DynamoDB JSON. Binary values are encoded as
+// base64 strings by encoding/json.
+func encodeValue(value types.AttributeValue) (map[string]any, error) {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": v.Value}, nil
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": v.Value}, nil
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": v.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": v.Value}, nil
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": v.Value}, nil
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": v.Value}, nil
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": v.Value}, nil
+	case *types.AttributeValueMemberBS:
+		return map[string]any{"BS": v.Value}, nil
+	case *types.AttributeValueMemberL:
+		list := make([]any, 0, len(v.Value))
+		for _, element := range v.Value {
+			encoded, err := encodeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, encoded)
+		}
+		return map[string]any{"L": list}, nil
+	case *types.AttributeValueMemberM:
+		encoded, err := encodeItem(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"M": encoded}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute value %T", value)
+	}
+}
+
+// decodeItem decodes each attribute of a DynamoDB JSON item.
+func decodeItem(raw map[string]map[string]json.RawMessage) (dynamap.Item, error) {
+	item := make(dynamap.Item, len(raw))
+	for name, value := range raw {
+		v, err := decodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode attribute %s: %w", name, err)
+		}
+		item[name] = v
+	}
+	return item, nil
+}
+
+// decodeValue decodes a DynamoDB JSON attribute value, which has exactly one type key.
+func decodeValue(raw map[string]json.RawMessage) (types.AttributeValue, error) {
+	if len(raw) != 1 {
+		return nil, fmt.Errorf("attribute value must have exactly one type, got %d", len(raw))
+	}
+
+	for kind, data := range raw {
+		switch kind {
+		case "S":
+			var v types.AttributeValueMemberS
+			return &v, json.Unmarshal(data, &v.Value)
+		case "N":
+			var v types.AttributeValueMemberN
+			return &v, json.Unmarshal(data, &v.Value)
+		case "B":
+			var v types.AttributeValueMemberB
+			return &v, json.Unmarshal(data, &v.Value)
+		case "BOOL":
+			var v types.AttributeValueMemberBOOL
+			return &v, json.Unmarshal(data, &v.Value)
+		case "NULL":
+			var v types.AttributeValueMemberNULL
+			return &v, json.Unmarshal(data, &v.Value)
+		case "SS":
+			var v types.AttributeValueMemberSS
+			return &v, json.Unmarshal(data, &v.Value)
+		case "NS":
+			var v types.AttributeValueMemberNS
+			return &v, json.Unmarshal(data, &v.Value)
+		case "BS":
+			var v types.AttributeValueMemberBS
+			return &v, json.Unmarshal(data, &v.Value)
+		case "L":
+			var elements []map[string]json.RawMessage
+			if err := json.Unmarshal(data, &elements); err != nil {
+				return nil, err
+			}
+			list := make([]types.AttributeValue, 0, len(elements))
+			for _, element := range elements {
+				decoded, err := decodeValue(element)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, decoded)
+			}
+			return &types.AttributeValueMemberL{Value: list}, nil
+		case "M":
+			var attributes map[string]map[string]json.RawMessage
+			if err := json.Unmarshal(data, &attributes); err != nil {
+				return nil, err
+			}
+			decoded, err := decodeItem(attributes)
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberM{Value: decoded}, nil
+		default:
+			return nil, fmt.Errorf("unsupported attribute type %q", kind)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,40 @@
+package dynamapio
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+func TestMarshalItem(t *testing.T) {
+	item := dynamap.Item{
+		"s":    &types.AttributeValueMemberS{Value: "text"},
+		"n":    &types.AttributeValueMemberN{Value: "1.5"},
+		"b":    &types.AttributeValueMemberB{Value: []byte{1, 2}},
+		"bool": &types.AttributeValueMemberBOOL{Value: true},
+		"null": &types.AttributeValueMemberNULL{Value: true},
+		"ss":   &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"ns":   &types.AttributeValueMemberNS{Value: []string{"1"}},
+		"bs":   &types.AttributeValueMemberBS{Value: [][]byte{{3}}},
+		"l":    &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "x"}}},
+		"m":    &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"k": &types.AttributeValueMemberN{Value: "2"}}},
+	}
+
+	data, err := MarshalItem(item)
+	if err != nil {
+		t.Fatalf("Failed to marshal item: %v", err)
+	}
+	decoded, err := UnmarshalItem(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal item: %v", err)
+	}
+	if !reflect.DeepEqual(item, decoded) {
+		t.Errorf("Expected the item to round trip, got %s", data)
+	}
+
+	if _, err := UnmarshalItem([]byte(`{"a":{"S":"x","N":"1"}}`)); err == nil {
+		t.Error("Expected an error for an attribute value with two types")
+	}
+}
@@ -0,0 +1,141 @@
+package dynamapio
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// Resource is an entity in JSON:API format, as understood by dynamock.SeedTestData.SeedFromJSON.
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    map[string]any          `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+}
+
+// Relationship is a named relationship of a [Resource] in JSON:API format.
+type Relationship struct {
+	Data Linkage `json:"data"`
+}
+
+// ResourceIdentifier identifies the target of a relationship.
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Linkage is the list of targets of a relationship. It is encoded as an array, and
+// decoded from an array, a single resource identifier or null.
+type Linkage []ResourceIdentifier
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Linkage) UnmarshalJSON(data []byte) error {
+	var identifiers []ResourceIdentifier
+	if err := json.Unmarshal(data, &identifiers); err == nil {
+		*l = identifiers
+		return nil
+	}
+
+	var identifier *ResourceIdentifier
+	if err := json.Unmarshal(data, &identifier); err != nil {
+		return fmt.Errorf("relationship data must be an object or array of objects: %w", err)
+	}
+	*l = nil
+	if identifier != nil {
+		*l = Linkage{*identifier}
+	}
+	return nil
+}
+
+// MarshalSelf implements dynamap.Marshaler.
+func (r *Resource) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	if r.Type == "" || r.ID == "" {
+		return fmt.Errorf("resource requires a type and id")
+	}
+	opts.WithSelfTarget(r.Type, r.ID)
+	return nil
+}
+
+// MarshalRefs implements dynamap.RefMarshaler.
+func (r *Resource) MarshalRefs(ctx *dynamap.RelationshipContext) error {
+	for name, relationship := range r.Relationships {
+		for _, identifier := range relationship.Data {
+			ctx.AddOne(name, &Resource{Type: identifier.Type, ID: identifier.ID})
+		}
+	}
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue implements attributevalue.Marshaler, storing the
+// attributes as the entity data.
+func (r *Resource) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	attributes := r.Attributes
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	return attributevalue.Marshal(attributes)
+}
+
+// resources groups relationships into resources by source entity, in the order each
+// entity first appears.
+type resources struct {
+	order []string
+	index map[string]*Resource
+}
+
+// add adds the relationship to the resource of its source entity.
+func (r *resources) add(rel dynamap.Relationship, opts func(*dynamap.MarshalOptions)) error {
+	if r.index == nil {
+		r.index = make(map[string]*Resource)
+	}
+
+	resource, ok := r.index[rel.Source]
+	if !ok {
+		prefix, id, err := dynamap.ParseKey(rel.Source, opts)
+		if err != nil {
+			return err
+		}
+		resource = &Resource{Type: prefix, ID: id}
+		r.index[rel.Source] = resource
+		r.order = append(r.order, rel.Source)
+	}
+
+	if rel.IsSelf() {
+		if attributes, ok := rel.Data.(map[string]any); ok {
+			resource.Attributes = attributes
+		}
+		return nil
+	} else if !rel.IsRef() {
+		return nil
+	}
+
+	_, _, name, err := dynamap.ParseLabel(rel.Label, opts)
+	if err != nil {
+		return err
+	}
+	prefix, id, err := dynamap.ParseKey(rel.Target, opts)
+	if err != nil {
+		return err
+	}
+
+	if resource.Relationships == nil {
+		resource.Relationships = make(map[string]Relationship)
+	}
+	relationship := resource.Relationships[name]
+	relationship.Data = append(relationship.Data, ResourceIdentifier{Type: prefix, ID: id})
+	resource.Relationships[name] = relationship
+	return nil
+}
+
+// document returns the resources in order.
+func (r *resources) document() []*Resource {
+	document := make([]*Resource, 0, len(r.order))
+	for _, source := range r.order {
+		document = append(document, r.index[source])
+	}
+	return document
+}
@@ -0,0 +1,32 @@
+// Package dynamaplock provides distributed locks and leases stored in a dynamap table.
+//
+// A lock is a self item whose hash and sort keys are "lock#<name>". A [Locker] acquires
+// it with a conditional write, so only one owner holds the lock at a time, and the
+// returned [Lease] expires unless it is renewed before [Locker.LeaseDuration] elapses.
+// Expired locks may be acquired by another owner, and are eventually deleted by the
+// table's time-to-live. The owner, fencing token and lease expiry of a lock are kept in
+// top-level attributes of its item rather than in its data, so locks work with any
+// [dynamap.Table.DataCodec].
+//
+//	locker := dynamaplock.NewLocker(table, dynamodb.NewFromConfig(cfg))
+//
+//	lease, err := locker.Acquire(ctx, "nightly-report")
+//	if err != nil {
+//		return err
+//	}
+//	defer lease.Release(ctx)
+//
+//	// Renew the lease in the background while the work runs
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	go func() {
+//		if err := lease.KeepAlive(ctx); errors.Is(err, dynamaplock.ErrLost) {
+//			cancel()
+//		}
+//	}()
+//
+// A lease can be lost without its owner noticing, for example while the process is
+// paused. Every acquisition of a lock is assigned a greater fencing token than the last;
+// pass [Lease.Token] to the resources guarded by the lock, and have them reject writes
+// carrying a token lower than one they have already seen.
+package dynamaplock
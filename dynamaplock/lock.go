@@ -0,0 +1,356 @@
+package dynamaplock
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+const (
+	// Prefix is the key prefix of lock items, whose hash and sort keys are "lock#<name>".
+	Prefix = "lock"
+	// DefaultLeaseDuration is how long a lease is held unless it is renewed.
+	DefaultLeaseDuration = 30 * time.Second
+	// DefaultRetryInterval is how long [Locker.Acquire] waits before retrying a held lock.
+	DefaultRetryInterval = time.Second
+
+	// AttributeNameOwner is the attribute of lock items recording the owner holding the lock.
+	AttributeNameOwner = "lock_owner"
+	// AttributeNameToken is the attribute of lock items recording the fencing token of the
+	// last acquisition.
+	AttributeNameToken = "lock_token"
+	// AttributeNameLeaseExpires is the attribute of lock items recording when the lease
+	// expires, in unix milliseconds.
+	AttributeNameLeaseExpires = "lock_expires_at"
+)
+
+// ttlGrace delays the time-to-live of lock items past the expiry of their lease, so the
+// table never deletes a lock that is still held.
+const ttlGrace = time.Second
+
+// The errors of the package match [dynamap.ErrConditionFailed] with [errors.Is].
+var (
+	// ErrLocked is returned when a lock is held by an unexpired lease.
+	ErrLocked error = conditionError("lock is held")
+	// ErrLost is returned when a lease expired or its lock was acquired by another lease.
+	ErrLost error = conditionError("lease lost")
+)
+
+// conditionError is an error that matches [dynamap.ErrConditionFailed] with [errors.Is].
+type conditionError string
+
+// Error implements the error interface.
+func (e conditionError) Error() string {
+	return string(e)
+}
+
+// Is reports whether target is [dynamap.ErrConditionFailed].
+func (e conditionError) Is(target error) bool {
+	return target == dynamap.ErrConditionFailed
+}
+
+// lockKey marshals the key of the lock item named name. The lock has no data; its state
+// is kept in top-level attributes, so conditions and updates address it whatever the
+// data codec of the table.
+type lockKey struct {
+	name string
+}
+
+// MarshalSelf implements dynamap.Marshaler.
+func (k lockKey) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget(Prefix, k.name)
+	opts.Registry = nil // Locks are internal and not registered entities
+	return nil
+}
+
+// lock is the state of a lock, stored in the top-level attributes of its item.
+type lock struct {
+	Owner     string `dynamodbav:"lock_owner"`
+	Token     int64  `dynamodbav:"lock_token"`
+	ExpiresAt int64  `dynamodbav:"lock_expires_at"` // Lease expiry in unix milliseconds
+}
+
+// Locker acquires named locks in a table on behalf of an owner.
+//
+// Example:
+//
+//	locker := dynamaplock.NewLocker(table, client)
+//	locker.LeaseDuration = time.Minute
+//
+//	lease, err := locker.TryAcquire(ctx, "rebuild-index")
+//	if errors.Is(err, dynamaplock.ErrLocked) {
+//		return nil // another worker is rebuilding the index
+//	}
+type Locker struct {
+	Table             *dynamap.Table         // The table holding the locks
+	Client            dynamap.DynamoDBClient // The client reading and writing the locks
+	Owner             string                 // Identifies the lock holder; recorded in the lock item
+	LeaseDuration     time.Duration          // How long a lease is held unless renewed. Default is DefaultLeaseDuration.
+	RetryInterval     time.Duration          // How long Acquire waits between attempts. Default is DefaultRetryInterval.
+	HeartbeatInterval time.Duration          // How often KeepAlive renews a lease. Default is a third of the lease duration.
+}
+
+// NewLocker creates a new [Locker] of the table with a random owner.
+func NewLocker(table *dynamap.Table, client dynamap.DynamoDBClient) *Locker {
+	return &Locker{
+		Table:         table,
+		Client:        client,
+		Owner:         rand.Text(),
+		LeaseDuration: DefaultLeaseDuration,
+		RetryInterval: DefaultRetryInterval,
+	}
+}
+
+// Acquire acquires the named lock, waiting for it to be released or to expire if it is
+// held. It returns when the lock is acquired, a request fails, or the context is done.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	interval := l.RetryInterval
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+
+	for {
+		lease, err := l.TryAcquire(ctx, name)
+		if !errors.Is(err, ErrLocked) {
+			return lease, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// TryAcquire acquires the named lock if it is free, or returns [ErrLocked] if it is held
+// by an unexpired lease, including a lease of the same owner.
+//
+// Each acquisition is assigned a fencing token one greater than the token of the last
+// acquisition. The first acquisition of a lock, or of a lock deleted by the table's
+// time-to-live, is assigned the current time in unix milliseconds, so tokens keep
+// increasing as long as the clocks of the owners roughly agree.
+func (l *Locker) TryAcquire(ctx context.Context, name string) (*Lease, error) {
+	client := dynamap.NewClient(l.Table, l.Client)
+	now, duration := l.now(), l.leaseDuration()
+
+	current, err := l.get(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &lock{Owner: l.Owner, Token: now.UnixMilli(), ExpiresAt: now.Add(duration).UnixMilli()}
+	condition := expression.AttributeNotExists(expression.Name(dynamap.AttributeNameSource))
+	if current != nil {
+		if current.ExpiresAt > now.UnixMilli() {
+			return nil, fmt.Errorf("%w: %s is held by %s", ErrLocked, name, current.Owner)
+		}
+		next.Token = current.Token + 1
+		condition = tokenIs(current.Token)
+	}
+
+	input, err := l.Table.MarshalPut(lockKey{name}, func(mo *dynamap.MarshalOptions) {
+		mo.Created = now
+		mo.TimeToLive = duration + ttlGrace
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+	state, err := attributevalue.MarshalMap(next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+	maps.Copy(input.Item, state)
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+	input.ConditionExpression = expr.Condition()
+	input.ExpressionAttributeNames = expr.Names()
+	input.ExpressionAttributeValues = expr.Values()
+
+	if _, err := client.PutItem(ctx, input); isConditionFailed(err) {
+		return nil, fmt.Errorf("%w: %s was acquired concurrently", ErrLocked, name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return &Lease{Name: name, Owner: l.Owner, Token: next.Token, locker: l, expires: now.Add(duration)}, nil
+}
+
+// get reads the named lock, returning nil if it does not exist. Locks are read
+// consistently and regardless of [dynamap.Table.FilterExpired], since expired locks are
+// replaced rather than created.
+func (l *Locker) get(ctx context.Context, client dynamap.DynamoDBClient, name string) (*lock, error) {
+	input, err := l.Table.MarshalGet(lockKey{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+	input.ConsistentRead = aws.Bool(true)
+
+	output, err := client.GetItem(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock: %w", err)
+	}
+	if len(output.Item) == 0 {
+		return nil, nil
+	}
+
+	var current lock
+	if err := attributevalue.UnmarshalMap(output.Item, &current); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lock: %w", err)
+	}
+	return &current, nil
+}
+
+// now returns the current time of the table clock.
+func (l *Locker) now() time.Time {
+	return dynamap.NewMarshalOptions(l.Table.MarshalOptions).Tick()
+}
+
+// leaseDuration returns the lease duration, or the default if it is not set.
+func (l *Locker) leaseDuration() time.Duration {
+	if l.LeaseDuration <= 0 {
+		return DefaultLeaseDuration
+	}
+	return l.LeaseDuration
+}
+
+// Lease is a lock held by a [Locker] until it expires or is released.
+type Lease struct {
+	Name  string // The name of the lock
+	Owner string // The owner holding the lock
+	Token int64  // The fencing token of the acquisition; greater than the tokens of earlier leases
+
+	locker  *Locker
+	mu      sync.Mutex
+	expires time.Time
+}
+
+// Expires returns when the lease expires unless it is renewed.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expires
+}
+
+// Renew extends the lease by the lease duration of its [Locker]. [ErrLost] is returned
+// if the lease already expired or its lock was acquired by another lease.
+func (l *Lease) Renew(ctx context.Context) error {
+	now := l.locker.now()
+	expires := now.Add(l.locker.leaseDuration())
+
+	update := func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.
+			Set(expression.Name(AttributeNameLeaseExpires), expression.Value(expires.UnixMilli())).
+			Set(expression.Name(dynamap.AttributeNameExpires), expression.Value(expires.Add(ttlGrace).Unix()))
+	}
+	condition := expression.And(
+		tokenIs(l.Token),
+		expression.Name(AttributeNameOwner).Equal(expression.Value(l.Owner)),
+		expression.Name(AttributeNameLeaseExpires).GreaterThan(expression.Value(now.UnixMilli())),
+	)
+
+	if err := l.update(ctx, update, condition); err != nil {
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+
+	l.mu.Lock()
+	l.expires = expires
+	l.mu.Unlock()
+	return nil
+}
+
+// Release releases the lock so it can be acquired immediately. The lock item is kept
+// until the table's time-to-live deletes it, so the next acquisition continues its
+// fencing tokens. [ErrLost] is returned if the lock was acquired by another lease.
+func (l *Lease) Release(ctx context.Context) error {
+	now := l.locker.now()
+
+	update := func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Set(expression.Name(AttributeNameLeaseExpires), expression.Value(now.UnixMilli()))
+	}
+	condition := expression.And(
+		tokenIs(l.Token),
+		expression.Name(AttributeNameOwner).Equal(expression.Value(l.Owner)),
+	)
+
+	if err := l.update(ctx, update, condition); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+
+	l.mu.Lock()
+	l.expires = now
+	l.mu.Unlock()
+	return nil
+}
+
+// KeepAlive renews the lease every [Locker.HeartbeatInterval] until the context is done,
+// and returns nil. Failed renewals are retried on the next heartbeat; [ErrLost] is
+// returned once the lease is lost or expires before it could be renewed.
+func (l *Lease) KeepAlive(ctx context.Context) error {
+	interval := l.locker.HeartbeatInterval
+	if interval <= 0 {
+		interval = l.locker.leaseDuration() / 3
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := l.Renew(ctx)
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case errors.Is(err, ErrLost):
+			return err
+		case !l.Expires().After(l.locker.now()):
+			return fmt.Errorf("%w: %w", ErrLost, err)
+		}
+	}
+}
+
+// update applies the update to the lock item if the condition holds, returning [ErrLost]
+// if it does not. The update is marshaled by the table, so attribute names such as the
+// expiration follow its naming.
+func (l *Lease) update(ctx context.Context, update dynamap.UpdaterFunc, condition expression.ConditionBuilder) error {
+	input, err := l.locker.Table.MarshalUpdate(lockKey{l.Name}, dynamap.UpdateWithCondition(update, condition), func(mo *dynamap.MarshalOptions) {
+		mo.ReturnValues = types.ReturnValueNone
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	_, err = dynamap.NewClient(l.locker.Table, l.locker.Client).UpdateItem(ctx, input)
+	if isConditionFailed(err) {
+		return fmt.Errorf("%w: %s", ErrLost, l.Name)
+	}
+	return err
+}
+
+// tokenIs returns a condition that holds if the lock was last acquired with the token.
+func tokenIs(token int64) expression.ConditionBuilder {
+	return expression.Name(AttributeNameToken).Equal(expression.Value(token))
+}
+
+// isConditionFailed reports whether err is a conditional check failure.
+func isConditionFailed(err error) bool {
+	var failed *types.ConditionalCheckFailedException
+	return errors.As(err, &failed)
+}
@@ -0,0 +1,309 @@
+package dynamaplock
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// memoryClient stores items in memory, evaluating the conditions and updates written by
+// the locker.
+type memoryClient struct {
+	dynamap.DynamoDBClient
+	mu    sync.Mutex
+	items map[string]dynamap.Item
+	fail  error
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{items: make(map[string]dynamap.Item)}
+}
+
+func itemKey(item dynamap.Item) string {
+	source, target, _ := dynamap.UnmarshalTableKey(item)
+	return source + "|" + target
+}
+
+func (c *memoryClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: c.items[itemKey(params.Key)]}, nil
+}
+
+func (c *memoryClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := itemKey(params.Item)
+	if !evaluate(c.items[key], aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	c.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *memoryClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail != nil {
+		return nil, c.fail
+	}
+	key := itemKey(params.Key)
+	item := c.items[key]
+	if item == nil || !evaluate(item, aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	for _, action := range strings.Split(strings.TrimPrefix(strings.TrimSpace(aws.ToString(params.UpdateExpression)), "SET "), ", ") {
+		path, value, _ := strings.Cut(action, " = ")
+		names := strings.Split(path, ".")
+		m := item
+		for _, name := range names[:len(names)-1] {
+			m = m[params.ExpressionAttributeNames[name]].(*types.AttributeValueMemberM).Value
+		}
+		m[params.ExpressionAttributeNames[names[len(names)-1]]] = params.ExpressionAttributeValues[value]
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// evaluate evaluates attribute_not_exists conditions, and conjunctions of numeric
+// comparisons and string equalities.
+func evaluate(item dynamap.Item, condition string, names map[string]string, values map[string]types.AttributeValue) bool {
+	if condition == "" {
+		return true
+	}
+	if strings.HasPrefix(condition, "attribute_not_exists") {
+		return item == nil
+	}
+	if item == nil {
+		return false
+	}
+
+	for _, clause := range strings.Split(condition, " AND ") {
+		fields := strings.Fields(strings.Trim(clause, "()"))
+		var actual types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+		for _, name := range strings.Split(fields[0], ".") {
+			actual = actual.(*types.AttributeValueMemberM).Value[names[name]]
+		}
+
+		switch want := values[fields[2]].(type) {
+		case *types.AttributeValueMemberS:
+			if s, ok := actual.(*types.AttributeValueMemberS); !ok || s.Value != want.Value {
+				return false
+			}
+		case *types.AttributeValueMemberN:
+			n, ok := actual.(*types.AttributeValueMemberN)
+			if !ok {
+				return false
+			}
+			a, _ := strconv.ParseInt(n.Value, 10, 64)
+			b, _ := strconv.ParseInt(want.Value, 10, 64)
+			if fields[1] == "=" && a != b || fields[1] == ">" && a <= b {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func newTestLocker(client dynamap.DynamoDBClient, now *time.Time, owner string) *Locker {
+	table := dynamap.NewTable("test-table")
+	table.Clock = func() time.Time { return *now }
+	locker := NewLocker(table, client)
+	locker.Owner = owner
+	locker.LeaseDuration = 10 * time.Second
+	locker.RetryInterval = time.Millisecond
+	return locker
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acquire and release", func(t *testing.T) {
+		client := newMemoryClient()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		a := newTestLocker(client, &now, "a")
+		b := newTestLocker(client, &now, "b")
+
+		lease, err := a.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+		if lease.Token != now.UnixMilli() || !lease.Expires().Equal(now.Add(10*time.Second)) {
+			t.Errorf("Unexpected lease: token %d, expires %v", lease.Token, lease.Expires())
+		}
+
+		item := client.items["lock#report|lock#report"]
+		if expires := item[dynamap.AttributeNameExpires].(*types.AttributeValueMemberN).Value; expires != strconv.FormatInt(now.Add(11*time.Second).Unix(), 10) {
+			t.Errorf("Expected the item to expire after the lease, got %s", expires)
+		}
+
+		if _, err := b.TryAcquire(ctx, "report"); !errors.Is(err, ErrLocked) {
+			t.Errorf("Expected ErrLocked, got %v", err)
+		} else if !errors.Is(err, dynamap.ErrConditionFailed) || errors.Is(err, ErrLost) {
+			t.Errorf("Expected ErrLocked to match dynamap.ErrConditionFailed, got %v", err)
+		}
+		if _, err := a.TryAcquire(ctx, "report"); !errors.Is(err, ErrLocked) {
+			t.Errorf("Expected ErrLocked for the same owner, got %v", err)
+		}
+
+		if err := lease.Release(ctx); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+		next, err := b.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire released lock: %v", err)
+		}
+		if next.Token != lease.Token+1 {
+			t.Errorf("Expected token %d, got %d", lease.Token+1, next.Token)
+		}
+	})
+
+	t.Run("expiry and fencing", func(t *testing.T) {
+		client := newMemoryClient()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		a := newTestLocker(client, &now, "a")
+		b := newTestLocker(client, &now, "b")
+
+		lease, err := a.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		now = now.Add(5 * time.Second)
+		if err := lease.Renew(ctx); err != nil {
+			t.Fatalf("Failed to renew lease: %v", err)
+		}
+		if !lease.Expires().Equal(now.Add(10 * time.Second)) {
+			t.Errorf("Expected the renewed expiry, got %v", lease.Expires())
+		}
+
+		now = now.Add(9 * time.Second)
+		if _, err := b.TryAcquire(ctx, "report"); !errors.Is(err, ErrLocked) {
+			t.Errorf("Expected the renewed lease to be held, got %v", err)
+		}
+
+		now = now.Add(2 * time.Second)
+		next, err := b.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire expired lock: %v", err)
+		}
+		if next.Token <= lease.Token {
+			t.Errorf("Expected a greater fencing token than %d, got %d", lease.Token, next.Token)
+		}
+
+		if err := lease.Renew(ctx); !errors.Is(err, ErrLost) {
+			t.Errorf("Expected ErrLost on renewal, got %v", err)
+		}
+		if err := lease.Release(ctx); !errors.Is(err, ErrLost) {
+			t.Errorf("Expected ErrLost on release, got %v", err)
+		}
+		if err := next.Renew(ctx); err != nil {
+			t.Errorf("Failed to renew new lease: %v", err)
+		}
+	})
+
+	t.Run("acquire waits", func(t *testing.T) {
+		client := newMemoryClient()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		a := newTestLocker(client, &now, "a")
+		b := newTestLocker(client, &now, "b")
+
+		lease, err := a.Acquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err := b.Acquire(timeout, "report"); !errors.Is(err, ErrLocked) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected ErrLocked after the deadline, got %v", err)
+		}
+
+		if err := lease.Release(ctx); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+		if _, err := b.Acquire(ctx, "report"); err != nil {
+			t.Errorf("Failed to acquire released lock: %v", err)
+		}
+	})
+
+	t.Run("data codec and aliases", func(t *testing.T) {
+		client := newMemoryClient()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		a := newTestLocker(client, &now, "a")
+		a.Table.DataCodec = dynamap.GzipDataCodec{}
+		a.Table.Aliases = []dynamap.AttributeAlias{{Name: dynamap.AttributeNameExpires, Alias: "ttl"}}
+
+		lease, err := a.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		now = now.Add(5 * time.Second)
+		if err := lease.Renew(ctx); err != nil {
+			t.Fatalf("Failed to renew lease: %v", err)
+		}
+
+		item := client.items["lock#report|lock#report"]
+		if token := item[AttributeNameToken].(*types.AttributeValueMemberN).Value; token != strconv.FormatInt(lease.Token, 10) {
+			t.Errorf("Expected token %d, got %s", lease.Token, token)
+		}
+		if _, ok := item[dynamap.AttributeNameExpires]; ok {
+			t.Error("Expected the expiration to be written under its alias only")
+		}
+		if ttl := item["ttl"].(*types.AttributeValueMemberN).Value; ttl != strconv.FormatInt(now.Add(11*time.Second).Unix(), 10) {
+			t.Errorf("Expected the renewal to extend the aliased expiration, got %s", ttl)
+		}
+
+		if err := lease.Release(ctx); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+		if _, err := newTestLocker(client, &now, "b").TryAcquire(ctx, "report"); err != nil {
+			t.Errorf("Failed to acquire released lock: %v", err)
+		}
+	})
+
+	t.Run("keep alive", func(t *testing.T) {
+		client := newMemoryClient()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		a := newTestLocker(client, &now, "a")
+		a.HeartbeatInterval = time.Millisecond
+
+		lease, err := a.TryAcquire(ctx, "report")
+		if err != nil {
+			t.Fatalf("Failed to acquire lock: %v", err)
+		}
+
+		// Each reading of the clock advances it by a second
+		var ticks atomic.Int64
+		a.Table.Clock = func() time.Time { return now.Add(time.Duration(ticks.Add(1)) * time.Second) }
+
+		done, cancel := context.WithCancel(ctx)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		if err := lease.KeepAlive(done); err != nil {
+			t.Fatalf("Expected renewals to succeed, got %v", err)
+		}
+		if !lease.Expires().After(now.Add(10 * time.Second)) {
+			t.Errorf("Expected the lease to be renewed, got %v", lease.Expires())
+		}
+
+		client.mu.Lock()
+		client.fail = errors.New("unavailable")
+		client.mu.Unlock()
+		if err := lease.KeepAlive(ctx); !errors.Is(err, ErrLost) {
+			t.Errorf("Expected ErrLost once renewals fail past expiry, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package dynamappb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nisimpson/dynamap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto converts a relationship to its message. The data is encoded as JSON.
+func ToProto(rel dynamap.Relationship) (*Relationship, error) {
+	data, err := encodeJSON(rel.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	m := &Relationship{
+		Source:     rel.Source,
+		Target:     rel.Target,
+		Label:      rel.Label,
+		CreatedAt:  toTimestamp(rel.CreatedAt),
+		UpdatedAt:  toTimestamp(rel.UpdatedAt),
+		Expires:    toTimestamp(rel.Expires),
+		Data:       data,
+		RefSortKey: rel.GSI1SK,
+		ActorId:    rel.ActorID,
+		RequestId:  rel.RequestID,
+		Reason:     rel.Reason,
+	}
+	if rel.DeletedAt != nil {
+		// Keep the field even for a zero time, since its presence marks the deletion
+		m.DeletedAt = timestamppb.New(*rel.DeletedAt)
+	}
+	return m, nil
+}
+
+// FromProto converts a message to its relationship. The data is decoded from JSON into a
+// generic value, such as a map[string]any; unmarshal it into an entity with
+// [dynamap.Relationship] helpers or by decoding [Relationship.Data] directly.
+func FromProto(m *Relationship) (dynamap.Relationship, error) {
+	if m == nil {
+		return dynamap.Relationship{}, nil
+	}
+
+	data, err := decodeJSON(m.Data)
+	if err != nil {
+		return dynamap.Relationship{}, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	rel := dynamap.Relationship{
+		Source:    m.Source,
+		Target:    m.Target,
+		Label:     m.Label,
+		CreatedAt: fromTimestamp(m.CreatedAt),
+		UpdatedAt: fromTimestamp(m.UpdatedAt),
+		Expires:   fromTimestamp(m.Expires),
+		Data:      data,
+		GSI1SK:    m.RefSortKey,
+		ActorID:   m.ActorId,
+		RequestID: m.RequestId,
+		Reason:    m.Reason,
+	}
+	if m.DeletedAt != nil {
+		deleted := m.DeletedAt.AsTime()
+		rel.DeletedAt = &deleted
+	}
+	return rel, nil
+}
+
+// RefToProto converts a ref to its message. The snapshot and edge are encoded as JSON.
+func RefToProto(ref dynamap.Ref) (*Ref, error) {
+	snapshot, err := encodeJSON(ref.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	edge, err := encodeJSON(ref.Edge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode edge: %w", err)
+	}
+	return &Ref{
+		Name:     ref.Name,
+		SourceId: ref.SourceID,
+		TargetId: ref.TargetID,
+		Snapshot: snapshot,
+		Edge:     edge,
+	}, nil
+}
+
+// RefFromProto converts a message to its ref. The snapshot and edge are decoded from
+// JSON into generic values.
+func RefFromProto(m *Ref) (dynamap.Ref, error) {
+	if m == nil {
+		return dynamap.Ref{}, nil
+	}
+
+	snapshot, err := decodeJSON(m.Snapshot)
+	if err != nil {
+		return dynamap.Ref{}, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	edge, err := decodeJSON(m.Edge)
+	if err != nil {
+		return dynamap.Ref{}, fmt.Errorf("failed to decode edge: %w", err)
+	}
+	return dynamap.Ref{
+		Name:     m.Name,
+		SourceID: m.SourceId,
+		TargetID: m.TargetId,
+		Snapshot: snapshot,
+		Edge:     edge,
+	}, nil
+}
+
+// PageToProto converts a page of relationships and the cursor of the next page, such as
+// one created with [dynamap.MarshalStartKey], to its message.
+func PageToProto(relationships []dynamap.Relationship, nextCursor string) (*Page, error) {
+	page := &Page{NextCursor: nextCursor}
+	for i, rel := range relationships {
+		m, err := ToProto(rel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert relationship %d: %w", i, err)
+		}
+		page.Relationships = append(page.Relationships, m)
+	}
+	return page, nil
+}
+
+// PageFromProto converts a message to its page of relationships and the cursor of the
+// next page, which can be passed to [dynamap.UnmarshalStartKey].
+func PageFromProto(m *Page) ([]dynamap.Relationship, string, error) {
+	if m == nil {
+		return nil, "", nil
+	}
+
+	var relationships []dynamap.Relationship
+	for i, rel := range m.Relationships {
+		converted, err := FromProto(rel)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to convert relationship %d: %w", i, err)
+		}
+		relationships = append(relationships, converted)
+	}
+	return relationships, m.NextCursor, nil
+}
+
+// toTimestamp returns the timestamp of t, or nil if t is the zero time.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp returns the timestamp as a UTC time, or the zero time if ts is nil.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// encodeJSON returns the JSON encoding of v, or nil if v is nil.
+func encodeJSON(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// decodeJSON returns the value encoded in data, or nil if data is empty.
+func decodeJSON(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
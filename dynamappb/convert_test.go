@@ -0,0 +1,226 @@
+package dynamappb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nisimpson/dynamap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToProto(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)
+	deleted := created.Add(time.Hour)
+	rel := dynamap.Relationship{
+		Source:    "order#O1",
+		Target:    "product#P1",
+		Label:     "order/products",
+		CreatedAt: created,
+		UpdatedAt: created,
+		Data:      map[string]any{"quantity": float64(2)},
+		GSI1SK:    "2024-05-01",
+		DeletedAt: &deleted,
+		ActorID:   "user-1",
+		Reason:    "cancelled",
+	}
+
+	message, err := ToProto(rel)
+	if err != nil {
+		t.Fatalf("Failed to convert relationship: %v", err)
+	}
+	if message.Expires != nil {
+		t.Errorf("Expected no expiry, got %+v", message.Expires)
+	}
+	if string(message.Data) != `{"quantity":2}` {
+		t.Errorf("Expected JSON data, got %s", message.Data)
+	}
+
+	b, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded Relationship
+	if err := proto.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	got, err := FromProto(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to convert message: %v", err)
+	}
+	if !reflect.DeepEqual(got, rel) {
+		t.Errorf("Expected %+v, got %+v", rel, got)
+	}
+}
+
+func TestRelationshipWireFormat(t *testing.T) {
+	message := &Relationship{
+		Source:    "a",
+		Label:     "b",
+		CreatedAt: &timestamppb.Timestamp{Seconds: 1, Nanos: 2},
+		Data:      []byte("{}"),
+	}
+	want := []byte{
+		0x0a, 0x01, 'a', // source
+		0x1a, 0x01, 'b', // label
+		0x22, 0x04, 0x08, 0x01, 0x10, 0x02, // created_at
+		0x3a, 0x02, '{', '}', // data
+	}
+
+	got, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, got %x", want, got)
+	}
+
+	// Unknown fields of newer peers are kept aside
+	var decoded Relationship
+	if err := proto.Unmarshal(append(want, 0xf8, 0x01, 0x07), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	decoded.ProtoReflect().SetUnknown(nil)
+	if !proto.Equal(&decoded, message) {
+		t.Errorf("Expected %v, got %v", message, &decoded)
+	}
+
+	for _, b := range [][]byte{{0x0a, 0x05, 'a'}, {0x0a}, {0x0b}, {0x00}} {
+		if err := proto.Unmarshal(b, &decoded); err == nil {
+			t.Errorf("Expected %x to be rejected", b)
+		}
+	}
+}
+
+func TestRefToProto(t *testing.T) {
+	ref := dynamap.Ref{
+		Name:     "products",
+		SourceID: "O1",
+		TargetID: "P1",
+		Snapshot: map[string]any{"name": "Widget"},
+		Edge:     map[string]any{"quantity": float64(2)},
+	}
+
+	message, err := RefToProto(ref)
+	if err != nil {
+		t.Fatalf("Failed to convert ref: %v", err)
+	}
+	b, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded Ref
+	if err := proto.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	got, err := RefFromProto(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to convert message: %v", err)
+	}
+	if !reflect.DeepEqual(got, ref) {
+		t.Errorf("Expected %+v, got %+v", ref, got)
+	}
+}
+
+func TestPageToProto(t *testing.T) {
+	relationships := []dynamap.Relationship{
+		{Source: "product#P1", Target: "product#P1", Label: "product"},
+		{Source: "product#P2", Target: "product#P2", Label: "product"},
+	}
+
+	page, err := PageToProto(relationships, "cursor")
+	if err != nil {
+		t.Fatalf("Failed to convert page: %v", err)
+	}
+	b, err := proto.Marshal(page)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded Page
+	if err := proto.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	got, cursor, err := PageFromProto(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to convert message: %v", err)
+	}
+	if cursor != "cursor" || !reflect.DeepEqual(got, relationships) {
+		t.Errorf("Expected %+v with the cursor, got %+v %q", relationships, got, cursor)
+	}
+
+	request := &PageRequest{Limit: -1, Cursor: "cursor"}
+	b, err = proto.Marshal(request)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decodedRequest PageRequest
+	if err := proto.Unmarshal(b, &decodedRequest); err != nil || !proto.Equal(&decodedRequest, request) {
+		t.Errorf("Expected %v, got %v (%v)", request, &decodedRequest, err)
+	}
+}
+
+func TestPageJSON(t *testing.T) {
+	page, err := PageToProto([]dynamap.Relationship{{Source: "product#P1", Target: "product#P1", Label: "product"}}, "cursor")
+	if err != nil {
+		t.Fatalf("Failed to convert page: %v", err)
+	}
+
+	b, err := protojson.Marshal(page)
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	var decoded Page
+	if err := protojson.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if !proto.Equal(&decoded, page) {
+		t.Errorf("Expected %v, got %v", page, &decoded)
+	}
+}
+
+func FuzzRelationship(f *testing.F) {
+	f.Add("order#O1", "product#P1", "order/products", int64(1714566600), int64(123), []byte(`{"quantity":2}`), "2024-05-01", true)
+	f.Add("", "", "", int64(0), int64(0), []byte(nil), "", false)
+	f.Add("a", "b", "c", int64(-62135596800), int64(999999999), []byte(`"text"`), "", true)
+
+	f.Fuzz(func(t *testing.T, source, target, label string, seconds, nanos int64, data []byte, sortKey string, deleted bool) {
+		message := &Relationship{Source: source, Target: target, Label: label, Data: data, RefSortKey: sortKey}
+		if seconds != 0 || nanos != 0 {
+			message.CreatedAt = &timestamppb.Timestamp{Seconds: seconds, Nanos: int32(nanos % 1e9)}
+		}
+		if deleted {
+			message.DeletedAt = &timestamppb.Timestamp{Seconds: seconds}
+		}
+
+		b, err := proto.Marshal(message)
+		if err != nil {
+			return // Strings that are not valid UTF-8
+		}
+		var decoded Relationship
+		if err := proto.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal message: %v", err)
+		}
+		if !proto.Equal(&decoded, message) {
+			t.Fatalf("Expected %v, got %v", message, &decoded)
+		}
+
+		rel, err := FromProto(&decoded)
+		if err != nil {
+			return // Data that is not valid JSON
+		}
+		again, err := ToProto(rel)
+		if err != nil {
+			t.Fatalf("Failed to convert relationship: %v", err)
+		}
+		got, err := FromProto(again)
+		if err != nil {
+			t.Fatalf("Failed to convert message: %v", err)
+		}
+		if !reflect.DeepEqual(got, rel) {
+			t.Fatalf("Expected %+v, got %+v", rel, got)
+		}
+	})
+}
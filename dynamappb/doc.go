@@ -0,0 +1,25 @@
+// Package dynamappb converts dynamap relationships, refs, and pages of query results to
+// and from protocol buffer messages, for services that exchange dynamap-backed data over
+// gRPC.
+//
+// The messages are generated from dynamap.proto with protoc-gen-go, so they work with
+// the standard protobuf runtime and any peer that uses code generated from the same file.
+// Relationship data, ref snapshots, and edges are carried as JSON bytes:
+//
+//	message, err := dynamappb.ToProto(relationship)
+//	if err != nil {
+//		return err
+//	}
+//	payload, err := proto.Marshal(message)
+//
+// Pages carry the opaque cursor created by a [dynamap.Paginator], so the client of a
+// service can page through results without seeing the start key:
+//
+//	cursor, err := dynamap.MarshalStartKey(ctx, paginator, output.LastEvaluatedKey)
+//	if err != nil {
+//		return err
+//	}
+//	page, err := dynamappb.PageToProto(relationships, cursor)
+package dynamappb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative dynamap.proto
@@ -0,0 +1,10 @@
+// Package dynamaps3 stores the entity data that a dynamap table offloads to a blob store
+// as objects in an S3 bucket. It is kept apart from the dynamap package so that tables
+// without a blob store do not depend on the S3 client.
+//
+//	table.BlobStore = &dynamaps3.BlobStore{
+//		Client: s3.NewFromConfig(cfg),
+//		Bucket: "my-table-blobs",
+//		Prefix: "entities/",
+//	}
+package dynamaps3
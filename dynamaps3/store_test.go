@@ -0,0 +1,67 @@
+package dynamaps3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// mockS3Client stores objects in memory for testing.
+type mockS3Client struct {
+	objects map[string][]byte
+}
+
+func (m *mockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(m.objects, aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+	client := &mockS3Client{objects: make(map[string][]byte)}
+	store := &BlobStore{Client: client, Bucket: "blobs", Prefix: "entities/"}
+
+	if err := store.PutBlob(ctx, "product#P1/product#P1", []byte("data")); err != nil {
+		t.Fatalf("Failed to put blob: %v", err)
+	}
+	if _, ok := client.objects["blobs/entities/product#P1/product#P1"]; !ok {
+		t.Fatal("Expected object with prefixed key")
+	}
+
+	data, err := store.GetBlob(ctx, "product#P1/product#P1")
+	if err != nil {
+		t.Fatalf("Failed to get blob: %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("Expected 'data', got %q", data)
+	}
+
+	if err := store.DeleteBlob(ctx, "product#P1/product#P1"); err != nil {
+		t.Fatalf("Failed to delete blob: %v", err)
+	}
+	if _, err := store.GetBlob(ctx, "product#P1/product#P1"); err == nil {
+		t.Error("Expected error getting deleted blob")
+	}
+}
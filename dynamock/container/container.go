@@ -0,0 +1,88 @@
+// Package container starts DynamoDB Local in a container with testcontainers-go. It is
+// kept apart from dynamock so that tests which do not use containers do not depend on
+// testcontainers-go. Importing it registers [StartLocalDynamoDB] with
+// [dynamock.RegisterContainer], so setting [dynamock.ContainerEnv] lets
+// [dynamock.WithLocalDynamoDB] and [dynamock.RunIntegrationTest] start a container when
+// nothing is listening on the configured port:
+//
+//	import _ "github.com/nisimpson/dynamap/dynamock/container"
+package container
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nisimpson/dynamap/dynamock"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Image is the image started by [Start]. Pin it to a specific version to make CI runs
+// reproducible.
+var Image = "amazon/dynamodb-local:latest"
+
+// localPort is the port DynamoDB Local listens on inside the container.
+const localPort = "8000/tcp"
+
+func init() {
+	dynamock.RegisterContainer(StartLocalDynamoDB)
+}
+
+// Start starts DynamoDB Local in a container and waits for it to accept connections.
+// It returns the endpoint of the instance, such as "http://localhost:32768", and a
+// function that terminates the container. The instance keeps its data in memory and
+// shares it across regions and credentials, so every client connected to the endpoint
+// sees the same tables.
+//
+// Example usage:
+//
+//	endpoint, cleanup, err := container.Start(ctx)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer cleanup(ctx)
+func Start(ctx context.Context) (string, func(context.Context) error, error) {
+	c, err := testcontainers.Run(ctx, Image,
+		testcontainers.WithExposedPorts(localPort),
+		testcontainers.WithCmd("-jar", "DynamoDBLocal.jar", "-inMemory", "-sharedDb"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort(localPort)),
+	)
+	if err != nil {
+		if c != nil {
+			_ = c.Terminate(context.WithoutCancel(ctx))
+		}
+		return "", nil, fmt.Errorf("failed to start DynamoDB Local container: %w", err)
+	}
+
+	endpoint, err := c.PortEndpoint(ctx, localPort, "http")
+	if err != nil {
+		_ = c.Terminate(context.WithoutCancel(ctx))
+		return "", nil, fmt.Errorf("failed to get DynamoDB Local container endpoint: %w", err)
+	}
+
+	cleanup := func(ctx context.Context) error {
+		if err := c.Terminate(ctx); err != nil {
+			return fmt.Errorf("failed to terminate DynamoDB Local container: %w", err)
+		}
+		return nil
+	}
+
+	return endpoint, cleanup, nil
+}
+
+// StartLocalDynamoDB starts DynamoDB Local in a container like [Start] and returns a
+// LocalDynamoDB connected to it.
+func StartLocalDynamoDB(ctx context.Context) (*dynamock.LocalDynamoDB, func(context.Context) error, error) {
+	endpoint, cleanup, err := Start(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	local, err := dynamock.NewLocalDynamoDBForEndpoint(endpoint)
+	if err != nil {
+		_ = cleanup(context.WithoutCancel(ctx))
+		return nil, nil, err
+	}
+
+	return local, cleanup, nil
+}
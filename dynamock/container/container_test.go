@@ -0,0 +1,50 @@
+package container
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/nisimpson/dynamap/dynamock"
+)
+
+// TestStartLocalDynamoDB_Integration starts DynamoDB Local with Docker.
+func TestStartLocalDynamoDB_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	if enabled, _ := strconv.ParseBool(os.Getenv(dynamock.ContainerEnv)); !enabled {
+		t.Skipf("Set %s to run DynamoDB Local in a container", dynamock.ContainerEnv)
+	}
+
+	ctx := context.Background()
+	local, cleanup, err := StartLocalDynamoDB(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start DynamoDB Local: %v", err)
+	}
+	defer func() {
+		if err := cleanup(ctx); err != nil {
+			t.Errorf("Failed to stop DynamoDB Local: %v", err)
+		}
+	}()
+
+	if !local.IsAvailable(ctx) {
+		t.Fatalf("DynamoDB Local not available at %s", local.Endpoint)
+	}
+
+	tableName := dynamock.NewTestTable("container")
+	if err := local.CreateDynamapTable(ctx, tableName); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	dynamock.AssertTableExists(t, local.Client, tableName)
+}
+
+// TestRunIntegrationTest_Integration starts a container through the registered starter.
+func TestRunIntegrationTest_Integration(t *testing.T) {
+	config := dynamock.DefaultIntegrationTestConfig()
+	config.Port = 1 // Nothing listens here, so a container is started when enabled
+	dynamock.RunIntegrationTest(t, config, func(local *dynamock.LocalDynamoDB, tableName string) {
+		dynamock.AssertTableExists(t, local.Client, tableName)
+	})
+}
@@ -0,0 +1,5 @@
+package dynamock_test
+
+// Start DynamoDB Local containers for this package's integration tests when
+// DYNAMOCK_CONTAINER is set.
+import _ "github.com/nisimpson/dynamap/dynamock/container"
@@ -0,0 +1,68 @@
+package dynamock
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// ContainerEnv is the environment variable that lets [WithLocalDynamoDB] and
+// [RunIntegrationTest] start DynamoDB Local in a container when nothing is listening
+// on the configured port. Any value accepted by [strconv.ParseBool] as true enables it.
+// Containers are started by the function passed to [RegisterContainer], usually by
+// importing the dynamock/container package.
+const ContainerEnv = "DYNAMOCK_CONTAINER"
+
+// ContainerStarter starts DynamoDB Local in a container. It returns a LocalDynamoDB
+// connected to the container and a function that terminates it.
+type ContainerStarter func(ctx context.Context) (*LocalDynamoDB, func(context.Context) error, error)
+
+var (
+	containerMu      sync.RWMutex
+	containerStarter ContainerStarter
+)
+
+// RegisterContainer sets the function used to start DynamoDB Local containers for
+// integration tests. The dynamock/container package registers one when imported, so
+// dynamock itself does not depend on a container runtime:
+//
+//	import _ "github.com/nisimpson/dynamap/dynamock/container"
+func RegisterContainer(start ContainerStarter) {
+	containerMu.Lock()
+	defer containerMu.Unlock()
+	containerStarter = start
+}
+
+// containersEnabled reports whether [ContainerEnv] enables DynamoDB Local containers.
+func containersEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(ContainerEnv))
+	return enabled
+}
+
+// startContainer starts DynamoDB Local in a container for the duration of the test.
+func startContainer(t testing.TB) *LocalDynamoDB {
+	t.Helper()
+
+	containerMu.RLock()
+	start := containerStarter
+	containerMu.RUnlock()
+	if start == nil {
+		t.Fatalf("No container registered; import github.com/nisimpson/dynamap/dynamock/container to start DynamoDB Local")
+	}
+
+	ctx := context.Background()
+	local, cleanup, err := start(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start DynamoDB Local: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := cleanup(ctx); err != nil {
+			t.Errorf("Failed to stop DynamoDB Local: %v", err)
+		}
+	})
+
+	return local
+}
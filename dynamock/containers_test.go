@@ -0,0 +1,41 @@
+package dynamock
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContainersEnabled(t *testing.T) {
+	t.Setenv(ContainerEnv, "")
+	if containersEnabled() || DefaultIntegrationTestConfig().Container {
+		t.Error("Expected containers to be disabled by default")
+	}
+
+	t.Setenv(ContainerEnv, "true")
+	if !containersEnabled() || !DefaultIntegrationTestConfig().Container {
+		t.Error("Expected containers to be enabled")
+	}
+}
+
+func TestRegisterContainer(t *testing.T) {
+	previous := containerStarter
+	t.Cleanup(func() { RegisterContainer(previous) })
+
+	var stopped bool
+	local := NewLocalDynamoDB(DefaultLocalPort)
+	RegisterContainer(func(ctx context.Context) (*LocalDynamoDB, func(context.Context) error, error) {
+		return local, func(context.Context) error {
+			stopped = true
+			return nil
+		}, nil
+	})
+
+	t.Run("starts registered container", func(t *testing.T) {
+		if got := startContainer(t); got != local {
+			t.Errorf("Expected the registered instance, got %+v", got)
+		}
+	})
+	if !stopped {
+		t.Error("Expected container to be stopped after the test")
+	}
+}
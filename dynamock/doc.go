@@ -2,6 +2,8 @@
 //
 // This package includes:
 //   - Expectation-based mock DynamoDB client for unit testing
+//   - Stateful in-memory DynamoDB simulator honoring the dynamap schema
+//   - Deterministic fault, latency and cancellation injection for testing retries and timeouts
 //   - Local DynamoDB integration utilities
 //   - Generic test data builders with fluent and functional APIs
 //   - Test data seeding helpers
@@ -25,6 +27,41 @@
 //	putInput, _ := table.MarshalPut(entity)
 //	_, err := mock.PutItem(ctx, putInput)
 //
+// Expectations verify the calls made, with matchers on their keys, labels and
+// expressions:
+//
+//	mock.Expect("PutItem", dynamock.MatchLabel("order")).Times(2)
+//	...
+//	mock.AssertExpectations(t)
+//
+// # Fake Client
+//
+// The FakeClient stores items in memory and evaluates requests against them like
+// DynamoDB: key conditions on the table and its indexes, including the ref index,
+// condition, filter, update and projection expressions, limits and LastEvaluatedKey:
+//
+//	table := dynamap.NewTable("test-table")
+//	client := dynamock.NewFakeClient(table)
+//
+//	err := table.Put(ctx, client, entity)
+//	input, _ := table.MarshalQuery(&dynamap.QueryList{Label: "entity", Limit: 10})
+//	output, err := client.Query(ctx, input)
+//
+// # Fault Injection
+//
+// A FaultClient wraps a mock or fake client and deterministically fails a fraction of
+// its requests with throttling, condition, validation or unprocessed batch failures:
+//
+//	client := dynamock.NewFaultClient(dynamock.NewFakeClient(table),
+//		dynamock.Throttle(0.5, "PutItem"),
+//		dynamock.Unprocessed(0.25),
+//	)
+//
+// Latencies add delay and jitter per operation, and requests whose context is done
+// while delayed fail with the context's error:
+//
+//	client.Latencies = []*dynamock.Latency{dynamock.Delay(20*time.Millisecond, 10*time.Millisecond)}
+//
 // # Generic Test Data Builders
 //
 // The package provides both fluent builders and functional options for creating test entities:
@@ -89,6 +126,28 @@
 //		err = local.DeleteTable(ctx, tableName)
 //	}
 //
+// The dynamock/container package runs DynamoDB Local in a container with
+// testcontainers-go, and registers itself with RegisterContainer when imported. Setting
+// the DYNAMOCK_CONTAINER environment variable then makes WithLocalDynamoDB and
+// RunIntegrationTest start one when nothing is listening on the configured port, so CI
+// only needs Docker:
+//
+//	local, cleanup, err := container.StartLocalDynamoDB(ctx)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer cleanup(ctx)
+//
+// NewClientForEndpoint and NewLocalDynamoDBForEndpoint connect to other endpoints, such
+// as LocalStack or a shared development endpoint, with a region, credentials and HTTP
+// client set by options. Setting the DYNAMOCK_ENDPOINT environment variable runs
+// WithLocalDynamoDB and RunIntegrationTest against that endpoint:
+//
+//	client, err := dynamock.NewClientForEndpoint("https://localstack.internal:4566",
+//		dynamock.WithRegion("eu-west-1"),
+//		dynamock.WithStaticCredentials("test", "test", ""),
+//	)
+//
 // # Integration Test Helpers
 //
 // The package provides several helpers for integration testing:
@@ -0,0 +1,190 @@
+package dynamock
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// EndpointEnv is the environment variable that points [WithLocalDynamoDB] and
+// [RunIntegrationTest] at a DynamoDB endpoint other than DynamoDB Local on localhost,
+// such as LocalStack or a shared development endpoint. The region is read from
+// AWS_REGION and static credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN when they are set.
+const EndpointEnv = "DYNAMOCK_ENDPOINT"
+
+// EndpointOptions configures a client created by [NewClientForEndpoint].
+type EndpointOptions struct {
+	// Region is the region requests are signed for. Defaults to us-east-1.
+	Region string
+
+	// Credentials provides the credentials requests are signed with. Defaults to
+	// anonymous credentials, which DynamoDB Local accepts.
+	Credentials aws.CredentialsProvider
+
+	// HTTPClient sends the requests, for example one trusting a private certificate
+	// authority. Defaults to the AWS SDK client.
+	HTTPClient aws.HTTPClient
+}
+
+// EndpointOption is a functional option for configuring [EndpointOptions].
+type EndpointOption func(*EndpointOptions)
+
+// WithRegion sets the region requests are signed for.
+func WithRegion(region string) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.Region = region
+	}
+}
+
+// WithCredentials sets the provider of the credentials requests are signed with.
+func WithCredentials(provider aws.CredentialsProvider) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.Credentials = provider
+	}
+}
+
+// WithStaticCredentials signs requests with a fixed access key, such as the "test"
+// key LocalStack expects.
+func WithStaticCredentials(accessKeyID, secretAccessKey, sessionToken string) EndpointOption {
+	return WithCredentials(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken))
+}
+
+// WithHTTPClient sets the HTTP client that sends the requests.
+func WithHTTPClient(client aws.HTTPClient) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.HTTPClient = client
+	}
+}
+
+// WithAWSConfig uses the region, credentials and HTTP client of cfg, such as one loaded
+// with config.LoadDefaultConfig, for connecting to tables in an AWS account.
+func WithAWSConfig(cfg aws.Config) EndpointOption {
+	return func(o *EndpointOptions) {
+		if cfg.Region != "" {
+			o.Region = cfg.Region
+		}
+		if cfg.Credentials != nil {
+			o.Credentials = cfg.Credentials
+		}
+		if cfg.HTTPClient != nil {
+			o.HTTPClient = cfg.HTTPClient
+		}
+	}
+}
+
+// NewClientForEndpoint creates a DynamoDB client that sends every request to endpoint,
+// which must be an http or https URL. Without options the client behaves like
+// [NewLocalClient]; use them to reach LocalStack, shared development endpoints or
+// tables in an AWS account.
+//
+// Example usage:
+//
+//	client, err := dynamock.NewClientForEndpoint("https://localstack.internal:4566",
+//		dynamock.WithRegion("eu-west-1"),
+//		dynamock.WithStaticCredentials("test", "test", ""),
+//	)
+func NewClientForEndpoint(endpoint string, opts ...EndpointOption) (*dynamodb.Client, error) {
+	if _, err := parseEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return newEndpointClient(endpoint, opts...), nil
+}
+
+// NewLocalDynamoDBForEndpoint creates a LocalDynamoDB for the instance at endpoint, so
+// the table and integration helpers work against any DynamoDB endpoint. Port is the
+// port of the endpoint, or the default port of its scheme.
+func NewLocalDynamoDBForEndpoint(endpoint string, opts ...EndpointOption) (*LocalDynamoDB, error) {
+	u, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(endpointPort(u))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	return &LocalDynamoDB{
+		Client:   newEndpointClient(endpoint, opts...),
+		Endpoint: endpoint,
+		Port:     port,
+	}, nil
+}
+
+// newEndpointClient creates a DynamoDB client that sends every request to endpoint.
+func newEndpointClient(endpoint string, opts ...EndpointOption) *dynamodb.Client {
+	options := EndpointOptions{
+		Region:      "us-east-1", // DynamoDB Local doesn't care about region
+		Credentials: aws.AnonymousCredentials{},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	cfg := aws.Config{
+		Region:      options.Region,
+		Credentials: options.Credentials,
+		HTTPClient:  options.HTTPClient,
+		EndpointResolverWithOptions: aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:           endpoint,
+					SigningRegion: region,
+				}, nil
+			},
+		),
+	}
+
+	return dynamodb.NewFromConfig(cfg)
+}
+
+// parseEndpoint parses an http or https endpoint URL.
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+	return u, nil
+}
+
+// endpointPort returns the port of u, or the default port of its scheme.
+func endpointPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// endpointFromEnv returns the endpoint set by [EndpointEnv] and the options for
+// connecting to it, or an empty endpoint if the variable is not set.
+func endpointFromEnv() (string, []EndpointOption) {
+	endpoint := os.Getenv(EndpointEnv)
+	if endpoint == "" {
+		return "", nil
+	}
+
+	var opts []EndpointOption
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		opts = append(opts, WithRegion(region))
+	}
+	if key := os.Getenv("AWS_ACCESS_KEY_ID"); key != "" {
+		opts = append(opts, WithStaticCredentials(key, os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")))
+	}
+
+	return endpoint, opts
+}
@@ -0,0 +1,110 @@
+package dynamock
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestNewClientForEndpoint(t *testing.T) {
+	httpClient := &http.Client{}
+	client, err := NewClientForEndpoint("https://dynamodb.dev.internal",
+		WithRegion("eu-west-1"),
+		WithStaticCredentials("key", "secret", ""),
+		WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	options := client.Options()
+	if options.Region != "eu-west-1" {
+		t.Errorf("Expected region eu-west-1, got %q", options.Region)
+	}
+	if options.HTTPClient != httpClient {
+		t.Error("Expected the HTTP client to be used")
+	}
+	creds, err := options.Credentials.Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "key" || creds.SecretAccessKey != "secret" {
+		t.Errorf("Expected static credentials, got %v and %v", creds, err)
+	}
+
+	for _, endpoint := range []string{"localhost:8000", "ftp://localhost:8000", "http://:8000"} {
+		if _, err := NewClientForEndpoint(endpoint); err == nil {
+			t.Errorf("Expected an error for endpoint %q", endpoint)
+		}
+	}
+}
+
+func TestWithAWSConfig(t *testing.T) {
+	options := EndpointOptions{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}}
+	WithAWSConfig(aws.Config{Region: "us-west-2"})(&options)
+
+	// Settings missing from the config keep their defaults
+	if options.Region != "us-west-2" {
+		t.Errorf("Expected region us-west-2, got %q", options.Region)
+	}
+	if _, ok := options.Credentials.(aws.AnonymousCredentials); !ok {
+		t.Errorf("Expected anonymous credentials, got %T", options.Credentials)
+	}
+}
+
+func TestNewLocalDynamoDBForEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		port     int
+		address  string
+	}{
+		{endpoint: "http://127.0.0.1:32768", port: 32768, address: "127.0.0.1:32768"},
+		{endpoint: "https://dynamodb.us-east-1.amazonaws.com", port: 443, address: "dynamodb.us-east-1.amazonaws.com:443"},
+		{endpoint: "http://localstack", port: 80, address: "localstack:80"},
+	}
+
+	for _, tt := range tests {
+		local, err := NewLocalDynamoDBForEndpoint(tt.endpoint)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.endpoint, err)
+		}
+		if local.Port != tt.port || local.Endpoint != tt.endpoint {
+			t.Errorf("Unexpected port %d and endpoint %q for %q", local.Port, local.Endpoint, tt.endpoint)
+		}
+		if got := local.address(); got != tt.address {
+			t.Errorf("Expected address %q, got %q", tt.address, got)
+		}
+	}
+
+	// Instances created without an endpoint are reached on localhost
+	if got := (&LocalDynamoDB{Port: 8000}).address(); got != "localhost:8000" {
+		t.Errorf("Expected address localhost:8000, got %q", got)
+	}
+}
+
+func TestEndpointFromEnv(t *testing.T) {
+	t.Setenv(EndpointEnv, "")
+	if endpoint, _ := endpointFromEnv(); endpoint != "" || DefaultIntegrationTestConfig().Endpoint != "" {
+		t.Error("Expected no endpoint by default")
+	}
+
+	t.Setenv(EndpointEnv, "http://localhost:4566")
+	t.Setenv("AWS_REGION", "ap-southeast-2")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	config := DefaultIntegrationTestConfig()
+	if config.Endpoint != "http://localhost:4566" {
+		t.Errorf("Expected endpoint from the environment, got %q", config.Endpoint)
+	}
+	client, err := NewClientForEndpoint(config.Endpoint, config.EndpointOptions...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if region := client.Options().Region; region != "ap-southeast-2" {
+		t.Errorf("Expected region ap-southeast-2, got %q", region)
+	}
+	creds, err := client.Options().Credentials.Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "test" {
+		t.Errorf("Expected credentials from the environment, got %v and %v", creds, err)
+	}
+}
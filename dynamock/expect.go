@@ -0,0 +1,448 @@
+package dynamock
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// Call is an operation call recorded by a [MockClient].
+type Call struct {
+	Operation string // The operation name, such as "PutItem"
+	Input     any    // The operation input, such as *dynamodb.PutItemInput
+}
+
+// Matcher matches the input of a recorded call.
+type Matcher struct {
+	description string
+	match       func(input any) bool
+}
+
+// String describes the matcher, such as "label=order".
+func (m Matcher) String() string {
+	return m.description
+}
+
+// Match returns true if input matches.
+func (m Matcher) Match(input any) bool {
+	return m.match(input)
+}
+
+// MatchFunc returns a [Matcher] calling match with the operation input, such as a
+// *dynamodb.PutItemInput, described by description.
+func MatchFunc(description string, match func(input any) bool) Matcher {
+	return Matcher{description: description, match: match}
+}
+
+// MatchTable matches calls to the named table. Batch and transaction calls match if any
+// of their requests is to the table.
+func MatchTable(name string) Matcher {
+	return MatchFunc("table="+name, func(input any) bool {
+		return slices.Contains(callTables(input), name)
+	})
+}
+
+// MatchKey matches calls reading or writing the item with the source and target keys.
+// Batch and transaction calls match if any of their requests is for the item.
+func MatchKey(source, target string) Matcher {
+	return MatchFunc(fmt.Sprintf("key=%s/%s", source, target), func(input any) bool {
+		return slices.ContainsFunc(callItems(input), func(item dynamap.Item) bool {
+			return stringAttribute(item, dynamap.AttributeNameSource) == source &&
+				stringAttribute(item, dynamap.AttributeNameTarget) == target
+		})
+	})
+}
+
+// MatchLabel matches calls writing an item with the label, or queries of the label. Batch
+// and transaction calls match if any of their items has the label.
+func MatchLabel(label string) Matcher {
+	return MatchFunc("label="+label, func(input any) bool {
+		if query, ok := input.(*dynamodb.QueryInput); ok {
+			for _, value := range query.ExpressionAttributeValues {
+				if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value == label {
+					return true
+				}
+			}
+			return false
+		}
+		return slices.ContainsFunc(callItems(input), func(item dynamap.Item) bool {
+			return stringAttribute(item, dynamap.AttributeNameLabel) == label
+		})
+	})
+}
+
+// MatchExpression matches calls with a key condition, condition, filter, update or
+// projection expression containing fragment. Expression attribute names are replaced by
+// the names they stand for before matching, so fragment may use the attribute names, as in
+// "attribute_not_exists(hk)".
+func MatchExpression(fragment string) Matcher {
+	return MatchFunc(fmt.Sprintf("expression~%q", fragment), func(input any) bool {
+		return slices.ContainsFunc(callExpressions(input), func(expr string) bool {
+			return strings.Contains(expr, fragment)
+		})
+	})
+}
+
+// Expectation is an expected operation call, set with [MockClient.Expect].
+type Expectation struct {
+	operation string
+	matchers  []Matcher
+	min, max  int // The expected number of calls; max is -1 if unbounded
+	calls     int
+	output    any
+	err       error
+}
+
+// Times expects exactly n matching calls.
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// Once expects exactly one matching call.
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Never expects no matching calls.
+func (e *Expectation) Never() *Expectation {
+	return e.Times(0)
+}
+
+// AtLeast expects n or more matching calls.
+func (e *Expectation) AtLeast(n int) *Expectation {
+	e.min, e.max = n, -1
+	return e
+}
+
+// AtMost expects at most n matching calls.
+func (e *Expectation) AtMost(n int) *Expectation {
+	e.min, e.max = 0, n
+	return e
+}
+
+// Return sets the response to matching calls. The output must be the output type of the
+// operation, such as *dynamodb.PutItemOutput; a nil output returns an empty one.
+func (e *Expectation) Return(output any, err error) *Expectation {
+	e.output, e.err = output, err
+	return e
+}
+
+// String describes the expectation, such as "PutItem(label=order)".
+func (e *Expectation) String() string {
+	descriptions := make([]string, len(e.matchers))
+	for i, matcher := range e.matchers {
+		descriptions[i] = matcher.String()
+	}
+	return e.operation + "(" + strings.Join(descriptions, ", ") + ")"
+}
+
+// matches returns true if a call to operation with input matches the expectation.
+func (e *Expectation) matches(operation string, input any) bool {
+	return e.operation == operation && !slices.ContainsFunc(e.matchers, func(m Matcher) bool { return !m.Match(input) })
+}
+
+// exhausted returns true if the expectation has been called as many times as allowed.
+func (e *Expectation) exhausted() bool {
+	return e.max >= 0 && e.calls >= e.max
+}
+
+// describeTimes describes the expected number of calls.
+func (e *Expectation) describeTimes() string {
+	switch {
+	case e.min == e.max:
+		return fmt.Sprintf("exactly %d", e.min)
+	case e.max < 0:
+		return fmt.Sprintf("at least %d", e.min)
+	default:
+		return fmt.Sprintf("at most %d", e.max)
+	}
+}
+
+// Expect adds an expectation that operation, such as "PutItem", is called with inputs
+// matching all matchers. Expectations are expected at least once unless constrained with
+// [Expectation.Times] and similar methods.
+//
+// Matching calls are answered with the response set by [Expectation.Return], or an empty
+// output; the first expectation that matches and is not yet exhausted answers, and calls
+// matching only exhausted expectations are counted against the first of them so that
+// [MockClient.AssertExpectations] reports them. Calls matching no expectation are handled
+// by the operation's func field.
+//
+// Example:
+//
+//	mock := dynamock.NewMockClient(t)
+//	mock.Expect("PutItem", dynamock.MatchLabel("order")).Times(2)
+//	mock.Expect("Query", dynamock.MatchLabel("order")).Return(&dynamodb.QueryOutput{}, nil)
+//	...
+//	mock.AssertExpectations(t)
+func (m *MockClient) Expect(operation string, matchers ...Matcher) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{operation: operation, matchers: matchers, min: 1, max: -1}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// AssertExpectations reports an error to t for each expectation called a number of
+// times outside its constraints, and returns true if all were met.
+func (m *MockClient) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, e := range m.expectations {
+		if e.calls < e.min || (e.max >= 0 && e.calls > e.max) {
+			t.Errorf("dynamock: %s called %d times, expected %s", e, e.calls, e.describeTimes())
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Calls returns the recorded calls, in order, optionally restricted to the operations.
+func (m *MockClient) Calls(operations ...string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(operations) == 0 {
+		return slices.Clone(m.calls)
+	}
+	var calls []Call
+	for _, call := range m.calls {
+		if slices.Contains(operations, call.Operation) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of recorded calls to operation with inputs matching all
+// matchers.
+func (m *MockClient) CallCount(operation string, matchers ...Matcher) int {
+	e := &Expectation{operation: operation, matchers: matchers}
+	count := 0
+	for _, call := range m.Calls(operation) {
+		if e.matches(call.Operation, call.Input) {
+			count++
+		}
+	}
+	return count
+}
+
+// record records a call and returns the expectation answering it, if any.
+func (m *MockClient) record(operation string, input any) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Operation: operation, Input: input})
+
+	var first *Expectation
+	for _, e := range m.expectations {
+		if !e.matches(operation, input) {
+			continue
+		}
+		if !e.exhausted() {
+			e.calls++
+			return e
+		}
+		if first == nil {
+			first = e
+		}
+	}
+	if first != nil {
+		first.calls++
+	}
+	return first
+}
+
+// invoke records a call to operation and answers it with the matching expectation, or
+// with fn if there is none.
+func invoke[T, U any](m *MockClient, operation string, fn DynamoDBAPICall[T, U], ctx context.Context, params *T, optFns []func(*dynamodb.Options)) (*U, error) {
+	e := m.record(operation, params)
+	if e == nil {
+		return fn(ctx, params, optFns...)
+	}
+	if e.output == nil {
+		if e.err != nil {
+			return nil, e.err
+		}
+		return new(U), nil
+	}
+	output, ok := e.output.(*U)
+	if !ok {
+		return nil, fmt.Errorf("dynamock: expectation %s returns %T, expected %T", e, e.output, output)
+	}
+	return output, e.err
+}
+
+// callTables returns the tables of an operation input.
+func callTables(input any) []string {
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.GetItemInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.UpdateItemInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.DeleteItemInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.QueryInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.ScanInput:
+		return []string{aws.ToString(in.TableName)}
+	case *dynamodb.BatchWriteItemInput:
+		return sortedKeys(in.RequestItems)
+	case *dynamodb.BatchGetItemInput:
+		return sortedKeys(in.RequestItems)
+	case *dynamodb.TransactWriteItemsInput:
+		var tables []string
+		for _, item := range in.TransactItems {
+			switch {
+			case item.Put != nil:
+				tables = append(tables, aws.ToString(item.Put.TableName))
+			case item.Update != nil:
+				tables = append(tables, aws.ToString(item.Update.TableName))
+			case item.Delete != nil:
+				tables = append(tables, aws.ToString(item.Delete.TableName))
+			case item.ConditionCheck != nil:
+				tables = append(tables, aws.ToString(item.ConditionCheck.TableName))
+			}
+		}
+		return tables
+	}
+	return nil
+}
+
+// callItems returns the items written and the keys read or deleted by an operation input.
+func callItems(input any) []dynamap.Item {
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		return []dynamap.Item{in.Item}
+	case *dynamodb.GetItemInput:
+		return []dynamap.Item{in.Key}
+	case *dynamodb.UpdateItemInput:
+		return []dynamap.Item{in.Key}
+	case *dynamodb.DeleteItemInput:
+		return []dynamap.Item{in.Key}
+	case *dynamodb.BatchWriteItemInput:
+		var items []dynamap.Item
+		for _, table := range sortedKeys(in.RequestItems) {
+			for _, request := range in.RequestItems[table] {
+				switch {
+				case request.PutRequest != nil:
+					items = append(items, request.PutRequest.Item)
+				case request.DeleteRequest != nil:
+					items = append(items, request.DeleteRequest.Key)
+				}
+			}
+		}
+		return items
+	case *dynamodb.BatchGetItemInput:
+		var items []dynamap.Item
+		for _, table := range sortedKeys(in.RequestItems) {
+			for _, key := range in.RequestItems[table].Keys {
+				items = append(items, key)
+			}
+		}
+		return items
+	case *dynamodb.TransactWriteItemsInput:
+		var items []dynamap.Item
+		for _, item := range in.TransactItems {
+			switch {
+			case item.Put != nil:
+				items = append(items, item.Put.Item)
+			case item.Update != nil:
+				items = append(items, item.Update.Key)
+			case item.Delete != nil:
+				items = append(items, item.Delete.Key)
+			case item.ConditionCheck != nil:
+				items = append(items, item.ConditionCheck.Key)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// callExpressions returns the expressions of an operation input, with their expression
+// attribute names resolved.
+func callExpressions(input any) []string {
+	var expressions []string
+	add := func(names map[string]string, exprs ...*string) {
+		for _, expr := range exprs {
+			if expr != nil {
+				expressions = append(expressions, resolveNames(*expr, names))
+			}
+		}
+	}
+
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		add(in.ExpressionAttributeNames, in.ConditionExpression)
+	case *dynamodb.GetItemInput:
+		add(in.ExpressionAttributeNames, in.ProjectionExpression)
+	case *dynamodb.UpdateItemInput:
+		add(in.ExpressionAttributeNames, in.UpdateExpression, in.ConditionExpression)
+	case *dynamodb.DeleteItemInput:
+		add(in.ExpressionAttributeNames, in.ConditionExpression)
+	case *dynamodb.QueryInput:
+		add(in.ExpressionAttributeNames, in.KeyConditionExpression, in.FilterExpression, in.ProjectionExpression)
+	case *dynamodb.ScanInput:
+		add(in.ExpressionAttributeNames, in.FilterExpression, in.ProjectionExpression)
+	case *dynamodb.BatchGetItemInput:
+		for _, table := range sortedKeys(in.RequestItems) {
+			request := in.RequestItems[table]
+			add(request.ExpressionAttributeNames, request.ProjectionExpression)
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		for _, item := range in.TransactItems {
+			switch {
+			case item.Put != nil:
+				add(item.Put.ExpressionAttributeNames, item.Put.ConditionExpression)
+			case item.Update != nil:
+				add(item.Update.ExpressionAttributeNames, item.Update.UpdateExpression, item.Update.ConditionExpression)
+			case item.Delete != nil:
+				add(item.Delete.ExpressionAttributeNames, item.Delete.ConditionExpression)
+			case item.ConditionCheck != nil:
+				add(item.ConditionCheck.ExpressionAttributeNames, item.ConditionCheck.ConditionExpression)
+			}
+		}
+	}
+	return expressions
+}
+
+// resolveNames replaces the expression attribute names in expr by the names they stand
+// for. Longer placeholders are replaced first, so that #10 is not read as #1.
+func resolveNames(expr string, names map[string]string) string {
+	placeholders := sortedKeys(names)
+	sort.SliceStable(placeholders, func(i, j int) bool { return len(placeholders[i]) > len(placeholders[j]) })
+	for _, placeholder := range placeholders {
+		expr = strings.ReplaceAll(expr, placeholder, names[placeholder])
+	}
+	return expr
+}
+
+// stringAttribute returns the string value of the named attribute of item.
+func stringAttribute(item dynamap.Item, name string) string {
+	if s, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,128 @@
+package dynamock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// recordingT records the errors reported by assertions.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, format)
+}
+
+func TestMockClient_Expect(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+
+	t.Run("times called with label", func(t *testing.T) {
+		mock := NewMockClient(t)
+		mock.Expect("PutItem", MatchLabel("order")).Times(2)
+		mock.Expect("PutItem", MatchLabel("product")).Never()
+
+		for _, id := range []string{"O1", "O2"} {
+			entity := NewEntity(WithID(id), WithPrefix("order"), WithLabel("order")).Build()
+			if err := table.Put(ctx, mock, entity); err != nil {
+				t.Fatalf("Failed to put order: %v", err)
+			}
+		}
+
+		mock.AssertExpectations(t)
+		if got := mock.CallCount("PutItem", MatchKey("order#O2", "order#O2")); got != 1 {
+			t.Errorf("Expected 1 put of order#O2, got %d", got)
+		}
+		if got := len(mock.Calls("PutItem")); got != 2 {
+			t.Errorf("Expected 2 recorded puts, got %d", got)
+		}
+	})
+
+	t.Run("reports unmet expectations", func(t *testing.T) {
+		mock := NewMockClient(t)
+		mock.Expect("PutItem", MatchLabel("order")).Once()
+		mock.Expect("DeleteItem").AtLeast(1)
+
+		for range 2 {
+			if _, err := mock.PutItem(ctx, &dynamodb.PutItemInput{Item: dynamap.Item{"label": &types.AttributeValueMemberS{Value: "order"}}}); err != nil {
+				t.Fatalf("PutItem failed: %v", err)
+			}
+		}
+
+		recorder := &recordingT{TB: t}
+		if mock.AssertExpectations(recorder) {
+			t.Error("Expected unmet expectations")
+		}
+		if len(recorder.errors) != 2 {
+			t.Errorf("Expected 2 errors, got %v", recorder.errors)
+		}
+	})
+
+	t.Run("returns responses", func(t *testing.T) {
+		mock := NewMockClient(t)
+		failure := &types.ConditionalCheckFailedException{Message: aws.String("exists")}
+		mock.Expect("PutItem", MatchExpression("attribute_not_exists(hk)")).Return(nil, failure)
+		mock.Expect("Query", MatchLabel("order"), MatchTable("test-table")).Return(&dynamodb.QueryOutput{Count: 3}, nil)
+
+		put, err := table.MarshalPut(NewEntity(WithID("O1"), WithPrefix("order"), WithLabel("order")).Build())
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		put.ExpressionAttributeNames = map[string]string{"#hk": "hk"}
+		put.ConditionExpression = aws.String("attribute_not_exists(#hk)")
+		if _, err := mock.PutItem(ctx, put); !errors.Is(err, failure) {
+			t.Errorf("Expected condition failure, got %v", err)
+		}
+
+		query, err := table.MarshalQuery(&dynamap.QueryList{Label: "order"})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		output, err := mock.Query(ctx, query)
+		if err != nil || output.Count != 3 {
+			t.Errorf("Expected the query output, got %v and %v", output, err)
+		}
+
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("unmatched calls use the operation func", func(t *testing.T) {
+		mock := NewMockClient(t)
+		mock.Expect("GetItem", MatchKey("order#O1", "order#O1")).Never()
+		called := false
+		mock.GetFunc = func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			called = true
+			return &dynamodb.GetItemOutput{}, nil
+		}
+
+		get, err := table.MarshalGet(NewEntity(WithID("O2"), WithPrefix("order")).Build())
+		if err != nil {
+			t.Fatalf("Failed to marshal get: %v", err)
+		}
+		if _, err := mock.GetItem(ctx, get); err != nil {
+			t.Fatalf("GetItem failed: %v", err)
+		}
+		if !called {
+			t.Error("Expected GetFunc to be called")
+		}
+		mock.AssertExpectations(t)
+	})
+}
+
+func TestResolveNames(t *testing.T) {
+	got := resolveNames("#1 = :1 AND #10 <> :10", map[string]string{"#1": "label", "#10": "gsi1_sk"})
+	if !strings.Contains(got, "label = :1") || !strings.Contains(got, "gsi1_sk <> :10") {
+		t.Errorf("Unexpected expression %q", got)
+	}
+}
@@ -0,0 +1,1183 @@
+package dynamock
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// token is a lexical element of a DynamoDB expression.
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type tokenKind int
+
+const (
+	tokenEOF    tokenKind = iota
+	tokenIdent            // Attribute names, keywords and function names
+	tokenName             // Expression attribute name placeholders, such as #name
+	tokenValue            // Expression attribute value placeholders, such as :value
+	tokenNumber           // List indexes
+	tokenSymbol           // Operators and punctuation
+)
+
+// tokenize splits a DynamoDB expression into tokens.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || c == ':':
+			j := i + 1
+			for j < len(expr) && isIdentByte(expr[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("invalid placeholder at position %d", i)
+			}
+			kind := tokenName
+			if c == ':' {
+				kind = tokenValue
+			}
+			tokens = append(tokens, token{kind: kind, text: expr[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[i:j]})
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(expr) && isIdentByte(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[i:j]})
+			i = j
+		default:
+			symbol := symbolAt(expr[i:])
+			if symbol == "" {
+				return nil, fmt.Errorf("invalid character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol})
+			i += len(symbol)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// symbolAt returns the operator or punctuation symbol at the start of s, if any.
+func symbolAt(s string) string {
+	for _, symbol := range []string{"<>", "<=", ">=", "(", ")", ",", ".", "[", "]", "=", "<", ">", "+", "-"} {
+		if strings.HasPrefix(s, symbol) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// isIdentByte returns true if c may appear in an attribute name or placeholder.
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// pathElement is an attribute name or list index of a document path.
+type pathElement struct {
+	name  string
+	index int
+	list  bool
+}
+
+// path is a document path, such as data.items[0].name.
+type path []pathElement
+
+// parser parses DynamoDB expressions, substituting the expression attribute names and
+// values.
+type parser struct {
+	tokens []token
+	pos    int
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func newParser(expr string, names map[string]string, values map[string]types.AttributeValue) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens, names: names, values: values}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword returns true and consumes the next token if it is the keyword, in any case.
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol returns true and consumes the next token if it is the symbol.
+func (p *parser) symbol(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(symbol string) error {
+	if !p.symbol(symbol) {
+		return fmt.Errorf("expected %q, got %q", symbol, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) end() error {
+	if t := p.peek(); t.kind != tokenEOF {
+		return fmt.Errorf("unexpected token %q", t.text)
+	}
+	return nil
+}
+
+// path parses a document path.
+func (p *parser) path() (path, error) {
+	var result path
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokenIdent:
+			result = append(result, pathElement{name: t.text})
+		case tokenName:
+			name, ok := p.names[t.text]
+			if !ok {
+				return nil, fmt.Errorf("undefined expression attribute name %s", t.text)
+			}
+			result = append(result, pathElement{name: name})
+		default:
+			return nil, fmt.Errorf("expected attribute name, got %q", t.text)
+		}
+
+		for p.symbol("[") {
+			t := p.next()
+			if t.kind != tokenNumber {
+				return nil, fmt.Errorf("expected list index, got %q", t.text)
+			}
+			index, _ := strconv.Atoi(t.text)
+			result = append(result, pathElement{index: index, list: true})
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.symbol(".") {
+			return result, nil
+		}
+	}
+}
+
+// value parses an expression attribute value placeholder.
+func (p *parser) value() (types.AttributeValue, error) {
+	t := p.next()
+	if t.kind != tokenValue {
+		return nil, fmt.Errorf("expected expression attribute value, got %q", t.text)
+	}
+	value, ok := p.values[t.text]
+	if !ok {
+		return nil, fmt.Errorf("undefined expression attribute value %s", t.text)
+	}
+	return value, nil
+}
+
+// operand is a value compared by a condition.
+type operand interface {
+	eval(item dynamap.Item) (types.AttributeValue, bool)
+}
+
+type pathOperand struct{ path path }
+
+func (o pathOperand) eval(item dynamap.Item) (types.AttributeValue, bool) {
+	return getPath(item, o.path)
+}
+
+type valueOperand struct{ value types.AttributeValue }
+
+func (o valueOperand) eval(dynamap.Item) (types.AttributeValue, bool) {
+	return o.value, true
+}
+
+type sizeOperand struct{ path path }
+
+func (o sizeOperand) eval(item dynamap.Item) (types.AttributeValue, bool) {
+	value, ok := getPath(item, o.path)
+	if !ok {
+		return nil, false
+	}
+	var size int
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		size = utf8.RuneCountInString(v.Value)
+	case *types.AttributeValueMemberB:
+		size = len(v.Value)
+	case *types.AttributeValueMemberL:
+		size = len(v.Value)
+	case *types.AttributeValueMemberM:
+		size = len(v.Value)
+	case *types.AttributeValueMemberSS:
+		size = len(v.Value)
+	case *types.AttributeValueMemberNS:
+		size = len(v.Value)
+	case *types.AttributeValueMemberBS:
+		size = len(v.Value)
+	default:
+		return nil, false
+	}
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(size)}, true
+}
+
+// operand parses a path, value or size function.
+func (p *parser) operand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenValue:
+		value, err := p.value()
+		return valueOperand{value: value}, err
+	case t.kind == tokenIdent && strings.EqualFold(t.text, "size") && p.tokens[p.pos+1].text == "(":
+		p.pos += 2
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		return sizeOperand{path: path}, p.expect(")")
+	default:
+		path, err := p.path()
+		return pathOperand{path: path}, err
+	}
+}
+
+// condition is a condition, filter or key condition expression.
+type condition interface {
+	match(item dynamap.Item) bool
+}
+
+type andCondition struct{ left, right condition }
+
+func (c andCondition) match(item dynamap.Item) bool { return c.left.match(item) && c.right.match(item) }
+
+type orCondition struct{ left, right condition }
+
+func (c orCondition) match(item dynamap.Item) bool { return c.left.match(item) || c.right.match(item) }
+
+type notCondition struct{ condition condition }
+
+func (c notCondition) match(item dynamap.Item) bool { return !c.condition.match(item) }
+
+type compareCondition struct {
+	op          string
+	left, right operand
+}
+
+func (c compareCondition) match(item dynamap.Item) bool {
+	left, ok := c.left.eval(item)
+	if !ok {
+		return false
+	}
+	right, ok := c.right.eval(item)
+	if !ok {
+		return false
+	}
+
+	switch c.op {
+	case "=":
+		return equalValues(left, right)
+	case "<>":
+		return !equalValues(left, right)
+	}
+
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+type betweenCondition struct{ value, low, high operand }
+
+func (c betweenCondition) match(item dynamap.Item) bool {
+	return compareCondition{op: ">=", left: c.value, right: c.low}.match(item) &&
+		compareCondition{op: "<=", left: c.value, right: c.high}.match(item)
+}
+
+type inCondition struct {
+	value   operand
+	choices []operand
+}
+
+func (c inCondition) match(item dynamap.Item) bool {
+	return slices.ContainsFunc(c.choices, func(choice operand) bool {
+		return compareCondition{op: "=", left: c.value, right: choice}.match(item)
+	})
+}
+
+type functionCondition struct {
+	name string
+	path path
+	arg  operand
+}
+
+func (c functionCondition) match(item dynamap.Item) bool {
+	value, exists := getPath(item, c.path)
+	switch c.name {
+	case "attribute_exists":
+		return exists
+	case "attribute_not_exists":
+		return !exists
+	}
+	if !exists {
+		return false
+	}
+
+	arg, ok := c.arg.eval(item)
+	if !ok {
+		return false
+	}
+
+	switch c.name {
+	case "attribute_type":
+		s, ok := arg.(*types.AttributeValueMemberS)
+		return ok && typeOf(value) == s.Value
+	case "begins_with":
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			prefix, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(v.Value, prefix.Value)
+		case *types.AttributeValueMemberB:
+			prefix, ok := arg.(*types.AttributeValueMemberB)
+			return ok && bytes.HasPrefix(v.Value, prefix.Value)
+		}
+		return false
+	default: // contains
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			s, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.Contains(v.Value, s.Value)
+		case *types.AttributeValueMemberB:
+			b, ok := arg.(*types.AttributeValueMemberB)
+			return ok && bytes.Contains(v.Value, b.Value)
+		case *types.AttributeValueMemberL:
+			return slices.ContainsFunc(v.Value, func(element types.AttributeValue) bool { return equalValues(element, arg) })
+		case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+			return slices.ContainsFunc(setElements(value), func(element types.AttributeValue) bool { return equalValues(element, arg) })
+		}
+		return false
+	}
+}
+
+// parseCondition parses a condition, filter or key condition expression.
+func parseCondition(expr string, names map[string]string, values map[string]types.AttributeValue) (condition, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	return c, p.end()
+}
+
+func (p *parser) or() (condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (condition, error) {
+	if p.keyword("NOT") {
+		c, err := p.not()
+		return notCondition{condition: c}, err
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (condition, error) {
+	if p.symbol("(") {
+		c, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return c, p.expect(")")
+	}
+
+	if t := p.peek(); t.kind == tokenIdent && p.tokens[p.pos+1].text == "(" {
+		name := strings.ToLower(t.text)
+		switch name {
+		case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains":
+			p.pos += 2
+			path, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			c := functionCondition{name: name, path: path}
+			if name != "attribute_exists" && name != "attribute_not_exists" {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+				if c.arg, err = p.operand(); err != nil {
+					return nil, err
+				}
+			}
+			return c, p.expect(")")
+		}
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.keyword("BETWEEN"):
+		low, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, fmt.Errorf("expected AND in BETWEEN, got %q", p.peek().text)
+		}
+		high, err := p.operand()
+		return betweenCondition{value: left, low: low, high: high}, err
+	case p.keyword("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		c := inCondition{value: left}
+		for {
+			choice, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			c.choices = append(c.choices, choice)
+			if !p.symbol(",") {
+				return c, p.expect(")")
+			}
+		}
+	}
+
+	t := p.next()
+	if t.kind != tokenSymbol || !slices.Contains([]string{"=", "<>", "<", "<=", ">", ">="}, t.text) {
+		return nil, fmt.Errorf("expected comparator, got %q", t.text)
+	}
+	right, err := p.operand()
+	return compareCondition{op: t.text, left: left, right: right}, err
+}
+
+// update is a parsed update expression.
+type update struct {
+	set    []setAction
+	remove []path
+	add    []valueAction
+	delete []valueAction
+}
+
+type setAction struct {
+	path  path
+	value valueExpression
+}
+
+type valueAction struct {
+	path  path
+	value types.AttributeValue
+}
+
+// valueExpression is the right-hand side of a SET action.
+type valueExpression interface {
+	evalValue(item dynamap.Item) (types.AttributeValue, error)
+}
+
+type operandValue struct{ operand operand }
+
+func (v operandValue) evalValue(item dynamap.Item) (types.AttributeValue, error) {
+	value, ok := v.operand.eval(item)
+	if !ok {
+		return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+	}
+	return value, nil
+}
+
+type arithmeticValue struct {
+	op          string
+	left, right valueExpression
+}
+
+func (v arithmeticValue) evalValue(item dynamap.Item) (types.AttributeValue, error) {
+	left, err := v.left.evalValue(item)
+	if err != nil {
+		return nil, err
+	}
+	right, err := v.right.evalValue(item)
+	if err != nil {
+		return nil, err
+	}
+	a, aok := numberOf(left)
+	b, bok := numberOf(right)
+	if !aok || !bok {
+		return nil, fmt.Errorf("incorrect operand type for operator or function; operator: %s", v.op)
+	}
+	if v.op == "+" {
+		return numberValue(new(big.Rat).Add(a, b)), nil
+	}
+	return numberValue(new(big.Rat).Sub(a, b)), nil
+}
+
+type ifNotExistsValue struct {
+	path     path
+	fallback valueExpression
+}
+
+func (v ifNotExistsValue) evalValue(item dynamap.Item) (types.AttributeValue, error) {
+	if value, ok := getPath(item, v.path); ok {
+		return value, nil
+	}
+	return v.fallback.evalValue(item)
+}
+
+type listAppendValue struct{ left, right valueExpression }
+
+func (v listAppendValue) evalValue(item dynamap.Item) (types.AttributeValue, error) {
+	left, err := v.left.evalValue(item)
+	if err != nil {
+		return nil, err
+	}
+	right, err := v.right.evalValue(item)
+	if err != nil {
+		return nil, err
+	}
+	a, aok := left.(*types.AttributeValueMemberL)
+	b, bok := right.(*types.AttributeValueMemberL)
+	if !aok || !bok {
+		return nil, fmt.Errorf("incorrect operand type for operator or function; operator or function: list_append")
+	}
+	return &types.AttributeValueMemberL{Value: append(slices.Clone(a.Value), b.Value...)}, nil
+}
+
+// parseUpdate parses an update expression.
+func parseUpdate(expr string, names map[string]string, values map[string]types.AttributeValue) (*update, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &update{}
+	for p.peek().kind != tokenEOF {
+		switch {
+		case p.keyword("SET"):
+			for {
+				path, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect("="); err != nil {
+					return nil, err
+				}
+				value, err := p.setValue()
+				if err != nil {
+					return nil, err
+				}
+				u.set = append(u.set, setAction{path: path, value: value})
+				if !p.symbol(",") {
+					break
+				}
+			}
+		case p.keyword("REMOVE"):
+			for {
+				path, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				u.remove = append(u.remove, path)
+				if !p.symbol(",") {
+					break
+				}
+			}
+		case p.keyword("ADD"), p.keyword("DELETE"):
+			deleting := strings.EqualFold(p.tokens[p.pos-1].text, "DELETE")
+			for {
+				path, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				value, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if deleting {
+					u.delete = append(u.delete, valueAction{path: path, value: value})
+				} else {
+					u.add = append(u.add, valueAction{path: path, value: value})
+				}
+				if !p.symbol(",") {
+					break
+				}
+			}
+		default:
+			return nil, fmt.Errorf("expected SET, REMOVE, ADD or DELETE, got %q", p.peek().text)
+		}
+	}
+
+	return u, nil
+}
+
+// setValue parses the value of a SET action: an operand or function, optionally added to
+// or subtracted from another.
+func (p *parser) setValue() (valueExpression, error) {
+	left, err := p.setTerm()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"+", "-"} {
+		if p.symbol(op) {
+			right, err := p.setTerm()
+			return arithmeticValue{op: op, left: left, right: right}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) setTerm() (valueExpression, error) {
+	if t := p.peek(); t.kind == tokenIdent && p.tokens[p.pos+1].text == "(" {
+		switch strings.ToLower(t.text) {
+		case "if_not_exists":
+			p.pos += 2
+			path, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			fallback, err := p.setValue()
+			if err != nil {
+				return nil, err
+			}
+			return ifNotExistsValue{path: path, fallback: fallback}, p.expect(")")
+		case "list_append":
+			p.pos += 2
+			left, err := p.setValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			right, err := p.setValue()
+			if err != nil {
+				return nil, err
+			}
+			return listAppendValue{left: left, right: right}, p.expect(")")
+		}
+	}
+
+	operand, err := p.operand()
+	return operandValue{operand: operand}, err
+}
+
+// apply applies the update to item in place. Values are evaluated against the item as it
+// was before the update.
+func (u *update) apply(item dynamap.Item) error {
+	original := cloneItem(item)
+
+	values := make([]types.AttributeValue, len(u.set))
+	for i, action := range u.set {
+		value, err := action.value.evalValue(original)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	for i, action := range u.set {
+		if err := setPath(item, action.path, cloneValue(values[i])); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range u.remove {
+		removePath(item, path)
+	}
+
+	for _, action := range u.add {
+		current, exists := getPath(item, action.path)
+		if !exists {
+			if err := setPath(item, action.path, cloneValue(action.value)); err != nil {
+				return err
+			}
+			continue
+		}
+		a, aok := numberOf(current)
+		b, bok := numberOf(action.value)
+		switch {
+		case aok && bok:
+			if err := setPath(item, action.path, numberValue(new(big.Rat).Add(a, b))); err != nil {
+				return err
+			}
+		case typeOf(current) == typeOf(action.value) && isSet(current):
+			if err := setPath(item, action.path, setUnion(current, action.value)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("an operand in the update expression has an incorrect data type")
+		}
+	}
+
+	for _, action := range u.delete {
+		current, exists := getPath(item, action.path)
+		if !exists {
+			continue
+		}
+		if typeOf(current) != typeOf(action.value) || !isSet(current) {
+			return fmt.Errorf("an operand in the update expression has an incorrect data type")
+		}
+		if remaining := setDifference(current, action.value); remaining == nil {
+			removePath(item, action.path)
+		} else if err := setPath(item, action.path, remaining); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paths returns the document paths changed by the update.
+func (u *update) paths() []path {
+	var paths []path
+	for _, action := range u.set {
+		paths = append(paths, action.path)
+	}
+	paths = append(paths, u.remove...)
+	for _, action := range append(slices.Clone(u.add), u.delete...) {
+		paths = append(paths, action.path)
+	}
+	return paths
+}
+
+// parseProjection parses a projection expression into its document paths.
+func parseProjection(expr string, names map[string]string) ([]path, error) {
+	p, err := newParser(expr, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []path
+	for {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if !p.symbol(",") {
+			return paths, p.end()
+		}
+	}
+}
+
+// project returns the attributes of item at the given paths.
+func project(item dynamap.Item, paths []path) dynamap.Item {
+	projected := make(dynamap.Item)
+	for _, path := range paths {
+		value, ok := getPath(item, path)
+		if !ok {
+			continue
+		}
+		container := types.AttributeValue(&types.AttributeValueMemberM{Value: projected})
+		for i, element := range path[:len(path)-1] {
+			next := path[i+1]
+			child, ok := childOf(container, element)
+			if !ok {
+				if next.list {
+					child = &types.AttributeValueMemberL{}
+				} else {
+					child = &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue)}
+				}
+				setChild(container, element, child)
+			}
+			container = child
+		}
+		setChild(container, path[len(path)-1], cloneValue(value))
+	}
+	return projected
+}
+
+// getPath returns the value of item at path.
+func getPath(item dynamap.Item, p path) (types.AttributeValue, bool) {
+	value, ok := item[p[0].name]
+	if !ok || p[0].list {
+		return nil, false
+	}
+	for _, element := range p[1:] {
+		if value, ok = childOf(value, element); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setPath sets the value of item at path. The parent of a nested path must exist.
+func setPath(item dynamap.Item, p path, value types.AttributeValue) error {
+	container := types.AttributeValue(&types.AttributeValueMemberM{Value: item})
+	for _, element := range p[:len(p)-1] {
+		child, ok := childOf(container, element)
+		if !ok {
+			return fmt.Errorf("the document path provided in the update expression is invalid for update")
+		}
+		container = child
+	}
+	if !setChild(container, p[len(p)-1], value) {
+		return fmt.Errorf("the document path provided in the update expression is invalid for update")
+	}
+	return nil
+}
+
+// removePath removes the value of item at path, if any.
+func removePath(item dynamap.Item, p path) {
+	container := types.AttributeValue(&types.AttributeValueMemberM{Value: item})
+	for _, element := range p[:len(p)-1] {
+		child, ok := childOf(container, element)
+		if !ok {
+			return
+		}
+		container = child
+	}
+	switch c := container.(type) {
+	case *types.AttributeValueMemberM:
+		delete(c.Value, p[len(p)-1].name)
+	case *types.AttributeValueMemberL:
+		if index := p[len(p)-1].index; p[len(p)-1].list && index < len(c.Value) {
+			c.Value = slices.Delete(c.Value, index, index+1)
+		}
+	}
+}
+
+// childOf returns the element of a map or list value.
+func childOf(value types.AttributeValue, element pathElement) (types.AttributeValue, bool) {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberM:
+		if element.list {
+			return nil, false
+		}
+		child, ok := v.Value[element.name]
+		return child, ok
+	case *types.AttributeValueMemberL:
+		if !element.list || element.index >= len(v.Value) {
+			return nil, false
+		}
+		return v.Value[element.index], true
+	}
+	return nil, false
+}
+
+// setChild sets the element of a map or list value. List elements past the end of the
+// list are appended.
+func setChild(container types.AttributeValue, element pathElement, value types.AttributeValue) bool {
+	switch c := container.(type) {
+	case *types.AttributeValueMemberM:
+		if element.list {
+			return false
+		}
+		if c.Value == nil {
+			c.Value = make(map[string]types.AttributeValue)
+		}
+		c.Value[element.name] = value
+		return true
+	case *types.AttributeValueMemberL:
+		if !element.list {
+			return false
+		}
+		if element.index < len(c.Value) {
+			c.Value[element.index] = value
+		} else {
+			c.Value = append(c.Value, value)
+		}
+		return true
+	}
+	return false
+}
+
+// typeOf returns the DynamoDB type descriptor of value, such as "S" or "NS".
+func typeOf(value types.AttributeValue) string {
+	switch value.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	}
+	return ""
+}
+
+// numberOf returns the value of a number attribute.
+func numberOf(value types.AttributeValue) (*big.Rat, bool) {
+	n, ok := value.(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(n.Value)
+}
+
+// numberValue returns a number attribute in its shortest decimal form.
+func numberValue(n *big.Rat) types.AttributeValue {
+	if n.IsInt() {
+		return &types.AttributeValueMemberN{Value: n.Num().String()}
+	}
+	s := strings.TrimRight(n.FloatString(38), "0")
+	return &types.AttributeValueMemberN{Value: s}
+}
+
+// compareValues orders two strings, numbers or binaries of the same type.
+func compareValues(a, b types.AttributeValue) (int, bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		x, xok := numberOf(a)
+		y, yok := numberOf(b)
+		if xok && yok {
+			return x.Cmp(y), true
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value), true
+		}
+	}
+	return 0, false
+}
+
+// equalValues returns true if the values are equal. Sets are compared regardless of the
+// order of their elements.
+func equalValues(a, b types.AttributeValue) bool {
+	if typeOf(a) != typeOf(b) {
+		return false
+	}
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		cmp, ok := compareValues(a, b)
+		return ok && cmp == 0
+	case *types.AttributeValueMemberBOOL:
+		return a.Value == b.(*types.AttributeValueMemberBOOL).Value
+	case *types.AttributeValueMemberNULL:
+		return a.Value == b.(*types.AttributeValueMemberNULL).Value
+	case *types.AttributeValueMemberL:
+		return slices.EqualFunc(a.Value, b.(*types.AttributeValueMemberL).Value, equalValues)
+	case *types.AttributeValueMemberM:
+		other := b.(*types.AttributeValueMemberM).Value
+		if len(a.Value) != len(other) {
+			return false
+		}
+		for name, value := range a.Value {
+			if o, ok := other[name]; !ok || !equalValues(value, o) {
+				return false
+			}
+		}
+		return true
+	default:
+		x, y := setElements(a), setElements(b)
+		return len(x) == len(y) && !slices.ContainsFunc(x, func(e types.AttributeValue) bool {
+			return !slices.ContainsFunc(y, func(o types.AttributeValue) bool { return equalValues(e, o) })
+		})
+	}
+}
+
+// isSet returns true if value is a string, number or binary set.
+func isSet(value types.AttributeValue) bool {
+	switch value.(type) {
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		return true
+	}
+	return false
+}
+
+// setElements returns the elements of a set as scalar values.
+func setElements(value types.AttributeValue) []types.AttributeValue {
+	var elements []types.AttributeValue
+	switch v := value.(type) {
+	case *types.AttributeValueMemberSS:
+		for _, s := range v.Value {
+			elements = append(elements, &types.AttributeValueMemberS{Value: s})
+		}
+	case *types.AttributeValueMemberNS:
+		for _, n := range v.Value {
+			elements = append(elements, &types.AttributeValueMemberN{Value: n})
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range v.Value {
+			elements = append(elements, &types.AttributeValueMemberB{Value: b})
+		}
+	}
+	return elements
+}
+
+// newSet returns a set of the type of like holding elements, or nil if elements is empty.
+func newSet(like types.AttributeValue, elements []types.AttributeValue) types.AttributeValue {
+	if len(elements) == 0 {
+		return nil
+	}
+	switch like.(type) {
+	case *types.AttributeValueMemberSS:
+		set := &types.AttributeValueMemberSS{}
+		for _, e := range elements {
+			set.Value = append(set.Value, e.(*types.AttributeValueMemberS).Value)
+		}
+		return set
+	case *types.AttributeValueMemberNS:
+		set := &types.AttributeValueMemberNS{}
+		for _, e := range elements {
+			set.Value = append(set.Value, e.(*types.AttributeValueMemberN).Value)
+		}
+		return set
+	default:
+		set := &types.AttributeValueMemberBS{}
+		for _, e := range elements {
+			set.Value = append(set.Value, e.(*types.AttributeValueMemberB).Value)
+		}
+		return set
+	}
+}
+
+// setUnion returns the elements of either set.
+func setUnion(a, b types.AttributeValue) types.AttributeValue {
+	elements := setElements(a)
+	for _, e := range setElements(b) {
+		if !slices.ContainsFunc(elements, func(o types.AttributeValue) bool { return equalValues(e, o) }) {
+			elements = append(elements, e)
+		}
+	}
+	return newSet(a, elements)
+}
+
+// setDifference returns the elements of a that are not in b, or nil if there are none.
+func setDifference(a, b types.AttributeValue) types.AttributeValue {
+	removed := setElements(b)
+	elements := slices.DeleteFunc(setElements(a), func(e types.AttributeValue) bool {
+		return slices.ContainsFunc(removed, func(o types.AttributeValue) bool { return equalValues(e, o) })
+	})
+	return newSet(a, elements)
+}
+
+// cloneItem returns a deep copy of item.
+func cloneItem(item dynamap.Item) dynamap.Item {
+	if item == nil {
+		return nil
+	}
+	clone := make(dynamap.Item, len(item))
+	for name, value := range item {
+		clone[name] = cloneValue(value)
+	}
+	return clone
+}
+
+// cloneValue returns a deep copy of value.
+func cloneValue(value types.AttributeValue) types.AttributeValue {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: bytes.Clone(v.Value)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberL:
+		list := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, len(v.Value))}
+		for i, element := range v.Value {
+			list.Value[i] = cloneValue(element)
+		}
+		return list
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: cloneItem(v.Value)}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: slices.Clone(v.Value)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: slices.Clone(v.Value)}
+	case *types.AttributeValueMemberBS:
+		set := &types.AttributeValueMemberBS{Value: make([][]byte, len(v.Value))}
+		for i, b := range v.Value {
+			set.Value[i] = bytes.Clone(b)
+		}
+		return set
+	}
+	return value
+}
@@ -0,0 +1,926 @@
+package dynamock
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/nisimpson/dynamap"
+)
+
+// FakeClient is a stateful, in-memory DynamoDB simulator. Unlike [MockClient], it stores
+// the items written to it and evaluates requests against them the way DynamoDB does:
+// key conditions on the table keys and on secondary indexes such as the dynamap ref
+// index, condition, filter, update and projection expressions, limits applied before
+// filters, and LastEvaluatedKey pagination.
+//
+// Tables are created from the schema of a [dynamap.Table], or with CreateTable. Requests
+// to unknown tables fail with a ResourceNotFoundException, and invalid requests with a
+// ValidationException. FakeClient is safe for concurrent use.
+//
+// Example:
+//
+//	table := dynamap.NewTable("test-table")
+//	client := dynamock.NewFakeClient(table)
+//
+//	err := table.Put(ctx, client, &Order{ID: "O1"})
+//	...
+//	list, err := table.MarshalList(&dynamap.QueryList{Label: "order"})
+//	output, err := client.Query(ctx, list)
+type FakeClient struct {
+	mu     sync.Mutex
+	tables map[string]*fakeTable
+}
+
+// Ensure FakeClient implements DynamoDBAPI and the optional interfaces
+var (
+	_ DynamoDBAPI      = (*FakeClient)(nil)
+	_ BatchGetAPI      = (*FakeClient)(nil)
+	_ TransactWriteAPI = (*FakeClient)(nil)
+)
+
+// fakeTable holds the schema and items of a table.
+type fakeTable struct {
+	description types.TableDescription
+	key         fakeSchema
+	indexes     map[string]fakeIndex
+	items       map[string]dynamap.Item
+}
+
+// fakeSchema is the key schema of a table or index.
+type fakeSchema struct {
+	hash, sort string
+}
+
+// fakeIndex is a secondary index of a table.
+type fakeIndex struct {
+	key        fakeSchema
+	projection types.Projection
+}
+
+// NewFakeClient creates an empty FakeClient holding a table for each of tables, with the
+// schema of [dynamap.Table.CreateTableInput].
+func NewFakeClient(tables ...*dynamap.Table) *FakeClient {
+	client := &FakeClient{tables: make(map[string]*fakeTable)}
+	for _, table := range tables {
+		if _, err := client.CreateTable(context.Background(), table.CreateTableInput()); err != nil {
+			panic(fmt.Sprintf("dynamock: failed to create fake table %s: %v", table.TableName, err))
+		}
+	}
+	return client
+}
+
+// Items returns a copy of the items stored in the table, in key order.
+func (c *FakeClient) Items(tableName string) []dynamap.Item {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, ok := c.tables[tableName]
+	if !ok {
+		return nil
+	}
+	var items []dynamap.Item
+	for _, key := range slices.Sorted(maps.Keys(table.items)) {
+		items = append(items, cloneItem(table.items[key]))
+	}
+	return items
+}
+
+// Reset removes all items from every table, keeping their schemas.
+func (c *FakeClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, table := range c.tables {
+		table.items = make(map[string]dynamap.Item)
+	}
+}
+
+// CreateTable creates a table with the key schema and global and local secondary
+// indexes of params.
+func (c *FakeClient) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := aws.ToString(params.TableName)
+	if _, ok := c.tables[name]; ok {
+		return nil, &types.ResourceInUseException{Message: aws.String("Table already exists: " + name)}
+	}
+
+	table := &fakeTable{
+		key:     schemaOf(params.KeySchema),
+		indexes: make(map[string]fakeIndex),
+		items:   make(map[string]dynamap.Item),
+		description: types.TableDescription{
+			TableName:            params.TableName,
+			TableArn:             aws.String("arn:aws:dynamodb:us-east-1:000000000000:table/" + name),
+			TableStatus:          types.TableStatusActive,
+			KeySchema:            params.KeySchema,
+			AttributeDefinitions: params.AttributeDefinitions,
+			StreamSpecification:  params.StreamSpecification,
+		},
+	}
+	if table.key.hash == "" {
+		return nil, validationError("One or more parameter values were invalid: missing hash key in key schema")
+	}
+
+	for _, index := range params.GlobalSecondaryIndexes {
+		table.indexes[aws.ToString(index.IndexName)] = fakeIndex{key: schemaOf(index.KeySchema), projection: projectionOf(index.Projection)}
+		table.description.GlobalSecondaryIndexes = append(table.description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   index.IndexName,
+			KeySchema:   index.KeySchema,
+			Projection:  index.Projection,
+			IndexStatus: types.IndexStatusActive,
+		})
+	}
+	for _, index := range params.LocalSecondaryIndexes {
+		table.indexes[aws.ToString(index.IndexName)] = fakeIndex{key: schemaOf(index.KeySchema), projection: projectionOf(index.Projection)}
+		table.description.LocalSecondaryIndexes = append(table.description.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+			IndexName:  index.IndexName,
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		})
+	}
+
+	c.tables[name] = table
+	description := table.description
+	return &dynamodb.CreateTableOutput{TableDescription: &description}, nil
+}
+
+// DeleteTable deletes a table and its items.
+func (c *FakeClient) DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.tables, aws.ToString(params.TableName))
+	description := table.description
+	description.TableStatus = types.TableStatusDeleting
+	return &dynamodb.DeleteTableOutput{TableDescription: &description}, nil
+}
+
+// DescribeTable describes a table and its indexes.
+func (c *FakeClient) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	description := table.description
+	description.ItemCount = aws.Int64(int64(len(table.items)))
+	return &dynamodb.DescribeTableOutput{Table: &description}, nil
+}
+
+// ListTables lists the tables in name order.
+func (c *FakeClient) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &dynamodb.ListTablesOutput{TableNames: slices.Sorted(maps.Keys(c.tables))}, nil
+}
+
+// PutItem stores an item, replacing any item with the same key.
+func (c *FakeClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.itemKey(params.Item, true)
+	if err != nil {
+		return nil, err
+	}
+	old := table.items[key]
+	if err := checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old); err != nil {
+		return nil, err
+	}
+
+	table.items[key] = cloneItem(params.Item)
+
+	output := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = old
+	}
+	return output, nil
+}
+
+// GetItem returns the item with the given key, if any.
+func (c *FakeClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.itemKey(params.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := table.items[key]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	item, err = projectItem(item, params.ProjectionExpression, params.ExpressionAttributeNames)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: item}, nil
+}
+
+// UpdateItem updates the item with the given key, creating it if it does not exist.
+func (c *FakeClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.itemKey(params.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	old := table.items[key]
+	if err := checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old); err != nil {
+		return nil, err
+	}
+
+	item, changed, err := table.updateItem(old, params.Key, aws.ToString(params.UpdateExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+	table.items[key] = item
+
+	output := &dynamodb.UpdateItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueAllOld:
+		output.Attributes = cloneItem(old)
+	case types.ReturnValueAllNew:
+		output.Attributes = cloneItem(item)
+	case types.ReturnValueUpdatedOld:
+		output.Attributes = project(old, changed)
+	case types.ReturnValueUpdatedNew:
+		output.Attributes = project(item, changed)
+	}
+	return output, nil
+}
+
+// DeleteItem removes the item with the given key, if any.
+func (c *FakeClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.itemKey(params.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	old := table.items[key]
+	if err := checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old); err != nil {
+		return nil, err
+	}
+
+	delete(table.items, key)
+
+	output := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = old
+	}
+	return output, nil
+}
+
+// BatchWriteItem puts and deletes up to 25 items. All requests are processed, so
+// UnprocessedItems is always empty.
+func (c *FakeClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type write struct {
+		table *fakeTable
+		key   string
+		item  dynamap.Item
+	}
+
+	var (
+		writes []write
+		seen   = make(map[string]bool)
+	)
+	for name, requests := range params.RequestItems {
+		table, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		for _, request := range requests {
+			var (
+				key  string
+				item dynamap.Item
+				err  error
+			)
+			switch {
+			case request.PutRequest != nil:
+				item = request.PutRequest.Item
+				key, err = table.itemKey(item, true)
+			case request.DeleteRequest != nil:
+				key, err = table.itemKey(request.DeleteRequest.Key, false)
+			default:
+				err = validationError("Supplied write request has neither a put nor a delete request")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if seen[name+"\x00"+key] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[name+"\x00"+key] = true
+			writes = append(writes, write{table: table, key: key, item: item})
+		}
+	}
+	if len(writes) > 25 {
+		return nil, validationError("Too many items requested for the BatchWriteItem call")
+	}
+
+	for _, w := range writes {
+		if w.item != nil {
+			w.table.items[w.key] = cloneItem(w.item)
+		} else {
+			delete(w.table.items, w.key)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}, nil
+}
+
+// BatchGetItem returns up to 100 items by key. All keys are processed, so UnprocessedKeys
+// is always empty.
+func (c *FakeClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	output := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]map[string]types.AttributeValue),
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+	count := 0
+	for name, request := range params.RequestItems {
+		table, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		count += len(request.Keys)
+		if count > 100 {
+			return nil, validationError("Too many items requested for the BatchGetItem call")
+		}
+		responses := []map[string]types.AttributeValue{}
+		for _, k := range request.Keys {
+			key, err := table.itemKey(k, false)
+			if err != nil {
+				return nil, err
+			}
+			item, ok := table.items[key]
+			if !ok {
+				continue
+			}
+			item, err = projectItem(item, request.ProjectionExpression, request.ExpressionAttributeNames)
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, item)
+		}
+		output.Responses[name] = responses
+	}
+	return output, nil
+}
+
+// TransactWriteItems applies up to 100 puts, updates, deletes and condition checks
+// atomically. If any condition fails, no write is applied and a
+// TransactionCanceledException lists the reason of each action.
+func (c *FakeClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(params.TransactItems) > 100 {
+		return nil, validationError("Member must have length less than or equal to 100")
+	}
+
+	type write struct {
+		table *fakeTable
+		key   string
+		item  dynamap.Item // The item written, or nil to delete
+		check bool         // Condition checks do not write
+	}
+
+	var (
+		writes   []write
+		reasons  = make([]types.CancellationReason, len(params.TransactItems))
+		canceled bool
+		seen     = make(map[string]bool)
+	)
+	for i, action := range params.TransactItems {
+		var (
+			tableName, condition *string
+			names                map[string]string
+			values               map[string]types.AttributeValue
+			itemKey              dynamap.Item
+			put                  bool
+		)
+		switch {
+		case action.Put != nil:
+			tableName, condition, names, values = action.Put.TableName, action.Put.ConditionExpression, action.Put.ExpressionAttributeNames, action.Put.ExpressionAttributeValues
+			itemKey, put = action.Put.Item, true
+		case action.Update != nil:
+			tableName, condition, names, values = action.Update.TableName, action.Update.ConditionExpression, action.Update.ExpressionAttributeNames, action.Update.ExpressionAttributeValues
+			itemKey = action.Update.Key
+		case action.Delete != nil:
+			tableName, condition, names, values = action.Delete.TableName, action.Delete.ConditionExpression, action.Delete.ExpressionAttributeNames, action.Delete.ExpressionAttributeValues
+			itemKey = action.Delete.Key
+		case action.ConditionCheck != nil:
+			tableName, condition, names, values = action.ConditionCheck.TableName, action.ConditionCheck.ConditionExpression, action.ConditionCheck.ExpressionAttributeNames, action.ConditionCheck.ExpressionAttributeValues
+			itemKey = action.ConditionCheck.Key
+		default:
+			return nil, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+		}
+
+		table, err := c.table(tableName)
+		if err != nil {
+			return nil, err
+		}
+		key, err := table.itemKey(itemKey, put)
+		if err != nil {
+			return nil, err
+		}
+		if seen[aws.ToString(tableName)+"\x00"+key] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		seen[aws.ToString(tableName)+"\x00"+key] = true
+
+		old := table.items[key]
+		reasons[i] = types.CancellationReason{Code: aws.String("None")}
+		if err := checkCondition(condition, names, values, old); err != nil {
+			var failed *types.ConditionalCheckFailedException
+			if !errors.As(err, &failed) {
+				return nil, err
+			}
+			reasons[i] = types.CancellationReason{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")}
+			canceled = true
+			continue
+		}
+
+		w := write{table: table, key: key}
+		switch {
+		case action.Put != nil:
+			w.item = cloneItem(action.Put.Item)
+		case action.Update != nil:
+			if w.item, _, err = table.updateItem(old, action.Update.Key, aws.ToString(action.Update.UpdateExpression), names, values); err != nil {
+				return nil, err
+			}
+		case action.ConditionCheck != nil:
+			w.check = true
+		}
+		writes = append(writes, w)
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, w := range writes {
+		switch {
+		case w.check:
+		case w.item != nil:
+			w.table.items[w.key] = w.item
+		default:
+			delete(w.table.items, w.key)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// Query returns the items of a table or index matching the key condition, in sort key
+// order, evaluating at most Limit items before applying the filter.
+func (c *FakeClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := table.schema(params.IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if params.KeyConditionExpression == nil {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request")
+	}
+	keyCondition, err := parseExpression(parseCondition, *params.KeyConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []dynamap.Item
+	for _, item := range table.indexItems(params.IndexName) {
+		if keyCondition.match(item) {
+			items = append(items, item)
+		}
+	}
+
+	// Items are ordered by the sort key, then by table key for index items sharing one
+	less := func(a, b dynamap.Item) bool {
+		if cmp := compareKeys(a[schema.sort], b[schema.sort]); cmp != 0 {
+			return cmp < 0
+		}
+		return table.keyString(a) < table.keyString(b)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	start := params.ExclusiveStartKey
+	after := func(item dynamap.Item) bool { return less(start, item) }
+	if params.ScanIndexForward != nil && !*params.ScanIndexForward {
+		slices.Reverse(items)
+		after = func(item dynamap.Item) bool { return less(item, start) }
+	}
+	if len(start) > 0 {
+		items = slices.DeleteFunc(items, func(item dynamap.Item) bool { return !after(item) })
+	}
+
+	page, lastKey := table.page(items, params.Limit, params.IndexName)
+	matched, err := filterItems(page, params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.QueryOutput{
+		Count:            int32(len(matched)),
+		ScannedCount:     int32(len(page)),
+		LastEvaluatedKey: lastKey,
+	}
+	if params.Select != types.SelectCount {
+		if output.Items, err = table.projectItems(matched, params.IndexName, params.ProjectionExpression, params.ExpressionAttributeNames); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// Scan returns the items of a table or index in key order, evaluating at most Limit items
+// before applying the filter. Items are assigned to parallel scan segments by the hash of
+// their key.
+func (c *FakeClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := table.schema(params.IndexName); err != nil {
+		return nil, err
+	}
+	segments := int(aws.ToInt32(params.TotalSegments))
+	segment := int(aws.ToInt32(params.Segment))
+	if segments < 0 || segment < 0 || (segments > 0 && segment >= segments) {
+		return nil, validationError("The Segment parameter must be less than the TotalSegments parameter")
+	}
+
+	var items []dynamap.Item
+	for _, item := range table.indexItems(params.IndexName) {
+		if segments > 0 {
+			h := fnv.New32a()
+			h.Write([]byte(table.keyString(item)))
+			if int(h.Sum32()%uint32(segments)) != segment {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return table.keyString(items[i]) < table.keyString(items[j]) })
+	if start := params.ExclusiveStartKey; len(start) > 0 {
+		items = slices.DeleteFunc(items, func(item dynamap.Item) bool { return table.keyString(item) <= table.keyString(start) })
+	}
+
+	page, lastKey := table.page(items, params.Limit, params.IndexName)
+	matched, err := filterItems(page, params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.ScanOutput{
+		Count:            int32(len(matched)),
+		ScannedCount:     int32(len(page)),
+		LastEvaluatedKey: lastKey,
+	}
+	if params.Select != types.SelectCount {
+		if output.Items, err = table.projectItems(matched, params.IndexName, params.ProjectionExpression, params.ExpressionAttributeNames); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// table returns the named table, or a ResourceNotFoundException.
+func (c *FakeClient) table(name *string) (*fakeTable, error) {
+	table, ok := c.tables[aws.ToString(name)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found: Table: " + aws.ToString(name) + " not found")}
+	}
+	return table, nil
+}
+
+// schema returns the key schema of the table, or of the named index.
+func (t *fakeTable) schema(indexName *string) (fakeSchema, error) {
+	if indexName == nil {
+		return t.key, nil
+	}
+	index, ok := t.indexes[*indexName]
+	if !ok {
+		return fakeSchema{}, validationError("The table does not have the specified index: " + *indexName)
+	}
+	return index.key, nil
+}
+
+// itemKey validates the table key attributes of item and returns its encoded key. Items
+// written must also hold string, number or binary values for the keys of every index
+// they are part of; requests reading or deleting items must hold only the key.
+func (t *fakeTable) itemKey(item dynamap.Item, write bool) (string, error) {
+	for _, name := range []string{t.key.hash, t.key.sort} {
+		if name == "" {
+			continue
+		}
+		value, ok := item[name]
+		if !ok {
+			return "", validationError("One or more parameter values were invalid: Missing the key " + name + " in the item")
+		}
+		if err := t.checkKeyType(name, value); err != nil {
+			return "", err
+		}
+	}
+
+	if !write {
+		if len(item) != len(t.key.names()) {
+			return "", validationError("The provided key element does not match the schema")
+		}
+		return t.keyString(item), nil
+	}
+
+	for _, index := range t.indexes {
+		for _, name := range index.key.names() {
+			if value, ok := item[name]; ok {
+				if err := t.checkKeyType(name, value); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+	return t.keyString(item), nil
+}
+
+// checkKeyType validates that value has the type defined for the key attribute name.
+func (t *fakeTable) checkKeyType(name string, value types.AttributeValue) error {
+	for _, definition := range t.description.AttributeDefinitions {
+		if aws.ToString(definition.AttributeName) == name && typeOf(value) != string(definition.AttributeType) {
+			return validationError(fmt.Sprintf("One or more parameter values were invalid: Type mismatch for key %s expected: %s actual: %s", name, definition.AttributeType, typeOf(value)))
+		}
+	}
+	return nil
+}
+
+// keyString encodes the table key of item.
+func (t *fakeTable) keyString(item dynamap.Item) string {
+	key := keyValueString(item[t.key.hash])
+	if t.key.sort != "" {
+		key += "\x00" + keyValueString(item[t.key.sort])
+	}
+	return key
+}
+
+// indexItems returns the items of the table, or of the named index. Indexes are sparse:
+// only items holding all the index key attributes are part of them.
+func (t *fakeTable) indexItems(indexName *string) []dynamap.Item {
+	var names []string
+	if indexName != nil {
+		names = t.indexes[*indexName].key.names()
+	}
+
+	var items []dynamap.Item
+	for _, item := range t.items {
+		if !slices.ContainsFunc(names, func(name string) bool { _, ok := item[name]; return !ok }) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// page returns the first limit items, and the key of the last one if more items remain.
+// Keys of index items hold both the table and the index keys.
+func (t *fakeTable) page(items []dynamap.Item, limit *int32, indexName *string) ([]dynamap.Item, dynamap.Item) {
+	n := int(aws.ToInt32(limit))
+	if n <= 0 || n >= len(items) {
+		return items, nil
+	}
+
+	last := items[n-1]
+	names := t.key.names()
+	if indexName != nil {
+		names = append(names, t.indexes[*indexName].key.names()...)
+	}
+	key := make(dynamap.Item, len(names))
+	for _, name := range names {
+		key[name] = cloneValue(last[name])
+	}
+	return items[:n], key
+}
+
+// projectItems returns copies of items holding the attributes projected by the index, if
+// any, and the projection expression.
+func (t *fakeTable) projectItems(items []dynamap.Item, indexName, expr *string, names map[string]string) ([]map[string]types.AttributeValue, error) {
+	projected := []map[string]types.AttributeValue{}
+	for _, item := range items {
+		if indexName != nil {
+			index := t.indexes[*indexName]
+			switch index.projection.ProjectionType {
+			case types.ProjectionTypeKeysOnly, types.ProjectionTypeInclude:
+				attributes := append(t.key.names(), index.key.names()...)
+				if index.projection.ProjectionType == types.ProjectionTypeInclude {
+					attributes = append(attributes, index.projection.NonKeyAttributes...)
+				}
+				item = maps.Clone(item)
+				maps.DeleteFunc(item, func(name string, _ types.AttributeValue) bool { return !slices.Contains(attributes, name) })
+			}
+		}
+		item, err := projectItem(item, expr, names)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, item)
+	}
+	return projected, nil
+}
+
+// updateItem returns a copy of old, or of a new item with key if old is nil, updated by
+// the update expression, and the paths the update changed.
+func (t *fakeTable) updateItem(old, key dynamap.Item, expr string, names map[string]string, values map[string]types.AttributeValue) (dynamap.Item, []path, error) {
+	item := cloneItem(old)
+	if item == nil {
+		item = cloneItem(key)
+	}
+	if expr == "" {
+		return item, nil, nil
+	}
+
+	u, err := parseExpression(parseUpdate, expr, names, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range u.paths() {
+		if slices.Contains(t.key.names(), p[0].name) {
+			return nil, nil, validationError("One or more parameter values were invalid: Cannot update attribute " + p[0].name + ". This attribute is part of the key")
+		}
+	}
+	if err := u.apply(item); err != nil {
+		return nil, nil, validationError("Invalid UpdateExpression: " + err.Error())
+	}
+	if _, err := t.itemKey(item, true); err != nil {
+		return nil, nil, err
+	}
+	return item, u.paths(), nil
+}
+
+// names returns the key attribute names of the schema.
+func (s fakeSchema) names() []string {
+	if s.sort == "" {
+		return []string{s.hash}
+	}
+	return []string{s.hash, s.sort}
+}
+
+// schemaOf returns the key schema described by elements.
+func schemaOf(elements []types.KeySchemaElement) fakeSchema {
+	var schema fakeSchema
+	for _, element := range elements {
+		if element.KeyType == types.KeyTypeHash {
+			schema.hash = aws.ToString(element.AttributeName)
+		} else {
+			schema.sort = aws.ToString(element.AttributeName)
+		}
+	}
+	return schema
+}
+
+// projectionOf returns the index projection, which projects all attributes by default.
+func projectionOf(projection *types.Projection) types.Projection {
+	if projection == nil || projection.ProjectionType == "" {
+		return types.Projection{ProjectionType: types.ProjectionTypeAll}
+	}
+	return *projection
+}
+
+// checkCondition evaluates the condition expression, if any, against the existing item,
+// returning a ConditionalCheckFailedException if it is not met.
+func checkCondition(expr *string, names map[string]string, values map[string]types.AttributeValue, item dynamap.Item) error {
+	if aws.ToString(expr) == "" {
+		return nil
+	}
+	condition, err := parseExpression(parseCondition, *expr, names, values)
+	if err != nil {
+		return err
+	}
+	if !condition.match(item) {
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	return nil
+}
+
+// filterItems returns the items matching the filter expression, if any.
+func filterItems(items []dynamap.Item, expr *string, names map[string]string, values map[string]types.AttributeValue) ([]dynamap.Item, error) {
+	if aws.ToString(expr) == "" {
+		return items, nil
+	}
+	filter, err := parseExpression(parseCondition, *expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(slices.Clone(items), func(item dynamap.Item) bool { return !filter.match(item) }), nil
+}
+
+// projectItem returns a copy of item holding the attributes of the projection expression,
+// or all attributes if there is none.
+func projectItem(item dynamap.Item, expr *string, names map[string]string) (dynamap.Item, error) {
+	if aws.ToString(expr) == "" {
+		return cloneItem(item), nil
+	}
+	paths, err := parseProjection(*expr, names)
+	if err != nil {
+		return nil, validationError("Invalid ProjectionExpression: " + err.Error())
+	}
+	return project(item, paths), nil
+}
+
+// parseExpression parses an expression, reporting syntax errors and undefined placeholders
+// as a ValidationException.
+func parseExpression[T any](parse func(string, map[string]string, map[string]types.AttributeValue) (T, error), expr string, names map[string]string, values map[string]types.AttributeValue) (T, error) {
+	result, err := parse(expr, names, values)
+	if err != nil {
+		return result, validationError("Invalid expression: " + err.Error())
+	}
+	return result, nil
+}
+
+// compareKeys orders two key values of the same type; missing values sort first.
+func compareKeys(a, b types.AttributeValue) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	cmp, _ := compareValues(a, b)
+	return cmp
+}
+
+// keyValueString encodes a key value so that equal values have equal encodings.
+func keyValueString(value types.AttributeValue) string {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		if n, ok := new(big.Rat).SetString(v.Value); ok {
+			return "N:" + n.RatString()
+		}
+		return "N:" + v.Value
+	case *types.AttributeValueMemberB:
+		return "B:" + base64.StdEncoding.EncodeToString(v.Value)
+	}
+	return ""
+}
+
+// validationError returns a ValidationException with message.
+func validationError(message string) error {
+	return &smithy.GenericAPIError{Code: "ValidationException", Message: strings.TrimSpace(message), Fault: smithy.FaultClient}
+}
@@ -0,0 +1,379 @@
+package dynamock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/nisimpson/dynamap"
+)
+
+func newFakeProducts(t *testing.T) (*dynamap.Table, *FakeClient) {
+	t.Helper()
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+	client := NewFakeClient(table)
+
+	for _, product := range []struct{ id, category string }{
+		{"P1", "books"}, {"P2", "electronics"}, {"P3", "electronics"}, {"P4", "garden"},
+	} {
+		entity := NewEntity(WithID(product.id), WithPrefix("product"), WithLabel("product"), WithRefSortKey(product.category)).Build()
+		if err := table.Put(ctx, client, entity); err != nil {
+			t.Fatalf("Failed to put product %s: %v", product.id, err)
+		}
+	}
+	return table, client
+}
+
+func TestFakeClient_GetItem(t *testing.T) {
+	ctx := context.Background()
+	table, client := newFakeProducts(t)
+
+	input, err := table.MarshalGet(NewEntity(WithID("P2"), WithPrefix("product")).Build())
+	if err != nil {
+		t.Fatalf("Failed to marshal get: %v", err)
+	}
+	output, err := client.GetItem(ctx, input)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if got := output.Item["gsi1_sk"].(*types.AttributeValueMemberS).Value; got != "electronics" {
+		t.Errorf("Expected ref sort key 'electronics', got %q", got)
+	}
+
+	input.Key["hk"] = &types.AttributeValueMemberS{Value: "product#P9"}
+	input.Key["sk"] = &types.AttributeValueMemberS{Value: "product#P9"}
+	output, err = client.GetItem(ctx, input)
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if output.Item != nil {
+		t.Errorf("Expected no item, got %v", output.Item)
+	}
+
+	exists, err := table.MultiExists(ctx, client, NewEntity(WithID("P1"), WithPrefix("product")).Build(), NewEntity(WithID("P9"), WithPrefix("product")).Build())
+	if err != nil {
+		t.Fatalf("MultiExists failed: %v", err)
+	}
+	if !exists[0] || exists[1] {
+		t.Errorf("Expected [true false], got %v", exists)
+	}
+}
+
+func TestFakeClient_QueryRefIndex(t *testing.T) {
+	ctx := context.Background()
+	table, client := newFakeProducts(t)
+
+	t.Run("key condition on the sort key", func(t *testing.T) {
+		input, err := table.MarshalQuery(&dynamap.QueryList{
+			Label:         "product",
+			RefSortFilter: expression.Key("gsi1_sk").BeginsWith("elec"),
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if output.Count != 2 || output.LastEvaluatedKey != nil {
+			t.Errorf("Expected 2 items and no last key, got %d and %v", output.Count, output.LastEvaluatedKey)
+		}
+	})
+
+	t.Run("pages in sort key order", func(t *testing.T) {
+		for _, descending := range []bool{false, true} {
+			var (
+				categories []string
+				start      dynamap.Item
+			)
+			for {
+				input, err := table.MarshalQuery(&dynamap.QueryList{Label: "product", Limit: 3, StartKey: start, SortDescending: descending})
+				if err != nil {
+					t.Fatalf("Failed to marshal query: %v", err)
+				}
+				output, err := client.Query(ctx, input)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				for _, item := range output.Items {
+					categories = append(categories, item["gsi1_sk"].(*types.AttributeValueMemberS).Value)
+				}
+				if output.LastEvaluatedKey == nil {
+					break
+				}
+				if _, ok := output.LastEvaluatedKey["label"]; !ok {
+					t.Errorf("Expected index keys in last key, got %v", output.LastEvaluatedKey)
+				}
+				start = output.LastEvaluatedKey
+			}
+
+			want := []string{"books", "electronics", "electronics", "garden"}
+			if descending {
+				want = []string{"garden", "electronics", "electronics", "books"}
+			}
+			if len(categories) != len(want) {
+				t.Fatalf("Expected %v, got %v", want, categories)
+			}
+			for i := range want {
+				if categories[i] != want[i] {
+					t.Errorf("Expected %v, got %v", want, categories)
+					break
+				}
+			}
+		}
+	})
+
+	t.Run("limit is applied before the filter", func(t *testing.T) {
+		output, err := client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String("test-table"),
+			IndexName:              aws.String(table.RefIndexName),
+			KeyConditionExpression: aws.String("#label = :label"),
+			FilterExpression:       aws.String("#sort <> :books"),
+			ExpressionAttributeNames: map[string]string{
+				"#label": "label",
+				"#sort":  "gsi1_sk",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":label": &types.AttributeValueMemberS{Value: "product"},
+				":books": &types.AttributeValueMemberS{Value: "books"},
+			},
+			Limit: aws.Int32(2),
+		})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if output.Count != 1 || output.ScannedCount != 2 || output.LastEvaluatedKey == nil {
+			t.Errorf("Expected 1 of 2 items and a last key, got %d of %d and %v", output.Count, output.ScannedCount, output.LastEvaluatedKey)
+		}
+	})
+}
+
+func TestFakeClient_ConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeClient(dynamap.NewTable("test-table"))
+	key := dynamap.Item{
+		"hk": &types.AttributeValueMemberS{Value: "counter#C1"},
+		"sk": &types.AttributeValueMemberS{Value: "counter#C1"},
+	}
+
+	put := &dynamodb.PutItemInput{
+		TableName:                aws.String("test-table"),
+		Item:                     key,
+		ConditionExpression:      aws.String("attribute_not_exists(#hk)"),
+		ExpressionAttributeNames: map[string]string{"#hk": "hk"},
+	}
+	if _, err := client.PutItem(ctx, put); err != nil {
+		t.Fatalf("PutItem failed: %v", err)
+	}
+	var ccf *types.ConditionalCheckFailedException
+	if _, err := client.PutItem(ctx, put); !errors.As(err, &ccf) {
+		t.Errorf("Expected ConditionalCheckFailedException, got %v", err)
+	}
+
+	output, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String("test-table"),
+		Key:              key,
+		UpdateExpression: aws.String("SET #count = if_not_exists(#count, :zero) + :one, #tags = list_append(if_not_exists(#tags, :empty), :tag) ADD #seen :seen"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+			"#tags":  "tags",
+			"#seen":  "seen",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":zero":  &types.AttributeValueMemberN{Value: "0"},
+			":one":   &types.AttributeValueMemberN{Value: "1"},
+			":empty": &types.AttributeValueMemberL{},
+			":tag":   &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "new"}}},
+			":seen":  &types.AttributeValueMemberSS{Value: []string{"a"}},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		t.Fatalf("UpdateItem failed: %v", err)
+	}
+	if got := output.Attributes["count"].(*types.AttributeValueMemberN).Value; got != "1" {
+		t.Errorf("Expected count 1, got %s", got)
+	}
+	if got := len(output.Attributes["tags"].(*types.AttributeValueMemberL).Value); got != 1 {
+		t.Errorf("Expected 1 tag, got %d", got)
+	}
+
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String("test-table"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET #count = #count + :one"),
+		ConditionExpression:       aws.String("#count < :one"),
+		ExpressionAttributeNames:  map[string]string{"#count": "count"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": &types.AttributeValueMemberN{Value: "1"}},
+	})
+	if !errors.As(err, &ccf) {
+		t.Errorf("Expected ConditionalCheckFailedException, got %v", err)
+	}
+
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String("test-table"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET hk = :hk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":hk": &types.AttributeValueMemberS{Value: "x"}},
+	})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationException" {
+		t.Errorf("Expected ValidationException updating a key, got %v", err)
+	}
+}
+
+func TestFakeClient_TransactWriteItems(t *testing.T) {
+	ctx := context.Background()
+	table, client := newFakeProducts(t)
+	key := func(id string) dynamap.Item {
+		return dynamap.Item{
+			"hk": &types.AttributeValueMemberS{Value: "product#" + id},
+			"sk": &types.AttributeValueMemberS{Value: "product#" + id},
+		}
+	}
+
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Delete: &types.Delete{TableName: aws.String(table.TableName), Key: key("P1")}},
+			{ConditionCheck: &types.ConditionCheck{
+				TableName:                aws.String(table.TableName),
+				Key:                      key("P9"),
+				ConditionExpression:      aws.String("attribute_exists(#hk)"),
+				ExpressionAttributeNames: map[string]string{"#hk": "hk"},
+			}},
+		},
+	})
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		t.Fatalf("Expected TransactionCanceledException, got %v", err)
+	}
+	if code := aws.ToString(canceled.CancellationReasons[1].Code); code != "ConditionalCheckFailed" {
+		t.Errorf("Expected ConditionalCheckFailed reason, got %s", code)
+	}
+	if len(client.Items(table.TableName)) != 4 {
+		t.Error("Expected canceled transaction to write nothing")
+	}
+}
+
+func TestFakeClient_Scan(t *testing.T) {
+	ctx := context.Background()
+	table, client := newFakeProducts(t)
+
+	seen := make(map[string]int)
+	for segment := range 3 {
+		input := &dynamodb.ScanInput{
+			TableName:     aws.String(table.TableName),
+			Segment:       aws.Int32(int32(segment)),
+			TotalSegments: aws.Int32(3),
+			Limit:         aws.Int32(1),
+		}
+		for {
+			output, err := client.Scan(ctx, input)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			for _, item := range output.Items {
+				seen[item["hk"].(*types.AttributeValueMemberS).Value]++
+			}
+			if output.LastEvaluatedKey == nil {
+				break
+			}
+			input.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected 4 items across segments, got %v", seen)
+	}
+	for hk, count := range seen {
+		if count != 1 {
+			t.Errorf("Expected %s scanned once, got %d", hk, count)
+		}
+	}
+}
+
+func TestFakeClient_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeClient(dynamap.NewTable("test-table"))
+
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("missing")}); !errors.As(err, &notFound) {
+		t.Errorf("Expected ResourceNotFoundException, got %v", err)
+	}
+
+	var apiErr smithy.APIError
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("test-table"),
+		Item:      dynamap.Item{"hk": &types.AttributeValueMemberS{Value: "a"}},
+	})
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationException" {
+		t.Errorf("Expected ValidationException for a missing key, got %v", err)
+	}
+
+	_, err = client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String("test-table"),
+		KeyConditionExpression: aws.String("hk = :missing"),
+	})
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationException" {
+		t.Errorf("Expected ValidationException for an undefined value, got %v", err)
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	item := dynamap.Item{
+		"name":  &types.AttributeValueMemberS{Value: "widget"},
+		"price": &types.AttributeValueMemberN{Value: "12.50"},
+		"tags":  &types.AttributeValueMemberSS{Value: []string{"blue", "small"}},
+		"data": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"sizes": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberN{Value: "3"}}},
+		}},
+	}
+	values := map[string]types.AttributeValue{
+		":name":  &types.AttributeValueMemberS{Value: "widget"},
+		":wid":   &types.AttributeValueMemberS{Value: "wid"},
+		":low":   &types.AttributeValueMemberN{Value: "10"},
+		":high":  &types.AttributeValueMemberN{Value: "12.5"},
+		":blue":  &types.AttributeValueMemberS{Value: "blue"},
+		":three": &types.AttributeValueMemberN{Value: "3"},
+		":two":   &types.AttributeValueMemberN{Value: "2"},
+		":ss":    &types.AttributeValueMemberS{Value: "SS"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"#n = :name", true},
+		{"#n <> :name", false},
+		{"price BETWEEN :low AND :high", true},
+		{"price > :high", false},
+		{"begins_with(#n, :wid) AND contains(tags, :blue)", true},
+		{"NOT attribute_exists(missing) and attribute_type(tags, :ss)", true},
+		{"data.sizes[0] = :three", true},
+		{"size(tags) = :two OR #n = :wid", true},
+		{"#n IN (:wid, :blue)", false},
+		{"(price < :low OR price >= :high) AND attribute_not_exists(data)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := parseCondition(tt.expr, map[string]string{"#n": "name"}, values)
+			if err != nil {
+				t.Fatalf("Failed to parse condition: %v", err)
+			}
+			if got := c.match(item); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	for _, expr := range []string{"#n =", "#missing = :name", "price BETWEEN :low", "#n = :name extra"} {
+		if _, err := parseCondition(expr, map[string]string{"#n": "name"}, values); err == nil {
+			t.Errorf("Expected error parsing %q", expr)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package dynamock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FaultKind is the kind of failure injected by a [Fault].
+type FaultKind string
+
+const (
+	FaultThrottle        FaultKind = "throttle"         // ProvisionedThroughputExceededException
+	FaultConditionFailed FaultKind = "condition_failed" // ConditionalCheckFailedException, or TransactionCanceledException for transactions
+	FaultValidation      FaultKind = "validation"       // ValidationException
+	FaultUnprocessed     FaultKind = "unprocessed"      // Unprocessed items and keys of batch operations
+	FaultCanceled        FaultKind = "canceled"         // context.Canceled, as if the caller canceled the request
+	FaultTimeout         FaultKind = "timeout"          // context.DeadlineExceeded, as if the request timed out
+)
+
+// faultOperations are the operations each kind of fault applies to by default.
+var faultOperations = map[FaultKind][]string{
+	FaultThrottle:        {"PutItem", "GetItem", "UpdateItem", "DeleteItem", "BatchWriteItem", "BatchGetItem", "TransactWriteItems", "Query", "Scan"},
+	FaultConditionFailed: {"PutItem", "UpdateItem", "DeleteItem", "TransactWriteItems"},
+	FaultValidation:      {"PutItem", "GetItem", "UpdateItem", "DeleteItem", "BatchWriteItem", "BatchGetItem", "TransactWriteItems", "Query", "Scan"},
+	FaultUnprocessed:     {"BatchWriteItem", "BatchGetItem"},
+	FaultCanceled:        {"PutItem", "GetItem", "UpdateItem", "DeleteItem", "BatchWriteItem", "BatchGetItem", "TransactWriteItems", "Query", "Scan"},
+	FaultTimeout:         {"PutItem", "GetItem", "UpdateItem", "DeleteItem", "BatchWriteItem", "BatchGetItem", "TransactWriteItems", "Query", "Scan"},
+}
+
+// Fault injects failures into a fraction of the requests of a [FaultClient].
+//
+// Failures are spread evenly and deterministically over the requests the fault applies
+// to: a rate of 0.25 fails every fourth request, and a rate of 1 fails every request.
+// Random faults instead fail each request with a probability of rate, drawn from the
+// seeded random source of the [FaultClient], so runs with the same seed fail the same
+// requests.
+type Fault struct {
+	Kind       FaultKind // The kind of failure
+	Rate       float64   // The fraction of requests failed, from 0 to 1
+	Operations []string  // The operations failed, such as "PutItem". Empty applies to all operations the kind applies to.
+	Message    string    // The error message. Empty uses a message describing the injected fault.
+	Random     bool      // Fail requests at random with a probability of Rate, instead of evenly
+
+	mu       sync.Mutex
+	requests int // Number of requests the fault applied to
+	injected int // Number of requests failed
+}
+
+// Throttle returns a [Fault] failing rate of the requests to operations, or to all
+// operations, with a ProvisionedThroughputExceededException.
+func Throttle(rate float64, operations ...string) *Fault {
+	return &Fault{Kind: FaultThrottle, Rate: rate, Operations: operations}
+}
+
+// ConditionFailed returns a [Fault] failing rate of the writes to operations, or to all
+// conditional writes, with a ConditionalCheckFailedException. Transactions fail with a
+// TransactionCanceledException whose first action failed its condition.
+func ConditionFailed(rate float64, operations ...string) *Fault {
+	return &Fault{Kind: FaultConditionFailed, Rate: rate, Operations: operations}
+}
+
+// ValidationFailed returns a [Fault] failing rate of the requests to operations, or to all
+// operations, with a ValidationException.
+func ValidationFailed(rate float64, operations ...string) *Fault {
+	return &Fault{Kind: FaultValidation, Rate: rate, Operations: operations}
+}
+
+// Unprocessed returns a [Fault] partially processing rate of the batch writes and gets:
+// the first half of their requests is processed, and the rest is returned in
+// UnprocessedItems or UnprocessedKeys.
+func Unprocessed(rate float64) *Fault {
+	return &Fault{Kind: FaultUnprocessed, Rate: rate}
+}
+
+// Canceled returns a [Fault] failing rate of the requests to operations, or to all
+// operations, with context.Canceled.
+func Canceled(rate float64, operations ...string) *Fault {
+	return &Fault{Kind: FaultCanceled, Rate: rate, Operations: operations}
+}
+
+// Timeout returns a [Fault] failing rate of the requests to operations, or to all
+// operations, with context.DeadlineExceeded.
+func Timeout(rate float64, operations ...string) *Fault {
+	return &Fault{Kind: FaultTimeout, Rate: rate, Operations: operations}
+}
+
+// Injected returns the number of requests the fault failed.
+func (f *Fault) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+// next counts a request to operation and returns true if the fault fails it. Random
+// faults draw from random.
+func (f *Fault) next(operation string, random func() float64) bool {
+	operations := f.Operations
+	if len(operations) == 0 {
+		operations = faultOperations[f.Kind]
+	}
+	if !slices.Contains(operations, operation) {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+	rate := math.Max(0, math.Min(1, f.Rate))
+	failed := math.Floor(float64(f.requests)*rate) > math.Floor(float64(f.requests-1)*rate)
+	if f.Random {
+		failed = random() < rate
+	}
+	if failed {
+		f.injected++
+		return true
+	}
+	return false
+}
+
+// err returns the error injected into a request to operation.
+func (f *Fault) err(operation string) error {
+	message := f.Message
+	if message == "" {
+		message = fmt.Sprintf("dynamock: injected %s fault on %s", f.Kind, operation)
+	}
+
+	switch f.Kind {
+	case FaultCanceled:
+		return fmt.Errorf("%s: %w", message, context.Canceled)
+	case FaultTimeout:
+		return fmt.Errorf("%s: %w", message, context.DeadlineExceeded)
+	case FaultThrottle:
+		return &types.ProvisionedThroughputExceededException{Message: aws.String(message)}
+	case FaultConditionFailed:
+		if operation == "TransactWriteItems" {
+			return &types.TransactionCanceledException{
+				Message: aws.String(message),
+				CancellationReasons: []types.CancellationReason{
+					{Code: aws.String("ConditionalCheckFailed"), Message: aws.String(message)},
+				},
+			}
+		}
+		return &types.ConditionalCheckFailedException{Message: aws.String(message)}
+	default:
+		return validationError(message)
+	}
+}
+
+// Latency delays the requests of a [FaultClient] to operations by Delay, plus a random
+// duration up to Jitter drawn from the seeded random source of the client.
+type Latency struct {
+	Delay      time.Duration // The delay added to every request
+	Jitter     time.Duration // The maximum random delay added on top of Delay
+	Operations []string      // The operations delayed, such as "Query". Empty delays all operations.
+}
+
+// Delay returns a [Latency] delaying the requests to operations, or to all operations,
+// by delay plus up to jitter.
+func Delay(delay, jitter time.Duration, operations ...string) *Latency {
+	return &Latency{Delay: delay, Jitter: jitter, Operations: operations}
+}
+
+// FaultClient wraps a [DynamoDBAPI], such as a [MockClient] or [FakeClient], injecting
+// the failures of its faults into the requests it forwards, so that retry and backoff
+// code paths can be tested deterministically. Faults are checked in order, and the first
+// one failing a request decides its failure. Failed requests are not forwarded, except
+// for the processed half of batches with unprocessed items.
+//
+// Requests are delayed by the latencies of the client before faults are checked. A
+// request whose context is done before or while it is delayed fails with the context's
+// error, so timeouts and cancellation in callers can be exercised. Jitter and random
+// faults draw from a random source seeded by Seed, making chaotic runs reproducible.
+//
+// Example:
+//
+//	throttle := dynamock.Throttle(0.5, "PutItem")
+//	client := dynamock.NewFaultClient(dynamock.NewFakeClient(table), throttle, dynamock.Unprocessed(1))
+//	client.Latencies = []*dynamock.Latency{dynamock.Delay(10*time.Millisecond, 5*time.Millisecond, "Query")}
+//
+//	err := table.Put(ctx, client, entity) // Fails every second put
+//	...
+//	fmt.Println(throttle.Injected())
+type FaultClient struct {
+	Client    DynamoDBAPI // The client requests are forwarded to
+	Faults    []*Fault    // The faults injected
+	Latencies []*Latency  // The delays added to requests
+	Seed      uint64      // The seed of the random source for jitter and random faults
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// Ensure FaultClient implements DynamoDBAPI and the optional interfaces
+var (
+	_ DynamoDBAPI      = (*FaultClient)(nil)
+	_ BatchGetAPI      = (*FaultClient)(nil)
+	_ TransactWriteAPI = (*FaultClient)(nil)
+)
+
+// NewFaultClient creates a [FaultClient] injecting faults into the requests to client.
+func NewFaultClient(client DynamoDBAPI, faults ...*Fault) *FaultClient {
+	return &FaultClient{Client: client, Faults: faults}
+}
+
+// float64 returns a random number in [0, 1) from the seeded random source.
+func (c *FaultClient) float64() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.random == nil {
+		c.random = rand.New(rand.NewPCG(c.Seed, c.Seed))
+	}
+	return c.random.Float64()
+}
+
+// fault counts a request to operation against every fault, and returns the first fault
+// failing it, if any.
+func (c *FaultClient) fault(operation string) *Fault {
+	var failed *Fault
+	for _, f := range c.Faults {
+		if f.next(operation, c.float64) && failed == nil {
+			failed = f
+		}
+	}
+	return failed
+}
+
+// delay waits for the latencies of operation, returning the context's error if it is
+// done first.
+func (c *FaultClient) delay(ctx context.Context, operation string) error {
+	var delay time.Duration
+	for _, l := range c.Latencies {
+		if len(l.Operations) > 0 && !slices.Contains(l.Operations, operation) {
+			continue
+		}
+		delay += l.Delay
+		if l.Jitter > 0 {
+			delay += time.Duration(c.float64() * float64(l.Jitter))
+		}
+	}
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// before delays a request to operation, and returns the fault failing it, if any.
+func (c *FaultClient) before(ctx context.Context, operation string) (*Fault, error) {
+	if err := c.delay(ctx, operation); err != nil {
+		return nil, err
+	}
+	return c.fault(operation), nil
+}
+
+// inject delays a request to operation, and returns the error of the fault failing it,
+// if any.
+func (c *FaultClient) inject(ctx context.Context, operation string) error {
+	f, err := c.before(ctx, operation)
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		return f.err(operation)
+	}
+	return nil
+}
+
+// PutItem forwards the request unless a fault fails it.
+func (c *FaultClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := c.inject(ctx, "PutItem"); err != nil {
+		return nil, err
+	}
+	return c.Client.PutItem(ctx, params, optFns...)
+}
+
+// GetItem forwards the request unless a fault fails it.
+func (c *FaultClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := c.inject(ctx, "GetItem"); err != nil {
+		return nil, err
+	}
+	return c.Client.GetItem(ctx, params, optFns...)
+}
+
+// UpdateItem forwards the request unless a fault fails it.
+func (c *FaultClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := c.inject(ctx, "UpdateItem"); err != nil {
+		return nil, err
+	}
+	return c.Client.UpdateItem(ctx, params, optFns...)
+}
+
+// DeleteItem forwards the request unless a fault fails it.
+func (c *FaultClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := c.inject(ctx, "DeleteItem"); err != nil {
+		return nil, err
+	}
+	return c.Client.DeleteItem(ctx, params, optFns...)
+}
+
+// TransactWriteItems forwards the request unless a fault fails it. The wrapped client
+// must implement [TransactWriteAPI].
+func (c *FaultClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := c.inject(ctx, "TransactWriteItems"); err != nil {
+		return nil, err
+	}
+	client, ok := c.Client.(TransactWriteAPI)
+	if !ok {
+		return nil, fmt.Errorf("dynamock: %T does not implement TransactWriteItems", c.Client)
+	}
+	return client.TransactWriteItems(ctx, params, optFns...)
+}
+
+// Query forwards the request unless a fault fails it.
+func (c *FaultClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := c.inject(ctx, "Query"); err != nil {
+		return nil, err
+	}
+	return c.Client.Query(ctx, params, optFns...)
+}
+
+// Scan forwards the request unless a fault fails it. The wrapped client must implement
+// Scan, as [FakeClient] does.
+func (c *FaultClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := c.inject(ctx, "Scan"); err != nil {
+		return nil, err
+	}
+	scanner, ok := c.Client.(interface {
+		Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("dynamock: %T does not implement Scan", c.Client)
+	}
+	return scanner.Scan(ctx, params, optFns...)
+}
+
+// BatchWriteItem forwards the request unless a fault fails it. Batches with unprocessed
+// items forward their first half, and return the rest unprocessed.
+func (c *FaultClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f, err := c.before(ctx, "BatchWriteItem")
+	switch {
+	case err != nil:
+		return nil, err
+	case f == nil:
+		return c.Client.BatchWriteItem(ctx, params, optFns...)
+	case f.Kind != FaultUnprocessed:
+		return nil, f.err("BatchWriteItem")
+	}
+
+	type request struct {
+		table string
+		write types.WriteRequest
+	}
+	var requests []request
+	for _, table := range sortedKeys(params.RequestItems) {
+		for _, write := range params.RequestItems[table] {
+			requests = append(requests, request{table: table, write: write})
+		}
+	}
+
+	half := len(requests) / 2
+	processed, unprocessed := make(map[string][]types.WriteRequest), make(map[string][]types.WriteRequest)
+	for i, r := range requests {
+		if i < half {
+			processed[r.table] = append(processed[r.table], r.write)
+		} else {
+			unprocessed[r.table] = append(unprocessed[r.table], r.write)
+		}
+	}
+
+	output := &dynamodb.BatchWriteItemOutput{}
+	if len(processed) > 0 {
+		input := *params
+		input.RequestItems = processed
+		if output, err = c.Client.BatchWriteItem(ctx, &input, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if output.UnprocessedItems == nil {
+		output.UnprocessedItems = make(map[string][]types.WriteRequest)
+	}
+	for table, writes := range unprocessed {
+		output.UnprocessedItems[table] = append(output.UnprocessedItems[table], writes...)
+	}
+	return output, nil
+}
+
+// BatchGetItem forwards the request unless a fault fails it. Batches with unprocessed
+// keys forward their first half, and return the rest unprocessed. The wrapped client must
+// implement [BatchGetAPI].
+func (c *FaultClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	client, ok := c.Client.(BatchGetAPI)
+	if !ok {
+		return nil, fmt.Errorf("dynamock: %T does not implement BatchGetItem", c.Client)
+	}
+
+	f, err := c.before(ctx, "BatchGetItem")
+	switch {
+	case err != nil:
+		return nil, err
+	case f == nil:
+		return client.BatchGetItem(ctx, params, optFns...)
+	case f.Kind != FaultUnprocessed:
+		return nil, f.err("BatchGetItem")
+	}
+
+	total := 0
+	for _, request := range params.RequestItems {
+		total += len(request.Keys)
+	}
+
+	half, i := total/2, 0
+	processed, unprocessed := make(map[string]types.KeysAndAttributes), make(map[string]types.KeysAndAttributes)
+	for _, table := range sortedKeys(params.RequestItems) {
+		request := params.RequestItems[table]
+		for _, key := range request.Keys {
+			target := unprocessed
+			if i < half {
+				target = processed
+			}
+			keys := target[table]
+			if keys.Keys == nil {
+				keys = request
+				keys.Keys = nil
+			}
+			keys.Keys = append(keys.Keys, key)
+			target[table] = keys
+			i++
+		}
+	}
+
+	output := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]map[string]types.AttributeValue)}
+	if len(processed) > 0 {
+		input := *params
+		input.RequestItems = processed
+		if output, err = client.BatchGetItem(ctx, &input, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if output.UnprocessedKeys == nil {
+		output.UnprocessedKeys = make(map[string]types.KeysAndAttributes)
+	}
+	for table, keys := range unprocessed {
+		if existing, ok := output.UnprocessedKeys[table]; ok {
+			keys.Keys = append(existing.Keys, keys.Keys...)
+		}
+		output.UnprocessedKeys[table] = keys
+	}
+	return output, nil
+}
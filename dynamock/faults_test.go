@@ -0,0 +1,189 @@
+package dynamock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/nisimpson/dynamap"
+)
+
+func TestFaultClient(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+	entity := func(id string) *TestEntity {
+		return NewEntity(WithID(id), WithPrefix("product"), WithLabel("product")).Build()
+	}
+
+	t.Run("throttles a fraction of requests", func(t *testing.T) {
+		throttle := Throttle(0.5, "PutItem")
+		client := NewFaultClient(NewFakeClient(table), throttle)
+
+		var failed []int
+		for i := range 4 {
+			if err := table.Put(ctx, client, entity("P1")); errors.Is(err, dynamap.ErrThrottled) {
+				failed = append(failed, i)
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		if len(failed) != 2 || failed[0] != 1 || failed[1] != 3 {
+			t.Errorf("Expected every second put to fail, got failures %v", failed)
+		}
+		if throttle.Injected() != 2 {
+			t.Errorf("Expected 2 injected faults, got %d", throttle.Injected())
+		}
+
+		// Reads are not affected by a fault restricted to puts
+		if _, err := table.Get(ctx, client, entity("P1"), entity("P1")); err != nil {
+			t.Errorf("Expected get to succeed, got %v", err)
+		}
+	})
+
+	t.Run("fails conditions and transactions", func(t *testing.T) {
+		client := NewFaultClient(NewFakeClient(table), ConditionFailed(1))
+
+		if err := table.Put(ctx, client, entity("P1")); !errors.Is(err, dynamap.ErrConditionFailed) {
+			t.Errorf("Expected condition failure, got %v", err)
+		}
+		_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{})
+		var canceled *types.TransactionCanceledException
+		if !errors.As(err, &canceled) || aws.ToString(canceled.CancellationReasons[0].Code) != "ConditionalCheckFailed" {
+			t.Errorf("Expected canceled transaction, got %v", err)
+		}
+	})
+
+	t.Run("fails validation", func(t *testing.T) {
+		client := NewFaultClient(NewFakeClient(table), ValidationFailed(1, "Query"))
+		input, err := table.MarshalQuery(&dynamap.QueryList{Label: "product"})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		var apiErr smithy.APIError
+		if _, err := client.Query(ctx, input); !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationException" {
+			t.Errorf("Expected ValidationException, got %v", err)
+		}
+	})
+
+	t.Run("leaves batch items unprocessed", func(t *testing.T) {
+		fake := NewFakeClient(table)
+		unprocessed := Unprocessed(0.5)
+		client := NewFaultClient(fake, unprocessed)
+
+		var items []dynamap.Item
+		for _, id := range []string{"P1", "P2", "P3", "P4"} {
+			input, err := table.MarshalPut(entity(id))
+			if err != nil {
+				t.Fatalf("Failed to marshal put: %v", err)
+			}
+			items = append(items, input.Item)
+		}
+
+		// Every second batch request is partially processed, so the first one is not
+		output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{}})
+		if err != nil || len(output.UnprocessedItems) != 0 {
+			t.Fatalf("Expected first batch to be processed, got %v and %v", output, err)
+		}
+		if err := table.PutItems(ctx, client, items); err != nil {
+			t.Fatalf("Failed to put items: %v", err)
+		}
+		if got := len(fake.Items(table.TableName)); got != 4 {
+			t.Errorf("Expected retries to write all 4 items, got %d", got)
+		}
+		if unprocessed.Injected() == 0 {
+			t.Error("Expected unprocessed items")
+		}
+
+		var keys []dynamap.Item
+		for _, item := range items {
+			keys = append(keys, dynamap.Item{"hk": item["hk"], "sk": item["sk"]})
+		}
+		get, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{table.TableName: {Keys: keys}},
+		})
+		if err != nil {
+			t.Fatalf("BatchGetItem failed: %v", err)
+		}
+		if len(get.Responses[table.TableName]) != 2 || len(get.UnprocessedKeys[table.TableName].Keys) != 2 {
+			t.Errorf("Expected 2 items and 2 unprocessed keys, got %d and %d", len(get.Responses[table.TableName]), len(get.UnprocessedKeys[table.TableName].Keys))
+		}
+	})
+
+	t.Run("delays requests", func(t *testing.T) {
+		client := NewFaultClient(NewFakeClient(table))
+		client.Latencies = []*Latency{Delay(20*time.Millisecond, 10*time.Millisecond, "PutItem")}
+
+		start := time.Now()
+		if err := table.Put(ctx, client, entity("P1")); err != nil {
+			t.Fatalf("Failed to put: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("Expected put to take at least 20ms, took %v", elapsed)
+		}
+
+		timeout, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		if err := table.Put(timeout, client, entity("P2")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if _, err := table.Get(timeout, client, entity("P1"), entity("P1")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected undelayed requests to honor the done context, got %v", err)
+		}
+	})
+
+	t.Run("simulates cancellation", func(t *testing.T) {
+		client := NewFaultClient(NewFakeClient(table), Canceled(1, "Query"), Timeout(1, "PutItem"))
+		input, err := table.MarshalQuery(&dynamap.QueryList{Label: "product"})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if _, err := client.Query(ctx, input); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected canceled query, got %v", err)
+		}
+		if err := table.Put(ctx, client, entity("P1")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected timed out put, got %v", err)
+		}
+	})
+
+	t.Run("random faults are reproducible", func(t *testing.T) {
+		run := func(seed uint64) []bool {
+			client := NewFaultClient(NewFakeClient(table), &Fault{Kind: FaultThrottle, Rate: 0.5, Random: true})
+			client.Seed = seed
+			var failed []bool
+			for range 20 {
+				failed = append(failed, table.Put(ctx, client, entity("P1")) != nil)
+			}
+			return failed
+		}
+
+		first, second := run(7), run(7)
+		count := 0
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatalf("Expected the same failures for the same seed, got %v and %v", first, second)
+			}
+			if first[i] {
+				count++
+			}
+		}
+		if count == 0 || count == len(first) {
+			t.Errorf("Expected some requests to fail at random, got %d of %d", count, len(first))
+		}
+	})
+	t.Run("optional operations", func(t *testing.T) {
+		// Embedding the interface hides the optional operations of the mock
+		client := NewFaultClient(struct{ DynamoDBAPI }{NewMockClient(t)})
+
+		if _, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{}); err == nil {
+			t.Error("Expected error forwarding BatchGetItem")
+		}
+		if _, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{}); err == nil {
+			t.Error("Expected error forwarding TransactWriteItems")
+		}
+	})
+}
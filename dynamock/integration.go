@@ -86,18 +86,24 @@ func WithIsolatedTable(t *testing.T, client *dynamodb.Client, fn func(tableName
 }
 
 // WithLocalDynamoDB runs a test function with a local DynamoDB instance.
-// It checks if DynamoDB Local is available and skips the test if not.
+// It checks if DynamoDB Local is available and skips the test if not. When [ContainerEnv]
+// is set, it starts DynamoDB Local in a container for the test instead of skipping.
+// When [EndpointEnv] is set, it uses that endpoint instead of the port.
 func WithLocalDynamoDB(t *testing.T, port int, fn func(local *LocalDynamoDB)) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	local := NewLocalDynamoDB(port)
+	endpoint, opts := endpointFromEnv()
+	local := newIntegrationDynamoDB(t, port, endpoint, opts)
 	ctx := context.Background()
 
 	// Check if DynamoDB Local is available
 	if !local.IsAvailable(ctx) {
-		t.Skipf("DynamoDB Local not available on port %d", port)
+		if endpoint != "" || !containersEnabled() {
+			t.Skipf("DynamoDB not available at %s", local.Endpoint)
+		}
+		local = startContainer(t)
 	}
 
 	// Run the test function
@@ -180,15 +186,32 @@ type IntegrationTestConfig struct {
 	SkipIfNotRunning bool
 	TablePrefix      string
 	CleanupTimeout   time.Duration
+
+	// Container starts DynamoDB Local in a container for the test when nothing is
+	// listening on Port. It defaults to true when [ContainerEnv] is set.
+	Container bool
+
+	// Endpoint is the URL of the DynamoDB instance to test against instead of DynamoDB
+	// Local on Port, such as LocalStack or a shared development endpoint. It defaults to
+	// the value of [EndpointEnv].
+	Endpoint string
+
+	// EndpointOptions configure the client connecting to Endpoint. They default to the
+	// region and credentials read from the environment when [EndpointEnv] is set.
+	EndpointOptions []EndpointOption
 }
 
 // DefaultIntegrationTestConfig returns a default configuration for integration tests.
 func DefaultIntegrationTestConfig() *IntegrationTestConfig {
+	endpoint, opts := endpointFromEnv()
 	return &IntegrationTestConfig{
 		Port:             DefaultLocalPort,
 		SkipIfNotRunning: true,
 		TablePrefix:      "integration-test",
 		CleanupTimeout:   30 * time.Second,
+		Container:        containersEnabled(),
+		Endpoint:         endpoint,
+		EndpointOptions:  opts,
 	}
 }
 
@@ -202,15 +225,17 @@ func RunIntegrationTest(t *testing.T, config *IntegrationTestConfig, fn func(loc
 		config = DefaultIntegrationTestConfig()
 	}
 
-	local := NewLocalDynamoDB(config.Port)
+	local := newIntegrationDynamoDB(t, config.Port, config.Endpoint, config.EndpointOptions)
 	ctx := context.Background()
 
 	// Check if DynamoDB Local is available
 	if !local.IsAvailable(ctx) {
-		if config.SkipIfNotRunning {
-			t.Skipf("DynamoDB Local not available on port %d", config.Port)
+		if config.Container && config.Endpoint == "" {
+			local = startContainer(t)
+		} else if config.SkipIfNotRunning {
+			t.Skipf("DynamoDB not available at %s", local.Endpoint)
 		} else {
-			t.Fatalf("DynamoDB Local not available on port %d", config.Port)
+			t.Fatalf("DynamoDB not available at %s", local.Endpoint)
 		}
 	}
 
@@ -237,6 +262,22 @@ func RunIntegrationTest(t *testing.T, config *IntegrationTestConfig, fn func(loc
 	fn(local, tableName)
 }
 
+// newIntegrationDynamoDB returns the instance at endpoint, or DynamoDB Local on port if
+// endpoint is empty.
+func newIntegrationDynamoDB(t testing.TB, port int, endpoint string, opts []EndpointOption) *LocalDynamoDB {
+	t.Helper()
+
+	if endpoint == "" {
+		return NewLocalDynamoDB(port)
+	}
+
+	local, err := NewLocalDynamoDBForEndpoint(endpoint, opts...)
+	if err != nil {
+		t.Fatalf("Failed to connect to DynamoDB: %v", err)
+	}
+	return local
+}
+
 // AssertTableExists verifies that a table exists.
 func AssertTableExists(t *testing.T, client *dynamodb.Client, tableName string) {
 	ctx := context.Background()
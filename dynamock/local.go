@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
 )
 
 // LocalDynamoDB represents a connection to a local DynamoDB instance.
@@ -27,22 +28,7 @@ type LocalDynamoDB struct {
 //	client := dynamock.NewLocalClient(8000)
 //	// Use client with your tests
 func NewLocalClient(port int) *dynamodb.Client {
-	endpoint := fmt.Sprintf("http://localhost:%d", port)
-
-	cfg := aws.Config{
-		Region:      "us-east-1", // DynamoDB Local doesn't care about region
-		Credentials: aws.AnonymousCredentials{},
-		EndpointResolverWithOptions: aws.EndpointResolverWithOptionsFunc(
-			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-				return aws.Endpoint{
-					URL:           endpoint,
-					SigningRegion: region,
-				}, nil
-			},
-		),
-	}
-
-	return dynamodb.NewFromConfig(cfg)
+	return newEndpointClient(fmt.Sprintf("http://localhost:%d", port))
 }
 
 // NewLocalDynamoDB creates a LocalDynamoDB instance with the specified port.
@@ -58,10 +44,11 @@ func NewLocalDynamoDB(port int) *LocalDynamoDB {
 	}
 }
 
-// IsAvailable checks if DynamoDB Local is running on the configured port.
+// IsAvailable checks if DynamoDB Local is running on the configured endpoint, or on the
+// configured port of localhost if the endpoint is not set.
 func (l *LocalDynamoDB) IsAvailable(ctx context.Context) bool {
 	// Try to connect to the port
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", l.Port), 2*time.Second)
+	conn, err := net.DialTimeout("tcp", l.address(), 2*time.Second)
 	if err != nil {
 		return false
 	}
@@ -72,6 +59,14 @@ func (l *LocalDynamoDB) IsAvailable(ctx context.Context) bool {
 	return err == nil
 }
 
+// address returns the host and port of the instance.
+func (l *LocalDynamoDB) address() string {
+	if u, err := parseEndpoint(l.Endpoint); err == nil {
+		return net.JoinHostPort(u.Hostname(), endpointPort(u))
+	}
+	return fmt.Sprintf("localhost:%d", l.Port)
+}
+
 // WaitForAvailable waits for DynamoDB Local to become available.
 // Returns an error if it doesn't become available within the timeout.
 func (l *LocalDynamoDB) WaitForAvailable(ctx context.Context, timeout time.Duration) error {
@@ -163,6 +158,19 @@ func (l *LocalDynamoDB) CreateDynamapTable(ctx context.Context, tableName string
 	return l.WaitForTableActive(ctx, tableName, 30*time.Second)
 }
 
+// CreateTable creates the table described by [dynamap.Table.CreateTableInput], honoring
+// the key names, aliases and secondary indexes of the table, and waits for it to become
+// active. Use it instead of CreateDynamapTable for tables that do not use the standard
+// schema.
+func (l *LocalDynamoDB) CreateTable(ctx context.Context, table *dynamap.Table) error {
+	_, err := l.Client.CreateTable(ctx, table.CreateTableInput())
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", table.TableName, err)
+	}
+
+	return l.WaitForTableActive(ctx, table.TableName, 30*time.Second)
+}
+
 // WaitForTableActive waits for a table to become active.
 func (l *LocalDynamoDB) WaitForTableActive(ctx context.Context, tableName string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
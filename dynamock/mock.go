@@ -2,6 +2,7 @@ package dynamock
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -19,19 +20,44 @@ type DynamoDBAPI interface {
 	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
+// BatchGetAPI is implemented by clients that support batch reads, such as [MockClient]
+// and [FakeClient]. It matches the optional [dynamap.BatchGetClient] interface.
+type BatchGetAPI interface {
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+// TransactWriteAPI is implemented by clients that support transactions, such as
+// [MockClient] and [FakeClient]. It matches the optional [dynamap.TransactWriteClient]
+// interface.
+type TransactWriteAPI interface {
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
 // MockClient is a simple expectation-based mock for DynamoDB operations.
-// Users can set expectations for specific operations without needing integration.
+// Users can set expectations for specific operations without needing integration,
+// either by replacing the func of an operation or with [MockClient.Expect]. Every call is
+// recorded and can be inspected with [MockClient.Calls].
 type MockClient struct {
 	PutFunc            DynamoDBAPICall[dynamodb.PutItemInput, dynamodb.PutItemOutput]
 	GetFunc            DynamoDBAPICall[dynamodb.GetItemInput, dynamodb.GetItemOutput]
 	QueryFunc          DynamoDBAPICall[dynamodb.QueryInput, dynamodb.QueryOutput]
 	BatchWriteItemFunc DynamoDBAPICall[dynamodb.BatchWriteItemInput, dynamodb.BatchWriteItemOutput]
+	BatchGetItemFunc   DynamoDBAPICall[dynamodb.BatchGetItemInput, dynamodb.BatchGetItemOutput]
+	TransactWriteFunc  DynamoDBAPICall[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]
 	DeleteFunc         DynamoDBAPICall[dynamodb.DeleteItemInput, dynamodb.DeleteItemOutput]
 	UpdateFunc         DynamoDBAPICall[dynamodb.UpdateItemInput, dynamodb.UpdateItemOutput]
+
+	mu           sync.Mutex
+	calls        []Call
+	expectations []*Expectation
 }
 
-// Ensure MockClient implements DynamoDBAPI
-var _ DynamoDBAPI = (*MockClient)(nil)
+// Ensure MockClient implements DynamoDBAPI and the optional interfaces
+var (
+	_ DynamoDBAPI      = (*MockClient)(nil)
+	_ BatchGetAPI      = (*MockClient)(nil)
+	_ TransactWriteAPI = (*MockClient)(nil)
+)
 
 // NewMockClient creates a new mock DynamoDB client with default configuration.
 func NewMockClient(t *testing.T) *MockClient {
@@ -40,6 +66,8 @@ func NewMockClient(t *testing.T) *MockClient {
 		GetFunc:            defaultFunc[dynamodb.GetItemInput, dynamodb.GetItemOutput](t),
 		QueryFunc:          defaultFunc[dynamodb.QueryInput, dynamodb.QueryOutput](t),
 		BatchWriteItemFunc: defaultFunc[dynamodb.BatchWriteItemInput, dynamodb.BatchWriteItemOutput](t),
+		BatchGetItemFunc:   defaultFunc[dynamodb.BatchGetItemInput, dynamodb.BatchGetItemOutput](t),
+		TransactWriteFunc:  defaultFunc[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput](t),
 		DeleteFunc:         defaultFunc[dynamodb.DeleteItemInput, dynamodb.DeleteItemOutput](t),
 		UpdateFunc:         defaultFunc[dynamodb.UpdateItemInput, dynamodb.UpdateItemOutput](t),
 	}
@@ -54,30 +82,40 @@ func defaultFunc[T, U any](t *testing.T) DynamoDBAPICall[T, U] {
 
 // PutItem stores an item in the mock table.
 func (m *MockClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
-	return m.PutFunc(ctx, params, optFns...)
+	return invoke(m, "PutItem", m.PutFunc, ctx, params, optFns)
 }
 
 // GetItem retrieves an item from the mock table.
 func (m *MockClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-	return m.GetFunc(ctx, params, optFns...)
+	return invoke(m, "GetItem", m.GetFunc, ctx, params, optFns)
 }
 
 // UpdateItem updates an item in the mock table.
 func (m *MockClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
-	return m.UpdateFunc(ctx, params, optFns...)
+	return invoke(m, "UpdateItem", m.UpdateFunc, ctx, params, optFns)
 }
 
 // DeleteItem removes an item from the mock table.
 func (m *MockClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
-	return m.DeleteFunc(ctx, params, optFns...)
+	return invoke(m, "DeleteItem", m.DeleteFunc, ctx, params, optFns)
 }
 
 // BatchWriteItem processes batch write operations.
 func (m *MockClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
-	return m.BatchWriteItemFunc(ctx, params, optFns...)
+	return invoke(m, "BatchWriteItem", m.BatchWriteItemFunc, ctx, params, optFns)
+}
+
+// BatchGetItem processes batch get operations.
+func (m *MockClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return invoke(m, "BatchGetItem", m.BatchGetItemFunc, ctx, params, optFns)
+}
+
+// TransactWriteItems processes transactional write operations.
+func (m *MockClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return invoke(m, "TransactWriteItems", m.TransactWriteFunc, ctx, params, optFns)
 }
 
 // Query performs a query operation.
 func (m *MockClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
-	return m.QueryFunc(ctx, params, optFns...)
+	return invoke(m, "Query", m.QueryFunc, ctx, params, optFns)
 }
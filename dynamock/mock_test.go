@@ -33,6 +33,14 @@ func TestNewMockClient(t *testing.T) {
 		t.Error("BatchWriteItemFunc not initialized")
 	}
 
+	if mock.BatchGetItemFunc == nil {
+		t.Error("BatchGetItemFunc not initialized")
+	}
+
+	if mock.TransactWriteFunc == nil {
+		t.Error("TransactWriteFunc not initialized")
+	}
+
 	if mock.DeleteFunc == nil {
 		t.Error("DeleteFunc not initialized")
 	}
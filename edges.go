@@ -0,0 +1,448 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// MaxTransactSize is the maximum number of actions allowed in a DynamoDB transaction.
+	MaxTransactSize = 100
+)
+
+// ErrTargetNotFound is returned when an attach is conditioned on the existence of
+// a target entity that does not exist.
+var ErrTargetNotFound = newError(ErrNotFound, "target not found")
+
+// EdgeAttribute returns a NameBuilder that references an attribute of the edge payload
+// written by [RelationshipContext.AddOneWithData], for use in query filters.
+//
+// Example:
+//
+//	// Only return order lines with a quantity of at least 2
+//	filter := dynamap.EdgeAttribute("quantity").GreaterThanEqual(expression.Value(2))
+func EdgeAttribute(name string) expression.NameBuilder {
+	return DataAttribute("Edge." + name)
+}
+
+// UnmarshalEdge unmarshals the edge payload of ref, written by
+// [RelationshipContext.AddOneWithData], into out. If the relationship has no payload,
+// out is left untouched.
+func UnmarshalEdge(ref *Relationship, out any) error {
+	var edge any
+	switch data := ref.Data.(type) {
+	case Ref:
+		edge = data.Edge
+	case *Ref:
+		edge = data.Edge
+	case map[string]any:
+		edge = data["Edge"]
+	}
+
+	if edge == nil {
+		return nil
+	}
+
+	av, err := attributevalue.Marshal(edge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal edge: %w", err)
+	}
+	if err := attributevalue.Unmarshal(av, out); err != nil {
+		return fmt.Errorf("failed to unmarshal edge: %w", err)
+	}
+
+	return nil
+}
+
+// EdgeOptions contains configuration options for attaching and detaching relationships.
+type EdgeOptions struct {
+	Transact       bool                    // If true, edges are written using transactions
+	CountAttribute string                  // Optional source attribute adjusted by the number of edges; implies Transact
+	RequireTargets bool                    // If true, attached targets must have a self relationship; implies Transact
+	Inverse        string                  // Optional name of the mirrored relationship written in each target partition
+	MarshalOptions []func(*MarshalOptions) // Options applied when marshaling the source and targets
+}
+
+// newEdgeOptions creates the options for edges named name. The count attribute defaults
+// to the counter configured for the relationship in [Table.Counters].
+func (t *Table) newEdgeOptions(name string, opts []func(*EdgeOptions)) EdgeOptions {
+	options := EdgeOptions{CountAttribute: t.Counters[name]}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.CountAttribute != "" || options.RequireTargets {
+		options.Transact = true
+	}
+	return options
+}
+
+// marshalEdges marshals the relationships named name between source and each of targets,
+// without marshaling the source entity or any of its other relationships. If an inverse
+// name is configured, the mirrored relationship of each edge is returned at the same
+// position in inverses.
+func (t *Table) marshalEdges(source Marshaler, name string, targets []Marshaler, opts EdgeOptions) (marshalOpts MarshalOptions, edges, inverses []Relationship, err error) {
+	marshalOpts = NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts.MarshalOptions)
+	})
+
+	if err := source.MarshalSelf(&marshalOpts); err != nil {
+		return marshalOpts, nil, nil, fmt.Errorf("failed to marshal self: %w", err)
+	}
+
+	ctx := &RelationshipContext{
+		source: marshalOpts.sourceKey(),
+		opts:   marshalOpts,
+	}
+
+	ctx.AddMany(name, targets)
+	if ctx.err != nil {
+		return marshalOpts, nil, nil, ctx.err
+	}
+
+	if opts.Inverse != "" {
+		for _, edge := range ctx.refs {
+			inverse, err := marshalOpts.inverseRef(edge, opts.Inverse)
+			if err != nil {
+				return marshalOpts, nil, nil, fmt.Errorf("failed to marshal inverse %s: %w", opts.Inverse, err)
+			}
+			inverses = append(inverses, inverse)
+		}
+	}
+
+	if err := marshalOpts.validate(append(ctx.refs, inverses...)...); err != nil {
+		return marshalOpts, nil, nil, err
+	}
+
+	return marshalOpts, ctx.refs, inverses, nil
+}
+
+// MarshalAttach marshals the relationships named name between source and each of targets
+// into batch write put requests. Unlike [Table.MarshalBatch], only the relationship items
+// are written; the source self relationship is left untouched. If [EdgeOptions.Inverse] is
+// set, the mirrored relationships are written as well.
+func (t *Table) MarshalAttach(source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) ([]*dynamodb.BatchWriteItemInput, error) {
+	marshalOpts, edges, inverses, err := t.marshalEdges(source, name, targets, t.newEdgeOptions(name, opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edges: %w", err)
+	}
+
+	var requests []types.WriteRequest
+	for _, edge := range append(edges, inverses...) {
+		item, err := marshalOpts.marshalItem(edge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal relationship: %w", err)
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	return t.chunkBatchWrite(requests), nil
+}
+
+// MarshalDetach marshals the relationships named name between source and each of targets
+// into batch write delete requests. The source self relationship is left untouched. If
+// [EdgeOptions.Inverse] is set, the mirrored relationships are deleted as well.
+func (t *Table) MarshalDetach(source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) ([]*dynamodb.BatchWriteItemInput, error) {
+	_, edges, inverses, err := t.marshalEdges(source, name, targets, t.newEdgeOptions(name, opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edges: %w", err)
+	}
+
+	var requests []types.WriteRequest
+	for _, edge := range append(edges, inverses...) {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: edge.itemKey()},
+		})
+	}
+
+	return t.chunkBatchWrite(requests), nil
+}
+
+// MarshalTransactAttach marshals the relationships named name between source and each of
+// targets into transact write requests. If [EdgeOptions.CountAttribute] is set, each
+// transaction also increments the attribute on the source self relationship by the number
+// of edges it writes, and the edges are conditioned on not already existing so the count
+// stays accurate. If [EdgeOptions.RequireTargets] is set, each edge is accompanied by a
+// condition check on the existence of the target's self relationship. If
+// [EdgeOptions.Inverse] is set, each mirrored relationship is written in the same
+// transaction as its edge.
+func (t *Table) MarshalTransactAttach(source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) ([]*dynamodb.TransactWriteItemsInput, error) {
+	options := t.newEdgeOptions(name, opts)
+	marshalOpts, edges, inverses, err := t.marshalEdges(source, name, targets, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edges: %w", err)
+	}
+
+	var groups [][]types.TransactWriteItem
+	for i, edge := range edges {
+		item, err := marshalOpts.marshalItem(edge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal relationship: %w", err)
+		}
+
+		put := &types.Put{
+			TableName: aws.String(t.TableName),
+			Item:      item,
+		}
+
+		if options.CountAttribute != "" {
+			expr, err := expression.NewBuilder().
+				WithCondition(expression.AttributeNotExists(expression.Name(AttributeNameSource))).
+				Build()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build condition expression: %w", err)
+			}
+			put.ConditionExpression = expr.Condition()
+			put.ExpressionAttributeNames = expr.Names()
+		}
+
+		group := []types.TransactWriteItem{{Put: put}}
+
+		if inverses != nil {
+			item, err := marshalOpts.marshalItem(inverses[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal inverse relationship: %w", err)
+			}
+			group = append(group, types.TransactWriteItem{
+				Put: &types.Put{TableName: aws.String(t.TableName), Item: item},
+			})
+		}
+
+		if options.RequireTargets {
+			check, err := t.targetExistsCheck(edge)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, types.TransactWriteItem{ConditionCheck: check})
+		}
+
+		groups = append(groups, group)
+	}
+
+	return t.chunkTransactEdges(groups, marshalOpts, options, 1)
+}
+
+// MarshalTransactDetach marshals the relationships named name between source and each of
+// targets into transact write delete requests. If [EdgeOptions.CountAttribute] is set, each
+// transaction also decrements the attribute on the source self relationship by the number
+// of edges it deletes, and the deletes are conditioned on the edges existing. If
+// [EdgeOptions.Inverse] is set, each mirrored relationship is deleted with its edge.
+func (t *Table) MarshalTransactDetach(source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) ([]*dynamodb.TransactWriteItemsInput, error) {
+	options := t.newEdgeOptions(name, opts)
+	marshalOpts, edges, inverses, err := t.marshalEdges(source, name, targets, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edges: %w", err)
+	}
+
+	var groups [][]types.TransactWriteItem
+	for i, edge := range edges {
+		del := &types.Delete{
+			TableName: aws.String(t.TableName),
+			Key:       edge.itemKey(),
+		}
+
+		if options.CountAttribute != "" {
+			expr, err := expression.NewBuilder().
+				WithCondition(expression.AttributeExists(expression.Name(AttributeNameSource))).
+				Build()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build condition expression: %w", err)
+			}
+			del.ConditionExpression = expr.Condition()
+			del.ExpressionAttributeNames = expr.Names()
+		}
+
+		group := []types.TransactWriteItem{{Delete: del}}
+		if inverses != nil {
+			group = append(group, types.TransactWriteItem{
+				Delete: &types.Delete{TableName: aws.String(t.TableName), Key: inverses[i].itemKey()},
+			})
+		}
+
+		groups = append(groups, group)
+	}
+
+	return t.chunkTransactEdges(groups, marshalOpts, options, -1)
+}
+
+// targetExistsCheck creates a condition check on the existence of the self relationship
+// of the edge target.
+func (t *Table) targetExistsCheck(edge Relationship) (*types.ConditionCheck, error) {
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeExists(expression.Name(AttributeNameSource))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build condition expression: %w", err)
+	}
+
+	return &types.ConditionCheck{
+		TableName: aws.String(t.TableName),
+		Key: Item{
+			AttributeNameSource: &types.AttributeValueMemberS{Value: edge.Target},
+			AttributeNameTarget: &types.AttributeValueMemberS{Value: edge.Target},
+		},
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	}, nil
+}
+
+// chunkTransactEdges splits the groups of edge actions into transactions, keeping the
+// actions of each edge in the same transaction. If a count attribute is configured, each
+// transaction reserves one action to adjust the source counter by sign times the number
+// of edges in the transaction.
+func (t *Table) chunkTransactEdges(groups [][]types.TransactWriteItem, source MarshalOptions, opts EdgeOptions, sign int) ([]*dynamodb.TransactWriteItemsInput, error) {
+	size := MaxTransactSize
+	if opts.CountAttribute != "" {
+		size--
+	}
+
+	var (
+		transactions []*dynamodb.TransactWriteItemsInput
+		chunk        []types.TransactWriteItem
+		edges        int
+	)
+
+	flush := func() error {
+		if edges == 0 {
+			return nil
+		}
+
+		if opts.CountAttribute != "" {
+			update := expression.Add(
+				expression.Name(opts.CountAttribute),
+				expression.Value(sign*edges),
+			)
+			expr, err := expression.NewBuilder().WithUpdate(update).Build()
+			if err != nil {
+				return fmt.Errorf("failed to build update expression: %w", err)
+			}
+
+			counter := &types.Update{
+				TableName:                 aws.String(t.TableName),
+				Key:                       source.itemKey(),
+				UpdateExpression:          expr.Update(),
+				ExpressionAttributeValues: expr.Values(),
+			}
+			counter.ExpressionAttributeNames = source.aliasNames(expr.Names(), &counter.UpdateExpression)
+			chunk = append(chunk, types.TransactWriteItem{Update: counter})
+		}
+
+		transactions = append(transactions, &dynamodb.TransactWriteItemsInput{
+			TransactItems: chunk,
+		})
+		chunk, edges = nil, 0
+		return nil
+	}
+
+	for _, group := range groups {
+		if len(chunk)+len(group) > size {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		chunk = append(chunk, group...)
+		edges++
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// Attach writes the relationships named name between source and each of targets, without
+// re-marshaling the source entity or its other relationships. By default the edges are
+// written with batch writes; use [EdgeOptions.Transact] or [EdgeOptions.CountAttribute]
+// to write them transactionally.
+func (t *Table) Attach(ctx context.Context, client DynamoDBClient, source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) error {
+	client = t.client(client)
+	opts = contextEdgeOptions(ctx, opts)
+	if t.newEdgeOptions(name, opts).Transact {
+		transactions, err := t.MarshalTransactAttach(source, name, targets, opts...)
+		if err != nil {
+			return err
+		}
+		return transactWriteAll(ctx, client, transactions)
+	}
+
+	batches, err := t.MarshalAttach(source, name, targets, opts...)
+	if err != nil {
+		return err
+	}
+	return batchWriteAll(ctx, client, batches)
+}
+
+// Detach deletes the relationships named name between source and each of targets. The
+// source and target self relationships are left untouched. By default the edges are
+// deleted with batch writes; use [EdgeOptions.Transact] or [EdgeOptions.CountAttribute]
+// to delete them transactionally.
+func (t *Table) Detach(ctx context.Context, client DynamoDBClient, source Marshaler, name string, targets []Marshaler, opts ...func(*EdgeOptions)) error {
+	client = t.client(client)
+	opts = contextEdgeOptions(ctx, opts)
+	if t.newEdgeOptions(name, opts).Transact {
+		transactions, err := t.MarshalTransactDetach(source, name, targets, opts...)
+		if err != nil {
+			return err
+		}
+		return transactWriteAll(ctx, client, transactions)
+	}
+
+	batches, err := t.MarshalDetach(source, name, targets, opts...)
+	if err != nil {
+		return err
+	}
+	return batchWriteAll(ctx, client, batches)
+}
+
+// transactWriteAll executes each of the transactions in order. If a transaction is
+// canceled due to a failed target condition check, [ErrTargetNotFound] is returned.
+func transactWriteAll(ctx context.Context, client DynamoDBClient, transactions []*dynamodb.TransactWriteItemsInput) error {
+	for _, transaction := range transactions {
+		if _, err := transactWriteItems(ctx, client, transaction); err != nil {
+			if key, ok := failedConditionCheck(transaction, err); ok {
+				return fmt.Errorf("%w: %s", ErrTargetNotFound, key)
+			}
+			return fmt.Errorf("failed to transact write items: %w", classify(err))
+		}
+	}
+	return nil
+}
+
+// failedConditionCheck returns the source key of the first condition check in transaction
+// that caused err, if any.
+func failedConditionCheck(transaction *dynamodb.TransactWriteItemsInput, err error) (string, bool) {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return "", false
+	}
+
+	for i, reason := range canceled.CancellationReasons {
+		if i >= len(transaction.TransactItems) || aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+			continue
+		}
+		if check := transaction.TransactItems[i].ConditionCheck; check != nil {
+			key, _, _ := UnmarshalTableKey(check.Key)
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// itemKey returns the table key of the relationship.
+func (r Relationship) itemKey() Item {
+	return Item{
+		AttributeNameSource: &types.AttributeValueMemberS{Value: r.Source},
+		AttributeNameTarget: &types.AttributeValueMemberS{Value: r.Target},
+	}
+}
@@ -0,0 +1,472 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for attach and detach operations
+
+func TestTableMarshalAttach(t *testing.T) {
+	table := NewTable("test-table")
+	order := &Order{ID: "O1"}
+	products := SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"})
+
+	t.Run("writes only edge items", func(t *testing.T) {
+		batches, err := table.MarshalAttach(order, "products", products)
+		if err != nil {
+			t.Fatalf("Failed to marshal attach: %v", err)
+		}
+
+		requests := batches[0].RequestItems["test-table"]
+		if len(requests) != 2 {
+			t.Fatalf("Expected 2 requests, got %d", len(requests))
+		}
+
+		item := requests[0].PutRequest.Item
+		if hk := item["hk"].(*types.AttributeValueMemberS).Value; hk != "order#O1" {
+			t.Errorf("Expected hk 'order#O1', got %s", hk)
+		}
+		if sk := item["sk"].(*types.AttributeValueMemberS).Value; sk != "product#P1" {
+			t.Errorf("Expected sk 'product#P1', got %s", sk)
+		}
+		if label := item["label"].(*types.AttributeValueMemberS).Value; label != "order/O1/products" {
+			t.Errorf("Expected label 'order/O1/products', got %s", label)
+		}
+	})
+
+	t.Run("detach deletes edge keys", func(t *testing.T) {
+		batches, err := table.MarshalDetach(order, "products", products)
+		if err != nil {
+			t.Fatalf("Failed to marshal detach: %v", err)
+		}
+
+		requests := batches[0].RequestItems["test-table"]
+		if len(requests) != 2 {
+			t.Fatalf("Expected 2 requests, got %d", len(requests))
+		}
+		if requests[1].DeleteRequest == nil {
+			t.Fatal("Expected delete request")
+		}
+		if sk := requests[1].DeleteRequest.Key["sk"].(*types.AttributeValueMemberS).Value; sk != "product#P2" {
+			t.Errorf("Expected sk 'product#P2', got %s", sk)
+		}
+	})
+
+	t.Run("marshal errors", func(t *testing.T) {
+		if _, err := table.MarshalAttach(&errorEntity{}, "products", products); err == nil {
+			t.Error("Expected error from source marshal")
+		}
+		if _, err := table.MarshalDetach(order, "products", []Marshaler{&errorEntity{}}); err == nil {
+			t.Error("Expected error from target marshal")
+		}
+	})
+}
+
+func TestTableMarshalTransactAttach(t *testing.T) {
+	table := NewTable("test-table")
+	order := &Order{ID: "O1"}
+
+	t.Run("without counter", func(t *testing.T) {
+		transactions, err := table.MarshalTransactAttach(order, "products", SliceOf(&Product{ID: "P1"}))
+		if err != nil {
+			t.Fatalf("Failed to marshal transact attach: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		if len(actions) != 1 {
+			t.Fatalf("Expected 1 action, got %d", len(actions))
+		}
+		if actions[0].Put.ConditionExpression != nil {
+			t.Error("Expected no condition expression without counter")
+		}
+	})
+
+	t.Run("with counter", func(t *testing.T) {
+		var products []*Product
+		for i := 0; i < 150; i++ {
+			products = append(products, &Product{ID: fmt.Sprintf("P%d", i)})
+		}
+
+		transactions, err := table.MarshalTransactAttach(order, "products", SliceOf(products...), func(opts *EdgeOptions) {
+			opts.CountAttribute = "product_count"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal transact attach: %v", err)
+		}
+
+		if len(transactions) != 2 {
+			t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+		}
+
+		actions := transactions[0].TransactItems
+		if len(actions) != MaxTransactSize {
+			t.Fatalf("Expected %d actions, got %d", MaxTransactSize, len(actions))
+		}
+		if actions[0].Put.ConditionExpression == nil {
+			t.Error("Expected condition expression with counter")
+		}
+
+		update := actions[len(actions)-1].Update
+		if update == nil {
+			t.Fatal("Expected counter update as last action")
+		}
+		if hk := update.Key["hk"].(*types.AttributeValueMemberS).Value; hk != "order#O1" {
+			t.Errorf("Expected counter on 'order#O1', got %s", hk)
+		}
+		if aws.ToString(update.UpdateExpression) == "" {
+			t.Error("Expected update expression")
+		}
+
+		last := transactions[1].TransactItems
+		count := last[len(last)-1].Update.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberN).Value
+		if count != "51" {
+			t.Errorf("Expected count increment 51, got %s", count)
+		}
+	})
+
+	t.Run("detach with counter decrements", func(t *testing.T) {
+		transactions, err := table.MarshalTransactDetach(order, "products", SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"}), func(opts *EdgeOptions) {
+			opts.CountAttribute = "product_count"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal transact detach: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		if actions[0].Delete == nil || actions[0].Delete.ConditionExpression == nil {
+			t.Fatal("Expected conditional delete")
+		}
+
+		count := actions[2].Update.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberN).Value
+		if count != "-2" {
+			t.Errorf("Expected count decrement -2, got %s", count)
+		}
+	})
+}
+
+func TestTableAttachDetach(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	order := &Order{ID: "O1"}
+	products := SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"})
+
+	for _, transact := range []bool{false, true} {
+		t.Run(fmt.Sprintf("transact=%v", transact), func(t *testing.T) {
+			client := newMockDynamoDBClient()
+			withTransact := func(opts *EdgeOptions) { opts.Transact = transact }
+
+			if err := table.Attach(ctx, client, order, "products", products, withTransact); err != nil {
+				t.Fatalf("Failed to attach: %v", err)
+			}
+
+			if len(client.items) != 2 {
+				t.Fatalf("Expected 2 items, got %d", len(client.items))
+			}
+			if _, ok := client.items["order#O1#product#P2"]; !ok {
+				t.Error("Expected edge item for P2")
+			}
+
+			if err := table.Detach(ctx, client, order, "products", products[:1], withTransact); err != nil {
+				t.Fatalf("Failed to detach: %v", err)
+			}
+
+			if len(client.items) != 1 {
+				t.Fatalf("Expected 1 item, got %d", len(client.items))
+			}
+			if _, ok := client.items["order#O1#product#P1"]; ok {
+				t.Error("Expected edge item for P1 to be deleted")
+			}
+		})
+	}
+}
+
+func TestTableAttachInverse(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	order := &Order{ID: "O1"}
+	products := SliceOf(&Product{ID: "P1"}, &Product{ID: "P2"})
+
+	for _, transact := range []bool{false, true} {
+		t.Run(fmt.Sprintf("transact=%v", transact), func(t *testing.T) {
+			client := newMockDynamoDBClient()
+			withInverse := func(opts *EdgeOptions) {
+				opts.Transact = transact
+				opts.Inverse = "orders"
+			}
+
+			if err := table.Attach(ctx, client, order, "products", products, withInverse); err != nil {
+				t.Fatalf("Failed to attach: %v", err)
+			}
+
+			if len(client.items) != 4 {
+				t.Fatalf("Expected 4 items, got %d", len(client.items))
+			}
+			inverse, ok := client.items["product#P2#order#O1"]
+			if !ok {
+				t.Fatal("Expected inverse item for P2")
+			}
+			if label := inverse["label"].(*types.AttributeValueMemberS).Value; label != "product/P2/orders" {
+				t.Errorf("Expected label 'product/P2/orders', got %s", label)
+			}
+
+			if err := table.Detach(ctx, client, order, "products", products[:1], withInverse); err != nil {
+				t.Fatalf("Failed to detach: %v", err)
+			}
+
+			if len(client.items) != 2 {
+				t.Fatalf("Expected 2 items, got %d", len(client.items))
+			}
+			if _, ok := client.items["product#P1#order#O1"]; ok {
+				t.Error("Expected inverse item for P1 to be deleted")
+			}
+		})
+	}
+
+	t.Run("count edges, not inverses", func(t *testing.T) {
+		transactions, err := table.MarshalTransactAttach(order, "products", products, func(opts *EdgeOptions) {
+			opts.Inverse = "orders"
+			opts.CountAttribute = "product_count"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal transact attach: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		if len(actions) != 5 {
+			t.Fatalf("Expected 5 actions, got %d", len(actions))
+		}
+		if value := actions[4].Update.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberN).Value; value != "2" {
+			t.Errorf("Expected count increment of 2, got %s", value)
+		}
+	})
+}
+
+// conditionCheckClient cancels transactions whose condition checks reference
+// items missing from the mock table.
+type conditionCheckClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *conditionCheckClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var (
+		reasons  []types.CancellationReason
+		canceled bool
+	)
+
+	for _, action := range params.TransactItems {
+		reason := types.CancellationReason{Code: aws.String("None")}
+		if check := action.ConditionCheck; check != nil {
+			hk := check.Key["hk"].(*types.AttributeValueMemberS).Value
+			sk := check.Key["sk"].(*types.AttributeValueMemberS).Value
+			if _, ok := c.items[hk+"#"+sk]; !ok {
+				reason.Code = aws.String("ConditionalCheckFailed")
+				canceled = true
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	return c.mockDynamoDBClient.TransactWriteItems(ctx, params, optFns...)
+}
+
+func TestTableAttachRequireTargets(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	order := &Order{ID: "O1"}
+	requireTargets := func(opts *EdgeOptions) { opts.RequireTargets = true }
+
+	t.Run("marshals condition checks", func(t *testing.T) {
+		transactions, err := table.MarshalTransactAttach(order, "products", SliceOf(&Product{ID: "P1"}), requireTargets)
+		if err != nil {
+			t.Fatalf("Failed to marshal transact attach: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		if len(actions) != 2 {
+			t.Fatalf("Expected 2 actions, got %d", len(actions))
+		}
+
+		check := actions[1].ConditionCheck
+		if check == nil {
+			t.Fatal("Expected condition check")
+		}
+		if sk := check.Key["sk"].(*types.AttributeValueMemberS).Value; sk != "product#P1" {
+			t.Errorf("Expected check on 'product#P1', got %s", sk)
+		}
+	})
+
+	t.Run("keeps edge actions together", func(t *testing.T) {
+		var products []*Product
+		for i := 0; i < 60; i++ {
+			products = append(products, &Product{ID: fmt.Sprintf("P%d", i)})
+		}
+
+		transactions, err := table.MarshalTransactAttach(order, "products", SliceOf(products...), requireTargets, func(opts *EdgeOptions) {
+			opts.CountAttribute = "product_count"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal transact attach: %v", err)
+		}
+
+		if len(transactions) != 2 {
+			t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+		}
+		if n := len(transactions[0].TransactItems); n != 99 {
+			t.Errorf("Expected 99 actions, got %d", n)
+		}
+	})
+
+	t.Run("existing targets", func(t *testing.T) {
+		client := &conditionCheckClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		putInput, _ := table.MarshalPut(&Product{ID: "P1"})
+		_, _ = client.PutItem(ctx, putInput)
+
+		err := table.Attach(ctx, client, order, "products", SliceOf(&Product{ID: "P1"}), requireTargets)
+		if err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		if _, ok := client.items["order#O1#product#P1"]; !ok {
+			t.Error("Expected edge item for P1")
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		client := &conditionCheckClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+		err := table.Attach(ctx, client, order, "products", SliceOf(&Product{ID: "P2"}), requireTargets)
+		if !errors.Is(err, ErrTargetNotFound) {
+			t.Fatalf("Expected ErrTargetNotFound, got %v", err)
+		}
+		if len(client.items) != 0 {
+			t.Errorf("Expected no items to be written, got %d", len(client.items))
+		}
+	})
+}
+
+type orderLine struct {
+	Quantity int `dynamodbav:"quantity"`
+}
+
+type lineOrder struct {
+	ID         string         `dynamodbav:"id"`
+	Quantities map[string]int `dynamodbav:"-"`
+}
+
+func (o *lineOrder) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	return nil
+}
+
+func (o *lineOrder) MarshalRefs(ctx *RelationshipContext) error {
+	for id, quantity := range o.Quantities {
+		ctx.AddOneWithData("products", &Product{ID: id}, orderLine{Quantity: quantity})
+	}
+	return nil
+}
+
+func (o *lineOrder) UnmarshalRef(name string, id string, ref *Relationship) error {
+	var line orderLine
+	if err := UnmarshalEdge(ref, &line); err != nil {
+		return err
+	}
+	if o.Quantities == nil {
+		o.Quantities = make(map[string]int)
+	}
+	o.Quantities[ref.Target] = line.Quantity
+	return nil
+}
+
+func TestEdgeData(t *testing.T) {
+	table := NewTable("test-table")
+
+	t.Run("round trip", func(t *testing.T) {
+		batches, err := table.MarshalBatch(&lineOrder{ID: "O1", Quantities: map[string]int{"P1": 2}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		var items []Item
+		for _, request := range batches[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		out := &lineOrder{}
+		if _, err := UnmarshalEntity(items, out, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if out.Quantities["product#P1"] != 2 {
+			t.Errorf("Expected quantity 2, got %v", out.Quantities)
+		}
+	})
+
+	t.Run("no payload", func(t *testing.T) {
+		line := orderLine{Quantity: 1}
+		if err := UnmarshalEdge(&Relationship{Data: Ref{Name: "products"}}, &line); err != nil {
+			t.Fatalf("Failed to unmarshal edge: %v", err)
+		}
+		if line.Quantity != 1 {
+			t.Errorf("Expected line to be untouched, got %+v", line)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryEntity{
+			Source:          &lineOrder{ID: "O1"},
+			ConditionFilter: EdgeAttribute("quantity").GreaterThanEqual(expression.Value(2)),
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.FilterExpression), ".") {
+			t.Errorf("Expected nested filter path, got %s", aws.ToString(input.FilterExpression))
+		}
+
+		var names []string
+		for _, name := range input.ExpressionAttributeNames {
+			names = append(names, name)
+		}
+		if !slices.Contains(names, "Edge") || !slices.Contains(names, "quantity") {
+			t.Errorf("Expected filter on data.Edge.quantity, got %v", names)
+		}
+	})
+}
+
+// basicClient implements only the operations of DynamoDBClient.
+type basicClient struct {
+	DynamoDBClient
+}
+
+func TestUnsupportedOperations(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	client := basicClient{newMockDynamoDBClient()}
+	order := &Order{ID: "O1"}
+	products := SliceOf(&Product{ID: "P1"})
+
+	if err := table.Attach(ctx, client, order, "products", products); err != nil {
+		t.Fatalf("Failed to attach with batch writes: %v", err)
+	}
+
+	err := table.Attach(ctx, client, order, "products", products, func(opts *EdgeOptions) { opts.Transact = true })
+	if !errors.Is(err, ErrUnsupportedOperation) || !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrUnsupportedOperation for transactions, got %v", err)
+	}
+
+	if _, err := table.BatchGet(ctx, client, order); !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("Expected ErrUnsupportedOperation for batch gets, got %v", err)
+	}
+}
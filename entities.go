@@ -0,0 +1,103 @@
+package dynamap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// EntityGroup is an entity assembled by [UnmarshalEntities] from the items of one source key.
+type EntityGroup[T RefUnmarshaler] struct {
+	Source        string         // The source key shared by the items
+	Entity        T              // The entity the items were unmarshaled into
+	Relationships []Relationship // The relationships returned by UnmarshalEntity
+}
+
+// UnmarshalEntities groups items by source key and unmarshals each group into an entity
+// created with newEntity using [UnmarshalEntity], so the self relationship and refs of
+// each entity are applied to that entity only. Groups are returned in the order their
+// source key first appears in items. Use it instead of UnmarshalEntity to read partitions
+// or pages that contain several entities, such as those of [Table.QueryEntityItems] with
+// an [QueryEntity.EntityLimit] greater than one. In lenient mode, the items skipped in
+// every group are reported in a single [*UnmarshalError].
+//
+// Example:
+//
+//	groups, err := dynamap.UnmarshalEntities(items, func() *Order { return &Order{} }, table.MarshalOptions)
+//	for _, group := range groups {
+//		fmt.Println(group.Source, len(group.Entity.Products))
+//	}
+func UnmarshalEntities[T RefUnmarshaler](items []Item, newEntity func() T, opts ...func(*MarshalOptions)) ([]EntityGroup[T], error) {
+	if len(items) == 0 {
+		return nil, ErrItemNotFound
+	}
+
+	marshalOpts := NewMarshalOptions(opts...)
+	if marshalOpts.KeyNames.IsSet() {
+		items = renameItems(items, marshalOpts.KeyNames.inverse())
+	}
+
+	var (
+		sources []string
+		groups  = make(map[string][]Item)
+	)
+
+	for _, item := range items {
+		source, _, err := UnmarshalTableKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+		if _, ok := groups[source]; !ok {
+			sources = append(sources, source)
+		}
+		groups[source] = append(groups[source], item)
+	}
+
+	var (
+		result  = make([]EntityGroup[T], 0, len(sources))
+		skipped UnmarshalError
+	)
+
+	for _, source := range sources {
+		var (
+			entity       = newEntity()
+			groupSkipped *UnmarshalError
+		)
+		relationships, err := UnmarshalEntity(groups[source], entity, opts...)
+		if errors.As(err, &groupSkipped) {
+			skipped.Items = append(skipped.Items, groupSkipped.Items...)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entity %s: %w", source, err)
+		}
+		result = append(result, EntityGroup[T]{
+			Source:        source,
+			Entity:        entity,
+			Relationships: relationships,
+		})
+	}
+
+	return result, skipped.err()
+}
+
+// UnmarshalEntityAs unmarshals items into a new value of T using [UnmarshalEntity]. If T is
+// a pointer type, such as *Order, it points to a new zero value, so callers need not
+// construct the entity beforehand.
+//
+// Example:
+//
+//	order, relationships, err := dynamap.UnmarshalEntityAs[*Order](items, table.MarshalOptions)
+func UnmarshalEntityAs[T RefUnmarshaler](items []Item, opts ...func(*MarshalOptions)) (T, []Relationship, error) {
+	out := newValue[T]()
+	relationships, err := UnmarshalEntity(items, out, opts...)
+	return out, relationships, err
+}
+
+// newValue returns the zero value of T, or a pointer to a new zero value if T is a
+// pointer type.
+func newValue[T any]() T {
+	var value T
+	if typ := reflect.TypeFor[T](); typ.Kind() == reflect.Pointer {
+		value = reflect.New(typ.Elem()).Interface().(T)
+	}
+	return value
+}
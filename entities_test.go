@@ -0,0 +1,89 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// Tests for multi-entity unmarshaling
+
+func TestUnmarshalEntities(t *testing.T) {
+	var items []Item
+	for _, order := range []*Order{
+		{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}},
+		{ID: "O2", Products: []Product{{ID: "P3"}}},
+	} {
+		relationships, err := MarshalRelationships(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal relationships: %v", err)
+		}
+		for _, rel := range relationships {
+			item, err := attributevalue.MarshalMap(rel)
+			if err != nil {
+				t.Fatalf("Failed to marshal item: %v", err)
+			}
+			items = append(items, item)
+		}
+	}
+
+	t.Run("groups items by source key", func(t *testing.T) {
+		groups, err := UnmarshalEntities(items, func() *Order { return &Order{} })
+		if err != nil {
+			t.Fatalf("Failed to unmarshal entities: %v", err)
+		}
+		if len(groups) != 2 {
+			t.Fatalf("Expected 2 entities, got %d", len(groups))
+		}
+		if groups[0].Source != "order#O1" || groups[1].Source != "order#O2" {
+			t.Errorf("Expected sources in item order, got %s and %s", groups[0].Source, groups[1].Source)
+		}
+		if len(groups[0].Entity.Products) != 2 {
+			t.Errorf("Expected 2 products for O1, got %d", len(groups[0].Entity.Products))
+		}
+		if len(groups[1].Entity.Products) != 1 {
+			t.Errorf("Expected 1 product for O2, got %d", len(groups[1].Entity.Products))
+		}
+		if len(groups[1].Relationships) != 2 {
+			t.Errorf("Expected 2 relationships for O2, got %d", len(groups[1].Relationships))
+		}
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		if _, err := UnmarshalEntities(nil, func() *Order { return &Order{} }); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
+}
+
+func TestUnmarshalEntityAs(t *testing.T) {
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+	relationships, err := MarshalRelationships(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal relationships: %v", err)
+	}
+
+	var items []Item
+	for _, rel := range relationships {
+		item, err := attributevalue.MarshalMap(rel)
+		if err != nil {
+			t.Fatalf("Failed to marshal item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	got, relationships, err := UnmarshalEntityAs[*Order](items)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal entity: %v", err)
+	}
+	if got == nil || got.ID != "O1" {
+		t.Fatalf("Expected order O1, got %+v", got)
+	}
+	if len(got.Products) != 2 {
+		t.Errorf("Expected 2 products, got %d", len(got.Products))
+	}
+	if len(relationships) != 3 {
+		t.Errorf("Expected 3 relationships, got %d", len(relationships))
+	}
+}
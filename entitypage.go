@@ -0,0 +1,78 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+)
+
+// QueryEntityItems executes the query until q.EntityLimit complete entities are read, where
+// an entity is the self relationship and refs sharing a source key. Pages are requested
+// with q.Limit items each, so an entity split across pages is never returned partially.
+// If q.EntityLimit is not set, a single page is read like [DynamoDBClient.Query].
+//
+// The returned start key is the key of the last item of the last entity, and is nil once
+// the query is exhausted. Pass it as q.StartKey to fetch the next entities, or convert it
+// to a client cursor with [MarshalStartKey].
+//
+// Example:
+//
+//	query := &dynamap.QueryEntity{Source: order, Limit: 100, EntityLimit: 1}
+//	items, startKey, err := table.QueryEntityItems(ctx, client, query)
+//	_, err = dynamap.UnmarshalEntity(items, order, table.MarshalOptions)
+func (t *Table) QueryEntityItems(ctx context.Context, client DynamoDBClient, q *QueryEntity, opts ...func(*MarshalOptions)) ([]Item, Item, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+
+	var (
+		page     = *q
+		items    []Item
+		entities int
+		source   string
+	)
+
+	for {
+		input, err := t.MarshalQuery(&page, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query entity: %w", classify(err))
+		}
+
+		if q.EntityLimit <= 0 {
+			return output.Items, output.LastEvaluatedKey, nil
+		}
+
+		for _, item := range output.Items {
+			key, _, err := UnmarshalTableKey(item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+			}
+
+			// The previous entity is complete once an item of the next one is read
+			if key != source {
+				if entities == q.EntityLimit {
+					return items, tableKey(items[len(items)-1]), nil
+				}
+				source = key
+				entities++
+			}
+			items = append(items, item)
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, nil, nil
+		}
+		page.StartKey = output.LastEvaluatedKey
+	}
+}
+
+// tableKey returns the table key of item.
+func tableKey(item Item) Item {
+	return Item{
+		AttributeNameSource: item[AttributeNameSource],
+		AttributeNameTarget: item[AttributeNameTarget],
+	}
+}
@@ -0,0 +1,152 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pagedTableClient answers queries with every stored item in table key order, honoring the
+// limit and exclusive start key. The key condition is ignored, so items of several
+// partitions can be read by a single query.
+type pagedTableClient struct {
+	*mockDynamoDBClient
+	calls int
+}
+
+func (c *pagedTableClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.calls++
+	key := func(item Item) string {
+		return item["hk"].(*types.AttributeValueMemberS).Value + "#" + item["sk"].(*types.AttributeValueMemberS).Value
+	}
+
+	items := slices.Collect(maps.Values(c.items))
+	slices.SortFunc(items, func(a, b Item) int { return strings.Compare(key(a), key(b)) })
+
+	if start := params.ExclusiveStartKey; start != nil {
+		i := slices.IndexFunc(items, func(item Item) bool { return key(item) == key(start) })
+		items = items[i+1:]
+	}
+
+	output := &dynamodb.QueryOutput{Items: items}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		output.Items = items[:limit]
+		output.LastEvaluatedKey = tableKey(items[limit-1])
+	}
+	return output, nil
+}
+
+// Tests for entity limited queries
+
+func TestQueryEntityItems(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+
+	newClient := func(t *testing.T, orders ...*Order) *pagedTableClient {
+		client := &pagedTableClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		for _, order := range orders {
+			batches, err := table.MarshalBatch(order)
+			if err != nil {
+				t.Fatalf("Failed to marshal batch: %v", err)
+			}
+			for _, batch := range batches {
+				if _, err := client.BatchWriteItem(ctx, batch); err != nil {
+					t.Fatalf("Failed to write batch: %v", err)
+				}
+			}
+		}
+		return client
+	}
+
+	t.Run("reads an entity across pages", func(t *testing.T) {
+		client := newClient(t, &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}, {ID: "P3"}, {ID: "P4"}}})
+		items, startKey, err := table.QueryEntityItems(ctx, client, &QueryEntity{
+			Source:      &Order{ID: "O1"},
+			Limit:       2,
+			EntityLimit: 1,
+		})
+		if err != nil {
+			t.Fatalf("Failed to query entity items: %v", err)
+		}
+		if len(items) != 5 {
+			t.Errorf("Expected 5 items, got %d", len(items))
+		}
+		if startKey != nil {
+			t.Errorf("Expected no start key, got %v", startKey)
+		}
+		if client.calls != 3 {
+			t.Errorf("Expected 3 queries, got %d", client.calls)
+		}
+	})
+
+	t.Run("stops at the entity limit", func(t *testing.T) {
+		client := newClient(t,
+			&Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}},
+			&Order{ID: "O2", Products: []Product{{ID: "P3"}}},
+		)
+		query := &QueryEntity{Source: &Order{ID: "O1"}, Limit: 2, EntityLimit: 1}
+
+		items, startKey, err := table.QueryEntityItems(ctx, client, query)
+		if err != nil {
+			t.Fatalf("Failed to query entity items: %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("Expected 3 items, got %d", len(items))
+		}
+		for _, item := range items {
+			if source, _, _ := UnmarshalTableKey(item); source != "order#O1" {
+				t.Errorf("Expected source 'order#O1', got %s", source)
+			}
+		}
+		if got := startKey["sk"].(*types.AttributeValueMemberS).Value; got != "product#P2" {
+			t.Errorf("Expected start key at 'product#P2', got %s", got)
+		}
+
+		query.StartKey = startKey
+		items, startKey, err = table.QueryEntityItems(ctx, client, query)
+		if err != nil {
+			t.Fatalf("Failed to query entity items: %v", err)
+		}
+		if len(items) != 2 {
+			t.Errorf("Expected 2 items, got %d", len(items))
+		}
+		if startKey != nil {
+			t.Errorf("Expected no start key, got %v", startKey)
+		}
+	})
+
+	t.Run("reads a single page without entity limit", func(t *testing.T) {
+		client := newClient(t, &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}})
+		items, startKey, err := table.QueryEntityItems(ctx, client, &QueryEntity{Source: &Order{ID: "O1"}, Limit: 2})
+		if err != nil {
+			t.Fatalf("Failed to query entity items: %v", err)
+		}
+		if len(items) != 2 {
+			t.Errorf("Expected 2 items, got %d", len(items))
+		}
+		if startKey == nil {
+			t.Error("Expected start key")
+		}
+	})
+
+	t.Run("load entity is not truncated", func(t *testing.T) {
+		client := newClient(t, &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}, {ID: "P3"}}})
+		var out summarizedOrder
+		out.ID = "O1"
+		if _, err := table.LoadEntity(ctx, client, &QueryEntity{Source: &out.Order, Limit: 1, EntityLimit: 1}, &out); err != nil {
+			t.Fatalf("Failed to load entity: %v", err)
+		}
+		if len(out.Products) != 3 {
+			t.Errorf("Expected 3 products, got %d", len(out.Products))
+		}
+		if out.summary == nil || out.summary.Truncated {
+			t.Error("Expected summary not to be truncated")
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package dynamap
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
@@ -346,3 +350,105 @@ func TestQueryErrors(t *testing.T) {
 		}
 	})
 }
+
+// erroringClient fails every get with err.
+type erroringClient struct {
+	*mockDynamoDBClient
+	err error
+}
+
+func (c *erroringClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return nil, c.err
+}
+
+func TestErrorCategories(t *testing.T) {
+	t.Run("sentinels match their category", func(t *testing.T) {
+		tests := []struct {
+			err      error
+			category error
+		}{
+			{ErrItemNotFound, ErrNotFound},
+			{ErrTargetNotFound, ErrNotFound},
+			{ErrUniqueConstraintViolation, ErrConditionFailed},
+			{&UniqueConstraintError{Field: "email"}, ErrConditionFailed},
+			{ErrInvalidKey, ErrValidation},
+			{ErrInvalidLabel, ErrValidation},
+			{ErrUnknownPrefix, ErrValidation},
+			{ErrTransactionTooLarge, ErrValidation},
+			{ErrNoCounter, ErrValidation},
+			{ErrSchemaMismatch, ErrValidation},
+			{ErrEmptyStreamRecord, ErrValidation},
+			{ErrMaxDepthExceeded, ErrValidation},
+			{ErrBlobNotLoaded, ErrValidation},
+			{fmt.Errorf("failed to get item: %w", ErrItemNotFound), ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			if !errors.Is(tt.err, tt.category) {
+				t.Errorf("Expected %v to match %v", tt.err, tt.category)
+			}
+		}
+		if errors.Is(ErrItemNotFound, ErrValidation) {
+			t.Error("Expected ErrItemNotFound not to match ErrValidation")
+		}
+		if ErrItemNotFound.Error() != "item not found" {
+			t.Errorf("Expected the message to be kept, got %q", ErrItemNotFound)
+		}
+	})
+
+	t.Run("classifies dynamodb errors", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			err      error
+			category error
+		}{
+			{"throughput", &types.ProvisionedThroughputExceededException{}, ErrThrottled},
+			{"request limit", &types.RequestLimitExceeded{}, ErrThrottled},
+			{"condition", &types.ConditionalCheckFailedException{}, ErrConditionFailed},
+			{"canceled transaction", &types.TransactionCanceledException{
+				CancellationReasons: []types.CancellationReason{{Code: aws.String("None")}, {Code: aws.String("ConditionalCheckFailed")}},
+			}, ErrConditionFailed},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := classify(tt.err)
+				if !errors.Is(err, tt.category) {
+					t.Errorf("Expected %v, got %v", tt.category, err)
+				}
+				if err.Error() != tt.err.Error() {
+					t.Errorf("Expected the message to be kept, got %q", err)
+				}
+				if !errors.Is(err, tt.err) {
+					t.Error("Expected the original error to be wrapped")
+				}
+			})
+		}
+
+		other := errors.New("boom")
+		if classify(other) != other {
+			t.Error("Expected errors without a category to be returned unchanged")
+		}
+	})
+
+	t.Run("table operations", func(t *testing.T) {
+		ctx := context.Background()
+		table := NewTable("test-table")
+
+		client := &erroringClient{newMockDynamoDBClient(), &types.ProvisionedThroughputExceededException{}}
+		_, err := table.Get(ctx, client, &Product{ID: "P1"}, &Product{})
+		if !errors.Is(err, ErrThrottled) {
+			t.Errorf("Expected ErrThrottled, got %v", err)
+		}
+
+		_, err = table.Get(ctx, newMockDynamoDBClient(), &Product{ID: "P1"}, &Product{})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		_, err = table.Paginator(newMockDynamoDBClient()).StartKey(ctx, "unknown")
+		if !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("Expected ErrCursorExpired, got %v", err)
+		}
+	})
+}
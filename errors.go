@@ -0,0 +1,147 @@
+package dynamap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The categories of errors returned by dynamap. Errors returned by DynamoDB and the more
+// specific errors of the package, such as [ErrItemNotFound] or [ErrInvalidKey], match
+// their category with [errors.Is]:
+//
+//	_, err := table.Get(ctx, client, &Order{ID: "O1"}, &order)
+//	switch {
+//	case errors.Is(err, dynamap.ErrNotFound):
+//		// respond 404
+//	case errors.Is(err, dynamap.ErrThrottled):
+//		// back off and retry
+//	}
+//
+// Queries of lists return no items rather than [ErrNotFound].
+var (
+	// ErrNotFound is returned when an item, entity or relationship does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConditionFailed is returned when a conditional write is rejected because its
+	// condition was not met.
+	ErrConditionFailed = errors.New("condition failed")
+	// ErrCursorExpired is returned when a page cursor is unknown or has expired.
+	ErrCursorExpired = errors.New("cursor expired")
+	// ErrThrottled is returned when DynamoDB throttles a request that exceeded the
+	// provisioned throughput or the account limits.
+	ErrThrottled = errors.New("request throttled")
+	// ErrValidation is returned when a request, key, label or entity is invalid.
+	ErrValidation = errors.New("validation failed")
+)
+
+// ConditionFailedError is returned when a conditional write on an entity is rejected.
+// It matches [ErrConditionFailed] with [errors.Is].
+type ConditionFailedError struct {
+	Source string // The hash key of the rejected item
+	Target string // The sort key of the rejected item
+	Err    error  // The underlying error
+}
+
+// Error implements the error interface.
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("%s: %s %s: %v", ErrConditionFailed, e.Source, e.Target, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConditionFailedError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is [ErrConditionFailed].
+func (e *ConditionFailedError) Is(target error) bool {
+	return target == ErrConditionFailed
+}
+
+// conditionFailed translates a conditional check failure on the item identified by key
+// into a [*ConditionFailedError]. Other errors are returned unchanged.
+func conditionFailed(key Item, err error) error {
+	var ccf *types.ConditionalCheckFailedException
+	if !errors.As(err, &ccf) {
+		return err
+	}
+
+	source, target, _ := UnmarshalTableKey(key)
+	return &ConditionFailedError{
+		Source: source,
+		Target: target,
+		Err:    err,
+	}
+}
+
+// categorizedError is an error that matches its category with [errors.Is].
+type categorizedError struct {
+	err      error
+	category error
+}
+
+// newError returns a new error with the message that matches category with [errors.Is].
+func newError(category error, message string) error {
+	return &categorizedError{err: errors.New(message), category: category}
+}
+
+// Error implements the error interface.
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *categorizedError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the category of the error.
+func (e *categorizedError) Is(target error) bool {
+	return target == e.category
+}
+
+// classify adds the category of an error returned by DynamoDB, such as [ErrThrottled], to
+// its chain. Errors without a category are returned unchanged.
+func classify(err error) error {
+	if category := categoryOf(err); category != nil && !errors.Is(err, category) {
+		return &categorizedError{err: err, category: category}
+	}
+	return err
+}
+
+// categoryOf returns the category of an error returned by DynamoDB, or nil.
+func categoryOf(err error) error {
+	var (
+		ccf      *types.ConditionalCheckFailedException
+		canceled *types.TransactionCanceledException
+		coded    interface{ ErrorCode() string }
+	)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &ccf):
+		return ErrConditionFailed
+	case errors.As(err, &canceled):
+		for _, reason := range canceled.CancellationReasons {
+			switch aws.ToString(reason.Code) {
+			case "ConditionalCheckFailed":
+				return ErrConditionFailed
+			case "ThrottlingError", "ProvisionedThroughputExceeded":
+				return ErrThrottled
+			case "ValidationError":
+				return ErrValidation
+			}
+		}
+	case errors.As(err, &coded):
+		switch coded.ErrorCode() {
+		case "ProvisionedThroughputExceededException", "RequestLimitExceeded", "ThrottlingException":
+			return ErrThrottled
+		case "ValidationException":
+			return ErrValidation
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,564 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// EventPrefix is the key prefix of aggregate events, whose sort keys are "evt#<sequence>".
+	EventPrefix = "evt"
+	// SnapshotPrefix is the key prefix of aggregate snapshots, whose sort keys are
+	// "snap#<sequence>".
+	SnapshotPrefix = "snap"
+	// AttributeNameEventType is the attribute holding the type of an event.
+	AttributeNameEventType = "event_type"
+	// MaxEventSequence is the greatest sequence number of an aggregate event.
+	MaxEventSequence = 999999
+)
+
+// eventSequenceFormat zero-pads sequence numbers so event sort keys sort in sequence order.
+const eventSequenceFormat = "%06d"
+
+// ErrInvalidSequence is returned when an event or snapshot sequence is out of range.
+var ErrInvalidSequence = newError(ErrValidation, "invalid event sequence")
+
+// Event is an entry of an aggregate's append-only event log, written by
+// [Table.AppendEvent] to the partition of the aggregate.
+type Event struct {
+	Sequence  int       // Position of the event in the log, starting at 1
+	Type      string    // The event type
+	CreatedAt time.Time // When the event was appended
+
+	item Item
+	opts func(*MarshalOptions)
+}
+
+// Unmarshal unmarshals the event data into out.
+func (e Event) Unmarshal(out any) error {
+	_, err := UnmarshalSelf(e.item, out, e.opts)
+	return err
+}
+
+// AggregateSnapshot is the state of an aggregate after the event at Sequence was applied,
+// written by [Table.SaveSnapshot].
+type AggregateSnapshot struct {
+	Sequence  int       // Sequence of the last event applied to the state
+	CreatedAt time.Time // When the snapshot was saved
+
+	item Item
+	opts func(*MarshalOptions)
+}
+
+// Unmarshal unmarshals the snapshot state into out.
+func (s AggregateSnapshot) Unmarshal(out any) error {
+	_, err := UnmarshalSelf(s.item, out, s.opts)
+	return err
+}
+
+// EventOptions configures [Table.AppendEvent].
+type EventOptions struct {
+	// Sequence is the position of the appended event. Writers that replayed the log up to
+	// sequence n pass n+1, so the append fails with a [*ConditionFailedError] if another
+	// event was appended since. Zero appends after the latest event.
+	Sequence int
+
+	Type           string                  // The event type. Default is the name of the event's Go type.
+	MarshalOptions []func(*MarshalOptions) // Options applied when marshaling the aggregate
+}
+
+// LoadEventsOptions configures [Table.LoadEvents].
+type LoadEventsOptions struct {
+	After int // Only events with a greater sequence are returned, such as the sequence of a snapshot
+	Limit int // Maximum number of events returned. Zero returns all events.
+}
+
+// AppendEvent appends the event to the log of the aggregate. Events share the partition
+// of the aggregate and have no label, so they are not indexed; the aggregate itself does
+// not need to be stored. Each sequence is written at most once, so concurrent writers
+// cannot both append at the same position.
+//
+// Example:
+//
+//	events, err := table.LoadEvents(ctx, client, &Account{ID: "A1"})
+//	// ... apply events and validate the command
+//	event, err := table.AppendEvent(ctx, client, &Account{ID: "A1"}, Deposited{Amount: 10}, func(eo *dynamap.EventOptions) {
+//		eo.Sequence = len(events) + 1
+//	})
+//	if errors.Is(err, dynamap.ErrConditionFailed) {
+//		// another event was appended; reload and retry
+//	}
+func (t *Table) AppendEvent(ctx context.Context, client DynamoDBClient, aggregate Marshaler, event any, opts ...func(*EventOptions)) (Event, error) {
+	client = t.client(client)
+	var options EventOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	marshalOpts, source, err := t.aggregateKey(ctx, aggregate, options.MarshalOptions)
+	if err != nil {
+		return Event{}, err
+	}
+
+	sequence := options.Sequence
+	if sequence == 0 {
+		items, err := t.queryLog(ctx, client, marshalOpts, source, EventPrefix, 0, 1, false)
+		if err != nil {
+			return Event{}, err
+		}
+		sequence = 1
+		if len(items) > 0 {
+			latest, err := marshalOpts.logSequence(items[0])
+			if err != nil {
+				return Event{}, err
+			}
+			sequence = latest + 1
+		}
+	}
+
+	eventType := options.Type
+	if eventType == "" {
+		eventType = typeName(event)
+	}
+
+	item, err := marshalOpts.logItem(source, EventPrefix, sequence, event)
+	if err != nil {
+		return Event{}, err
+	}
+	item[AttributeNameEventType] = &types.AttributeValueMemberS{Value: eventType}
+
+	if err := t.putLogItem(ctx, client, item, true); err != nil {
+		return Event{}, err
+	}
+
+	return logEvent(marshalOpts, item, t.MarshalOptions)
+}
+
+// LoadEvents returns the events of the aggregate in sequence order, using consistent reads.
+//
+// Example:
+//
+//	events, err := table.LoadEvents(ctx, client, &Account{ID: "A1"})
+//	for _, event := range events {
+//		switch event.Type {
+//		case "Deposited":
+//			var deposited Deposited
+//			err := event.Unmarshal(&deposited)
+//		}
+//	}
+func (t *Table) LoadEvents(ctx context.Context, client DynamoDBClient, aggregate Marshaler, opts ...func(*LoadEventsOptions)) ([]Event, error) {
+	client = t.client(client)
+	var options LoadEventsOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	marshalOpts, source, err := t.aggregateKey(ctx, aggregate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := t.queryLog(ctx, client, marshalOpts, source, EventPrefix, options.After, options.Limit, true)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(items))
+	for _, item := range items {
+		event, err := logEvent(marshalOpts, item, t.MarshalOptions)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// SaveSnapshot stores the state of the aggregate after the event at sequence was applied,
+// so the aggregate can be loaded without replaying its whole log. Saving a snapshot at the
+// same sequence again overwrites it.
+//
+// Example:
+//
+//	err := table.SaveSnapshot(ctx, client, &Account{ID: "A1"}, events[len(events)-1].Sequence, account)
+func (t *Table) SaveSnapshot(ctx context.Context, client DynamoDBClient, aggregate Marshaler, sequence int, state any) error {
+	client = t.client(client)
+	marshalOpts, source, err := t.aggregateKey(ctx, aggregate, nil)
+	if err != nil {
+		return err
+	}
+
+	item, err := marshalOpts.logItem(source, SnapshotPrefix, sequence, state)
+	if err != nil {
+		return err
+	}
+	return t.putLogItem(ctx, client, item, false)
+}
+
+// LatestSnapshot returns the snapshot of the aggregate with the greatest sequence.
+// [ErrItemNotFound] is returned if the aggregate has no snapshot.
+//
+// Example:
+//
+//	snapshot, err := table.LatestSnapshot(ctx, client, &Account{ID: "A1"})
+//	err = snapshot.Unmarshal(&account)
+//	events, err := table.LoadEvents(ctx, client, &account, func(lo *dynamap.LoadEventsOptions) {
+//		lo.After = snapshot.Sequence
+//	})
+func (t *Table) LatestSnapshot(ctx context.Context, client DynamoDBClient, aggregate Marshaler) (AggregateSnapshot, error) {
+	client = t.client(client)
+	marshalOpts, source, err := t.aggregateKey(ctx, aggregate, nil)
+	if err != nil {
+		return AggregateSnapshot{}, err
+	}
+
+	items, err := t.queryLog(ctx, client, marshalOpts, source, SnapshotPrefix, 0, 1, false)
+	if err != nil {
+		return AggregateSnapshot{}, err
+	}
+	if len(items) == 0 {
+		return AggregateSnapshot{}, ErrItemNotFound
+	}
+
+	return logSnapshot(marshalOpts, items[0], t.MarshalOptions)
+}
+
+// AggregateOptions configures [Table.LoadAggregate].
+type AggregateOptions struct {
+	// SnapshotEvery saves a snapshot of the loaded aggregate if at least this many events
+	// were applied after its latest snapshot. Zero never saves snapshots.
+	SnapshotEvery int
+}
+
+// LoadAggregate loads the aggregate from its latest snapshot, if any, then calls apply
+// with each newer event in sequence order. The sequence of the last applied event is
+// returned, or zero if the aggregate has no snapshot or events; append the next event at
+// the following sequence.
+//
+// Example:
+//
+//	account := &Account{ID: "A1"}
+//	sequence, err := table.LoadAggregate(ctx, client, account, account.Apply, func(ao *dynamap.AggregateOptions) {
+//		ao.SnapshotEvery = 100
+//	})
+//	_, err = table.AppendEvent(ctx, client, account, Withdrawn{Amount: 5}, func(eo *dynamap.EventOptions) {
+//		eo.Sequence = sequence + 1
+//	})
+func (t *Table) LoadAggregate(ctx context.Context, client DynamoDBClient, aggregate Marshaler, apply func(Event) error, opts ...func(*AggregateOptions)) (int, error) {
+	var options AggregateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var sequence int
+	latest, err := t.LatestSnapshot(ctx, client, aggregate)
+	switch {
+	case err == nil:
+		if err := latest.Unmarshal(aggregate); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+		}
+		sequence = latest.Sequence
+	case !errors.Is(err, ErrItemNotFound):
+		return 0, err
+	}
+
+	events, err := t.LoadEvents(ctx, client, aggregate, func(lo *LoadEventsOptions) {
+		lo.After = sequence
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if sequence, err = replay(events, sequence, apply); err != nil {
+		return sequence, err
+	}
+
+	if options.SnapshotEvery > 0 && len(events) >= options.SnapshotEvery {
+		if err := t.SaveSnapshot(ctx, client, aggregate, sequence, aggregate); err != nil {
+			return sequence, err
+		}
+	}
+	return sequence, nil
+}
+
+// UnmarshalAggregate loads the aggregate from the items of its partition, such as those
+// returned by a [QueryEntity]: the latest snapshot among the items is unmarshaled into
+// aggregate, then apply is called with each newer event in sequence order. Other items of
+// the partition are ignored, and the event and snapshot items are in turn ignored by
+// [UnmarshalEntity], so an aggregate and its refs can be read with a single query. The
+// sequence of the last applied event is returned.
+//
+// Example:
+//
+//	input, err := table.MarshalQuery(&dynamap.QueryEntity{Source: &Account{ID: "A1"}})
+//	output, err := client.Query(ctx, input)
+//	_, err = dynamap.UnmarshalEntity(output.Items, account, table.MarshalOptions)
+//	sequence, err := dynamap.UnmarshalAggregate(output.Items, account, account.Apply, table.MarshalOptions)
+func UnmarshalAggregate(items []Item, aggregate any, apply func(Event) error, opts ...func(*MarshalOptions)) (int, error) {
+	marshalOpts := NewMarshalOptions(opts...)
+	if marshalOpts.KeyNames.IsSet() {
+		items = renameItems(items, marshalOpts.KeyNames.inverse())
+	}
+	tableOpts := func(mo *MarshalOptions) { mo.apply(opts) }
+
+	var (
+		sequence int
+		latest   Item
+		events   []Event
+	)
+	for _, item := range items {
+		switch marshalOpts.logPrefix(item) {
+		case SnapshotPrefix:
+			s, err := marshalOpts.logSequence(item)
+			if err != nil {
+				return 0, err
+			}
+			if s > sequence {
+				sequence, latest = s, item
+			}
+		case EventPrefix:
+			event, err := logEvent(marshalOpts, item, tableOpts)
+			if err != nil {
+				return 0, err
+			}
+			events = append(events, event)
+		}
+	}
+
+	if latest != nil {
+		s, err := logSnapshot(marshalOpts, latest, tableOpts)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.Unmarshal(aggregate); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+		}
+	}
+
+	events = slices.DeleteFunc(events, func(e Event) bool { return e.Sequence <= sequence })
+	slices.SortFunc(events, func(a, b Event) int { return a.Sequence - b.Sequence })
+	return replay(events, sequence, apply)
+}
+
+// replay calls apply with each event, returning the sequence of the last applied event.
+func replay(events []Event, sequence int, apply func(Event) error) (int, error) {
+	for _, event := range events {
+		if err := apply(event); err != nil {
+			return sequence, fmt.Errorf("failed to apply event %d: %w", event.Sequence, err)
+		}
+		sequence = event.Sequence
+	}
+	return sequence, nil
+}
+
+// logPrefix returns the key prefix of an event or snapshot item, or an empty string if
+// the item is neither.
+func (mo MarshalOptions) logPrefix(item Item) string {
+	if _, ok := mo.unaliasItem(item)[AttributeNameLabel]; ok {
+		return ""
+	}
+	prefix, _, err := mo.keyCodec().DecodeKey(StringValue(item[AttributeNameTarget]))
+	if err != nil || prefix != EventPrefix && prefix != SnapshotPrefix {
+		return ""
+	}
+	return prefix
+}
+
+// aggregateKey returns the marshal options of the aggregate and its hash key.
+func (t *Table) aggregateKey(ctx context.Context, aggregate Marshaler, opts []func(*MarshalOptions)) (MarshalOptions, string, error) {
+	opts = contextOptions(ctx, opts)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+		mo.SkipRefs = true
+	})
+	if err := aggregate.MarshalSelf(&marshalOpts); err != nil {
+		return marshalOpts, "", fmt.Errorf("failed to marshal self: %w", err)
+	}
+	return marshalOpts, marshalOpts.sourceKey(), nil
+}
+
+// logKey returns the sort key of the event or snapshot at sequence.
+func (mo MarshalOptions) logKey(prefix string, sequence int) string {
+	return mo.keyCodec().EncodeKey(prefix, fmt.Sprintf(eventSequenceFormat, sequence))
+}
+
+// logSequence returns the sequence of an event or snapshot item.
+func (mo MarshalOptions) logSequence(item Item) (int, error) {
+	_, id, err := mo.keyCodec().DecodeKey(StringValue(item[AttributeNameTarget]))
+	if err != nil {
+		return 0, err
+	}
+	sequence, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSequence, id)
+	}
+	return sequence, nil
+}
+
+// logItem returns the event or snapshot item at sequence in the partition of source.
+// Like revisions, log items have no label so they are not indexed.
+func (mo MarshalOptions) logItem(source, prefix string, sequence int, data any) (Item, error) {
+	if sequence < 1 || sequence > MaxEventSequence {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSequence, sequence)
+	}
+	if err := mo.validateAliases(); err != nil {
+		return nil, err
+	}
+
+	av, err := attributevalue.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	if mo.DataCodec != nil {
+		if av, err = mo.DataCodec.Encode(av); err != nil {
+			return nil, fmt.Errorf("failed to encode data: %w", err)
+		}
+	}
+
+	now := mo.Tick()
+	item := Item{
+		AttributeNameSource:  &types.AttributeValueMemberS{Value: source},
+		AttributeNameTarget:  &types.AttributeValueMemberS{Value: mo.logKey(prefix, sequence)},
+		AttributeNameCreated: RFC3339TimestampCodec{}.EncodeTime(now),
+		AttributeNameUpdated: RFC3339TimestampCodec{}.EncodeTime(now),
+		AttributeNameData:    av,
+	}
+	for name, value := range mo.audit().attributes() {
+		item[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	if err := mo.encodeTimestamps(item); err != nil {
+		return nil, err
+	}
+
+	return mo.aliasItem(item), nil
+}
+
+// putLogItem writes the event or snapshot item. If once is true, the write fails with a
+// [*ConditionFailedError] if the item exists.
+func (t *Table) putLogItem(ctx context.Context, client DynamoDBClient, item Item, once bool) error {
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(t.TableName),
+		Item:      item,
+	}
+	if once {
+		input.ConditionExpression = aws.String("attribute_not_exists(#sk)")
+		input.ExpressionAttributeNames = map[string]string{"#sk": AttributeNameTarget}
+	}
+
+	if _, err := client.PutItem(ctx, input); err != nil {
+		return conditionFailed(item, fmt.Errorf("failed to put %s: %w", StringValue(item[AttributeNameTarget]), classify(err)))
+	}
+	return nil
+}
+
+// queryLog returns up to limit event or snapshot items of the partition with a sequence
+// greater than after, in sequence order if ascending.
+func (t *Table) queryLog(ctx context.Context, client DynamoDBClient, mo MarshalOptions, source, prefix string, after, limit int, ascending bool) ([]Item, error) {
+	keyCondition := expression.Key(AttributeNameSource).Equal(expression.Value(source))
+	if after > 0 {
+		keyCondition = keyCondition.And(expression.Key(AttributeNameTarget).Between(
+			expression.Value(mo.logKey(prefix, after+1)),
+			expression.Value(mo.logKey(prefix, MaxEventSequence)),
+		))
+	} else {
+		keyCondition = keyCondition.And(expression.Key(AttributeNameTarget).BeginsWith(mo.keyCodec().EncodeKey(prefix, "")))
+	}
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(ascending),
+		ConsistentRead:            aws.Bool(true),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(int32(limit))
+	}
+
+	var items []Item
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s items: %w", prefix, classify(err))
+		}
+		items = append(items, output.Items...)
+		if limit > 0 && len(items) >= limit {
+			items = items[:limit]
+			break
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+	return items, nil
+}
+
+// logEvent returns the [Event] of an event item of the aggregate marshaled with mo. The
+// item is decoded with the table options opts.
+func logEvent(mo MarshalOptions, item Item, opts func(*MarshalOptions)) (Event, error) {
+	rel, err := unmarshalRelationship(item, opts)
+	if err != nil {
+		return Event{}, err
+	}
+	sequence, err := mo.logSequence(item)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Sequence:  sequence,
+		Type:      StringValue(item[AttributeNameEventType]),
+		CreatedAt: rel.CreatedAt,
+		item:      item,
+		opts:      opts,
+	}, nil
+}
+
+// logSnapshot returns the [AggregateSnapshot] of a snapshot item of the aggregate marshaled
+// with mo. The item is decoded with the table options opts.
+func logSnapshot(mo MarshalOptions, item Item, opts func(*MarshalOptions)) (AggregateSnapshot, error) {
+	rel, err := unmarshalRelationship(item, opts)
+	if err != nil {
+		return AggregateSnapshot{}, err
+	}
+	sequence, err := mo.logSequence(item)
+	if err != nil {
+		return AggregateSnapshot{}, err
+	}
+
+	// Snapshots unmarshal as the self relationship of the aggregate
+	item = maps.Clone(item)
+	item[AttributeNameTarget] = item[AttributeNameSource]
+	return AggregateSnapshot{Sequence: sequence, CreatedAt: rel.CreatedAt, item: item, opts: opts}, nil
+}
+
+// typeName returns the name of the type of v, dereferencing pointers.
+func typeName(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
@@ -0,0 +1,256 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type ledger struct {
+	ID      string `dynamodbav:"id"`
+	Balance int    `dynamodbav:"balance"`
+}
+
+func (l *ledger) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("ledger", l.ID)
+	return nil
+}
+
+func (l *ledger) UnmarshalRef(name, id string, ref *Relationship) error {
+	return nil
+}
+
+// apply applies a deposit to the ledger.
+func (l *ledger) apply(event Event) error {
+	var d deposited
+	if err := event.Unmarshal(&d); err != nil {
+		return err
+	}
+	l.Balance += d.Amount
+	return nil
+}
+
+type deposited struct {
+	Amount int `dynamodbav:"amount"`
+}
+
+// eventClient serves event and snapshot queries by sort key prefix or range, and rejects
+// puts conditioned on a missing item that exists.
+type eventClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *eventClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	key := StringValue(params.Item["hk"]) + "#" + StringValue(params.Item["sk"])
+	if _, ok := c.items[key]; ok && strings.Contains(aws.ToString(params.ConditionExpression), "attribute_not_exists") {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	return c.mockDynamoDBClient.PutItem(ctx, params, optFns...)
+}
+
+func (c *eventClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	var hk string
+	var bounds []string
+	for _, value := range params.ExpressionAttributeValues {
+		s := StringValue(value)
+		if strings.HasPrefix(s, EventPrefix+"#") || strings.HasPrefix(s, SnapshotPrefix+"#") {
+			bounds = append(bounds, s)
+		} else {
+			hk = s
+		}
+	}
+	slices.Sort(bounds)
+
+	var items []Item
+	for _, key := range slices.Sorted(maps.Keys(c.items)) {
+		item := c.items[key]
+		sk := StringValue(item["sk"])
+		if StringValue(item["hk"]) != hk {
+			continue
+		}
+		if len(bounds) == 1 && strings.HasPrefix(sk, bounds[0]) || len(bounds) == 2 && sk >= bounds[0] && sk <= bounds[1] {
+			items = append(items, item)
+		}
+	}
+	if !aws.ToBool(params.ScanIndexForward) {
+		slices.Reverse(items)
+	}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.Clock = func() time.Time { return now }
+	client := &eventClient{mockDynamoDBClient: newMockDynamoDBClient()}
+	aggregate := &ledger{ID: "A1"}
+
+	for _, amount := range []int{10, 20, 30} {
+		if _, err := table.AppendEvent(ctx, client, aggregate, &deposited{Amount: amount}); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	item, ok := client.items["ledger#A1#evt#000002"]
+	if !ok {
+		t.Fatalf("Expected the event in the aggregate partition, got %v", slices.Collect(maps.Keys(client.items)))
+	}
+	if _, ok := item[AttributeNameLabel]; ok {
+		t.Error("Expected events to have no label")
+	}
+
+	events, err := table.LoadEvents(ctx, client, aggregate)
+	if err != nil {
+		t.Fatalf("Failed to load events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	for i, event := range events {
+		var got deposited
+		if err := event.Unmarshal(&got); err != nil {
+			t.Fatalf("Failed to unmarshal event: %v", err)
+		}
+		if event.Sequence != i+1 || event.Type != "deposited" || got.Amount != (i+1)*10 || !event.CreatedAt.Equal(now) {
+			t.Errorf("Unexpected event %d: %+v %+v", i, event, got)
+		}
+	}
+
+	t.Run("rejects stale sequences", func(t *testing.T) {
+		_, err := table.AppendEvent(ctx, client, aggregate, deposited{Amount: 1}, func(eo *EventOptions) {
+			eo.Sequence = 3
+			eo.Type = "Deposited"
+		})
+		var conditionErr *ConditionFailedError
+		if !errors.Is(err, ErrConditionFailed) || !errors.As(err, &conditionErr) || conditionErr.Target != "evt#000003" {
+			t.Errorf("Expected a condition failure on the taken sequence, got %v", err)
+		}
+
+		event, err := table.AppendEvent(ctx, client, aggregate, deposited{Amount: 40}, func(eo *EventOptions) {
+			eo.Sequence = 4
+			eo.Type = "Deposited"
+		})
+		if err != nil || event.Sequence != 4 || event.Type != "Deposited" {
+			t.Errorf("Expected the event at the next sequence, got %+v (%v)", event, err)
+		}
+
+		if _, err := table.AppendEvent(ctx, client, aggregate, deposited{}, func(eo *EventOptions) {
+			eo.Sequence = MaxEventSequence + 1
+		}); !errors.Is(err, ErrInvalidSequence) || !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrInvalidSequence, got %v", err)
+		}
+	})
+
+	t.Run("snapshots", func(t *testing.T) {
+		if _, err := table.LatestSnapshot(ctx, client, aggregate); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("Expected ErrItemNotFound without snapshots, got %v", err)
+		}
+
+		for _, state := range []ledger{{ID: "A1", Balance: 30}, {ID: "A1", Balance: 60}} {
+			sequence := state.Balance / 20
+			if err := table.SaveSnapshot(ctx, client, aggregate, sequence, state); err != nil {
+				t.Fatalf("Failed to save snapshot: %v", err)
+			}
+		}
+
+		snapshot, err := table.LatestSnapshot(ctx, client, aggregate)
+		if err != nil {
+			t.Fatalf("Failed to load snapshot: %v", err)
+		}
+		var state ledger
+		if err := snapshot.Unmarshal(&state); err != nil || snapshot.Sequence != 3 || state.Balance != 60 {
+			t.Fatalf("Expected the latest snapshot, got %+v %+v (%v)", snapshot, state, err)
+		}
+
+		events, err := table.LoadEvents(ctx, client, aggregate, func(lo *LoadEventsOptions) {
+			lo.After = snapshot.Sequence
+		})
+		if err != nil || len(events) != 1 || events[0].Sequence != 4 {
+			t.Errorf("Expected the events after the snapshot, got %+v (%v)", events, err)
+		}
+	})
+}
+
+func TestLoadAggregate(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := &eventClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	for range 5 {
+		if _, err := table.AppendEvent(ctx, client, &ledger{ID: "A1"}, deposited{Amount: 10}); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	load := func() (*ledger, int, int) {
+		t.Helper()
+		aggregate := &ledger{ID: "A1"}
+		var applied int
+		sequence, err := table.LoadAggregate(ctx, client, aggregate, func(e Event) error {
+			applied++
+			return aggregate.apply(e)
+		}, func(ao *AggregateOptions) {
+			ao.SnapshotEvery = 3
+		})
+		if err != nil {
+			t.Fatalf("Failed to load aggregate: %v", err)
+		}
+		return aggregate, sequence, applied
+	}
+
+	aggregate, sequence, applied := load()
+	if aggregate.Balance != 50 || sequence != 5 || applied != 5 {
+		t.Fatalf("Expected all events applied, got %+v at %d (%d applied)", aggregate, sequence, applied)
+	}
+	if _, ok := client.items["ledger#A1#snap#000005"]; !ok {
+		t.Fatal("Expected a snapshot after replaying enough events")
+	}
+
+	if _, err := table.AppendEvent(ctx, client, aggregate, deposited{Amount: 5}, func(eo *EventOptions) {
+		eo.Sequence = sequence + 1
+	}); err != nil {
+		t.Fatalf("Failed to append event: %v", err)
+	}
+
+	aggregate, sequence, applied = load()
+	if aggregate.Balance != 55 || sequence != 6 || applied != 1 {
+		t.Errorf("Expected the snapshot and one event, got %+v at %d (%d applied)", aggregate, sequence, applied)
+	}
+	if _, ok := client.items["ledger#A1#snap#000006"]; ok {
+		t.Error("Expected no snapshot before enough events are replayed")
+	}
+
+	t.Run("from entity query", func(t *testing.T) {
+		if err := table.Put(ctx, client, &ledger{ID: "A1"}); err != nil {
+			t.Fatalf("Failed to put aggregate: %v", err)
+		}
+		var items []Item
+		for _, key := range slices.Sorted(maps.Keys(client.items)) {
+			if strings.HasPrefix(key, "ledger#A1#") {
+				items = append(items, client.items[key])
+			}
+		}
+
+		aggregate := &ledger{}
+		if _, err := UnmarshalEntity(items, aggregate, table.MarshalOptions); err != nil || aggregate.ID != "A1" {
+			t.Fatalf("Expected the entity to skip events and snapshots, got %+v (%v)", aggregate, err)
+		}
+
+		sequence, err := UnmarshalAggregate(items, aggregate, aggregate.apply, table.MarshalOptions)
+		if err != nil || sequence != 6 || aggregate.Balance != 55 {
+			t.Errorf("Expected the aggregate from the partition, got %+v at %d (%v)", aggregate, sequence, err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ExpandRefs returns a copy of items where the data of each relationship named in names is
+// replaced with the data of its target's self relationship, loaded with batch get requests.
+// When the items are passed to [UnmarshalEntity], [RefUnmarshaler.UnmarshalRef] receives
+// the full target data instead of the [Ref] stub. Relationships whose target does not
+// exist keep their stub.
+func (t *Table) ExpandRefs(ctx context.Context, client DynamoDBClient, items []Item, names ...string) ([]Item, error) {
+	client = t.client(client)
+	if len(names) == 0 {
+		return items, nil
+	}
+
+	marshalOpts := NewMarshalOptions(t.MarshalOptions)
+	targets := make(map[int]string)
+
+	for i, item := range items {
+		source, target, err := UnmarshalTableKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+		if source == target {
+			continue
+		}
+
+		rel, err := UnmarshalSelf(item, &Ref{}, t.MarshalOptions)
+		if err != nil {
+			// Chunk continuations and unknown relationships are left as is
+			continue
+		}
+		if _, _, name, err := marshalOpts.splitLabel(rel); err == nil && slices.Contains(names, name) {
+			targets[i] = target
+		}
+	}
+
+	selves, err := t.getSelfItems(ctx, client, slices.Collect(maps.Values(targets)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := slices.Clone(items)
+	for i, target := range targets {
+		self, ok := selves[target]
+		if !ok {
+			continue
+		}
+
+		item := maps.Clone(items[i])
+		for _, name := range marshalOpts.attributeNames(AttributeNameData) {
+			delete(item, name)
+			if data, ok := self[name]; ok {
+				item[name] = data
+			}
+		}
+		result[i] = item
+	}
+
+	return result, nil
+}
+
+// LoadEntity executes the query, expands the relationships named in
+// [QueryEntity.ExpandRefs] using [Table.ExpandRefs], and unmarshals the results into out
+// using [UnmarshalEntity]. Only a single page of results is loaded, unless
+// [QueryEntity.EntityLimit] is set to read complete entities with [Table.QueryEntityItems].
+//
+// Example:
+//
+//	order := &Order{ID: "O1"}
+//	_, err := table.LoadEntity(ctx, client, &dynamap.QueryEntity{
+//		Source:     order,
+//		ExpandRefs: []string{"products"},
+//	}, order)
+func (t *Table) LoadEntity(ctx context.Context, client DynamoDBClient, q *QueryEntity, out RefUnmarshaler, opts ...func(*MarshalOptions)) ([]Relationship, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	items, lastKey, err := t.QueryEntityItems(ctx, client, q, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if items, err = t.HydrateItems(ctx, items); err != nil {
+		return nil, err
+	}
+
+	if items, err = t.ExpandRefs(ctx, client, items, q.ExpandRefs...); err != nil {
+		return nil, err
+	}
+
+	// Entity limited queries never end in the middle of an entity
+	truncated := q.EntityLimit <= 0 && len(lastKey) > 0
+
+	return unmarshalEntity(items, out, truncated, func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+}
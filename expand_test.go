@@ -0,0 +1,78 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+)
+
+// expandedOrder records the data of each product ref by target key.
+type expandedOrder struct {
+	Order
+	data map[string]any
+}
+
+func (o *expandedOrder) UnmarshalRef(name string, id string, ref *Relationship) error {
+	if o.data == nil {
+		o.data = make(map[string]any)
+	}
+	o.data[ref.Target] = ref.Data
+	return o.Order.UnmarshalRef(name, id, ref)
+}
+
+// Tests for expanding refs
+
+func TestTableLoadEntity(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newPartitionClient()
+
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+	batches, err := table.MarshalBatch(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+	if err := batchWriteAll(ctx, client, batches); err != nil {
+		t.Fatalf("Failed to write order: %v", err)
+	}
+	if err := table.Put(ctx, client, &Product{ID: "P1", Category: "electronics"}); err != nil {
+		t.Fatalf("Failed to put product: %v", err)
+	}
+
+	t.Run("expanded", func(t *testing.T) {
+		out := &expandedOrder{}
+		_, err := table.LoadEntity(ctx, client, &QueryEntity{
+			Source:     &Order{ID: "O1"},
+			ExpandRefs: []string{"products"},
+		}, out)
+		if err != nil {
+			t.Fatalf("Failed to load entity: %v", err)
+		}
+
+		if len(out.Products) != 2 {
+			t.Fatalf("Expected 2 products, got %d", len(out.Products))
+		}
+
+		p1, ok := out.data["product#P1"].(map[string]any)
+		if !ok || p1["category"] != "electronics" {
+			t.Errorf("Expected P1 data with category, got %v", out.data["product#P1"])
+		}
+
+		// P2 does not exist, so its stub is kept
+		p2, ok := out.data["product#P2"].(map[string]any)
+		if !ok || p2["TargetID"] != "P2" {
+			t.Errorf("Expected P2 ref stub, got %v", out.data["product#P2"])
+		}
+	})
+
+	t.Run("not expanded", func(t *testing.T) {
+		out := &expandedOrder{}
+		if _, err := table.LoadEntity(ctx, client, &QueryEntity{Source: &Order{ID: "O1"}}, out); err != nil {
+			t.Fatalf("Failed to load entity: %v", err)
+		}
+
+		p1, ok := out.data["product#P1"].(map[string]any)
+		if !ok || p1["TargetID"] != "P1" {
+			t.Errorf("Expected P1 ref stub, got %v", out.data["product#P1"])
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package dynamap
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// placeholderPattern matches expression attribute name and value placeholders.
+	placeholderPattern = regexp.MustCompile(`[#:][A-Za-z0-9_]+`)
+
+	// keyComparePattern matches comparisons in key condition expressions.
+	keyComparePattern = regexp.MustCompile(`([#\w]+)\s*(<=|>=|<|>|=)\s*(:\w+)`)
+
+	// keyBeginsWithPattern matches begins_with functions in key condition expressions.
+	keyBeginsWithPattern = regexp.MustCompile(`begins_with\s*\(\s*([#\w]+)\s*,\s*(:\w+)\s*\)`)
+
+	// keyBetweenPattern matches ranges in key condition expressions.
+	keyBetweenPattern = regexp.MustCompile(`([#\w]+)\s+BETWEEN\s+(:\w+)\s+AND\s+(:\w+)`)
+)
+
+// Explain renders a human-readable explanation of a marshaled query or update request:
+// the index used, the key condition, filter, update and condition expressions with their
+// names and values substituted, and notes on how selective the request is. It helps find
+// out why a query returns nothing. Other inputs return an empty string.
+//
+// Expression values are always included, so explanations should not be logged where
+// entity data must stay private. Set [Table.Debug] to explain every request the table
+// marshals.
+//
+// Example:
+//
+//	input, err := table.MarshalQuery(&dynamap.QueryList{Label: "product"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(table.Explain(input))
+func (t *Table) Explain(input any) string {
+	var b strings.Builder
+	switch in := input.(type) {
+	case *dynamodb.QueryInput:
+		t.explainQuery(&b, in)
+	case *dynamodb.UpdateItemInput:
+		t.explainUpdate(&b, in)
+	default:
+		return ""
+	}
+	return b.String()
+}
+
+// explainQuery writes the explanation of a query request to b.
+func (t *Table) explainQuery(b *strings.Builder, in *dynamodb.QueryInput) {
+	index := aws.ToString(in.IndexName)
+	fmt.Fprintf(b, "Query table %q", aws.ToString(in.TableName))
+	if index != "" {
+		fmt.Fprintf(b, " using index %q", index)
+	}
+	b.WriteString("\n")
+
+	names, values := in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	keyCondition := aws.ToString(in.KeyConditionExpression)
+	filter := aws.ToString(in.FilterExpression)
+
+	fmt.Fprintf(b, "  key condition: %s\n", renderExpression(keyCondition, names, values))
+	if filter != "" {
+		fmt.Fprintf(b, "  filter: %s\n", renderExpression(filter, names, values))
+	}
+	if in.ProjectionExpression != nil {
+		fmt.Fprintf(b, "  projection: %s\n", renderExpression(aws.ToString(in.ProjectionExpression), names, values))
+	}
+	if in.ScanIndexForward != nil && !*in.ScanIndexForward {
+		b.WriteString("  order: descending\n")
+	} else {
+		b.WriteString("  order: ascending\n")
+	}
+	if in.Limit != nil {
+		fmt.Fprintf(b, "  limit: %d\n", *in.Limit)
+	}
+	if in.ExclusiveStartKey != nil {
+		fmt.Fprintf(b, "  start after: %s\n", renderItem(in.ExclusiveStartKey))
+	}
+
+	var notes []string
+	notes = append(notes, t.explainKeyCondition(index, keyCondition, names, values)...)
+	if filter != "" {
+		notes = append(notes, "The filter is applied after items are read, so filtered items still consume read capacity.")
+		if in.Limit != nil {
+			notes = append(notes, fmt.Sprintf("The limit of %d counts items read before the filter: a page may hold fewer items, or none, while LastEvaluatedKey is still set. Keep paging until it is empty.", *in.Limit))
+		}
+	}
+	if index != "" {
+		notes = append(notes, fmt.Sprintf("Index %q is eventually consistent: items written moments ago may not be returned yet.", index))
+		if aws.ToBool(in.ConsistentRead) {
+			notes = append(notes, "ConsistentRead is not supported on global secondary indexes; DynamoDB rejects the request.")
+		}
+	}
+	if in.Select == types.SelectCount {
+		notes = append(notes, "Select is COUNT: only the number of matching items is returned, not the items.")
+	}
+	notes = append(notes, missingPlaceholders(names, values, keyCondition, filter, aws.ToString(in.ProjectionExpression))...)
+	writeNotes(b, notes)
+}
+
+// explainKeyCondition returns notes on the selectivity of a key condition on the index.
+func (t *Table) explainKeyCondition(index, keyCondition string, names map[string]string, values map[string]types.AttributeValue) []string {
+	partitionKey, sortKey := t.indexKeys(index)
+	name := func(placeholder string) string {
+		if resolved, ok := names[placeholder]; ok {
+			return resolved
+		}
+		return placeholder
+	}
+
+	// Find the partition key equality; if the key is unknown, it is the first one
+	var notes []string
+	compares := keyComparePattern.FindAllStringSubmatch(keyCondition, -1)
+	partition := slices.IndexFunc(compares, func(match []string) bool {
+		return match[2] == "=" && name(match[1]) == partitionKey
+	})
+	if partition < 0 {
+		partition = slices.IndexFunc(compares, func(match []string) bool { return match[2] == "=" })
+	}
+	if partition < 0 {
+		notes = append(notes, "The key condition has no partition key equality; DynamoDB rejects the request.")
+	} else {
+		match := compares[partition]
+		if value, ok := values[match[3]]; ok && isEmptyValue(value) {
+			notes = append(notes, fmt.Sprintf("The partition key %s is empty; no items can match.", name(match[1])))
+		} else {
+			notes = append(notes, fmt.Sprintf("Reads the single partition where %s = %s.", name(match[1]), renderValue(values[match[3]])))
+		}
+		compares = append(compares[:partition:partition], compares[partition+1:]...)
+	}
+
+	// The remaining condition, if any, is on the sort key
+	switch {
+	case len(compares) > 0:
+		match := compares[0]
+		if match[2] == "=" && index == "" {
+			notes = append(notes, fmt.Sprintf("Sort key equality on %s: at most one item can match.", name(match[1])))
+		} else {
+			notes = append(notes, fmt.Sprintf("Reads the items where %s %s %s.", name(match[1]), match[2], renderValue(values[match[3]])))
+		}
+	case keyBeginsWithPattern.MatchString(keyCondition):
+		match := keyBeginsWithPattern.FindStringSubmatch(keyCondition)
+		if value, ok := values[match[2]]; ok && isEmptyValue(value) {
+			notes = append(notes, fmt.Sprintf("The prefix of %s is empty, so every item in the partition is read.", name(match[1])))
+		} else {
+			notes = append(notes, fmt.Sprintf("Reads the items where %s starts with %s.", name(match[1]), renderValue(values[match[2]])))
+		}
+	case keyBetweenPattern.MatchString(keyCondition):
+		match := keyBetweenPattern.FindStringSubmatch(keyCondition)
+		low, high := values[match[2]], values[match[3]]
+		if low != nil && high != nil && compareAttributes(low, high) > 0 {
+			notes = append(notes, fmt.Sprintf("The lower bound of %s is greater than the upper bound; DynamoDB rejects the request.", name(match[1])))
+		} else {
+			notes = append(notes, fmt.Sprintf("Reads the items where %s is between %s and %s, inclusive.", name(match[1]), renderValue(values[match[2]]), renderValue(values[match[3]])))
+		}
+	default:
+		if sortKey != "" {
+			notes = append(notes, fmt.Sprintf("No condition on the sort key %s: every item in the partition is read.", sortKey))
+		}
+	}
+	return notes
+}
+
+// indexKeys returns the partition and sort key attributes of the named index, or of the
+// table if index is empty. Unknown indexes return empty names.
+func (t *Table) indexKeys(index string) (partitionKey, sortKey string) {
+	switch index {
+	case "":
+		return AttributeNameSource, AttributeNameTarget
+	case t.RefIndexName:
+		return NewMarshalOptions(t.MarshalOptions).refIndexHashKey(), AttributeNameRefSortKey
+	}
+	for _, idx := range t.Indexes {
+		if idx.Name == index {
+			return idx.PartitionKey, idx.SortKey
+		}
+	}
+	return "", ""
+}
+
+// explainUpdate writes the explanation of an update request to b.
+func (t *Table) explainUpdate(b *strings.Builder, in *dynamodb.UpdateItemInput) {
+	fmt.Fprintf(b, "UpdateItem table %q key %s\n", aws.ToString(in.TableName), renderItem(in.Key))
+
+	names, values := in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	update := aws.ToString(in.UpdateExpression)
+	condition := aws.ToString(in.ConditionExpression)
+
+	b.WriteString("  update:\n")
+	for _, clause := range strings.Split(update, "\n") {
+		if clause = strings.TrimSpace(clause); clause != "" {
+			fmt.Fprintf(b, "    %s\n", renderExpression(clause, names, values))
+		}
+	}
+	if condition != "" {
+		fmt.Fprintf(b, "  condition: %s\n", renderExpression(condition, names, values))
+	}
+	if in.ReturnValues != "" {
+		fmt.Fprintf(b, "  returns: %s\n", in.ReturnValues)
+	}
+
+	var notes []string
+	if condition != "" {
+		notes = append(notes, "The condition is checked against the stored item; if it does not hold, nothing is written and the update fails with ErrConditionFailed.")
+	} else {
+		notes = append(notes, "There is no condition: the update creates the item if no item has this key.")
+	}
+	notes = append(notes, missingPlaceholders(names, values, update, condition)...)
+	writeNotes(b, notes)
+}
+
+// writeNotes writes the notes of an explanation to b.
+func writeNotes(b *strings.Builder, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+	b.WriteString("  notes:\n")
+	for _, note := range notes {
+		fmt.Fprintf(b, "    - %s\n", note)
+	}
+}
+
+// missingPlaceholders returns notes on the placeholders of the expressions that have no
+// name or value.
+func missingPlaceholders(names map[string]string, values map[string]types.AttributeValue, expressions ...string) []string {
+	var notes []string
+	seen := make(map[string]bool)
+	for _, expr := range expressions {
+		for _, placeholder := range placeholderPattern.FindAllString(expr, -1) {
+			if seen[placeholder] {
+				continue
+			}
+			seen[placeholder] = true
+
+			_, hasName := names[placeholder]
+			_, hasValue := values[placeholder]
+			if placeholder[0] == '#' && !hasName || placeholder[0] == ':' && !hasValue {
+				notes = append(notes, fmt.Sprintf("%s is not defined; DynamoDB rejects the request.", placeholder))
+			}
+		}
+	}
+	return notes
+}
+
+// renderExpression substitutes the names and values of the placeholders in expr.
+// Undefined placeholders are kept as is.
+func renderExpression(expr string, names map[string]string, values map[string]types.AttributeValue) string {
+	return placeholderPattern.ReplaceAllStringFunc(expr, func(placeholder string) string {
+		if name, ok := names[placeholder]; ok {
+			return name
+		}
+		if value, ok := values[placeholder]; ok {
+			return renderValue(value)
+		}
+		return placeholder
+	})
+}
+
+// renderItem renders the attributes of an item, such as a key, in attribute name order.
+func renderItem(item Item) string {
+	var pairs []string
+	for _, name := range slices.Sorted(maps.Keys(item)) {
+		pairs = append(pairs, name+"="+renderValue(item[name]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// renderValue renders an attribute value as a literal: strings are quoted, and lists and
+// maps are rendered as JSON.
+func renderValue(value types.AttributeValue) string {
+	switch v := value.(type) {
+	case nil:
+		return "<undefined>"
+	case *types.AttributeValueMemberS:
+		return strconv.Quote(v.Value)
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return strconv.FormatBool(v.Value)
+	case *types.AttributeValueMemberNULL:
+		return "null"
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("<%d bytes>", len(v.Value))
+	}
+
+	var decoded any
+	if err := attributevalue.Unmarshal(value, &decoded); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	b, err := json.Marshal(decoded)
+	if err != nil {
+		return fmt.Sprintf("%v", decoded)
+	}
+	return string(b)
+}
+
+// isEmptyValue returns true if value is an empty string.
+func isEmptyValue(value types.AttributeValue) bool {
+	s, ok := value.(*types.AttributeValueMemberS)
+	return ok && s.Value == ""
+}
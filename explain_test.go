@@ -0,0 +1,163 @@
+package dynamap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestTableExplain(t *testing.T) {
+	table := NewTable("test-table")
+
+	t.Run("query", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryList{
+			Label:           "product",
+			RefSortFilter:   expression.Key(AttributeNameRefSortKey).BeginsWith("books"),
+			ConditionFilter: expression.Name("data.price").GreaterThan(expression.Value(10)),
+			Limit:           5,
+			SortDescending:  true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		explanation := table.Explain(input)
+		for _, want := range []string{
+			`Query table "test-table" using index "ref-index"`,
+			`label = "product"`,
+			`begins_with (gsi1_sk, "books")`,
+			"filter: data.price > 10",
+			"order: descending",
+			`Reads the single partition where label = "product".`,
+			`Reads the items where gsi1_sk starts with "books".`,
+			"The limit of 5 counts items read before the filter",
+			"eventually consistent",
+		} {
+			if !strings.Contains(explanation, want) {
+				t.Errorf("Expected %q in explanation:\n%s", want, explanation)
+			}
+		}
+	})
+
+	t.Run("entity query", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryEntity{Source: &Product{ID: "P1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		explanation := table.Explain(input)
+		if !strings.Contains(explanation, `Reads the single partition where hk = "product#P1".`) {
+			t.Errorf("Expected the entity partition in explanation:\n%s", explanation)
+		}
+		if strings.Contains(explanation, "index") {
+			t.Errorf("Expected no index in explanation:\n%s", explanation)
+		}
+	})
+
+	t.Run("queries that cannot match", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input *dynamodb.QueryInput
+			want  string
+		}{
+			{
+				name: "empty partition",
+				input: &dynamodb.QueryInput{
+					KeyConditionExpression:    aws.String("#0 = :0"),
+					ExpressionAttributeNames:  map[string]string{"#0": "hk"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{":0": &types.AttributeValueMemberS{Value: ""}},
+				},
+				want: "The partition key hk is empty; no items can match.",
+			},
+			{
+				name: "reversed range",
+				input: &dynamodb.QueryInput{
+					KeyConditionExpression:   aws.String("(#0 = :0) AND (#1 BETWEEN :1 AND :2)"),
+					ExpressionAttributeNames: map[string]string{"#0": "hk", "#1": "sk"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":0": &types.AttributeValueMemberS{Value: "order#O1"},
+						":1": &types.AttributeValueMemberS{Value: "b"},
+						":2": &types.AttributeValueMemberS{Value: "a"},
+					},
+				},
+				want: "The lower bound of sk is greater than the upper bound",
+			},
+			{
+				name: "undefined value",
+				input: &dynamodb.QueryInput{
+					KeyConditionExpression:   aws.String("#0 = :0"),
+					ExpressionAttributeNames: map[string]string{"#0": "hk"},
+				},
+				want: ":0 is not defined",
+			},
+			{
+				name: "consistent index read",
+				input: &dynamodb.QueryInput{
+					IndexName:                 aws.String("ref-index"),
+					ConsistentRead:            aws.Bool(true),
+					KeyConditionExpression:    aws.String("#0 = :0"),
+					ExpressionAttributeNames:  map[string]string{"#0": "label"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{":0": &types.AttributeValueMemberS{Value: "product"}},
+				},
+				want: "ConsistentRead is not supported",
+			},
+		}
+
+		for _, tt := range tests {
+			if explanation := table.Explain(tt.input); !strings.Contains(explanation, tt.want) {
+				t.Errorf("%s: expected %q in explanation:\n%s", tt.name, tt.want, explanation)
+			}
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		updater := UpdateWithCondition(
+			&testUpdater{},
+			expression.Name("data.category").Equal(expression.Value("electronics")),
+		)
+		input, err := table.MarshalUpdate(&Product{ID: "P1"}, updater)
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+
+		explanation := table.Explain(input)
+		for _, want := range []string{
+			`UpdateItem table "test-table" key hk="product#P1" sk="product#P1"`,
+			`data.category = "updated"`,
+			`condition: data.category = "electronics"`,
+			"fails with ErrConditionFailed",
+		} {
+			if !strings.Contains(explanation, want) {
+				t.Errorf("Expected %q in explanation:\n%s", want, explanation)
+			}
+		}
+	})
+
+	t.Run("other requests", func(t *testing.T) {
+		if explanation := table.Explain(&dynamodb.GetItemInput{}); explanation != "" {
+			t.Errorf("Expected no explanation, got %q", explanation)
+		}
+	})
+}
+
+func TestTableDebug(t *testing.T) {
+	var buf bytes.Buffer
+	table := NewTable("test-table")
+	table.Debug = &buf
+
+	if _, err := table.MarshalQuery(&QueryList{Label: "product"}); err != nil {
+		t.Fatalf("Failed to marshal query: %v", err)
+	}
+	if _, err := table.MarshalGet(&Product{ID: "P1"}); err != nil {
+		t.Fatalf("Failed to marshal get: %v", err)
+	}
+
+	if got := strings.Count(buf.String(), "Query table"); got != 1 {
+		t.Errorf("Expected one query explanation, got:\n%s", buf.String())
+	}
+}
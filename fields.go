@@ -0,0 +1,124 @@
+package dynamap
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TagName is the struct tag key used to control how entity data fields are persisted.
+// Supported values are [TagTransient] and [TagWriteOnly].
+//
+// Example:
+//
+//	type User struct {
+//		ID           string `dynamodbav:"id"`
+//		Password     string `dynamodbav:"password" dynamap:"transient"`
+//		PasswordHash string `dynamodbav:"password_hash" dynamap:"writeonly"`
+//	}
+const TagName = "dynamap"
+
+const (
+	TagTransient = "transient" // The field is never written to the table
+	TagWriteOnly = "writeonly" // The field is written to the table, but scrubbed when read
+)
+
+// taggedFieldsCache holds the data attribute names of tagged fields, by struct type and tag value.
+var taggedFieldsCache sync.Map
+
+type taggedFieldsKey struct {
+	typ reflect.Type
+	tag string
+}
+
+// taggedFields returns the data attribute names of the top-level fields of v tagged with
+// the [TagName] value tag. v may be a struct, or a pointer or interface holding one.
+func taggedFields(v any, tag string) []string {
+	typ := structType(reflect.ValueOf(v))
+	if typ == nil {
+		return nil
+	}
+
+	key := taggedFieldsKey{typ: typ, tag: tag}
+	if names, ok := taggedFieldsCache.Load(key); ok {
+		return names.([]string)
+	}
+
+	var names []string
+	for _, field := range reflect.VisibleFields(typ) {
+		if len(field.Index) > 1 || !field.IsExported() {
+			continue
+		}
+		if !slices.Contains(strings.Split(field.Tag.Get(TagName), ","), tag) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("dynamodbav"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+
+	taggedFieldsCache.Store(key, names)
+	return names
+}
+
+// structType returns the struct type that v holds, following pointers and interfaces, or
+// nil if v does not hold a struct.
+func structType(v reflect.Value) reflect.Type {
+	for v.IsValid() {
+		switch v.Kind() {
+		case reflect.Struct:
+			return v.Type()
+		case reflect.Interface:
+			v = v.Elem()
+		case reflect.Pointer:
+			if v.IsNil() {
+				typ := v.Type().Elem()
+				for typ.Kind() == reflect.Pointer {
+					typ = typ.Elem()
+				}
+				if typ.Kind() == reflect.Struct {
+					return typ
+				}
+				return nil
+			}
+			v = v.Elem()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// transientFields returns the data attributes of the self relationship data that must not
+// be written to the table.
+func (mo MarshalOptions) transientFields(data any) []string {
+	return slices.Concat(mo.TransientFields, taggedFields(data, TagTransient))
+}
+
+// writeOnlyFields returns the data attributes that must be scrubbed before the self
+// relationship data is unmarshaled into out.
+func (mo MarshalOptions) writeOnlyFields(out any) []string {
+	return slices.Concat(mo.WriteOnlyFields, taggedFields(out, TagWriteOnly))
+}
+
+// scrubData returns a copy of the data attribute without the named attributes. Data that
+// is not a map, or has none of the attributes, is returned as-is.
+func scrubData(data types.AttributeValue, names []string) types.AttributeValue {
+	m, ok := data.(*types.AttributeValueMemberM)
+	if !ok || !slices.ContainsFunc(names, func(name string) bool { _, ok := m.Value[name]; return ok }) {
+		return data
+	}
+
+	scrubbed := maps.Clone(m.Value)
+	for _, name := range names {
+		delete(scrubbed, name)
+	}
+	return &types.AttributeValueMemberM{Value: scrubbed}
+}
@@ -0,0 +1,100 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type account struct {
+	ID           string `dynamodbav:"id"`
+	Email        string `dynamodbav:"email"`
+	Password     string `dynamodbav:"password" dynamap:"transient"`
+	PasswordHash string `dynamodbav:"password_hash" dynamap:"writeonly"`
+	Notes        string `dynamodbav:"notes,omitempty"`
+	refs         []string
+}
+
+func (a *account) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("account", a.ID)
+	return nil
+}
+
+func (a *account) UnmarshalRef(name string, id string, ref *Relationship) error {
+	a.refs = append(a.refs, name)
+	return nil
+}
+
+// Tests for transient and write-only fields
+
+func TestTransientAndWriteOnlyFields(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newPartitionClient()
+
+	in := &account{ID: "A1", Email: "a@example.com", Password: "secret", PasswordHash: "hash", Notes: "vip"}
+	if err := table.Put(ctx, client, in); err != nil {
+		t.Fatalf("Failed to put account: %v", err)
+	}
+
+	data := client.items["account#A1#account#A1"]["data"].(*types.AttributeValueMemberM).Value
+
+	t.Run("transient tag", func(t *testing.T) {
+		if _, ok := data["password"]; ok {
+			t.Error("Expected transient field to not be persisted")
+		}
+	})
+
+	t.Run("write-only tag", func(t *testing.T) {
+		if _, ok := data["password_hash"]; !ok {
+			t.Fatal("Expected write-only field to be persisted")
+		}
+
+		out := &account{}
+		if _, err := table.LoadEntity(ctx, client, &QueryEntity{Source: &account{ID: "A1"}}, out); err != nil {
+			t.Fatalf("Failed to load account: %v", err)
+		}
+		if out.PasswordHash != "" {
+			t.Errorf("Expected write-only field to be scrubbed, got %q", out.PasswordHash)
+		}
+		if out.Email != "a@example.com" {
+			t.Errorf("Expected email to be read, got %q", out.Email)
+		}
+
+		var list []*account
+		if _, err := UnmarshalList([]Item{client.items["account#A1#account#A1"]}, &list); err != nil {
+			t.Fatalf("Failed to unmarshal list: %v", err)
+		}
+		if list[0].PasswordHash != "" {
+			t.Errorf("Expected write-only field to be scrubbed from list, got %q", list[0].PasswordHash)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		withOptions := func(mo *MarshalOptions) {
+			mo.TransientFields = []string{"notes"}
+			mo.WriteOnlyFields = []string{"email"}
+		}
+
+		if err := table.Put(ctx, client, in, withOptions); err != nil {
+			t.Fatalf("Failed to put account: %v", err)
+		}
+
+		item := client.items["account#A1#account#A1"]
+		if _, ok := item["data"].(*types.AttributeValueMemberM).Value["notes"]; ok {
+			t.Error("Expected transient option field to not be persisted")
+		}
+
+		out := &account{}
+		if _, err := UnmarshalSelf(item, out, withOptions); err != nil {
+			t.Fatalf("Failed to unmarshal self: %v", err)
+		}
+		if out.Email != "" {
+			t.Errorf("Expected write-only option field to be scrubbed, got %q", out.Email)
+		}
+		if item["data"].(*types.AttributeValueMemberM).Value["email"] == nil {
+			t.Error("Expected the stored item to be left unmodified")
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package dynamap
+
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// Field is a fluent builder of filter conditions on an item attribute, usable as the
+// ConditionFilter of queries without building expressions by hand.
+//
+// Example:
+//
+//	query := &dynamap.QueryList{
+//		Label: "product",
+//		ConditionFilter: dynamap.And(
+//			dynamap.Data("category").Eq("electronics"),
+//			dynamap.Data("price").Between(10, 100),
+//			dynamap.Or(dynamap.Data("tags").Contains("sale"), dynamap.Data("featured").Eq(true)),
+//		),
+//	}
+type Field struct {
+	name expression.NameBuilder
+}
+
+// Data returns a [Field] for the attribute at path within the entity data, such as "price"
+// or "address.city".
+func Data(path string) Field {
+	return Field{name: DataAttribute(path)}
+}
+
+// Attribute returns a [Field] for a top-level item attribute, such as [AttributeNameCreated].
+func Attribute(name string) Field {
+	return Field{name: expression.Name(name)}
+}
+
+// Eq filters for items whose field equals value.
+func (f Field) Eq(value any) expression.ConditionBuilder {
+	return f.name.Equal(expression.Value(value))
+}
+
+// Ne filters for items whose field does not equal value.
+func (f Field) Ne(value any) expression.ConditionBuilder {
+	return f.name.NotEqual(expression.Value(value))
+}
+
+// Lt filters for items whose field is less than value.
+func (f Field) Lt(value any) expression.ConditionBuilder {
+	return f.name.LessThan(expression.Value(value))
+}
+
+// Le filters for items whose field is less than or equal to value.
+func (f Field) Le(value any) expression.ConditionBuilder {
+	return f.name.LessThanEqual(expression.Value(value))
+}
+
+// Gt filters for items whose field is greater than value.
+func (f Field) Gt(value any) expression.ConditionBuilder {
+	return f.name.GreaterThan(expression.Value(value))
+}
+
+// Ge filters for items whose field is greater than or equal to value.
+func (f Field) Ge(value any) expression.ConditionBuilder {
+	return f.name.GreaterThanEqual(expression.Value(value))
+}
+
+// Between filters for items whose field is between low and high, inclusive.
+func (f Field) Between(low, high any) expression.ConditionBuilder {
+	return f.name.Between(expression.Value(low), expression.Value(high))
+}
+
+// In filters for items whose field equals one of values. At least one value is required.
+func (f Field) In(value any, values ...any) expression.ConditionBuilder {
+	var operands []expression.OperandBuilder
+	for _, v := range values {
+		operands = append(operands, expression.Value(v))
+	}
+	return f.name.In(expression.Value(value), operands...)
+}
+
+// BeginsWith filters for items whose string field starts with prefix.
+func (f Field) BeginsWith(prefix string) expression.ConditionBuilder {
+	return f.name.BeginsWith(prefix)
+}
+
+// Contains filters for items whose string field contains value as a substring, or whose
+// set or list field contains value as an element.
+func (f Field) Contains(value any) expression.ConditionBuilder {
+	return expression.Contains(f.name, value)
+}
+
+// Exists filters for items that have the field.
+func (f Field) Exists() expression.ConditionBuilder {
+	return f.name.AttributeExists()
+}
+
+// NotExists filters for items that are missing the field, such as entities written before
+// a migration marker was introduced.
+func (f Field) NotExists() expression.ConditionBuilder {
+	return f.name.AttributeNotExists()
+}
+
+// IsNull filters for items whose field is set to a null value.
+func (f Field) IsNull() expression.ConditionBuilder {
+	return f.name.AttributeType(expression.Null)
+}
+
+// NotNull filters for items whose field is set to a value other than null.
+func (f Field) NotNull() expression.ConditionBuilder {
+	return And(f.Exists(), Not(f.IsNull()))
+}
+
+// IsNullOrMissing filters for items whose field is either missing or set to null, which
+// both unmarshal to the zero value.
+func (f Field) IsNullOrMissing() expression.ConditionBuilder {
+	return Or(f.NotExists(), f.IsNull())
+}
+
+// IsType filters for items whose field has the attribute type, such as [expression.String]
+// or [expression.Number].
+func (f Field) IsType(t expression.DynamoDBAttributeType) expression.ConditionBuilder {
+	return f.name.AttributeType(t)
+}
+
+// Size returns a [Size] for comparing the size of the field: the length of a string or
+// binary value, or the number of elements in a set, list or map.
+func (f Field) Size() Size {
+	return Size{size: f.name.Size()}
+}
+
+// Size is a fluent builder of filter conditions on the size of an item attribute.
+//
+// Example:
+//
+//	filter := dynamap.Data("tags").Size().Gt(3)
+type Size struct {
+	size expression.SizeBuilder
+}
+
+// Eq filters for items whose field size equals n.
+func (s Size) Eq(n int) expression.ConditionBuilder {
+	return s.size.Equal(expression.Value(n))
+}
+
+// Ne filters for items whose field size does not equal n.
+func (s Size) Ne(n int) expression.ConditionBuilder {
+	return s.size.NotEqual(expression.Value(n))
+}
+
+// Lt filters for items whose field size is less than n.
+func (s Size) Lt(n int) expression.ConditionBuilder {
+	return s.size.LessThan(expression.Value(n))
+}
+
+// Le filters for items whose field size is less than or equal to n.
+func (s Size) Le(n int) expression.ConditionBuilder {
+	return s.size.LessThanEqual(expression.Value(n))
+}
+
+// Gt filters for items whose field size is greater than n.
+func (s Size) Gt(n int) expression.ConditionBuilder {
+	return s.size.GreaterThan(expression.Value(n))
+}
+
+// Ge filters for items whose field size is greater than or equal to n.
+func (s Size) Ge(n int) expression.ConditionBuilder {
+	return s.size.GreaterThanEqual(expression.Value(n))
+}
+
+// Between filters for items whose field size is between low and high, inclusive.
+func (s Size) Between(low, high int) expression.ConditionBuilder {
+	return s.size.Between(expression.Value(low), expression.Value(high))
+}
+
+// LabelPrefix filters for relationships whose label starts with prefix, such as
+// "order/O1/" for the refs of an order. The label is a key attribute of the ref index, so
+// this filter applies to queries on the table, such as [QueryEntity].
+func LabelPrefix(prefix string) expression.ConditionBuilder {
+	return Attribute(AttributeNameLabel).BeginsWith(prefix)
+}
+
+// RefSortPrefix filters the ref sort key of ref index queries for values starting with
+// prefix. Use it as the RefSortFilter of queries such as [QueryList].
+func RefSortPrefix(prefix string) expression.KeyConditionBuilder {
+	return expression.Key(AttributeNameRefSortKey).BeginsWith(prefix)
+}
+
+// And filters for items matching all of conditions. Unset conditions are ignored, and an
+// unset condition is returned if none remain.
+func And(conditions ...expression.ConditionBuilder) expression.ConditionBuilder {
+	return combine(expression.And, conditions)
+}
+
+// Or filters for items matching any of conditions. Unset conditions are ignored, and an
+// unset condition is returned if none remain.
+func Or(conditions ...expression.ConditionBuilder) expression.ConditionBuilder {
+	return combine(expression.Or, conditions)
+}
+
+// Not filters for items that do not match condition.
+func Not(condition expression.ConditionBuilder) expression.ConditionBuilder {
+	return expression.Not(condition)
+}
+
+// combine joins the set conditions with op.
+func combine(op func(left, right expression.ConditionBuilder, other ...expression.ConditionBuilder) expression.ConditionBuilder, conditions []expression.ConditionBuilder) expression.ConditionBuilder {
+	var set []expression.ConditionBuilder
+	for _, condition := range conditions {
+		if condition.IsSet() {
+			set = append(set, condition)
+		}
+	}
+
+	switch len(set) {
+	case 0:
+		return expression.ConditionBuilder{}
+	case 1:
+		return set[0]
+	default:
+		return op(set[0], set[1], set[2:]...)
+	}
+}
@@ -0,0 +1,74 @@
+package dynamap
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// Tests for the filter DSL
+
+func TestFilters(t *testing.T) {
+	table := NewTable("test-table")
+
+	tests := []struct {
+		name   string
+		filter expression.ConditionBuilder
+		want   string
+	}{
+		{"eq", Data("category").Eq("electronics"), "#0.#1 = :0"},
+		{"ne", Data("category").Ne("books"), "#0.#1 <> :0"},
+		{"lt", Data("price").Lt(10), "#0.#1 < :0"},
+		{"le", Data("price").Le(10), "#0.#1 <= :0"},
+		{"gt", Data("price").Gt(10), "#0.#1 > :0"},
+		{"ge", Data("price").Ge(10), "#0.#1 >= :0"},
+		{"between", Data("price").Between(10, 100), "#0.#1 BETWEEN :0 AND :1"},
+		{"in", Data("status").In("new", "paid"), "#0.#1 IN (:0, :1)"},
+		{"begins with", Data("sku").BeginsWith("A-"), "begins_with (#0.#1, :0)"},
+		{"contains", Data("tags").Contains("sale"), "contains (#0.#1, :0)"},
+		{"attribute", Attribute(AttributeNameCreated).Gt("2025"), "#0 > :0"},
+		{"label prefix", LabelPrefix("order/O1/"), "begins_with (#0, :0)"},
+		{"and", And(Data("a").Eq(1), Data("b").Eq(2)), "(#0.#1 = :0) AND (#0.#2 = :1)"},
+		{"or", Or(Data("a").Eq(1), Data("b").Eq(2)), "(#0.#1 = :0) OR (#0.#2 = :1)"},
+		{"not", Not(Data("a").Eq(1)), "NOT (#0.#1 = :0)"},
+		{"exists", Data("migrated").Exists(), "attribute_exists (#0.#1)"},
+		{"not exists", Data("migrated").NotExists(), "attribute_not_exists (#0.#1)"},
+		{"is null", Data("deleted").IsNull(), "attribute_type (#0.#1, :0)"},
+		{"not null", Data("deleted").NotNull(), "(attribute_exists (#0.#1)) AND (NOT (attribute_type (#0.#1, :0)))"},
+		{"null or missing", Data("deleted").IsNullOrMissing(), "(attribute_not_exists (#0.#1)) OR (attribute_type (#0.#1, :0))"},
+		{"is type", Data("price").IsType(expression.Number), "attribute_type (#0.#1, :0)"},
+		{"size eq", Data("tags").Size().Eq(0), "size (#0.#1) = :0"},
+		{"size gt", Data("tags").Size().Gt(3), "size (#0.#1) > :0"},
+		{"size between", Data("name").Size().Between(1, 10), "size (#0.#1) BETWEEN :0 AND :1"},
+		{"single and", And(expression.ConditionBuilder{}, Data("a").Eq(1)), "#0.#1 = :0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := table.MarshalQuery(&QueryList{Label: "product", ConditionFilter: tt.filter})
+			if err != nil {
+				t.Fatalf("Failed to marshal query: %v", err)
+			}
+			if got := aws.ToString(input.FilterExpression); got != tt.want {
+				t.Errorf("Expected filter %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("empty and", func(t *testing.T) {
+		if And().IsSet() || Or(expression.ConditionBuilder{}).IsSet() {
+			t.Error("Expected unset condition")
+		}
+	})
+
+	t.Run("ref sort prefix", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryList{Label: "product", RefSortFilter: RefSortPrefix("2025-")})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if got := aws.ToString(input.KeyConditionExpression); got != "(#0 = :0) AND (begins_with (#1, :1))" {
+			t.Errorf("Unexpected key condition %q", got)
+		}
+	})
+}
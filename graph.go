@@ -0,0 +1,297 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DefaultMaxDepth is the default maximum number of hops followed by [Graph.Traverse].
+const DefaultMaxDepth = 5
+
+// ErrMaxDepthExceeded is returned when a traversal path is longer than the graph allows.
+var ErrMaxDepthExceeded = newError(ErrValidation, "traversal path exceeds max depth")
+
+// Graph follows named relationships across entity partitions.
+//
+// Example:
+//
+//	graph := dynamap.NewGraph(table, client)
+//	root, err := graph.Traverse(ctx, &Order{ID: "O1"}, "products", "supplier")
+//
+//	var suppliers []Supplier
+//	err = dynamap.UnmarshalNodes(root.Nodes(2), &suppliers)
+type Graph struct {
+	Table    *Table         // The table holding the entities
+	Client   DynamoDBClient // The client used to load the entities
+	MaxDepth int            // Maximum number of hops in a path; defaults to DefaultMaxDepth
+}
+
+// NewGraph creates a new [Graph] over the table.
+func NewGraph(table *Table, client DynamoDBClient) *Graph {
+	return &Graph{Table: table, Client: client, MaxDepth: DefaultMaxDepth}
+}
+
+// GraphNode is an entity reached by [Graph.Traverse].
+type GraphNode struct {
+	Prefix   string        // The entity prefix
+	ID       string        // The entity identifier
+	Name     string        // The relationship name followed to reach the node; empty for the root
+	Edge     *Relationship // The relationship followed to reach the node; nil for the root
+	Children []*GraphNode  // The entities reached by following the next relationship in the path
+
+	key  string
+	item Item
+	opts func(*MarshalOptions)
+}
+
+// Found returns true if the self relationship of the entity exists.
+func (n *GraphNode) Found() bool {
+	return n.item != nil
+}
+
+// Unmarshal unmarshals the self relationship of the entity into out using [UnmarshalSelf].
+// [ErrItemNotFound] is returned if the entity does not exist.
+func (n *GraphNode) Unmarshal(out any, opts ...func(*MarshalOptions)) (Relationship, error) {
+	if n.item == nil {
+		return Relationship{}, fmt.Errorf("%w: %s", ErrItemNotFound, n.key)
+	}
+	return UnmarshalSelf(n.item, out, func(mo *MarshalOptions) {
+		n.opts(mo)
+		mo.apply(opts)
+	})
+}
+
+// Nodes returns the nodes at the given depth of the tree, where the root is at depth 0,
+// in traversal order.
+func (n *GraphNode) Nodes(depth int) []*GraphNode {
+	nodes := []*GraphNode{n}
+	for range depth {
+		var next []*GraphNode
+		for _, node := range nodes {
+			next = append(next, node.Children...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// UnmarshalNodes calls [GraphNode.Unmarshal] on each node that was found and stores the
+// result in out. Nodes whose entity does not exist are skipped.
+func UnmarshalNodes[T any](nodes []*GraphNode, out *[]T, opts ...func(*MarshalOptions)) ([]Relationship, error) {
+	var relationships []Relationship
+
+	for i, node := range nodes {
+		if !node.Found() {
+			continue
+		}
+		var value T
+		rel, err := node.Unmarshal(&value, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node %d: %w", i, err)
+		}
+		*out = append(*out, value)
+		relationships = append(relationships, rel)
+	}
+
+	return relationships, nil
+}
+
+// Traverse loads the start entity and follows each relationship name in path in turn,
+// returning the start entity as the root of a tree of the entities reached. For example,
+// the path "products", "supplier" loads the products of an order, then the supplier of
+// each product.
+//
+// Each partition along the path is queried once, no matter how many nodes reach it, and
+// the entities at the end of the path are loaded with batch get requests. Relationships
+// whose target does not exist are kept in the tree; use [GraphNode.Found] to detect them.
+func (g *Graph) Traverse(ctx context.Context, start Marshaler, path ...string) (*GraphNode, error) {
+	maxDepth := g.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if len(path) > maxDepth {
+		return nil, fmt.Errorf("%w: %d hops, max %d", ErrMaxDepthExceeded, len(path), maxDepth)
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		g.Table.MarshalOptions(mo)
+		mo.SkipRefs = true
+	})
+	if err := start.MarshalSelf(&marshalOpts); err != nil {
+		return nil, fmt.Errorf("failed to marshal self: %w", err)
+	}
+
+	root := g.newNode(marshalOpts.sourceKey(), marshalOpts.SourcePrefix, marshalOpts.SourceID)
+	level := []*GraphNode{root}
+	partitions := make(map[string][]Item)
+
+	for _, name := range path {
+		var next []*GraphNode
+
+		for _, node := range level {
+			items, ok := partitions[node.key]
+			if !ok {
+				var err error
+				if items, err = g.Table.queryPartition(ctx, g.Client, node.key); err != nil {
+					return nil, err
+				}
+				partitions[node.key] = items
+			}
+
+			children, err := g.expand(node, items, name)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, children...)
+		}
+
+		level = next
+	}
+
+	if err := g.loadLeaves(ctx, level, partitions); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// newNode creates a node for the entity with the given key.
+func (g *Graph) newNode(key, prefix, id string) *GraphNode {
+	return &GraphNode{Prefix: prefix, ID: id, key: key, opts: g.Table.MarshalOptions}
+}
+
+// expand sets the self item of node from its partition items, and adds a child for each
+// relationship named name.
+func (g *Graph) expand(node *GraphNode, items []Item, name string) ([]*GraphNode, error) {
+	marshalOpts := NewMarshalOptions(g.Table.MarshalOptions)
+
+	for _, item := range items {
+		source, target, err := UnmarshalTableKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+
+		if source == target {
+			node.item = item
+			continue
+		}
+
+		rel, err := UnmarshalSelf(item, &Ref{}, g.Table.MarshalOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal relationship: %w", err)
+		}
+
+		if _, _, refName, err := marshalOpts.splitLabel(rel); err != nil || refName != name {
+			continue
+		}
+
+		prefix, id, err := marshalOpts.keyCodec().DecodeKey(rel.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode target key: %w", err)
+		}
+
+		child := g.newNode(rel.Target, prefix, id)
+		child.Name = name
+		child.Edge = &rel
+		node.Children = append(node.Children, child)
+	}
+
+	return node.Children, nil
+}
+
+// loadLeaves sets the self item of each node at the end of the path. Nodes in partitions
+// already queried are resolved from those items; the rest are loaded with batch gets.
+func (g *Graph) loadLeaves(ctx context.Context, nodes []*GraphNode, partitions map[string][]Item) error {
+	var keys []string
+
+	for _, node := range nodes {
+		if items, ok := partitions[node.key]; ok {
+			item, err := selfItem(items)
+			if err != nil {
+				return err
+			}
+			node.item = item
+			continue
+		}
+		keys = append(keys, node.key)
+	}
+
+	items, err := g.Table.getSelfItems(ctx, g.Client, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if item, ok := items[node.key]; ok {
+			node.item = item
+		}
+	}
+
+	return nil
+}
+
+// selfItem returns the self relationship among the partition items, or nil if there is
+// none.
+func selfItem(items []Item) (Item, error) {
+	for _, item := range items {
+		source, target, err := UnmarshalTableKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+		if source == target {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// queryPartition returns every item in the partition with the given source key, following
+// pagination until the partition is exhausted. Chunked items are reassembled and offloaded
+// data is retrieved from the table's blob store.
+func (t *Table) queryPartition(ctx context.Context, client DynamoDBClient, key string) ([]Item, error) {
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key(AttributeNameSource).Equal(expression.Value(key))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	items, err := queryAll(ctx, client, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query partition: %w", classify(err))
+	}
+
+	if items, err = assembleChunks(items); err != nil {
+		return nil, err
+	}
+
+	return t.HydrateItems(ctx, items)
+}
+
+// queryAll executes the query, following pagination until the results are exhausted.
+func queryAll(ctx context.Context, client DynamoDBClient, input *dynamodb.QueryInput) ([]Item, error) {
+	var items []Item
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, classify(err)
+		}
+
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,140 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// partitionClient answers queries on the table partition key and records the partitions
+// queried.
+type partitionClient struct {
+	*mockDynamoDBClient
+	queried []string
+}
+
+func newPartitionClient() *partitionClient {
+	return &partitionClient{mockDynamoDBClient: newMockDynamoDBClient()}
+}
+
+func (c *partitionClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	var key string
+	for _, value := range params.ExpressionAttributeValues {
+		key = value.(*types.AttributeValueMemberS).Value
+	}
+	c.queried = append(c.queried, key)
+
+	var items []Item
+	for id, item := range c.items {
+		if strings.HasPrefix(id, key+"#") {
+			items = append(items, item)
+		}
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+type supplier struct {
+	ID   string `dynamodbav:"id"`
+	Name string `dynamodbav:"name"`
+}
+
+func (s *supplier) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("supplier", s.ID)
+	return nil
+}
+
+// Tests for graph traversal
+
+func TestGraphTraverse(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newPartitionClient()
+
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+	batches, err := table.MarshalBatch(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+	if err := batchWriteAll(ctx, client, batches); err != nil {
+		t.Fatalf("Failed to write order: %v", err)
+	}
+
+	for _, p := range order.Products {
+		if err := table.Put(ctx, client, &p); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		if err := table.Attach(ctx, client, &p, "supplier", []Marshaler{&supplier{ID: "S1"}}); err != nil {
+			t.Fatalf("Failed to attach supplier: %v", err)
+		}
+	}
+	if err := table.Put(ctx, client, &supplier{ID: "S1", Name: "Acme"}); err != nil {
+		t.Fatalf("Failed to put supplier: %v", err)
+	}
+
+	graph := NewGraph(table, client)
+
+	t.Run("multiple hops", func(t *testing.T) {
+		client.queried = nil
+		root, err := graph.Traverse(ctx, &Order{ID: "O1"}, "products", "supplier")
+		if err != nil {
+			t.Fatalf("Failed to traverse: %v", err)
+		}
+
+		var o Order
+		if _, err := root.Unmarshal(&o); err != nil || o.ID != "O1" {
+			t.Errorf("Expected root order O1, got %+v (%v)", o, err)
+		}
+
+		products := root.Nodes(1)
+		if len(products) != 2 || products[0].Name != "products" || products[0].Edge == nil {
+			t.Fatalf("Expected 2 products reached by 'products', got %+v", products)
+		}
+
+		var suppliers []supplier
+		if _, err := UnmarshalNodes(root.Nodes(2), &suppliers); err != nil {
+			t.Fatalf("Failed to unmarshal suppliers: %v", err)
+		}
+		if len(suppliers) != 2 || suppliers[0].Name != "Acme" {
+			t.Errorf("Expected supplier Acme for each product, got %+v", suppliers)
+		}
+
+		if len(client.queried) != 3 {
+			t.Errorf("Expected 3 partition queries, got %v", client.queried)
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		if err := table.Attach(ctx, client, &Product{ID: "P1"}, "supplier", []Marshaler{&supplier{ID: "S2"}}); err != nil {
+			t.Fatalf("Failed to attach supplier: %v", err)
+		}
+
+		root, err := graph.Traverse(ctx, &Product{ID: "P1"}, "supplier")
+		if err != nil {
+			t.Fatalf("Failed to traverse: %v", err)
+		}
+
+		var found int
+		for _, node := range root.Children {
+			if node.Found() {
+				found++
+			} else if _, err := node.Unmarshal(&supplier{}); !errors.Is(err, ErrItemNotFound) {
+				t.Errorf("Expected ErrItemNotFound, got %v", err)
+			}
+		}
+		if len(root.Children) != 2 || found != 1 {
+			t.Errorf("Expected 1 of 2 suppliers found, got %d of %d", found, len(root.Children))
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		graph := NewGraph(table, client)
+		graph.MaxDepth = 1
+		if _, err := graph.Traverse(ctx, order, "products", "supplier"); !errors.Is(err, ErrMaxDepthExceeded) {
+			t.Errorf("Expected ErrMaxDepthExceeded, got %v", err)
+		}
+	})
+}
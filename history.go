@@ -0,0 +1,266 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RevisionSuffix is appended to the sort key of a self relationship to form the sort
+// key of its revisions, followed by the revision id.
+const RevisionSuffix = "#rev#"
+
+// revisionIDFormat formats revision times so revision ids sort lexicographically.
+const revisionIDFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Revision is an immutable copy of an entity's self relationship, written by
+// [Table.Put] and [Table.Update] when [Table.History] is set.
+type Revision struct {
+	ID        string    // Identifies the revision; ids sort in the order revisions were written
+	CreatedAt time.Time // When the revision was written
+	Expires   time.Time // When the revision expires; zero if the table has no HistoryTTL
+
+	item Item
+	opts func(*MarshalOptions)
+}
+
+// Unmarshal unmarshals the entity data of the revision into out. The returned
+// relationship has the keys and timestamps of the entity when the revision was written,
+// but no label.
+func (r Revision) Unmarshal(out any) (Relationship, error) {
+	return UnmarshalSelf(r.item, out, r.opts)
+}
+
+// HistoryOptions configures [Table.QueryHistory].
+type HistoryOptions struct {
+	Limit     int  // Maximum number of revisions returned. Zero returns all revisions.
+	Ascending bool // If true, revisions are returned oldest first. Default is newest first.
+}
+
+// revisionItem returns the revision of a self relationship item written at now. Revisions
+// share the partition of the entity and have no label, so they are not indexed.
+func (t *Table) revisionItem(mo MarshalOptions, item Item, now time.Time) Item {
+	target := StringValue(item[AttributeNameTarget])
+	revision := Item{
+		AttributeNameSource: item[AttributeNameSource],
+		AttributeNameTarget: &types.AttributeValueMemberS{Value: revisionKey(target, now.UTC().Format(revisionIDFormat))},
+	}
+
+	copied := []string{
+		AttributeNameData, AttributeNameCreated, AttributeNameUpdated, AttributeNameDeleted, AttributeNameBlob,
+		AttributeNameActor, AttributeNameRequestID, AttributeNameReason,
+	}
+	for _, name := range copied {
+		for _, name := range mo.attributeNames(name) {
+			if value, ok := item[name]; ok {
+				revision[name] = value
+			}
+		}
+	}
+
+	if t.HistoryTTL > 0 {
+		expires := strconv.FormatInt(now.Add(t.HistoryTTL).Unix(), 10)
+		revision[mo.aliasName(AttributeNameExpires)] = &types.AttributeValueMemberN{Value: expires}
+	}
+
+	return revision
+}
+
+// putRevision writes the revision of the updated self relationship item.
+func (t *Table) putRevision(ctx context.Context, client DynamoDBClient, mo MarshalOptions, item Item) error {
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(t.TableName),
+		Item:                     t.revisionItem(mo, item, mo.Tick()),
+		ConditionExpression:      aws.String("attribute_not_exists(#sk)"),
+		ExpressionAttributeNames: map[string]string{"#sk": AttributeNameTarget},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put revision: %w", classify(err))
+	}
+	return nil
+}
+
+// putWithRevision writes the item and its revision in a transaction.
+func (t *Table) putWithRevision(ctx context.Context, client DynamoDBClient, mo MarshalOptions, input *dynamodb.PutItemInput) error {
+	_, err := transactWriteItems(ctx, client, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                 input.TableName,
+				Item:                      input.Item,
+				ConditionExpression:       input.ConditionExpression,
+				ExpressionAttributeNames:  input.ExpressionAttributeNames,
+				ExpressionAttributeValues: input.ExpressionAttributeValues,
+			}},
+			{Put: &types.Put{
+				TableName:                input.TableName,
+				Item:                     t.revisionItem(mo, input.Item, mo.Tick()),
+				ConditionExpression:      aws.String("attribute_not_exists(#sk)"),
+				ExpressionAttributeNames: map[string]string{"#sk": AttributeNameTarget},
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put item: %w", classify(err))
+	}
+	return nil
+}
+
+// QueryHistory returns the revisions of the entity, newest first. Expired revisions not
+// yet deleted by DynamoDB are skipped if [Table.FilterExpired] is set.
+//
+// Example:
+//
+//	revisions, err := table.QueryHistory(ctx, client, &Order{ID: "123"}, func(ho *dynamap.HistoryOptions) {
+//		ho.Limit = 10
+//	})
+//	for _, revision := range revisions {
+//		var order Order
+//		_, err := revision.Unmarshal(&order)
+//	}
+func (t *Table) QueryHistory(ctx context.Context, client DynamoDBClient, in Marshaler, opts ...func(*HistoryOptions)) ([]Revision, error) {
+	client = t.client(client)
+	var options HistoryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	get, err := t.MarshalGet(in)
+	if err != nil {
+		return nil, err
+	}
+
+	source, target := StringValue(get.Key[AttributeNameSource]), StringValue(get.Key[AttributeNameTarget])
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key(AttributeNameSource).Equal(expression.Value(source)).
+			And(expression.Key(AttributeNameTarget).BeginsWith(revisionKey(target, "")))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(options.Ascending),
+	}
+
+	marshalOpts := NewMarshalOptions(t.MarshalOptions)
+	if t.FilterExpired {
+		filterExpired(input, marshalOpts.Tick())
+		input.ExpressionAttributeNames = marshalOpts.aliasNames(input.ExpressionAttributeNames, &input.KeyConditionExpression, &input.FilterExpression)
+	}
+
+	var items []Item
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query history: %w", classify(err))
+		}
+		items = append(items, output.Items...)
+		if options.Limit > 0 && len(items) >= options.Limit {
+			items = items[:options.Limit]
+			break
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	if items, err = t.HydrateItems(ctx, items); err != nil {
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0, len(items))
+	for _, item := range items {
+		revision, err := t.revision(item, target)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves the revision of the entity with the id returned by
+// [Table.QueryHistory], and unmarshals its data into out. [ErrItemNotFound] is returned
+// if the revision does not exist, or has expired and [Table.FilterExpired] is set.
+func (t *Table) GetRevision(ctx context.Context, client DynamoDBClient, in Marshaler, id string, out any) (Relationship, error) {
+	client = t.client(client)
+	input, err := t.MarshalGet(in)
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	target := StringValue(input.Key[AttributeNameTarget])
+	input.Key = maps.Clone(input.Key)
+	input.Key[AttributeNameTarget] = &types.AttributeValueMemberS{Value: revisionKey(target, id)}
+
+	output, err := client.GetItem(ctx, input)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("failed to get revision: %w", classify(err))
+	}
+
+	marshalOpts := NewMarshalOptions(t.MarshalOptions)
+	if len(output.Item) == 0 || t.FilterExpired && isExpired(output.Item, marshalOpts.aliasName(AttributeNameExpires), marshalOpts.Tick()) {
+		return Relationship{}, ErrItemNotFound
+	}
+
+	items, err := t.HydrateItems(ctx, []Item{output.Item})
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	revision, err := t.revision(items[0], target)
+	if err != nil {
+		return Relationship{}, err
+	}
+	return revision.Unmarshal(out)
+}
+
+// revision returns the [Revision] of a revision item of the entity with the sort key target.
+func (t *Table) revision(item Item, target string) (Revision, error) {
+	id := strings.TrimPrefix(StringValue(item[AttributeNameTarget]), revisionKey(target, ""))
+	createdAt, err := time.Parse(revisionIDFormat, id)
+	if err != nil {
+		return Revision{}, fmt.Errorf("invalid revision id %q: %w", id, err)
+	}
+
+	revision := Revision{ID: id, CreatedAt: createdAt, opts: t.MarshalOptions}
+	if expires, ok := item[NewMarshalOptions(t.MarshalOptions).aliasName(AttributeNameExpires)].(*types.AttributeValueMemberN); ok {
+		if seconds, err := strconv.ParseInt(expires.Value, 10, 64); err == nil {
+			revision.Expires = time.Unix(seconds, 0).UTC()
+		}
+	}
+
+	// Revisions unmarshal as the self relationship they were copied from
+	revision.item = maps.Clone(item)
+	revision.item[AttributeNameTarget] = &types.AttributeValueMemberS{Value: target}
+	return revision, nil
+}
+
+// revisionKey returns the sort key of the revision of the entity with the sort key target.
+func revisionKey(target, id string) string {
+	return target + RevisionSuffix + id
+}
+
+// isRevisionKey reports whether an item with the keys source and target is a revision of
+// the entity in the partition source.
+func isRevisionKey(source, target string) bool {
+	id, ok := strings.CutPrefix(target, revisionKey(source, ""))
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(revisionIDFormat, id)
+	return err == nil
+}
@@ -0,0 +1,238 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// historyClient queries revisions by sort key prefix and returns the new image of updates.
+type historyClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *historyClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	var hk, prefix string
+	for _, value := range params.ExpressionAttributeValues {
+		if s, ok := value.(*types.AttributeValueMemberS); ok {
+			if strings.Contains(s.Value, RevisionSuffix) {
+				prefix = s.Value
+			} else {
+				hk = s.Value
+			}
+		}
+	}
+
+	var items []Item
+	for _, key := range slices.Sorted(maps.Keys(c.items)) {
+		item := c.items[key]
+		if StringValue(item["hk"]) == hk && strings.HasPrefix(StringValue(item["sk"]), prefix) {
+			items = append(items, item)
+		}
+	}
+	if !aws.ToBool(params.ScanIndexForward) {
+		slices.Reverse(items)
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func (c *historyClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	key := StringValue(params.Key["hk"]) + "#" + StringValue(params.Key["sk"])
+	item := maps.Clone(c.items[key])
+	item["updated_at"] = params.ExpressionAttributeValues[":0"]
+	c.items[key] = item
+
+	output := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllNew {
+		output.Attributes = item
+	}
+	return output, nil
+}
+
+// Tests for revision history
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	newTable := func() (*Table, *historyClient) {
+		table := NewTable("test-table")
+		table.History = true
+		return table, &historyClient{newMockDynamoDBClient()}
+	}
+
+	t.Run("puts write revisions", func(t *testing.T) {
+		table, client := newTable()
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "books"}, fixedClock(first)); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "games"}, fixedClock(second)); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		var product Product
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &product); err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if product.Category != "games" {
+			t.Errorf("Expected the latest product, got %+v", product)
+		}
+
+		revisions, err := table.QueryHistory(ctx, client, &Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to query history: %v", err)
+		}
+		if len(revisions) != 2 {
+			t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+		}
+		if !revisions[0].CreatedAt.Equal(second) || !revisions[1].CreatedAt.Equal(first) {
+			t.Errorf("Expected revisions newest first, got %v and %v", revisions[0].CreatedAt, revisions[1].CreatedAt)
+		}
+
+		var oldest Product
+		rel, err := revisions[1].Unmarshal(&oldest)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal revision: %v", err)
+		}
+		if oldest.Category != "books" {
+			t.Errorf("Expected the first category, got %+v", oldest)
+		}
+		if !rel.IsSelf() || rel.Label != "" {
+			t.Errorf("Expected an unlabeled self relationship, got %+v", rel)
+		}
+
+		var revised Product
+		if _, err := table.GetRevision(ctx, client, &Product{ID: "P1"}, revisions[1].ID, &revised); err != nil {
+			t.Fatalf("Failed to get revision: %v", err)
+		}
+		if revised.Category != "books" {
+			t.Errorf("Expected the first category, got %+v", revised)
+		}
+
+		limited, err := table.QueryHistory(ctx, client, &Product{ID: "P1"}, func(ho *HistoryOptions) {
+			ho.Limit = 1
+			ho.Ascending = true
+		})
+		if err != nil {
+			t.Fatalf("Failed to query history: %v", err)
+		}
+		if len(limited) != 1 || limited[0].ID != revisions[1].ID {
+			t.Errorf("Expected the oldest revision, got %+v", limited)
+		}
+	})
+
+	t.Run("revisions are not part of the entity", func(t *testing.T) {
+		table, client := newTable()
+		if err := table.Put(ctx, client, &Order{ID: "O1"}); err != nil {
+			t.Fatalf("Failed to put order: %v", err)
+		}
+
+		var items []Item
+		for _, item := range client.items {
+			if _, ok := item[AttributeNameLabel]; !ok && !strings.Contains(StringValue(item["sk"]), RevisionSuffix) {
+				t.Errorf("Expected unlabeled items to be revisions, got %v", item)
+			}
+			items = append(items, item)
+		}
+		if len(items) != 2 {
+			t.Fatalf("Expected the order and its revision, got %d items", len(items))
+		}
+
+		var order Order
+		relationships, err := UnmarshalEntity(items, &order, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(relationships) != 1 {
+			t.Errorf("Expected only the self relationship, got %d", len(relationships))
+		}
+	})
+
+	t.Run("refs with revision suffix are part of the entity", func(t *testing.T) {
+		table, _ := newTable()
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "rev#2026-01-01T00:00:00.000000000Z"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		var items []Item
+		for _, request := range batches[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		var order Order
+		if _, err := UnmarshalEntity(items, &order, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(order.Products) != 1 {
+			t.Errorf("Expected the product ref, got %+v", order.Products)
+		}
+	})
+
+	t.Run("updates write revisions", func(t *testing.T) {
+		table, client := newTable()
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "books"}, fixedClock(first)); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		output, err := table.Update(ctx, client, &Product{ID: "P1"}, Increment("stock", 1), fixedClock(second), func(mo *MarshalOptions) {
+			mo.ReturnValues = types.ReturnValueNone
+		})
+		if err != nil {
+			t.Fatalf("Failed to update product: %v", err)
+		}
+		if output.Attributes != nil {
+			t.Errorf("Expected no attributes returned, got %v", output.Attributes)
+		}
+
+		revisions, err := table.QueryHistory(ctx, client, &Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to query history: %v", err)
+		}
+		if len(revisions) != 2 || !revisions[0].CreatedAt.Equal(second) {
+			t.Fatalf("Expected the update revision first, got %+v", revisions)
+		}
+	})
+
+	t.Run("updates returning old values are rejected", func(t *testing.T) {
+		table, client := newTable()
+		_, err := table.Update(ctx, client, &Product{ID: "P1"}, Increment("stock", 1), func(mo *MarshalOptions) {
+			mo.ReturnValues = types.ReturnValueAllOld
+		})
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("revisions expire", func(t *testing.T) {
+		table, client := newTable()
+		table.HistoryTTL = 24 * time.Hour
+		if err := table.Put(ctx, client, &Product{ID: "P1"}, fixedClock(first)); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		revisions, err := table.QueryHistory(ctx, client, &Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to query history: %v", err)
+		}
+		if len(revisions) != 1 || !revisions[0].Expires.Equal(first.Add(24*time.Hour)) {
+			t.Errorf("Expected the revision to expire after a day, got %+v", revisions)
+		}
+	})
+
+	t.Run("missing revision", func(t *testing.T) {
+		table, client := newTable()
+		_, err := table.GetRevision(ctx, client, &Product{ID: "P1"}, first.Format(revisionIDFormat), &Product{})
+		if err != ErrItemNotFound {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
+}
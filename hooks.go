@@ -0,0 +1,54 @@
+package dynamap
+
+import "fmt"
+
+// BeforeMarshalHook is implemented by entities that prepare themselves before they are
+// written, such as to normalize fields, compute derived sort keys or check invariants.
+// [MarshalRelationships] calls BeforeMarshal before [Marshaler.MarshalSelf], so the self
+// and ref relationships see the prepared entity. It is not called when an entity is only
+// marshaled for its key, such as by [Table.Get] and [Table.Delete].
+//
+// Example:
+//
+//	func (u *User) BeforeMarshal() error {
+//		u.Email = strings.ToLower(u.Email)
+//		if u.Email == "" {
+//			return errors.New("email is required")
+//		}
+//		return nil
+//	}
+type BeforeMarshalHook interface {
+	BeforeMarshal() error
+}
+
+// AfterUnmarshalHook is implemented by entities that complete themselves after they are
+// read, such as to fill derived fields or check invariants. [UnmarshalSelf] calls
+// AfterUnmarshal after [Unmarshaler.UnmarshalSelf], and [UnmarshalEntity] calls it once
+// all relationships have been applied.
+type AfterUnmarshalHook interface {
+	AfterUnmarshal() error
+}
+
+// beforeMarshal calls the [BeforeMarshalHook] of in, if it implements one.
+func beforeMarshal(in any) error {
+	hook, ok := in.(BeforeMarshalHook)
+	if !ok {
+		return nil
+	}
+	if err := hook.BeforeMarshal(); err != nil {
+		return fmt.Errorf("failed to run before marshal hook: %w", err)
+	}
+	return nil
+}
+
+// afterUnmarshal calls the [AfterUnmarshalHook] of out, if it implements one.
+func afterUnmarshal(out any) error {
+	hook, ok := out.(AfterUnmarshalHook)
+	if !ok {
+		return nil
+	}
+	if err := hook.AfterUnmarshal(); err != nil {
+		return fmt.Errorf("failed to run after unmarshal hook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package dynamap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// hookedOrder normalizes its buyer before it is marshaled and counts its products after
+// it is unmarshaled.
+type hookedOrder struct {
+	Order
+	ProductCount int `dynamodbav:"-"`
+}
+
+func (o *hookedOrder) BeforeMarshal() error {
+	if o.PurchasedBy == "" {
+		return errors.New("purchased_by is required")
+	}
+	o.PurchasedBy = strings.ToLower(o.PurchasedBy)
+	return nil
+}
+
+func (o *hookedOrder) AfterUnmarshal() error {
+	o.ProductCount = len(o.Products)
+	return nil
+}
+
+// Tests for entity hooks
+
+func TestEntityHooks(t *testing.T) {
+	t.Run("before marshal", func(t *testing.T) {
+		order := &hookedOrder{Order: Order{ID: "O1", PurchasedBy: "User1", Products: []Product{{ID: "P1"}}}}
+		relationships, err := MarshalRelationships(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal relationships: %v", err)
+		}
+		data, ok := relationships[0].Data.(*hookedOrder)
+		if !ok || data.PurchasedBy != "user1" {
+			t.Errorf("Expected the normalized buyer, got %+v", relationships[0].Data)
+		}
+
+		if _, err := MarshalRelationships(&hookedOrder{Order: Order{ID: "O2"}}); err == nil {
+			t.Error("Expected the hook error")
+		}
+	})
+
+	t.Run("after unmarshal", func(t *testing.T) {
+		order := &hookedOrder{Order: Order{ID: "O1", PurchasedBy: "user1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}}
+		inputs, err := NewTable("test-table").MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		var items []Item
+		for _, request := range inputs[0].RequestItems["test-table"] {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		var entity hookedOrder
+		if _, err := UnmarshalEntity(items, &entity); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if entity.ProductCount != 2 {
+			t.Errorf("Expected 2 products counted, got %d", entity.ProductCount)
+		}
+
+		var self hookedOrder
+		self.Products = []Product{{ID: "P1"}}
+		if _, err := UnmarshalSelf(items[0], &self); err != nil {
+			t.Fatalf("Failed to unmarshal self: %v", err)
+		}
+		if self.ProductCount != 1 {
+			t.Errorf("Expected the hook to run, got %d", self.ProductCount)
+		}
+	})
+}
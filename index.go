@@ -0,0 +1,135 @@
+package dynamap
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrUnknownIndex is returned when an entity or query refers to a secondary index the
+// table does not declare.
+var ErrUnknownIndex = newError(ErrValidation, "unknown secondary index")
+
+// SecondaryIndex declares an additional global secondary index on the table. Entities
+// populate its key attributes with [MarshalOptions.WithIndex].
+type SecondaryIndex struct {
+	Name         string // The index name
+	PartitionKey string // The partition key attribute, e.g. "gsi2_pk"
+	SortKey      string // Optional sort key attribute, e.g. "gsi2_sk"
+}
+
+// IndexKey is the partition and sort key of an item on a secondary index.
+type IndexKey struct {
+	PartitionKey string // The partition key value
+	SortKey      string // Optional sort key value
+}
+
+// WithIndex sets the key of the entity on the named secondary index. Only the self
+// relationship is written to secondary indexes; refs are not.
+//
+// Example:
+//
+//	func (c *Customer) MarshalSelf(opts *dynamap.MarshalOptions) error {
+//		opts.WithSelfTarget("customer", c.ID)
+//		opts.WithIndex("by-email", "email#"+c.Email, "")
+//		return nil
+//	}
+func (mo *MarshalOptions) WithIndex(name, partitionKey, sortKey string) *MarshalOptions {
+	if mo.SecondaryIndexes == nil {
+		mo.SecondaryIndexes = make(map[string]IndexKey)
+	}
+	mo.SecondaryIndexes[name] = IndexKey{PartitionKey: partitionKey, SortKey: sortKey}
+	return mo
+}
+
+// index returns the table's declaration of the named secondary index.
+func (mo MarshalOptions) index(name string) (SecondaryIndex, error) {
+	for _, index := range mo.Indexes {
+		if index.Name == name {
+			return index, nil
+		}
+	}
+	return SecondaryIndex{}, fmt.Errorf("%w: %s", ErrUnknownIndex, name)
+}
+
+// indexItem adds the secondary index key attributes in keys to item. Partition keys are
+// scoped to the tenant, if one is set.
+func (mo MarshalOptions) indexItem(item Item, keys map[string]IndexKey) error {
+	for name, key := range keys {
+		index, err := mo.index(name)
+		if err != nil {
+			return err
+		}
+
+		item[index.PartitionKey] = &types.AttributeValueMemberS{Value: mo.tenantLabel(key.PartitionKey)}
+		if index.SortKey != "" && key.SortKey != "" {
+			item[index.SortKey] = &types.AttributeValueMemberS{Value: key.SortKey}
+		}
+	}
+	return nil
+}
+
+// QueryIndex is a QueryMarshaler that searches one of the table's secondary indexes,
+// for example to look up a customer by email.
+//
+// Example:
+//
+//	input, err := table.MarshalQuery(&dynamap.QueryIndex{
+//		Index:        "by-email",
+//		PartitionKey: "email#jane@example.com",
+//	})
+type QueryIndex struct {
+	Index           string                         // The secondary index name
+	PartitionKey    string                         // The index partition key value
+	SortKeyFilter   expression.KeyConditionBuilder // Optional filters on the index sort key attribute
+	ConditionFilter expression.ConditionBuilder    // Optional filters on the relationship
+	Limit           int                            // Maximum number of items to return
+	StartKey        Item                           // Exclusive start key for pagination
+	SortDescending  bool                           // If true, scans backward
+}
+
+// MarshalQuery implements QueryMarshaler for QueryIndex.
+func (q *QueryIndex) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	index, err := opts.index(q.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	keyCondition := expression.Key(index.PartitionKey).Equal(expression.Value(opts.tenantLabel(q.PartitionKey)))
+	if q.SortKeyFilter.IsSet() {
+		keyCondition = keyCondition.And(q.SortKeyFilter)
+	}
+
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+	if q.ConditionFilter.IsSet() {
+		builder = builder.WithFilter(q.ConditionFilter)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ScanIndexForward:          aws.Bool(!q.SortDescending),
+		ExclusiveStartKey:         q.StartKey,
+	}
+
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(int32(q.Limit))
+	}
+
+	return input, nil
+}
+
+// UseIndex implements QueryMarshaler for QueryIndex.
+func (q *QueryIndex) UseIndex(*Table) string {
+	return q.Index
+}
@@ -0,0 +1,130 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type customer struct {
+	ID     string    `dynamodbav:"id"`
+	Email  string    `dynamodbav:"email"`
+	Region string    `dynamodbav:"region"`
+	Orders []Product `dynamodbav:"-"`
+}
+
+func (c *customer) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("customer", c.ID)
+	opts.WithIndex("by-email", "email#"+c.Email, "")
+	opts.WithIndex("by-region", "region#"+c.Region, c.ID)
+	return nil
+}
+
+func (c *customer) MarshalRefs(ctx *RelationshipContext) error {
+	for i := range c.Orders {
+		ctx.AddOne("orders", &c.Orders[i])
+	}
+	return nil
+}
+
+func newIndexedTable() *Table {
+	table := NewTable("test-table")
+	table.Indexes = []SecondaryIndex{
+		{Name: "by-email", PartitionKey: "gsi2_pk"},
+		{Name: "by-region", PartitionKey: "gsi3_pk", SortKey: "gsi3_sk"},
+	}
+	return table
+}
+
+// Tests for secondary indexes
+
+func TestSecondaryIndexes(t *testing.T) {
+	c := &customer{ID: "C1", Email: "jane@example.com", Region: "us", Orders: []Product{{ID: "P1"}}}
+
+	t.Run("self item", func(t *testing.T) {
+		input, err := newIndexedTable().MarshalPut(c)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		if pk := input.Item["gsi2_pk"].(*types.AttributeValueMemberS).Value; pk != "email#jane@example.com" {
+			t.Errorf("Expected gsi2_pk 'email#jane@example.com', got %s", pk)
+		}
+		if sk := input.Item["gsi3_sk"].(*types.AttributeValueMemberS).Value; sk != "C1" {
+			t.Errorf("Expected gsi3_sk 'C1', got %s", sk)
+		}
+		if _, ok := input.Item["gsi2_sk"]; ok {
+			t.Error("Expected no sort key for index without one")
+		}
+	})
+
+	t.Run("refs not indexed", func(t *testing.T) {
+		batches, err := newIndexedTable().MarshalBatch(c)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		ref := batches[0].RequestItems["test-table"][1].PutRequest.Item
+		if _, ok := ref["gsi2_pk"]; ok {
+			t.Error("Expected ref item without secondary index keys")
+		}
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		table := newIndexedTable()
+		table.TenantID = "t1"
+
+		input, err := table.MarshalPut(c)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if pk := input.Item["gsi2_pk"].(*types.AttributeValueMemberS).Value; pk != "t1|email#jane@example.com" {
+			t.Errorf("Expected tenant scoped partition key, got %s", pk)
+		}
+	})
+
+	t.Run("undeclared index", func(t *testing.T) {
+		if _, err := NewTable("test-table").MarshalPut(c); !errors.Is(err, ErrUnknownIndex) {
+			t.Errorf("Expected ErrUnknownIndex, got %v", err)
+		}
+	})
+}
+
+func TestQueryIndex(t *testing.T) {
+	table := newIndexedTable()
+
+	t.Run("query", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryIndex{
+			Index:         "by-region",
+			PartitionKey:  "region#us",
+			SortKeyFilter: expression.Key("gsi3_sk").BeginsWith("C"),
+			Limit:         5,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		if *input.IndexName != "by-region" {
+			t.Errorf("Expected index 'by-region', got %s", *input.IndexName)
+		}
+		if *input.Limit != 5 {
+			t.Errorf("Expected limit 5, got %d", *input.Limit)
+		}
+
+		var found bool
+		for _, name := range input.ExpressionAttributeNames {
+			found = found || name == "gsi3_pk"
+		}
+		if !found {
+			t.Error("Expected key condition on gsi3_pk")
+		}
+	})
+
+	t.Run("unknown index", func(t *testing.T) {
+		if _, err := table.MarshalQuery(&QueryIndex{Index: "by-name"}); !errors.Is(err, ErrUnknownIndex) {
+			t.Errorf("Expected ErrUnknownIndex, got %v", err)
+		}
+	})
+}
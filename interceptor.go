@@ -0,0 +1,197 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Operation names the DynamoDB request seen by an [Interceptor].
+type Operation string
+
+const (
+	OperationPut           Operation = "PutItem"
+	OperationGet           Operation = "GetItem"
+	OperationQuery         Operation = "Query"
+	OperationUpdate        Operation = "UpdateItem"
+	OperationDelete        Operation = "DeleteItem"
+	OperationBatchGet      Operation = "BatchGetItem"
+	OperationBatchWrite    Operation = "BatchWriteItem"
+	OperationTransactWrite Operation = "TransactWriteItems"
+	OperationScan          Operation = "Scan"
+)
+
+// CacheStatus reports how a caching interceptor handled a request.
+type CacheStatus string
+
+const (
+	CacheHit  CacheStatus = "hit"  // The request was answered from the cache
+	CacheMiss CacheStatus = "miss" // The request was cacheable but executed
+)
+
+// Request is a DynamoDB request passing through an interceptor chain.
+type Request struct {
+	Operation Operation   // The DynamoDB operation
+	Input     any         // The request input, such as *dynamodb.PutItemInput. Before may replace it with an input of the same type.
+	Output    any         // The request output, such as *dynamodb.PutItemOutput. Set after the request succeeds.
+	Err       error       // The error returned by the request or an interceptor
+	Started   time.Time   // The time the request entered the chain
+	Cache     CacheStatus // How a caching interceptor handled the request, or empty if no cache applied. Visible to the After of interceptors registered before the cache.
+}
+
+// Interceptor runs cross-cutting logic, such as tenant enforcement, caching, metrics or
+// auditing, around every request a [Table] or [Client] executes.
+//
+// Before is called in registration order before the request executes. It may modify
+// the input, return an error to reject the request, or set [Request.Output] to answer
+// the request without executing it. After is called in reverse order once the request
+// completes, for every interceptor whose Before succeeded; it may inspect or replace the
+// output and error.
+type Interceptor interface {
+	Before(ctx context.Context, req *Request) error
+	After(ctx context.Context, req *Request)
+}
+
+// InterceptorFuncs adapts functions to an [Interceptor]. Either function may be nil.
+//
+// Example:
+//
+//	table.Interceptors = append(table.Interceptors, dynamap.InterceptorFuncs{
+//		AfterFunc: func(ctx context.Context, req *dynamap.Request) {
+//			metrics.Observe(string(req.Operation), time.Since(req.Started))
+//		},
+//	})
+type InterceptorFuncs struct {
+	BeforeFunc func(ctx context.Context, req *Request) error
+	AfterFunc  func(ctx context.Context, req *Request)
+}
+
+// Before calls BeforeFunc, if set.
+func (f InterceptorFuncs) Before(ctx context.Context, req *Request) error {
+	if f.BeforeFunc == nil {
+		return nil
+	}
+	return f.BeforeFunc(ctx, req)
+}
+
+// After calls AfterFunc, if set.
+func (f InterceptorFuncs) After(ctx context.Context, req *Request) {
+	if f.AfterFunc != nil {
+		f.AfterFunc(ctx, req)
+	}
+}
+
+// intercept runs the request through the interceptors, executing it with call unless
+// an interceptor rejects or answers it.
+func intercept[In, Out any](ctx context.Context, interceptors []Interceptor, op Operation, input In, call func(In) (Out, error)) (Out, error) {
+	req := &Request{Operation: op, Input: input, Started: time.Now()}
+
+	ran := 0
+	for _, interceptor := range interceptors {
+		if req.Err = interceptor.Before(ctx, req); req.Err != nil {
+			break
+		}
+		ran++
+		if req.Output != nil {
+			break
+		}
+	}
+
+	if req.Err == nil && req.Output == nil {
+		if in, ok := req.Input.(In); !ok {
+			req.Err = fmt.Errorf("interceptor replaced %s input with %T", op, req.Input)
+		} else if out, err := call(in); err != nil {
+			req.Err = err
+		} else {
+			req.Output = out
+		}
+	}
+
+	for i := ran - 1; i >= 0; i-- {
+		interceptors[i].After(ctx, req)
+	}
+
+	out, ok := req.Output.(Out)
+	if !ok && req.Output != nil && req.Err == nil {
+		req.Err = fmt.Errorf("interceptor answered %s with %T", op, req.Output)
+	}
+	return out, req.Err
+}
+
+// interceptClient runs the requests of a table through its interceptors.
+type interceptClient struct {
+	next         DynamoDBClient
+	table        *Table
+	interceptors []Interceptor
+}
+
+// client returns client wrapped to run its requests through the table interceptors and
+// logger, if any. Clients already wrapped by the table are returned as is.
+func (t *Table) client(client DynamoDBClient) DynamoDBClient {
+	if c, ok := client.(*interceptClient); ok && c.table == t {
+		return client
+	}
+
+	interceptors := t.Interceptors
+	if t.Logger != nil {
+		interceptors = append(interceptors[:len(interceptors):len(interceptors)], logInterceptor{table: t})
+	}
+	if t.KeyNames.IsSet() {
+		interceptors = append(interceptors[:len(interceptors):len(interceptors)], keyNamesInterceptor{table: t})
+	}
+	if len(interceptors) == 0 {
+		return client
+	}
+
+	return &interceptClient{next: client, table: t, interceptors: interceptors}
+}
+
+func (c *interceptClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationPut, params, func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		return c.next.PutItem(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationGet, params, func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return c.next.GetItem(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return intercept(ctx, c.interceptors, OperationQuery, params, func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		return c.next.Query(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationUpdate, params, func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		return c.next.UpdateItem(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationDelete, params, func(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+		return c.next.DeleteItem(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationBatchGet, params, func(in *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		return batchGetItem(ctx, c.next, in, optFns...)
+	})
+}
+
+func (c *interceptClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return intercept(ctx, c.interceptors, OperationBatchWrite, params, func(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		return c.next.BatchWriteItem(ctx, in, optFns...)
+	})
+}
+
+func (c *interceptClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return intercept(ctx, c.interceptors, OperationTransactWrite, params, func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		return transactWriteItems(ctx, c.next, in, optFns...)
+	})
+}
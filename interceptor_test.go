@@ -0,0 +1,140 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// recordingInterceptor appends its name and the operation to calls before and after each request.
+func recordingInterceptor(name string, calls *[]string) Interceptor {
+	return InterceptorFuncs{
+		BeforeFunc: func(ctx context.Context, req *Request) error {
+			*calls = append(*calls, fmt.Sprintf("%s before %s", name, req.Operation))
+			return nil
+		},
+		AfterFunc: func(ctx context.Context, req *Request) {
+			*calls = append(*calls, fmt.Sprintf("%s after %s", name, req.Operation))
+		},
+	}
+}
+
+// Tests for interceptors
+
+func TestTableInterceptors(t *testing.T) {
+	ctx := context.Background()
+	product := &Product{ID: "P1", Category: "widgets"}
+
+	t.Run("order", func(t *testing.T) {
+		var calls []string
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{recordingInterceptor("a", &calls), recordingInterceptor("b", &calls)}
+
+		if err := table.Put(ctx, newMockDynamoDBClient(), product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		want := []string{"a before PutItem", "b before PutItem", "b after PutItem", "a after PutItem"}
+		if !slices.Equal(calls, want) {
+			t.Errorf("Expected %v, got %v", want, calls)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		var calls []string
+		errDenied := errors.New("denied")
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{
+			recordingInterceptor("a", &calls),
+			InterceptorFuncs{BeforeFunc: func(ctx context.Context, req *Request) error { return errDenied }},
+			recordingInterceptor("c", &calls),
+		}
+
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, product); !errors.Is(err, errDenied) {
+			t.Fatalf("Expected denied error, got %v", err)
+		}
+		if len(client.items) != 0 {
+			t.Error("Expected the request not to execute")
+		}
+		if want := []string{"a before PutItem", "a after PutItem"}; !slices.Equal(calls, want) {
+			t.Errorf("Expected %v, got %v", want, calls)
+		}
+	})
+
+	t.Run("answer", func(t *testing.T) {
+		stored := newMockDynamoDBClient()
+		if err := NewTable("test-table").Put(ctx, stored, product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{InterceptorFuncs{
+			BeforeFunc: func(ctx context.Context, req *Request) error {
+				req.Output = &dynamodb.GetItemOutput{Item: stored.items["product#P1#product#P1"]}
+				return nil
+			},
+		}}
+
+		var got Product
+		if _, err := table.Get(ctx, newMockDynamoDBClient(), &Product{ID: "P1"}, &got); err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if got.Category != "widgets" {
+			t.Errorf("Expected answered product, got %+v", got)
+		}
+	})
+
+	t.Run("wrong answer", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{InterceptorFuncs{
+			BeforeFunc: func(ctx context.Context, req *Request) error {
+				req.Output = &dynamodb.QueryOutput{}
+				return nil
+			},
+		}}
+
+		if err := table.Put(ctx, newMockDynamoDBClient(), product); err == nil {
+			t.Error("Expected an error for an output of the wrong type")
+		}
+	})
+
+	t.Run("modify input", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{InterceptorFuncs{
+			BeforeFunc: func(ctx context.Context, req *Request) error {
+				if in, ok := req.Input.(*dynamodb.PutItemInput); ok {
+					in.Item["audited"] = &types.AttributeValueMemberBOOL{Value: true}
+				}
+				return nil
+			},
+		}}
+
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		if _, ok := client.items["product#P1#product#P1"]["audited"]; !ok {
+			t.Error("Expected the interceptor to modify the item")
+		}
+	})
+
+	t.Run("nested calls", func(t *testing.T) {
+		var calls []string
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{recordingInterceptor("a", &calls)}
+
+		client := table.client(newMockDynamoDBClient())
+		if err := table.Put(ctx, client, product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		if len(calls) != 2 {
+			t.Errorf("Expected the request to be intercepted once, got %v", calls)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package dynamap
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// marshalItem marshals in to a DynamoDB item, encoding the data attribute and renaming
+// aliased attributes.
+func (mo MarshalOptions) marshalItem(in any) (Item, error) {
+	if err := mo.validateAliases(); err != nil {
+		return nil, err
+	}
+
+	item, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if rel, ok := in.(Relationship); ok {
+		omitZeroTimes(item, rel)
+		if err := mo.indexItem(item, rel.SecondaryIndexes); err != nil {
+			return nil, err
+		}
+		if err := mo.sparseItem(item, rel.SparseAttributes); err != nil {
+			return nil, err
+		}
+		if data, ok := item[AttributeNameData]; ok && rel.IsSelf() {
+			item[AttributeNameData] = scrubData(data, mo.transientFields(rel.Data))
+		}
+	}
+
+	mo.refIndexHashItem(item)
+	if err := mo.refNameItem(item); err != nil {
+		return nil, err
+	}
+
+	if err := mo.encodeTimestamps(item); err != nil {
+		return nil, err
+	}
+
+	if data, ok := item[AttributeNameData]; ok && mo.DataCodec != nil {
+		if item[AttributeNameData], err = mo.DataCodec.Encode(data); err != nil {
+			return nil, fmt.Errorf("failed to encode data: %w", err)
+		}
+	}
+
+	return mo.aliasItem(item), nil
+}
+
+// unmarshalItem returns a copy of item with aliased attributes restored to their original
+// names and the data attribute decoded. The provided item is not modified.
+func (mo MarshalOptions) unmarshalItem(item Item) (Item, error) {
+	item, err := mo.decodeTimestamps(mo.unaliasItem(item))
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := item[AttributeNameData]
+	if !ok || mo.DataCodec == nil {
+		return item, nil
+	}
+
+	decoded, err := mo.DataCodec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	item = maps.Clone(item)
+	item[AttributeNameData] = decoded
+	return item, nil
+}
+
+// omitZeroTimes removes the timestamps and expiration of rel that are not set from its
+// marshaled item, so that items without a time to live are never written with an
+// expiration in the past.
+func omitZeroTimes(item Item, rel Relationship) {
+	if rel.CreatedAt.IsZero() {
+		delete(item, AttributeNameCreated)
+	}
+	if rel.UpdatedAt.IsZero() {
+		delete(item, AttributeNameUpdated)
+	}
+	if rel.Expires.IsZero() {
+		delete(item, AttributeNameExpires)
+	}
+}
@@ -0,0 +1,21 @@
+// Package jsonapi encodes the results of dynamap queries as JSON:API documents, for API
+// layers that speak JSON:API.
+//
+// [MarshalDocument] turns the relationships of a QueryEntity result into a document whose
+// primary data is the entity, with its relationships as resource linkage and the
+// denormalized snapshots of its targets as included resources:
+//
+//	var order Order
+//	relationships, err := dynamap.UnmarshalEntity(output.Items, &order, table.MarshalOptions)
+//	if err != nil {
+//		return err
+//	}
+//	document, err := jsonapi.MarshalDocument(relationships, table.MarshalOptions)
+//	if err != nil {
+//		return err
+//	}
+//	return json.NewEncoder(w).Encode(document)
+//
+// [MarshalListDocument] does the same for QueryList results, with a list of entities as
+// primary data.
+package jsonapi
@@ -0,0 +1,228 @@
+package jsonapi
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/nisimpson/dynamap"
+)
+
+// Document is a JSON:API top-level document.
+type Document struct {
+	Data     any            `json:"data"`               // The primary data: a *Resource, []*Resource or nil
+	Included []*Resource    `json:"included,omitempty"` // Resources related to the primary data
+	Meta     map[string]any `json:"meta,omitempty"`     // Non-standard information, such as the cursor of the next page
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    map[string]any          `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+}
+
+// Relationship is a JSON:API relationship object. Since dynamap does not record whether
+// a relationship is to-one or to-many, its data is always an array.
+type Relationship struct {
+	Data []ResourceIdentifier `json:"data"`
+}
+
+// ResourceIdentifier is a JSON:API resource identifier object. The edge data of the
+// relationship, if any, is its meta.
+type ResourceIdentifier struct {
+	Type string         `json:"type"`
+	ID   string         `json:"id"`
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// MarshalDocument creates a document from the relationships of a single entity, such as
+// the result of [dynamap.UnmarshalEntity]. The self relationship is the primary data; the
+// data is null if it is missing. Ref relationships become the resource linkage of the
+// entity, and targets with a snapshot are included. The options are used to parse keys
+// and labels, and should match those the relationships were unmarshaled with.
+func MarshalDocument(relationships []dynamap.Relationship, opts ...func(*dynamap.MarshalOptions)) (*Document, error) {
+	builder, err := build(relationships, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &Document{Included: builder.included()}
+	switch len(builder.primary) {
+	case 0:
+	case 1:
+		document.Data = builder.primary[0]
+	default:
+		return nil, fmt.Errorf("expected the relationships of one entity, got %d entities", len(builder.primary))
+	}
+	return document, nil
+}
+
+// MarshalListDocument creates a document from the relationships of a list of entities,
+// such as the result of [dynamap.UnmarshalList]. Each self relationship is a resource of
+// the primary data, in order. Ref relationships are handled as in [MarshalDocument].
+func MarshalListDocument(relationships []dynamap.Relationship, opts ...func(*dynamap.MarshalOptions)) (*Document, error) {
+	builder, err := build(relationships, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := builder.primary
+	if primary == nil {
+		primary = []*Resource{}
+	}
+	return &Document{Data: primary, Included: builder.included()}, nil
+}
+
+// builder collects the resources of a document.
+type builder struct {
+	opts      func(*dynamap.MarshalOptions)
+	primary   []*Resource
+	resources map[string]*Resource // All resources with attributes, by key
+	sources   map[string]*Resource // Resources holding linkage, by source key
+	snapshots []string             // Keys of included resources, in order
+}
+
+// build adds each relationship to a new builder.
+func build(relationships []dynamap.Relationship, opts []func(*dynamap.MarshalOptions)) (*builder, error) {
+	b := &builder{
+		opts: func(mo *dynamap.MarshalOptions) {
+			for _, opt := range opts {
+				opt(mo)
+			}
+		},
+		resources: make(map[string]*Resource),
+		sources:   make(map[string]*Resource),
+	}
+
+	// Self relationships first, so linkage is added to the primary resources
+	for _, rel := range relationships {
+		if rel.IsSelf() {
+			if err := b.addSelf(rel); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, rel := range relationships {
+		if rel.IsRef() {
+			if err := b.addRef(rel); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// addSelf adds the self relationship as a primary resource.
+func (b *builder) addSelf(rel dynamap.Relationship) error {
+	resource, err := b.resource(rel.Source)
+	if err != nil {
+		return err
+	}
+	if resource.Attributes, err = attributes(rel.Data); err != nil {
+		return fmt.Errorf("failed to convert data of %s: %w", rel.Source, err)
+	}
+
+	b.primary = append(b.primary, resource)
+	b.resources[rel.Source] = resource
+	b.sources[rel.Source] = resource
+	return nil
+}
+
+// addRef adds the ref relationship to the linkage of its source, and includes its target
+// if the relationship holds a snapshot.
+func (b *builder) addRef(rel dynamap.Relationship) error {
+	_, _, name, err := dynamap.ParseLabel(rel.Label, b.opts)
+	if err != nil {
+		return err
+	}
+
+	source, ok := b.sources[rel.Source]
+	if !ok {
+		if source, err = b.resource(rel.Source); err != nil {
+			return err
+		}
+		b.sources[rel.Source] = source
+	}
+	target, err := b.resource(rel.Target)
+	if err != nil {
+		return err
+	}
+
+	data, err := attributes(rel.Data)
+	if err != nil {
+		return fmt.Errorf("failed to convert data of %s %s: %w", rel.Source, rel.Target, err)
+	}
+
+	identifier := ResourceIdentifier{Type: target.Type, ID: target.ID}
+	if edge, ok := data["Edge"].(map[string]any); ok {
+		identifier.Meta = edge
+	}
+
+	if source.Relationships == nil {
+		source.Relationships = make(map[string]Relationship)
+	}
+	relationship := source.Relationships[name]
+	relationship.Data = append(relationship.Data, identifier)
+	source.Relationships[name] = relationship
+
+	if snapshot, ok := data["Snapshot"].(map[string]any); ok {
+		if _, ok := b.resources[rel.Target]; !ok {
+			target.Attributes = withoutIdentity(snapshot)
+			b.resources[rel.Target] = target
+			b.snapshots = append(b.snapshots, rel.Target)
+		}
+	}
+	return nil
+}
+
+// resource returns a new resource identified by the key.
+func (b *builder) resource(key string) (*Resource, error) {
+	prefix, id, err := dynamap.ParseKey(key, b.opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Resource{Type: prefix, ID: id}, nil
+}
+
+// included returns the included resources, in the order their snapshots were found.
+func (b *builder) included() []*Resource {
+	var included []*Resource
+	for _, key := range b.snapshots {
+		included = append(included, b.resources[key])
+	}
+	return included
+}
+
+// attributes converts relationship data into a generic map. Data unmarshaled from items
+// is already a map; typed data is converted as dynamap would store it.
+func attributes(data any) (map[string]any, error) {
+	switch data := data.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return withoutIdentity(data), nil
+	}
+
+	value, err := attributevalue.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var converted map[string]any
+	if err := attributevalue.Unmarshal(value, &converted); err != nil {
+		return nil, err
+	}
+	return withoutIdentity(converted), nil
+}
+
+// withoutIdentity returns a copy of attributes without the id and type members, which
+// JSON:API reserves for the resource identity.
+func withoutIdentity(attributes map[string]any) map[string]any {
+	copied := make(map[string]any, len(attributes))
+	for name, value := range attributes {
+		if name != "id" && name != "type" {
+			copied[name] = value
+		}
+	}
+	return copied
+}
@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nisimpson/dynamap"
+)
+
+type order struct {
+	ID       string   `dynamodbav:"id"`
+	Status   string   `dynamodbav:"status"`
+	Products []string `dynamodbav:"-"`
+}
+
+func (o *order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	return nil
+}
+
+func (o *order) MarshalRefs(ctx *dynamap.RelationshipContext) error {
+	for _, id := range o.Products {
+		ctx.AddOneWithData("products", &product{ID: id, Name: "Product " + id}, map[string]any{"quantity": 2})
+	}
+	return nil
+}
+
+type product struct {
+	ID   string `dynamodbav:"id"`
+	Name string `dynamodbav:"name"`
+}
+
+func (p *product) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("product", p.ID)
+	return nil
+}
+
+func (p *product) Snapshot() any {
+	return map[string]any{"name": p.Name}
+}
+
+// unmarshalRelationships marshals the entity to items and unmarshals them back, as a
+// query of the entity would return them.
+func unmarshalRelationships(t *testing.T, in dynamap.RefMarshaler) []dynamap.Relationship {
+	t.Helper()
+	inputs, err := dynamap.NewTable("test-table").MarshalBatch(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+	var relationships []dynamap.Relationship
+	for _, request := range inputs[0].RequestItems["test-table"] {
+		rel, err := dynamap.UnmarshalRelationship(request.PutRequest.Item)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal relationship: %v", err)
+		}
+		relationships = append(relationships, rel)
+	}
+	return relationships
+}
+
+func TestMarshalDocument(t *testing.T) {
+	t.Run("entity with refs", func(t *testing.T) {
+		relationships := unmarshalRelationships(t, &order{ID: "O1", Status: "paid", Products: []string{"P1", "P2"}})
+
+		document, err := MarshalDocument(relationships)
+		if err != nil {
+			t.Fatalf("Failed to marshal document: %v", err)
+		}
+
+		data, err := json.Marshal(document)
+		if err != nil {
+			t.Fatalf("Failed to encode document: %v", err)
+		}
+		var decoded struct {
+			Data struct {
+				Type          string         `json:"type"`
+				ID            string         `json:"id"`
+				Attributes    map[string]any `json:"attributes"`
+				Relationships map[string]struct {
+					Data []ResourceIdentifier `json:"data"`
+				} `json:"relationships"`
+			} `json:"data"`
+			Included []Resource `json:"included"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to decode document: %v", err)
+		}
+
+		if decoded.Data.Type != "order" || decoded.Data.ID != "O1" || decoded.Data.Attributes["status"] != "paid" {
+			t.Errorf("Expected the order as primary data, got %s", data)
+		}
+		if _, ok := decoded.Data.Attributes["id"]; ok {
+			t.Errorf("Expected the id to be removed from the attributes, got %v", decoded.Data.Attributes)
+		}
+		products := decoded.Data.Relationships["products"].Data
+		if len(products) != 2 || products[0].Type != "product" || products[0].Meta["quantity"] != float64(2) {
+			t.Errorf("Expected the product linkage with edge meta, got %+v", products)
+		}
+		if len(decoded.Included) != 2 || decoded.Included[0].Attributes["name"] != "Product "+decoded.Included[0].ID {
+			t.Errorf("Expected the product snapshots to be included, got %+v", decoded.Included)
+		}
+	})
+
+	t.Run("typed data", func(t *testing.T) {
+		relationships, err := dynamap.MarshalRelationships(&order{ID: "O1", Status: "paid", Products: []string{"P1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal relationships: %v", err)
+		}
+		document, err := MarshalDocument(relationships)
+		if err != nil {
+			t.Fatalf("Failed to marshal document: %v", err)
+		}
+		resource := document.Data.(*Resource)
+		if resource.Attributes["status"] != "paid" || len(resource.Relationships["products"].Data) != 1 || len(document.Included) != 1 {
+			t.Errorf("Expected the order and its product, got %+v %+v", resource, document.Included)
+		}
+	})
+
+	t.Run("missing entity", func(t *testing.T) {
+		document, err := MarshalDocument(nil)
+		if err != nil || document.Data != nil {
+			t.Errorf("Expected null data, got %+v, %v", document, err)
+		}
+	})
+
+	t.Run("several entities", func(t *testing.T) {
+		relationships := append(
+			unmarshalRelationships(t, &order{ID: "O1"}),
+			unmarshalRelationships(t, &order{ID: "O2"})...,
+		)
+		if _, err := MarshalDocument(relationships); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
+
+func TestMarshalListDocument(t *testing.T) {
+	relationships := append(
+		unmarshalRelationships(t, &order{ID: "O1", Status: "paid"}),
+		unmarshalRelationships(t, &order{ID: "O2", Status: "open"})...,
+	)
+
+	document, err := MarshalListDocument(relationships)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+	resources := document.Data.([]*Resource)
+	if len(resources) != 2 || resources[0].ID != "O1" || resources[1].Attributes["status"] != "open" {
+		t.Errorf("Expected both orders, got %+v", resources)
+	}
+
+	empty, err := MarshalListDocument(nil)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+	if data, _ := json.Marshal(empty); string(data) != `{"data":[]}` {
+		t.Errorf("Expected an empty list, got %s", data)
+	}
+}
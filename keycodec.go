@@ -0,0 +1,111 @@
+package dynamap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidKey is returned when a key cannot be decoded into a prefix and id.
+var ErrInvalidKey = newError(ErrValidation, "invalid key")
+
+// KeyCodec encodes entity prefixes and identifiers into hash and sort keys, and decodes
+// them back. Set [Table.KeyCodec] to integrate with existing key formats, such as versioned
+// keys or zero-padded numeric identifiers.
+//
+// Example:
+//
+//	type versionedCodec struct{}
+//
+//	func (versionedCodec) EncodeKey(prefix, id string) string {
+//		return strings.ToUpper(prefix) + "#" + id + "#v1"
+//	}
+//
+//	func (versionedCodec) DecodeKey(key string) (string, string, error) {
+//		parts := strings.Split(key, "#")
+//		if len(parts) != 3 {
+//			return "", "", dynamap.ErrInvalidKey
+//		}
+//		return strings.ToLower(parts[0]), parts[1], nil
+//	}
+type KeyCodec interface {
+	// EncodeKey joins the prefix and id into a key.
+	EncodeKey(prefix, id string) string
+	// DecodeKey splits the key into its prefix and id.
+	DecodeKey(key string) (prefix, id string, err error)
+}
+
+// DelimitedKeyCodec is the default [KeyCodec], which joins the prefix and id with a delimiter.
+type DelimitedKeyCodec struct {
+	Delimiter string // Delimiter between the prefix and id
+}
+
+// EncodeKey implements [KeyCodec].
+func (c DelimitedKeyCodec) EncodeKey(prefix, id string) string {
+	return prefix + c.Delimiter + id
+}
+
+// DecodeKey implements [KeyCodec]. The key is split on the first delimiter.
+func (c DelimitedKeyCodec) DecodeKey(key string) (prefix, id string, err error) {
+	prefix, id, ok := strings.Cut(key, c.Delimiter)
+	if !ok || prefix == "" || id == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return prefix, id, nil
+}
+
+// keyCodec returns the configured key codec, or a [DelimitedKeyCodec] using the key delimiter,
+// scoped to the tenant if one is set.
+func (mo MarshalOptions) keyCodec() KeyCodec {
+	var codec KeyCodec = DelimitedKeyCodec{Delimiter: mo.KeyDelimiter}
+	if mo.KeyCodec != nil {
+		codec = mo.KeyCodec
+	}
+	if prefix := mo.tenantPrefix(); prefix != "" {
+		codec = tenantKeyCodec{KeyCodec: codec, prefix: prefix}
+	}
+	return codec
+}
+
+// ParseKey decodes a hash or sort key such as "product#P1" into its prefix and id. Pass
+// [Table.MarshalOptions] to use the table's key codec or delimiter.
+//
+// Example:
+//
+//	prefix, id, err := dynamap.ParseKey(rel.Source, table.MarshalOptions)
+func ParseKey(key string, opts ...func(*MarshalOptions)) (prefix, id string, err error) {
+	return NewMarshalOptions(opts...).keyCodec().DecodeKey(key)
+}
+
+// EntityID returns the id of the entity referenced by the item's sort key. For self
+// relationships this is the id of the entity itself.
+func EntityID(item Item, opts ...func(*MarshalOptions)) (string, error) {
+	_, id, err := parseTarget(item, opts)
+	return id, err
+}
+
+// EntityPrefix returns the prefix of the entity referenced by the item's sort key. For self
+// relationships this is the prefix of the entity itself.
+func EntityPrefix(item Item, opts ...func(*MarshalOptions)) (string, error) {
+	prefix, _, err := parseTarget(item, opts)
+	return prefix, err
+}
+
+// EntityID returns the id of the entity referenced by the relationship target.
+func (r Relationship) EntityID(opts ...func(*MarshalOptions)) (string, error) {
+	_, id, err := ParseKey(r.Target, opts...)
+	return id, err
+}
+
+// EntityPrefix returns the prefix of the entity referenced by the relationship target.
+func (r Relationship) EntityPrefix(opts ...func(*MarshalOptions)) (string, error) {
+	prefix, _, err := ParseKey(r.Target, opts...)
+	return prefix, err
+}
+
+func parseTarget(item Item, opts []func(*MarshalOptions)) (prefix, id string, err error) {
+	_, target, err := UnmarshalTableKey(item)
+	if err != nil {
+		return "", "", err
+	}
+	return ParseKey(target, opts...)
+}
@@ -0,0 +1,132 @@
+package dynamap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// versionedCodec encodes keys as "PREFIX#ID#v1".
+type versionedCodec struct{}
+
+func (versionedCodec) EncodeKey(prefix, id string) string {
+	return fmt.Sprintf("%s#%s#v1", strings.ToUpper(prefix), id)
+}
+
+func (versionedCodec) DecodeKey(key string) (string, string, error) {
+	parts := strings.Split(key, "#")
+	if len(parts) != 3 {
+		return "", "", ErrInvalidKey
+	}
+	return strings.ToLower(parts[0]), parts[1], nil
+}
+
+// Tests for key codecs
+
+func TestKeyCodec(t *testing.T) {
+	t.Run("delimited codec", func(t *testing.T) {
+		codec := DelimitedKeyCodec{Delimiter: "#"}
+		if key := codec.EncodeKey("order", "O1"); key != "order#O1" {
+			t.Errorf("Expected 'order#O1', got %s", key)
+		}
+
+		prefix, id, err := codec.DecodeKey("order#O1#2")
+		if err != nil || prefix != "order" || id != "O1#2" {
+			t.Errorf("Expected 'order' and 'O1#2', got %s %s %v", prefix, id, err)
+		}
+
+		for _, key := range []string{"order", "#O1", "order#"} {
+			if _, _, err := codec.DecodeKey(key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+			}
+		}
+	})
+
+	t.Run("table codec", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.KeyCodec = versionedCodec{}
+
+		putInput, err := table.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if hk := putInput.Item["hk"].(*types.AttributeValueMemberS).Value; hk != "PRODUCT#P1#v1" {
+			t.Errorf("Expected hk 'PRODUCT#P1#v1', got %s", hk)
+		}
+
+		queryInput, err := table.MarshalQuery(&QueryEntity{Source: &Product{ID: "P1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if _, ok := findValue[*types.AttributeValueMemberS](queryInput.ExpressionAttributeValues); !ok {
+			t.Fatal("Expected key condition value")
+		}
+		for _, value := range queryInput.ExpressionAttributeValues {
+			if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value != "PRODUCT#P1#v1" {
+				t.Errorf("Expected key condition on 'PRODUCT#P1#v1', got %s", s.Value)
+			}
+		}
+	})
+
+	t.Run("registry uses codec", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.KeyCodec = versionedCodec{}
+		table.Registry = NewRegistry(EntitySchema{Prefix: "product"})
+
+		if _, err := table.MarshalPut(&Product{ID: "P1"}); err != nil {
+			t.Errorf("Expected valid relationship, got %v", err)
+		}
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	t.Run("default delimiter", func(t *testing.T) {
+		prefix, id, err := ParseKey("product#P1")
+		if err != nil || prefix != "product" || id != "P1" {
+			t.Errorf("Expected 'product' and 'P1', got %s %s %v", prefix, id, err)
+		}
+	})
+
+	t.Run("table delimiter", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.KeyDelimiter = "|"
+
+		prefix, id, err := ParseKey("product|P1", table.MarshalOptions)
+		if err != nil || prefix != "product" || id != "P1" {
+			t.Errorf("Expected 'product' and 'P1', got %s %s %v", prefix, id, err)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, _, err := ParseKey("product"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey, got %v", err)
+		}
+	})
+
+	t.Run("entity id from item and relationship", func(t *testing.T) {
+		rel := Relationship{Source: "order#O1", Target: "product#P1", Label: "order/O1/products"}
+		item := Item{
+			"hk": &types.AttributeValueMemberS{Value: rel.Source},
+			"sk": &types.AttributeValueMemberS{Value: rel.Target},
+		}
+
+		if id, err := EntityID(item); err != nil || id != "P1" {
+			t.Errorf("Expected id 'P1', got %s %v", id, err)
+		}
+		if prefix, err := EntityPrefix(item); err != nil || prefix != "product" {
+			t.Errorf("Expected prefix 'product', got %s %v", prefix, err)
+		}
+		if id, err := rel.EntityID(); err != nil || id != "P1" {
+			t.Errorf("Expected id 'P1', got %s %v", id, err)
+		}
+		if prefix, err := rel.EntityPrefix(); err != nil || prefix != "product" {
+			t.Errorf("Expected prefix 'product', got %s %v", prefix, err)
+		}
+		if _, err := EntityID(Item{}); err == nil {
+			t.Error("Expected error for item without keys")
+		}
+	})
+}
@@ -0,0 +1,293 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// KeyNames are the names of the key attributes of a table whose schema differs from the
+// dynamap attribute names, such as an existing table keyed by PK and SK. Empty names keep
+// the dynamap names.
+//
+// Requests executed by the table, or by a [Client] of the table, are translated to the
+// names of the table, and items read are translated back, so entities, queries and
+// expressions keep using [AttributeNameSource] and the other dynamap names. Requests built
+// by the Marshal methods of the table use the dynamap names too; execute them with a
+// [Client] to translate them. PartiQL statements cannot be translated, so
+// [Table.MarshalStatement] uses the names of the table instead. The unmarshal functions read items with either names when
+// given the table options.
+//
+// Example:
+//
+//	table.KeyNames = dynamap.KeyNames{Source: "PK", Target: "SK", Label: "GSI1PK", RefSortKey: "GSI1SK"}
+//	table.RefIndexName = "GSI1"
+type KeyNames struct {
+	Source     string // Partition key of the table. Default is "hk".
+	Target     string // Sort key of the table. Default is "sk".
+	Label      string // Partition key of the ref index. Default is "label".
+	RefSortKey string // Sort key of the ref index. Default is "gsi1_sk".
+}
+
+// IsSet returns true if any of the names differ from the dynamap attribute names.
+func (k KeyNames) IsSet() bool {
+	return len(k.renames()) > 0
+}
+
+// renames returns the table names of the renamed key attributes, by dynamap name.
+func (k KeyNames) renames() map[string]string {
+	renames := make(map[string]string, 4)
+	for name, rename := range map[string]string{
+		AttributeNameSource:     k.Source,
+		AttributeNameTarget:     k.Target,
+		AttributeNameLabel:      k.Label,
+		AttributeNameRefSortKey: k.RefSortKey,
+	} {
+		if rename != "" && rename != name {
+			renames[name] = rename
+		}
+	}
+	return renames
+}
+
+// name returns the table name of the dynamap attribute name.
+func (k KeyNames) name(name string) string {
+	if rename, ok := k.renames()[name]; ok {
+		return rename
+	}
+	return name
+}
+
+// inverse returns the dynamap names of the renamed key attributes, by table name.
+func (k KeyNames) inverse() map[string]string {
+	inverse := make(map[string]string, 4)
+	for name, rename := range k.renames() {
+		inverse[rename] = name
+	}
+	return inverse
+}
+
+// canonicalItem returns a copy of item with the key attributes renamed to the dynamap
+// names. The item is returned as is if no key attributes are renamed.
+func (k KeyNames) canonicalItem(item Item) Item {
+	return renameItem(item, k.inverse())
+}
+
+// renameItem returns a copy of item with the attributes renamed. The provided item is not
+// modified, and is returned as is if none of its attributes are renamed.
+func renameItem(item Item, renames map[string]string) Item {
+	var renamed Item
+	for from, to := range renames {
+		value, ok := item[from]
+		if !ok {
+			continue
+		}
+		if renamed == nil {
+			renamed = maps.Clone(item)
+		}
+		delete(renamed, from)
+		renamed[to] = value
+	}
+	if renamed == nil {
+		return item
+	}
+	return renamed
+}
+
+// renameItems returns copies of items with the attributes renamed.
+func renameItems(items []Item, renames map[string]string) []Item {
+	if items == nil {
+		return nil
+	}
+	renamed := make([]Item, len(items))
+	for i, item := range items {
+		renamed[i] = renameItem(item, renames)
+	}
+	return renamed
+}
+
+// renameNames returns a copy of expression attribute names with the top-level attributes
+// of expressions renamed. Nested path segments, such as label in data.label, keep their
+// name; see [renamePaths].
+func renameNames(names map[string]string, renames map[string]string, expressions ...**string) map[string]string {
+	return renamePaths(names, func(name string) string {
+		if to, ok := renames[name]; ok {
+			return to
+		}
+		return name
+	}, expressions...)
+}
+
+// keyNamesInterceptor translates the requests of a table to its [KeyNames], and the
+// items it reads back to the dynamap names.
+type keyNamesInterceptor struct {
+	table *Table
+}
+
+// Before implements [Interceptor]. The input is copied before it is translated.
+func (k keyNamesInterceptor) Before(ctx context.Context, req *Request) error {
+	var (
+		table   = k.table.TableName
+		renames = k.table.KeyNames.renames()
+	)
+
+	switch input := req.Input.(type) {
+	case *dynamodb.PutItemInput:
+		if aws.ToString(input.TableName) == table {
+			renamed := *input
+			renamed.Item = renameItem(input.Item, renames)
+			renamed.ExpressionAttributeNames = renameNames(input.ExpressionAttributeNames, renames, &renamed.ConditionExpression)
+			req.Input = &renamed
+		}
+	case *dynamodb.GetItemInput:
+		if aws.ToString(input.TableName) == table {
+			renamed := *input
+			renamed.Key = renameItem(input.Key, renames)
+			renamed.ExpressionAttributeNames = renameNames(input.ExpressionAttributeNames, renames, &renamed.ProjectionExpression)
+			req.Input = &renamed
+		}
+	case *dynamodb.QueryInput:
+		if aws.ToString(input.TableName) == table {
+			renamed := *input
+			renamed.ExclusiveStartKey = renameItem(input.ExclusiveStartKey, renames)
+			renamed.ExpressionAttributeNames = renameNames(input.ExpressionAttributeNames, renames,
+				&renamed.KeyConditionExpression, &renamed.FilterExpression, &renamed.ProjectionExpression)
+			req.Input = &renamed
+		}
+	case *dynamodb.UpdateItemInput:
+		if aws.ToString(input.TableName) == table {
+			renamed := *input
+			renamed.Key = renameItem(input.Key, renames)
+			renamed.ExpressionAttributeNames = renameNames(input.ExpressionAttributeNames, renames,
+				&renamed.UpdateExpression, &renamed.ConditionExpression)
+			req.Input = &renamed
+		}
+	case *dynamodb.DeleteItemInput:
+		if aws.ToString(input.TableName) == table {
+			renamed := *input
+			renamed.Key = renameItem(input.Key, renames)
+			renamed.ExpressionAttributeNames = renameNames(input.ExpressionAttributeNames, renames, &renamed.ConditionExpression)
+			req.Input = &renamed
+		}
+	case *dynamodb.BatchGetItemInput:
+		if keys, ok := input.RequestItems[table]; ok {
+			renamed := *input
+			renamed.RequestItems = maps.Clone(input.RequestItems)
+			keys.Keys = renameItems(keys.Keys, renames)
+			keys.ExpressionAttributeNames = renameNames(keys.ExpressionAttributeNames, renames, &keys.ProjectionExpression)
+			renamed.RequestItems[table] = keys
+			req.Input = &renamed
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if requests, ok := input.RequestItems[table]; ok {
+			renamed := *input
+			renamed.RequestItems = maps.Clone(input.RequestItems)
+			renamed.RequestItems[table] = renameWriteRequests(requests, renames)
+			req.Input = &renamed
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		renamed := *input
+		renamed.TransactItems = make([]types.TransactWriteItem, len(input.TransactItems))
+		for i, action := range input.TransactItems {
+			if action.Put != nil && aws.ToString(action.Put.TableName) == table {
+				put := *action.Put
+				put.Item = renameItem(put.Item, renames)
+				put.ExpressionAttributeNames = renameNames(put.ExpressionAttributeNames, renames, &put.ConditionExpression)
+				action.Put = &put
+			}
+			if action.Update != nil && aws.ToString(action.Update.TableName) == table {
+				update := *action.Update
+				update.Key = renameItem(update.Key, renames)
+				update.ExpressionAttributeNames = renameNames(update.ExpressionAttributeNames, renames,
+					&update.UpdateExpression, &update.ConditionExpression)
+				action.Update = &update
+			}
+			if action.Delete != nil && aws.ToString(action.Delete.TableName) == table {
+				del := *action.Delete
+				del.Key = renameItem(del.Key, renames)
+				del.ExpressionAttributeNames = renameNames(del.ExpressionAttributeNames, renames, &del.ConditionExpression)
+				action.Delete = &del
+			}
+			if action.ConditionCheck != nil && aws.ToString(action.ConditionCheck.TableName) == table {
+				check := *action.ConditionCheck
+				check.Key = renameItem(check.Key, renames)
+				check.ExpressionAttributeNames = renameNames(check.ExpressionAttributeNames, renames, &check.ConditionExpression)
+				action.ConditionCheck = &check
+			}
+			renamed.TransactItems[i] = action
+		}
+		req.Input = &renamed
+	}
+
+	return nil
+}
+
+// After implements [Interceptor]. The output is copied before it is translated.
+func (k keyNamesInterceptor) After(ctx context.Context, req *Request) {
+	var (
+		table   = k.table.TableName
+		inverse = k.table.KeyNames.inverse()
+	)
+
+	switch output := req.Output.(type) {
+	case *dynamodb.PutItemOutput:
+		renamed := *output
+		renamed.Attributes = renameItem(output.Attributes, inverse)
+		req.Output = &renamed
+	case *dynamodb.GetItemOutput:
+		renamed := *output
+		renamed.Item = renameItem(output.Item, inverse)
+		req.Output = &renamed
+	case *dynamodb.QueryOutput:
+		renamed := *output
+		renamed.Items = renameItems(output.Items, inverse)
+		renamed.LastEvaluatedKey = renameItem(output.LastEvaluatedKey, inverse)
+		req.Output = &renamed
+	case *dynamodb.UpdateItemOutput:
+		renamed := *output
+		renamed.Attributes = renameItem(output.Attributes, inverse)
+		req.Output = &renamed
+	case *dynamodb.DeleteItemOutput:
+		renamed := *output
+		renamed.Attributes = renameItem(output.Attributes, inverse)
+		req.Output = &renamed
+	case *dynamodb.BatchGetItemOutput:
+		renamed := *output
+		if items, ok := output.Responses[table]; ok {
+			renamed.Responses = maps.Clone(output.Responses)
+			renamed.Responses[table] = renameItems(items, inverse)
+		}
+		if keys, ok := output.UnprocessedKeys[table]; ok {
+			renamed.UnprocessedKeys = maps.Clone(output.UnprocessedKeys)
+			keys.Keys = renameItems(keys.Keys, inverse)
+			keys.ExpressionAttributeNames = renameNames(keys.ExpressionAttributeNames, inverse, &keys.ProjectionExpression)
+			renamed.UnprocessedKeys[table] = keys
+		}
+		req.Output = &renamed
+	case *dynamodb.BatchWriteItemOutput:
+		if requests, ok := output.UnprocessedItems[table]; ok {
+			renamed := *output
+			renamed.UnprocessedItems = maps.Clone(output.UnprocessedItems)
+			renamed.UnprocessedItems[table] = renameWriteRequests(requests, inverse)
+			req.Output = &renamed
+		}
+	}
+}
+
+// renameWriteRequests returns copies of the write requests with the attributes renamed.
+func renameWriteRequests(requests []types.WriteRequest, renames map[string]string) []types.WriteRequest {
+	renamed := make([]types.WriteRequest, len(requests))
+	for i, request := range requests {
+		if request.PutRequest != nil {
+			request.PutRequest = &types.PutRequest{Item: renameItem(request.PutRequest.Item, renames)}
+		}
+		if request.DeleteRequest != nil {
+			request.DeleteRequest = &types.DeleteRequest{Key: renameItem(request.DeleteRequest.Key, renames)}
+		}
+		renamed[i] = request
+	}
+	return renamed
+}
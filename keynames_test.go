@@ -0,0 +1,183 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pkClient stores items keyed by PK and SK, and records the queries it receives.
+type pkClient struct {
+	*mockDynamoDBClient
+	queries []*dynamodb.QueryInput
+}
+
+func (c *pkClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.items[StringValue(params.Item["PK"])+"#"+StringValue(params.Item["SK"])] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *pkClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.items[StringValue(params.Key["PK"])+"#"+StringValue(params.Key["SK"])]}, nil
+}
+
+func (c *pkClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, params)
+	var items []Item
+	for _, item := range c.items {
+		items = append(items, item)
+	}
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: Item{"PK": items[0]["PK"], "SK": items[0]["SK"]}}, nil
+}
+
+// Tests for key names
+
+func TestKeyNames(t *testing.T) {
+	ctx := context.Background()
+	newTable := func() *Table {
+		table := NewTable("test-table")
+		table.KeyNames = KeyNames{Source: "PK", Target: "SK", Label: "GSI1PK", RefSortKey: "GSI1SK"}
+		return table
+	}
+
+	t.Run("items use the table names", func(t *testing.T) {
+		table := newTable()
+		client := &pkClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "books"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		item := client.items["product#P1#product#P1"]
+		if StringValue(item["GSI1PK"]) != "product" || StringValue(item["GSI1SK"]) != "books" {
+			t.Errorf("Expected the ref index names, got %v", item)
+		}
+		for _, name := range []string{AttributeNameSource, AttributeNameTarget, AttributeNameLabel, AttributeNameRefSortKey} {
+			if _, ok := item[name]; ok {
+				t.Errorf("Expected no %s attribute, got %v", name, item)
+			}
+		}
+
+		var product Product
+		rel, err := table.Get(ctx, client, &Product{ID: "P1"}, &product)
+		if err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if product.Category != "books" || rel.Source != "product#P1" || rel.Label != "product" {
+			t.Errorf("Expected the product, got %+v %+v", product, rel)
+		}
+	})
+
+	t.Run("queries use the table names", func(t *testing.T) {
+		table := newTable()
+		client := &pkClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		input, err := table.MarshalQuery(&QueryList{Label: "product"})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		output, err := table.client(client).Query(ctx, input)
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+
+		names := client.queries[0].ExpressionAttributeNames
+		if !slices.Contains(slices.Collect(maps.Values(names)), "GSI1PK") {
+			t.Errorf("Expected the label name to be translated, got %v", names)
+		}
+		if input.ExpressionAttributeNames["#0"] != AttributeNameLabel {
+			t.Error("Expected the marshaled input not to be modified")
+		}
+		if StringValue(output.Items[0][AttributeNameSource]) != "product#P1" || StringValue(output.LastEvaluatedKey[AttributeNameTarget]) != "product#P1" {
+			t.Errorf("Expected items with dynamap names, got %v %v", output.Items, output.LastEvaluatedKey)
+		}
+	})
+
+	t.Run("clients translate marshaled requests", func(t *testing.T) {
+		table := newTable()
+		raw := &pkClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		input, err := table.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if _, err := NewClient(table, raw).PutItem(ctx, input); err != nil {
+			t.Fatalf("Failed to put item: %v", err)
+		}
+		if _, ok := raw.items["product#P1#product#P1"]; !ok {
+			t.Errorf("Expected the item keyed by PK and SK, got %v", raw.items)
+		}
+	})
+
+	t.Run("nested names are kept", func(t *testing.T) {
+		table := newTable()
+		client := &pkClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		input, err := table.MarshalQuery(&QueryList{
+			Label: "product",
+			ConditionFilter: DataAttribute(AttributeNameLabel).Equal(expression.Value("sale")).
+				And(DataAttribute(AttributeNameTarget).AttributeExists()),
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if _, err := NewClient(table, client).Query(ctx, input); err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+
+		query := client.queries[0]
+		if key := resolveNames(*query.KeyConditionExpression, query.ExpressionAttributeNames); !strings.HasPrefix(key, "GSI1PK = ") {
+			t.Errorf("Expected the key condition on GSI1PK, got %q", key)
+		}
+		filter := resolveNames(*query.FilterExpression, query.ExpressionAttributeNames)
+		if !strings.Contains(filter, "data.label = ") || !strings.Contains(filter, "attribute_exists (data.sk)") {
+			t.Errorf("Expected the filter on data.label and data.sk, got %q", filter)
+		}
+		if slices.Contains(slices.Collect(maps.Values(input.ExpressionAttributeNames)), "GSI1PK") {
+			t.Error("Expected the marshaled input not to be modified")
+		}
+	})
+
+	t.Run("unmarshal items with table names", func(t *testing.T) {
+		table := newTable()
+		item := Item{
+			"PK":     &types.AttributeValueMemberS{Value: "order#O1"},
+			"SK":     &types.AttributeValueMemberS{Value: "order#O1"},
+			"GSI1PK": &types.AttributeValueMemberS{Value: "order"},
+			"data":   &types.AttributeValueMemberM{Value: Item{"id": &types.AttributeValueMemberS{Value: "O1"}}},
+		}
+
+		var order Order
+		relationships, err := UnmarshalEntity([]Item{item}, &order, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if order.ID != "O1" || relationships[0].Label != "order" {
+			t.Errorf("Expected the order, got %+v %+v", order, relationships)
+		}
+	})
+
+	t.Run("create table input", func(t *testing.T) {
+		table := newTable()
+		table.RefIndexName = "GSI1"
+		input := table.CreateTableInput()
+		if aws.ToString(input.KeySchema[0].AttributeName) != "PK" || aws.ToString(input.KeySchema[1].AttributeName) != "SK" {
+			t.Errorf("Expected the PK and SK table key, got %v", input.KeySchema)
+		}
+		index := input.GlobalSecondaryIndexes[0]
+		if aws.ToString(index.KeySchema[0].AttributeName) != "GSI1PK" || aws.ToString(index.KeySchema[1].AttributeName) != "GSI1SK" {
+			t.Errorf("Expected the GSI1PK and GSI1SK index key, got %v", index.KeySchema)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package dynamap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// ErrInvalidLabel is returned when a label is malformed or not allowed for the entity.
+var ErrInvalidLabel = newError(ErrValidation, "invalid label")
+
+// LabelCodec encodes relationship labels and decodes them back. Self relationship labels
+// consist of the entity prefix only, while other relationship labels identify the source
+// entity and the relationship name. Set [Table.LabelCodec] to integrate with existing
+// label formats.
+type LabelCodec interface {
+	// EncodeLabel joins the source prefix, source id and relationship name into a label.
+	EncodeLabel(prefix, id, name string) string
+	// DecodeLabel splits the label into its source prefix, source id and relationship name.
+	// Self relationship labels decode to the prefix with an empty id and name.
+	DecodeLabel(label string) (prefix, id, name string, err error)
+}
+
+// DelimitedLabelCodec is the default [LabelCodec], which formats labels as
+// "<prefix>/<id>/<name>" using a delimiter.
+type DelimitedLabelCodec struct {
+	Delimiter string // Delimiter between label segments
+}
+
+// EncodeLabel implements [LabelCodec].
+func (c DelimitedLabelCodec) EncodeLabel(prefix, id, name string) string {
+	return prefix + c.Delimiter + id + c.Delimiter + name
+}
+
+// DecodeLabel implements [LabelCodec].
+func (c DelimitedLabelCodec) DecodeLabel(label string) (prefix, id, name string, err error) {
+	parts := strings.Split(label, c.Delimiter)
+	if len(parts) == 1 {
+		return parts[0], "", "", nil
+	} else if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("%w: invalid label length; should be 1 or 3", ErrInvalidLabel)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// ParseLabel decodes a relationship label such as "order/O1/products" into the source
+// prefix, source id and relationship name. Pass [Table.MarshalOptions] to use the table's
+// label codec or delimiter.
+func ParseLabel(label string, opts ...func(*MarshalOptions)) (prefix, id, name string, err error) {
+	return NewMarshalOptions(opts...).labelCodec().DecodeLabel(label)
+}
+
+// FallbackUnmarshaler can receive relationships with unrecognized labels when
+// [MarshalOptions.LenientLabels] is set.
+type FallbackUnmarshaler interface {
+	// UnmarshalUnknown is invoked by [UnmarshalEntity] for each relationship whose
+	// label could not be decoded. The relationship data is unmarshaled as-is.
+	UnmarshalUnknown(ref *Relationship) error
+}
+
+// labelCodec returns the configured label codec, or a [DelimitedLabelCodec] using the
+// label delimiter, scoped to the tenant if one is set.
+func (mo MarshalOptions) labelCodec() LabelCodec {
+	var codec LabelCodec = DelimitedLabelCodec{Delimiter: mo.LabelDelimiter}
+	if mo.LabelCodec != nil {
+		codec = mo.LabelCodec
+	}
+	if prefix := mo.tenantPrefix(); prefix != "" {
+		codec = tenantLabelCodec{LabelCodec: codec, prefix: prefix}
+	}
+	return codec
+}
+
+// unmarshalUnknown routes a relationship item with an unrecognized label to out, if
+// lenient labels are enabled. Returns true if the item was handled.
+func (mo MarshalOptions) unmarshalUnknown(item Item, out any) (Relationship, bool, error) {
+	var rel Relationship
+	if !mo.LenientLabels {
+		return rel, false, nil
+	}
+
+	item, err := mo.unmarshalItem(item)
+	if err != nil {
+		return rel, false, err
+	}
+
+	if err := attributevalue.UnmarshalMap(item, &rel); err != nil {
+		return rel, false, fmt.Errorf("failed to unmarshal relationship: %w", err)
+	}
+
+	if _, _, name, err := mo.splitLabel(rel); err == nil && name != "" {
+		return rel, false, nil
+	}
+
+	if fallback, ok := out.(FallbackUnmarshaler); ok {
+		if err := fallback.UnmarshalUnknown(&rel); err != nil {
+			return rel, false, fmt.Errorf("failed to unmarshal unknown label %s: %w", rel.Label, err)
+		}
+	}
+
+	return rel, true, nil
+}
@@ -0,0 +1,131 @@
+package dynamap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// versionedLabelCodec encodes labels as "v2:<prefix>:<id>:<name>".
+type versionedLabelCodec struct{}
+
+func (versionedLabelCodec) EncodeLabel(prefix, id, name string) string {
+	return strings.Join([]string{"v2", prefix, id, name}, ":")
+}
+
+func (versionedLabelCodec) DecodeLabel(label string) (string, string, string, error) {
+	parts := strings.Split(label, ":")
+	switch {
+	case len(parts) == 1:
+		return parts[0], "", "", nil
+	case len(parts) == 4 && parts[0] == "v2":
+		return parts[1], parts[2], parts[3], nil
+	default:
+		return "", "", "", ErrInvalidLabel
+	}
+}
+
+// legacyOrder records relationships with unrecognized labels.
+type legacyOrder struct {
+	Order
+	unknown []string
+}
+
+func (o *legacyOrder) UnmarshalUnknown(ref *Relationship) error {
+	o.unknown = append(o.unknown, ref.Label)
+	return nil
+}
+
+// Tests for label codecs
+
+func TestLabelCodec(t *testing.T) {
+	t.Run("delimited codec", func(t *testing.T) {
+		codec := DelimitedLabelCodec{Delimiter: "/"}
+		if label := codec.EncodeLabel("order", "O1", "products"); label != "order/O1/products" {
+			t.Errorf("Expected 'order/O1/products', got %s", label)
+		}
+		if prefix, _, name, err := codec.DecodeLabel("order"); err != nil || prefix != "order" || name != "" {
+			t.Errorf("Expected self label 'order', got %s %s %v", prefix, name, err)
+		}
+		if _, _, _, err := codec.DecodeLabel("a/b"); !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("Expected ErrInvalidLabel, got %v", err)
+		}
+	})
+
+	t.Run("table codec", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.LabelCodec = versionedLabelCodec{}
+
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		requests := batches[0].RequestItems["test-table"]
+		var items []Item
+		for _, request := range requests {
+			items = append(items, request.PutRequest.Item)
+		}
+
+		if label := items[1]["label"].(*types.AttributeValueMemberS).Value; label != "v2:order:O1:products" {
+			t.Errorf("Expected label 'v2:order:O1:products', got %s", label)
+		}
+
+		var order Order
+		if _, err := UnmarshalEntity(items, &order, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(order.Products) != 1 {
+			t.Errorf("Expected 1 product, got %+v", order.Products)
+		}
+	})
+
+	t.Run("lenient labels", func(t *testing.T) {
+		relationships, err := MarshalRelationships(&Order{ID: "O1", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal relationships: %v", err)
+		}
+
+		var items []Item
+		for _, rel := range relationships {
+			item, err := attributevalue.MarshalMap(rel)
+			if err != nil {
+				t.Fatalf("Failed to marshal item: %v", err)
+			}
+			items = append(items, item)
+		}
+
+		legacy, _ := attributevalue.MarshalMap(Relationship{
+			Source: "order#O1",
+			Target: "customer#C1",
+			Label:  "ORDER/CUSTOMER",
+			Data:   map[string]string{"legacy": "true"},
+		})
+		items = append(items, legacy)
+
+		var strict Order
+		if _, err := UnmarshalEntity(items, &strict); err == nil {
+			t.Error("Expected error for unrecognized label")
+		}
+
+		var order legacyOrder
+		relationships, err = UnmarshalEntity(items, &order, func(opts *MarshalOptions) {
+			opts.LenientLabels = true
+		})
+		if err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(relationships) != 3 {
+			t.Errorf("Expected 3 relationships, got %d", len(relationships))
+		}
+		if len(order.Products) != 1 {
+			t.Errorf("Expected 1 product, got %d", len(order.Products))
+		}
+		if len(order.unknown) != 1 || order.unknown[0] != "ORDER/CUSTOMER" {
+			t.Errorf("Expected unknown label 'ORDER/CUSTOMER', got %v", order.unknown)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package dynamap
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// LazyItem is an item whose keys, label, timestamps and audit attributes are unmarshaled
+// up front, and whose data is decoded only when [LazyItem.Data] is called. Use it to read
+// the keys of large query results, such as when building an index of ids, without paying
+// for decoding every payload.
+type LazyItem struct {
+	// Relationship holds the attributes of the item other than its data. Its Data field
+	// is always nil; use [LazyItem.Data] to decode the data.
+	Relationship
+
+	item Item
+	opts []func(*MarshalOptions)
+}
+
+// UnmarshalLazy unmarshals the attributes of item other than its data into a [LazyItem].
+// The data attribute is neither unmarshaled nor decoded with [MarshalOptions.DataCodec]
+// until [LazyItem.Data] is called.
+//
+// Example:
+//
+//	ids := make([]string, 0, len(items))
+//	for _, item := range items {
+//		lazy, err := dynamap.UnmarshalLazy(item, table.MarshalOptions)
+//		if err != nil {
+//			return err
+//		}
+//		ids = append(ids, lazy.Target)
+//	}
+func UnmarshalLazy(item Item, opts ...func(*MarshalOptions)) (LazyItem, error) {
+	marshalOpts := NewMarshalOptions(opts...)
+
+	keys := make(Item, len(item))
+	data := marshalOpts.attributeNames(AttributeNameData)
+	for name, value := range item {
+		if !slices.Contains(data, name) {
+			keys[name] = value
+		}
+	}
+
+	keys, err := marshalOpts.decodeTimestamps(marshalOpts.unaliasItem(keys))
+	if err != nil {
+		return LazyItem{}, err
+	}
+
+	lazy := LazyItem{item: item, opts: opts}
+	if err := attributevalue.UnmarshalMap(keys, &lazy.Relationship); err != nil {
+		return LazyItem{}, fmt.Errorf("failed to unmarshal relationship: %w", err)
+	}
+	return lazy, nil
+}
+
+// UnmarshalLazyList calls [UnmarshalLazy] on each item in items. It is the lazy
+// counterpart of [UnmarshalList] for results of [QueryList].
+func UnmarshalLazyList(items []Item, opts ...func(*MarshalOptions)) ([]LazyItem, error) {
+	lazy := make([]LazyItem, 0, len(items))
+	for i, item := range items {
+		value, err := UnmarshalLazy(item, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal item %d: %w", i, err)
+		}
+		lazy = append(lazy, value)
+	}
+	return lazy, nil
+}
+
+// Data decodes the data of the item and unmarshals it into out, as [UnmarshalSelf] does.
+func (l LazyItem) Data(out any) error {
+	_, err := UnmarshalSelf(l.item, out, l.opts...)
+	return err
+}
+
+// Item returns the item the LazyItem was unmarshaled from.
+func (l LazyItem) Item() Item {
+	return l.item
+}
@@ -0,0 +1,61 @@
+package dynamap
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// countingDataCodec counts the data attributes it decodes.
+type countingDataCodec struct {
+	GzipDataCodec
+	decodes int
+}
+
+func (c *countingDataCodec) Decode(data types.AttributeValue) (types.AttributeValue, error) {
+	c.decodes++
+	return c.GzipDataCodec.Decode(data)
+}
+
+// Tests for lazy unmarshaling
+
+func TestUnmarshalLazy(t *testing.T) {
+	codec := &countingDataCodec{}
+	table := NewTable("test-table")
+	table.DataCodec = codec
+	table.Aliases = []AttributeAlias{{Name: AttributeNameData, Alias: "d"}}
+
+	var items []Item
+	for _, product := range []*Product{{ID: "P1", Category: "books"}, {ID: "P2", Category: "games"}} {
+		input, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		items = append(items, input.Item)
+	}
+
+	lazy, err := UnmarshalLazyList(items, table.MarshalOptions)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal lazy list: %v", err)
+	}
+	if len(lazy) != 2 || lazy[0].Target != "product#P1" || lazy[1].Label != "product" || lazy[1].GSI1SK != "games" {
+		t.Fatalf("Expected the keys of both products, got %+v", lazy)
+	}
+	if lazy[0].CreatedAt.IsZero() || lazy[0].Relationship.Data != nil {
+		t.Errorf("Expected timestamps and no data, got %+v", lazy[0].Relationship)
+	}
+	if codec.decodes != 0 {
+		t.Errorf("Expected no data to be decoded, got %d decodes", codec.decodes)
+	}
+
+	var product Product
+	if err := lazy[1].Data(&product); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+	if product.ID != "P2" || product.Category != "games" {
+		t.Errorf("Expected the second product, got %+v", product)
+	}
+	if codec.decodes != 1 {
+		t.Errorf("Expected one decode, got %d", codec.decodes)
+	}
+}
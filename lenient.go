@@ -0,0 +1,72 @@
+package dynamap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ItemError is the error of an item skipped by an unmarshal function in lenient mode.
+type ItemError struct {
+	Source string // The hash key of the item, if it could be read
+	Target string // The sort key of the item, if it could be read
+	Err    error  // The error returned when unmarshaling the item
+}
+
+// Error implements the error interface.
+func (e ItemError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Source, e.Target, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalError is returned by [UnmarshalEntity] and [UnmarshalList] when
+// [MarshalOptions.LenientItems] is set and some items failed to unmarshal. The results of
+// the other items are returned along with it, so callers can log the error and carry on:
+//
+//	relationships, err := dynamap.UnmarshalEntity(items, &order, table.MarshalOptions, func(mo *dynamap.MarshalOptions) {
+//		mo.LenientItems = true
+//	})
+//	var skipped *dynamap.UnmarshalError
+//	if errors.As(err, &skipped) {
+//		log.Printf("skipped %d items: %v", len(skipped.Items), skipped)
+//	} else if err != nil {
+//		return err
+//	}
+type UnmarshalError struct {
+	Items []ItemError // The skipped items, in item order
+}
+
+// Error implements the error interface.
+func (e *UnmarshalError) Error() string {
+	messages := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		messages[i] = item.Error()
+	}
+	return fmt.Sprintf("failed to unmarshal %d items: %s", len(e.Items), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the skipped items.
+func (e *UnmarshalError) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		errs[i] = item
+	}
+	return errs
+}
+
+// add records that item was skipped because of err.
+func (e *UnmarshalError) add(item Item, err error) {
+	source, target, _ := UnmarshalTableKey(item)
+	e.Items = append(e.Items, ItemError{Source: source, Target: target, Err: err})
+}
+
+// err returns e if any item was skipped, or nil.
+func (e *UnmarshalError) err() error {
+	if len(e.Items) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,88 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for lenient unmarshaling
+
+func TestLenientItems(t *testing.T) {
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+	relationships, err := MarshalRelationships(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal relationships: %v", err)
+	}
+
+	var items []Item
+	for _, rel := range relationships {
+		item, err := attributevalue.MarshalMap(rel)
+		if err != nil {
+			t.Fatalf("Failed to marshal item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	// A legacy ref whose label cannot be parsed
+	items = append(items, Item{
+		"hk":    &types.AttributeValueMemberS{Value: "order#O1"},
+		"sk":    &types.AttributeValueMemberS{Value: "legacy#L1"},
+		"label": &types.AttributeValueMemberS{Value: "junk"},
+	})
+
+	lenient := func(mo *MarshalOptions) { mo.LenientItems = true }
+
+	t.Run("strict mode aborts", func(t *testing.T) {
+		var out Order
+		if _, err := UnmarshalEntity(items, &out); err == nil {
+			t.Fatal("Expected error")
+		}
+	})
+
+	t.Run("lenient entity", func(t *testing.T) {
+		var out Order
+		relationships, err := UnmarshalEntity(items, &out, lenient)
+
+		var skipped *UnmarshalError
+		if !errors.As(err, &skipped) {
+			t.Fatalf("Expected UnmarshalError, got %v", err)
+		}
+		if len(skipped.Items) != 1 {
+			t.Fatalf("Expected 1 skipped item, got %d", len(skipped.Items))
+		}
+		if skipped.Items[0].Source != "order#O1" || skipped.Items[0].Target != "legacy#L1" {
+			t.Errorf("Expected skipped key 'order#O1' 'legacy#L1', got %q %q", skipped.Items[0].Source, skipped.Items[0].Target)
+		}
+		if len(relationships) != 3 {
+			t.Errorf("Expected 3 relationships, got %d", len(relationships))
+		}
+		if len(out.Products) != 2 {
+			t.Errorf("Expected 2 products, got %d", len(out.Products))
+		}
+	})
+
+	t.Run("lenient list", func(t *testing.T) {
+		list := []Item{items[0], {"hk": &types.AttributeValueMemberS{Value: "order#O2"}, "data": &types.AttributeValueMemberS{Value: "junk"}}}
+
+		var out []Order
+		relationships, err := UnmarshalList(list, &out, lenient)
+
+		var skipped *UnmarshalError
+		if !errors.As(err, &skipped) || len(skipped.Items) != 1 {
+			t.Fatalf("Expected 1 skipped item, got %v", err)
+		}
+		if len(relationships) != 1 || len(out) != 1 {
+			t.Errorf("Expected 1 result, got %d relationships and %d values", len(relationships), len(out))
+		}
+	})
+
+	t.Run("no skipped items", func(t *testing.T) {
+		var out Order
+		if _, err := UnmarshalEntity(items[:3], &out, lenient); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultLoaderWait is the default time a [Loader] collects keys before loading them.
+const DefaultLoaderWait = time.Millisecond
+
+// LoaderOptions configures a [Loader].
+type LoaderOptions struct {
+	Wait     time.Duration // Time to collect keys before a batch is loaded. Default is DefaultLoaderWait.
+	MaxBatch int           // Number of keys that loads a batch without waiting. Default is MaxBatchGetSize.
+	NoCache  bool          // If true, loaded items are not kept for later loads of the same key
+}
+
+// newLoaderOptions returns the loader options with defaults applied.
+func newLoaderOptions(opts []func(*LoaderOptions)) LoaderOptions {
+	options := LoaderOptions{
+		Wait:     DefaultLoaderWait,
+		MaxBatch: MaxBatchGetSize,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// Loader coalesces the loads of entities made within a short window, such as by the
+// resolvers of a GraphQL query, into batch get item requests. Loaded items are kept by
+// key, so each entity is read at most once for the lifetime of the loader.
+//
+// A Loader is meant to be scoped to a single request: create one per request, so
+// entities are not shared between callers and writes made by later requests are seen.
+// Entities are loaded with the marshal options carried by the context of the first load
+// of each batch. A Loader is safe for concurrent use.
+//
+// Example:
+//
+//	// In the request middleware
+//	products := dynamap.NewLoader[*Product](table, client)
+//	ctx = context.WithValue(ctx, productsKey{}, products)
+//
+//	// In the resolver of order.products, called concurrently for each product
+//	product := &Product{ID: id}
+//	_, err := products.Load(ctx, product)
+type Loader[T Marshaler] struct {
+	table   *Table
+	client  DynamoDBClient
+	options LoaderOptions
+
+	mu      sync.Mutex
+	pending *loaderBatch            // The batch collecting keys, if any
+	batches map[string]*loaderBatch // The batch of each requested key, by source key
+}
+
+// loaderBatch is a set of keys loaded together.
+type loaderBatch struct {
+	ctx     context.Context
+	sources []string
+	done    chan struct{}
+	items   map[string]Item // The loaded items, by source key; set when done
+	err     error           // The error of the batch; set when done
+}
+
+// NewLoader creates a [Loader] of the entities of the table.
+func NewLoader[T Marshaler](table *Table, client DynamoDBClient, opts ...func(*LoaderOptions)) *Loader[T] {
+	return &Loader[T]{
+		table:   table,
+		client:  table.client(client),
+		options: newLoaderOptions(opts),
+		batches: make(map[string]*loaderBatch),
+	}
+}
+
+// Load loads the self relationship of entity and unmarshals it into entity via
+// [UnmarshalSelf]. The load waits for the batch holding the key of entity, unless the key
+// was already loaded. Returns [ErrItemNotFound] if the entity does not exist.
+func (l *Loader[T]) Load(ctx context.Context, entity T) (Relationship, error) {
+	source, batch, err := l.enqueue(ctx, entity)
+	if err != nil {
+		return Relationship{}, err
+	}
+	return l.result(ctx, source, batch, entity)
+}
+
+// LoadMany loads each of entities as [Loader.Load] does, in a single batch if they fit.
+// The returned relationships are in the same order as entities. Entities that were not
+// found are left untouched and their corresponding relationship is the zero value.
+func (l *Loader[T]) LoadMany(ctx context.Context, entities ...T) ([]Relationship, error) {
+	var (
+		sources = make([]string, len(entities))
+		batches = make([]*loaderBatch, len(entities))
+	)
+
+	for i, entity := range entities {
+		source, batch, err := l.enqueue(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+		sources[i], batches[i] = source, batch
+	}
+
+	relationships := make([]Relationship, len(entities))
+	for i, entity := range entities {
+		rel, err := l.result(ctx, sources[i], batches[i], entity)
+		if errors.Is(err, ErrItemNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		relationships[i] = rel
+	}
+
+	return relationships, nil
+}
+
+// Clear removes the loaded item of entity, so the next load reads it again. Call it
+// after writing an entity that may be loaded again by the same request.
+func (l *Loader[T]) Clear(ctx context.Context, entity T) error {
+	source, err := l.sourceKey(ctx, entity)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.batches, source)
+	return nil
+}
+
+// sourceKey returns the source key of entity.
+func (l *Loader[T]) sourceKey(ctx context.Context, entity T) (string, error) {
+	opts := contextOptions(ctx, nil)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		l.table.MarshalOptions(mo)
+		mo.apply(opts)
+		mo.SkipRefs = true // Only need self relationship for key
+	})
+
+	if err := entity.MarshalSelf(&marshalOpts); err != nil {
+		return "", fmt.Errorf("failed to marshal self: %w", err)
+	}
+	return marshalOpts.sourceKey(), nil
+}
+
+// enqueue returns the batch loading the key of entity, adding the key to the pending
+// batch unless it was already requested.
+func (l *Loader[T]) enqueue(ctx context.Context, entity T) (string, *loaderBatch, error) {
+	source, err := l.sourceKey(ctx, entity)
+	if err != nil {
+		return "", nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if batch, ok := l.batches[source]; ok {
+		return source, batch, nil
+	}
+
+	batch := l.pending
+	if batch == nil {
+		batch = &loaderBatch{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		l.pending = batch
+		time.AfterFunc(l.options.Wait, func() { l.dispatch(batch) })
+	}
+
+	batch.sources = append(batch.sources, source)
+	l.batches[source] = batch
+	if len(batch.sources) >= l.options.MaxBatch {
+		l.pending = nil
+		go l.load(batch)
+	}
+
+	return source, batch, nil
+}
+
+// dispatch loads the batch if it is still pending.
+func (l *Loader[T]) dispatch(batch *loaderBatch) {
+	l.mu.Lock()
+	if l.pending != batch {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+
+	l.load(batch)
+}
+
+// load loads the items of the batch. Keys of failed batches, and all keys if the loader
+// does not cache, are forgotten so they are read again by later loads.
+func (l *Loader[T]) load(batch *loaderBatch) {
+	batch.items, batch.err = l.table.getSelfItems(batch.ctx, l.client, batch.sources)
+
+	if batch.err != nil || l.options.NoCache {
+		l.mu.Lock()
+		for _, source := range batch.sources {
+			if l.batches[source] == batch {
+				delete(l.batches, source)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	close(batch.done)
+}
+
+// result waits for the batch and unmarshals the item of source into entity.
+func (l *Loader[T]) result(ctx context.Context, source string, batch *loaderBatch, entity T) (Relationship, error) {
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return Relationship{}, ctx.Err()
+	}
+
+	if batch.err != nil {
+		return Relationship{}, batch.err
+	}
+
+	item, ok := batch.items[source]
+	if !ok {
+		return Relationship{}, ErrItemNotFound
+	}
+
+	opts := contextOptions(ctx, nil)
+	return UnmarshalSelf(item, entity, func(mo *MarshalOptions) {
+		l.table.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+}
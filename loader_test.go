@@ -0,0 +1,122 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// batchCountingClient counts the batch get requests and keys it receives.
+type batchCountingClient struct {
+	*mockDynamoDBClient
+	mu      sync.Mutex
+	batches []int
+}
+
+func (c *batchCountingClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, keys := range params.RequestItems {
+		c.batches = append(c.batches, len(keys.Keys))
+	}
+	return c.mockDynamoDBClient.BatchGetItem(ctx, params, optFns...)
+}
+
+// Tests for the loader
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	newClient := func(t *testing.T) *batchCountingClient {
+		client := &batchCountingClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		for _, id := range []string{"P1", "P2", "P3"} {
+			if err := table.Put(ctx, client, &Product{ID: id, Category: "category " + id}); err != nil {
+				t.Fatalf("Failed to put product: %v", err)
+			}
+		}
+		return client
+	}
+
+	t.Run("concurrent loads are batched", func(t *testing.T) {
+		client := newClient(t)
+		loader := NewLoader[*Product](table, client, func(lo *LoaderOptions) {
+			lo.Wait = 50 * time.Millisecond
+		})
+
+		var (
+			wg       sync.WaitGroup
+			ids      = []string{"P1", "P2", "P3", "P1", "P4"}
+			products = make([]*Product, len(ids))
+			errs     = make([]error, len(ids))
+		)
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				products[i] = &Product{ID: id}
+				_, errs[i] = loader.Load(ctx, products[i])
+			}()
+		}
+		wg.Wait()
+
+		if len(client.batches) != 1 || client.batches[0] != 4 {
+			t.Errorf("Expected one batch of 4 keys, got %v", client.batches)
+		}
+		for i, id := range ids[:4] {
+			if errs[i] != nil || products[i].Category != "category "+id {
+				t.Errorf("Expected product %s, got %+v, %v", id, products[i], errs[i])
+			}
+		}
+		if !errors.Is(errs[4], ErrItemNotFound) {
+			t.Errorf("Expected the missing product not to be found, got %v", errs[4])
+		}
+	})
+
+	t.Run("loaded items are cached", func(t *testing.T) {
+		client := newClient(t)
+		loader := NewLoader[*Product](table, client)
+
+		if _, err := loader.Load(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to load product: %v", err)
+		}
+		if _, err := loader.Load(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to load product: %v", err)
+		}
+		if len(client.batches) != 1 {
+			t.Errorf("Expected one batch, got %v", client.batches)
+		}
+
+		if err := loader.Clear(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to clear product: %v", err)
+		}
+		if _, err := loader.Load(ctx, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to load product: %v", err)
+		}
+		if len(client.batches) != 2 {
+			t.Errorf("Expected the cleared product to be loaded again, got %v", client.batches)
+		}
+	})
+
+	t.Run("load many", func(t *testing.T) {
+		client := newClient(t)
+		loader := NewLoader[*Product](table, client, func(lo *LoaderOptions) {
+			lo.MaxBatch = 2
+		})
+
+		products := []*Product{{ID: "P1"}, {ID: "P2"}, {ID: "P4"}, {ID: "P3"}}
+		relationships, err := loader.LoadMany(ctx, products...)
+		if err != nil {
+			t.Fatalf("Failed to load products: %v", err)
+		}
+		if len(client.batches) != 2 {
+			t.Errorf("Expected two batches, got %v", client.batches)
+		}
+		if relationships[2].Source != "" || products[3].Category != "category P3" || relationships[3].Source != "product#P3" {
+			t.Errorf("Unexpected results %+v %+v", relationships, products)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package dynamap
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Logger receives structured logs of the requests marshaled and executed by a [Table].
+// It is satisfied by [*slog.Logger].
+//
+// Example:
+//
+//	table.Logger = slog.Default()
+//	table.LogData = true // Only while debugging; logs entity data and expression values
+type Logger interface {
+	LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+// Redacted replaces entity data and expression values in logs, unless [Table.LogData] is set.
+const Redacted = "[REDACTED]"
+
+// logMarshal logs a marshaled request at debug level, and writes its explanation to
+// [Table.Debug] if set.
+func (t *Table) logMarshal(input any) {
+	if t.Debug != nil {
+		if explanation := t.Explain(input); explanation != "" {
+			io.WriteString(t.Debug, explanation)
+		}
+	}
+	if t.Logger == nil {
+		return
+	}
+	t.Logger.LogAttrs(context.Background(), slog.LevelDebug, "dynamap marshal", t.requestAttrs(input)...)
+}
+
+// logExecute logs an executed request at debug level, or at error level if it failed.
+func (t *Table) logExecute(ctx context.Context, input any, elapsed time.Duration, err error) {
+	attrs := append(t.requestAttrs(input), slog.Duration("elapsed", elapsed))
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	t.Logger.LogAttrs(ctx, level, "dynamap execute", attrs...)
+}
+
+// requestAttrs summarizes a DynamoDB request: its operation, keys and expressions. Items
+// and expression values are redacted unless [Table.LogData] is set.
+func (t *Table) requestAttrs(input any) []slog.Attr {
+	var attrs []slog.Attr
+
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		attrs = append(attrs,
+			slog.String("operation", "PutItem"),
+			slog.String("table", aws.ToString(in.TableName)),
+			keyAttr(in.Item),
+			slog.String("condition", aws.ToString(in.ConditionExpression)),
+			t.dataAttr("item", in.Item),
+		)
+	case *dynamodb.GetItemInput:
+		attrs = append(attrs,
+			slog.String("operation", "GetItem"),
+			slog.String("table", aws.ToString(in.TableName)),
+			keyAttr(in.Key),
+		)
+	case *dynamodb.DeleteItemInput:
+		attrs = append(attrs,
+			slog.String("operation", "DeleteItem"),
+			slog.String("table", aws.ToString(in.TableName)),
+			keyAttr(in.Key),
+			slog.String("condition", aws.ToString(in.ConditionExpression)),
+		)
+	case *dynamodb.UpdateItemInput:
+		attrs = append(attrs,
+			slog.String("operation", "UpdateItem"),
+			slog.String("table", aws.ToString(in.TableName)),
+			keyAttr(in.Key),
+			slog.String("update", aws.ToString(in.UpdateExpression)),
+			slog.String("condition", aws.ToString(in.ConditionExpression)),
+			t.dataAttr("values", in.ExpressionAttributeValues),
+		)
+	case *dynamodb.QueryInput:
+		attrs = append(attrs,
+			slog.String("operation", "Query"),
+			slog.String("table", aws.ToString(in.TableName)),
+			slog.String("index", aws.ToString(in.IndexName)),
+			slog.String("key_condition", aws.ToString(in.KeyConditionExpression)),
+			slog.String("filter", aws.ToString(in.FilterExpression)),
+			t.dataAttr("values", in.ExpressionAttributeValues),
+		)
+	case *dynamodb.BatchGetItemInput:
+		var keys int
+		for _, request := range in.RequestItems {
+			keys += len(request.Keys)
+		}
+		attrs = append(attrs,
+			slog.String("operation", "BatchGetItem"),
+			slog.Int("keys", keys),
+		)
+	case *dynamodb.BatchWriteItemInput:
+		var puts, deletes int
+		for _, requests := range in.RequestItems {
+			for _, request := range requests {
+				if request.PutRequest != nil {
+					puts++
+				} else if request.DeleteRequest != nil {
+					deletes++
+				}
+			}
+		}
+		attrs = append(attrs,
+			slog.String("operation", "BatchWriteItem"),
+			slog.Int("puts", puts),
+			slog.Int("deletes", deletes),
+		)
+	case *dynamodb.TransactWriteItemsInput:
+		var keys []any
+		for _, action := range in.TransactItems {
+			switch {
+			case action.Put != nil:
+				keys = append(keys, keyString(action.Put.Item))
+			case action.Update != nil:
+				keys = append(keys, keyString(action.Update.Key))
+			case action.Delete != nil:
+				keys = append(keys, keyString(action.Delete.Key))
+			case action.ConditionCheck != nil:
+				keys = append(keys, keyString(action.ConditionCheck.Key))
+			}
+		}
+		attrs = append(attrs,
+			slog.String("operation", "TransactWriteItems"),
+			slog.Int("actions", len(in.TransactItems)),
+			slog.Any("keys", keys),
+		)
+	case *dynamodb.ExecuteStatementInput:
+		var parameters any = Redacted
+		if t.LogData {
+			parameters = in.Parameters
+		}
+		attrs = append(attrs,
+			slog.String("operation", "ExecuteStatement"),
+			slog.String("statement", aws.ToString(in.Statement)),
+			slog.Any("parameters", parameters),
+		)
+	}
+
+	return attrs
+}
+
+// dataAttr returns the attribute values as a log attribute, or [Redacted] unless
+// [Table.LogData] is set.
+func (t *Table) dataAttr(name string, values map[string]types.AttributeValue) slog.Attr {
+	if !t.LogData || values == nil {
+		return slog.String(name, Redacted)
+	}
+
+	var decoded map[string]any
+	if err := attributevalue.UnmarshalMap(values, &decoded); err != nil {
+		return slog.String(name, err.Error())
+	}
+	return slog.Any(name, decoded)
+}
+
+// keyAttr returns the table key of item as a log attribute.
+func keyAttr(item Item) slog.Attr {
+	return slog.String("key", keyString(item))
+}
+
+// keyString formats the table key of item as "<hk> <sk>".
+func keyString(item Item) string {
+	source, target, err := UnmarshalTableKey(item)
+	if err != nil {
+		return ""
+	}
+	return source + " " + target
+}
+
+// logInterceptor logs each request executed by a table.
+type logInterceptor struct {
+	table *Table
+}
+
+func (l logInterceptor) Before(ctx context.Context, req *Request) error {
+	return nil
+}
+
+func (l logInterceptor) After(ctx context.Context, req *Request) {
+	l.table.logExecute(ctx, req.Input, time.Since(req.Started), req.Err)
+}
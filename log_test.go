@@ -0,0 +1,142 @@
+package dynamap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type failingPutClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *failingPutClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, errors.New("throttled")
+}
+
+// logRecords decodes the JSON records written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Tests for structured logging
+
+func TestTableLogger(t *testing.T) {
+	ctx := context.Background()
+	product := &Product{ID: "P1", Category: "secret-widgets"}
+
+	newTable := func(buf *bytes.Buffer) *Table {
+		table := NewTable("test-table")
+		table.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		return table
+	}
+
+	t.Run("marshal and execute", func(t *testing.T) {
+		var buf bytes.Buffer
+		table := newTable(&buf)
+
+		if err := table.Put(ctx, newMockDynamoDBClient(), product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		records := logRecords(t, &buf)
+		if len(records) != 2 {
+			t.Fatalf("Expected marshal and execute records, got %d", len(records))
+		}
+		if records[0]["msg"] != "dynamap marshal" || records[1]["msg"] != "dynamap execute" {
+			t.Errorf("Unexpected messages: %v, %v", records[0]["msg"], records[1]["msg"])
+		}
+		for _, record := range records {
+			if record["operation"] != "PutItem" {
+				t.Errorf("Expected PutItem operation, got %v", record["operation"])
+			}
+			if record["key"] != "product#P1 product#P1" {
+				t.Errorf("Expected product key, got %v", record["key"])
+			}
+			if record["item"] != Redacted {
+				t.Errorf("Expected item to be redacted, got %v", record["item"])
+			}
+		}
+		if _, ok := records[1]["elapsed"]; !ok {
+			t.Error("Expected elapsed time in execute record")
+		}
+		if strings.Contains(buf.String(), "secret-widgets") {
+			t.Error("Expected entity data to be redacted")
+		}
+	})
+
+	t.Run("log data", func(t *testing.T) {
+		var buf bytes.Buffer
+		table := newTable(&buf)
+		table.LogData = true
+
+		if _, err := table.MarshalPut(product); err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if !strings.Contains(buf.String(), "secret-widgets") {
+			t.Errorf("Expected entity data in log, got %s", buf.String())
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		var buf bytes.Buffer
+		table := newTable(&buf)
+
+		if _, err := table.MarshalQuery(&QueryList{Label: "product"}); err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		records := logRecords(t, &buf)
+		if len(records) != 1 {
+			t.Fatalf("Expected 1 record, got %d", len(records))
+		}
+		if records[0]["index"] != table.RefIndexName || records[0]["key_condition"] == "" {
+			t.Errorf("Expected index and key condition, got %v", records[0])
+		}
+		if records[0]["values"] != Redacted {
+			t.Errorf("Expected values to be redacted, got %v", records[0]["values"])
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var buf bytes.Buffer
+		table := newTable(&buf)
+
+		client := &failingPutClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := table.Put(ctx, client, product); err == nil {
+			t.Fatal("Expected put to fail")
+		}
+
+		records := logRecords(t, &buf)
+		last := records[len(records)-1]
+		if last["level"] != "ERROR" || last["error"] != "throttled" {
+			t.Errorf("Expected error record, got %v", last)
+		}
+	})
+
+	t.Run("no logger", func(t *testing.T) {
+		table := NewTable("test-table")
+		client := newMockDynamoDBClient()
+		if table.client(client) != DynamoDBClient(client) {
+			t.Error("Expected client to be unwrapped without a logger")
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package dynamap
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"fmt"
+	"iter"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MergeOptions configures [MergeSortedQueries].
+type MergeOptions struct {
+	SortAttribute string // The attribute each query is sorted by. Default is AttributeNameRefSortKey.
+	Descending    bool   // If true, the queries are sorted in descending order
+	Prefetch      int    // Pages read ahead of the merge per query. Default is 1.
+}
+
+// MergeSortedQueries executes the queries concurrently and yields their items as a single
+// stream ordered by the sort attribute, such as a feed over the shards or buckets of a
+// label. Each query must already return its items in that order, which is the case for
+// the sort key of the queried index; set [MergeOptions.Descending] to match queries that
+// scan backward. Each query is paginated in the background, reading at most
+// [MergeOptions.Prefetch] pages ahead of the merge. The queries are stopped when the
+// caller stops iterating, or after the first error, which is yielded.
+//
+// Example:
+//
+//	var inputs []*dynamodb.QueryInput
+//	for _, label := range []string{"order.0", "order.1", "order.2"} {
+//		input, _ := table.MarshalQuery(&dynamap.QueryList{Label: label, Limit: 25, SortDescending: true})
+//		inputs = append(inputs, input)
+//	}
+//
+//	for item, err := range dynamap.MergeSortedQueries(ctx, client, inputs, func(mo *dynamap.MergeOptions) {
+//		mo.Descending = true
+//	}) {
+//		...
+//	}
+func MergeSortedQueries(ctx context.Context, client DynamoDBClient, inputs []*dynamodb.QueryInput, opts ...func(*MergeOptions)) iter.Seq2[Item, error] {
+	options := MergeOptions{SortAttribute: AttributeNameRefSortKey, Prefetch: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(yield func(Item, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		merge := &mergeHeap{options: options}
+		for _, input := range inputs {
+			stream := &mergeStream{pages: prefetchPages(ctx, client, input, options.Prefetch)}
+			if ok, err := stream.next(); err != nil {
+				yield(nil, err)
+				return
+			} else if ok {
+				merge.streams = append(merge.streams, stream)
+			}
+		}
+		heap.Init(merge)
+
+		for merge.Len() > 0 {
+			stream := merge.streams[0]
+			if !yield(stream.head(), nil) {
+				return
+			}
+
+			if ok, err := stream.next(); err != nil {
+				yield(nil, err)
+				return
+			} else if ok {
+				heap.Fix(merge, 0)
+			} else {
+				heap.Pop(merge)
+			}
+		}
+	}
+}
+
+// mergePage is a page of query results, or the error that ended the query.
+type mergePage struct {
+	items []Item
+	err   error
+}
+
+// prefetchPages paginates the query in the background, sending each page to the returned
+// channel, which is closed once the results are exhausted or the context is canceled.
+func prefetchPages(ctx context.Context, client DynamoDBClient, input *dynamodb.QueryInput, prefetch int) <-chan mergePage {
+	pages := make(chan mergePage, max(prefetch, 1)-1)
+	request := *input
+
+	go func() {
+		defer close(pages)
+		for {
+			output, err := client.Query(ctx, &request)
+			page := mergePage{err: err}
+			if err != nil {
+				page.err = fmt.Errorf("failed to query: %w", classify(err))
+			} else {
+				page.items = output.Items
+			}
+
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+
+			if err != nil || len(output.LastEvaluatedKey) == 0 {
+				return
+			}
+			request.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}()
+
+	return pages
+}
+
+// mergeStream is the position of the merge within the results of one query.
+type mergeStream struct {
+	pages <-chan mergePage
+	items []Item
+	pos   int
+}
+
+// head returns the current item of the stream.
+func (s *mergeStream) head() Item {
+	return s.items[s.pos]
+}
+
+// next advances the stream to its next item, waiting for the next page if needed. It
+// returns false once the stream is exhausted.
+func (s *mergeStream) next() (bool, error) {
+	if s.items != nil {
+		s.pos++
+	}
+
+	for s.pos >= len(s.items) {
+		page, ok := <-s.pages
+		if !ok {
+			return false, nil
+		}
+		if page.err != nil {
+			return false, page.err
+		}
+		s.items, s.pos = page.items, 0
+	}
+
+	return true, nil
+}
+
+// mergeHeap orders streams by the sort attribute of their current items.
+type mergeHeap struct {
+	options MergeOptions
+	streams []*mergeStream
+}
+
+func (h *mergeHeap) Len() int { return len(h.streams) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	c := compareAttributes(h.streams[i].head()[h.options.SortAttribute], h.streams[j].head()[h.options.SortAttribute])
+	if h.options.Descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.streams[i], h.streams[j] = h.streams[j], h.streams[i] }
+
+func (h *mergeHeap) Push(x any) { h.streams = append(h.streams, x.(*mergeStream)) }
+
+func (h *mergeHeap) Pop() any {
+	last := h.streams[len(h.streams)-1]
+	h.streams = h.streams[:len(h.streams)-1]
+	return last
+}
+
+// compareAttributes compares two sort key values of the same type. Missing values sort
+// first; values of differing types are compared by type name.
+func compareAttributes(a, b types.AttributeValue) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			x, _, errA := big.ParseFloat(a.Value, 10, 128, big.ToNearestEven)
+			y, _, errB := big.ParseFloat(b.Value, 10, 128, big.ToNearestEven)
+			if errA == nil && errB == nil {
+				return x.Cmp(y)
+			}
+			return strings.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value)
+		}
+	}
+
+	return strings.Compare(fmt.Sprintf("%T", a), fmt.Sprintf("%T", b))
+}
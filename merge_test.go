@@ -0,0 +1,119 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type failingQueryClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *failingQueryClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return nil, errors.New("throttled")
+}
+
+// Tests for merging sorted queries
+
+func TestMergeSortedQueries(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.LabelShards = LabelShards{"shipment": {Shards: 3}}
+	client := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 12 {
+		s := &shipment{ID: fmt.Sprintf("S%02d", i), Created: created.Add(time.Duration(i) * time.Hour)}
+		if err := table.Put(ctx, client, s); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+	}
+
+	inputs := func(t *testing.T, descending bool) []*dynamodb.QueryInput {
+		var inputs []*dynamodb.QueryInput
+		for shard := range 3 {
+			input, err := table.MarshalQuery(&QueryList{Label: fmt.Sprintf("shipment.%d", shard), Limit: 2, SortDescending: descending})
+			if err != nil {
+				t.Fatalf("Failed to marshal query: %v", err)
+			}
+			inputs = append(inputs, input)
+		}
+		return inputs
+	}
+
+	collect := func(t *testing.T, seq func(func(Item, error) bool), n int) []string {
+		var ids []string
+		for item, err := range seq {
+			if err != nil {
+				t.Fatalf("Failed to merge queries: %v", err)
+			}
+			ids = append(ids, item["hk"].(*types.AttributeValueMemberS).Value)
+			if len(ids) == n {
+				break
+			}
+		}
+		return ids
+	}
+
+	var want []string
+	for i := range 12 {
+		want = append(want, fmt.Sprintf("shipment#S%02d", i))
+	}
+
+	t.Run("ascending", func(t *testing.T) {
+		got := collect(t, MergeSortedQueries(ctx, client, inputs(t, false)), 0)
+		if !slices.Equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("descending with early stop", func(t *testing.T) {
+		seq := MergeSortedQueries(ctx, client, inputs(t, true), func(mo *MergeOptions) {
+			mo.Descending = true
+			mo.Prefetch = 2
+		})
+		got := collect(t, seq, 5)
+		reversed := slices.Clone(want)
+		slices.Reverse(reversed)
+		if !slices.Equal(got, reversed[:5]) {
+			t.Errorf("Expected %v, got %v", reversed[:5], got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		failing := &failingQueryClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		for _, err := range MergeSortedQueries(ctx, failing, inputs(t, false)) {
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			return
+		}
+		t.Error("Expected the error to be yielded")
+	})
+}
+
+func TestCompareAttributes(t *testing.T) {
+	tests := []struct {
+		a, b types.AttributeValue
+		want int
+	}{
+		{&types.AttributeValueMemberS{Value: "a"}, &types.AttributeValueMemberS{Value: "b"}, -1},
+		{&types.AttributeValueMemberN{Value: "10"}, &types.AttributeValueMemberN{Value: "9"}, 1},
+		{&types.AttributeValueMemberN{Value: "1.50"}, &types.AttributeValueMemberN{Value: "1.5"}, 0},
+		{&types.AttributeValueMemberB{Value: []byte{1}}, &types.AttributeValueMemberB{Value: []byte{2}}, -1},
+		{nil, &types.AttributeValueMemberS{Value: "a"}, -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareAttributes(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareAttributes(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
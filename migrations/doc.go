@@ -0,0 +1,24 @@
+// Package migrations applies versioned changes to the items of dynamap tables, such as
+// renamed labels, new key formats or new secondary index attributes.
+//
+// A [Migration] pairs a version with a function returning the [Change] to make to each
+// item. [Relabel], [Rekey] and [SetAttribute] cover common changes, and [Chain] combines
+// them. A [Runner] applies the pending migrations in version order, scanning the table
+// in parallel segments and writing the changes with batch writes, and records the
+// applied versions in a "migration#applied" item:
+//
+//	runner := migrations.NewRunner(table, dynamodb.NewFromConfig(cfg),
+//		migrations.Migration{Version: 1, Name: "rename customers", Migrate: migrations.Relabel("customer", "account")},
+//	)
+//
+//	// Preview the changes
+//	runner.DryRun = true
+//	report, err := runner.Run(ctx)
+//	for _, change := range report.Migrations[0].Changes {
+//		log.Println(migrations.Keys(change.Item))
+//	}
+//
+//	// Apply them
+//	runner.DryRun = false
+//	report, err = runner.Run(ctx)
+package migrations
@@ -0,0 +1,210 @@
+package migrations
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+// Migration is a versioned change to the items of a table, such as a new label, key
+// format or index attribute. Migrations are applied in version order, once each.
+type Migration struct {
+	Version int         // Unique, increasing version of the migration
+	Name    string      // Description of the migration, recorded when it is applied
+	Migrate MigrateFunc // Function called for every item of the table
+}
+
+// MigrateFunc returns the change to make to an item of the table, or an empty change to
+// leave it as is. Items may be scanned again after they are written, including items
+// written by the migration itself, so migrate functions must be idempotent: a migrated
+// item should produce an empty change.
+type MigrateFunc func(ctx context.Context, item dynamap.Item) (Change, error)
+
+// Change is the set of writes replacing a migrated item.
+type Change struct {
+	Put    []dynamap.Item // Items written, replacing any items with the same key
+	Delete []dynamap.Item // Items deleted; only their hash and sort keys are used
+}
+
+// Empty returns true if the change makes no writes.
+func (c Change) Empty() bool {
+	return len(c.Put) == 0 && len(c.Delete) == 0
+}
+
+// Chain returns a function applying each of fns in order to the items written by the
+// previous function. Items deleted by any function are deleted unless a later function
+// writes them again.
+func Chain(fns ...MigrateFunc) MigrateFunc {
+	return func(ctx context.Context, item dynamap.Item) (Change, error) {
+		var (
+			change  Change
+			current = []dynamap.Item{item}
+		)
+
+		for _, fn := range fns {
+			var next []dynamap.Item
+			for _, item := range current {
+				c, err := fn(ctx, item)
+				if err != nil {
+					return Change{}, err
+				}
+				if c.Empty() {
+					next = append(next, item)
+					continue
+				}
+				next = append(next, c.Put...)
+				change.Delete = append(change.Delete, c.Delete...)
+			}
+			current = next
+		}
+
+		if len(current) == 1 && sameKey(current[0], item) && maps.EqualFunc(current[0], item, equalValue) {
+			return Change{}, nil
+		}
+
+		change.Put = current
+		change.Delete = without(change.Delete, current)
+		return change, nil
+	}
+}
+
+// Relabel changes the label of items labeled from to to, such as after renaming an
+// entity type or relationship.
+//
+// Example:
+//
+//	migrations.Relabel("customer", "account")
+func Relabel(from, to string) MigrateFunc {
+	return SetAttribute(dynamap.AttributeNameLabel, func(item dynamap.Item) (types.AttributeValue, bool) {
+		if dynamap.StringValue(item[dynamap.AttributeNameLabel]) != from {
+			return nil, false
+		}
+		return &types.AttributeValueMemberS{Value: to}, true
+	})
+}
+
+// Rekey moves items to the hash and sort keys returned by key, such as after changing
+// the table key codec. Items are written under their new key before their old key is
+// deleted. Items for which key returns false, or their current keys, are left as is.
+//
+// Example:
+//
+//	migrations.Rekey(func(item dynamap.Item) (string, string, bool) {
+//		hk, sk := migrations.Keys(item)
+//		return strings.ToUpper(hk), strings.ToUpper(sk), true
+//	})
+func Rekey(key func(item dynamap.Item) (hk, sk string, ok bool)) MigrateFunc {
+	return func(ctx context.Context, item dynamap.Item) (Change, error) {
+		hk, sk, ok := key(item)
+		if oldHK, oldSK := Keys(item); !ok || hk == oldHK && sk == oldSK {
+			return Change{}, nil
+		}
+
+		moved := maps.Clone(item)
+		moved[dynamap.AttributeNameSource] = &types.AttributeValueMemberS{Value: hk}
+		moved[dynamap.AttributeNameTarget] = &types.AttributeValueMemberS{Value: sk}
+		return Change{Put: []dynamap.Item{moved}, Delete: []dynamap.Item{item}}, nil
+	}
+}
+
+// SetAttribute sets the attribute name of items to the value returned by value, such as
+// the key of a new secondary index. Items for which value returns false, or the current
+// value of the attribute, are left as is. A nil value removes the attribute.
+//
+// Example:
+//
+//	migrations.SetAttribute("gsi2_sk", func(item dynamap.Item) (types.AttributeValue, bool) {
+//		created, ok := item[dynamap.AttributeNameCreated]
+//		return created, ok
+//	})
+func SetAttribute(name string, value func(item dynamap.Item) (types.AttributeValue, bool)) MigrateFunc {
+	return func(ctx context.Context, item dynamap.Item) (Change, error) {
+		v, ok := value(item)
+		if current, exists := item[name]; !ok || v == nil && !exists || v != nil && exists && equalValue(current, v) {
+			return Change{}, nil
+		}
+
+		updated := maps.Clone(item)
+		if v == nil {
+			delete(updated, name)
+		} else {
+			updated[name] = v
+		}
+		return Change{Put: []dynamap.Item{updated}}, nil
+	}
+}
+
+// RemoveZeroTimes removes the created, updated and expires attributes of items holding the
+// zero time, such as the expirations in the past written for relationships without a
+// time to live before dynamap omitted them. Zero timestamps are recognized as RFC 3339
+// strings or epoch milliseconds, and zero expirations as any non-positive epoch.
+//
+// Example:
+//
+//	migrations.Migration{Version: 2, Name: "remove zero times", Migrate: migrations.RemoveZeroTimes()}
+func RemoveZeroTimes() MigrateFunc {
+	remove := func(name string, zero func(types.AttributeValue) bool) MigrateFunc {
+		return SetAttribute(name, func(item dynamap.Item) (types.AttributeValue, bool) {
+			value, ok := item[name]
+			return nil, ok && zero(value)
+		})
+	}
+
+	return Chain(
+		remove(dynamap.AttributeNameCreated, zeroTimestamp),
+		remove(dynamap.AttributeNameUpdated, zeroTimestamp),
+		remove(dynamap.AttributeNameExpires, func(value types.AttributeValue) bool {
+			n, ok := value.(*types.AttributeValueMemberN)
+			if !ok {
+				return false
+			}
+			seconds, err := strconv.ParseInt(n.Value, 10, 64)
+			return err == nil && seconds <= 0
+		}),
+	)
+}
+
+// zeroTimestamp returns true if value is a timestamp of the zero time.
+func zeroTimestamp(value types.AttributeValue) bool {
+	t, err := dynamap.EpochMillisTimestampCodec{}.DecodeTime(value)
+	return err == nil && t.IsZero()
+}
+
+// Keys returns the hash and sort keys of an item.
+func Keys(item dynamap.Item) (hk, sk string) {
+	return dynamap.StringValue(item[dynamap.AttributeNameSource]), dynamap.StringValue(item[dynamap.AttributeNameTarget])
+}
+
+// sameKey returns true if the items have the same hash and sort keys.
+func sameKey(a, b dynamap.Item) bool {
+	aHK, aSK := Keys(a)
+	bHK, bSK := Keys(b)
+	return aHK == bHK && aSK == bSK
+}
+
+// without returns the items whose keys are not among the keys of others.
+func without(items, others []dynamap.Item) []dynamap.Item {
+	var result []dynamap.Item
+	for _, item := range items {
+		kept := true
+		for _, other := range others {
+			if sameKey(item, other) {
+				kept = false
+				break
+			}
+		}
+		if kept {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// equalValue returns true if the attribute values are deeply equal.
+func equalValue(a, b types.AttributeValue) bool {
+	return reflect.DeepEqual(a, b)
+}
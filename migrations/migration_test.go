@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+func TestMigrateFuncs(t *testing.T) {
+	ctx := context.Background()
+	item := dynamap.Item{
+		dynamap.AttributeNameSource:  &types.AttributeValueMemberS{Value: "customer#C1"},
+		dynamap.AttributeNameTarget:  &types.AttributeValueMemberS{Value: "customer#C1"},
+		dynamap.AttributeNameLabel:   &types.AttributeValueMemberS{Value: "customer"},
+		dynamap.AttributeNameCreated: &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00Z"},
+	}
+
+	t.Run("set attribute", func(t *testing.T) {
+		index := SetAttribute("gsi2_sk", func(item dynamap.Item) (types.AttributeValue, bool) {
+			created, ok := item[dynamap.AttributeNameCreated]
+			return created, ok
+		})
+
+		change, err := index(ctx, item)
+		if err != nil || len(change.Put) != 1 || change.Put[0]["gsi2_sk"] == nil {
+			t.Fatalf("Expected the indexed item, got %+v, %v", change, err)
+		}
+		if _, ok := item["gsi2_sk"]; ok {
+			t.Error("Expected the scanned item to be left unmodified")
+		}
+		if change, _ := index(ctx, change.Put[0]); !change.Empty() {
+			t.Errorf("Expected the indexed item to be unchanged, got %+v", change)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		migrate := Chain(
+			Relabel("customer", "account"),
+			Rekey(func(item dynamap.Item) (string, string, bool) { return "account#C1", "account#C1", true }),
+			Relabel("missing", "unused"),
+		)
+
+		change, err := migrate(ctx, item)
+		if err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		if len(change.Put) != 1 || len(change.Delete) != 1 {
+			t.Fatalf("Expected one put and one delete, got %+v", change)
+		}
+		if hk, _ := Keys(change.Put[0]); hk != "account#C1" || dynamap.StringValue(change.Put[0][dynamap.AttributeNameLabel]) != "account" {
+			t.Errorf("Expected the relabeled account, got %v", change.Put[0])
+		}
+		if hk, _ := Keys(change.Delete[0]); hk != "customer#C1" {
+			t.Errorf("Expected the customer to be deleted, got %s", hk)
+		}
+
+		if change, _ := migrate(ctx, change.Put[0]); !change.Empty() {
+			t.Errorf("Expected the migrated item to be unchanged, got %+v", change)
+		}
+	})
+}
+
+func TestRemoveZeroTimes(t *testing.T) {
+	ctx := context.Background()
+	item := dynamap.Item{
+		dynamap.AttributeNameSource:  &types.AttributeValueMemberS{Value: "customer#C1"},
+		dynamap.AttributeNameTarget:  &types.AttributeValueMemberS{Value: "customer#C1"},
+		dynamap.AttributeNameCreated: &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00Z"},
+		dynamap.AttributeNameUpdated: &types.AttributeValueMemberS{Value: "0001-01-01T00:00:00Z"},
+		dynamap.AttributeNameExpires: &types.AttributeValueMemberN{Value: "-62135596800"},
+	}
+
+	migrate := RemoveZeroTimes()
+	change, err := migrate(ctx, item)
+	if err != nil || len(change.Put) != 1 {
+		t.Fatalf("Expected the cleaned item, got %+v, %v", change, err)
+	}
+
+	cleaned := change.Put[0]
+	if _, ok := cleaned[dynamap.AttributeNameUpdated]; ok {
+		t.Error("Expected the zero updated timestamp to be removed")
+	}
+	if _, ok := cleaned[dynamap.AttributeNameExpires]; ok {
+		t.Error("Expected the zero expiration to be removed")
+	}
+	if _, ok := cleaned[dynamap.AttributeNameCreated]; !ok {
+		t.Error("Expected the created timestamp to be kept")
+	}
+
+	if change, _ := migrate(ctx, cleaned); !change.Empty() {
+		t.Errorf("Expected the cleaned item to be unchanged, got %+v", change)
+	}
+}
@@ -0,0 +1,281 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nisimpson/dynamap"
+)
+
+const (
+	// DefaultSegments is the default number of segments scanned in parallel.
+	DefaultSegments = 4
+	// LedgerPrefix is the prefix of the item recording the applied migrations, whose
+	// hash and sort keys are "migration#applied".
+	LedgerPrefix = "migration"
+)
+
+// AppliedMigration records a migration applied to the table.
+type AppliedMigration struct {
+	Version   int       `dynamodbav:"version"`
+	Name      string    `dynamodbav:"name"`
+	AppliedAt time.Time `dynamodbav:"applied_at"`
+}
+
+// ledger is the item recording the applied migrations.
+type ledger struct {
+	Applied []AppliedMigration `dynamodbav:"applied"`
+}
+
+// MarshalSelf implements dynamap.Marshaler.
+func (l *ledger) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget(LedgerPrefix, "applied")
+	opts.Registry = nil // The ledger is internal and not a registered entity
+	return nil
+}
+
+// Report describes the migrations run by [Runner.Run].
+type Report struct {
+	DryRun     bool              // If true, no changes were written
+	Migrations []MigrationReport // The migrations run, in order
+}
+
+// MigrationReport describes the changes made by a migration.
+type MigrationReport struct {
+	Version int          // The migration version
+	Name    string       // The migration name
+	Scanned int          // Number of items scanned
+	Changed int          // Number of items changed
+	Puts    int          // Number of items written
+	Deletes int          // Number of items deleted
+	Changes []ItemChange // The change to each changed item; recorded in dry runs only
+}
+
+// ItemChange is the change made to a scanned item.
+type ItemChange struct {
+	Item   dynamap.Item // The item as scanned
+	Change Change       // The change replacing it
+}
+
+// Runner applies migrations to a table. Each pending migration scans the table in
+// parallel segments, writes the changes with batch writes, and is then recorded in the
+// ledger item so it is not applied again. Runs of the same table should not overlap.
+//
+// Example:
+//
+//	runner := migrations.NewRunner(table, client,
+//		migrations.Migration{Version: 1, Name: "rename customers", Migrate: migrations.Relabel("customer", "account")},
+//		migrations.Migration{Version: 2, Name: "index by creation", Migrate: migrations.SetAttribute("gsi2_sk", createdAt)},
+//	)
+//	runner.DryRun = true
+//	report, err := runner.Run(ctx)
+type Runner struct {
+	Table       *dynamap.Table            // The migrated table
+	Client      dynamap.MaintenanceClient // The client scanning and writing the table
+	Migrations  []Migration               // The migrations, applied in version order
+	Segments    int                       // Number of segments scanned in parallel. Default is DefaultSegments.
+	DryRun      bool                      // If true, changes are reported without being written or recorded
+	RateLimiter *dynamap.RateLimiter      // Optional limiter pacing the scans and writes, e.g. to leave capacity for production traffic
+}
+
+// NewRunner creates a new [Runner] of the migrations.
+func NewRunner(table *dynamap.Table, client dynamap.MaintenanceClient, migrations ...Migration) *Runner {
+	return &Runner{Table: table, Client: client, Migrations: migrations, Segments: DefaultSegments}
+}
+
+// client returns the client of the runner, paced by its rate limiter if it has one.
+func (r *Runner) client() dynamap.MaintenanceClient {
+	if r.RateLimiter == nil {
+		return r.Client
+	}
+	return r.RateLimiter.LimitClient(r.Client)
+}
+
+// Applied returns the migrations recorded as applied to the table, in the order they
+// were applied.
+func (r *Runner) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	var l ledger
+	if _, err := r.Table.Get(ctx, r.client(), &ledger{}, &l); errors.Is(err, dynamap.ErrItemNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return l.Applied, nil
+}
+
+// Run applies the pending migrations in version order, stopping at the first failure.
+// In a dry run, every pending migration scans the table as it is, so a migration does
+// not see the changes of the migrations before it.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	pending := slices.Clone(r.Migrations)
+	slices.SortFunc(pending, func(a, b Migration) int { return a.Version - b.Version })
+	for i := 1; i < len(pending); i++ {
+		if pending[i].Version == pending[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", pending[i].Version)
+		}
+	}
+
+	applied, err := r.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: r.DryRun}
+	for _, migration := range pending {
+		if slices.ContainsFunc(applied, func(a AppliedMigration) bool { return a.Version == migration.Version }) {
+			continue
+		}
+
+		result, err := r.migrate(ctx, migration)
+		report.Migrations = append(report.Migrations, result)
+		if err != nil {
+			return report, fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+		}
+		if r.DryRun {
+			continue
+		}
+
+		applied = append(applied, AppliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: dynamap.NewMarshalOptions(r.Table.MarshalOptions).Tick()})
+		if err := r.Table.Put(ctx, r.client(), &ledger{Applied: applied}); err != nil {
+			return report, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+	}
+
+	return report, nil
+}
+
+// migrate scans the table in parallel segments, applying the migration to each item.
+func (r *Runner) migrate(ctx context.Context, migration Migration) (MigrationReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	segments := r.Segments
+	if segments <= 0 {
+		segments = DefaultSegments
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		errs   = make([]error, segments)
+		report = MigrationReport{Version: migration.Version, Name: migration.Name}
+	)
+
+	for segment := range segments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[segment] = r.migrateSegment(ctx, migration, segment, segments, &mu, &report); errs[segment] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return report, err
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// migrateSegment scans a segment of the table, applying the migration to each item and
+// writing the changes in batches.
+func (r *Runner) migrateSegment(ctx context.Context, migration Migration, segment, segments int, mu *sync.Mutex, report *MigrationReport) error {
+	var puts, deletes []dynamap.Item
+
+	flush := func() error {
+		if r.DryRun {
+			puts, deletes = nil, nil
+			return nil
+		}
+		// Items are written before others are deleted, so an interrupted migration
+		// leaves duplicates rather than losing items.
+		if len(puts) > 0 {
+			if err := r.Table.PutItems(ctx, r.client(), puts); err != nil {
+				return err
+			}
+		}
+		if len(deletes) > 0 {
+			if err := r.Table.DeleteItems(ctx, r.client(), deletes); err != nil {
+				return err
+			}
+		}
+		puts, deletes = nil, nil
+		return nil
+	}
+
+	ledgerKey, err := r.ledgerKey()
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:     aws.String(r.Table.TableName),
+		Segment:       aws.Int32(int32(segment)),
+		TotalSegments: aws.Int32(int32(segments)),
+	}
+
+	for {
+		output, err := r.client().Scan(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to scan segment %d: %w", segment, err)
+		}
+
+		for _, item := range output.Items {
+			if hk, _ := Keys(item); hk == ledgerKey {
+				continue
+			}
+
+			change, err := migration.Migrate(ctx, item)
+			if err != nil {
+				hk, sk := Keys(item)
+				return fmt.Errorf("failed to migrate item %s %s: %w", hk, sk, err)
+			}
+
+			mu.Lock()
+			report.Scanned++
+			if !change.Empty() {
+				report.Changed++
+				report.Puts += len(change.Put)
+				report.Deletes += len(change.Delete)
+				if r.DryRun {
+					report.Changes = append(report.Changes, ItemChange{Item: item, Change: change})
+				}
+			}
+			mu.Unlock()
+
+			puts = append(puts, change.Put...)
+			deletes = append(deletes, change.Delete...)
+			if len(puts)+len(deletes) >= dynamap.MaxBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	return flush()
+}
+
+// ledgerKey returns the hash key of the ledger item, which is not migrated.
+func (r *Runner) ledgerKey() (string, error) {
+	input, err := r.Table.MarshalGet(&ledger{})
+	if err != nil {
+		return "", err
+	}
+	hk, _ := Keys(input.Key)
+	return hk, nil
+}
@@ -0,0 +1,195 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nisimpson/dynamap"
+)
+
+type customer struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (c *customer) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("customer", c.ID)
+	return nil
+}
+
+// memoryClient stores items in memory and scans them by segment, one item per page.
+type memoryClient struct {
+	dynamap.DynamoDBClient
+	mu    sync.Mutex
+	items map[string]dynamap.Item
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{items: make(map[string]dynamap.Item)}
+}
+
+func itemKey(item dynamap.Item) string {
+	hk, sk := Keys(item)
+	return hk + "|" + sk
+}
+
+func (c *memoryClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[itemKey(params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *memoryClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: c.items[itemKey(params.Key)]}, nil
+}
+
+func (c *memoryClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, requests := range params.RequestItems {
+		for _, request := range requests {
+			if request.PutRequest != nil {
+				c.items[itemKey(request.PutRequest.Item)] = request.PutRequest.Item
+			}
+			if request.DeleteRequest != nil {
+				delete(c.items, itemKey(request.DeleteRequest.Key))
+			}
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *memoryClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	for key := range c.items {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		if int32(h.Sum32()%uint32(aws.ToInt32(params.TotalSegments))) == aws.ToInt32(params.Segment) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if params.ExclusiveStartKey != nil {
+		start := itemKey(params.ExclusiveStartKey)
+		keys = keys[sort.Search(len(keys), func(i int) bool { return keys[i] > start }):]
+	}
+	if len(keys) == 0 {
+		return &dynamodb.ScanOutput{}, nil
+	}
+
+	item := c.items[keys[0]]
+	output := &dynamodb.ScanOutput{Items: []dynamap.Item{item}}
+	if len(keys) > 1 {
+		output.LastEvaluatedKey = item
+	}
+	return output, nil
+}
+
+func seedCustomers(t *testing.T, table *dynamap.Table, client *memoryClient, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := table.Put(context.Background(), client, &customer{ID: id}); err != nil {
+			t.Fatalf("Failed to put customer: %v", err)
+		}
+	}
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+
+	relabel := Migration{Version: 1, Name: "rename customers", Migrate: Relabel("customer", "account")}
+	rekey := Migration{Version: 2, Name: "rekey accounts", Migrate: Rekey(func(item dynamap.Item) (string, string, bool) {
+		hk, sk := Keys(item)
+		return strings.Replace(hk, "customer#", "account#", 1), strings.Replace(sk, "customer#", "account#", 1), true
+	})}
+
+	t.Run("dry run", func(t *testing.T) {
+		client := newMemoryClient()
+		seedCustomers(t, table, client, "C1", "C2", "C3")
+
+		runner := NewRunner(table, client, rekey, relabel)
+		runner.DryRun = true
+		report, err := runner.Run(ctx)
+		if err != nil {
+			t.Fatalf("Failed to run migrations: %v", err)
+		}
+
+		if len(report.Migrations) != 2 || report.Migrations[0].Version != 1 {
+			t.Fatalf("Expected migrations in version order, got %+v", report.Migrations)
+		}
+		if r := report.Migrations[1]; r.Scanned != 3 || r.Changed != 3 || r.Puts != 3 || r.Deletes != 3 || len(r.Changes) != 3 {
+			t.Errorf("Unexpected report %+v", r)
+		}
+		if _, ok := client.items["customer#C1|customer#C1"]; !ok || len(client.items) != 3 {
+			t.Error("Expected the dry run to leave items unchanged")
+		}
+		if applied, _ := runner.Applied(ctx); len(applied) != 0 {
+			t.Errorf("Expected no applied migrations, got %+v", applied)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		client := newMemoryClient()
+		seedCustomers(t, table, client, "C1", "C2", "C3")
+
+		runner := NewRunner(table, client, relabel, rekey)
+		if _, err := runner.Run(ctx); err != nil {
+			t.Fatalf("Failed to run migrations: %v", err)
+		}
+
+		item, ok := client.items["account#C2|account#C2"]
+		if !ok || item[dynamap.AttributeNameLabel].(*types.AttributeValueMemberS).Value != "account" {
+			t.Errorf("Expected the relabeled and rekeyed account, got %v", client.items)
+		}
+		if len(client.items) != 4 {
+			t.Errorf("Expected 3 accounts and the ledger, got %d items", len(client.items))
+		}
+
+		applied, err := runner.Applied(ctx)
+		if err != nil || len(applied) != 2 || applied[1].Name != "rekey accounts" {
+			t.Errorf("Expected 2 applied migrations, got %+v, %v", applied, err)
+		}
+
+		report, err := runner.Run(ctx)
+		if err != nil || len(report.Migrations) != 0 {
+			t.Errorf("Expected applied migrations to be skipped, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		client := newMemoryClient()
+		seedCustomers(t, table, client, "C1")
+
+		failure := errors.New("failure")
+		runner := NewRunner(table, client, Migration{Version: 1, Migrate: func(ctx context.Context, item dynamap.Item) (Change, error) {
+			return Change{}, failure
+		}})
+		if _, err := runner.Run(ctx); !errors.Is(err, failure) {
+			t.Errorf("Expected the migration error, got %v", err)
+		}
+		if applied, _ := runner.Applied(ctx); len(applied) != 0 {
+			t.Errorf("Expected the failed migration not to be recorded, got %+v", applied)
+		}
+	})
+
+	t.Run("duplicate versions", func(t *testing.T) {
+		if _, err := NewRunner(table, newMemoryClient(), relabel, relabel).Run(ctx); err == nil {
+			t.Error("Expected an error for duplicate versions")
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PositionKey returns the ref sort key of an ordered relationship at the zero-based
+// position. Keys are zero-padded so they sort in position order.
+func PositionKey(position int) string {
+	return fmt.Sprintf("%010d", position)
+}
+
+// refSortKey returns the ref sort key of item, or an empty string if it has none.
+func (mo MarshalOptions) refSortKey(item Item) string {
+	var key string
+	if value, ok := mo.unaliasItem(item)[AttributeNameRefSortKey]; ok {
+		_ = attributevalue.Unmarshal(value, &key)
+	}
+	return key
+}
+
+// sortByRefSortKey returns a copy of items sorted by ref sort key. Items without a ref
+// sort key, such as the self relationship, sort first.
+func (mo MarshalOptions) sortByRefSortKey(items []Item) []Item {
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, func(a, b Item) int {
+		return strings.Compare(mo.refSortKey(a), mo.refSortKey(b))
+	})
+
+	return items
+}
+
+// MarshalReorder marshals transactions that rewrite the positions of the relationships
+// named name between source and targets, so that targets are in the given order. Each
+// update is conditioned on the relationship existing. Up to 100 relationships are
+// reordered atomically; larger collections are split across transactions.
+func (t *Table) MarshalReorder(source Marshaler, name string, targets []Marshaler, opts ...func(*MarshalOptions)) ([]*dynamodb.TransactWriteItemsInput, error) {
+	_, edges, _, err := t.marshalEdges(source, name, targets, EdgeOptions{MarshalOptions: opts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edges: %w", err)
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	var transactions []*dynamodb.TransactWriteItemsInput
+	for chunk := range slices.Chunk(edges, MaxTransactSize) {
+		var actions []types.TransactWriteItem
+		for _, edge := range chunk {
+			position := len(transactions)*MaxTransactSize + len(actions)
+
+			update := marshalOpts.touch(expression.Set(expression.Name(AttributeNameRefSortKey), expression.Value(PositionKey(position))), time.RFC3339Nano)
+
+			expr, err := expression.NewBuilder().
+				WithUpdate(update).
+				WithCondition(expression.AttributeExists(expression.Name(AttributeNameSource))).
+				Build()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build update expression: %w", err)
+			}
+
+			reorder := &types.Update{
+				TableName:                 aws.String(t.TableName),
+				Key:                       edge.itemKey(),
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeValues: expr.Values(),
+			}
+			reorder.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &reorder.UpdateExpression, &reorder.ConditionExpression)
+			actions = append(actions, types.TransactWriteItem{Update: reorder})
+		}
+
+		transactions = append(transactions, &dynamodb.TransactWriteItemsInput{TransactItems: actions})
+	}
+
+	return transactions, nil
+}
+
+// Reorder marshals the position updates using [Table.MarshalReorder] and executes them.
+func (t *Table) Reorder(ctx context.Context, client DynamoDBClient, source Marshaler, name string, targets []Marshaler, opts ...func(*MarshalOptions)) error {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	transactions, err := t.MarshalReorder(source, name, targets, opts...)
+	if err != nil {
+		return err
+	}
+	return transactWriteAll(ctx, client, transactions)
+}
@@ -0,0 +1,101 @@
+package dynamap
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type playlist struct {
+	ID    string    `dynamodbav:"id"`
+	Songs []Product `dynamodbav:"-"`
+	order []string
+}
+
+func (p *playlist) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("playlist", p.ID)
+	return nil
+}
+
+func (p *playlist) MarshalRefs(ctx *RelationshipContext) error {
+	songs := make([]Marshaler, len(p.Songs))
+	for i := range p.Songs {
+		songs[i] = &p.Songs[i]
+	}
+	ctx.AddManyOrdered("songs", songs)
+	return nil
+}
+
+func (p *playlist) UnmarshalRef(name string, id string, ref *Relationship) error {
+	p.order = append(p.order, ref.Target)
+	return nil
+}
+
+// Tests for ordered relationships
+
+func TestOrderedRefs(t *testing.T) {
+	table := NewTable("test-table")
+	songs := []Product{{ID: "S3"}, {ID: "S1"}, {ID: "S2"}}
+
+	batches, err := table.MarshalBatch(&playlist{ID: "L1", Songs: songs})
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+
+	var items []Item
+	for _, request := range batches[0].RequestItems["test-table"] {
+		items = append(items, request.PutRequest.Item)
+	}
+
+	t.Run("positions", func(t *testing.T) {
+		for i, item := range items[1:] {
+			if key := item["gsi1_sk"].(*types.AttributeValueMemberS).Value; key != PositionKey(i) {
+				t.Errorf("Expected position %s, got %s", PositionKey(i), key)
+			}
+		}
+	})
+
+	t.Run("unmarshal in position order", func(t *testing.T) {
+		// Items are returned by the table in sort key order
+		sorted := slices.Clone(items)
+		slices.Reverse(sorted)
+
+		out := &playlist{}
+		if _, err := UnmarshalEntity(sorted, out, func(mo *MarshalOptions) { mo.OrderRefs = true }); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+
+		expected := []string{"product#S3", "product#S1", "product#S2"}
+		if !slices.Equal(out.order, expected) {
+			t.Errorf("Expected order %v, got %v", expected, out.order)
+		}
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		transactions, err := table.MarshalReorder(&playlist{ID: "L1"}, "songs", SliceOf(&Product{ID: "S1"}, &Product{ID: "S2"}, &Product{ID: "S3"}))
+		if err != nil {
+			t.Fatalf("Failed to marshal reorder: %v", err)
+		}
+
+		actions := transactions[0].TransactItems
+		if len(actions) != 3 {
+			t.Fatalf("Expected 3 updates, got %d", len(actions))
+		}
+
+		update := actions[2].Update
+		if sk := update.Key["sk"].(*types.AttributeValueMemberS).Value; sk != "product#S3" {
+			t.Errorf("Expected update of product#S3, got %s", sk)
+		}
+
+		var found bool
+		for _, value := range update.ExpressionAttributeValues {
+			if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value == PositionKey(2) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected position %s for product#S3", PositionKey(2))
+		}
+	})
+}
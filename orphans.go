@@ -0,0 +1,284 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// QuarantinePrefix is the key prefix and label of quarantined orphans. An orphan of
+	// the source "order#O1" is moved to the partition "orphan#order#O1", and all orphans
+	// are listed by querying the ref index for the "orphan" label.
+	QuarantinePrefix = "orphan"
+	// AttributeNameOrphanLabel is the attribute holding the original label of a
+	// quarantined orphan.
+	AttributeNameOrphanLabel = "orphan_label"
+)
+
+// OrphanAction is the action taken on the orphans found by [Table.FindOrphans] and
+// [Table.CheckOrphans].
+type OrphanAction string
+
+const (
+	OrphanActionReport     OrphanAction = "report"     // Orphans are reported only
+	OrphanActionDelete     OrphanAction = "delete"     // Orphans are deleted
+	OrphanActionQuarantine OrphanAction = "quarantine" // Orphans are moved to a quarantine partition
+)
+
+// OrphanOptions configures [Table.FindOrphans] and [Table.CheckOrphans].
+type OrphanOptions struct {
+	Action        OrphanAction // The action taken on orphans. Default is OrphanActionReport.
+	Segment       int          // The segment scanned by this worker, for parallel scans
+	TotalSegments int          // The number of parallel workers. Zero scans the whole table.
+	PageSize      int32        // Maximum number of items read per scan request. Zero uses the DynamoDB default.
+	RateLimiter   *RateLimiter // Optional limiter pacing the scan, target checks and writes
+}
+
+// Orphan is a relationship item whose target self relationship does not exist.
+type Orphan struct {
+	Source string // The source key of the relationship
+	Target string // The target key of the missing entity
+	Name   string // The relationship name
+	Item   Item   // The relationship item, as read
+}
+
+// OrphanReport describes the orphans found by [Table.FindOrphans] and [Table.CheckOrphans].
+type OrphanReport struct {
+	Scanned     int      // Number of items read
+	Checked     int      // Number of relationship items whose target was checked
+	Orphans     []Orphan // The orphans found
+	Deleted     int      // Number of orphans deleted
+	Quarantined int      // Number of orphans quarantined
+}
+
+// FindOrphans scans the table for relationship items whose target self relationship no
+// longer exists, such as refs left behind by deletes that did not cascade. Targets are
+// checked with batch get requests reading only their keys; if [Table.FilterExpired] is
+// set, expired targets do not exist. Items whose labels cannot be decoded by the table's
+// label codec, such as those of other tenants, are skipped.
+//
+// Orphans are reported, deleted or quarantined according to [OrphanOptions.Action].
+// Quarantined orphans are moved to the partition of their source key prefixed by
+// [QuarantinePrefix] and labeled with it, keeping their original label in
+// [AttributeNameOrphanLabel]. Run workers with different [OrphanOptions.Segment] values to
+// scan in parallel.
+//
+// Example:
+//
+//	report, err := table.FindOrphans(ctx, client, func(o *dynamap.OrphanOptions) {
+//		o.Action = dynamap.OrphanActionQuarantine
+//	})
+//	for _, orphan := range report.Orphans {
+//		log.Printf("%s -> %s (%s)", orphan.Source, orphan.Target, orphan.Name)
+//	}
+func (t *Table) FindOrphans(ctx context.Context, client MaintenanceClient, opts ...func(*OrphanOptions)) (OrphanReport, error) {
+	var (
+		options OrphanOptions
+		report  OrphanReport
+	)
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.RateLimiter != nil {
+		client = options.RateLimiter.LimitClient(client)
+	}
+
+	input := &dynamodb.ScanInput{TableName: aws.String(t.TableName)}
+	if options.TotalSegments > 0 {
+		input.Segment = aws.Int32(int32(options.Segment))
+		input.TotalSegments = aws.Int32(int32(options.TotalSegments))
+	}
+	if options.PageSize > 0 {
+		input.Limit = aws.Int32(options.PageSize)
+	}
+
+	for {
+		output, err := client.Scan(ctx, input)
+		if err != nil {
+			return report, fmt.Errorf("failed to scan table %s: %w", t.TableName, classify(err))
+		}
+
+		report.Scanned += len(output.Items)
+		if err := t.checkOrphans(ctx, client, output.Items, options, &report); err != nil {
+			return report, err
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	return report, nil
+}
+
+// CheckOrphans checks the relationship items changed by events, as decoded from a table
+// stream, and handles the orphans among them like [Table.FindOrphans]. It processes
+// changes incrementally instead of scanning the table:
+//   - inserted or modified relationships are orphans if their target does not exist, and
+//   - removed entities orphan the relationships pointing to them that are mirrored in
+//     their own partition, such as those written with [EdgeOptions.Inverse].
+//
+// Relationships without a mirror are only found by [Table.FindOrphans]. Scanning and
+// segment options are ignored.
+//
+// Example:
+//
+//	report, err := table.CheckOrphans(ctx, client, events, func(o *dynamap.OrphanOptions) {
+//		o.Action = dynamap.OrphanActionDelete
+//	})
+func (t *Table) CheckOrphans(ctx context.Context, client DynamoDBClient, events []ChangeEvent, opts ...func(*OrphanOptions)) (OrphanReport, error) {
+	var (
+		options     OrphanOptions
+		report      OrphanReport
+		items       []Item
+		mirrored    []Item
+		marshalOpts = NewMarshalOptions(t.MarshalOptions)
+	)
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.RateLimiter != nil {
+		client = options.RateLimiter.limitWrites(client)
+	}
+
+	for _, event := range events {
+		switch {
+		case event.Type != ChangeRemove && event.IsRef():
+			items = append(items, event.NewImage)
+		case event.Type == ChangeRemove && event.IsSelf():
+			removed := event.Relationship.Source
+			partition, err := t.queryPartition(ctx, t.client(client), removed)
+			if err != nil {
+				return report, err
+			}
+			// The mirror of a relationship pointing to the removed entity has the
+			// opposite key
+			for _, item := range partition {
+				if _, ok := marshalOpts.orphanCandidate(item); ok {
+					mirrored = append(mirrored, Item{
+						AttributeNameSource: item[AttributeNameTarget],
+						AttributeNameTarget: item[AttributeNameSource],
+					})
+				}
+			}
+		}
+	}
+
+	for _, batch := range t.chunkBatchGet(mirrored) {
+		responses, err := batchGetAll(ctx, t.client(client), batch)
+		if err != nil {
+			return report, err
+		}
+		items = append(items, responses[t.TableName]...)
+	}
+
+	report.Scanned = len(items)
+	return report, t.checkOrphans(ctx, client, items, options, &report)
+}
+
+// checkOrphans checks the targets of the relationship items among items, and handles the
+// orphans found according to options.
+func (t *Table) checkOrphans(ctx context.Context, client DynamoDBClient, items []Item, options OrphanOptions, report *OrphanReport) error {
+	var (
+		marshalOpts = NewMarshalOptions(t.MarshalOptions)
+		candidates  []Orphan
+		keys        []Item
+		seen        = make(map[string]bool)
+	)
+
+	for _, item := range items {
+		orphan, ok := marshalOpts.orphanCandidate(item)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, orphan)
+		if !seen[orphan.Target] {
+			keys = append(keys, Relationship{Source: orphan.Target, Target: orphan.Target}.itemKey())
+			seen[orphan.Target] = true
+		}
+	}
+	report.Checked += len(candidates)
+
+	existing, err := t.existingKeys(ctx, t.client(client), keys, marshalOpts)
+	if err != nil {
+		return err
+	}
+
+	var puts, deletes []Item
+	for _, orphan := range candidates {
+		if existing[orphan.Target+"\x00"+orphan.Target] {
+			continue
+		}
+		report.Orphans = append(report.Orphans, orphan)
+
+		switch options.Action {
+		case OrphanActionDelete:
+			deletes = append(deletes, marshalOpts.unaliasItem(orphan.Item))
+		case OrphanActionQuarantine:
+			puts = append(puts, marshalOpts.quarantineItem(orphan.Item))
+			deletes = append(deletes, marshalOpts.unaliasItem(orphan.Item))
+		}
+	}
+
+	// Quarantined copies are written before the orphans are deleted, so an interrupted
+	// run leaves duplicates rather than losing items.
+	if len(puts) > 0 {
+		if err := t.PutItems(ctx, client, puts); err != nil {
+			return fmt.Errorf("failed to quarantine orphans: %w", err)
+		}
+		report.Quarantined += len(puts)
+	}
+	if len(deletes) > 0 {
+		if err := t.DeleteItems(ctx, client, deletes); err != nil {
+			return fmt.Errorf("failed to delete orphans: %w", err)
+		}
+		if options.Action == OrphanActionDelete {
+			report.Deleted += len(deletes)
+		}
+	}
+
+	return nil
+}
+
+// orphanCandidate returns the orphan that item would be if its target did not exist, or
+// false if item is not a relationship between two entities.
+func (mo MarshalOptions) orphanCandidate(item Item) (Orphan, bool) {
+	canonical := mo.unaliasItem(item)
+	var (
+		source = StringValue(canonical[AttributeNameSource])
+		target = StringValue(canonical[AttributeNameTarget])
+		label  = StringValue(canonical[AttributeNameLabel])
+	)
+
+	if !(Relationship{Source: source, Target: target, Label: label}).IsRef() {
+		return Orphan{}, false
+	}
+	_, _, name, err := mo.labelCodec().DecodeLabel(label)
+	if err != nil || name == "" {
+		return Orphan{}, false
+	}
+
+	return Orphan{Source: source, Target: target, Name: name, Item: item}, true
+}
+
+// quarantineItem returns the copy of an orphaned item moved to its quarantine partition.
+func (mo MarshalOptions) quarantineItem(item Item) Item {
+	quarantined := maps.Clone(mo.unaliasItem(item))
+	source := StringValue(quarantined[AttributeNameSource])
+
+	quarantined[AttributeNameOrphanLabel] = quarantined[AttributeNameLabel]
+	quarantined[AttributeNameSource] = &types.AttributeValueMemberS{
+		Value: mo.keyCodec().EncodeKey(QuarantinePrefix, strings.TrimPrefix(source, mo.tenantPrefix())),
+	}
+	quarantined[AttributeNameLabel] = &types.AttributeValueMemberS{Value: mo.tenantLabel(QuarantinePrefix)}
+	mo.refIndexHashItem(quarantined)
+
+	return mo.aliasItem(quarantined)
+}
@@ -0,0 +1,206 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// orphanClient scans every stored item in key order, and answers queries with the items
+// of the partition matching the key condition value.
+type orphanClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *orphanClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	output := &dynamodb.ScanOutput{}
+	for _, key := range slices.Sorted(maps.Keys(c.items)) {
+		output.Items = append(output.Items, c.items[key])
+	}
+	return output, nil
+}
+
+func (c *orphanClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	output := &dynamodb.QueryOutput{}
+	for _, value := range params.ExpressionAttributeValues {
+		for _, key := range slices.Sorted(maps.Keys(c.items)) {
+			if item := c.items[key]; StringValue(item["hk"]) == StringValue(value) {
+				output.Items = append(output.Items, item)
+			}
+		}
+	}
+	return output, nil
+}
+
+// Tests for orphan detection
+
+func TestFindOrphans(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+
+	newClient := func(t *testing.T) *orphanClient {
+		client := &orphanClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		for _, batch := range batches {
+			if _, err := client.BatchWriteItem(ctx, batch); err != nil {
+				t.Fatalf("Failed to write batch: %v", err)
+			}
+		}
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+		return client
+	}
+
+	t.Run("reports orphans", func(t *testing.T) {
+		client := newClient(t)
+		report, err := table.FindOrphans(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to find orphans: %v", err)
+		}
+		if report.Scanned != 4 || report.Checked != 2 {
+			t.Errorf("Expected 4 items scanned and 2 checked, got %d and %d", report.Scanned, report.Checked)
+		}
+		if len(report.Orphans) != 1 {
+			t.Fatalf("Expected 1 orphan, got %d", len(report.Orphans))
+		}
+		orphan := report.Orphans[0]
+		if orphan.Source != "order#O1" || orphan.Target != "product#P2" || orphan.Name != "products" {
+			t.Errorf("Unexpected orphan %+v", orphan)
+		}
+		if _, ok := client.items["order#O1#product#P2"]; !ok {
+			t.Error("Expected orphan to be kept")
+		}
+	})
+
+	t.Run("deletes orphans", func(t *testing.T) {
+		client := newClient(t)
+		report, err := table.FindOrphans(ctx, client, func(o *OrphanOptions) { o.Action = OrphanActionDelete })
+		if err != nil {
+			t.Fatalf("Failed to find orphans: %v", err)
+		}
+		if report.Deleted != 1 {
+			t.Errorf("Expected 1 deleted orphan, got %d", report.Deleted)
+		}
+		if _, ok := client.items["order#O1#product#P2"]; ok {
+			t.Error("Expected orphan to be deleted")
+		}
+		if _, ok := client.items["order#O1#product#P1"]; !ok {
+			t.Error("Expected ref to existing product to be kept")
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		client := newClient(t)
+		limiter, waited := newTestRateLimiter(0.5, 0)
+		report, err := table.FindOrphans(ctx, client, func(o *OrphanOptions) { o.RateLimiter = limiter })
+		if err != nil {
+			t.Fatalf("Failed to find orphans: %v", err)
+		}
+		if len(report.Orphans) != 1 {
+			t.Errorf("Expected 1 orphan, got %d", len(report.Orphans))
+		}
+		// The scan empties the bucket, and the target checks wait for their units
+		if *waited == 0 {
+			t.Error("Expected the limiter to pace the reads")
+		}
+	})
+
+	t.Run("quarantines orphans", func(t *testing.T) {
+		client := newClient(t)
+		report, err := table.FindOrphans(ctx, client, func(o *OrphanOptions) { o.Action = OrphanActionQuarantine })
+		if err != nil {
+			t.Fatalf("Failed to find orphans: %v", err)
+		}
+		if report.Quarantined != 1 || report.Deleted != 0 {
+			t.Errorf("Expected 1 quarantined and 0 deleted orphans, got %d and %d", report.Quarantined, report.Deleted)
+		}
+		if _, ok := client.items["order#O1#product#P2"]; ok {
+			t.Error("Expected orphan to be moved")
+		}
+		quarantined, ok := client.items["orphan#order#O1#product#P2"]
+		if !ok {
+			t.Fatal("Expected quarantined orphan")
+		}
+		if got := StringValue(quarantined[AttributeNameLabel]); got != QuarantinePrefix {
+			t.Errorf("Expected label %q, got %q", QuarantinePrefix, got)
+		}
+		if got := StringValue(quarantined[AttributeNameOrphanLabel]); got != "order/O1/products" {
+			t.Errorf("Expected original label 'order/O1/products', got %q", got)
+		}
+
+		// Quarantined orphans are not checked again
+		report, err = table.FindOrphans(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to find orphans: %v", err)
+		}
+		if len(report.Orphans) != 0 {
+			t.Errorf("Expected no orphans, got %d", len(report.Orphans))
+		}
+	})
+}
+
+func TestCheckOrphans(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := &orphanClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	event := func(t *testing.T, oldImage, newImage Item) ChangeEvent {
+		t.Helper()
+		event, err := UnmarshalStreamRecord(oldImage, newImage, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal stream record: %v", err)
+		}
+		return event
+	}
+
+	for _, product := range []*Product{{ID: "P1"}, {ID: "P2"}} {
+		if err := table.Put(ctx, client, product); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+	}
+	err := table.Attach(ctx, client, &Order{ID: "O1"}, "products", []Marshaler{&Product{ID: "P1"}, &Product{ID: "P2"}}, func(o *EdgeOptions) {
+		o.Inverse = "orders"
+	})
+	if err != nil {
+		t.Fatalf("Failed to attach products: %v", err)
+	}
+
+	t.Run("inserted refs", func(t *testing.T) {
+		ref := client.items["order#O1#product#P1"]
+		report, err := table.CheckOrphans(ctx, client, []ChangeEvent{event(t, nil, ref)})
+		if err != nil {
+			t.Fatalf("Failed to check orphans: %v", err)
+		}
+		if report.Checked != 1 || len(report.Orphans) != 0 {
+			t.Errorf("Expected 1 checked ref and no orphans, got %d and %d", report.Checked, len(report.Orphans))
+		}
+	})
+
+	t.Run("removed targets", func(t *testing.T) {
+		self := client.items["product#P2#product#P2"]
+		delete(client.items, "product#P2#product#P2")
+
+		report, err := table.CheckOrphans(ctx, client, []ChangeEvent{event(t, self, nil)}, func(o *OrphanOptions) {
+			o.Action = OrphanActionDelete
+		})
+		if err != nil {
+			t.Fatalf("Failed to check orphans: %v", err)
+		}
+		if len(report.Orphans) != 1 || report.Orphans[0].Target != "product#P2" {
+			t.Fatalf("Expected orphan of product#P2, got %+v", report.Orphans)
+		}
+		if _, ok := client.items["order#O1#product#P2"]; ok {
+			t.Error("Expected orphan to be deleted")
+		}
+		if _, ok := client.items["order#O1#product#P1"]; !ok {
+			t.Error("Expected ref to existing product to be kept")
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package dynamap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// OutboxPrefix is the key prefix and label of outbox event items.
+	OutboxPrefix = "outbox"
+	// AttributeNameClaimedBy is the attribute that identifies the owner of a claimed event.
+	AttributeNameClaimedBy = "claimed_by"
+	// AttributeNameClaimedUntil is the attribute that stores when a claim expires, in unix
+	// milliseconds.
+	AttributeNameClaimedUntil = "claimed_until"
+	// DefaultLeaseDuration is how long a claimed event is hidden from other claimers.
+	DefaultLeaseDuration = time.Minute
+)
+
+// timeSortKeyFormat formats times in sort keys so they sort lexicographically.
+const timeSortKeyFormat = "2006-01-02T15:04:05.000000000Z"
+
+// OutboxEvent is an event stored in the table's outbox until it is published.
+type OutboxEvent struct {
+	ID        string    `dynamodbav:"id"`         // Unique event id; generated if empty
+	Type      string    `dynamodbav:"type"`       // The event type
+	Payload   any       `dynamodbav:"payload"`    // The event payload
+	CreatedAt time.Time `dynamodbav:"created_at"` // When the event was recorded; set if zero
+}
+
+// MarshalSelf implements [Marshaler].
+func (e *OutboxEvent) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget(OutboxPrefix, e.ID)
+	opts.RefSortKey = e.CreatedAt.UTC().Format(timeSortKeyFormat) + opts.KeyDelimiter + e.ID
+	opts.Created = e.CreatedAt
+	return nil
+}
+
+// DecodePayload unmarshals the event payload into out. Payloads of events read from the
+// table are generic maps; use this to convert them back into their original type.
+func (e *OutboxEvent) DecodePayload(out any) error {
+	av, err := attributevalue.Marshal(e.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if err := attributevalue.Unmarshal(av, out); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return nil
+}
+
+// Outbox records events in the same transaction as the entity changes that produced them,
+// and lets publishers claim, publish and delete them. Events are claimed with a lease, so
+// an event whose publisher fails is published again once its lease expires; consumers
+// should tolerate duplicates.
+//
+// Example:
+//
+//	outbox := dynamap.NewOutbox(table, "worker-1")
+//
+//	input, err := table.MarshalTransactWrite(order)
+//	err = outbox.Append(input, &dynamap.OutboxEvent{Type: "OrderPlaced", Payload: order})
+//	_, err = client.TransactWriteItems(ctx, input)
+//
+//	// In the publisher
+//	events, err := outbox.Claim(ctx, client, 10)
+//	for _, event := range events {
+//		if err := publish(event); err == nil {
+//			err = outbox.Delete(ctx, client, event)
+//		}
+//	}
+type Outbox struct {
+	Table         *Table        // The table holding the outbox
+	Owner         string        // Identifies the publisher claiming events
+	LeaseDuration time.Duration // How long claimed events are hidden from other publishers
+}
+
+// NewOutbox creates a new [Outbox] in the table for the publisher identified by owner.
+func NewOutbox(table *Table, owner string) *Outbox {
+	return &Outbox{
+		Table:         table,
+		Owner:         owner,
+		LeaseDuration: DefaultLeaseDuration,
+	}
+}
+
+// Append adds put actions for the events to the transaction. Events without an id or
+// creation time are assigned one. [ErrTransactionTooLarge] is returned if the transaction
+// would exceed [MaxTransactSize] actions.
+func (o *Outbox) Append(input *dynamodb.TransactWriteItemsInput, events ...*OutboxEvent) error {
+	if n := len(input.TransactItems) + len(events); n > MaxTransactSize {
+		return fmt.Errorf("%w: %d actions", ErrTransactionTooLarge, n)
+	}
+
+	now := NewMarshalOptions(o.Table.MarshalOptions).Tick()
+	for _, event := range events {
+		if event.ID == "" {
+			id, err := newEventID()
+			if err != nil {
+				return fmt.Errorf("failed to generate event id: %w", err)
+			}
+			event.ID = id
+		}
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = now
+		}
+
+		put, err := o.Table.MarshalPut(event, func(mo *MarshalOptions) {
+			mo.Registry = nil // outbox events are not registered entities
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		input.TransactItems = append(input.TransactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: put.TableName,
+				Item:      put.Item,
+			},
+		})
+	}
+
+	return nil
+}
+
+// Claim reads up to limit unclaimed events, oldest first, and claims them for the outbox
+// owner until the lease expires. Events claimed concurrently by another publisher are
+// skipped, so fewer than limit events may be returned even if more are pending.
+func (o *Outbox) Claim(ctx context.Context, client DynamoDBClient, limit int) ([]*OutboxEvent, error) {
+	var (
+		now   = NewMarshalOptions(o.Table.MarshalOptions).Tick()
+		lease = o.LeaseDuration
+	)
+
+	if lease <= 0 {
+		lease = DefaultLeaseDuration
+	}
+
+	input, err := o.Table.MarshalQuery(&QueryList{
+		Label:           OutboxPrefix,
+		ConditionFilter: claimable(now),
+		Limit:           limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", classify(err))
+	}
+
+	var events []*OutboxEvent
+	for _, item := range output.Items {
+		event := &OutboxEvent{}
+		if _, err := UnmarshalSelf(item, event, o.Table.MarshalOptions); err != nil {
+			return events, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+
+		claimed, err := o.claim(ctx, client, item, now, lease)
+		if err != nil {
+			return events, err
+		} else if claimed {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// claim claims the event item until the lease expires, returning false if the event was
+// claimed by another publisher first.
+func (o *Outbox) claim(ctx context.Context, client DynamoDBClient, item Item, now time.Time, lease time.Duration) (bool, error) {
+	update := expression.
+		Set(expression.Name(AttributeNameClaimedBy), expression.Value(o.Owner)).
+		Set(expression.Name(AttributeNameClaimedUntil), expression.Value(now.Add(lease).UnixMilli()))
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(itemExists().And(claimable(now))).
+		Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(o.Table.TableName),
+		Key:                       Item{AttributeNameSource: item[AttributeNameSource], AttributeNameTarget: item[AttributeNameTarget]},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to claim event: %w", classify(err))
+	}
+
+	return true, nil
+}
+
+// Delete removes published events from the outbox. Events no longer claimed by the outbox
+// owner are not deleted, and a [*ConditionFailedError] is returned.
+func (o *Outbox) Delete(ctx context.Context, client DynamoDBClient, events ...*OutboxEvent) error {
+	expr, err := expression.NewBuilder().WithCondition(o.claimedByOwner()).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	for _, event := range events {
+		input, err := o.Table.MarshalDelete(event)
+		if err != nil {
+			return err
+		}
+
+		input.ConditionExpression = expr.Condition()
+		input.ExpressionAttributeNames = expr.Names()
+		input.ExpressionAttributeValues = expr.Values()
+
+		if _, err := client.DeleteItem(ctx, input); err != nil {
+			return conditionFailed(input.Key, fmt.Errorf("failed to delete event: %w", classify(err)))
+		}
+	}
+
+	return nil
+}
+
+// Release gives up the outbox owner's claim on events so they can be claimed again
+// immediately, for example after a failed publish.
+func (o *Outbox) Release(ctx context.Context, client DynamoDBClient, events ...*OutboxEvent) error {
+	update := expression.
+		Remove(expression.Name(AttributeNameClaimedBy)).
+		Remove(expression.Name(AttributeNameClaimedUntil))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(o.claimedByOwner()).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	for _, event := range events {
+		input, err := o.Table.MarshalGet(event)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 input.TableName,
+			Key:                       input.Key,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		})
+		if err != nil {
+			return conditionFailed(input.Key, fmt.Errorf("failed to release event: %w", classify(err)))
+		}
+	}
+
+	return nil
+}
+
+// claimedByOwner returns a condition that holds if the item is claimed by the outbox owner.
+func (o *Outbox) claimedByOwner() expression.ConditionBuilder {
+	return expression.Name(AttributeNameClaimedBy).Equal(expression.Value(o.Owner))
+}
+
+// claimable returns a condition that holds if the item is unclaimed or its claim expired.
+func claimable(now time.Time) expression.ConditionBuilder {
+	return expression.Or(
+		expression.AttributeNotExists(expression.Name(AttributeNameClaimedUntil)),
+		expression.Name(AttributeNameClaimedUntil).LessThan(expression.Value(now.UnixMilli())),
+	)
+}
+
+// newEventID returns a random event id.
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// outboxClient serves outbox queries from the mock table and rejects claims and deletes
+// of items listed as claimed by another publisher.
+type outboxClient struct {
+	*mockDynamoDBClient
+	claimed map[string]bool
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *outboxClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	var items []Item
+	for _, item := range c.items {
+		if label, ok := item["label"].(*types.AttributeValueMemberS); ok && label.Value == OutboxPrefix {
+			items = append(items, item)
+		}
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func (c *outboxClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, params)
+	if c.claimed[params.Key["hk"].(*types.AttributeValueMemberS).Value] {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *outboxClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if c.claimed[params.Key["hk"].(*types.AttributeValueMemberS).Value] {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	return c.mockDynamoDBClient.DeleteItem(ctx, params, optFns...)
+}
+
+// Tests for the outbox
+
+func TestTableMarshalTransactWrite(t *testing.T) {
+	table := NewTable("test-table")
+
+	input, err := table.MarshalTransactWrite(&Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}})
+	if err != nil {
+		t.Fatalf("Failed to marshal transact write: %v", err)
+	}
+	if len(input.TransactItems) != 3 {
+		t.Fatalf("Expected 3 actions, got %d", len(input.TransactItems))
+	}
+	if name := *input.TransactItems[0].Put.TableName; name != "test-table" {
+		t.Errorf("Expected table name 'test-table', got %s", name)
+	}
+
+	products := make([]Product, MaxTransactSize)
+	if _, err := table.MarshalTransactWrite(&Order{ID: "O1", Products: products}); !errors.Is(err, ErrTransactionTooLarge) {
+		t.Errorf("Expected ErrTransactionTooLarge, got %v", err)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	outbox := NewOutbox(table, "worker-1")
+
+	t.Run("append", func(t *testing.T) {
+		input, err := table.MarshalTransactWrite(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal transact write: %v", err)
+		}
+
+		event := &OutboxEvent{Type: "ProductCreated", Payload: &Product{ID: "P1", Category: "books"}}
+		if err := outbox.Append(input, event); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+
+		if len(input.TransactItems) != 2 {
+			t.Fatalf("Expected 2 actions, got %d", len(input.TransactItems))
+		}
+		if event.ID == "" || event.CreatedAt.IsZero() {
+			t.Errorf("Expected generated id and creation time, got %+v", event)
+		}
+
+		item := input.TransactItems[1].Put.Item
+		if hk := item["hk"].(*types.AttributeValueMemberS).Value; hk != "outbox#"+event.ID {
+			t.Errorf("Expected hk 'outbox#%s', got %s", event.ID, hk)
+		}
+		if label := item["label"].(*types.AttributeValueMemberS).Value; label != OutboxPrefix {
+			t.Errorf("Expected label %q, got %s", OutboxPrefix, label)
+		}
+	})
+
+	t.Run("append too large", func(t *testing.T) {
+		input := &dynamodb.TransactWriteItemsInput{TransactItems: make([]types.TransactWriteItem, MaxTransactSize)}
+		if err := outbox.Append(input, &OutboxEvent{Type: "Ignored"}); !errors.Is(err, ErrTransactionTooLarge) {
+			t.Errorf("Expected ErrTransactionTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("claim and delete", func(t *testing.T) {
+		client := &outboxClient{mockDynamoDBClient: newMockDynamoDBClient(), claimed: map[string]bool{"outbox#E2": true}}
+
+		input := &dynamodb.TransactWriteItemsInput{}
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := outbox.Append(input,
+			&OutboxEvent{ID: "E1", Type: "OrderPlaced", Payload: &Product{ID: "P1", Category: "books"}, CreatedAt: created},
+			&OutboxEvent{ID: "E2", Type: "OrderPlaced", CreatedAt: created},
+		); err != nil {
+			t.Fatalf("Failed to append events: %v", err)
+		}
+		if _, err := client.TransactWriteItems(ctx, input); err != nil {
+			t.Fatalf("Failed to write events: %v", err)
+		}
+
+		events, err := outbox.Claim(ctx, client, 10)
+		if err != nil {
+			t.Fatalf("Failed to claim: %v", err)
+		}
+		if len(events) != 1 || events[0].ID != "E1" {
+			t.Fatalf("Expected only E1 to be claimed, got %+v", events)
+		}
+
+		var owner bool
+		for _, value := range client.updates[0].ExpressionAttributeValues {
+			if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value == "worker-1" {
+				owner = true
+			}
+		}
+		if !owner {
+			t.Error("Expected claim by 'worker-1'")
+		}
+
+		var payload Product
+		if err := events[0].DecodePayload(&payload); err != nil {
+			t.Fatalf("Failed to decode payload: %v", err)
+		}
+		if payload.Category != "books" {
+			t.Errorf("Expected category 'books', got %s", payload.Category)
+		}
+
+		if err := outbox.Delete(ctx, client, events...); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		if _, ok := client.items["outbox#E1#outbox#E1"]; ok {
+			t.Error("Expected E1 to be deleted")
+		}
+
+		if err := outbox.Delete(ctx, client, &OutboxEvent{ID: "E2"}); !errors.Is(err, ErrConditionFailed) {
+			t.Errorf("Expected ErrConditionFailed deleting unclaimed event, got %v", err)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		client := &outboxClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := outbox.Release(ctx, client, &OutboxEvent{ID: "E1"}); err != nil {
+			t.Fatalf("Failed to release: %v", err)
+		}
+		if len(client.updates) != 1 || client.updates[0].ConditionExpression == nil {
+			t.Error("Expected conditional release update")
+		}
+	})
+}
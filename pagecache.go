@@ -0,0 +1,132 @@
+package dynamap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// PageCacheInterceptor is an [Interceptor] that caches query pages, such as those of
+// [Table.QueryList], keyed by the query fingerprint and the start key of the page. Paging
+// back and forth through results, as with a browser's back button, then answers repeated
+// pages from the cache instead of consuming read capacity. Since pages are keyed by the
+// start key a cursor resolves to, cursors issued again for the same position share the
+// cached page. Index queries are cached too; consistent reads bypass the cache.
+//
+// Unlike [CacheInterceptor], writes do not invalidate cached pages: a page may be stale for
+// up to the TTL, so keep it short. Hits and misses are reported in [Request.Cache] to the
+// interceptors registered before the cache.
+//
+// Example:
+//
+//	metrics := dynamap.InterceptorFuncs{
+//		AfterFunc: func(ctx context.Context, req *dynamap.Request) {
+//			if req.Cache != "" {
+//				cacheRequests.WithLabelValues(string(req.Cache)).Inc()
+//			}
+//		},
+//	}
+//	pages := dynamap.NewPageCacheInterceptor(dynamap.NewLRUCache(1000), 30*time.Second)
+//	table.Interceptors = append(table.Interceptors, metrics, pages)
+type PageCacheInterceptor struct {
+	Cache Cache         // The cache holding pages
+	TTL   time.Duration // Time pages are cached. Default is DefaultCacheTTL.
+
+	pending sync.Map // Cache keys of the pages that missed, by request
+}
+
+// NewPageCacheInterceptor creates a new [PageCacheInterceptor] caching pages for ttl.
+func NewPageCacheInterceptor(cache Cache, ttl time.Duration) *PageCacheInterceptor {
+	return &PageCacheInterceptor{Cache: cache, TTL: ttl}
+}
+
+// Before implements [Interceptor] by answering queries from the cached pages.
+func (c *PageCacheInterceptor) Before(ctx context.Context, req *Request) error {
+	input, ok := req.Input.(*dynamodb.QueryInput)
+	if !ok || aws.ToBool(input.ConsistentRead) {
+		return nil
+	}
+
+	key, err := pageCacheKey(input)
+	if err != nil {
+		return nil
+	}
+
+	req.Cache = CacheMiss
+	value, found, err := c.Cache.Get(ctx, key)
+	if err != nil || !found {
+		c.pending.Store(req, key)
+		return nil
+	}
+
+	var cached cachedOutput
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&cached); err != nil {
+		c.pending.Store(req, key)
+		return nil
+	}
+
+	req.Cache = CacheHit
+	req.Output = &dynamodb.QueryOutput{
+		Items:            cached.Items,
+		Count:            int32(len(cached.Items)),
+		LastEvaluatedKey: cached.LastKey,
+	}
+	return nil
+}
+
+// After implements [Interceptor] by caching the pages that missed.
+func (c *PageCacheInterceptor) After(ctx context.Context, req *Request) {
+	key, ok := c.pending.LoadAndDelete(req)
+	if !ok || req.Err != nil {
+		return
+	}
+
+	output, ok := req.Output.(*dynamodb.QueryOutput)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	cached := cachedOutput{Items: output.Items, LastKey: output.LastEvaluatedKey}
+	if err := gob.NewEncoder(&buf).Encode(cached); err == nil {
+		_ = c.Cache.Set(ctx, key.(string), buf.Bytes(), c.ttl())
+	}
+}
+
+// ttl returns the time pages are cached.
+func (c *PageCacheInterceptor) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultCacheTTL
+	}
+	return c.TTL
+}
+
+// pageCacheKey returns the cache key of a query page: the query fingerprint, and a digest
+// of the start key and the other fields shaping the page.
+func pageCacheKey(input *dynamodb.QueryInput) (string, error) {
+	fingerprint, err := QueryFingerprint(input)
+	if err != nil {
+		return "", err
+	}
+
+	start, err := decodeAttributes(input.ExclusiveStartKey)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal([]any{start, input.Limit, input.ProjectionExpression, input.Select})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return CacheKeyPrefix + "page/" + fingerprint + "/" + base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
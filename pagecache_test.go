@@ -0,0 +1,114 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pagingClient answers every query with two pages of one item, counting the queries.
+type pagingClient struct {
+	DynamoDBClient
+	queries int
+}
+
+func (c *pagingClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries++
+	if params.ExclusiveStartKey == nil {
+		return &dynamodb.QueryOutput{
+			Items:            []Item{{"hk": &types.AttributeValueMemberS{Value: "item#1"}}},
+			LastEvaluatedKey: Item{"hk": &types.AttributeValueMemberS{Value: "item#1"}},
+		}, nil
+	}
+	return &dynamodb.QueryOutput{
+		Items: []Item{{"hk": &types.AttributeValueMemberS{Value: "item#2"}}},
+	}, nil
+}
+
+func TestPageCacheInterceptor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewLRUCache(100)
+	cache.Clock = func() time.Time { return now }
+
+	var statuses []CacheStatus
+	table := NewTable("test-table")
+	table.Interceptors = []Interceptor{
+		InterceptorFuncs{AfterFunc: func(ctx context.Context, req *Request) {
+			statuses = append(statuses, req.Cache)
+		}},
+		NewPageCacheInterceptor(cache, time.Minute),
+	}
+	next := &pagingClient{}
+	client := table.client(next)
+
+	input, err := table.MarshalQuery(&QueryList{Label: "product", Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to marshal query: %v", err)
+	}
+
+	query := func(start Item) *dynamodb.QueryOutput {
+		t.Helper()
+		in := *input
+		in.ExclusiveStartKey = start
+		output, err := client.Query(ctx, &in)
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+		return output
+	}
+
+	// Page forward, then back to the first page and forward again
+	first := query(nil)
+	second := query(first.LastEvaluatedKey)
+	if StringValue(second.Items[0]["hk"]) != "item#2" {
+		t.Fatalf("Expected the second page, got %v", second.Items)
+	}
+	if again := query(nil); StringValue(again.Items[0]["hk"]) != "item#1" || StringValue(again.LastEvaluatedKey["hk"]) != "item#1" {
+		t.Errorf("Expected the cached first page, got %+v", again)
+	}
+	query(first.LastEvaluatedKey)
+
+	if next.queries != 2 {
+		t.Errorf("Expected 2 queries to DynamoDB, got %d", next.queries)
+	}
+	want := []CacheStatus{CacheMiss, CacheMiss, CacheHit, CacheHit}
+	if len(statuses) != len(want) {
+		t.Fatalf("Expected statuses %v, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("Expected statuses %v, got %v", want, statuses)
+			break
+		}
+	}
+
+	t.Run("other queries miss", func(t *testing.T) {
+		other, _ := table.MarshalQuery(&QueryList{Label: "order", Limit: 1})
+		if _, err := client.Query(ctx, other); err != nil || next.queries != 3 {
+			t.Errorf("Expected another query to miss, got %d queries (%v)", next.queries, err)
+		}
+	})
+
+	t.Run("consistent reads bypass", func(t *testing.T) {
+		in := *input
+		in.ConsistentRead = aws.Bool(true)
+		statuses = nil
+		if _, err := client.Query(ctx, &in); err != nil || next.queries != 4 || statuses[0] != "" {
+			t.Errorf("Expected the query to bypass the cache, got %d queries and %v (%v)", next.queries, statuses, err)
+		}
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		before := next.queries
+		query(nil)
+		if next.queries != before+1 {
+			t.Errorf("Expected the expired page to be queried, got %d queries", next.queries-before)
+		}
+	})
+}
@@ -4,15 +4,25 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// CursorLabel is the label of the page cursors stored by [TablePaginator].
+const CursorLabel = "page"
+
+// ErrCursorMismatch is returned when a page cursor bound to a query fingerprint is used
+// with a different query.
+var ErrCursorMismatch = newError(ErrValidation, "cursor does not match the query")
+
 func init() {
 	// Register DynamoDB types with gob
 	gob.Register(map[string]types.AttributeValue{})
@@ -40,41 +50,46 @@ type Paginator interface {
 	StartKey(ctx context.Context, cursor string) (Item, error)
 }
 
-// TablePaginator implements Pagination by storing and retrieving start keys in the same table.
+// TablePaginator implements Pagination by storing and retrieving start keys in a
+// [CursorStore]. By default cursors are stored in the same table.
 type TablePaginator struct {
-	table  *Table         // table configuration
-	client DynamoDBClient // dynamodb client
+	table *Table      // table configuration
+	store CursorStore // storage of the cursors
 }
 
 // PageCursor represents an item in the dynamodb table that stores last evaluated key
 // information from query results. Cursor is generated from the current time and salt
-// while Key is a gob encoded form of the last evaluated key.
+// while Key is a gob encoded form of the last evaluated key. Fingerprint is the query
+// fingerprint the cursor is bound to, if any; see [WithCursorFingerprint].
 //
 // PageCursor implements Marshaler and Unmarshaler.
 type PageCursor struct {
-	Cursor string
-	Key    []byte
+	Cursor      string
+	Key         []byte
+	Fingerprint string `dynamodbav:",omitempty"`
 }
 
 // MarshalSelf implements Marshaler by providing a self-relationship:
 //   - source id: current time + salt
 //   - source prefix: "page"
-//   - ttl: 24 hours (default)
+//   - ttl: 24 hours, unless set by the options
 func (p *PageCursor) MarshalSelf(opts *MarshalOptions) error {
 	opts.SourcePrefix = "page"
 	opts.SourceID = p.Cursor
 	opts.TargetPrefix = "page"
 	opts.TargetID = p.Cursor
-	opts.Label = "page"
-	opts.TimeToLive = 24 * time.Hour // Default TTL, can be overridden by table config
+	opts.Label = CursorLabel
+	if opts.TimeToLive == 0 {
+		opts.TimeToLive = 24 * time.Hour // Default TTL, overridden by Table.PaginationTTL
+	}
 	opts.RefSortKey = p.Cursor
 
 	return nil
 }
 
-// PageCursor implements Pagination by storing the last evaluated key into the dynamodb table.
-// The key itself is stored as a self-relationship, with the relationship data encoded as
-// binary. If lastkey is nil, an empty string is returned.
+// PageCursor implements Pagination by storing the last evaluated key in the cursor store.
+// The key itself is gob encoded into [PageCursor.Key]. If lastkey is nil, an empty string
+// is returned.
 func (t *TablePaginator) PageCursor(ctx context.Context, lastkey Item) (string, error) {
 	if lastkey == nil || len(lastkey) == 0 {
 		return "", nil
@@ -86,73 +101,50 @@ func (t *TablePaginator) PageCursor(ctx context.Context, lastkey Item) (string,
 		return "", fmt.Errorf("failed to generate cursor: %w", err)
 	}
 
-	// Convert DynamoDB types to JSON for storage
-	keyData, err := attributevalue.MarshalMap(lastkey)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal last key: %w", err)
-	}
-
-	// Encode as gob
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	if err := encoder.Encode(keyData); err != nil {
+	// Encode as gob; the attribute value types are registered so nested values round trip
+	buf := getBuffer()
+	defer putBuffer(buf)
+	encoder := gob.NewEncoder(buf)
+	if err := encoder.Encode(lastkey); err != nil {
 		return "", fmt.Errorf("failed to encode last key: %w", err)
 	}
 
 	// Create the page cursor
+	fingerprint, _ := CursorFingerprintFromContext(ctx)
 	pageCursor := &PageCursor{
-		Cursor: cursor,
-		Key:    buf.Bytes(),
+		Cursor:      cursor,
+		Key:         bytes.Clone(buf.Bytes()),
+		Fingerprint: fingerprint,
 	}
 
-	// Store the cursor in the table with TTL
-	putInput, err := t.table.MarshalPut(pageCursor, func(opts *MarshalOptions) {
-		opts.TimeToLive = t.table.PaginationTTL
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal page cursor: %w", err)
+	// Store the cursor with TTL
+	ttl := t.table.PaginationTTL
+	if override, ok := CursorTTLFromContext(ctx); ok {
+		ttl = override
 	}
-
-	// Store in DynamoDB
-	_, err = t.client.PutItem(ctx, putInput)
-	if err != nil {
-		return "", fmt.Errorf("failed to store page cursor: %w", err)
+	if err := t.store.SaveCursor(ctx, pageCursor, ttl); err != nil {
+		return "", err
 	}
 
 	return cursor, nil
 }
 
-// StartKey implements Pagination by retrieving the self-relationship referenced by
-// cursor. If found the PageCursor data is decoded from binary and returned.
-// If the relationship is not found, nil is returned.
+// StartKey implements Pagination by loading the page cursor from the cursor store. If
+// found the PageCursor key is decoded from binary and returned.
+// [ErrCursorExpired] is returned if the cursor is unknown or has expired, and
+// [ErrCursorMismatch] if ctx carries a query fingerprint the cursor is not bound to.
 func (t *TablePaginator) StartKey(ctx context.Context, cursor string) (Item, error) {
 	if cursor == "" {
 		return nil, nil
 	}
 
-	// Create a page cursor to get the key
-	pageCursor := &PageCursor{Cursor: cursor}
-
-	// Get the cursor from the table
-	getInput, err := t.table.MarshalGet(pageCursor)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal get request: %w", err)
-	}
-
-	result, err := t.client.GetItem(ctx, getInput)
+	pageCursor, err := t.store.LoadCursor(ctx, cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get page cursor: %w", err)
+		return nil, err
 	}
 
-	if result.Item == nil {
-		// Cursor not found or expired
-		return nil, nil
-	}
-
-	// Unmarshal the cursor
-	_, err = UnmarshalSelf(result.Item, pageCursor)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal page cursor: %w", err)
+	if fingerprint, ok := CursorFingerprintFromContext(ctx); ok && pageCursor.Fingerprint != fingerprint {
+		return nil, ErrCursorMismatch
 	}
 
 	// Decode the key data
@@ -171,12 +163,183 @@ func (t *TablePaginator) StartKey(ctx context.Context, cursor string) (Item, err
 	return keyData, nil
 }
 
-// Paginator returns a Paginator to extract and generate client cursors.
+// Paginator returns a Paginator to extract and generate client cursors. Cursors are
+// stored in the table itself.
 func (t *Table) Paginator(client DynamoDBClient) Paginator {
+	return t.PaginatorWithStore(client, nil)
+}
+
+// PaginatorWithStore returns a Paginator that keeps page cursors in store. If store is
+// nil, cursors are stored in the table itself, as with [Table.Paginator].
+//
+//	// Keep cursors out of the data table
+//	cursors := dynamap.NewTable("cursors")
+//	paginator := table.PaginatorWithStore(client, dynamap.NewTableCursorStore(cursors, client))
+func (t *Table) PaginatorWithStore(client DynamoDBClient, store CursorStore) Paginator {
+	if store == nil {
+		store = NewTableCursorStore(t, client)
+	}
 	return &TablePaginator{
-		table:  t,
-		client: client,
+		table: t,
+		store: store,
+	}
+}
+
+// cursorFingerprintKey is the context key of the query fingerprint of page cursors.
+type cursorFingerprintKey struct{}
+
+// WithCursorFingerprint returns a copy of ctx binding page cursors to the query
+// fingerprint, such as one returned by [QueryFingerprint]. Cursors created by
+// [TablePaginator.PageCursor] with the context record the fingerprint, and
+// [TablePaginator.StartKey] rejects cursors recorded with a different fingerprint, or
+// none, with [ErrCursorMismatch]. This prevents clients from replaying a cursor against
+// another query:
+//
+//	input, err := table.MarshalQuery(query)
+//	fingerprint, err := dynamap.QueryFingerprint(input)
+//	ctx = dynamap.WithCursorFingerprint(ctx, fingerprint)
+//	query.StartKey, err = paginator.StartKey(ctx, cursor)
+func WithCursorFingerprint(ctx context.Context, fingerprint string) context.Context {
+	return context.WithValue(ctx, cursorFingerprintKey{}, fingerprint)
+}
+
+// CursorFingerprintFromContext returns the query fingerprint carried by ctx, if any.
+func CursorFingerprintFromContext(ctx context.Context) (string, bool) {
+	fingerprint, ok := ctx.Value(cursorFingerprintKey{}).(string)
+	return fingerprint, ok
+}
+
+// cursorTTLKey is the context key of the time to live of page cursors.
+type cursorTTLKey struct{}
+
+// WithCursorTTL returns a copy of ctx overriding [Table.PaginationTTL] for the cursors
+// created by [TablePaginator.PageCursor] with the context, such as to keep the cursors of
+// long running exports for longer than those of interactive lists.
+func WithCursorTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cursorTTLKey{}, ttl)
+}
+
+// CursorTTLFromContext returns the cursor time to live carried by ctx, if any.
+func CursorTTLFromContext(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(cursorTTLKey{}).(time.Duration)
+	return ttl, ok
+}
+
+// CursorStats describes the page cursors stored in a table.
+type CursorStats struct {
+	Active  int // Number of cursors that have not expired, including cursors without a TTL
+	Expired int // Number of expired cursors not yet deleted by DynamoDB
+}
+
+// CountCursors counts the page cursors stored in the table using the label index.
+func (t *Table) CountCursors(ctx context.Context, client DynamoDBClient) (CursorStats, error) {
+	var (
+		stats       CursorStats
+		marshalOpts = NewMarshalOptions(t.MarshalOptions)
+		now         = marshalOpts.Tick()
+	)
+	err := t.queryCursors(ctx, client, func(items []Item) error {
+		for _, item := range items {
+			if isExpired(item, marshalOpts.aliasName(AttributeNameExpires), now) {
+				stats.Expired++
+			} else {
+				stats.Active++
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// PurgeExpiredCursors deletes the expired page cursors of the table, found using the
+// label index, and returns the number of deleted cursors. Run it periodically if the
+// table has no time to live enabled, or to reclaim space sooner than DynamoDB deletes
+// expired items.
+//
+// Example:
+//
+//	n, err := table.PurgeExpiredCursors(ctx, client)
+func (t *Table) PurgeExpiredCursors(ctx context.Context, client DynamoDBClient) (int, error) {
+	var (
+		purged      int
+		marshalOpts = NewMarshalOptions(t.MarshalOptions)
+		now         = marshalOpts.Tick()
+	)
+	err := t.queryCursors(ctx, client, func(items []Item) error {
+		var expired []Item
+		for _, item := range items {
+			if isExpired(item, marshalOpts.aliasName(AttributeNameExpires), now) {
+				expired = append(expired, item)
+			}
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+		if err := t.DeleteItems(ctx, client, expired); err != nil {
+			return fmt.Errorf("failed to delete cursors: %w", err)
+		}
+		purged += len(expired)
+		return nil
+	})
+	return purged, err
+}
+
+// queryCursors calls fn with each page of page cursor items of the table. Expired items
+// are included even if [Table.FilterExpired] is set.
+func (t *Table) queryCursors(ctx context.Context, client DynamoDBClient, fn func([]Item) error) error {
+	client = t.client(client)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(contextOptions(ctx, nil))
+	})
+
+	query := &QueryList{Label: CursorLabel}
+	input, err := query.MarshalQuery(&marshalOpts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+	input.TableName = aws.String(t.TableName)
+	input.ExpressionAttributeNames = marshalOpts.aliasNames(input.ExpressionAttributeNames, &input.KeyConditionExpression, &input.FilterExpression)
+	if index := query.UseIndex(t); index != "" {
+		input.IndexName = aws.String(index)
 	}
+
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to query cursors: %w", classify(err))
+		}
+		if err := fn(output.Items); err != nil {
+			return err
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// QueryFingerprint returns a digest of the results the query pages through: its table,
+// index, key condition, filter, attribute names and values, and direction. The start key,
+// limit and projection are not part of the fingerprint, so every page of a query shares
+// it regardless of the page size.
+func QueryFingerprint(input *dynamodb.QueryInput) (string, error) {
+	values, err := decodeAttributes(input.ExpressionAttributeValues)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode attribute values: %w", err)
+	}
+
+	b, err := json.Marshal([]any{
+		aws.ToString(input.TableName), aws.ToString(input.IndexName),
+		aws.ToString(input.KeyConditionExpression), aws.ToString(input.FilterExpression),
+		input.ExpressionAttributeNames, values, input.ScanIndexForward == nil || *input.ScanIndexForward,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
 }
 
 // MarshalStartKey marshals a page key into a page cursor to return to clients.
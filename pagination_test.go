@@ -2,8 +2,11 @@ package dynamap
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
@@ -56,6 +59,9 @@ func TestPagination(t *testing.T) {
 		if retrievedKey == nil {
 			t.Error("Expected non-nil start key")
 		}
+		if hk, ok := retrievedKey["hk"].(*types.AttributeValueMemberS); !ok || hk.Value != "test#123" {
+			t.Errorf("Expected start key hk 'test#123', got %#v", retrievedKey["hk"])
+		}
 	})
 
 	t.Run("empty cursor returns nil start key", func(t *testing.T) {
@@ -68,10 +74,10 @@ func TestPagination(t *testing.T) {
 		}
 	})
 
-	t.Run("non-existent cursor returns nil", func(t *testing.T) {
+	t.Run("non-existent cursor is expired", func(t *testing.T) {
 		result, err := paginator.StartKey(ctx, "non-existent-cursor")
-		if err != nil {
-			t.Errorf("Unexpected error for non-existent cursor: %v", err)
+		if !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("Expected ErrCursorExpired for non-existent cursor, got %v", err)
 		}
 		if result != nil {
 			t.Error("Expected nil result for non-existent cursor")
@@ -102,6 +108,51 @@ func TestPagination(t *testing.T) {
 			t.Error("Expected nil result for empty key data")
 		}
 	})
+
+	t.Run("bound cursors reject other queries", func(t *testing.T) {
+		fingerprint := func(q QueryMarshaler) string {
+			t.Helper()
+			input, err := table.MarshalQuery(q)
+			if err != nil {
+				t.Fatalf("Failed to marshal query: %v", err)
+			}
+			fp, err := QueryFingerprint(input)
+			if err != nil {
+				t.Fatalf("Failed to fingerprint query: %v", err)
+			}
+			return fp
+		}
+
+		products := fingerprint(&QueryList{Label: "product", Limit: 10})
+		if other := fingerprint(&QueryList{Label: "product", Limit: 50}); other != products {
+			t.Errorf("Expected the page size not to change the fingerprint, got %s and %s", products, other)
+		}
+		orders := fingerprint(&QueryList{Label: "order"})
+		if orders == products {
+			t.Fatal("Expected different queries to have different fingerprints")
+		}
+
+		lastkey := Item{"hk": &types.AttributeValueMemberS{Value: "product#1"}}
+		cursor, err := paginator.PageCursor(WithCursorFingerprint(ctx, products), lastkey)
+		if err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+
+		if key, err := paginator.StartKey(WithCursorFingerprint(ctx, products), cursor); err != nil || key == nil {
+			t.Errorf("Expected the start key for the same query, got %v (%v)", key, err)
+		}
+		if _, err := paginator.StartKey(WithCursorFingerprint(ctx, orders), cursor); !errors.Is(err, ErrCursorMismatch) || !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrCursorMismatch for another query, got %v", err)
+		}
+		if _, err := paginator.StartKey(ctx, cursor); err != nil {
+			t.Errorf("Expected unbound reads to accept the cursor, got %v", err)
+		}
+
+		unbound, _ := paginator.PageCursor(ctx, lastkey)
+		if _, err := paginator.StartKey(WithCursorFingerprint(ctx, products), unbound); !errors.Is(err, ErrCursorMismatch) {
+			t.Errorf("Expected ErrCursorMismatch for an unbound cursor, got %v", err)
+		}
+	})
 }
 
 func TestMarshalAndUnmarshalStartKey(t *testing.T) {
@@ -195,3 +246,114 @@ func TestGenerateCursor(t *testing.T) {
 		}
 	})
 }
+
+func TestPurgeExpiredCursors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.Clock = func() time.Time { return now }
+	client := &labelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+	paginator := table.Paginator(client)
+	lastkey := Item{"hk": &types.AttributeValueMemberS{Value: "product#1"}}
+
+	for range 2 {
+		if _, err := paginator.PageCursor(ctx, lastkey); err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+	}
+	export, err := paginator.PageCursor(WithCursorTTL(ctx, 72*time.Hour), lastkey)
+	if err != nil {
+		t.Fatalf("Failed to create cursor: %v", err)
+	}
+
+	var expires int64
+	if err := attributevalue.Unmarshal(client.items["page#"+export+"#page#"+export][AttributeNameExpires], &expires); err != nil || expires != now.Add(72*time.Hour).Unix() {
+		t.Errorf("Expected the cursor TTL override, got %d (%v)", expires, err)
+	}
+
+	now = now.Add(table.PaginationTTL + time.Second)
+	stats, err := table.CountCursors(ctx, client)
+	if err != nil || stats != (CursorStats{Active: 1, Expired: 2}) {
+		t.Fatalf("Expected 1 active and 2 expired cursors, got %+v (%v)", stats, err)
+	}
+
+	purged, err := table.PurgeExpiredCursors(ctx, client)
+	if err != nil || purged != 2 {
+		t.Fatalf("Expected 2 purged cursors, got %d (%v)", purged, err)
+	}
+	if stats, _ := table.CountCursors(ctx, client); stats != (CursorStats{Active: 1}) {
+		t.Errorf("Expected only the active cursor to remain, got %+v", stats)
+	}
+	if key, err := paginator.StartKey(ctx, export); err != nil || key == nil {
+		t.Errorf("Expected the active cursor to be readable, got %v (%v)", key, err)
+	}
+}
+
+func TestCursorStores(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	lastkey := Item{
+		"hk": &types.AttributeValueMemberS{Value: "test#123"},
+		"sk": &types.AttributeValueMemberS{Value: "test#456"},
+	}
+
+	t.Run("memory store", func(t *testing.T) {
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		store := NewMemoryCursorStore()
+		store.Clock = func() time.Time { return now }
+		client := newMockDynamoDBClient()
+		paginator := table.PaginatorWithStore(client, store)
+
+		cursor, err := paginator.PageCursor(WithCursorTTL(ctx, time.Minute), lastkey)
+		if err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+		if len(client.items) != 0 || store.Len() != 1 {
+			t.Fatalf("Expected the cursor in memory only, got %d items and %d cursors", len(client.items), store.Len())
+		}
+
+		startKey, err := paginator.StartKey(ctx, cursor)
+		if err != nil || StringValue(startKey["sk"]) != "test#456" {
+			t.Fatalf("Expected the start key, got %v (%v)", startKey, err)
+		}
+
+		now = now.Add(time.Minute)
+		if _, err := paginator.StartKey(ctx, cursor); !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("Expected ErrCursorExpired, got %v", err)
+		}
+		if store.Len() != 0 {
+			t.Errorf("Expected the expired cursor to be removed, got %d cursors", store.Len())
+		}
+	})
+
+	t.Run("memory store purges expired cursors", func(t *testing.T) {
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		store := &MemoryCursorStore{Clock: func() time.Time { return now }}
+		store.SaveCursor(ctx, &PageCursor{Cursor: "a"}, time.Minute)
+		store.SaveCursor(ctx, &PageCursor{Cursor: "b"}, 0)
+
+		now = now.Add(time.Hour)
+		if n := store.Purge(); n != 1 || store.Len() != 1 {
+			t.Errorf("Expected one cursor purged and one kept, got %d purged and %d kept", n, store.Len())
+		}
+		if _, err := store.LoadCursor(ctx, "b"); err != nil {
+			t.Errorf("Expected cursors without ttl to be kept, got %v", err)
+		}
+	})
+
+	t.Run("separate table store", func(t *testing.T) {
+		client, cursorClient := newMockDynamoDBClient(), newMockDynamoDBClient()
+		paginator := table.PaginatorWithStore(client, NewTableCursorStore(NewTable("cursor-table"), cursorClient))
+
+		cursor, err := paginator.PageCursor(ctx, lastkey)
+		if err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+		if len(client.items) != 0 || len(cursorClient.items) != 1 {
+			t.Fatalf("Expected the cursor in the cursor table, got %d and %d items", len(client.items), len(cursorClient.items))
+		}
+		if startKey, err := paginator.StartKey(ctx, cursor); err != nil || StringValue(startKey["hk"]) != "test#123" {
+			t.Errorf("Expected the start key, got %v (%v)", startKey, err)
+		}
+	})
+}
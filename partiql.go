@@ -0,0 +1,206 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// StatementClient is a DynamoDB client that can execute PartiQL statements.
+type StatementClient interface {
+	ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error)
+}
+
+// StatementMarshaler can marshal input into a PartiQL statement request. It is the PartiQL
+// alternative to [QueryMarshaler]; the items of the output can be unmarshaled with
+// [UnmarshalList] and [UnmarshalEntity] in the same way as query results.
+type StatementMarshaler interface {
+	// MarshalStatement marshals the statement into a DynamoDB ExecuteStatementInput. The
+	// table is used to resolve the table and index names.
+	MarshalStatement(*Table, *MarshalOptions) (*dynamodb.ExecuteStatementInput, error)
+}
+
+// StatementList is a StatementMarshaler that selects the entities with a label from the
+// ref index, like [QueryList].
+type StatementList struct {
+	Label            string // The relationship label
+	RefSortKeyPrefix string // Optional prefix of the label sort key
+	Filter           string // Optional PartiQL condition on the relationship, such as "expires" > ?
+	FilterParameters []any  // Values of the parameters in Filter
+	Limit            int    // Maximum number of items to evaluate
+	NextToken        string // Token of the next page of results
+	SortDescending   bool   // Scan direction (default: false)
+}
+
+// MarshalStatement implements StatementMarshaler for StatementList.
+func (s *StatementList) MarshalStatement(t *Table, opts *MarshalOptions) (*dynamodb.ExecuteStatementInput, error) {
+	stmt := &statementBuilder{}
+	stmt.from(t.TableName, t.RefIndexName)
+	stmt.where(opts.statementName(opts.refIndexHashKey())+" = ?", opts.tenantLabel(s.Label))
+
+	if s.RefSortKeyPrefix != "" {
+		stmt.where("begins_with("+opts.statementName(AttributeNameRefSortKey)+", ?)", s.RefSortKeyPrefix)
+	}
+	if s.Filter != "" {
+		stmt.where("("+s.Filter+")", s.FilterParameters...)
+	}
+	if s.SortDescending {
+		stmt.orderBy(opts.statementName(AttributeNameRefSortKey) + " DESC")
+	}
+
+	return stmt.build(s.Limit, s.NextToken)
+}
+
+// StatementEntity is a StatementMarshaler that selects the items in an entity's partition,
+// like [QueryEntity]. The results should be unmarshaled with [UnmarshalEntity].
+type StatementEntity struct {
+	Source           Marshaler // The entity whose partition is selected
+	Filter           string    // Optional PartiQL condition on the relationships
+	FilterParameters []any     // Values of the parameters in Filter
+	Limit            int       // Maximum number of items to evaluate
+	NextToken        string    // Token of the next page of results
+}
+
+// MarshalStatement implements StatementMarshaler for StatementEntity.
+func (s *StatementEntity) MarshalStatement(t *Table, opts *MarshalOptions) (*dynamodb.ExecuteStatementInput, error) {
+	sourceOpts := *opts
+	sourceOpts.SkipRefs = true
+
+	if err := s.Source.MarshalSelf(&sourceOpts); err != nil {
+		return nil, fmt.Errorf("failed to marshal source: %w", err)
+	}
+
+	stmt := &statementBuilder{}
+	stmt.from(t.TableName, "")
+	stmt.where(opts.statementName(AttributeNameSource)+" = ?", sourceOpts.sourceKey())
+
+	if s.Filter != "" {
+		stmt.where("("+s.Filter+")", s.FilterParameters...)
+	}
+
+	return stmt.build(s.Limit, s.NextToken)
+}
+
+// statementBuilder assembles a PartiQL select statement and its parameters.
+type statementBuilder struct {
+	source     string
+	conditions []string
+	order      string
+	parameters []any
+}
+
+func (b *statementBuilder) from(table, index string) {
+	b.source = quoteName(table)
+	if index != "" {
+		b.source += "." + quoteName(index)
+	}
+}
+
+func (b *statementBuilder) where(condition string, parameters ...any) {
+	b.conditions = append(b.conditions, condition)
+	b.parameters = append(b.parameters, parameters...)
+}
+
+func (b *statementBuilder) orderBy(order string) {
+	b.order = order
+}
+
+func (b *statementBuilder) build(limit int, nextToken string) (*dynamodb.ExecuteStatementInput, error) {
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM " + b.source)
+	if len(b.conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(b.conditions, " AND "))
+	}
+	if b.order != "" {
+		sb.WriteString(" ORDER BY " + b.order)
+	}
+
+	input := &dynamodb.ExecuteStatementInput{Statement: aws.String(sb.String())}
+
+	for _, parameter := range b.parameters {
+		av, err := attributevalue.Marshal(parameter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal statement parameter: %w", err)
+		}
+		input.Parameters = append(input.Parameters, av)
+	}
+
+	if limit > 0 {
+		input.Limit = aws.Int32(int32(limit))
+	}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+
+	return input, nil
+}
+
+// quoteName quotes a PartiQL identifier.
+func quoteName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// statementName returns the quoted attribute name used in statements for name, applying
+// attribute aliases and the [KeyNames] of the table. Statements cannot be translated by a
+// [Client], so they use the names of the table.
+func (mo MarshalOptions) statementName(name string) string {
+	return quoteName(mo.KeyNames.name(mo.aliasName(name)))
+}
+
+// MarshalStatement marshals the input into a PartiQL statement request.
+//
+// Example:
+//
+//	input, err := table.MarshalStatement(&dynamap.StatementList{Label: "order", SortDescending: true})
+//	output, err := client.ExecuteStatement(ctx, input)
+//	_, err = dynamap.UnmarshalList(output.Items, &orders)
+func (t *Table) MarshalStatement(in StatementMarshaler, opts ...func(*MarshalOptions)) (*dynamodb.ExecuteStatementInput, error) {
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	input, err := in.MarshalStatement(t, &marshalOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal statement: %w", err)
+	}
+
+	t.logMarshal(input)
+	return input, nil
+}
+
+// ExecuteStatement executes the statement, following next tokens until the results are
+// exhausted, and returns the items. Key attributes are renamed from the table's [KeyNames]
+// to the dynamap names. Unmarshal the results with [UnmarshalList] or
+// [UnmarshalEntity].
+func (t *Table) ExecuteStatement(ctx context.Context, client StatementClient, in StatementMarshaler, opts ...func(*MarshalOptions)) ([]Item, error) {
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalStatement(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for {
+		start := time.Now()
+		output, err := client.ExecuteStatement(ctx, input)
+		if t.Logger != nil {
+			t.logExecute(ctx, input, time.Since(start), err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute statement: %w", classify(err))
+		}
+
+		items = append(items, renameItems(output.Items, t.KeyNames.inverse())...)
+
+		if output.NextToken == nil {
+			return items, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
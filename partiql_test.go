@@ -0,0 +1,182 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// statementClient returns pages of items and records the executed statements.
+type statementClient struct {
+	pages  [][]Item
+	inputs []*dynamodb.ExecuteStatementInput
+}
+
+func (c *statementClient) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	input := *params
+	c.inputs = append(c.inputs, &input)
+
+	page := len(c.inputs) - 1
+	output := &dynamodb.ExecuteStatementOutput{Items: c.pages[page]}
+	if page < len(c.pages)-1 {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+// Tests for PartiQL statements
+
+func TestMarshalStatement(t *testing.T) {
+	table := NewTable("test-table")
+
+	t.Run("list", func(t *testing.T) {
+		input, err := table.MarshalStatement(&StatementList{
+			Label:            "order",
+			RefSortKeyPrefix: "2025-",
+			Filter:           `"purchased_by" = ?`,
+			FilterParameters: []any{"U1"},
+			Limit:            10,
+			SortDescending:   true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal statement: %v", err)
+		}
+
+		want := `SELECT * FROM "test-table"."ref-index" WHERE "label" = ? AND begins_with("gsi1_sk", ?) AND ("purchased_by" = ?) ORDER BY "gsi1_sk" DESC`
+		if got := aws.ToString(input.Statement); got != want {
+			t.Errorf("Expected statement\n%s\ngot\n%s", want, got)
+		}
+
+		var params []string
+		for _, p := range input.Parameters {
+			params = append(params, p.(*types.AttributeValueMemberS).Value)
+		}
+		if len(params) != 3 || params[0] != "order" || params[1] != "2025-" || params[2] != "U1" {
+			t.Errorf("Unexpected parameters: %v", params)
+		}
+		if aws.ToInt32(input.Limit) != 10 {
+			t.Errorf("Expected limit 10, got %d", aws.ToInt32(input.Limit))
+		}
+	})
+
+	t.Run("entity", func(t *testing.T) {
+		input, err := table.MarshalStatement(&StatementEntity{Source: &Order{ID: "O1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal statement: %v", err)
+		}
+
+		want := `SELECT * FROM "test-table" WHERE "hk" = ?`
+		if got := aws.ToString(input.Statement); got != want {
+			t.Errorf("Expected statement %q, got %q", want, got)
+		}
+		if hk := input.Parameters[0].(*types.AttributeValueMemberS).Value; hk != "order#O1" {
+			t.Errorf("Expected hash key parameter 'order#O1', got %q", hk)
+		}
+	})
+
+	t.Run("aliases and tenant", func(t *testing.T) {
+		aliased := NewTable("test-table")
+		aliased.TenantID = "T1"
+		aliased.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}}
+
+		input, err := aliased.MarshalStatement(&StatementList{Label: "order", RefSortKeyPrefix: "a"})
+		if err != nil {
+			t.Fatalf("Failed to marshal statement: %v", err)
+		}
+
+		want := `SELECT * FROM "test-table"."ref-index" WHERE "label" = ? AND begins_with("ref_sk", ?)`
+		if got := aws.ToString(input.Statement); got != want {
+			t.Errorf("Expected statement %q, got %q", want, got)
+		}
+		if label := input.Parameters[0].(*types.AttributeValueMemberS).Value; label != "T1|order" {
+			t.Errorf("Expected tenant label, got %q", label)
+		}
+	})
+}
+
+func TestExecuteStatement(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+
+	var pages [][]Item
+	for _, id := range []string{"O1", "O2"} {
+		input, err := table.MarshalPut(&Order{ID: id})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		pages = append(pages, []Item{input.Item})
+	}
+
+	client := &statementClient{pages: pages}
+	items, err := table.ExecuteStatement(ctx, client, &StatementList{Label: "order"})
+	if err != nil {
+		t.Fatalf("Failed to execute statement: %v", err)
+	}
+
+	if len(client.inputs) != 2 || aws.ToString(client.inputs[1].NextToken) != "next" {
+		t.Errorf("Expected the next token to be followed")
+	}
+
+	var orders []Order
+	if _, err := UnmarshalList(items, &orders); err != nil {
+		t.Fatalf("Failed to unmarshal list: %v", err)
+	}
+	if len(orders) != 2 || orders[0].ID != "O1" || orders[1].ID != "O2" {
+		t.Errorf("Unexpected orders: %+v", orders)
+	}
+}
+
+func TestStatementKeyNames(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.KeyNames = KeyNames{Source: "PK", Target: "SK", Label: "GSI1PK", RefSortKey: "GSI1SK"}
+	table.RefIndexName = "GSI1"
+
+	t.Run("list", func(t *testing.T) {
+		input, err := table.MarshalStatement(&StatementList{Label: "order", RefSortKeyPrefix: "a", SortDescending: true})
+		if err != nil {
+			t.Fatalf("Failed to marshal statement: %v", err)
+		}
+
+		want := `SELECT * FROM "test-table"."GSI1" WHERE "GSI1PK" = ? AND begins_with("GSI1SK", ?) ORDER BY "GSI1SK" DESC`
+		if got := aws.ToString(input.Statement); got != want {
+			t.Errorf("Expected statement\n%s\ngot\n%s", want, got)
+		}
+	})
+
+	t.Run("entity", func(t *testing.T) {
+		input, err := table.MarshalStatement(&StatementEntity{Source: &Order{ID: "O1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal statement: %v", err)
+		}
+
+		want := `SELECT * FROM "test-table" WHERE "PK" = ?`
+		if got := aws.ToString(input.Statement); got != want {
+			t.Errorf("Expected statement %q, got %q", want, got)
+		}
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		put, err := table.MarshalPut(&Order{ID: "O1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		stored := renameItem(put.Item, table.KeyNames.renames())
+
+		client := &statementClient{pages: [][]Item{{stored}}}
+		items, err := table.ExecuteStatement(ctx, client, &StatementList{Label: "order"})
+		if err != nil {
+			t.Fatalf("Failed to execute statement: %v", err)
+		}
+
+		if _, ok := items[0][AttributeNameSource]; !ok {
+			t.Errorf("Expected key attributes renamed to the dynamap names, got %v", items[0])
+		}
+		if _, ok := items[0]["PK"]; ok {
+			t.Error("Expected no table key attribute names")
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package dynamap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not returned to the pool,
+// so a single large item does not pin its memory for the life of the process. It is the
+// maximum size of a DynamoDB item.
+const maxPooledBufferSize = 400 << 10
+
+// bufferPool holds the buffers used to encode data attributes and page cursors.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool. The contents of buf must no longer be referenced.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// gzipWriterPool holds the writers used by [GzipDataCodec], which allocate large
+// compression tables when created.
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// getGzipWriter returns a writer from the pool that compresses to w.
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	writer := gzipWriterPool.Get().(*gzip.Writer)
+	writer.Reset(w)
+	return writer
+}
+
+// putGzipWriter returns writer to the pool.
+func putGzipWriter(writer *gzip.Writer) {
+	writer.Reset(io.Discard)
+	gzipWriterPool.Put(writer)
+}
+
+// keyEqualityCondition is the key condition written by the expression package for the
+// equality of a single key attribute, such as the label of a [QueryList].
+const keyEqualityCondition = "#0 = :0"
+
+// equalityKeyCondition returns the key condition expression, names and values of a query on
+// the equality of the key attribute name with value. It produces the same expression as
+// expression.Key(name).Equal(expression.Value(value)) without building one, which is the
+// key condition of most queries.
+func equalityKeyCondition(name, value string) (*string, map[string]string, map[string]types.AttributeValue) {
+	return aws.String(keyEqualityCondition),
+		map[string]string{"#0": name},
+		map[string]types.AttributeValue{":0": &types.AttributeValueMemberS{Value: value}}
+}
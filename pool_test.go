@@ -0,0 +1,65 @@
+package dynamap
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for pooled buffers and key condition templates
+
+func TestEqualityKeyCondition(t *testing.T) {
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key(AttributeNameLabel).Equal(expression.Value("product"))).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build expression: %v", err)
+	}
+
+	condition, names, values := equalityKeyCondition(AttributeNameLabel, "product")
+	if aws.ToString(condition) != aws.ToString(expr.KeyCondition()) {
+		t.Errorf("Expected %q, got %q", aws.ToString(expr.KeyCondition()), aws.ToString(condition))
+	}
+	if !reflect.DeepEqual(names, expr.Names()) || !reflect.DeepEqual(values, expr.Values()) {
+		t.Errorf("Expected %v %v, got %v %v", expr.Names(), expr.Values(), names, values)
+	}
+}
+
+func TestPooledBuffers(t *testing.T) {
+	t.Run("encoded data does not share pooled memory", func(t *testing.T) {
+		codec := GzipDataCodec{}
+		first, err := codec.Encode(&types.AttributeValueMemberS{Value: "first"})
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		snapshot := bytes.Clone(first.(*types.AttributeValueMemberB).Value)
+
+		if _, err := codec.Encode(&types.AttributeValueMemberS{Value: "second"}); err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		if !bytes.Equal(first.(*types.AttributeValueMemberB).Value, snapshot) {
+			t.Error("Expected the first encoding to be unchanged")
+		}
+
+		decoded, err := codec.Decode(first)
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		if s, ok := decoded.(*types.AttributeValueMemberS); !ok || s.Value != "first" {
+			t.Errorf("Expected the first value, got %v", decoded)
+		}
+	})
+
+	t.Run("large buffers are dropped", func(t *testing.T) {
+		buf := getBuffer()
+		buf.Grow(maxPooledBufferSize + 1)
+		putBuffer(buf)
+		if got := getBuffer(); got == buf {
+			t.Error("Expected the large buffer not to be pooled")
+		}
+	})
+}
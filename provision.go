@@ -0,0 +1,182 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultProvisionTimeout is the default time [Table.EnsureTable] waits for a new table
+// to become active.
+const DefaultProvisionTimeout = 5 * time.Minute
+
+// TableAdminClient is the subset of the DynamoDB client used to provision tables.
+type TableAdminClient interface {
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// ProvisionOptions configures the tables created by [Table.CreateTableInput] and
+// [Table.EnsureTable].
+type ProvisionOptions struct {
+	BillingMode        types.BillingMode    // Default is types.BillingModePayPerRequest
+	ReadCapacityUnits  int64                // Read capacity of the table and each index, if provisioned
+	WriteCapacityUnits int64                // Write capacity of the table and each index, if provisioned
+	StreamViewType     types.StreamViewType // If set, enables a stream with the view type, e.g. for change data capture
+	DisableTTL         bool                 // If true, time to live is not enabled on the expires attribute
+	Timeout            time.Duration        // Time to wait for a new table to become active. Default is DefaultProvisionTimeout.
+}
+
+// newProvisionOptions returns the provision options with defaults applied.
+func newProvisionOptions(opts []func(*ProvisionOptions)) ProvisionOptions {
+	options := ProvisionOptions{
+		BillingMode: types.BillingModePayPerRequest,
+		Timeout:     DefaultProvisionTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// throughput returns the provisioned throughput of the table and its indexes, or nil
+// if they are billed per request.
+func (po ProvisionOptions) throughput() *types.ProvisionedThroughput {
+	if po.BillingMode != types.BillingModeProvisioned {
+		return nil
+	}
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(po.ReadCapacityUnits),
+		WriteCapacityUnits: aws.Int64(po.WriteCapacityUnits),
+	}
+}
+
+// CreateTableInput returns a create table request for the canonical dynamap schema: the
+// hk and sk table key, the ref index keyed by label, or [Table.RefIndexHashAttribute], and
+// gsi1_sk, and the table's secondary indexes. Indexes project all attributes. Attribute
+// aliases are applied to the index keys, and [Table.KeyNames] to the table and ref index
+// keys.
+//
+// Example:
+//
+//	input := table.CreateTableInput(func(po *dynamap.ProvisionOptions) {
+//		po.StreamViewType = types.StreamViewTypeNewAndOldImages
+//	})
+//	_, err := client.CreateTable(ctx, input)
+func (t *Table) CreateTableInput(opts ...func(*ProvisionOptions)) *dynamodb.CreateTableInput {
+	options := newProvisionOptions(opts)
+	marshalOpts := NewMarshalOptions(t.MarshalOptions)
+
+	var definitions []types.AttributeDefinition
+	define := func(name string) {
+		for _, definition := range definitions {
+			if aws.ToString(definition.AttributeName) == name {
+				return
+			}
+		}
+		definitions = append(definitions, types.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+	}
+
+	keySchema := func(partitionKey, sortKey string) []types.KeySchemaElement {
+		define(partitionKey)
+		schema := []types.KeySchemaElement{{AttributeName: aws.String(partitionKey), KeyType: types.KeyTypeHash}}
+		if sortKey != "" {
+			define(sortKey)
+			schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(sortKey), KeyType: types.KeyTypeRange})
+		}
+		return schema
+	}
+
+	index := func(name, partitionKey, sortKey string) types.GlobalSecondaryIndex {
+		return types.GlobalSecondaryIndex{
+			IndexName:             aws.String(name),
+			KeySchema:             keySchema(partitionKey, sortKey),
+			Projection:            &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			ProvisionedThroughput: options.throughput(),
+		}
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName:             aws.String(t.TableName),
+		BillingMode:           options.BillingMode,
+		KeySchema:             keySchema(t.KeyNames.name(AttributeNameSource), t.KeyNames.name(AttributeNameTarget)),
+		ProvisionedThroughput: options.throughput(),
+	}
+
+	input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index(
+		t.RefIndexName,
+		t.KeyNames.name(marshalOpts.aliasName(marshalOpts.refIndexHashKey())),
+		t.KeyNames.name(marshalOpts.aliasName(AttributeNameRefSortKey)),
+	))
+	for _, secondary := range t.Indexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index(secondary.Name, secondary.PartitionKey, secondary.SortKey))
+	}
+
+	if options.StreamViewType != "" {
+		input.StreamSpecification = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: options.StreamViewType,
+		}
+	}
+
+	input.AttributeDefinitions = definitions
+	return input
+}
+
+// EnsureTable creates the table with [Table.CreateTableInput] unless it already exists,
+// waits for it to become active, and enables time to live on the expires attribute.
+// It returns true if the table was created. Existing tables are left unchanged.
+//
+// Example:
+//
+//	created, err := table.EnsureTable(ctx, dynamodb.NewFromConfig(cfg))
+func (t *Table) EnsureTable(ctx context.Context, client TableAdminClient, opts ...func(*ProvisionOptions)) (bool, error) {
+	options := newProvisionOptions(opts)
+
+	_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(t.TableName)})
+	var notFound *types.ResourceNotFoundException
+	if err == nil {
+		return false, nil
+	} else if !errors.As(err, &notFound) {
+		return false, fmt.Errorf("failed to describe table %s: %w", t.TableName, err)
+	}
+
+	if _, err := client.CreateTable(ctx, t.CreateTableInput(opts...)); err != nil {
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			return false, fmt.Errorf("failed to create table %s: %w", t.TableName, err)
+		}
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(client, func(o *dynamodb.TableExistsWaiterOptions) {
+		o.MinDelay = time.Second
+		o.MaxDelay = 10 * time.Second
+	})
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(t.TableName)}, options.Timeout); err != nil {
+		return true, fmt.Errorf("failed to wait for table %s: %w", t.TableName, err)
+	}
+
+	if !options.DisableTTL {
+		_, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String(t.TableName),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{
+				AttributeName: aws.String(NewMarshalOptions(t.MarshalOptions).aliasName(AttributeNameExpires)),
+				Enabled:       aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return true, fmt.Errorf("failed to enable time to live on table %s: %w", t.TableName, err)
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,141 @@
+package dynamap
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// adminClient provisions tables in memory, which are active as soon as they are created.
+type adminClient struct {
+	tables map[string]*dynamodb.CreateTableInput
+	ttl    map[string]string
+}
+
+func newAdminClient() *adminClient {
+	return &adminClient{tables: make(map[string]*dynamodb.CreateTableInput), ttl: make(map[string]string)}
+}
+
+func (c *adminClient) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.tables[aws.ToString(params.TableName)] = params
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (c *adminClient) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	input, ok := c.tables[aws.ToString(params.TableName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("table not found")}
+	}
+	description := &types.TableDescription{
+		TableName:            input.TableName,
+		TableStatus:          types.TableStatusActive,
+		KeySchema:            input.KeySchema,
+		AttributeDefinitions: input.AttributeDefinitions,
+		BillingModeSummary:   &types.BillingModeSummary{BillingMode: input.BillingMode},
+	}
+	for _, index := range input.GlobalSecondaryIndexes {
+		description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:  index.IndexName,
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		})
+	}
+	return &dynamodb.DescribeTableOutput{Table: description}, nil
+}
+
+func (c *adminClient) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	description := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if name, ok := c.ttl[aws.ToString(params.TableName)]; ok {
+		description = &types.TimeToLiveDescription{AttributeName: aws.String(name), TimeToLiveStatus: types.TimeToLiveStatusEnabled}
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: description}, nil
+}
+
+func (c *adminClient) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.ttl[aws.ToString(params.TableName)] = aws.ToString(params.TimeToLiveSpecification.AttributeName)
+	return &dynamodb.UpdateTimeToLiveOutput{}, nil
+}
+
+// Tests for table provisioning
+
+func TestCreateTableInput(t *testing.T) {
+	table := NewTable("test-table")
+	table.Indexes = []SecondaryIndex{{Name: "by-email", PartitionKey: "gsi2_pk", SortKey: "gsi2_sk"}}
+	table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}}
+
+	t.Run("on demand", func(t *testing.T) {
+		input := table.CreateTableInput()
+
+		if input.BillingMode != types.BillingModePayPerRequest || input.ProvisionedThroughput != nil {
+			t.Errorf("Expected on demand billing, got %s", input.BillingMode)
+		}
+		if got := aws.ToString(input.KeySchema[0].AttributeName) + "," + aws.ToString(input.KeySchema[1].AttributeName); got != "hk,sk" {
+			t.Errorf("Expected hk,sk table key, got %s", got)
+		}
+		if len(input.GlobalSecondaryIndexes) != 2 {
+			t.Fatalf("Expected 2 indexes, got %d", len(input.GlobalSecondaryIndexes))
+		}
+
+		ref := input.GlobalSecondaryIndexes[0]
+		if aws.ToString(ref.IndexName) != table.RefIndexName || aws.ToString(ref.KeySchema[1].AttributeName) != "ref_sk" {
+			t.Errorf("Expected aliased ref index, got %s %s", aws.ToString(ref.IndexName), aws.ToString(ref.KeySchema[1].AttributeName))
+		}
+
+		var names []string
+		for _, definition := range input.AttributeDefinitions {
+			names = append(names, aws.ToString(definition.AttributeName))
+		}
+		if want := []string{"hk", "sk", "label", "ref_sk", "gsi2_pk", "gsi2_sk"}; !slices.Equal(names, want) {
+			t.Errorf("Expected attribute definitions %v, got %v", want, names)
+		}
+	})
+
+	t.Run("provisioned", func(t *testing.T) {
+		input := table.CreateTableInput(func(po *ProvisionOptions) {
+			po.BillingMode = types.BillingModeProvisioned
+			po.ReadCapacityUnits = 10
+			po.WriteCapacityUnits = 5
+			po.StreamViewType = types.StreamViewTypeNewAndOldImages
+		})
+
+		if aws.ToInt64(input.ProvisionedThroughput.ReadCapacityUnits) != 10 {
+			t.Errorf("Expected provisioned throughput, got %+v", input.ProvisionedThroughput)
+		}
+		if input.GlobalSecondaryIndexes[1].ProvisionedThroughput == nil {
+			t.Error("Expected indexes to be provisioned")
+		}
+		if input.StreamSpecification == nil || input.StreamSpecification.StreamViewType != types.StreamViewTypeNewAndOldImages {
+			t.Errorf("Expected stream specification, got %+v", input.StreamSpecification)
+		}
+	})
+}
+
+func TestEnsureTable(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newAdminClient()
+
+	created, err := table.EnsureTable(ctx, client, func(po *ProvisionOptions) { po.Timeout = time.Second })
+	if err != nil {
+		t.Fatalf("Failed to ensure table: %v", err)
+	}
+	if !created {
+		t.Error("Expected the table to be created")
+	}
+	if client.ttl["test-table"] != AttributeNameExpires {
+		t.Errorf("Expected time to live on %s, got %q", AttributeNameExpires, client.ttl["test-table"])
+	}
+
+	created, err = table.EnsureTable(ctx, client)
+	if err != nil {
+		t.Fatalf("Failed to ensure table: %v", err)
+	}
+	if created {
+		t.Error("Expected the existing table to be left unchanged")
+	}
+}
@@ -21,6 +21,7 @@ type QueryMarshaler interface {
 // of entities with a specific label.
 type QueryList struct {
 	Label           string                         // The relationship label
+	Labels          []string                       // Additional labels merged by Table.QueryUnion
 	RefSortFilter   expression.KeyConditionBuilder // Optional filters on the label sort key
 	ConditionFilter expression.ConditionBuilder    // Optional filters on the relationship
 	Limit           int                            // Maximum number of items to return
@@ -30,8 +31,16 @@ type QueryList struct {
 
 // MarshalQuery implements QueryMarshaler for QueryList.
 func (q *QueryList) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	// Most list queries only match the label, which needs no expression builder
+	if !q.RefSortFilter.IsSet() && !q.ConditionFilter.IsSet() {
+		input := &dynamodb.QueryInput{ScanIndexForward: aws.Bool(!q.SortDescending)}
+		input.KeyConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues =
+			equalityKeyCondition(opts.refIndexHashKey(), opts.tenantLabel(q.Label))
+		return q.page(input), nil
+	}
+
 	// Build the key condition for the label
-	keyCondition := expression.Key(AttributeNameLabel).Equal(expression.Value(q.Label))
+	keyCondition := expression.Key(opts.refIndexHashKey()).Equal(expression.Value(opts.tenantLabel(q.Label)))
 
 	// Add label sort filter if provided
 	if q.RefSortFilter.IsSet() {
@@ -63,6 +72,11 @@ func (q *QueryList) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, er
 		input.FilterExpression = expr.Filter()
 	}
 
+	return q.page(input), nil
+}
+
+// page sets the limit and start key of the query on input.
+func (q *QueryList) page(input *dynamodb.QueryInput) *dynamodb.QueryInput {
 	// Add limit if specified
 	if q.Limit > 0 {
 		input.Limit = aws.Int32(int32(q.Limit))
@@ -73,19 +87,25 @@ func (q *QueryList) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, er
 		input.ExclusiveStartKey = q.StartKey
 	}
 
-	return input, nil
+	return input
 }
 
 // QueryEntity is a QueryMarshaler that searches within an entity's partition for
 // key relationships. The results of this query should be unmarshaled with
 // UnmarshalEntity.
+//
+// Limit bounds the number of items read per request, so a single page may end in the middle
+// of an entity. Set EntityLimit and use [Table.QueryEntityItems] or [Table.LoadEntity] to
+// read whole entities instead.
 type QueryEntity struct {
 	Source          Marshaler                      // The source entity
 	TargetFilter    expression.KeyConditionBuilder // Optional filters on the table sort key
 	ConditionFilter expression.ConditionBuilder    // Optional filters on the relationship
 	Limit           int                            // Maximum number of items to return
+	EntityLimit     int                            // Maximum number of complete entities to return
 	StartKey        Item                           // Exclusive start key for pagination
 	SortDescending  bool                           // If true, scans backward
+	ExpandRefs      []string                       // Relationship names whose targets are loaded by Table.LoadEntity
 }
 
 // MarshalQuery implements QueryMarshaler for QueryEntity.
@@ -99,7 +119,15 @@ func (q *QueryEntity) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput,
 	}
 
 	// Create the source key
-	sourceKey := opts.sourceKey()
+	sourceKey := sourceOpts.sourceKey()
+
+	// Most entity queries only match the source, which needs no expression builder
+	if !q.TargetFilter.IsSet() && !q.ConditionFilter.IsSet() {
+		input := &dynamodb.QueryInput{ScanIndexForward: aws.Bool(!q.SortDescending)}
+		input.KeyConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues =
+			equalityKeyCondition(AttributeNameSource, sourceKey)
+		return q.page(input), nil
+	}
 
 	// Build the key condition for the source
 	keyCondition := expression.Key(AttributeNameSource).Equal(expression.Value(sourceKey))
@@ -131,6 +159,11 @@ func (q *QueryEntity) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput,
 		ScanIndexForward:          aws.Bool(!q.SortDescending),
 	}
 
+	return q.page(input), nil
+}
+
+// page sets the limit and start key of the query on input.
+func (q *QueryEntity) page(input *dynamodb.QueryInput) *dynamodb.QueryInput {
 	// Add limit if specified
 	if q.Limit > 0 {
 		input.Limit = aws.Int32(int32(q.Limit))
@@ -141,7 +174,7 @@ func (q *QueryEntity) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput,
 		input.ExclusiveStartKey = q.StartKey
 	}
 
-	return input, nil
+	return input
 }
 
 func (QueryEntity) UseIndex(*Table) string { return "" }
@@ -149,21 +182,17 @@ func (QueryList) UseIndex(t *Table) string { return t.RefIndexName }
 
 // PeriodBefore creates a condition that filters for timestamps before or equal to the given moment.
 func PeriodBefore(name string, moment time.Time) expression.ConditionBuilder {
-	value := moment.Format(time.RFC3339)
-	return expression.Name(name).LessThanEqual(expression.Value(value))
+	return TimeFilters{}.Before(name, moment)
 }
 
 // PeriodAfter creates a condition that filters for timestamps after or equal to the given moment.
 func PeriodAfter(name string, moment time.Time) expression.ConditionBuilder {
-	value := moment.Format(time.RFC3339)
-	return expression.Name(name).GreaterThanEqual(expression.Value(value))
+	return TimeFilters{}.After(name, moment)
 }
 
 // PeriodBetween creates a condition that filters for timestamps between the start and end times.
 func PeriodBetween(name string, start, end time.Time) expression.ConditionBuilder {
-	startValue := start.Format(time.RFC3339)
-	endValue := end.Format(time.RFC3339)
-	return expression.Name(name).Between(expression.Value(startValue), expression.Value(endValue))
+	return TimeFilters{}.Between(name, start, end)
 }
 
 // CreatedBefore creates a condition that filters for entities created before or equal to the given moment.
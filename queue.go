@@ -0,0 +1,325 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// QueuePrefix is the key prefix of queue partitions, whose hash keys are "queue#<name>".
+	QueuePrefix = "queue"
+	// JobPrefix is the key prefix of queued jobs, whose sort keys are "job#<id>".
+	JobPrefix = "job"
+	// AttributeNameAttempts is the attribute that counts how many times a job was claimed.
+	AttributeNameAttempts = "attempts"
+	// DefaultVisibilityTimeout is how long a claimed job is hidden from other consumers.
+	DefaultVisibilityTimeout = 30 * time.Second
+)
+
+// Job is a unit of deferred work stored in a [Queue].
+type Job struct {
+	ID         string    `dynamodbav:"id"`          // Unique job id; generated if empty
+	Queue      string    `dynamodbav:"queue"`       // The name of the queue; set by Queue.Enqueue
+	Type       string    `dynamodbav:"type"`        // The job type
+	Payload    any       `dynamodbav:"payload"`     // The job payload
+	EnqueuedAt time.Time `dynamodbav:"enqueued_at"` // When the job was enqueued; set if zero
+	VisibleAt  time.Time `dynamodbav:"-"`           // When the job can be claimed; the enqueue time if zero
+	Attempts   int       `dynamodbav:"-"`           // Number of times the job was claimed, including the current claim
+}
+
+// MarshalSelf implements [Marshaler]. Jobs are written to the partition of their queue,
+// and their visibility time is the ref sort key of the queue label.
+func (j *Job) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSource(QueuePrefix, j.Queue)
+	opts.WithTarget(JobPrefix, j.ID)
+	opts.Label = opts.sourceKey()
+	opts.RefSortKey = j.visibilityKey(opts.KeyDelimiter)
+	opts.Created = j.EnqueuedAt
+	return nil
+}
+
+// DecodePayload unmarshals the job payload into out. Payloads of jobs read from the
+// table are generic maps; use this to convert them back into their original type.
+func (j *Job) DecodePayload(out any) error {
+	av, err := attributevalue.Marshal(j.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if err := attributevalue.Unmarshal(av, out); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return nil
+}
+
+// visibilityKey returns the ref sort key of the job, which orders jobs by visibility
+// time and identifies the current claim of the job.
+func (j *Job) visibilityKey(delimiter string) string {
+	visibleAt := j.VisibleAt
+	if visibleAt.IsZero() {
+		visibleAt = j.EnqueuedAt
+	}
+	return visibleAt.UTC().Format(timeSortKeyFormat) + delimiter + j.ID
+}
+
+// Queue is a work queue stored in a partition of the table, using the visibility
+// timeout pattern: claimed jobs are hidden from other consumers until their visibility
+// timeout expires, and are deleted once acknowledged. A job whose consumer fails is
+// claimed again after the timeout, so consumers should tolerate duplicates.
+//
+// Each claim changes the visibility of the job, so only the latest claim of a job may
+// extend, release or acknowledge it; earlier claims fail with a [*ConditionFailedError].
+//
+// Example:
+//
+//	queue := dynamap.NewQueue(table, "emails", "worker-1")
+//	err := queue.Enqueue(ctx, client, &dynamap.Job{Type: "Welcome", Payload: user})
+//
+//	// In the consumer
+//	jobs, err := queue.Claim(ctx, client, 10)
+//	for _, job := range jobs {
+//		if err := send(job); err == nil {
+//			err = queue.Ack(ctx, client, job)
+//		}
+//	}
+type Queue struct {
+	Table             *Table        // The table holding the queue
+	Name              string        // The name of the queue
+	Owner             string        // Identifies the consumer claiming jobs
+	VisibilityTimeout time.Duration // How long claimed jobs are hidden from other consumers
+}
+
+// NewQueue creates a new [Queue] in the table for the consumer identified by owner.
+func NewQueue(table *Table, name, owner string) *Queue {
+	return &Queue{
+		Table:             table,
+		Name:              name,
+		Owner:             owner,
+		VisibilityTimeout: DefaultVisibilityTimeout,
+	}
+}
+
+// Enqueue writes the jobs to the queue. Jobs without an id or enqueue time are assigned
+// one, and jobs with a visibility time in the future are not claimed before it.
+func (q *Queue) Enqueue(ctx context.Context, client DynamoDBClient, jobs ...*Job) error {
+	client = q.Table.client(client)
+	now := NewMarshalOptions(q.Table.MarshalOptions).Tick()
+	for _, job := range jobs {
+		if job.ID == "" {
+			id, err := newEventID()
+			if err != nil {
+				return fmt.Errorf("failed to generate job id: %w", err)
+			}
+			job.ID = id
+		}
+		if job.EnqueuedAt.IsZero() {
+			job.EnqueuedAt = now
+		}
+		job.Queue = q.Name
+
+		input, err := q.Table.MarshalPut(job, func(mo *MarshalOptions) {
+			mo.Registry = nil // jobs are not registered entities
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+
+		if _, err := client.PutItem(ctx, input); err != nil {
+			return fmt.Errorf("failed to enqueue job: %w", classify(err))
+		}
+	}
+
+	return nil
+}
+
+// Claim reads up to limit visible jobs, earliest visible first, and hides them from
+// other consumers until the visibility timeout expires. Jobs claimed concurrently by
+// another consumer are skipped, so fewer than limit jobs may be returned even if more
+// are visible.
+func (q *Queue) Claim(ctx context.Context, client DynamoDBClient, limit int) ([]*Job, error) {
+	client = q.Table.client(client)
+	var (
+		marshalOpts = NewMarshalOptions(q.Table.MarshalOptions)
+		now         = marshalOpts.Tick()
+		timeout     = q.VisibilityTimeout
+	)
+
+	if timeout <= 0 {
+		timeout = DefaultVisibilityTimeout
+	}
+
+	// Visibility keys are the visibility time followed by the job id, so the bound
+	// includes every job visible at now
+	input, err := q.Table.MarshalQuery(&QueryList{
+		Label:         marshalOpts.WithSource(QueuePrefix, q.Name).sourceKey(),
+		RefSortFilter: expression.Key(AttributeNameRefSortKey).LessThanEqual(expression.Value(now.UTC().Format(timeSortKeyFormat) + marshalOpts.KeyDelimiter + "\uffff")),
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue: %w", classify(err))
+	}
+
+	var jobs []*Job
+	for _, item := range output.Items {
+		job := &Job{}
+		if _, err := UnmarshalSelf(item, job, q.Table.MarshalOptions); err != nil {
+			return jobs, fmt.Errorf("failed to unmarshal job: %w", err)
+		}
+		if av, ok := item[AttributeNameAttempts]; ok {
+			if err := attributevalue.Unmarshal(av, &job.Attempts); err != nil {
+				return jobs, fmt.Errorf("failed to unmarshal attempts: %w", err)
+			}
+		}
+
+		claimed, err := q.claim(ctx, client, item, job, now.Add(timeout))
+		if err != nil {
+			return jobs, err
+		} else if claimed {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// claim hides the job item until visibleAt, returning false if the job was claimed by
+// another consumer first.
+func (q *Queue) claim(ctx context.Context, client DynamoDBClient, item Item, job *Job, visibleAt time.Time) (bool, error) {
+	claimed := *job
+	claimed.VisibleAt = visibleAt
+	marshalOpts := NewMarshalOptions(q.Table.MarshalOptions)
+
+	update := expression.
+		Set(expression.Name(AttributeNameRefSortKey), expression.Value(claimed.visibilityKey(marshalOpts.KeyDelimiter))).
+		Set(expression.Name(AttributeNameClaimedBy), expression.Value(q.Owner)).
+		Add(expression.Name(AttributeNameAttempts), expression.Value(1))
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(itemExists().And(expression.Name(AttributeNameRefSortKey).Equal(expression.Value(StringValue(item[AttributeNameRefSortKey]))))).
+		Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(q.Table.TableName),
+		Key:                       Item{AttributeNameSource: item[AttributeNameSource], AttributeNameTarget: item[AttributeNameTarget]},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	input.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &input.UpdateExpression, &input.ConditionExpression)
+
+	_, err = client.UpdateItem(ctx, input)
+
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", classify(err))
+	}
+
+	job.VisibleAt = visibleAt
+	job.Attempts++
+	return true, nil
+}
+
+// Ack deletes completed jobs from the queue. Jobs claimed again since they were claimed
+// by the consumer are not deleted, and a [*ConditionFailedError] is returned.
+func (q *Queue) Ack(ctx context.Context, client DynamoDBClient, jobs ...*Job) error {
+	client = q.Table.client(client)
+	marshalOpts := NewMarshalOptions(q.Table.MarshalOptions)
+	for _, job := range jobs {
+		input, err := q.Table.MarshalDelete(job)
+		if err != nil {
+			return err
+		}
+
+		expr, err := expression.NewBuilder().WithCondition(job.claimedAs(marshalOpts.KeyDelimiter)).Build()
+		if err != nil {
+			return fmt.Errorf("failed to build expression: %w", err)
+		}
+
+		input.ConditionExpression = expr.Condition()
+		input.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &input.ConditionExpression)
+		input.ExpressionAttributeValues = expr.Values()
+
+		if _, err := client.DeleteItem(ctx, input); err != nil {
+			return conditionFailed(input.Key, fmt.Errorf("failed to ack job: %w", classify(err)))
+		}
+	}
+
+	return nil
+}
+
+// Extend hides claimed jobs from other consumers for the timeout from now, for jobs
+// that take longer than the visibility timeout. Jobs claimed again since they were
+// claimed by the consumer are not extended, and a [*ConditionFailedError] is returned.
+func (q *Queue) Extend(ctx context.Context, client DynamoDBClient, timeout time.Duration, jobs ...*Job) error {
+	now := NewMarshalOptions(q.Table.MarshalOptions).Tick()
+	return q.setVisibility(ctx, client, now.Add(timeout), "extend", jobs)
+}
+
+// Release gives up the consumer's claim on jobs so they can be claimed again
+// immediately, for example after a failure.
+func (q *Queue) Release(ctx context.Context, client DynamoDBClient, jobs ...*Job) error {
+	now := NewMarshalOptions(q.Table.MarshalOptions).Tick()
+	return q.setVisibility(ctx, client, now, "release", jobs)
+}
+
+// setVisibility changes the visibility time of claimed jobs.
+func (q *Queue) setVisibility(ctx context.Context, client DynamoDBClient, visibleAt time.Time, action string, jobs []*Job) error {
+	client = q.Table.client(client)
+	marshalOpts := NewMarshalOptions(q.Table.MarshalOptions)
+	for _, job := range jobs {
+		input, err := q.Table.MarshalGet(job)
+		if err != nil {
+			return err
+		}
+
+		next := *job
+		next.VisibleAt = visibleAt
+		expr, err := expression.NewBuilder().
+			WithUpdate(expression.Set(expression.Name(AttributeNameRefSortKey), expression.Value(next.visibilityKey(marshalOpts.KeyDelimiter)))).
+			WithCondition(job.claimedAs(marshalOpts.KeyDelimiter)).
+			Build()
+		if err != nil {
+			return fmt.Errorf("failed to build expression: %w", err)
+		}
+
+		update := &dynamodb.UpdateItemInput{
+			TableName:                 input.TableName,
+			Key:                       input.Key,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeValues: expr.Values(),
+		}
+		update.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &update.UpdateExpression, &update.ConditionExpression)
+
+		if _, err := client.UpdateItem(ctx, update); err != nil {
+			return conditionFailed(input.Key, fmt.Errorf("failed to %s job: %w", action, classify(err)))
+		}
+		job.VisibleAt = visibleAt
+	}
+
+	return nil
+}
+
+// claimedAs returns a condition that holds if the job was not claimed again since its
+// current visibility time was set.
+func (j *Job) claimedAs(delimiter string) expression.ConditionBuilder {
+	return expression.Name(AttributeNameRefSortKey).Equal(expression.Value(j.visibilityKey(delimiter)))
+}
@@ -0,0 +1,276 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// queueClient serves queue queries from the mock table in visibility order, and applies
+// updates and deletes whose ref sort key condition holds.
+type queueClient struct {
+	*mockDynamoDBClient
+}
+
+// assignedValue returns the value assigned to or compared with the attribute in expr.
+func assignedValue(expr string, names map[string]string, values map[string]types.AttributeValue, attribute string) (types.AttributeValue, bool) {
+	for placeholder, name := range names {
+		if name != attribute {
+			continue
+		}
+		if m := regexp.MustCompile(placeholder + ` = (:\w+)`).FindStringSubmatch(expr); m != nil {
+			return values[m[1]], true
+		}
+	}
+	return nil, false
+}
+
+func (c *queueClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	label, _ := assignedValue(aws.ToString(params.KeyConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues, AttributeNameLabel)
+	var bound string
+	for _, value := range params.ExpressionAttributeValues {
+		if s := StringValue(value); s != StringValue(label) {
+			bound = s
+		}
+	}
+
+	var items []Item
+	for _, item := range c.items {
+		if StringValue(item[AttributeNameLabel]) == StringValue(label) && StringValue(item[AttributeNameRefSortKey]) <= bound {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return StringValue(items[i][AttributeNameRefSortKey]) < StringValue(items[j][AttributeNameRefSortKey])
+	})
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func (c *queueClient) item(key Item, condition string, names map[string]string, values map[string]types.AttributeValue) (Item, error) {
+	item := c.items[StringValue(key[AttributeNameSource])+"#"+StringValue(key[AttributeNameTarget])]
+	want, _ := assignedValue(condition, names, values, AttributeNameRefSortKey)
+	if item == nil || StringValue(item[AttributeNameRefSortKey]) != StringValue(want) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	return item, nil
+}
+
+func (c *queueClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	item, err := c.item(params.Key, aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	update := aws.ToString(params.UpdateExpression)
+	for _, name := range []string{AttributeNameRefSortKey, AttributeNameClaimedBy} {
+		if value, ok := assignedValue(update, params.ExpressionAttributeNames, params.ExpressionAttributeValues, name); ok {
+			item[name] = value
+		}
+	}
+	if strings.Contains(update, "ADD") {
+		attempts := 0
+		if n, ok := item[AttributeNameAttempts].(*types.AttributeValueMemberN); ok {
+			attempts, _ = strconv.Atoi(n.Value)
+		}
+		item[AttributeNameAttempts] = &types.AttributeValueMemberN{Value: strconv.Itoa(attempts + 1)}
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *queueClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if _, err := c.item(params.Key, aws.ToString(params.ConditionExpression), params.ExpressionAttributeNames, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	return c.mockDynamoDBClient.DeleteItem(ctx, params, optFns...)
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.Clock = func() time.Time { return now }
+
+	client := &queueClient{mockDynamoDBClient: newMockDynamoDBClient()}
+	worker1 := NewQueue(table, "emails", "worker-1")
+	worker2 := NewQueue(table, "emails", "worker-2")
+
+	first := &Job{Type: "Welcome", Payload: map[string]string{"to": "a@example.com"}}
+	delayed := &Job{ID: "delayed", Type: "Reminder", VisibleAt: now.Add(time.Hour)}
+	if err := worker1.Enqueue(ctx, client, first, delayed); err != nil {
+		t.Fatalf("Failed to enqueue jobs: %v", err)
+	}
+	if first.ID == "" || !first.EnqueuedAt.Equal(now) || first.Queue != "emails" {
+		t.Errorf("Expected the job to be assigned an id, enqueue time and queue, got %+v", first)
+	}
+
+	item, ok := client.items["queue#emails#job#"+first.ID]
+	if !ok {
+		t.Fatalf("Expected the job in the queue partition, got %v", client.items)
+	}
+	if label := StringValue(item[AttributeNameLabel]); label != "queue#emails" {
+		t.Errorf("Expected the queue label, got %q", label)
+	}
+
+	now = now.Add(time.Second)
+	jobs, err := worker1.Claim(ctx, client, 10)
+	if err != nil {
+		t.Fatalf("Failed to claim jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != first.ID || jobs[0].Attempts != 1 {
+		t.Fatalf("Expected the visible job to be claimed once, got %+v", jobs)
+	}
+	var payload map[string]string
+	if err := jobs[0].DecodePayload(&payload); err != nil || payload["to"] != "a@example.com" {
+		t.Errorf("Expected the decoded payload, got %v (%v)", payload, err)
+	}
+
+	if others, err := worker2.Claim(ctx, client, 10); err != nil || len(others) != 0 {
+		t.Errorf("Expected claimed jobs to be hidden, got %v (%v)", others, err)
+	}
+
+	// The claim expires and the job is claimed by another worker
+	now = now.Add(DefaultVisibilityTimeout + time.Second)
+	retried, err := worker2.Claim(ctx, client, 10)
+	if err != nil || len(retried) != 1 || retried[0].Attempts != 2 {
+		t.Fatalf("Expected the job to be claimed again, got %+v (%v)", retried, err)
+	}
+
+	if err := worker1.Ack(ctx, client, jobs[0]); !errors.Is(err, ErrConditionFailed) {
+		t.Errorf("Expected ErrConditionFailed for a stale claim, got %v", err)
+	}
+	if err := worker1.Extend(ctx, client, time.Minute, jobs[0]); !errors.Is(err, ErrConditionFailed) {
+		t.Errorf("Expected ErrConditionFailed extending a stale claim, got %v", err)
+	}
+
+	if err := worker2.Extend(ctx, client, time.Hour, retried[0]); err != nil {
+		t.Fatalf("Failed to extend job: %v", err)
+	}
+	now = now.Add(DefaultVisibilityTimeout + time.Second)
+	if others, err := worker1.Claim(ctx, client, 10); err != nil || len(others) != 0 {
+		t.Errorf("Expected extended jobs to be hidden, got %v (%v)", others, err)
+	}
+
+	if err := worker2.Release(ctx, client, retried[0]); err != nil {
+		t.Fatalf("Failed to release job: %v", err)
+	}
+	now = now.Add(time.Second)
+	released, err := worker1.Claim(ctx, client, 10)
+	if err != nil || len(released) != 1 {
+		t.Fatalf("Expected the released job to be claimed, got %+v (%v)", released, err)
+	}
+
+	if err := worker1.Ack(ctx, client, released[0]); err != nil {
+		t.Fatalf("Failed to ack job: %v", err)
+	}
+	if _, ok := client.items["queue#emails#job#"+first.ID]; ok {
+		t.Error("Expected the acknowledged job to be deleted")
+	}
+
+	now = now.Add(time.Hour)
+	if jobs, err := worker1.Claim(ctx, client, 10); err != nil || len(jobs) != 1 || jobs[0].ID != "delayed" {
+		t.Errorf("Expected the delayed job once visible, got %+v (%v)", jobs, err)
+	}
+}
+
+func TestQueueClaimVisibleNow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.Clock = func() time.Time { return now }
+
+	client := &queueClient{mockDynamoDBClient: newMockDynamoDBClient()}
+	queue := NewQueue(table, "emails", "worker-1")
+	if err := queue.Enqueue(ctx, client, &Job{ID: "J1", Type: "Welcome"}); err != nil {
+		t.Fatalf("Failed to enqueue job: %v", err)
+	}
+
+	// The job is visible at its enqueue time, without waiting for the clock to move
+	jobs, err := queue.Claim(ctx, client, 10)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != "J1" {
+		t.Errorf("Expected the job visible now to be claimed, got %+v (%v)", jobs, err)
+	}
+}
+
+// claimRecorder serves the items of a queue and records the updates claiming them.
+type claimRecorder struct {
+	*mockDynamoDBClient
+	items   []Item
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *claimRecorder) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{Items: c.items}, nil
+}
+
+func (c *claimRecorder) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, params)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func TestQueueClaimNames(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		setup func(*Table)
+		want  string
+	}{
+		{
+			name:  "aliases",
+			setup: func(table *Table) { table.Aliases = []AttributeAlias{{Name: AttributeNameRefSortKey, Alias: "ref_sk"}} },
+			want:  "ref_sk",
+		},
+		{
+			name:  "key names",
+			setup: func(table *Table) { table.KeyNames = KeyNames{RefSortKey: "GSI1SK"} },
+			want:  "GSI1SK",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			table := NewTable("test-table")
+			table.Clock = func() time.Time { return now }
+			tc.setup(table)
+
+			job := &Job{ID: "J1", Queue: "emails", Type: "Welcome", EnqueuedAt: now}
+			input, err := table.MarshalPut(job)
+			if err != nil {
+				t.Fatalf("Failed to marshal job: %v", err)
+			}
+			client := &claimRecorder{mockDynamoDBClient: newMockDynamoDBClient(), items: []Item{input.Item}}
+
+			if _, err := NewQueue(table, "emails", "worker-1").Claim(ctx, client, 10); err != nil {
+				t.Fatalf("Failed to claim jobs: %v", err)
+			}
+			if len(client.updates) != 1 {
+				t.Fatalf("Expected one claim, got %d", len(client.updates))
+			}
+
+			update := client.updates[0]
+			names := update.ExpressionAttributeNames
+			if expr := resolveNames(aws.ToString(update.UpdateExpression), names); !strings.Contains(expr, tc.want+" = ") {
+				t.Errorf("Expected the claim to set %s, got %s", tc.want, expr)
+			}
+			if expr := resolveNames(aws.ToString(update.ConditionExpression), names); !strings.Contains(expr, tc.want+" = ") {
+				t.Errorf("Expected the claim to check %s, got %s", tc.want, expr)
+			}
+			for _, name := range names {
+				if name == AttributeNameRefSortKey {
+					t.Errorf("Expected no %s in the claim names, got %v", AttributeNameRefSortKey, names)
+				}
+			}
+		})
+	}
+}
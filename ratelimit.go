@@ -0,0 +1,440 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// MinRateScale is the lowest fraction of its configured rates a [RateLimiter] backs off to.
+	MinRateScale = 1.0 / 16
+
+	// rateRecovery is the fraction of the configured rates restored after each request
+	// that is not throttled.
+	rateRecovery = 0.01
+)
+
+// RateLimiter is an [Interceptor] that paces requests to configured read and write
+// capacity unit rates using token buckets, so bulk operations such as backfills and
+// migrations don't exhaust the provisioned capacity of a table. The units of each
+// request are estimated from its items before it executes, then corrected using the
+// consumed capacity DynamoDB returns, which the limiter requests.
+//
+// When a request is throttled, or a batch request returns unprocessed items, the
+// limiter halves its rates down to [MinRateScale], then gradually restores them as
+// requests succeed.
+//
+// Example:
+//
+//	limiter := dynamap.NewRateLimiter(100, 50) // 100 RCU and 50 WCU per second
+//	table.Interceptors = append(table.Interceptors, limiter)
+type RateLimiter struct {
+	ReadUnits  float64 // Read capacity units per second. Zero disables read limiting.
+	WriteUnits float64 // Write capacity units per second. Zero disables write limiting.
+	Clock      Clock   // Clock used to refill the buckets. Default is DefaultClock.
+
+	mu     sync.Mutex
+	read   tokenBucket
+	write  tokenBucket
+	scale  float64
+	sleep  func(ctx context.Context, d time.Duration) error
+	loaded bool
+}
+
+// tokenBucket holds the capacity units available to requests. Its balance may be
+// negative, after a request consumes more units than were available.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a new [RateLimiter] allowing the given capacity units per second.
+func NewRateLimiter(readUnits, writeUnits float64) *RateLimiter {
+	return &RateLimiter{ReadUnits: readUnits, WriteUnits: writeUnits, Clock: DefaultClock}
+}
+
+// Scale returns the fraction of the configured rates currently allowed.
+func (l *RateLimiter) Scale() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.init()
+	return l.scale
+}
+
+// Wait blocks until the capacity units are available, then consumes them. Writes consume
+// write units and reads consume read units.
+func (l *RateLimiter) Wait(ctx context.Context, units float64, write bool) error {
+	l.mu.Lock()
+	delay := l.reserve(units, write)
+	sleep := l.sleep
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if sleep != nil {
+		return sleep(ctx, delay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Before implements [Interceptor] by waiting for the estimated capacity units of the request.
+func (l *RateLimiter) Before(ctx context.Context, req *Request) error {
+	units, write := estimateCapacity(req.Input)
+	req.Input = returnConsumedCapacity(req.Input)
+	return l.Wait(ctx, units, write)
+}
+
+// After implements [Interceptor] by correcting the estimate with the consumed capacity,
+// and adapting the rates to throttling.
+func (l *RateLimiter) After(ctx context.Context, req *Request) {
+	estimate, write := estimateCapacity(req.Input)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if consumed, ok := consumedCapacity(req.Output); ok {
+		l.reserve(consumed-estimate, write)
+	}
+
+	if throttled(req.Output, req.Err) {
+		l.scale = max(l.scale/2, MinRateScale)
+	} else if req.Err == nil {
+		l.scale = min(l.scale+rateRecovery, 1)
+	}
+}
+
+// LimitClient returns a client sending the requests of client, including its scans,
+// paced by the limiter. Scans don't pass through the interceptors of a table, so bulk
+// operations scanning a table, such as [Table.FindOrphans], accept a limiter in their
+// options and pace their requests with it.
+func (l *RateLimiter) LimitClient(client MaintenanceClient) MaintenanceClient {
+	return &limitedClient{
+		interceptClient:   l.limitWrites(client),
+		limitedScanClient: limitedScanClient{client: client, limiter: l},
+	}
+}
+
+// limitWrites returns client with its requests paced by the limiter.
+func (l *RateLimiter) limitWrites(client DynamoDBClient) *interceptClient {
+	return &interceptClient{next: client, interceptors: []Interceptor{l}}
+}
+
+// limitedClient is a [MaintenanceClient] whose requests are paced by a [RateLimiter].
+type limitedClient struct {
+	*interceptClient
+	limitedScanClient
+}
+
+// limitedScanClient is a [ScanClient] whose scans are paced by a [RateLimiter].
+type limitedScanClient struct {
+	client  ScanClient
+	limiter *RateLimiter
+}
+
+// Scan sends the scan request once the limiter allows it.
+func (c limitedScanClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return intercept(ctx, []Interceptor{c.limiter}, OperationScan, params, func(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		return c.client.Scan(ctx, in, optFns...)
+	})
+}
+
+// init sets the initial state of the limiter, with full buckets.
+func (l *RateLimiter) init() {
+	if l.loaded {
+		return
+	}
+	now := l.now()
+	l.read = tokenBucket{tokens: l.ReadUnits, last: now}
+	l.write = tokenBucket{tokens: l.WriteUnits, last: now}
+	l.scale = 1
+	l.loaded = true
+}
+
+// now returns the current time of the limiter clock.
+func (l *RateLimiter) now() time.Time {
+	if l.Clock == nil {
+		return DefaultClock()
+	}
+	return l.Clock()
+}
+
+// reserve refills the bucket, consumes the units and returns the time to wait until the
+// balance is no longer negative. Negative units return capacity to the bucket.
+func (l *RateLimiter) reserve(units float64, write bool) time.Duration {
+	l.init()
+
+	bucket, limit := &l.read, l.ReadUnits
+	if write {
+		bucket, limit = &l.write, l.WriteUnits
+	}
+	if limit <= 0 {
+		return 0
+	}
+
+	rate := limit * l.scale
+	now := l.now()
+	bucket.tokens = min(bucket.tokens+now.Sub(bucket.last).Seconds()*rate, rate)
+	bucket.last = now
+	bucket.tokens -= units
+
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / rate * float64(time.Second))
+}
+
+// estimateCapacity estimates the capacity units of a request, and whether they are write units.
+func estimateCapacity(input any) (float64, bool) {
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		return writeUnits(itemSize(in.Item)), true
+	case *dynamodb.UpdateItemInput:
+		return writeUnits(itemSize(in.Key) + itemSize(in.ExpressionAttributeValues)), true
+	case *dynamodb.DeleteItemInput:
+		return 1, true
+	case *dynamodb.BatchWriteItemInput:
+		var units float64
+		for _, requests := range in.RequestItems {
+			for _, request := range requests {
+				if request.PutRequest != nil {
+					units += writeUnits(itemSize(request.PutRequest.Item))
+				} else {
+					units++
+				}
+			}
+		}
+		return units, true
+	case *dynamodb.TransactWriteItemsInput:
+		var units float64
+		for _, action := range in.TransactItems {
+			if action.Put != nil {
+				units += writeUnits(itemSize(action.Put.Item))
+			} else {
+				units++
+			}
+		}
+		return 2 * units, true
+	case *dynamodb.GetItemInput:
+		return readUnits(aws.ToBool(in.ConsistentRead)), false
+	case *dynamodb.QueryInput:
+		return readUnits(aws.ToBool(in.ConsistentRead)), false
+	case *dynamodb.ScanInput:
+		return readUnits(aws.ToBool(in.ConsistentRead)), false
+	case *dynamodb.BatchGetItemInput:
+		var units float64
+		for _, request := range in.RequestItems {
+			units += float64(len(request.Keys)) * readUnits(aws.ToBool(request.ConsistentRead))
+		}
+		return units, false
+	}
+	return 0, false
+}
+
+// writeUnits returns the write capacity units of writing size bytes: one per kilobyte.
+func writeUnits(size int) float64 {
+	return max(math.Ceil(float64(size)/1024), 1)
+}
+
+// readUnits returns the read capacity units of reading an item of up to 4 kilobytes.
+func readUnits(consistent bool) float64 {
+	if consistent {
+		return 1
+	}
+	return 0.5
+}
+
+// itemSize approximates the size of an item as DynamoDB measures it.
+func itemSize(item Item) int {
+	var size int
+	for name, value := range item {
+		size += len(name) + attributeSize(value)
+	}
+	return size
+}
+
+// attributeSize approximates the size of an attribute value as DynamoDB measures it.
+func attributeSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return (len(v.Value)+1)/2 + 1
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberSS:
+		var size int
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		var size int
+		for _, n := range v.Value {
+			size += (len(n)+1)/2 + 1
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		var size int
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, element := range v.Value {
+			size += 1 + attributeSize(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		return 3 + itemSize(v.Value)
+	default:
+		return 1
+	}
+}
+
+// returnConsumedCapacity returns a copy of the input asking DynamoDB to return the
+// consumed capacity of the request, or the input itself if the caller already chose. The
+// input is not modified, since callers may reuse it.
+func returnConsumedCapacity(input any) any {
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.UpdateItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.DeleteItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.GetItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.QueryInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.BatchGetItemInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	case *dynamodb.ScanInput:
+		if in.ReturnConsumedCapacity == "" {
+			copied := *in
+			copied.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+			return &copied
+		}
+	}
+	return input
+}
+
+// consumedCapacity returns the capacity units consumed by a request, if DynamoDB returned them.
+func consumedCapacity(output any) (float64, bool) {
+	var capacities []types.ConsumedCapacity
+	switch out := output.(type) {
+	case *dynamodb.PutItemOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.UpdateItemOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.DeleteItemOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.GetItemOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.QueryOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.ScanOutput:
+		capacities = optionalCapacity(out.ConsumedCapacity)
+	case *dynamodb.BatchWriteItemOutput:
+		capacities = out.ConsumedCapacity
+	case *dynamodb.TransactWriteItemsOutput:
+		capacities = out.ConsumedCapacity
+	case *dynamodb.BatchGetItemOutput:
+		capacities = out.ConsumedCapacity
+	}
+
+	if len(capacities) == 0 {
+		return 0, false
+	}
+
+	var units float64
+	for _, capacity := range capacities {
+		units += aws.ToFloat64(capacity.CapacityUnits)
+	}
+	return units, true
+}
+
+// optionalCapacity returns the consumed capacity as a slice, which is empty if nil.
+func optionalCapacity(capacity *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if capacity == nil {
+		return nil
+	}
+	return []types.ConsumedCapacity{*capacity}
+}
+
+// throttled returns true if the request was throttled, or left batch items unprocessed
+// because the table lacked capacity.
+func throttled(output any, err error) bool {
+	var (
+		exceeded *types.ProvisionedThroughputExceededException
+		limited  *types.RequestLimitExceeded
+		coded    interface{ ErrorCode() string }
+	)
+	switch {
+	case errors.As(err, &exceeded), errors.As(err, &limited):
+		return true
+	case errors.As(err, &coded) && coded.ErrorCode() == "ThrottlingException":
+		return true
+	}
+
+	switch out := output.(type) {
+	case *dynamodb.BatchWriteItemOutput:
+		return out != nil && len(out.UnprocessedItems) > 0
+	case *dynamodb.BatchGetItemOutput:
+		return out != nil && len(out.UnprocessedKeys) > 0
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// throttlingClient fails the first batch writes with a throughput error, and leaves
+// the next batch write unprocessed.
+type throttlingClient struct {
+	*mockDynamoDBClient
+	failures    int
+	unprocessed int
+}
+
+func (c *throttlingClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if c.failures > 0 {
+		c.failures--
+		return nil, &types.ProvisionedThroughputExceededException{Message: new(string)}
+	}
+	if c.unprocessed > 0 {
+		c.unprocessed--
+		return &dynamodb.BatchWriteItemOutput{UnprocessedItems: params.RequestItems}, nil
+	}
+	return c.mockDynamoDBClient.BatchWriteItem(ctx, params, optFns...)
+}
+
+// newTestRateLimiter returns a limiter with a fake clock, advanced by its waits.
+func newTestRateLimiter(readUnits, writeUnits float64) (*RateLimiter, *time.Duration) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var waited time.Duration
+
+	limiter := NewRateLimiter(readUnits, writeUnits)
+	limiter.Clock = func() time.Time { return now }
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		now = now.Add(d)
+		return nil
+	}
+	return limiter, &waited
+}
+
+// Tests for rate limiting
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("paces writes", func(t *testing.T) {
+		limiter, waited := newTestRateLimiter(0, 10)
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{limiter}
+
+		// 30 single unit writes at 10 WCU: the first 10 use the full bucket
+		client := newMockDynamoDBClient()
+		for i := range 30 {
+			if err := table.Put(ctx, client, &Product{ID: fmt.Sprintf("P%d", i)}); err != nil {
+				t.Fatalf("Failed to put product: %v", err)
+			}
+		}
+
+		if *waited != 2*time.Second {
+			t.Errorf("Expected to wait 2s, got %v", *waited)
+		}
+	})
+
+	t.Run("unlimited reads", func(t *testing.T) {
+		limiter, waited := newTestRateLimiter(0, 10)
+		for range 100 {
+			if err := limiter.Wait(ctx, 1, false); err != nil {
+				t.Fatalf("Failed to wait: %v", err)
+			}
+		}
+		if *waited != 0 {
+			t.Errorf("Expected reads not to wait, got %v", *waited)
+		}
+	})
+
+	t.Run("consumed capacity", func(t *testing.T) {
+		limiter, waited := newTestRateLimiter(10, 0)
+		input := &dynamodb.QueryInput{}
+		req := &Request{Operation: OperationQuery, Input: input}
+		if err := limiter.Before(ctx, req); err != nil {
+			t.Fatalf("Failed before request: %v", err)
+		}
+		if req.Input.(*dynamodb.QueryInput).ReturnConsumedCapacity != types.ReturnConsumedCapacityTotal {
+			t.Error("Expected the limiter to request consumed capacity")
+		}
+		if input.ReturnConsumedCapacity != "" {
+			t.Error("Expected the caller's input to be left unchanged")
+		}
+
+		// The query consumed 20 units rather than the estimated 0.5
+		units := 20.0
+		req.Output = &dynamodb.QueryOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: &units}}
+		limiter.After(ctx, req)
+
+		if err := limiter.Wait(ctx, 0.5, false); err != nil {
+			t.Fatalf("Failed to wait: %v", err)
+		}
+		if *waited != time.Second+50*time.Millisecond {
+			t.Errorf("Expected to wait 1.05s, got %v", *waited)
+		}
+	})
+
+	t.Run("adaptive backoff", func(t *testing.T) {
+		limiter, _ := newTestRateLimiter(0, 100)
+		table := NewTable("test-table")
+		table.Interceptors = []Interceptor{limiter}
+
+		client := &throttlingClient{mockDynamoDBClient: newMockDynamoDBClient(), failures: 1}
+		if err := table.Attach(ctx, client, &Order{ID: "O1"}, "products", []Marshaler{&Product{ID: "P1"}}); err == nil {
+			t.Fatal("Expected the throttled write to fail")
+		}
+		if scale := limiter.Scale(); scale != 0.5 {
+			t.Errorf("Expected rates to be halved, got %v", scale)
+		}
+
+		client.unprocessed = 1
+		if err := table.Attach(ctx, client, &Order{ID: "O1"}, "products", []Marshaler{&Product{ID: "P1"}}); err != nil {
+			t.Fatalf("Failed to attach: %v", err)
+		}
+		if scale := limiter.Scale(); scale != 0.25+rateRecovery {
+			t.Errorf("Expected rates to be halved again and recover, got %v", scale)
+		}
+
+		for range 200 {
+			limiter.After(ctx, &Request{Operation: OperationPut, Output: &dynamodb.PutItemOutput{}})
+		}
+		if scale := limiter.Scale(); scale != 1 {
+			t.Errorf("Expected rates to recover, got %v", scale)
+		}
+	})
+
+	t.Run("paces scans", func(t *testing.T) {
+		limiter, waited := newTestRateLimiter(1, 0)
+		client := limiter.LimitClient(&orphanClient{mockDynamoDBClient: newMockDynamoDBClient()})
+
+		// Each scan is estimated at half a unit
+		for range 4 {
+			if _, err := client.Scan(ctx, &dynamodb.ScanInput{}); err != nil {
+				t.Fatalf("Failed to scan: %v", err)
+			}
+		}
+		if *waited != time.Second {
+			t.Errorf("Expected to wait 1s, got %v", *waited)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package dynamap
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// DecodeRefData decodes the data of ref, as received by [RefUnmarshaler.UnmarshalRef], into
+// a new value of T. The most complete data of the target is decoded:
+//   - the full target data, if the ref was expanded by [Table.ExpandRefs],
+//   - the [Ref.Snapshot] of the target, if it is a [Snapshotter], or
+//   - the [Ref] stub itself otherwise.
+//
+// If T is a pointer type, it points to a new value.
+//
+// Example:
+//
+//	func (o *Order) UnmarshalRef(name, id string, ref *dynamap.Relationship) error {
+//		product, err := dynamap.DecodeRefData[Product](ref)
+//		if err != nil {
+//			return err
+//		}
+//		o.Products = append(o.Products, product)
+//		return nil
+//	}
+func DecodeRefData[T any](ref *Relationship) (T, error) {
+	data := ref.Data
+	switch stub := data.(type) {
+	case Ref:
+		data = stub.target()
+	case *Ref:
+		data = stub.target()
+	case map[string]any:
+		if isRefStub(stub) {
+			if snapshot := stub["Snapshot"]; snapshot != nil {
+				data = snapshot
+			}
+		}
+	}
+
+	return decodeData[T](data)
+}
+
+// DataAs returns the data of rel as a value of T. Depending on how the relationship was
+// read, its data may be the typed value, a pointer to it, or a generic map, such as after
+// [UnmarshalRelationship]; DataAs normalizes each of them, so the same accessor works
+// regardless of the decode path:
+//
+//	product, err := dynamap.DataAs[Product](rel)  // from Product, *Product or map[string]any
+//	ptr, err := dynamap.DataAs[*Product](rel)     // the same, as a pointer
+//
+// If T is a pointer type and the data is not already a pointer of that type, it points to
+// a new value.
+func DataAs[T any](rel Relationship) (T, error) {
+	switch data := rel.Data.(type) {
+	case T:
+		return data, nil
+	case *T:
+		if data != nil {
+			return *data, nil
+		}
+	}
+
+	if typ := reflect.TypeFor[T](); typ.Kind() == reflect.Pointer {
+		if value := reflect.ValueOf(rel.Data); value.IsValid() && value.Type() == typ.Elem() {
+			ptr := reflect.New(typ.Elem())
+			ptr.Elem().Set(value)
+			return ptr.Interface().(T), nil
+		}
+	}
+
+	return decodeData[T](rel.Data)
+}
+
+// decodeData decodes data into a new value of T through its attribute value.
+func decodeData[T any](data any) (T, error) {
+	out := newValue[T]()
+
+	av, err := attributevalue.Marshal(data)
+	if err != nil {
+		return out, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	if err := attributevalue.Unmarshal(av, &out); err != nil {
+		return out, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return out, nil
+}
+
+// target returns the snapshot of the target, if any, or the ref itself.
+func (r Ref) target() any {
+	if r.Snapshot != nil {
+		return r.Snapshot
+	}
+	return r
+}
+
+// isRefStub returns true if data is a [Ref] rather than the data of an expanded target.
+func isRefStub(data map[string]any) bool {
+	for _, name := range []string{"Name", "SourceID", "TargetID"} {
+		if _, ok := data[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RefTypes maps relationship names to the Go types their data is decoded into by
+// [RefTypes.Decode]. Declare the types with [RefType]. RefTypes is not safe for concurrent
+// registration.
+//
+// Example:
+//
+//	var orderRefs = dynamap.RefTypes{
+//		"products": dynamap.RefType[Product](),
+//		"customer": dynamap.RefType[*Customer](),
+//	}
+//
+//	func (o *Order) UnmarshalRef(name, id string, ref *dynamap.Relationship) error {
+//		data, err := orderRefs.Decode(name, ref)
+//		if err != nil {
+//			return err
+//		}
+//		switch data := data.(type) {
+//		case Product:
+//			o.Products = append(o.Products, data)
+//		case *Customer:
+//			o.Customer = data
+//		}
+//		return nil
+//	}
+type RefTypes map[string]func(ref *Relationship) (any, error)
+
+// RefType returns a decoder of relationship data into T using [DecodeRefData], for
+// registration in [RefTypes].
+func RefType[T any]() func(ref *Relationship) (any, error) {
+	return func(ref *Relationship) (any, error) {
+		return DecodeRefData[T](ref)
+	}
+}
+
+// Decode decodes the data of ref into the type registered for the relationship name.
+// [ErrUnknownRelationship] is returned if no type is registered for name.
+func (r RefTypes) Decode(name string, ref *Relationship) (any, error) {
+	decode, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no type registered for %q", ErrUnknownRelationship, name)
+	}
+	data, err := decode(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ref %s: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,155 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// typedCart decodes its refs with the registered ref types.
+type typedCart struct {
+	cart
+	snapshots []map[string]string
+	products  []*Product
+}
+
+var typedCartRefs = RefTypes{
+	"items":    RefType[map[string]string](),
+	"products": RefType[*Product](),
+}
+
+func (c *typedCart) MarshalRefs(ctx *RelationshipContext) error {
+	if err := c.cart.MarshalRefs(ctx); err != nil {
+		return err
+	}
+	ctx.AddOne("products", &Product{ID: "P1", Category: "widgets"})
+	return nil
+}
+
+func (c *typedCart) UnmarshalRef(name string, id string, ref *Relationship) error {
+	data, err := typedCartRefs.Decode(name, ref)
+	if err != nil {
+		return err
+	}
+	switch data := data.(type) {
+	case map[string]string:
+		c.snapshots = append(c.snapshots, data)
+	case *Product:
+		c.products = append(c.products, data)
+	}
+	return nil
+}
+
+// Tests for decoding ref data
+
+func TestDecodeRefData(t *testing.T) {
+	in := &typedCart{cart: cart{ID: "C1", Items: []catalogItem{{ID: "I1", Name: "Widget"}}}}
+	relationships, err := MarshalRelationships(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal relationships: %v", err)
+	}
+
+	var items []Item
+	for _, rel := range relationships {
+		item, err := attributevalue.MarshalMap(rel)
+		if err != nil {
+			t.Fatalf("Failed to marshal item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	t.Run("registered types", func(t *testing.T) {
+		var out typedCart
+		if _, err := UnmarshalEntity(items, &out); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(out.snapshots) != 1 || out.snapshots[0]["name"] != "Widget" {
+			t.Errorf("Expected snapshot of the item, got %v", out.snapshots)
+		}
+		// Products are not snapshotters, so the ref stub is decoded
+		if len(out.products) != 1 || out.products[0].ID != "" {
+			t.Errorf("Expected product stub, got %+v", out.products)
+		}
+	})
+
+	t.Run("ref stub", func(t *testing.T) {
+		ref := &Relationship{Data: Ref{Name: "products", SourceID: "C1", TargetID: "P1"}}
+		stub, err := DecodeRefData[Ref](ref)
+		if err != nil {
+			t.Fatalf("Failed to decode ref data: %v", err)
+		}
+		if stub.TargetID != "P1" {
+			t.Errorf("Expected target P1, got %+v", stub)
+		}
+	})
+
+	t.Run("expanded target", func(t *testing.T) {
+		ref := &Relationship{Data: map[string]any{"id": "P1", "category": "widgets"}}
+		product, err := DecodeRefData[Product](ref)
+		if err != nil {
+			t.Fatalf("Failed to decode ref data: %v", err)
+		}
+		if product.ID != "P1" || product.Category != "widgets" {
+			t.Errorf("Expected expanded product, got %+v", product)
+		}
+	})
+
+	t.Run("unknown relationship", func(t *testing.T) {
+		_, err := typedCartRefs.Decode("orders", &Relationship{})
+		if !errors.Is(err, ErrUnknownRelationship) {
+			t.Errorf("Expected ErrUnknownRelationship, got %v", err)
+		}
+	})
+}
+
+func TestDataAs(t *testing.T) {
+	product := Product{ID: "P1", Category: "widgets"}
+	relationships, err := MarshalRelationships(&product)
+	if err != nil {
+		t.Fatalf("Failed to marshal relationships: %v", err)
+	}
+	item, err := attributevalue.MarshalMap(relationships[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal item: %v", err)
+	}
+	decoded, err := UnmarshalRelationship(item)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal relationship: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rel  Relationship
+	}{
+		{name: "value", rel: Relationship{Data: product}},
+		{name: "pointer", rel: relationships[0]},
+		{name: "map", rel: decoded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DataAs[Product](tt.rel)
+			if err != nil {
+				t.Fatalf("Failed to get data: %v", err)
+			}
+			if got != product {
+				t.Errorf("Expected %+v, got %+v", product, got)
+			}
+
+			ptr, err := DataAs[*Product](tt.rel)
+			if err != nil {
+				t.Fatalf("Failed to get data pointer: %v", err)
+			}
+			if ptr == nil || *ptr != product {
+				t.Errorf("Expected %+v, got %+v", product, ptr)
+			}
+		})
+	}
+
+	t.Run("mismatched data", func(t *testing.T) {
+		if _, err := DataAs[Product](Relationship{Data: "junk"}); err == nil {
+			t.Error("Expected error")
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package dynamap
+
+// refIndexHashKey returns the name of the partition key of the ref index: the
+// [MarshalOptions.RefIndexHashAttribute] if set, otherwise the label attribute.
+func (mo MarshalOptions) refIndexHashKey() string {
+	if mo.RefIndexHashAttribute != "" {
+		return mo.RefIndexHashAttribute
+	}
+	return AttributeNameLabel
+}
+
+// refIndexHashItem copies the label of item to the ref index hash attribute in place, if
+// the options declare one.
+func (mo MarshalOptions) refIndexHashItem(item Item) {
+	if mo.RefIndexHashAttribute == "" {
+		return
+	}
+	if label, ok := item[AttributeNameLabel]; ok {
+		item[mo.RefIndexHashAttribute] = label
+	}
+}
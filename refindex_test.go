@@ -0,0 +1,84 @@
+package dynamap
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for the ref index hash attribute
+
+func TestRefIndexHashAttribute(t *testing.T) {
+	ctx := context.Background()
+	newTable := func() *Table {
+		table := NewTable("test-table")
+		table.RefIndexHashAttribute = "gsi1_pk"
+		return table
+	}
+
+	t.Run("items copy the label", func(t *testing.T) {
+		table := newTable()
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		item := client.items["product#P1#product#P1"]
+		if StringValue(item["gsi1_pk"]) != "product" || StringValue(item[AttributeNameLabel]) != "product" {
+			t.Errorf("Expected the label in gsi1_pk and label, got %v", item)
+		}
+
+		var product Product
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &product); err != nil || product.ID != "P1" {
+			t.Errorf("Expected the product, got %+v, %v", product, err)
+		}
+	})
+
+	t.Run("list queries key on the attribute", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			table *Table
+			want  string
+		}{
+			{"set", newTable(), "gsi1_pk"},
+			{"unset", NewTable("test-table"), AttributeNameLabel},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for _, query := range []*QueryList{
+					{Label: "product"},
+					{Label: "product", ConditionFilter: expression.Name("category").Equal(expression.Value("books"))},
+				} {
+					input, err := tt.table.MarshalQuery(query)
+					if err != nil {
+						t.Fatalf("Failed to marshal query: %v", err)
+					}
+					if !slices.Contains(slices.Collect(maps.Values(input.ExpressionAttributeNames)), tt.want) {
+						t.Errorf("Expected key condition on %s, got %v", tt.want, input.ExpressionAttributeNames)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("create table input", func(t *testing.T) {
+		input := newTable().CreateTableInput()
+		index := input.GlobalSecondaryIndexes[0]
+		if aws.ToString(index.KeySchema[0].AttributeName) != "gsi1_pk" {
+			t.Errorf("Expected the index keyed by gsi1_pk, got %v", index.KeySchema)
+		}
+		var defined bool
+		for _, definition := range input.AttributeDefinitions {
+			defined = defined || aws.ToString(definition.AttributeName) == "gsi1_pk" && definition.AttributeType == types.ScalarAttributeTypeS
+		}
+		if !defined {
+			t.Errorf("Expected gsi1_pk to be defined, got %v", input.AttributeDefinitions)
+		}
+	})
+}
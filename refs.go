@@ -0,0 +1,113 @@
+package dynamap
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// QueryRefs is a QueryMarshaler that searches the edges named Name of every entity with
+// the key prefix Prefix, such as the products of all orders. It reads the secondary index
+// named by [Table.RefNameIndex], whose partition key holds the ref name of each edge:
+// its source prefix and name joined by the label delimiter, such as "order/products".
+// Unmarshal the results with [UnmarshalList] into [Ref] values.
+//
+// Example:
+//
+//	table.Indexes = []dynamap.SecondaryIndex{{Name: "refs", PartitionKey: "gsi2_pk", SortKey: "gsi1_sk"}}
+//	table.RefNameIndex = "refs"
+//
+//	// Every product of every order
+//	input, err := table.MarshalQuery(&dynamap.QueryRefs{Prefix: "order", Name: "products"})
+type QueryRefs struct {
+	Prefix          string                         // The source entity prefix, usually the entity type
+	Name            string                         // The relationship name
+	RefSortFilter   expression.KeyConditionBuilder // Optional filters on the index sort key attribute
+	ConditionFilter expression.ConditionBuilder    // Optional filters on the edges
+	Limit           int                            // Maximum number of items to return
+	StartKey        Item                           // Exclusive start key for pagination
+	SortDescending  bool                           // If true, scans backward
+}
+
+// MarshalQuery implements QueryMarshaler for QueryRefs.
+func (q *QueryRefs) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	if opts.RefNameIndex == "" {
+		return nil, fmt.Errorf("%w: ref name index is not set", ErrUnknownIndex)
+	}
+	index, err := opts.index(opts.RefNameIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	keyCondition := expression.Key(index.PartitionKey).Equal(expression.Value(opts.refName(q.Prefix, q.Name)))
+	if q.RefSortFilter.IsSet() {
+		keyCondition = keyCondition.And(q.RefSortFilter)
+	}
+
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+	if q.ConditionFilter.IsSet() {
+		builder = builder.WithFilter(q.ConditionFilter)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ScanIndexForward:          aws.Bool(!q.SortDescending),
+		ExclusiveStartKey:         q.StartKey,
+	}
+
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(int32(q.Limit))
+	}
+
+	return input, nil
+}
+
+// UseIndex implements QueryMarshaler for QueryRefs.
+func (q *QueryRefs) UseIndex(t *Table) string {
+	return t.RefNameIndex
+}
+
+// refName returns the ref name of the edges named name of entities with the key prefix
+// prefix, scoped to the tenant if one is set.
+func (mo MarshalOptions) refName(prefix, name string) string {
+	return mo.tenantLabel(prefix + mo.LabelDelimiter + name)
+}
+
+// refNameItem adds the key of item on the [MarshalOptions.RefNameIndex] in place, if the
+// options declare one and item is an edge. The index sort key, if any, is a copy of the
+// ref sort key. Self relationships and items whose label cannot be decoded are skipped.
+func (mo MarshalOptions) refNameItem(item Item) error {
+	if mo.RefNameIndex == "" {
+		return nil
+	}
+	index, err := mo.index(mo.RefNameIndex)
+	if err != nil {
+		return err
+	}
+
+	label, ok := item[AttributeNameLabel].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil
+	}
+	prefix, id, name, err := mo.labelCodec().DecodeLabel(label.Value)
+	if err != nil || id == "" || name == "" {
+		return nil
+	}
+
+	item[index.PartitionKey] = &types.AttributeValueMemberS{Value: mo.refName(prefix, name)}
+	if sortKey, ok := item[AttributeNameRefSortKey]; ok && index.SortKey != "" {
+		item[index.SortKey] = sortKey
+	}
+	return nil
+}
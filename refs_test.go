@@ -0,0 +1,177 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// refNameClient answers ref name index queries on gsi2_pk in gsi1_sk order, honoring the
+// scan direction, limit and exclusive start key.
+type refNameClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *refNameClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	refName := params.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value
+	sortKey := func(item Item) string {
+		return item["gsi1_sk"].(*types.AttributeValueMemberS).Value
+	}
+
+	var items []Item
+	for _, item := range c.items {
+		if pk, _ := item["gsi2_pk"].(*types.AttributeValueMemberS); pk != nil && pk.Value == refName {
+			items = append(items, item)
+		}
+	}
+
+	forward := aws.ToBool(params.ScanIndexForward)
+	slices.SortFunc(items, func(a, b Item) int {
+		if forward {
+			return strings.Compare(sortKey(a), sortKey(b))
+		}
+		return strings.Compare(sortKey(b), sortKey(a))
+	})
+
+	if start := params.ExclusiveStartKey; start != nil {
+		i := slices.IndexFunc(items, func(item Item) bool { return sortKey(item) == sortKey(start) })
+		items = items[i+1:]
+	}
+
+	output := &dynamodb.QueryOutput{Items: items}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		output.Items = items[:limit]
+		output.LastEvaluatedKey = items[limit-1]
+	}
+	return output, nil
+}
+
+// Tests for querying refs across entities
+
+func TestQueryRefs(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.Indexes = []SecondaryIndex{{Name: "refs", PartitionKey: "gsi2_pk", SortKey: "gsi1_sk"}}
+	table.RefNameIndex = "refs"
+	client := &refNameClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, order := range []*Order{
+		{ID: "O1", Created: created, Products: []Product{{ID: "P1", Category: "books"}, {ID: "P2", Category: "games"}}},
+		{ID: "O2", Created: created, Products: []Product{{ID: "P3", Category: "art"}}},
+		{ID: "O3", Created: created},
+	} {
+		batches, err := table.MarshalBatch(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		if err := batchWriteAll(ctx, client, batches); err != nil {
+			t.Fatalf("Failed to write order: %v", err)
+		}
+	}
+
+	t.Run("edge items", func(t *testing.T) {
+		for _, item := range client.items {
+			source, target, _ := UnmarshalTableKey(item)
+			pk, ok := item["gsi2_pk"].(*types.AttributeValueMemberS)
+			if source == target {
+				if ok {
+					t.Errorf("Expected no ref name on self item %s, got %s", source, pk.Value)
+				}
+				continue
+			}
+			if !ok || pk.Value != "order/products" {
+				t.Errorf("Expected ref name 'order/products' on edge %s, got %v", target, item["gsi2_pk"])
+			}
+		}
+	})
+
+	t.Run("marshal query", func(t *testing.T) {
+		startKey := Item{"hk": &types.AttributeValueMemberS{Value: "order#O1"}}
+		input, err := table.MarshalQuery(&QueryRefs{Prefix: "order", Name: "products", Limit: 5, StartKey: startKey, SortDescending: true})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		if aws.ToString(input.IndexName) != "refs" {
+			t.Errorf("Expected index 'refs', got %s", aws.ToString(input.IndexName))
+		}
+		if name := input.ExpressionAttributeNames["#0"]; name != "gsi2_pk" {
+			t.Errorf("Expected key condition on gsi2_pk, got %s", name)
+		}
+		if v := input.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value; v != "order/products" {
+			t.Errorf("Expected ref name 'order/products', got %s", v)
+		}
+		if aws.ToInt32(input.Limit) != 5 {
+			t.Errorf("Expected limit 5, got %d", aws.ToInt32(input.Limit))
+		}
+		if input.ExclusiveStartKey == nil {
+			t.Error("Expected exclusive start key")
+		}
+		if aws.ToBool(input.ScanIndexForward) {
+			t.Error("Expected descending scan")
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		var (
+			refs     []Ref
+			startKey Item
+		)
+		for {
+			input, err := table.MarshalQuery(&QueryRefs{Prefix: "order", Name: "products", Limit: 2, StartKey: startKey})
+			if err != nil {
+				t.Fatalf("Failed to marshal query: %v", err)
+			}
+			output, err := client.Query(ctx, input)
+			if err != nil {
+				t.Fatalf("Failed to query refs: %v", err)
+			}
+
+			var page []Ref
+			if _, err := UnmarshalList(output.Items, &page); err != nil {
+				t.Fatalf("Failed to unmarshal refs: %v", err)
+			}
+			refs = append(refs, page...)
+
+			if startKey = output.LastEvaluatedKey; startKey == nil {
+				break
+			}
+		}
+
+		if len(refs) != 3 {
+			t.Fatalf("Expected 3 edges, got %d", len(refs))
+		}
+		// In ref sort key order: art, books, games
+		if refs[0].TargetID != "P3" || refs[1].TargetID != "P1" || refs[2].TargetID != "P2" {
+			t.Errorf("Expected edges in ref sort key order, got %+v", refs)
+		}
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryRefs{Prefix: "order", Name: "products"}, func(mo *MarshalOptions) {
+			mo.TenantID = "acme"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		want := NewMarshalOptions(func(mo *MarshalOptions) { mo.TenantID = "acme" }).tenantLabel("order/products")
+		if v := input.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value; v != want {
+			t.Errorf("Expected ref name %s, got %s", want, v)
+		}
+	})
+
+	t.Run("index not set", func(t *testing.T) {
+		_, err := NewTable("test-table").MarshalQuery(&QueryRefs{Prefix: "order", Name: "products"})
+		if !errors.Is(err, ErrUnknownIndex) {
+			t.Errorf("Expected ErrUnknownIndex, got %v", err)
+		}
+	})
+}
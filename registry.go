@@ -0,0 +1,133 @@
+package dynamap
+
+import (
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrUnknownPrefix is returned when a key references an entity prefix that is
+	// not registered.
+	ErrUnknownPrefix = newError(ErrValidation, "unknown entity prefix")
+	// ErrUnknownRelationship is returned when a relationship name is not registered for
+	// the source entity.
+	ErrUnknownRelationship = newError(ErrValidation, "unknown relationship")
+)
+
+// EntitySchema describes the relationships an entity is allowed to have.
+type EntitySchema struct {
+	Prefix        string   // The entity key prefix, usually the entity type
+	Labels        []string // Allowed self relationship labels. Defaults to the prefix.
+	Relationships []string // Allowed relationship names
+}
+
+// Registry holds the schemas of known entities. When set on a [Table] or passed via
+// [MarshalOptions], [MarshalRelationships] and [UnmarshalEntity] validate relationships
+// against the registry and return descriptive errors instead of writing or reading
+// malformed keys and labels.
+//
+// Entities should be registered before the registry is used; a Registry is not safe for
+// concurrent registration.
+//
+// Example:
+//
+//	registry := dynamap.NewRegistry(
+//		dynamap.EntitySchema{Prefix: "order", Relationships: []string{"products"}},
+//		dynamap.EntitySchema{Prefix: "product"},
+//	)
+//	table.Registry = registry
+type Registry struct {
+	schemas map[string]EntitySchema
+}
+
+// NewRegistry creates a new [Registry] with the provided schemas.
+func NewRegistry(schemas ...EntitySchema) *Registry {
+	r := &Registry{schemas: make(map[string]EntitySchema)}
+	for _, schema := range schemas {
+		r.Register(schema)
+	}
+	return r
+}
+
+// Register adds the schema to the registry, replacing any schema with the same prefix.
+func (r *Registry) Register(schema EntitySchema) {
+	if len(schema.Labels) == 0 {
+		schema.Labels = []string{schema.Prefix}
+	}
+	r.schemas[schema.Prefix] = schema
+}
+
+// Schema returns the schema registered for prefix.
+func (r *Registry) Schema(prefix string) (EntitySchema, bool) {
+	schema, ok := r.schemas[prefix]
+	return schema, ok
+}
+
+// Validate returns an error if the relationship does not conform to the registered schemas.
+func (r *Registry) Validate(rel Relationship, opts MarshalOptions) error {
+	sourcePrefix, sourceID, err := r.parseKey(rel.Source, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := r.parseKey(rel.Target, opts); err != nil {
+		return err
+	}
+
+	schema := r.schemas[sourcePrefix]
+
+	// Self relationships must use an allowed label
+	if rel.Source == rel.Target {
+		if !slices.ContainsFunc(schema.Labels, func(label string) bool {
+			return rel.Label == opts.selfLabel(label, rel.Source, rel.CreatedAt)
+		}) {
+			return fmt.Errorf("%w: %q is not a label of %s", ErrInvalidLabel, rel.Label, sourcePrefix)
+		}
+		return nil
+	}
+
+	// Other relationships must be labeled "<source_prefix>/<source_id>/<name>"
+	prefix, id, name, err := opts.splitLabel(rel)
+	if err != nil || name == "" {
+		return fmt.Errorf("%w: %q is malformed", ErrInvalidLabel, rel.Label)
+	}
+
+	if prefix != sourcePrefix || id != sourceID {
+		return fmt.Errorf("%w: %q does not match source %q", ErrInvalidLabel, rel.Label, rel.Source)
+	}
+
+	if !slices.Contains(schema.Relationships, name) {
+		return fmt.Errorf("%w: %q is not a relationship of %s", ErrUnknownRelationship, name, sourcePrefix)
+	}
+
+	return nil
+}
+
+// parseKey decodes key into a registered prefix and id.
+func (r *Registry) parseKey(key string, opts MarshalOptions) (prefix, id string, err error) {
+	prefix, id, err = opts.keyCodec().DecodeKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, ok := r.schemas[prefix]; !ok {
+		return "", "", fmt.Errorf("%w: %q in key %q", ErrUnknownPrefix, prefix, key)
+	}
+
+	return prefix, id, nil
+}
+
+// validate validates each of relationships against the registry, if one is set.
+func (mo MarshalOptions) validate(relationships ...Relationship) error {
+	if mo.Registry == nil {
+		return nil
+	}
+
+	for _, rel := range relationships {
+		if err := mo.Registry.Validate(rel, mo); err != nil {
+			return fmt.Errorf("failed to validate relationship %s %s: %w", rel.Source, rel.Target, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+// Tests for the entity schema registry
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(
+		EntitySchema{Prefix: "order", Relationships: []string{"products"}},
+		EntitySchema{Prefix: "product"},
+	)
+	withRegistry := func(opts *MarshalOptions) { opts.Registry = registry }
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}}}
+
+	t.Run("valid relationships", func(t *testing.T) {
+		if _, err := MarshalRelationships(order, withRegistry); err != nil {
+			t.Errorf("Expected valid relationships, got %v", err)
+		}
+	})
+
+	t.Run("schema defaults", func(t *testing.T) {
+		schema, ok := registry.Schema("product")
+		if !ok {
+			t.Fatal("Expected product schema")
+		}
+		if len(schema.Labels) != 1 || schema.Labels[0] != "product" {
+			t.Errorf("Expected default label 'product', got %v", schema.Labels)
+		}
+	})
+
+	t.Run("unknown prefix", func(t *testing.T) {
+		registry := NewRegistry(EntitySchema{Prefix: "order", Relationships: []string{"products"}})
+		_, err := MarshalRelationships(order, func(opts *MarshalOptions) { opts.Registry = registry })
+		if !errors.Is(err, ErrUnknownPrefix) {
+			t.Errorf("Expected ErrUnknownPrefix, got %v", err)
+		}
+	})
+
+	t.Run("unknown relationship", func(t *testing.T) {
+		registry := NewRegistry(EntitySchema{Prefix: "order"}, EntitySchema{Prefix: "product"})
+		_, err := MarshalRelationships(order, func(opts *MarshalOptions) { opts.Registry = registry })
+		if !errors.Is(err, ErrUnknownRelationship) {
+			t.Errorf("Expected ErrUnknownRelationship, got %v", err)
+		}
+	})
+
+	t.Run("invalid label", func(t *testing.T) {
+		tests := []Relationship{
+			{Source: "product#P1", Target: "product#P1", Label: "item"},
+			{Source: "order#O1", Target: "product#P1", Label: "products"},
+			{Source: "order#O1", Target: "product#P1", Label: "order/O2/products"},
+		}
+		for _, rel := range tests {
+			if err := registry.Validate(rel, NewMarshalOptions()); !errors.Is(err, ErrInvalidLabel) {
+				t.Errorf("Expected ErrInvalidLabel for %q, got %v", rel.Label, err)
+			}
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		rel := Relationship{Source: "order", Target: "order", Label: "order"}
+		if err := registry.Validate(rel, NewMarshalOptions()); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey, got %v", err)
+		}
+	})
+
+	t.Run("table attach", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.Registry = registry
+
+		_, err := table.MarshalAttach(order, "reviews", SliceOf(&Product{ID: "P2"}))
+		if !errors.Is(err, ErrUnknownRelationship) {
+			t.Errorf("Expected ErrUnknownRelationship, got %v", err)
+		}
+	})
+
+	t.Run("unmarshal entity", func(t *testing.T) {
+		relationships, err := MarshalRelationships(order)
+		if err != nil {
+			t.Fatalf("Failed to marshal relationships: %v", err)
+		}
+
+		var items []Item
+		for _, rel := range relationships {
+			item, err := attributevalue.MarshalMap(rel)
+			if err != nil {
+				t.Fatalf("Failed to marshal item: %v", err)
+			}
+			items = append(items, item)
+		}
+
+		var out Order
+		if _, err := UnmarshalEntity(items, &out, withRegistry); err != nil {
+			t.Errorf("Expected valid entity, got %v", err)
+		}
+
+		registry := NewRegistry(EntitySchema{Prefix: "order"})
+		_, err = UnmarshalEntity(items, &out, func(opts *MarshalOptions) { opts.Registry = registry })
+		if !errors.Is(err, ErrUnknownPrefix) {
+			t.Errorf("Expected ErrUnknownPrefix, got %v", err)
+		}
+	})
+}
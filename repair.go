@@ -0,0 +1,256 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RepairPrefix is the key prefix of the items recording the progress of repairs, whose
+// hash and sort keys are "repair#<name>.<segment>".
+const RepairPrefix = "repair"
+
+// Repair rewrites the items of a table written with one key and label encoding into
+// another, such as after changing [Table.LabelDelimiter], [Table.KeyCodec] or the prefix
+// naming of entities. From and To describe the same table before and after the change;
+// every item is read with the From options and translated into the keys, labels, ref
+// index attribute, timestamps, data and attribute aliases of the To options.
+//
+// Items are rewritten in place with conditional writes: an item keeping its key is put
+// only if its label and update time are unchanged since it was scanned, and an item
+// moving to a new key is put there, if no item exists yet, and deleted from its old key in
+// a single transaction. Items changed concurrently are counted as conflicts and left as
+// they are. Translations must be idempotent, since rewritten items may be scanned again.
+//
+// Progress is recorded in the table after every scanned page, in an item keyed by the
+// repair name and segment, so an interrupted repair resumes where it stopped when run
+// again. Run workers with different Segment values to repair in parallel.
+//
+// Example:
+//
+//	from := dynamap.NewTable("my-table")
+//	to := dynamap.NewTable("my-table")
+//	to.LabelDelimiter = "|"
+//
+//	repair := dynamap.NewRepair("label-delimiter", from, to, client)
+//	progress, err := repair.Run(ctx)
+type Repair struct {
+	Name          string            // Unique name of the repair, keying its progress
+	From          *Table            // The table configured as the items were written
+	To            *Table            // The table configured as the items are rewritten
+	Client        MaintenanceClient // The client scanning and writing the table
+	Segment       int               // The segment repaired by this worker, for parallel repairs
+	TotalSegments int               // The number of parallel workers. Zero scans the whole table.
+	PageSize      int32             // Maximum number of items read per scan request. Zero uses the DynamoDB default.
+	RateLimiter   *RateLimiter      // Optional limiter pacing the scans and writes
+}
+
+// NewRepair creates a new [Repair] named name, rewriting the items of from into to.
+func NewRepair(name string, from, to *Table, client MaintenanceClient) *Repair {
+	return &Repair{Name: name, From: from, To: to, Client: client}
+}
+
+// RepairProgress records the progress of a [Repair] segment.
+type RepairProgress struct {
+	Name      string            `dynamodbav:"name"`      // The repair name
+	Segment   int               `dynamodbav:"segment"`   // The repaired segment
+	StartKey  map[string]string `dynamodbav:"start_key"` // The key the scan resumes from; empty before the first page
+	Done      bool              `dynamodbav:"done"`      // True once the segment is fully scanned
+	Scanned   int               `dynamodbav:"scanned"`   // Number of items scanned
+	Repaired  int               `dynamodbav:"repaired"`  // Number of items rewritten
+	Skipped   int               `dynamodbav:"skipped"`   // Number of items already matching the new encoding
+	Conflicts int               `dynamodbav:"conflicts"` // Number of items changed concurrently and left as they are
+}
+
+// MarshalSelf implements [Marshaler].
+func (p *RepairProgress) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget(RepairPrefix, p.Name+"."+strconv.Itoa(p.Segment))
+	opts.Registry = nil // Progress is internal and not a registered entity
+	return nil
+}
+
+// Progress returns the recorded progress of the repair segment, which is empty if the
+// repair has not started.
+func (r *Repair) Progress(ctx context.Context) (*RepairProgress, error) {
+	progress := &RepairProgress{Name: r.Name, Segment: r.Segment}
+	if _, err := r.To.Get(ctx, r.client(), progress, progress); errors.Is(err, ErrItemNotFound) {
+		return progress, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get repair progress: %w", err)
+	}
+	return progress, nil
+}
+
+// Run repairs the items of the segment, resuming from the recorded progress, and returns
+// the progress once the segment is fully scanned. Completed repairs are not run again.
+func (r *Repair) Run(ctx context.Context) (*RepairProgress, error) {
+	progress, err := r.Progress(ctx)
+	if err != nil || progress.Done {
+		return progress, err
+	}
+
+	input := &dynamodb.ScanInput{TableName: aws.String(r.To.TableName)}
+	if r.TotalSegments > 0 {
+		input.Segment = aws.Int32(int32(r.Segment))
+		input.TotalSegments = aws.Int32(int32(r.TotalSegments))
+	}
+	if r.PageSize > 0 {
+		input.Limit = aws.Int32(r.PageSize)
+	}
+	if len(progress.StartKey) > 0 {
+		input.ExclusiveStartKey = make(Item, len(progress.StartKey))
+		for name, value := range progress.StartKey {
+			input.ExclusiveStartKey[name] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+
+	var (
+		from = NewMarshalOptions(r.From.MarshalOptions)
+		to   = NewMarshalOptions(r.To.MarshalOptions)
+	)
+
+	for {
+		output, err := r.client().Scan(ctx, input)
+		if err != nil {
+			return progress, fmt.Errorf("failed to scan table %s: %w", r.To.TableName, classify(err))
+		}
+
+		for _, item := range output.Items {
+			if isRepairProgress(from, to, item) {
+				continue
+			}
+			progress.Scanned++
+			if err := r.repair(ctx, from, to, item, progress); err != nil {
+				return progress, err
+			}
+		}
+
+		progress.StartKey = make(map[string]string, len(output.LastEvaluatedKey))
+		for name, value := range output.LastEvaluatedKey {
+			progress.StartKey[name] = StringValue(value)
+		}
+		progress.Done = len(output.LastEvaluatedKey) == 0
+		if err := r.To.Put(ctx, r.client(), progress); err != nil {
+			return progress, fmt.Errorf("failed to record repair progress: %w", err)
+		}
+
+		if progress.Done {
+			return progress, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// client returns the client of the repair, paced by its rate limiter if it has one.
+func (r *Repair) client() MaintenanceClient {
+	if r.RateLimiter == nil {
+		return r.Client
+	}
+	return r.RateLimiter.LimitClient(r.Client)
+}
+
+// repair rewrites item with the to options, if its encoding changes.
+func (r *Repair) repair(ctx context.Context, from, to MarshalOptions, item Item, progress *RepairProgress) error {
+	source, target := keyStrings(from.unaliasItem(item))
+
+	// Translating the item into its own options normalizes it like the translation, so
+	// the two are equal if the new options encode the item the same way
+	current, err := translateItem(from, from, item)
+	if err != nil {
+		return fmt.Errorf("failed to read item %s %s: %w", source, target, err)
+	}
+	translated, err := translateItem(from, to, item)
+	if err != nil {
+		return fmt.Errorf("failed to translate item %s %s: %w", source, target, err)
+	}
+	if reflect.DeepEqual(translated, current) {
+		progress.Skipped++
+		return nil
+	}
+
+	client := r.To.client(r.client())
+
+	unchanged, err := repairCondition(from, item)
+	if err != nil {
+		return err
+	}
+
+	newSource, newTarget := keyStrings(translated)
+	if source == newSource && target == newTarget {
+		_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 aws.String(r.To.TableName),
+			Item:                      translated,
+			ConditionExpression:       unchanged.Condition(),
+			ExpressionAttributeNames:  unchanged.Names(),
+			ExpressionAttributeValues: unchanged.Values(),
+		})
+	} else {
+		_, err = transactWriteItems(ctx, client, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{
+					TableName:                aws.String(r.To.TableName),
+					Item:                     translated,
+					ConditionExpression:      aws.String("attribute_not_exists(#hk)"),
+					ExpressionAttributeNames: map[string]string{"#hk": AttributeNameSource},
+				}},
+				{Delete: &types.Delete{
+					TableName:                 aws.String(r.To.TableName),
+					Key:                       tableKey(from.unaliasItem(item)),
+					ConditionExpression:       unchanged.Condition(),
+					ExpressionAttributeNames:  unchanged.Names(),
+					ExpressionAttributeValues: unchanged.Values(),
+				}},
+			},
+		})
+	}
+
+	if err := classify(err); errors.Is(err, ErrConditionFailed) {
+		progress.Conflicts++
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to repair item %s %s: %w", source, target, err)
+	}
+	progress.Repaired++
+	return nil
+}
+
+// repairCondition returns the condition that item exists with the label and update time
+// it was scanned with.
+func repairCondition(from MarshalOptions, item Item) (expression.Expression, error) {
+	condition := expression.AttributeExists(expression.Name(AttributeNameSource))
+	for _, name := range []string{AttributeNameLabel, AttributeNameUpdated} {
+		name = from.aliasName(name)
+		if value, ok := item[name]; ok {
+			condition = condition.And(expression.Name(name).Equal(expression.Value(value)))
+		}
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return expr, fmt.Errorf("failed to build expression: %w", err)
+	}
+	return expr, nil
+}
+
+// isRepairProgress returns true if item records the progress of a repair.
+func isRepairProgress(from, to MarshalOptions, item Item) bool {
+	source, _ := keyStrings(from.unaliasItem(item))
+	for _, mo := range []MarshalOptions{from, to} {
+		if prefix, _, err := mo.keyCodec().DecodeKey(source); err == nil && prefix == RepairPrefix {
+			return true
+		}
+	}
+	return false
+}
+
+// keyStrings returns the hash and sort keys of an item.
+func keyStrings(item Item) (source, target string) {
+	return StringValue(item[AttributeNameSource]), StringValue(item[AttributeNameTarget])
+}
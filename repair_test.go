@@ -0,0 +1,170 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// repairClient scans the stored items in key order, honoring the limit and exclusive start
+// key, and fails the scan after failAfter pages if set. Writes to the keys in conflicts
+// fail their condition.
+type repairClient struct {
+	*mockDynamoDBClient
+	scans     int
+	failAfter int
+	conflicts map[string]bool
+}
+
+func (c *repairClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.scans++
+	if c.failAfter > 0 && c.scans > c.failAfter {
+		return nil, errors.New("scan interrupted")
+	}
+
+	key := func(item Item) string { return StringValue(item["hk"]) + "#" + StringValue(item["sk"]) }
+	keys := slices.Sorted(maps.Keys(c.items))
+	if start := params.ExclusiveStartKey; start != nil {
+		keys = slices.DeleteFunc(keys, func(k string) bool { return k <= key(start) })
+	}
+
+	output := &dynamodb.ScanOutput{}
+	for _, k := range keys {
+		output.Items = append(output.Items, c.items[k])
+	}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(output.Items) > limit {
+		output.Items = output.Items[:limit]
+		output.LastEvaluatedKey = tableKey(output.Items[limit-1])
+	}
+	return output, nil
+}
+
+func (c *repairClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if c.conflicts[StringValue(params.Item["hk"])+"#"+StringValue(params.Item["sk"])] {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("changed")}
+	}
+	return c.mockDynamoDBClient.PutItem(ctx, params, optFns...)
+}
+
+// Tests for table repairs
+
+func TestRepair(t *testing.T) {
+	ctx := context.Background()
+	from := NewTable("test-table")
+
+	newClient := func(t *testing.T) *repairClient {
+		client := &repairClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		batches, err := from.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+		for _, batch := range batches {
+			if _, err := client.BatchWriteItem(ctx, batch); err != nil {
+				t.Fatalf("Failed to write batch: %v", err)
+			}
+		}
+		return client
+	}
+
+	t.Run("relabels items in place", func(t *testing.T) {
+		client := newClient(t)
+		to := NewTable("test-table")
+		to.LabelDelimiter = "|"
+
+		progress, err := NewRepair("labels", from, to, client).Run(ctx)
+		if err != nil {
+			t.Fatalf("Failed to run repair: %v", err)
+		}
+		if !progress.Done || progress.Scanned != 3 || progress.Repaired != 2 || progress.Skipped != 1 {
+			t.Errorf("Unexpected progress %+v", progress)
+		}
+		if got := StringValue(client.items["order#O1#product#P1"]["label"]); got != "order|O1|products" {
+			t.Errorf("Expected label 'order|O1|products', got %q", got)
+		}
+
+		// Completed repairs are not run again
+		scans := client.scans
+		if _, err := NewRepair("labels", from, to, client).Run(ctx); err != nil {
+			t.Fatalf("Failed to run repair: %v", err)
+		}
+		if client.scans != scans {
+			t.Errorf("Expected no scans, got %d", client.scans-scans)
+		}
+	})
+
+	t.Run("moves items to new keys", func(t *testing.T) {
+		client := newClient(t)
+		to := NewTable("test-table")
+		to.KeyDelimiter = ":"
+
+		progress, err := NewRepair("keys", from, to, client).Run(ctx)
+		if err != nil {
+			t.Fatalf("Failed to run repair: %v", err)
+		}
+		if progress.Repaired != 3 {
+			t.Errorf("Expected 3 repaired items, got %d", progress.Repaired)
+		}
+		for _, key := range []string{"order:O1#order:O1", "order:O1#product:P1", "order:O1#product:P2"} {
+			if _, ok := client.items[key]; !ok {
+				t.Errorf("Expected item %s", key)
+			}
+		}
+		if _, ok := client.items["order#O1#order#O1"]; ok {
+			t.Error("Expected old key to be deleted")
+		}
+	})
+
+	t.Run("resumes from recorded progress", func(t *testing.T) {
+		client := newClient(t)
+		client.failAfter = 2
+		to := NewTable("test-table")
+		to.LabelDelimiter = "|"
+
+		repair := NewRepair("resume", from, to, client)
+		repair.PageSize = 1
+		if _, err := repair.Run(ctx); err == nil {
+			t.Fatal("Expected interrupted repair")
+		}
+
+		recorded, err := repair.Progress(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get progress: %v", err)
+		}
+		if recorded.Done || recorded.Scanned != 2 {
+			t.Errorf("Expected 2 items scanned before the interruption, got %+v", recorded)
+		}
+
+		client.failAfter = 0
+		progress, err := repair.Run(ctx)
+		if err != nil {
+			t.Fatalf("Failed to resume repair: %v", err)
+		}
+		if !progress.Done || progress.Scanned != 3 || progress.Repaired != 2 {
+			t.Errorf("Unexpected progress %+v", progress)
+		}
+	})
+
+	t.Run("counts conflicts", func(t *testing.T) {
+		client := newClient(t)
+		client.conflicts = map[string]bool{"order#O1#product#P2": true}
+		to := NewTable("test-table")
+		to.LabelDelimiter = "|"
+
+		progress, err := NewRepair("conflicts", from, to, client).Run(ctx)
+		if err != nil {
+			t.Fatalf("Failed to run repair: %v", err)
+		}
+		if progress.Repaired != 1 || progress.Conflicts != 1 {
+			t.Errorf("Expected 1 repaired item and 1 conflict, got %+v", progress)
+		}
+		if got := StringValue(client.items["order#O1#product#P2"]["label"]); got != "order/O1/products" {
+			t.Errorf("Expected conflicting item to be left as is, got label %q", got)
+		}
+	})
+}
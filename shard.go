@@ -0,0 +1,84 @@
+package dynamap
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+)
+
+// ShardDelimiter separates a label from its shard number, such as "order.7".
+const ShardDelimiter = "."
+
+// ShardedLabel spreads the writes to the self relationships of a popular entity type across
+// a fixed number of labels on the ref index, such as "order.0" through "order.9", so that
+// no single label is throttled. Each entity is assigned a shard by hashing its source key,
+// so rewrites of the entity keep the same label. Configure it per entity label with
+// [Table.LabelShards] and query all shards with [Table.QueryShards].
+//
+// Example:
+//
+//	table.LabelShards = dynamap.LabelShards{
+//		"order": {Shards: 10},
+//	}
+type ShardedLabel struct {
+	Shards int // The number of shards. Labels with fewer than two shards are not sharded.
+}
+
+// Shard returns the shard number assigned to the entity with the source key.
+func (s ShardedLabel) Shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(s.Shards))
+}
+
+// LabelShards maps self relationship labels to their write sharding strategy.
+type LabelShards map[string]ShardedLabel
+
+// shardLabel appends the shard of the source key to label, if the self relationship
+// label base is sharded.
+func (mo MarshalOptions) shardLabel(base, label, source string) string {
+	shards, ok := mo.LabelShards[base]
+	if !ok || shards.Shards < 2 {
+		return label
+	}
+	return label + ShardDelimiter + strconv.Itoa(shards.Shard(source))
+}
+
+// shardLabels returns every shard of label, if the self relationship label base is
+// sharded, or label alone otherwise.
+func (mo MarshalOptions) shardLabels(base, label string) []string {
+	shards, ok := mo.LabelShards[base]
+	if !ok || shards.Shards < 2 {
+		return []string{label}
+	}
+
+	labels := make([]string, shards.Shards)
+	for i := range labels {
+		labels[i] = label + ShardDelimiter + strconv.Itoa(i)
+	}
+	return labels
+}
+
+// QueryShards executes the query against every shard of the sharded label q.Label
+// concurrently, and merges the results in ref sort key order, descending if
+// q.SortDescending is set. Each shard reads at most q.Limit items, and at most q.Limit
+// items are returned per page. If the label is not sharded, the query is executed as-is.
+// Use [Table.QueryBuckets] for labels that are also time bucketed. Unmarshal the results
+// with [UnmarshalList].
+//
+// The returned start key encodes the position of each shard like [Table.QueryUnion], and
+// is nil once every shard is exhausted. Pass it as q.StartKey to fetch the next page.
+//
+// Example:
+//
+//	items, startKey, err := table.QueryShards(ctx, client, &dynamap.QueryList{Label: "order", Limit: 20})
+func (t *Table) QueryShards(ctx context.Context, client DynamoDBClient, q *QueryList, opts ...func(*MarshalOptions)) ([]Item, Item, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	return t.queryMerged(ctx, client, q, marshalOpts.shardLabels(q.Label, q.Label), opts)
+}
@@ -0,0 +1,160 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for sharded labels
+
+func TestShardedLabel(t *testing.T) {
+	shards := ShardedLabel{Shards: 4}
+	if shards.Shard("order#O1") != shards.Shard("order#O1") {
+		t.Error("Expected the same key to map to the same shard")
+	}
+
+	seen := make(map[int]bool)
+	for i := range 100 {
+		shard := shards.Shard(fmt.Sprintf("order#O%d", i))
+		if shard < 0 || shard >= 4 {
+			t.Fatalf("Shard %d out of range", shard)
+		}
+		seen[shard] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected keys spread across 4 shards, got %d", len(seen))
+	}
+}
+
+func TestQueryShards(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.LabelShards = LabelShards{"shipment": {Shards: 3}}
+	client := &labelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 10 {
+		s := &shipment{ID: fmt.Sprintf("S%d", i), Created: created.Add(time.Duration(i) * time.Hour)}
+		if err := table.Put(ctx, client, s); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+	}
+
+	t.Run("sharded label", func(t *testing.T) {
+		for _, item := range client.items {
+			label := item["label"].(*types.AttributeValueMemberS).Value
+			if !slices.Contains([]string{"shipment.0", "shipment.1", "shipment.2"}, label) {
+				t.Errorf("Unexpected label %q", label)
+			}
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		client.queried = nil
+		items, _, err := table.QueryShards(ctx, client, &QueryList{Label: "shipment", SortDescending: true, Limit: 3})
+		if err != nil {
+			t.Fatalf("Failed to query shards: %v", err)
+		}
+
+		slices.Sort(client.queried)
+		if want := []string{"shipment.0", "shipment.1", "shipment.2"}; !slices.Equal(client.queried, want) {
+			t.Errorf("Expected queried shards %v, got %v", want, client.queried)
+		}
+
+		var out []shipment
+		if _, err := UnmarshalList(items, &out); err != nil {
+			t.Fatalf("Failed to unmarshal list: %v", err)
+		}
+		var ids []string
+		for _, s := range out {
+			ids = append(ids, s.ID)
+		}
+		if want := []string{"S9", "S8", "S7"}; !slices.Equal(ids, want) {
+			t.Errorf("Expected %v, got %v", want, ids)
+		}
+	})
+
+	t.Run("bucketed shards", func(t *testing.T) {
+		table.LabelBuckets = LabelBuckets{"shipment": {Period: BucketYearly}}
+		defer func() { table.LabelBuckets = nil }()
+
+		s := &shipment{ID: "S1", Created: created}
+		if err := table.Put(ctx, client, s); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+
+		label := client.items["shipment#S1#shipment#S1"]["label"].(*types.AttributeValueMemberS).Value
+		if !strings.HasPrefix(label, "shipment/2025.") {
+			t.Errorf("Expected bucketed shard label, got %q", label)
+		}
+
+		client.queried = nil
+		if _, _, err := table.QueryBuckets(ctx, client, &QueryList{Label: "shipment"}, created, created); err != nil {
+			t.Fatalf("Failed to query buckets: %v", err)
+		}
+		if len(client.queried) != 3 {
+			t.Errorf("Expected 3 shards of one bucket queried, got %v", client.queried)
+		}
+	})
+}
+
+func TestQueryShardsPages(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.LabelShards = LabelShards{"shipment": {Shards: 3}}
+	client := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 10 {
+		s := &shipment{ID: fmt.Sprintf("S%d", i), Created: created.Add(time.Duration(i) * time.Hour)}
+		if err := table.Put(ctx, client, s); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+	}
+
+	var (
+		ids   []string
+		query = &QueryList{Label: "shipment", Limit: 3}
+	)
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("Expected the shards to be exhausted")
+		}
+
+		client.read = 0
+		items, startKey, err := table.QueryShards(ctx, client, query)
+		if err != nil {
+			t.Fatalf("Failed to query shards: %v", err)
+		}
+		if len(items) > 3 || client.read > 3*3 {
+			t.Errorf("Expected at most 3 items and 9 reads per page, got %d items and %d reads", len(items), client.read)
+		}
+
+		var out []shipment
+		if _, err := UnmarshalList(items, &out); err != nil {
+			t.Fatalf("Failed to unmarshal list: %v", err)
+		}
+		for _, s := range out {
+			ids = append(ids, s.ID)
+		}
+
+		if startKey == nil {
+			break
+		}
+		query.StartKey = startKey
+	}
+
+	var want []string
+	for i := range 10 {
+		want = append(want, fmt.Sprintf("S%d", i))
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+}
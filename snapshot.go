@@ -0,0 +1,116 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Snapshotter is a Marshaler that embeds a snapshot of its data into the relationships
+// that reference it, so sources can read target details without loading the targets.
+// The snapshot is stored in [Ref.Snapshot].
+//
+// Example:
+//
+//	func (p *Product) Snapshot() any {
+//		return map[string]any{"name": p.Name, "price": p.Price}
+//	}
+type Snapshotter interface {
+	Marshaler
+	// Snapshot returns the target data to denormalize into the relationship.
+	Snapshot() any
+}
+
+// RefreshRefs rewrites the snapshots of the relationships named name in the source
+// partition whose targets were updated after the snapshot was taken. Each target is
+// loaded, unmarshaled into a value created by newTarget, and its [Snapshotter.Snapshot]
+// is written to the relationship. The number of refreshed relationships is returned.
+//
+// Example:
+//
+//	n, err := table.RefreshRefs(ctx, client, order, "products", func() dynamap.Snapshotter {
+//		return &Product{}
+//	})
+func (t *Table) RefreshRefs(ctx context.Context, client DynamoDBClient, source Marshaler, name string, newTarget func() Snapshotter, opts ...func(*MarshalOptions)) (int, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+		mo.SkipRefs = true
+	})
+	if err := source.MarshalSelf(&marshalOpts); err != nil {
+		return 0, fmt.Errorf("failed to marshal self: %w", err)
+	}
+
+	items, err := t.queryPartition(ctx, client, marshalOpts.sourceKey())
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		refs    []Relationship
+		targets []string
+	)
+
+	for _, item := range items {
+		source, target, err := UnmarshalTableKey(item)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal table key: %w", err)
+		}
+		if source == target {
+			continue
+		}
+
+		var ref Ref
+		rel, err := UnmarshalSelf(item, &ref, t.MarshalOptions)
+		if err != nil || ref.Name != name {
+			continue
+		}
+		rel.Data = ref
+
+		refs = append(refs, rel)
+		targets = append(targets, target)
+	}
+
+	selves, err := t.getSelfItems(ctx, client, targets)
+	if err != nil {
+		return 0, err
+	}
+
+	var requests []types.WriteRequest
+	for _, rel := range refs {
+		self, ok := selves[rel.Target]
+		if !ok {
+			continue
+		}
+
+		target := newTarget()
+		targetRel, err := UnmarshalSelf(self, target, t.MarshalOptions)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal target %s: %w", rel.Target, err)
+		}
+
+		ref := rel.Data.(Ref)
+		if ref.Snapshot != nil && !targetRel.UpdatedAt.After(rel.UpdatedAt) {
+			continue
+		}
+
+		ref.Snapshot = target.Snapshot()
+		rel.Data = ref
+		rel.UpdatedAt = marshalOpts.Tick().UTC()
+
+		item, err := marshalOpts.marshalItem(rel)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal relationship: %w", err)
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+
+	if err := batchWriteAll(ctx, client, t.chunkBatchWrite(requests)); err != nil {
+		return 0, err
+	}
+
+	return len(requests), nil
+}
@@ -0,0 +1,117 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type catalogItem struct {
+	ID      string    `dynamodbav:"id"`
+	Name    string    `dynamodbav:"name"`
+	Updated time.Time `dynamodbav:"-"`
+}
+
+func (c *catalogItem) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("item", c.ID)
+	opts.Updated = c.Updated
+	return nil
+}
+
+func (c *catalogItem) Snapshot() any {
+	return map[string]any{"name": c.Name}
+}
+
+type cart struct {
+	ID    string        `dynamodbav:"id"`
+	Items []catalogItem `dynamodbav:"-"`
+	names map[string]string
+}
+
+func (c *cart) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("cart", c.ID)
+	return nil
+}
+
+func (c *cart) MarshalRefs(ctx *RelationshipContext) error {
+	for i := range c.Items {
+		ctx.AddOne("items", &c.Items[i])
+	}
+	return nil
+}
+
+func (c *cart) UnmarshalRef(name string, id string, ref *Relationship) error {
+	if c.names == nil {
+		c.names = make(map[string]string)
+	}
+	data := ref.Data.(map[string]any)
+	if snapshot, ok := data["Snapshot"].(map[string]any); ok {
+		c.names[ref.Target] = snapshot["name"].(string)
+	}
+	return nil
+}
+
+// Tests for ref snapshots
+
+func TestRefSnapshots(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newPartitionClient()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	item := catalogItem{ID: "I1", Name: "Widget", Updated: created}
+	batches, err := table.MarshalBatch(&cart{ID: "C1", Items: []catalogItem{item}})
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+	if err := batchWriteAll(ctx, client, batches); err != nil {
+		t.Fatalf("Failed to write cart: %v", err)
+	}
+	if err := table.Put(ctx, client, &item); err != nil {
+		t.Fatalf("Failed to put item: %v", err)
+	}
+
+	load := func(t *testing.T) *cart {
+		out := &cart{}
+		if _, err := table.LoadEntity(ctx, client, &QueryEntity{Source: &cart{ID: "C1"}}, out); err != nil {
+			t.Fatalf("Failed to load cart: %v", err)
+		}
+		return out
+	}
+
+	newTarget := func() Snapshotter { return &catalogItem{} }
+
+	t.Run("embedded", func(t *testing.T) {
+		if name := load(t).names["item#I1"]; name != "Widget" {
+			t.Errorf("Expected snapshot name 'Widget', got %q", name)
+		}
+	})
+
+	t.Run("fresh", func(t *testing.T) {
+		n, err := table.RefreshRefs(ctx, client, &cart{ID: "C1"}, "items", newTarget)
+		if err != nil {
+			t.Fatalf("Failed to refresh refs: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("Expected no refs refreshed, got %d", n)
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		renamed := &catalogItem{ID: "I1", Name: "Gadget", Updated: created.Add(time.Hour)}
+		if err := table.Put(ctx, client, renamed); err != nil {
+			t.Fatalf("Failed to put item: %v", err)
+		}
+
+		n, err := table.RefreshRefs(ctx, client, &cart{ID: "C1"}, "items", newTarget)
+		if err != nil {
+			t.Fatalf("Failed to refresh refs: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("Expected 1 ref refreshed, got %d", n)
+		}
+		if name := load(t).names["item#I1"]; name != "Gadget" {
+			t.Errorf("Expected snapshot name 'Gadget', got %q", name)
+		}
+	})
+}
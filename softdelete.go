@@ -0,0 +1,102 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// MarshalSoftDelete marshals the input into an update item request that marks the entity's
+// self relationship as deleted instead of removing it. The request sets the deleted_at
+// attribute and, if [Table.SoftDeleteTTL] is set, an expiration so the item is eventually
+// removed by DynamoDB. Relationships to other entities are left untouched.
+//
+// The request is conditioned on the item existing. Use [ExcludeDeleted] to filter soft
+// deleted relationships from queries.
+func (t *Table) MarshalSoftDelete(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemInput, error) {
+	marshalOpts := NewMarshalOptions(t.MarshalOptions, func(mo *MarshalOptions) { mo.apply(opts) })
+	now := marshalOpts.Tick().UTC()
+
+	updater := UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		base = base.Set(expression.Name(AttributeNameDeleted), expression.Value(marshalOpts.timestampValue(now, time.RFC3339)))
+		if t.SoftDeleteTTL > 0 {
+			base = base.Set(expression.Name(AttributeNameExpires), expression.Value(now.Add(t.SoftDeleteTTL).Unix()))
+		}
+		return base
+	})
+
+	opts = append(opts[:len(opts):len(opts)], fixedClock(now))
+	return t.MarshalUpdate(in, UpdateWithCondition(updater, itemExists()), opts...)
+}
+
+// MarshalRestore marshals the input into an update item request that clears the soft
+// deletion markers set by [Table.MarshalSoftDelete]. Note that the expiration is removed
+// as well, including any time-to-live the entity had before it was deleted.
+func (t *Table) MarshalRestore(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemInput, error) {
+	updater := UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.
+			Remove(expression.Name(AttributeNameDeleted)).
+			Remove(expression.Name(AttributeNameExpires))
+	})
+
+	return t.MarshalUpdate(in, UpdateWithCondition(updater, itemExists()), opts...)
+}
+
+// SoftDelete marshals the input using [Table.MarshalSoftDelete] and executes the request.
+// If the entity does not exist, a [*ConditionFailedError] is returned.
+func (t *Table) SoftDelete(ctx context.Context, client DynamoDBClient, in Marshaler, opts ...func(*MarshalOptions)) error {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalSoftDelete(in, opts...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.UpdateItem(ctx, input); err != nil {
+		return conditionFailed(input.Key, fmt.Errorf("failed to soft delete item: %w", classify(err)))
+	}
+
+	return nil
+}
+
+// Restore marshals the input using [Table.MarshalRestore] and executes the request.
+// If the entity does not exist, a [*ConditionFailedError] is returned.
+func (t *Table) Restore(ctx context.Context, client DynamoDBClient, in Marshaler, opts ...func(*MarshalOptions)) error {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalRestore(in, opts...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.UpdateItem(ctx, input); err != nil {
+		return conditionFailed(input.Key, fmt.Errorf("failed to restore item: %w", classify(err)))
+	}
+
+	return nil
+}
+
+// ExcludeDeleted creates a condition that filters out soft deleted relationships.
+func ExcludeDeleted() expression.ConditionBuilder {
+	return expression.Name(AttributeNameDeleted).AttributeNotExists()
+}
+
+// OnlyDeleted creates a condition that filters for soft deleted relationships.
+func OnlyDeleted() expression.ConditionBuilder {
+	return expression.Name(AttributeNameDeleted).AttributeExists()
+}
+
+// itemExists creates a condition that requires the item to exist.
+func itemExists() expression.ConditionBuilder {
+	return expression.AttributeExists(expression.Name(AttributeNameSource))
+}
+
+// fixedClock creates an option that stops the marshal clock at now.
+func fixedClock(now time.Time) func(*MarshalOptions) {
+	return func(mo *MarshalOptions) {
+		mo.Tick = func() time.Time { return now }
+	}
+}
@@ -0,0 +1,130 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for soft delete operations
+
+func TestTableMarshalSoftDelete(t *testing.T) {
+	table := NewTable("test-table")
+	product := &Product{ID: "P1"}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	withClock := func(opts *MarshalOptions) { opts.Tick = func() time.Time { return now } }
+
+	t.Run("sets deletion markers", func(t *testing.T) {
+		input, err := table.MarshalSoftDelete(product, withClock)
+		if err != nil {
+			t.Fatalf("Failed to marshal soft delete: %v", err)
+		}
+
+		names := make(map[string]bool)
+		for _, name := range input.ExpressionAttributeNames {
+			names[name] = true
+		}
+		for _, name := range []string{AttributeNameDeleted, AttributeNameExpires, AttributeNameUpdated} {
+			if !names[name] {
+				t.Errorf("Expected %s in expression names, got %v", name, input.ExpressionAttributeNames)
+			}
+		}
+
+		expires := strconv.FormatInt(now.Add(table.SoftDeleteTTL).Unix(), 10)
+		if n, ok := findValue[*types.AttributeValueMemberN](input.ExpressionAttributeValues); !ok || n.Value != expires {
+			t.Errorf("Expected expires %s, got %#v", expires, n)
+		}
+
+		if !strings.Contains(aws.ToString(input.ConditionExpression), "attribute_exists") {
+			t.Errorf("Expected existence condition, got %q", aws.ToString(input.ConditionExpression))
+		}
+	})
+
+	t.Run("without ttl", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.SoftDeleteTTL = 0
+
+		input, err := table.MarshalSoftDelete(product, withClock)
+		if err != nil {
+			t.Fatalf("Failed to marshal soft delete: %v", err)
+		}
+		for _, name := range input.ExpressionAttributeNames {
+			if name == AttributeNameExpires {
+				t.Error("Expected no expires attribute without TTL")
+			}
+		}
+	})
+
+	t.Run("restore clears markers", func(t *testing.T) {
+		input, err := table.MarshalRestore(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal restore: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.UpdateExpression), "REMOVE ") {
+			t.Errorf("Expected REMOVE action, got %s", aws.ToString(input.UpdateExpression))
+		}
+	})
+
+	t.Run("exclude deleted filter", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryList{Label: "product", ConditionFilter: ExcludeDeleted()})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.FilterExpression), "attribute_not_exists") {
+			t.Errorf("Expected attribute_not_exists filter, got %q", aws.ToString(input.FilterExpression))
+		}
+	})
+
+	t.Run("unmarshal deleted relationship", func(t *testing.T) {
+		input, err := table.MarshalPut(product)
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+
+		var out Product
+		rel, err := UnmarshalSelf(input.Item, &out)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if rel.Deleted() {
+			t.Error("Expected relationship not to be deleted")
+		}
+
+		input.Item[AttributeNameDeleted] = &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)}
+		if rel, _ = UnmarshalSelf(input.Item, &out); !rel.Deleted() {
+			t.Error("Expected relationship to be deleted")
+		}
+	})
+}
+
+func TestTableSoftDelete(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	product := &Product{ID: "P1"}
+
+	t.Run("executes requests", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		if err := table.SoftDelete(ctx, client, product); err != nil {
+			t.Fatalf("Failed to soft delete: %v", err)
+		}
+		if err := table.Restore(ctx, client, product); err != nil {
+			t.Fatalf("Failed to restore: %v", err)
+		}
+	})
+
+	t.Run("missing entity", func(t *testing.T) {
+		client := &conditionFailedClient{mockDynamoDBClient: newMockDynamoDBClient()}
+		if err := table.SoftDelete(ctx, client, product); !errors.Is(err, ErrConditionFailed) {
+			t.Errorf("Expected ErrConditionFailed, got %v", err)
+		}
+		if err := table.Restore(ctx, client, product); !errors.Is(err, ErrConditionFailed) {
+			t.Errorf("Expected ErrConditionFailed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package dynamap
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DefaultSortMaxItems is the default number of items [QuerySorted] reads before failing.
+const DefaultSortMaxItems = 10000
+
+// ErrSortLimitExceeded is returned by [QuerySorted] when a query matches more items than
+// [SortOptions.MaxItems].
+var ErrSortLimitExceeded = newError(ErrValidation, "too many items to sort client-side")
+
+// SortOptions configures [QuerySorted].
+type SortOptions[T any] struct {
+	Filter         func(T) bool            // Optional predicate; entities for which it returns false are skipped
+	Limit          int                     // Maximum number of entities per page. Zero returns every entity.
+	Descending     bool                    // If true, entities are returned in reverse order of the comparison
+	Cursor         string                  // Cursor of the page, returned with the previous page
+	MaxItems       int                     // Maximum number of items read. Default is DefaultSortMaxItems; negative disables the limit.
+	Paginator      Paginator               // Optional paginator of the cursors. Default is the table paginator.
+	MarshalOptions []func(*MarshalOptions) // Options used to marshal the query and unmarshal the items
+}
+
+func newSortOptions[T any](opts []func(*SortOptions[T])) SortOptions[T] {
+	options := SortOptions[T]{MaxItems: DefaultSortMaxItems}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// CompareBy returns a comparison of entities by the value of key, for [QuerySorted] and
+// [TopK].
+//
+// Example:
+//
+//	byPrice := dynamap.CompareBy(func(p Product) float64 { return p.Price })
+func CompareBy[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp.Compare(key(a), key(b))
+	}
+}
+
+// QuerySorted sorts and filters the results of a query client-side, for orders the
+// indexes cannot provide, such as sorting a list by a data field. It returns a page of
+// the entities accepted by [SortOptions.Filter] in the order of compare, and the cursor of
+// the next page, or an empty cursor after the last page.
+//
+// Sorting is done in memory: every page of the query is read on each call, so each page of
+// sorted results consumes the read capacity of the whole query, and queries matching more
+// than [SortOptions.MaxItems] items fail with [ErrSortLimitExceeded]. With a limit, only
+// the entities of the requested page are kept in memory. Narrow the query with key conditions and filters
+// so it reads as few items as possible, and prefer a ref sort key or secondary index for
+// orders that are requested often.
+//
+// Entities that compare equal are ordered by their table key, so pages are stable: the
+// cursor resumes after the last entity of the previous page, even if entities were added
+// or removed in between. The cursor expires if that entity is deleted.
+//
+// Example:
+//
+//	products, cursor, err := dynamap.QuerySorted(ctx, table, client, &dynamap.QueryList{Label: "product"},
+//		dynamap.CompareBy(func(p Product) float64 { return p.Price }),
+//		func(so *dynamap.SortOptions[Product]) {
+//			so.Descending = true
+//			so.Limit = 10
+//			so.Filter = func(p Product) bool { return p.InStock }
+//		},
+//	)
+func QuerySorted[T any](ctx context.Context, t *Table, client DynamoDBClient, q QueryMarshaler, compare func(a, b T) int, opts ...func(*SortOptions[T])) ([]T, string, error) {
+	options := newSortOptions(opts)
+	marshalOpts := contextOptions(ctx, options.MarshalOptions)
+	unmarshalOpts := append([]func(*MarshalOptions){t.MarshalOptions}, marshalOpts...)
+	client = t.client(client)
+	if options.Paginator == nil {
+		options.Paginator = t.Paginator(client)
+	}
+
+	order := func(a, b sortEntry[T]) int {
+		c := compare(a.value, b.value)
+		if options.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a.source, b.source), cmp.Compare(a.target, b.target))
+	}
+
+	// Resolve the last entity of the previous page
+	var after *sortEntry[T]
+	if options.Cursor != "" {
+		entry, err := sortCursorEntry[T](ctx, t, client, options, unmarshalOpts)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &entry
+	}
+
+	input, err := t.MarshalQuery(q, marshalOpts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Keep the first entities after the cursor in a heap whose root is the last of them
+	page := &sortHeap[T]{order: order}
+	read, more := 0, false
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to query: %w", classify(err))
+		}
+
+		for _, item := range output.Items {
+			if read++; options.MaxItems >= 0 && read > options.MaxItems {
+				return nil, "", fmt.Errorf("%w: more than %d items", ErrSortLimitExceeded, options.MaxItems)
+			}
+
+			entry, ok, err := newSortEntry[T](item, options.Filter, unmarshalOpts)
+			if err != nil {
+				return nil, "", err
+			}
+			if !ok || after != nil && order(entry, *after) <= 0 {
+				continue
+			}
+
+			switch {
+			case options.Limit <= 0 || page.Len() < options.Limit:
+				heap.Push(page, entry)
+			case order(entry, page.entries[0]) < 0:
+				page.entries[0] = entry
+				heap.Fix(page, 0)
+				more = true
+			default:
+				more = true
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	entries := page.entries
+	slices.SortFunc(entries, order)
+	values := make([]T, len(entries))
+	for i, entry := range entries {
+		values[i] = entry.value
+	}
+
+	if !more {
+		return values, "", nil
+	}
+	last := entries[len(entries)-1]
+	cursor, err := MarshalStartKey(ctx, options.Paginator, last.key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal cursor: %w", err)
+	}
+	return values, cursor, nil
+}
+
+// TopK returns the first k values in the order of compare, keeping only k values in
+// memory. Values that compare equal keep their order in values. If k is not positive,
+// every value is returned sorted.
+//
+// Example:
+//
+//	cheapest := dynamap.TopK(products, 5, dynamap.CompareBy(func(p Product) float64 { return p.Price }))
+func TopK[T any](values []T, k int, compare func(a, b T) int) []T {
+	if k <= 0 || k >= len(values) {
+		sorted := slices.Clone(values)
+		slices.SortStableFunc(sorted, compare)
+		return sorted
+	}
+
+	type indexed struct {
+		value T
+		index int
+	}
+	order := func(a, b sortEntry[indexed]) int {
+		return cmp.Or(compare(a.value.value, b.value.value), cmp.Compare(a.value.index, b.value.index))
+	}
+
+	top := &sortHeap[indexed]{order: order}
+	for i, value := range values {
+		entry := sortEntry[indexed]{value: indexed{value, i}}
+		if top.Len() < k {
+			heap.Push(top, entry)
+		} else if order(entry, top.entries[0]) < 0 {
+			top.entries[0] = entry
+			heap.Fix(top, 0)
+		}
+	}
+
+	slices.SortFunc(top.entries, top.order)
+	result := make([]T, len(top.entries))
+	for i, entry := range top.entries {
+		result[i] = entry.value.value
+	}
+	return result
+}
+
+// sortEntry is an entity being sorted, with its table key.
+type sortEntry[T any] struct {
+	value  T
+	source string
+	target string
+	key    Item
+}
+
+// newSortEntry unmarshals the item into an entry. It returns false if the entity is
+// rejected by the filter.
+func newSortEntry[T any](item Item, filter func(T) bool, opts []func(*MarshalOptions)) (sortEntry[T], bool, error) {
+	var value T
+	rel, err := UnmarshalSelf(item, &value, opts...)
+	if err != nil {
+		return sortEntry[T]{}, false, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+	if filter != nil && !filter(value) {
+		return sortEntry[T]{}, false, nil
+	}
+	key := Item{AttributeNameSource: item[AttributeNameSource], AttributeNameTarget: item[AttributeNameTarget]}
+	return sortEntry[T]{value: value, source: rel.Source, target: rel.Target, key: key}, true, nil
+}
+
+// sortCursorEntry reads the entity whose key is stored in the cursor of the options.
+func sortCursorEntry[T any](ctx context.Context, t *Table, client DynamoDBClient, options SortOptions[T], opts []func(*MarshalOptions)) (sortEntry[T], error) {
+	key, err := UnmarshalStartKey(ctx, options.Paginator, options.Cursor)
+	if err != nil {
+		return sortEntry[T]{}, fmt.Errorf("failed to unmarshal cursor: %w", err)
+	}
+
+	output, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(t.TableName), Key: key})
+	if err != nil {
+		return sortEntry[T]{}, fmt.Errorf("failed to get cursor entity: %w", classify(err))
+	}
+	if len(output.Item) == 0 {
+		return sortEntry[T]{}, newError(ErrCursorExpired, "cursor entity no longer exists")
+	}
+
+	entry, _, err := newSortEntry[T](output.Item, nil, opts)
+	return entry, err
+}
+
+// sortHeap is a heap of entries whose root is the last entry in order.
+type sortHeap[T any] struct {
+	entries []sortEntry[T]
+	order   func(a, b sortEntry[T]) int
+}
+
+func (h *sortHeap[T]) Len() int           { return len(h.entries) }
+func (h *sortHeap[T]) Less(i, j int) bool { return h.order(h.entries[i], h.entries[j]) > 0 }
+func (h *sortHeap[T]) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *sortHeap[T]) Push(x any)         { h.entries = append(h.entries, x.(sortEntry[T])) }
+
+func (h *sortHeap[T]) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
@@ -0,0 +1,154 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type listing struct {
+	ID    string `dynamodbav:"id"`
+	Price int    `dynamodbav:"price"`
+}
+
+func (l *listing) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("listing", l.ID)
+	opts.RefSortKey = l.ID
+	return nil
+}
+
+func listingIDs(listings []listing) []string {
+	var ids []string
+	for _, l := range listings {
+		ids = append(ids, l.ID)
+	}
+	return ids
+}
+
+// Tests for client-side sorting
+
+func TestQuerySorted(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	for _, l := range []*listing{
+		{ID: "L1", Price: 30},
+		{ID: "L2", Price: 10},
+		{ID: "L3", Price: 20},
+		{ID: "L4", Price: 10},
+		{ID: "L5", Price: 50},
+	} {
+		if err := table.Put(ctx, client, l); err != nil {
+			t.Fatalf("Failed to put listing: %v", err)
+		}
+	}
+
+	byPrice := CompareBy(func(l listing) int { return l.Price })
+	query := &QueryList{Label: "listing", Limit: 2} // Read two items per page
+
+	t.Run("pages", func(t *testing.T) {
+		var (
+			ids    []string
+			cursor string
+		)
+		for pages := 0; ; pages++ {
+			page, next, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+				so.Limit = 2
+				so.Cursor = cursor
+			})
+			if err != nil {
+				t.Fatalf("Failed to query sorted page: %v", err)
+			}
+			ids = append(ids, listingIDs(page)...)
+			if next == "" {
+				break
+			}
+			if pages > 3 {
+				t.Fatal("Expected the pages to end")
+			}
+			cursor = next
+		}
+
+		// Equal prices are ordered by key
+		if want := []string{"L2", "L4", "L3", "L1", "L5"}; !slices.Equal(ids, want) {
+			t.Errorf("Expected %v, got %v", want, ids)
+		}
+	})
+
+	t.Run("descending with filter", func(t *testing.T) {
+		page, cursor, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+			so.Descending = true
+			so.Filter = func(l listing) bool { return l.Price < 50 }
+		})
+		if err != nil {
+			t.Fatalf("Failed to query sorted page: %v", err)
+		}
+		if want := []string{"L1", "L3", "L2", "L4"}; !slices.Equal(listingIDs(page), want) || cursor != "" {
+			t.Errorf("Expected %v without cursor, got %v %q", want, listingIDs(page), cursor)
+		}
+	})
+
+	t.Run("stable across writes", func(t *testing.T) {
+		first, cursor, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+			so.Limit = 2
+		})
+		if err != nil {
+			t.Fatalf("Failed to query sorted page: %v", err)
+		}
+		if !slices.Equal(listingIDs(first), []string{"L2", "L4"}) {
+			t.Fatalf("Unexpected first page: %v", listingIDs(first))
+		}
+
+		// A new cheapest listing does not shift the next page
+		if err := table.Put(ctx, client, &listing{ID: "L0", Price: 5}); err != nil {
+			t.Fatalf("Failed to put listing: %v", err)
+		}
+		defer NewClient(table, client).Delete(ctx, &listing{ID: "L0"})
+
+		second, _, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+			so.Limit = 2
+			so.Cursor = cursor
+		})
+		if err != nil {
+			t.Fatalf("Failed to query sorted page: %v", err)
+		}
+		if !slices.Equal(listingIDs(second), []string{"L3", "L1"}) {
+			t.Errorf("Expected the page after the cursor, got %v", listingIDs(second))
+		}
+	})
+
+	t.Run("max items", func(t *testing.T) {
+		_, _, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+			so.MaxItems = 3
+		})
+		if !errors.Is(err, ErrSortLimitExceeded) || !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrSortLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("expired cursor", func(t *testing.T) {
+		_, _, err := QuerySorted(ctx, table, client, query, byPrice, func(so *SortOptions[listing]) {
+			so.Cursor = "unknown"
+		})
+		if !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("Expected ErrCursorExpired, got %v", err)
+		}
+	})
+}
+
+func TestTopK(t *testing.T) {
+	listings := []listing{{"L1", 30}, {"L2", 10}, {"L3", 20}, {"L4", 10}, {"L5", 50}}
+	byPrice := CompareBy(func(l listing) int { return l.Price })
+
+	if got := listingIDs(TopK(listings, 3, byPrice)); !slices.Equal(got, []string{"L2", "L4", "L3"}) {
+		t.Errorf("Expected the three cheapest in input order, got %v", got)
+	}
+	if got := listingIDs(TopK(listings, 0, byPrice)); !slices.Equal(got, []string{"L2", "L4", "L3", "L1", "L5"}) {
+		t.Errorf("Expected every listing sorted, got %v", got)
+	}
+	if listings[0].ID != "L1" {
+		t.Error("Expected the input to be left unsorted")
+	}
+}
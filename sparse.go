@@ -0,0 +1,142 @@
+package dynamap
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// reservedAttributes are the attribute names managed by dynamap.
+var reservedAttributes = []string{
+	AttributeNameSource,
+	AttributeNameTarget,
+	AttributeNameLabel,
+	AttributeNameCreated,
+	AttributeNameUpdated,
+	AttributeNameExpires,
+	AttributeNameData,
+	AttributeNameRefSortKey,
+	AttributeNameDeleted,
+	AttributeNameChunks,
+	AttributeNameBlob,
+}
+
+// WithSparse sets an attribute that is only written when value is not empty. Use sparse
+// attributes as the sort key of a sparse index partitioned on the label, so the index
+// only contains entities with the attribute set. Only the self relationship is written
+// with sparse attributes; refs are not.
+//
+// Declare the attribute with an empty value when it is not set rather than omitting it, so
+// [Table.MarshalUpsert] removes it from the existing item.
+//
+// Example:
+//
+//	func (o *Order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+//		opts.WithSelfTarget("order", o.ID)
+//		pending := ""
+//		if o.Status == "pending" {
+//			pending = o.PlacedAt.Format(time.RFC3339)
+//		}
+//		opts.WithSparse("pending_sk", pending)
+//		return nil
+//	}
+func (mo *MarshalOptions) WithSparse(attribute, value string) *MarshalOptions {
+	if mo.SparseAttributes == nil {
+		mo.SparseAttributes = make(map[string]string)
+	}
+	mo.SparseAttributes[attribute] = value
+	return mo
+}
+
+// sparseItem adds the non-empty sparse attributes to item.
+func (mo MarshalOptions) sparseItem(item Item, attributes map[string]string) error {
+	for name, value := range attributes {
+		for _, reserved := range reservedAttributes {
+			if name == reserved {
+				return fmt.Errorf("attribute %s is reserved and cannot be sparse", name)
+			}
+		}
+		if value != "" {
+			item[name] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+	return nil
+}
+
+// SetSparse creates an [Updater] that sets the sparse attribute, adding the item to the
+// sparse indexes keyed on it.
+func SetSparse(attribute, value string) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Set(expression.Name(attribute), expression.Value(value))
+	})
+}
+
+// ClearSparse creates an [Updater] that removes the sparse attribute, removing the item
+// from the sparse indexes keyed on it.
+func ClearSparse(attribute string) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Remove(expression.Name(attribute))
+	})
+}
+
+// QuerySparse is a QueryMarshaler that searches a sparse index partitioned on the label
+// and sorted by a sparse attribute. Only entities with the attribute set are returned.
+// Like the ref index, the index is partitioned on [MarshalOptions.RefIndexHashAttribute]
+// when the table sets one.
+//
+// Example:
+//
+//	input, err := table.MarshalQuery(&dynamap.QuerySparse{
+//		Index: "pending-orders",
+//		Label: "order",
+//	})
+type QuerySparse struct {
+	Index           string                         // The sparse index name
+	Label           string                         // The entity label
+	SortKeyFilter   expression.KeyConditionBuilder // Optional filters on the sparse attribute
+	ConditionFilter expression.ConditionBuilder    // Optional filters on the relationship
+	Limit           int                            // Maximum number of items to return
+	StartKey        Item                           // Exclusive start key for pagination
+	SortDescending  bool                           // If true, scans backward
+}
+
+// MarshalQuery implements QueryMarshaler for QuerySparse.
+func (q *QuerySparse) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	keyCondition := expression.Key(opts.refIndexHashKey()).Equal(expression.Value(opts.tenantLabel(q.Label)))
+	if q.SortKeyFilter.IsSet() {
+		keyCondition = keyCondition.And(q.SortKeyFilter)
+	}
+
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+	if q.ConditionFilter.IsSet() {
+		builder = builder.WithFilter(q.ConditionFilter)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ScanIndexForward:          aws.Bool(!q.SortDescending),
+		ExclusiveStartKey:         q.StartKey,
+	}
+
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(int32(q.Limit))
+	}
+
+	return input, nil
+}
+
+// UseIndex implements QueryMarshaler for QuerySparse.
+func (q *QuerySparse) UseIndex(*Table) string {
+	return q.Index
+}
@@ -0,0 +1,136 @@
+package dynamap
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type pendingOrder struct {
+	ID       string    `dynamodbav:"id"`
+	Status   string    `dynamodbav:"status"`
+	Products []Product `dynamodbav:"-"`
+}
+
+func (o *pendingOrder) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	pending := ""
+	if o.Status == "pending" {
+		pending = o.ID
+	}
+	opts.WithSparse("pending_sk", pending)
+	return nil
+}
+
+func (o *pendingOrder) MarshalRefs(ctx *RelationshipContext) error {
+	for i := range o.Products {
+		ctx.AddOne("products", &o.Products[i])
+	}
+	return nil
+}
+
+// Tests for sparse indexes
+
+func TestSparseAttributes(t *testing.T) {
+	table := NewTable("test-table")
+
+	t.Run("written when set", func(t *testing.T) {
+		batches, err := table.MarshalBatch(&pendingOrder{ID: "O1", Status: "pending", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		requests := batches[0].RequestItems["test-table"]
+		if _, ok := requests[0].PutRequest.Item["pending_sk"]; !ok {
+			t.Error("Expected pending_sk on self item")
+		}
+		if _, ok := requests[1].PutRequest.Item["pending_sk"]; ok {
+			t.Error("Expected no pending_sk on ref item")
+		}
+	})
+
+	t.Run("omitted when unset", func(t *testing.T) {
+		input, err := table.MarshalPut(&pendingOrder{ID: "O1", Status: "shipped"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if _, ok := input.Item["pending_sk"]; ok {
+			t.Error("Expected no pending_sk")
+		}
+	})
+
+	t.Run("reserved attribute", func(t *testing.T) {
+		opts := NewMarshalOptions()
+		opts.WithSparse("label", "x")
+		if _, err := opts.marshalItem(NewRelationship(nil, opts)); err == nil {
+			t.Error("Expected error for reserved sparse attribute")
+		}
+	})
+
+	t.Run("clear with update", func(t *testing.T) {
+		input, err := table.MarshalUpdate(&pendingOrder{ID: "O1"}, ClearSparse("pending_sk"))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.UpdateExpression), "REMOVE") {
+			t.Errorf("Expected REMOVE in update expression, got %s", aws.ToString(input.UpdateExpression))
+		}
+	})
+}
+
+func TestQuerySparse(t *testing.T) {
+	table := NewTable("test-table")
+	table.TenantID = "t1"
+
+	input, err := table.MarshalQuery(&QuerySparse{
+		Index:         "pending-orders",
+		Label:         "order",
+		SortKeyFilter: expression.Key("pending_sk").BeginsWith("O"),
+		Limit:         10,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal query: %v", err)
+	}
+
+	if aws.ToString(input.IndexName) != "pending-orders" {
+		t.Errorf("Expected index 'pending-orders', got %s", aws.ToString(input.IndexName))
+	}
+
+	var found bool
+	for _, value := range input.ExpressionAttributeValues {
+		if s, ok := value.(*types.AttributeValueMemberS); ok && s.Value == "t1|order" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected tenant scoped label in key condition")
+	}
+
+	var names []string
+	for _, name := range input.ExpressionAttributeNames {
+		names = append(names, name)
+	}
+	if !strings.Contains(strings.Join(names, ","), "pending_sk") {
+		t.Errorf("Expected sort key condition on pending_sk, got %v", names)
+	}
+}
+
+func TestQuerySparseRefIndexHashAttribute(t *testing.T) {
+	table := NewTable("test-table")
+	table.RefIndexHashAttribute = "gsi1_pk"
+
+	input, err := table.MarshalQuery(&QuerySparse{Index: "pending-orders", Label: "order"})
+	if err != nil {
+		t.Fatalf("Failed to marshal query: %v", err)
+	}
+
+	names := slices.Collect(maps.Values(input.ExpressionAttributeNames))
+	if !slices.Contains(names, "gsi1_pk") || slices.Contains(names, "label") {
+		t.Errorf("Expected key condition on gsi1_pk, got %v", names)
+	}
+}
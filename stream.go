@@ -0,0 +1,145 @@
+package dynamap
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// ChangeType identifies the kind of change captured in a stream record.
+type ChangeType string
+
+const (
+	ChangeInsert ChangeType = "INSERT" // The item was created
+	ChangeModify ChangeType = "MODIFY" // The item was updated
+	ChangeRemove ChangeType = "REMOVE" // The item was deleted
+)
+
+// ErrEmptyStreamRecord is returned when a stream record has neither an old nor a new image.
+var ErrEmptyStreamRecord = newError(ErrValidation, "stream record has no images")
+
+// ChangeEvent is a stream record decoded into relationships.
+type ChangeEvent struct {
+	Type            ChangeType    // The kind of change
+	Relationship    Relationship  // The item after the change, or before it for removals
+	OldRelationship *Relationship // The item before the change; nil for inserts
+	NewImage        Item          // The raw item after the change; nil for removals
+	OldImage        Item          // The raw item before the change; nil for inserts
+}
+
+// IsSelf returns true if the changed item is an entity's self relationship.
+func (e ChangeEvent) IsSelf() bool {
+	return e.Relationship.IsSelf()
+}
+
+// IsRef returns true if the changed item is a relationship between two entities.
+func (e ChangeEvent) IsRef() bool {
+	return e.Relationship.IsRef()
+}
+
+// Unmarshal unmarshals the entity data of the changed item into out, using the new image
+// or, for removals, the old image. The item is assumed to be a self relationship.
+func (e ChangeEvent) Unmarshal(out any, opts ...func(*MarshalOptions)) error {
+	item := e.NewImage
+	if item == nil {
+		item = e.OldImage
+	}
+	_, err := UnmarshalSelf(item, out, opts...)
+	return err
+}
+
+// IsSelf returns true if the relationship is an entity's self relationship, which has
+// equivalent source and target keys.
+func (r Relationship) IsSelf() bool {
+	return r.Source == r.Target
+}
+
+// IsRef returns true if the relationship links two different entities. Chunk continuation
+// items are neither self nor ref relationships.
+func (r Relationship) IsRef() bool {
+	return r.Source != r.Target && r.Label != ""
+}
+
+// UnmarshalStreamRecord decodes the old and new images of a DynamoDB stream record into a
+// [ChangeEvent]. Either image may be nil; the change type is derived from which images are
+// present. Options are used to decode aliased attributes and encoded data.
+//
+// Example:
+//
+//	event, err := dynamap.UnmarshalStreamRecord(oldImage, newImage, table.MarshalOptions)
+//	if event.IsSelf() && event.Type == dynamap.ChangeInsert {
+//		var product Product
+//		err = event.Unmarshal(&product, table.MarshalOptions)
+//	}
+func UnmarshalStreamRecord(oldImage, newImage Item, opts ...func(*MarshalOptions)) (ChangeEvent, error) {
+	event := ChangeEvent{OldImage: oldImage, NewImage: newImage}
+
+	switch {
+	case len(oldImage) == 0 && len(newImage) == 0:
+		return event, ErrEmptyStreamRecord
+	case len(oldImage) == 0:
+		event.Type = ChangeInsert
+		event.OldImage = nil
+	case len(newImage) == 0:
+		event.Type = ChangeRemove
+		event.NewImage = nil
+	default:
+		event.Type = ChangeModify
+	}
+
+	if event.OldImage != nil {
+		old, err := unmarshalRelationship(event.OldImage, opts...)
+		if err != nil {
+			return event, fmt.Errorf("failed to unmarshal old image: %w", err)
+		}
+		event.OldRelationship = &old
+		event.Relationship = old
+	}
+
+	if event.NewImage != nil {
+		rel, err := unmarshalRelationship(event.NewImage, opts...)
+		if err != nil {
+			return event, fmt.Errorf("failed to unmarshal new image: %w", err)
+		}
+		event.Relationship = rel
+	}
+
+	return event, nil
+}
+
+// UnmarshalRecord converts the images of a record read from the DynamoDB Streams API and
+// decodes them with [UnmarshalStreamRecord].
+func UnmarshalRecord(record streamtypes.Record, opts ...func(*MarshalOptions)) (ChangeEvent, error) {
+	if record.Dynamodb == nil {
+		return ChangeEvent{}, ErrEmptyStreamRecord
+	}
+
+	oldImage, err := attributevalue.FromDynamoDBStreamsMap(record.Dynamodb.OldImage)
+	if err != nil {
+		return ChangeEvent{}, fmt.Errorf("failed to convert old image: %w", err)
+	}
+
+	newImage, err := attributevalue.FromDynamoDBStreamsMap(record.Dynamodb.NewImage)
+	if err != nil {
+		return ChangeEvent{}, fmt.Errorf("failed to convert new image: %w", err)
+	}
+
+	return UnmarshalStreamRecord(oldImage, newImage, opts...)
+}
+
+// unmarshalRelationship unmarshals an item into a [Relationship], decoding aliased
+// attributes and encoded data with the options.
+func unmarshalRelationship(item Item, opts ...func(*MarshalOptions)) (Relationship, error) {
+	var rel Relationship
+	item, err := NewMarshalOptions(opts...).unmarshalItem(item)
+	if err != nil {
+		return rel, err
+	}
+
+	if err := attributevalue.UnmarshalMap(item, &rel); err != nil {
+		return rel, fmt.Errorf("failed to unmarshal relationship: %w", err)
+	}
+
+	return rel, nil
+}
@@ -0,0 +1,101 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// Tests for stream record decoding
+
+func TestUnmarshalStreamRecord(t *testing.T) {
+	table := NewTable("test-table")
+	order := &Order{ID: "O1", PurchasedBy: "john", Products: []Product{{ID: "P1"}}}
+
+	batches, err := table.MarshalBatch(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal batch: %v", err)
+	}
+	requests := batches[0].RequestItems["test-table"]
+	self, ref := requests[0].PutRequest.Item, requests[1].PutRequest.Item
+
+	t.Run("insert", func(t *testing.T) {
+		event, err := UnmarshalStreamRecord(nil, self, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal stream record: %v", err)
+		}
+		if event.Type != ChangeInsert || event.OldRelationship != nil {
+			t.Errorf("Expected insert without old relationship, got %+v", event)
+		}
+		if !event.IsSelf() || event.IsRef() {
+			t.Error("Expected self relationship")
+		}
+
+		var out Order
+		if err := event.Unmarshal(&out, table.MarshalOptions); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if out.PurchasedBy != "john" {
+			t.Errorf("Expected purchased by 'john', got %s", out.PurchasedBy)
+		}
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		event, err := UnmarshalStreamRecord(ref, ref)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal stream record: %v", err)
+		}
+		if event.Type != ChangeModify || event.OldRelationship == nil {
+			t.Errorf("Expected modify with old relationship, got %+v", event)
+		}
+		if !event.IsRef() {
+			t.Error("Expected ref relationship")
+		}
+		if event.Relationship.Label != "order/O1/products" {
+			t.Errorf("Expected label 'order/O1/products', got %s", event.Relationship.Label)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		event, err := UnmarshalStreamRecord(self, nil)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal stream record: %v", err)
+		}
+		if event.Type != ChangeRemove || event.NewImage != nil {
+			t.Errorf("Expected remove without new image, got %+v", event)
+		}
+		if event.Relationship.Source != "order#O1" {
+			t.Errorf("Expected removed relationship, got %+v", event.Relationship)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if _, err := UnmarshalStreamRecord(nil, nil); !errors.Is(err, ErrEmptyStreamRecord) {
+			t.Errorf("Expected ErrEmptyStreamRecord, got %v", err)
+		}
+		if _, err := UnmarshalRecord(streamtypes.Record{}); !errors.Is(err, ErrEmptyStreamRecord) {
+			t.Errorf("Expected ErrEmptyStreamRecord, got %v", err)
+		}
+	})
+
+	t.Run("streams record", func(t *testing.T) {
+		record := streamtypes.Record{
+			Dynamodb: &streamtypes.StreamRecord{
+				NewImage: map[string]streamtypes.AttributeValue{
+					"hk":    &streamtypes.AttributeValueMemberS{Value: "product#P1"},
+					"sk":    &streamtypes.AttributeValueMemberS{Value: "product#P1"},
+					"label": &streamtypes.AttributeValueMemberS{Value: "product"},
+				},
+			},
+		}
+
+		event, err := UnmarshalRecord(record)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal record: %v", err)
+		}
+		if event.Type != ChangeInsert || event.Relationship.Source != "product#P1" {
+			t.Errorf("Expected product insert, got %+v", event)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package dynamap
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// EntitySummary describes the items that were unmarshaled into an entity.
+// It is useful for API metadata and debugging.
+type EntitySummary struct {
+	Self      Relationship   // The self relationship, if it was found
+	Counts    map[string]int // The number of relationships found for each name
+	Labels    []string       // The raw labels of each relationship, in item order
+	Truncated bool           // True if the items were a partial query result
+}
+
+// Found returns true if the self relationship was found.
+func (s EntitySummary) Found() bool {
+	return s.Self.Source != ""
+}
+
+// SummaryUnmarshaler can receive a summary of the items unmarshaled by [UnmarshalEntity].
+type SummaryUnmarshaler interface {
+	// UnmarshalSummary is invoked by [UnmarshalEntity] after all items have
+	// been unmarshaled.
+	UnmarshalSummary(*EntitySummary) error
+}
+
+// SummarizeEntity creates an [EntitySummary] from relationships returned by [UnmarshalEntity].
+// Relationships with labels that cannot be parsed are listed in the summary labels but are
+// not counted.
+func SummarizeEntity(relationships []Relationship, opts ...func(*MarshalOptions)) EntitySummary {
+	marshalOpts := NewMarshalOptions(opts...)
+	return marshalOpts.summarize(relationships)
+}
+
+// UnmarshalQueryEntity calls [UnmarshalEntity] on the items of a QueryEntity output. If out
+// implements [SummaryUnmarshaler], the summary reports whether the output was truncated.
+func UnmarshalQueryEntity(output *dynamodb.QueryOutput, out RefUnmarshaler, opts ...func(*MarshalOptions)) ([]Relationship, error) {
+	return unmarshalEntity(output.Items, out, len(output.LastEvaluatedKey) > 0, opts...)
+}
+
+func (mo MarshalOptions) summarize(relationships []Relationship) EntitySummary {
+	summary := EntitySummary{Counts: make(map[string]int)}
+
+	for _, rel := range relationships {
+		if rel.Source == rel.Target {
+			summary.Self = rel
+			continue
+		}
+
+		summary.Labels = append(summary.Labels, rel.Label)
+		if _, _, name, err := mo.splitLabel(rel); err == nil {
+			summary.Counts[name]++
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,100 @@
+package dynamap
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// summarizedOrder records the summary received from UnmarshalEntity.
+type summarizedOrder struct {
+	Order
+	summary *EntitySummary
+}
+
+func (o *summarizedOrder) UnmarshalSummary(summary *EntitySummary) error {
+	o.summary = summary
+	return nil
+}
+
+// Tests for entity summaries
+
+func TestEntitySummary(t *testing.T) {
+	order := &Order{ID: "O1", Products: []Product{{ID: "P1"}, {ID: "P2"}}}
+	relationships, err := MarshalRelationships(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal relationships: %v", err)
+	}
+
+	var items []Item
+	for _, rel := range relationships {
+		item, err := attributevalue.MarshalMap(rel)
+		if err != nil {
+			t.Fatalf("Failed to marshal item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	t.Run("summarize relationships", func(t *testing.T) {
+		summary := SummarizeEntity(relationships)
+		if !summary.Found() {
+			t.Error("Expected self relationship to be found")
+		}
+		if summary.Counts["products"] != 2 {
+			t.Errorf("Expected 2 products, got %d", summary.Counts["products"])
+		}
+		if len(summary.Labels) != 2 || summary.Labels[0] != "order/O1/products" {
+			t.Errorf("Expected product labels, got %v", summary.Labels)
+		}
+	})
+
+	t.Run("summary unmarshaler", func(t *testing.T) {
+		var out summarizedOrder
+		if _, err := UnmarshalEntity(items, &out); err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if out.summary == nil {
+			t.Fatal("Expected summary")
+		}
+		if out.summary.Self.Source != "order#O1" {
+			t.Errorf("Expected self 'order#O1', got %s", out.summary.Self.Source)
+		}
+		if out.summary.Truncated {
+			t.Error("Expected summary not to be truncated")
+		}
+	})
+
+	t.Run("truncated query output", func(t *testing.T) {
+		var out summarizedOrder
+		output := &dynamodb.QueryOutput{
+			Items:            items[1:],
+			LastEvaluatedKey: Item{"hk": &types.AttributeValueMemberS{Value: "order#O1"}},
+		}
+		if _, err := UnmarshalQueryEntity(output, &out); err != nil {
+			t.Fatalf("Failed to unmarshal query entity: %v", err)
+		}
+		if !out.summary.Truncated {
+			t.Error("Expected summary to be truncated")
+		}
+		if out.summary.Found() {
+			t.Error("Expected self relationship not to be found")
+		}
+		if out.summary.Counts["products"] != 2 {
+			t.Errorf("Expected 2 products, got %d", out.summary.Counts["products"])
+		}
+	})
+
+	t.Run("unparsed labels are not counted", func(t *testing.T) {
+		summary := SummarizeEntity([]Relationship{
+			{Source: "order#O1", Target: "product#P1", Label: "a/b/c/d"},
+		})
+		if len(summary.Counts) != 0 {
+			t.Errorf("Expected no counts, got %v", summary.Counts)
+		}
+		if len(summary.Labels) != 1 {
+			t.Errorf("Expected 1 raw label, got %v", summary.Labels)
+		}
+	})
+}
@@ -1,11 +1,13 @@
 package dynamap
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -18,81 +20,263 @@ const (
 
 // MarshalPut marshals the input into a dynamodb put item input request. The request will
 // contain the entity's self-relationship; to marshal all entity relationships, use the
-// MarshalBatch function.
+// MarshalBatch function. If the entity data exceeds the table's ChunkSize, [ErrChunkedItem]
+// is returned since the chunks cannot be written in a single request.
 func (t *Table) MarshalPut(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.PutItemInput, error) {
-	// Marshal relationships (will only contain self due to SkipRefs)
-	relationships, err := MarshalRelationships(in, func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
-		mo.LabelDelimiter = t.LabelDelimiter
+	item, _, err := t.marshalSelf(in, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(t.TableName),
+		Item:      item,
+	}
+	t.logMarshal(input)
+	return input, nil
+}
+
+// marshalSelf marshals the self relationship of the input into an item, and returns it
+// with the relationship.
+func (t *Table) marshalSelf(in Marshaler, opts []func(*MarshalOptions)) (Item, Relationship, error) {
+	marshalOpts := func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 		mo.SkipRefs = true // Only marshal self for put operations
-	})
+	}
+
+	// Marshal relationships (will only contain self due to SkipRefs)
+	relationships, err := MarshalRelationships(in, marshalOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal relationships: %w", err)
+		return nil, Relationship{}, fmt.Errorf("failed to marshal relationships: %w", err)
 	}
 
 	if len(relationships) != 1 {
-		return nil, fmt.Errorf("expected exactly 1 relationship for put, got %d", len(relationships))
+		return nil, Relationship{}, fmt.Errorf("expected exactly 1 relationship for put, got %d", len(relationships))
 	}
 
 	// Marshal the relationship to DynamoDB item
-	item, err := attributevalue.MarshalMap(relationships[0])
+	options := NewMarshalOptions(marshalOpts)
+	item, err := options.marshalItem(relationships[0])
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal item: %w", err)
+		return nil, Relationship{}, fmt.Errorf("failed to marshal item: %w", err)
 	}
 
-	return &dynamodb.PutItemInput{
-		TableName: aws.String(t.TableName),
-		Item:      item,
-	}, nil
+	if items, err := options.chunkItem(item); err != nil {
+		return nil, Relationship{}, fmt.Errorf("failed to chunk item: %w", err)
+	} else if len(items) > 1 {
+		return nil, Relationship{}, fmt.Errorf("%w; use MarshalBatch to write all %d chunks", ErrChunkedItem, len(items))
+	}
+
+	return item, relationships[0], nil
+}
+
+// MarshalUpsert marshals the input into an update item request that writes the entity's
+// self relationship like [Table.MarshalPut], except that the creation timestamp of an
+// existing item is preserved. Use it instead of MarshalPut when re-saving entities.
+//
+// Attributes managed by dynamap that the new item does not carry, such as the expiration,
+// the soft deletion timestamp and the key attributes of secondary indexes, are removed from
+// the existing item, so an upsert of a soft deleted entity restores it. Sparse attributes
+// declared with an empty value by [MarshalOptions.WithSparse] are removed too, so the entity
+// leaves the sparse indexes keyed on them. Other attributes of the existing item, such as
+// counters, are left as they are.
+func (t *Table) MarshalUpsert(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemInput, error) {
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	item, self, err := t.marshalSelf(in, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		update  expression.UpdateBuilder
+		created = marshalOpts.attributeNames(AttributeNameCreated)
+	)
+
+	for name, value := range item {
+		switch {
+		case name == AttributeNameSource || name == AttributeNameTarget:
+			continue
+		case slices.Contains(created, name):
+			update = update.Set(expression.Name(name), expression.Name(name).IfNotExists(expression.Value(value)))
+		default:
+			update = update.Set(expression.Name(name), expression.Value(value))
+		}
+	}
+
+	for _, name := range marshalOpts.upsertRemovals(item, slices.Collect(maps.Keys(self.SparseAttributes))) {
+		update = update.Remove(expression.Name(name))
+	}
+
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build update expression: %w", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(t.TableName),
+		Key:                       Item{AttributeNameSource: item[AttributeNameSource], AttributeNameTarget: item[AttributeNameTarget]},
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              marshalOpts.ReturnValues,
+	}
+	t.logMarshal(input)
+	return input, nil
+}
+
+// upsertRemovals returns the sorted names of the attributes managed by dynamap, and of the
+// sparse attributes, that an upsert of item removes from the existing item because item
+// does not carry them.
+func (mo MarshalOptions) upsertRemovals(item Item, sparse []string) []string {
+	managed := []string{
+		AttributeNameUpdated, AttributeNameExpires, AttributeNameData, AttributeNameRefSortKey,
+		AttributeNameDeleted, AttributeNameChunks, AttributeNameBlob,
+		AttributeNameActor, AttributeNameRequestID, AttributeNameReason,
+	}
+	managed = append(managed, sparse...)
+	if mo.RefIndexHashAttribute != "" {
+		managed = append(managed, mo.RefIndexHashAttribute)
+	}
+	for _, index := range mo.Indexes {
+		managed = append(managed, index.PartitionKey)
+		if index.SortKey != "" {
+			managed = append(managed, index.SortKey)
+		}
+	}
+
+	var names []string
+	for _, name := range managed {
+		switch name {
+		case AttributeNameSource, AttributeNameTarget, AttributeNameLabel, AttributeNameCreated:
+			continue
+		}
+		for _, written := range mo.attributeNames(name) {
+			if _, ok := item[written]; !ok && !slices.Contains(names, written) {
+				names = append(names, written)
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return names
 }
 
 // MarshalBatch marshals the input into multiple batch write put requests. Since there is a
 // limit on how many requests can be contained in a single input, the requests are chunked
-// in sizes of 25 or less.
+// in sizes of 25 or less. Relationships with data larger than the table's ChunkSize are
+// split into continuation items.
 func (t *Table) MarshalBatch(in RefMarshaler, opts ...func(*MarshalOptions)) ([]*dynamodb.BatchWriteItemInput, error) {
-	// Marshal all relationships
-	relationships, err := MarshalRelationships(in, func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
-		mo.LabelDelimiter = t.LabelDelimiter
+	items, _, err := t.marshalItems(in, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	// Chunk requests into batches
+	batches := t.chunkBatchWrite(requests)
+	for _, batch := range batches {
+		t.logMarshal(batch)
+	}
+
+	return batches, nil
+}
+
+// marshalItems marshals all relationships of the input into items, splitting oversized
+// data into chunks. The relationships whose targets must exist are also returned.
+func (t *Table) marshalItems(in Marshaler, opts []func(*MarshalOptions)) (items []Item, required []Relationship, err error) {
+	marshalOpts := func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 		mo.SkipRefs = false // include all relationships for batch operations
-	})
+	}
+
+	// Marshal all relationships
+	relationships, err := MarshalRelationships(in, marshalOpts)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal relationships: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal relationships: %w", err)
 	}
 
-	// Chunk relationships into batches
-	var batches []*dynamodb.BatchWriteItemInput
-
-	for i := 0; i < len(relationships); i += MaxBatchSize {
-		end := i + MaxBatchSize
-		if end > len(relationships) {
-			end = len(relationships)
+	options := NewMarshalOptions(marshalOpts)
+	for _, rel := range relationships {
+		item, err := options.marshalItem(rel)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal relationship: %w", err)
 		}
 
-		var writeRequests []types.WriteRequest
-		for _, rel := range relationships[i:end] {
-			item, err := attributevalue.MarshalMap(rel)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal relationship: %w", err)
-			}
+		chunks, err := options.chunkItem(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to chunk relationship: %w", err)
+		}
 
-			writeRequests = append(writeRequests, types.WriteRequest{
-				PutRequest: &types.PutRequest{Item: item},
-			})
+		items = append(items, chunks...)
+		if rel.RequireTarget {
+			required = append(required, rel)
 		}
+	}
+
+	return items, required, nil
+}
 
-		batch := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				t.TableName: writeRequests,
+// ErrTransactionTooLarge is returned when a transaction would exceed [MaxTransactSize] actions.
+var ErrTransactionTooLarge = newError(ErrValidation, "transaction too large")
+
+// MarshalTransactWrite marshals all relationships of the input into put actions of a single
+// transact write request, so the entity and its refs are written atomically. More actions,
+// such as outbox events, may be appended before the request is executed.
+// Relationships added with [RefOptions.RequireTarget] are accompanied by a condition check
+// on the existence of the target's self relationship, once per target that is not written
+// by the transaction itself.
+// [ErrTransactionTooLarge] is returned if the entity has more than [MaxTransactSize] actions.
+func (t *Table) MarshalTransactWrite(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.TransactWriteItemsInput, error) {
+	items, required, err := t.marshalItems(in, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	written := make(map[string]bool, len(items))
+	actions := make([]types.TransactWriteItem, 0, len(items)+len(required))
+	for _, item := range items {
+		if source, target, err := UnmarshalTableKey(item); err == nil {
+			written[source+"\x00"+target] = true
+		}
+		actions = append(actions, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(t.TableName),
+				Item:      item,
 			},
+		})
+	}
+
+	for _, rel := range required {
+		if key := rel.Target + "\x00" + rel.Target; !written[key] {
+			written[key] = true
+			check, err := t.targetExistsCheck(rel)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, types.TransactWriteItem{ConditionCheck: check})
 		}
-		batches = append(batches, batch)
 	}
 
-	return batches, nil
+	if len(actions) > MaxTransactSize {
+		return nil, fmt.Errorf("%w: %d items", ErrTransactionTooLarge, len(actions))
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{TransactItems: actions}
+	t.logMarshal(input)
+	return input, nil
 }
 
 // MarshalGet marshals the input into a get item request. The self relationship key is used
@@ -100,8 +284,7 @@ func (t *Table) MarshalBatch(in RefMarshaler, opts ...func(*MarshalOptions)) ([]
 func (t *Table) MarshalGet(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.GetItemInput, error) {
 	// Create marshal options with table defaults
 	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
-		mo.LabelDelimiter = t.LabelDelimiter
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 		mo.SkipRefs = true // Only need self relationship for key
 	})
@@ -111,19 +294,21 @@ func (t *Table) MarshalGet(in Marshaler, opts ...func(*MarshalOptions)) (*dynamo
 		return nil, fmt.Errorf("failed to marshal self: %w", err)
 	}
 
-	return &dynamodb.GetItemInput{
+	input := &dynamodb.GetItemInput{
 		TableName: aws.String(t.TableName),
 		Key:       marshalOpts.itemKey(),
-	}, nil
+	}
+	t.logMarshal(input)
+	return input, nil
 }
 
 // MarshalDelete marshals the input into a delete item request.
 // The self relationship key is used to retrieve the relationship from dynamodb.
+// Set [MarshalOptions.ReturnValues] to [types.ReturnValueAllOld] to return the deleted item.
 func (t *Table) MarshalDelete(in Marshaler, opts ...func(*MarshalOptions)) (*dynamodb.DeleteItemInput, error) {
 	// Create marshal options with table defaults
 	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
-		mo.LabelDelimiter = t.LabelDelimiter
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 		mo.SkipRefs = true // Only need self relationship for key
 	})
@@ -133,10 +318,23 @@ func (t *Table) MarshalDelete(in Marshaler, opts ...func(*MarshalOptions)) (*dyn
 		return nil, fmt.Errorf("failed to marshal self: %w", err)
 	}
 
-	return &dynamodb.DeleteItemInput{
-		TableName: aws.String(t.TableName),
-		Key:       marshalOpts.itemKey(),
-	}, nil
+	input := &dynamodb.DeleteItemInput{
+		TableName:    aws.String(t.TableName),
+		Key:          marshalOpts.itemKey(),
+		ReturnValues: marshalOpts.ReturnValues,
+	}
+	t.logMarshal(input)
+	return input, nil
+}
+
+// UnmarshalDeleteResult unmarshals the attributes returned by a delete item request to out.
+// The request must have been marshaled with [types.ReturnValueAllOld]. Returns [ErrItemNotFound]
+// if no item was deleted.
+func UnmarshalDeleteResult(output *dynamodb.DeleteItemOutput, out any, opts ...func(*MarshalOptions)) (Relationship, error) {
+	if output == nil || len(output.Attributes) == 0 {
+		return Relationship{}, ErrItemNotFound
+	}
+	return UnmarshalSelf(output.Attributes, out, opts...)
 }
 
 // Updater can build update expressions for modifying relationships.
@@ -159,6 +357,13 @@ func DataAttribute(suffix string) expression.NameBuilder {
 }
 
 // MarshalUpdate marshals the input into a DynamoDB UpdateItem request using the provided updater.
+// If updater is a [ConditionalUpdater], the request is conditioned on its update condition.
+// If [MarshalOptions.PreserveCreated] is set, the creation timestamp is also set if missing.
+// If [MarshalOptions.OmitTimestamps] is set, neither timestamp is written.
+// If a [Validator] is set, it is called with the updater.
+// The audit attributes set in the options, such as [MarshalOptions.ActorID], are also set.
+// By default, the updated attributes are returned; set [MarshalOptions.ReturnValues] to
+// [types.ReturnValueAllOld] or [types.ReturnValueAllNew] to return the entire item.
 func (t *Table) MarshalUpdate(in Marshaler, updater Updater, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemInput, error) {
 	if updater == nil {
 		return nil, fmt.Errorf("updater is required")
@@ -166,8 +371,7 @@ func (t *Table) MarshalUpdate(in Marshaler, updater Updater, opts ...func(*Marsh
 
 	// Create marshal options with table defaults
 	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
-		mo.LabelDelimiter = t.LabelDelimiter
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 		mo.SkipRefs = true // Only need self relationship for key
 	})
@@ -177,33 +381,67 @@ func (t *Table) MarshalUpdate(in Marshaler, updater Updater, opts ...func(*Marsh
 		return nil, fmt.Errorf("failed to marshal self: %w", err)
 	}
 
+	if err := marshalOpts.validateEntity(updater); err != nil {
+		return nil, err
+	}
+
 	// Marshal the update expression
-	update := expression.Set(
-		expression.Name(AttributeNameUpdated),
-		expression.Value(marshalOpts.Tick().UTC().Format(time.RFC3339)),
-	)
+	update := marshalOpts.touch(expression.UpdateBuilder{}, time.RFC3339)
+	if marshalOpts.PreserveCreated && !marshalOpts.OmitTimestamps {
+		update = update.Set(
+			expression.Name(AttributeNameCreated),
+			expression.Name(AttributeNameCreated).IfNotExists(expression.Value(marshalOpts.timestampValue(marshalOpts.Tick(), time.RFC3339))),
+		)
+	}
+	update = marshalOpts.auditUpdate(update)
 	update = updater.UpdateRelationship(update)
-	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	builder := expression.NewBuilder().WithUpdate(update)
+
+	// Add the update condition if provided
+	if conditional, ok := updater.(ConditionalUpdater); ok {
+		if condition := conditional.UpdateCondition(); condition.IsSet() {
+			builder = builder.WithCondition(condition)
+		}
+	}
+
+	expr, err := builder.Build()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build update expression: %w", err)
 	}
 
-	return &dynamodb.UpdateItemInput{
+	returnValues := marshalOpts.ReturnValues
+	if returnValues == "" {
+		returnValues = types.ReturnValueUpdatedNew
+	}
+
+	input := &dynamodb.UpdateItemInput{
 		TableName:                 aws.String(t.TableName),
 		Key:                       marshalOpts.itemKey(),
 		UpdateExpression:          expr.Update(),
 		ConditionExpression:       expr.Condition(),
-		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
-		ReturnValues:              types.ReturnValueUpdatedNew,
-	}, nil
+		ReturnValues:              returnValues,
+	}
+	input.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &input.UpdateExpression, &input.ConditionExpression)
+	t.logMarshal(input)
+	return input, nil
+}
+
+// UnmarshalUpdateResult unmarshals the attributes returned by an update item request to out.
+// The request must have been marshaled with [types.ReturnValueAllOld] or [types.ReturnValueAllNew]
+// to return the entire entity. Returns [ErrItemNotFound] if no attributes were returned.
+func UnmarshalUpdateResult(output *dynamodb.UpdateItemOutput, out any, opts ...func(*MarshalOptions)) (Relationship, error) {
+	if output == nil || len(output.Attributes) == 0 {
+		return Relationship{}, ErrItemNotFound
+	}
+	return UnmarshalSelf(output.Attributes, out, opts...)
 }
 
 // MarshalQuery marshals the input into a query item request.
 func (t *Table) MarshalQuery(in QueryMarshaler, opts ...func(*MarshalOptions)) (*dynamodb.QueryInput, error) {
 	// Create marshal options with table defaults
 	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
-		mo.KeyDelimiter = t.KeyDelimiter
+		t.MarshalOptions(mo)
 		mo.apply(opts)
 	})
 
@@ -215,11 +453,81 @@ func (t *Table) MarshalQuery(in QueryMarshaler, opts ...func(*MarshalOptions)) (
 
 	// Set the table name
 	input.TableName = aws.String(t.TableName)
+	if t.FilterExpired {
+		filterExpired(input, marshalOpts.Tick())
+	}
+	input.ExpressionAttributeNames = marshalOpts.aliasNames(input.ExpressionAttributeNames,
+		&input.KeyConditionExpression, &input.FilterExpression, &input.ProjectionExpression)
 
 	// Set the index name if this is a QueryList (queries on label)
 	if index := in.UseIndex(t); index != "" {
 		input.IndexName = aws.String(index)
 	}
 
+	t.logMarshal(input)
 	return input, nil
 }
+
+// Put marshals the input using [Table.MarshalPut], offloads its data to the table's blob
+// store if it is too large, and writes the item. If [Table.History] is set, a revision of
+// the item is written in the same transaction.
+func (t *Table) Put(ctx context.Context, client DynamoDBClient, in Marshaler, opts ...func(*MarshalOptions)) error {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalPut(in, opts...)
+	if err != nil {
+		return err
+	}
+
+	if input.Item, err = t.OffloadItem(ctx, input.Item); err != nil {
+		return err
+	}
+
+	if t.History {
+		return t.putWithRevision(ctx, client, NewMarshalOptions(func(mo *MarshalOptions) {
+			t.MarshalOptions(mo)
+			mo.apply(opts)
+		}), input)
+	}
+
+	if _, err := client.PutItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to put item: %w", classify(err))
+	}
+
+	return nil
+}
+
+// Get retrieves the self relationship of the input, hydrates any offloaded data from the
+// table's blob store, and unmarshals it into out. [ErrItemNotFound] is returned if the
+// item does not exist, or has expired and [Table.FilterExpired] is set.
+func (t *Table) Get(ctx context.Context, client DynamoDBClient, in Marshaler, out any, opts ...func(*MarshalOptions)) (Relationship, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalGet(in, opts...)
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	output, err := client.GetItem(ctx, input)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("failed to get item: %w", classify(err))
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+	if len(output.Item) == 0 || t.FilterExpired && isExpired(output.Item, marshalOpts.aliasName(AttributeNameExpires), marshalOpts.Tick()) {
+		return Relationship{}, ErrItemNotFound
+	}
+
+	items, err := t.HydrateItems(ctx, []Item{output.Item})
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	return UnmarshalSelf(items[0], out, func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+}
@@ -2,9 +2,12 @@ package dynamap
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -39,11 +42,46 @@ func (m *mockDynamoDBClient) BatchWriteItem(ctx context.Context, params *dynamod
 				key := hk + "#" + sk
 				m.items[key] = request.PutRequest.Item
 			}
+			if request.DeleteRequest != nil {
+				hk := request.DeleteRequest.Key["hk"].(*types.AttributeValueMemberS).Value
+				sk := request.DeleteRequest.Key["sk"].(*types.AttributeValueMemberS).Value
+				delete(m.items, hk+"#"+sk)
+			}
 		}
 	}
 	return &dynamodb.BatchWriteItemOutput{}, nil
 }
 
+func (m *mockDynamoDBClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, action := range params.TransactItems {
+		if action.Put != nil {
+			hk := action.Put.Item["hk"].(*types.AttributeValueMemberS).Value
+			sk := action.Put.Item["sk"].(*types.AttributeValueMemberS).Value
+			m.items[hk+"#"+sk] = action.Put.Item
+		}
+		if action.Delete != nil {
+			hk := action.Delete.Key["hk"].(*types.AttributeValueMemberS).Value
+			sk := action.Delete.Key["sk"].(*types.AttributeValueMemberS).Value
+			delete(m.items, hk+"#"+sk)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	responses := make(map[string][]map[string]types.AttributeValue)
+	for table, request := range params.RequestItems {
+		for _, key := range request.Keys {
+			hk := key["hk"].(*types.AttributeValueMemberS).Value
+			sk := key["sk"].(*types.AttributeValueMemberS).Value
+			if item, exists := m.items[hk+"#"+sk]; exists {
+				responses[table] = append(responses[table], item)
+			}
+		}
+	}
+	return &dynamodb.BatchGetItemOutput{Responses: responses}, nil
+}
+
 func (m *mockDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
 	return &dynamodb.QueryOutput{
 		Items: []map[string]types.AttributeValue{},
@@ -67,8 +105,13 @@ func (m *mockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.De
 	sk := params.Key["sk"].(*types.AttributeValueMemberS).Value
 	key := hk + "#" + sk
 
+	output := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = m.items[key]
+	}
+
 	delete(m.items, key)
-	return &dynamodb.DeleteItemOutput{}, nil
+	return output, nil
 }
 
 func (m *mockDynamoDBClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
@@ -275,6 +318,40 @@ func TestTableMarshalDelete(t *testing.T) {
 			t.Error("Expected non-nil delete input")
 		}
 	})
+
+	t.Run("returns deleted item", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		putInput, _ := table.MarshalPut(product)
+		_, _ = client.PutItem(context.Background(), putInput)
+
+		deleteInput, err := table.MarshalDelete(&Product{ID: "P1"}, func(opts *MarshalOptions) {
+			opts.ReturnValues = types.ReturnValueAllOld
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal delete: %v", err)
+		}
+		if deleteInput.ReturnValues != types.ReturnValueAllOld {
+			t.Errorf("Expected ReturnValues ALL_OLD, got %s", deleteInput.ReturnValues)
+		}
+
+		output, _ := client.DeleteItem(context.Background(), deleteInput)
+		var deleted Product
+		rel, err := UnmarshalDeleteResult(output, &deleted)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal delete result: %v", err)
+		}
+		if deleted.Category != "electronics" {
+			t.Errorf("Expected category 'electronics', got %s", deleted.Category)
+		}
+		if rel.Source != "product#P1" {
+			t.Errorf("Expected source 'product#P1', got %s", rel.Source)
+		}
+
+		output, _ = client.DeleteItem(context.Background(), deleteInput)
+		if _, err := UnmarshalDeleteResult(output, &deleted); err != ErrItemNotFound {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
 }
 
 func TestTableCustomConfiguration(t *testing.T) {
@@ -374,6 +451,58 @@ func TestTableMarshalUpdate(t *testing.T) {
 			t.Error("Expected update expression to be set")
 		}
 	})
+
+	t.Run("return values", func(t *testing.T) {
+		updateInput, err := table.MarshalUpdate(product, &testUpdater{})
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if updateInput.ReturnValues != types.ReturnValueUpdatedNew {
+			t.Errorf("Expected default ReturnValues UPDATED_NEW, got %s", updateInput.ReturnValues)
+		}
+
+		updateInput, err = table.MarshalUpdate(product, &testUpdater{}, func(opts *MarshalOptions) {
+			opts.ReturnValues = types.ReturnValueAllOld
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if updateInput.ReturnValues != types.ReturnValueAllOld {
+			t.Errorf("Expected ReturnValues ALL_OLD, got %s", updateInput.ReturnValues)
+		}
+	})
+}
+
+func TestUnmarshalUpdateResult(t *testing.T) {
+	t.Run("previous state", func(t *testing.T) {
+		item, err := attributevalue.MarshalMap(Relationship{
+			Source: "product#P1",
+			Target: "product#P1",
+			Data:   &Product{ID: "P1", Category: "books"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal item: %v", err)
+		}
+
+		var previous Product
+		rel, err := UnmarshalUpdateResult(&dynamodb.UpdateItemOutput{Attributes: item}, &previous)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal update result: %v", err)
+		}
+		if previous.Category != "books" {
+			t.Errorf("Expected category 'books', got %s", previous.Category)
+		}
+		if rel.Target != "product#P1" {
+			t.Errorf("Expected target 'product#P1', got %s", rel.Target)
+		}
+	})
+
+	t.Run("no attributes", func(t *testing.T) {
+		var previous Product
+		if _, err := UnmarshalUpdateResult(&dynamodb.UpdateItemOutput{}, &previous); err != ErrItemNotFound {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
 }
 func TestDataAttribute(t *testing.T) {
 	t.Run("basic data attribute", func(t *testing.T) {
@@ -412,3 +541,142 @@ func TestDataAttribute(t *testing.T) {
 		}
 	})
 }
+
+func TestTableMarshalUpsert(t *testing.T) {
+	table := NewTable("test-table")
+	product := &Product{ID: "P1", Category: "electronics"}
+
+	upsertInput, err := table.MarshalUpsert(product)
+	if err != nil {
+		t.Fatalf("Failed to marshal upsert: %v", err)
+	}
+
+	if hk := upsertInput.Key["hk"].(*types.AttributeValueMemberS).Value; hk != "product#P1" {
+		t.Errorf("Expected hk 'product#P1', got %s", hk)
+	}
+	if len(upsertInput.Key) != 2 {
+		t.Errorf("Expected only key attributes in key, got %v", upsertInput.Key)
+	}
+
+	expr := aws.ToString(upsertInput.UpdateExpression)
+	if strings.Count(expr, "if_not_exists(") != 1 {
+		t.Errorf("Expected created_at to be set if not exists, got %s", expr)
+	}
+
+	names := make(map[string]bool)
+	for _, name := range upsertInput.ExpressionAttributeNames {
+		names[name] = true
+	}
+	for _, name := range []string{"label", "data", "created_at", "updated_at", "gsi1_sk"} {
+		if !names[name] {
+			t.Errorf("Expected %s to be written, got %v", name, upsertInput.ExpressionAttributeNames)
+		}
+	}
+	if names["hk"] || names["sk"] {
+		t.Error("Expected key attributes not to be updated")
+	}
+
+	for _, value := range upsertInput.ExpressionAttributeValues {
+		if m, ok := value.(*types.AttributeValueMemberM); ok {
+			if category := m.Value["category"].(*types.AttributeValueMemberS).Value; category != "electronics" {
+				t.Errorf("Expected category 'electronics', got %s", category)
+			}
+		}
+	}
+}
+
+func TestTableMarshalUpsertRemovals(t *testing.T) {
+	table := NewTable("test-table")
+	table.Indexes = []SecondaryIndex{{Name: "pending", PartitionKey: "label", SortKey: "pending_sk"}}
+
+	removed := func(t *testing.T, input *dynamodb.UpdateItemInput) map[string]bool {
+		t.Helper()
+		expr := aws.ToString(input.UpdateExpression)
+		i := strings.Index(expr, "REMOVE ")
+		if i < 0 {
+			return nil
+		}
+		clause, _, _ := strings.Cut(expr[i+len("REMOVE "):], "\n")
+		names := make(map[string]bool)
+		for _, placeholder := range strings.Split(clause, ", ") {
+			names[input.ExpressionAttributeNames[strings.TrimSpace(placeholder)]] = true
+		}
+		return names
+	}
+
+	t.Run("restores soft deleted entity", func(t *testing.T) {
+		input, err := table.MarshalUpsert(&Product{ID: "P1", Category: "books"})
+		if err != nil {
+			t.Fatalf("Failed to marshal upsert: %v", err)
+		}
+
+		names := removed(t, input)
+		for _, name := range []string{"deleted_at", "expires", "pending_sk"} {
+			if !names[name] {
+				t.Errorf("Expected %s to be removed, got %s", name, aws.ToString(input.UpdateExpression))
+			}
+		}
+		for _, name := range []string{"label", "created_at", "data", "gsi1_sk"} {
+			if names[name] {
+				t.Errorf("Expected %s not to be removed, got %s", name, aws.ToString(input.UpdateExpression))
+			}
+		}
+	})
+
+	t.Run("keeps time to live", func(t *testing.T) {
+		input, err := table.MarshalUpsert(&Product{ID: "P1", Category: "books"}, func(mo *MarshalOptions) {
+			mo.TimeToLive = time.Hour
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal upsert: %v", err)
+		}
+
+		if names := removed(t, input); names["expires"] || !names["deleted_at"] {
+			t.Errorf("Expected expires to be set and deleted_at removed, got %s", aws.ToString(input.UpdateExpression))
+		}
+	})
+
+	t.Run("removes declared sparse attributes", func(t *testing.T) {
+		table := NewTable("test-table")
+
+		input, err := table.MarshalUpsert(&pendingOrder{ID: "O1", Status: "pending"})
+		if err != nil {
+			t.Fatalf("Failed to marshal upsert: %v", err)
+		}
+		if names := removed(t, input); names["pending_sk"] {
+			t.Errorf("Expected pending_sk to be set, got %s", aws.ToString(input.UpdateExpression))
+		}
+
+		input, err = table.MarshalUpsert(&pendingOrder{ID: "O1", Status: "shipped"})
+		if err != nil {
+			t.Fatalf("Failed to marshal upsert: %v", err)
+		}
+		if names := removed(t, input); !names["pending_sk"] {
+			t.Errorf("Expected pending_sk to be removed, got %s", aws.ToString(input.UpdateExpression))
+		}
+	})
+}
+
+func TestTableMarshalUpdatePreserveCreated(t *testing.T) {
+	table := NewTable("test-table")
+	product := &Product{ID: "P1"}
+
+	updateInput, err := table.MarshalUpdate(product, Increment("views", 1), func(opts *MarshalOptions) {
+		opts.PreserveCreated = true
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal update: %v", err)
+	}
+
+	if !strings.Contains(aws.ToString(updateInput.UpdateExpression), "if_not_exists(") {
+		t.Errorf("Expected created_at to be set if not exists, got %s", aws.ToString(updateInput.UpdateExpression))
+	}
+
+	updateInput, err = table.MarshalUpdate(product, Increment("views", 1))
+	if err != nil {
+		t.Fatalf("Failed to marshal update: %v", err)
+	}
+	if strings.Contains(aws.ToString(updateInput.UpdateExpression), "if_not_exists(") {
+		t.Error("Expected created_at to be untouched by default")
+	}
+}
@@ -0,0 +1,56 @@
+package dynamap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tenantKeyCodec scopes the keys of another codec to a tenant.
+type tenantKeyCodec struct {
+	KeyCodec
+	prefix string
+}
+
+func (c tenantKeyCodec) EncodeKey(prefix, id string) string {
+	return c.prefix + c.KeyCodec.EncodeKey(prefix, id)
+}
+
+func (c tenantKeyCodec) DecodeKey(key string) (prefix, id string, err error) {
+	key, ok := strings.CutPrefix(key, c.prefix)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q does not belong to tenant", ErrInvalidKey, key)
+	}
+	return c.KeyCodec.DecodeKey(key)
+}
+
+// tenantLabelCodec scopes the labels of another codec to a tenant.
+type tenantLabelCodec struct {
+	LabelCodec
+	prefix string
+}
+
+func (c tenantLabelCodec) EncodeLabel(prefix, id, name string) string {
+	return c.prefix + c.LabelCodec.EncodeLabel(prefix, id, name)
+}
+
+func (c tenantLabelCodec) DecodeLabel(label string) (prefix, id, name string, err error) {
+	label, ok := strings.CutPrefix(label, c.prefix)
+	if !ok {
+		return "", "", "", fmt.Errorf("%w: %q does not belong to tenant", ErrInvalidLabel, label)
+	}
+	return c.LabelCodec.DecodeLabel(label)
+}
+
+// tenantPrefix returns the prefix applied to keys and labels, or an empty string if no
+// tenant is set.
+func (mo MarshalOptions) tenantPrefix() string {
+	if mo.TenantID == "" {
+		return ""
+	}
+	return mo.TenantID + mo.TenantDelimiter
+}
+
+// tenantLabel scopes a self relationship or query label to the tenant.
+func (mo MarshalOptions) tenantLabel(label string) string {
+	return mo.tenantPrefix() + label
+}
@@ -0,0 +1,105 @@
+package dynamap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for tenant-scoped keys and labels
+
+func TestTenant(t *testing.T) {
+	table := NewTable("test-table")
+	table.TenantID = "tenant1"
+
+	stringValue := func(item Item, name string) string {
+		return item[name].(*types.AttributeValueMemberS).Value
+	}
+
+	t.Run("scopes keys and labels", func(t *testing.T) {
+		batches, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal batch: %v", err)
+		}
+
+		requests := batches[0].RequestItems["test-table"]
+		self, ref := requests[0].PutRequest.Item, requests[1].PutRequest.Item
+
+		if hk := stringValue(self, "hk"); hk != "tenant1|order#O1" {
+			t.Errorf("Expected hk 'tenant1|order#O1', got %s", hk)
+		}
+		if label := stringValue(self, "label"); label != "tenant1|order" {
+			t.Errorf("Expected label 'tenant1|order', got %s", label)
+		}
+		if sk := stringValue(ref, "sk"); sk != "tenant1|product#P1" {
+			t.Errorf("Expected sk 'tenant1|product#P1', got %s", sk)
+		}
+		if label := stringValue(ref, "label"); label != "tenant1|order/O1/products" {
+			t.Errorf("Expected label 'tenant1|order/O1/products', got %s", label)
+		}
+
+		var order Order
+		relationships, err := UnmarshalEntity([]Item{self, ref}, &order, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal entity: %v", err)
+		}
+		if len(relationships) != 2 || len(order.Products) != 1 {
+			t.Errorf("Expected order with 1 product, got %+v", order)
+		}
+	})
+
+	t.Run("scopes queries", func(t *testing.T) {
+		listInput, err := table.MarshalQuery(&QueryList{Label: "product"})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if v, _ := findValue[*types.AttributeValueMemberS](listInput.ExpressionAttributeValues); v.Value != "tenant1|product" {
+			t.Errorf("Expected label 'tenant1|product', got %s", v.Value)
+		}
+
+		entityInput, err := table.MarshalQuery(&QueryEntity{Source: &Product{ID: "P1"}})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if v, _ := findValue[*types.AttributeValueMemberS](entityInput.ExpressionAttributeValues); v.Value != "tenant1|product#P1" {
+			t.Errorf("Expected key 'tenant1|product#P1', got %s", v.Value)
+		}
+	})
+
+	t.Run("per call tenant", func(t *testing.T) {
+		putInput, err := table.MarshalPut(&Product{ID: "P1"}, func(opts *MarshalOptions) {
+			opts.TenantID = "tenant2"
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if hk := stringValue(putInput.Item, "hk"); hk != "tenant2|product#P1" {
+			t.Errorf("Expected hk 'tenant2|product#P1', got %s", hk)
+		}
+	})
+
+	t.Run("parse keys", func(t *testing.T) {
+		prefix, id, err := ParseKey("tenant1|product#P1", table.MarshalOptions)
+		if err != nil || prefix != "product" || id != "P1" {
+			t.Errorf("Expected 'product' and 'P1', got %s %s %v", prefix, id, err)
+		}
+
+		if _, _, err := ParseKey("tenant2|product#P1", table.MarshalOptions); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for other tenant, got %v", err)
+		}
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.TenantID = "tenant1"
+		table.Registry = NewRegistry(
+			EntitySchema{Prefix: "order", Relationships: []string{"products"}},
+			EntitySchema{Prefix: "product"},
+		)
+
+		if _, err := table.MarshalBatch(&Order{ID: "O1", Products: []Product{{ID: "P1"}}}); err != nil {
+			t.Errorf("Expected valid relationships, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package dynamap
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TimestampCodec controls how the created_at, updated_at and deleted_at timestamps are
+// stored. By default timestamps are RFC 3339 strings. Set [Table.TimestampCodec] to store
+// them differently, such as [EpochMillisTimestampCodec] for smaller items and numeric
+// comparisons. Use [Table.TimeFilters] to filter on timestamps stored with a codec.
+//
+// The expires attribute is always stored as epoch seconds, as required by DynamoDB TTL.
+type TimestampCodec interface {
+	// EncodeTime returns the stored form of t.
+	EncodeTime(t time.Time) types.AttributeValue
+	// DecodeTime returns the time of a stored timestamp.
+	DecodeTime(value types.AttributeValue) (time.Time, error)
+}
+
+// RFC3339TimestampCodec is a [TimestampCodec] that stores timestamps as RFC 3339 strings
+// with nanosecond precision, the same form written when the table has no codec.
+type RFC3339TimestampCodec struct{}
+
+// EncodeTime implements [TimestampCodec].
+func (RFC3339TimestampCodec) EncodeTime(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: t.UTC().Format(time.RFC3339Nano)}
+}
+
+// DecodeTime implements [TimestampCodec].
+func (RFC3339TimestampCodec) DecodeTime(value types.AttributeValue) (time.Time, error) {
+	s, ok := value.(*types.AttributeValueMemberS)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected string timestamp, got %T", value)
+	}
+	return time.Parse(time.RFC3339Nano, s.Value)
+}
+
+// EpochMillisTimestampCodec is a [TimestampCodec] that stores timestamps as the number
+// of milliseconds since the Unix epoch. RFC 3339 strings written before the codec was set
+// are still read, so existing tables can switch without migrating their items.
+type EpochMillisTimestampCodec struct{}
+
+// EncodeTime implements [TimestampCodec].
+func (EpochMillisTimestampCodec) EncodeTime(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+}
+
+// DecodeTime implements [TimestampCodec].
+func (EpochMillisTimestampCodec) DecodeTime(value types.AttributeValue) (time.Time, error) {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberN:
+		millis, err := strconv.ParseInt(value.Value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch millis timestamp %q: %w", value.Value, err)
+		}
+		return time.UnixMilli(millis).UTC(), nil
+	default:
+		return RFC3339TimestampCodec{}.DecodeTime(value)
+	}
+}
+
+// timestampAttributes are the attributes stored with the [TimestampCodec].
+var timestampAttributes = []string{AttributeNameCreated, AttributeNameUpdated, AttributeNameDeleted}
+
+// encodeTimestamps encodes the timestamps of a marshaled item with the timestamp codec.
+func (mo MarshalOptions) encodeTimestamps(item Item) error {
+	if mo.TimestampCodec == nil {
+		return nil
+	}
+	for _, name := range timestampAttributes {
+		value, ok := item[name]
+		if !ok {
+			continue
+		}
+		t, err := RFC3339TimestampCodec{}.DecodeTime(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		item[name] = mo.TimestampCodec.EncodeTime(t)
+	}
+	return nil
+}
+
+// decodeTimestamps returns a copy of an unaliased item with the timestamps decoded by the
+// timestamp codec, so they unmarshal into a [Relationship]. The item is not modified.
+func (mo MarshalOptions) decodeTimestamps(item Item) (Item, error) {
+	if mo.TimestampCodec == nil {
+		return item, nil
+	}
+	decoded := maps.Clone(item)
+	for _, name := range timestampAttributes {
+		value, ok := item[name]
+		if !ok {
+			continue
+		}
+		t, err := mo.TimestampCodec.DecodeTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		decoded[name] = RFC3339TimestampCodec{}.EncodeTime(t)
+	}
+	return decoded, nil
+}
+
+// timestampValue returns the value of t written by update expressions: the stored form of
+// the timestamp codec, or t formatted with layout if the options have no codec.
+func (mo MarshalOptions) timestampValue(t time.Time, layout string) any {
+	if mo.TimestampCodec == nil {
+		return t.UTC().Format(layout)
+	}
+	return mo.TimestampCodec.EncodeTime(t)
+}
+
+// touch sets the updated timestamp to now, formatted with layout if the options have no
+// codec, unless [MarshalOptions.OmitTimestamps] is set.
+func (mo MarshalOptions) touch(update expression.UpdateBuilder, layout string) expression.UpdateBuilder {
+	if mo.OmitTimestamps {
+		return update
+	}
+	return update.Set(expression.Name(AttributeNameUpdated), expression.Value(mo.timestampValue(mo.Tick(), layout)))
+}
+
+// TimeFilters creates conditions on timestamps stored with a [TimestampCodec]. The zero
+// value compares RFC 3339 strings, like [PeriodBefore] and the other package functions.
+type TimeFilters struct {
+	Codec TimestampCodec // The codec the timestamps are stored with. Nil compares RFC 3339 strings.
+}
+
+// TimeFilters returns the time filters for the timestamps written by the table.
+//
+// Example:
+//
+//	filters := table.TimeFilters()
+//	query := &dynamap.QueryList{Label: "order", ConditionFilter: filters.CreatedAfter(lastWeek)}
+func (t *Table) TimeFilters() TimeFilters {
+	return TimeFilters{Codec: t.TimestampCodec}
+}
+
+// value returns the stored value of moment.
+func (f TimeFilters) value(moment time.Time) expression.OperandBuilder {
+	if f.Codec == nil {
+		return expression.Value(moment.Format(time.RFC3339))
+	}
+	return expression.Value(f.Codec.EncodeTime(moment))
+}
+
+// Before creates a condition that filters for timestamps before or equal to the given moment.
+func (f TimeFilters) Before(name string, moment time.Time) expression.ConditionBuilder {
+	return expression.Name(name).LessThanEqual(f.value(moment))
+}
+
+// After creates a condition that filters for timestamps after or equal to the given moment.
+func (f TimeFilters) After(name string, moment time.Time) expression.ConditionBuilder {
+	return expression.Name(name).GreaterThanEqual(f.value(moment))
+}
+
+// Between creates a condition that filters for timestamps between the start and end times.
+func (f TimeFilters) Between(name string, start, end time.Time) expression.ConditionBuilder {
+	return expression.Name(name).Between(f.value(start), f.value(end))
+}
+
+// CreatedBefore creates a condition that filters for entities created before or equal to the given moment.
+func (f TimeFilters) CreatedBefore(moment time.Time) expression.ConditionBuilder {
+	return f.Before(AttributeNameCreated, moment)
+}
+
+// CreatedAfter creates a condition that filters for entities created after or equal to the given moment.
+func (f TimeFilters) CreatedAfter(moment time.Time) expression.ConditionBuilder {
+	return f.After(AttributeNameCreated, moment)
+}
+
+// CreatedBetween creates a condition that filters for entities created between the start and end times.
+func (f TimeFilters) CreatedBetween(start, end time.Time) expression.ConditionBuilder {
+	return f.Between(AttributeNameCreated, start, end)
+}
+
+// UpdatedBefore creates a condition that filters for entities updated before or equal to the given moment.
+func (f TimeFilters) UpdatedBefore(moment time.Time) expression.ConditionBuilder {
+	return f.Before(AttributeNameUpdated, moment)
+}
+
+// UpdatedAfter creates a condition that filters for entities updated after or equal to the given moment.
+func (f TimeFilters) UpdatedAfter(moment time.Time) expression.ConditionBuilder {
+	return f.After(AttributeNameUpdated, moment)
+}
+
+// UpdatedBetween creates a condition that filters for entities updated between the start and end times.
+func (f TimeFilters) UpdatedBetween(start, end time.Time) expression.ConditionBuilder {
+	return f.Between(AttributeNameUpdated, start, end)
+}
@@ -0,0 +1,160 @@
+package dynamap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for timestamp codecs
+
+func TestTimestampCodec(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 678_000_000, time.UTC)
+
+	table := NewTable("test-table")
+	table.TimestampCodec = EpochMillisTimestampCodec{}
+	table.Clock = func() time.Time { return now }
+
+	t.Run("items store epoch millis", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		if err := table.Put(ctx, client, &Product{ID: "P1"}); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		item := client.items["product#P1#product#P1"]
+		created, ok := item[AttributeNameCreated].(*types.AttributeValueMemberN)
+		if !ok || created.Value != "1767323045678" {
+			t.Errorf("Expected epoch millis, got %#v", item[AttributeNameCreated])
+		}
+
+		rel, err := table.Get(ctx, client, &Product{ID: "P1"}, &Product{})
+		if err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if !rel.CreatedAt.Equal(now) || !rel.UpdatedAt.Equal(now) {
+			t.Errorf("Expected timestamps %v, got %v and %v", now, rel.CreatedAt, rel.UpdatedAt)
+		}
+
+		lazy, err := UnmarshalLazy(item, table.MarshalOptions)
+		if err != nil || !lazy.CreatedAt.Equal(now) {
+			t.Errorf("Expected the lazy timestamp %v, got %v, %v", now, lazy.CreatedAt, err)
+		}
+	})
+
+	t.Run("strings written before the codec are read", func(t *testing.T) {
+		legacy := NewTable("test-table")
+		legacy.Clock = table.Clock
+		input, err := legacy.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		rel, err := UnmarshalSelf(input.Item, &Product{}, table.MarshalOptions)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if !rel.CreatedAt.Equal(now) {
+			t.Errorf("Expected %v, got %v", now, rel.CreatedAt)
+		}
+	})
+
+	t.Run("updates write epoch millis", func(t *testing.T) {
+		input, err := table.MarshalUpdate(&Product{ID: "P1"}, Increment("stock", 1))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		var found bool
+		for _, value := range input.ExpressionAttributeValues {
+			if n, ok := value.(*types.AttributeValueMemberN); ok && n.Value == "1767323045678" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected the update timestamp in epoch millis, got %v", input.ExpressionAttributeValues)
+		}
+	})
+
+	t.Run("time filters", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			filters TimeFilters
+			check   func(types.AttributeValue) bool
+		}{
+			{"codec", table.TimeFilters(), func(v types.AttributeValue) bool {
+				n, ok := v.(*types.AttributeValueMemberN)
+				return ok && n.Value == "1767323045678"
+			}},
+			{"default", TimeFilters{}, func(v types.AttributeValue) bool {
+				s, ok := v.(*types.AttributeValueMemberS)
+				return ok && s.Value == "2026-01-02T03:04:05Z"
+			}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				expr, err := expression.NewBuilder().WithFilter(tt.filters.CreatedAfter(now)).Build()
+				if err != nil {
+					t.Fatalf("Failed to build expression: %v", err)
+				}
+				if !tt.check(expr.Values()[":0"]) {
+					t.Errorf("Unexpected filter value %#v", expr.Values()[":0"])
+				}
+			})
+		}
+	})
+}
+
+func TestOmitZeroTimes(t *testing.T) {
+	t.Run("no time to live", func(t *testing.T) {
+		input, err := NewTable("test-table").MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if _, ok := input.Item[AttributeNameExpires]; ok {
+			t.Errorf("Expected no expires attribute, got %v", input.Item[AttributeNameExpires])
+		}
+		if _, ok := input.Item[AttributeNameCreated]; !ok {
+			t.Error("Expected created timestamp")
+		}
+	})
+
+	t.Run("time to live", func(t *testing.T) {
+		input, err := NewTable("test-table").MarshalPut(&Product{ID: "P1"}, func(mo *MarshalOptions) {
+			mo.TimeToLive = time.Hour
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		if _, ok := input.Item[AttributeNameExpires]; !ok {
+			t.Error("Expected expires attribute")
+		}
+	})
+
+	t.Run("omit timestamps", func(t *testing.T) {
+		table := NewTable("test-table")
+		table.OmitTimestamps = true
+
+		input, err := table.MarshalPut(&Product{ID: "P1"})
+		if err != nil {
+			t.Fatalf("Failed to marshal put: %v", err)
+		}
+		for _, name := range []string{AttributeNameCreated, AttributeNameUpdated} {
+			if _, ok := input.Item[name]; ok {
+				t.Errorf("Expected no %s attribute", name)
+			}
+		}
+
+		update, err := table.MarshalUpdate(&Product{ID: "P1"}, Increment("count", 1))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		for _, name := range update.ExpressionAttributeNames {
+			if name == AttributeNameUpdated {
+				t.Error("Expected update not to set the updated timestamp")
+			}
+		}
+	})
+}
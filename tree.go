@@ -0,0 +1,218 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PathDelimiter separates the identifiers of a materialized [TreePath].
+const PathDelimiter = "/"
+
+// ErrInvalidMove is returned when a tree node is moved beneath itself.
+var ErrInvalidMove = newError(ErrValidation, "invalid move")
+
+// TreePath is the materialized path of a node in a tree of entities: the identifiers of its
+// ancestors from the root, followed by its own. Identifiers must not contain [PathDelimiter].
+type TreePath []string
+
+// ParsePath parses a path written by [MarshalOptions.WithPath].
+func ParsePath(path string) TreePath {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, PathDelimiter)
+}
+
+// PathOf returns the path of the tree node stored in the relationship.
+func PathOf(rel *Relationship) TreePath {
+	return ParsePath(rel.GSI1SK)
+}
+
+// String joins the identifiers of the path.
+func (p TreePath) String() string {
+	return strings.Join(p, PathDelimiter)
+}
+
+// ID returns the identifier of the node, which is the last identifier in the path.
+func (p TreePath) ID() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return p[len(p)-1]
+}
+
+// Parent returns the path of the parent node, or nil for a root node.
+func (p TreePath) Parent() TreePath {
+	if len(p) <= 1 {
+		return nil
+	}
+	return p[:len(p)-1]
+}
+
+// Child returns the path of the child node with the given identifier.
+func (p TreePath) Child(id string) TreePath {
+	return append(slices.Clip(p), id)
+}
+
+// HasPrefix returns true if p is prefix or one of its descendants.
+func (p TreePath) HasPrefix(prefix TreePath) bool {
+	return len(p) >= len(prefix) && slices.Equal(p[:len(prefix)], prefix)
+}
+
+// WithPath stores the materialized path of the entity in the ref sort key, so the entity
+// can be found with [QueryDescendants] and [Table.Ancestors]. The path should end with
+// the entity's own identifier.
+//
+// Example:
+//
+//	func (c *Category) MarshalSelf(opts *dynamap.MarshalOptions) error {
+//		opts.WithSelfTarget("category", c.ID)
+//		opts.WithPath(c.Path)
+//		return nil
+//	}
+func (mo *MarshalOptions) WithPath(path TreePath) *MarshalOptions {
+	mo.RefSortKey = path.String()
+	return mo
+}
+
+// QueryDescendants is a QueryMarshaler that searches the ref index for the descendants of
+// the tree node at Path among the entities with the label. The node itself is not included.
+type QueryDescendants struct {
+	Label           string                      // The entity label
+	Path            TreePath                    // The path of the ancestor node
+	ConditionFilter expression.ConditionBuilder // Optional filters on the relationship
+	Limit           int                         // Maximum number of items to return
+	StartKey        Item                        // Exclusive start key for pagination
+	SortDescending  bool                        // If true, scans backward
+}
+
+// MarshalQuery implements QueryMarshaler for QueryDescendants.
+func (q *QueryDescendants) MarshalQuery(opts *MarshalOptions) (*dynamodb.QueryInput, error) {
+	list := &QueryList{
+		Label:           q.Label,
+		RefSortFilter:   expression.Key(AttributeNameRefSortKey).BeginsWith(q.Path.String() + PathDelimiter),
+		ConditionFilter: q.ConditionFilter,
+		Limit:           q.Limit,
+		StartKey:        q.StartKey,
+		SortDescending:  q.SortDescending,
+	}
+	return list.MarshalQuery(opts)
+}
+
+// UseIndex implements QueryMarshaler for QueryDescendants.
+func (q *QueryDescendants) UseIndex(t *Table) string {
+	return t.RefIndexName
+}
+
+// Ancestors loads the self relationship items of the ancestors of the tree node at path
+// using batch get requests, ordered from the root. The ancestors must share the entity
+// prefix. Unmarshal the results with [UnmarshalList].
+func (t *Table) Ancestors(ctx context.Context, client DynamoDBClient, prefix string, path TreePath) ([]Item, error) {
+	client = t.client(client)
+	marshalOpts := NewMarshalOptions(t.MarshalOptions)
+
+	var keys []string
+	for _, id := range path.Parent() {
+		keys = append(keys, marshalOpts.keyCodec().EncodeKey(prefix, id))
+	}
+
+	selves, err := t.getSelfItems(ctx, client, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, key := range keys {
+		if item, ok := selves[key]; ok {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// Move moves the tree node at path, along with its subtree, beneath the node at parent by
+// rewriting the materialized path of each node. The updates are written in transactions
+// of up to 100 nodes, each conditioned on the node's path being unchanged. The number of
+// moved nodes is returned.
+func (t *Table) Move(ctx context.Context, client DynamoDBClient, label string, path, parent TreePath, opts ...func(*MarshalOptions)) (int, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	if len(path) == 0 || parent.HasPrefix(path) {
+		return 0, fmt.Errorf("%w: cannot move %q beneath %q", ErrInvalidMove, path, parent)
+	}
+
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	input, err := t.MarshalQuery(&QueryList{
+		Label:         label,
+		RefSortFilter: expression.Key(AttributeNameRefSortKey).BeginsWith(path.String()),
+	}, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := queryAll(ctx, client, input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query subtree: %w", classify(err))
+	}
+
+	var actions []types.TransactWriteItem
+	for _, item := range items {
+		var rel Relationship
+		if err := attributevalue.UnmarshalMap(marshalOpts.unaliasItem(item), &rel); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal relationship: %w", err)
+		}
+
+		// Skip siblings that share a prefix, such as "a/bc" when moving "a/b"
+		old := PathOf(&rel)
+		if !old.HasPrefix(path) {
+			continue
+		}
+
+		moved := append(parent.Child(path.ID()), old[len(path):]...)
+
+		update := marshalOpts.touch(expression.Set(expression.Name(AttributeNameRefSortKey), expression.Value(moved.String())), time.RFC3339Nano)
+
+		expr, err := expression.NewBuilder().
+			WithUpdate(update).
+			WithCondition(expression.Name(AttributeNameRefSortKey).Equal(expression.Value(rel.GSI1SK))).
+			Build()
+		if err != nil {
+			return 0, fmt.Errorf("failed to build update expression: %w", err)
+		}
+
+		move := &types.Update{
+			TableName:                 aws.String(t.TableName),
+			Key:                       rel.itemKey(),
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeValues: expr.Values(),
+		}
+		move.ExpressionAttributeNames = marshalOpts.aliasNames(expr.Names(), &move.UpdateExpression, &move.ConditionExpression)
+		actions = append(actions, types.TransactWriteItem{Update: move})
+	}
+
+	var transactions []*dynamodb.TransactWriteItemsInput
+	for chunk := range slices.Chunk(actions, MaxTransactSize) {
+		transactions = append(transactions, &dynamodb.TransactWriteItemsInput{TransactItems: chunk})
+	}
+
+	if err := transactWriteAll(ctx, client, transactions); err != nil {
+		return 0, err
+	}
+
+	return len(actions), nil
+}
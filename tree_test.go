@@ -0,0 +1,159 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// treeClient answers ref index queries with a begins_with condition on the ref sort key,
+// and applies ref sort key updates.
+type treeClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *treeClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	label := params.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value
+	prefix := params.ExpressionAttributeValues[":1"].(*types.AttributeValueMemberS).Value
+
+	var items []Item
+	for _, item := range c.items {
+		l, _ := item["label"].(*types.AttributeValueMemberS)
+		sk, _ := item["gsi1_sk"].(*types.AttributeValueMemberS)
+		if l != nil && sk != nil && l.Value == label && strings.HasPrefix(sk.Value, prefix) {
+			items = append(items, item)
+		}
+	}
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func (c *treeClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, action := range params.TransactItems {
+		update := action.Update
+		hk := update.Key["hk"].(*types.AttributeValueMemberS).Value
+		sk := update.Key["sk"].(*types.AttributeValueMemberS).Value
+		item := c.items[hk+"#"+sk]
+		for placeholder, name := range update.ExpressionAttributeNames {
+			if name != "gsi1_sk" {
+				continue
+			}
+			// Find the value assigned to the placeholder, e.g. "SET #0 = :1, #1 = :2"
+			for _, assignment := range strings.Split(strings.TrimPrefix(aws.ToString(update.UpdateExpression), "SET "), ", ") {
+				if lhs, rhs, _ := strings.Cut(strings.TrimSpace(assignment), " = "); lhs == placeholder {
+					item["gsi1_sk"] = update.ExpressionAttributeValues[rhs]
+				}
+			}
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+type category struct {
+	ID   string   `dynamodbav:"id"`
+	Path TreePath `dynamodbav:"-"`
+}
+
+func (c *category) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("category", c.ID)
+	opts.WithPath(c.Path)
+	return nil
+}
+
+func (c *category) UnmarshalSelf(rel *Relationship) error {
+	c.Path = PathOf(rel)
+	return nil
+}
+
+// Tests for trees
+
+func TestTreePath(t *testing.T) {
+	path := ParsePath("root/electronics/phones")
+
+	if path.ID() != "phones" {
+		t.Errorf("Expected id 'phones', got %s", path.ID())
+	}
+	if path.Parent().String() != "root/electronics" {
+		t.Errorf("Expected parent 'root/electronics', got %s", path.Parent())
+	}
+	if child := path.Parent().Child("tablets"); child.String() != "root/electronics/tablets" || path.ID() != "phones" {
+		t.Errorf("Expected child 'root/electronics/tablets', got %s", child)
+	}
+	if !path.HasPrefix(ParsePath("root/electronics")) || path.HasPrefix(ParsePath("root/elec")) {
+		t.Error("Expected prefix to match whole identifiers")
+	}
+}
+
+func TestTree(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := &treeClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	for _, path := range []string{"root", "root/electronics", "root/electronics/phones", "root/electronicsx", "root/books"} {
+		p := ParsePath(path)
+		if err := table.Put(ctx, client, &category{ID: p.ID(), Path: p}); err != nil {
+			t.Fatalf("Failed to put category: %v", err)
+		}
+	}
+
+	t.Run("descendants", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryDescendants{Label: "category", Path: ParsePath("root/electronics")})
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if prefix := input.ExpressionAttributeValues[":1"].(*types.AttributeValueMemberS).Value; prefix != "root/electronics/" {
+			t.Errorf("Expected prefix 'root/electronics/', got %s", prefix)
+		}
+		if *input.IndexName != "ref-index" {
+			t.Errorf("Expected ref-index, got %s", *input.IndexName)
+		}
+	})
+
+	t.Run("ancestors", func(t *testing.T) {
+		items, err := table.Ancestors(ctx, client, "category", ParsePath("root/electronics/phones"))
+		if err != nil {
+			t.Fatalf("Failed to load ancestors: %v", err)
+		}
+
+		var ancestors []category
+		if _, err := UnmarshalList(items, &ancestors); err != nil {
+			t.Fatalf("Failed to unmarshal ancestors: %v", err)
+		}
+		if len(ancestors) != 2 || ancestors[0].ID != "root" || ancestors[1].ID != "electronics" {
+			t.Errorf("Expected ancestors root, electronics, got %+v", ancestors)
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		n, err := table.Move(ctx, client, "category", ParsePath("root/electronics"), ParsePath("root/books"))
+		if err != nil {
+			t.Fatalf("Failed to move: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("Expected 2 nodes moved, got %d", n)
+		}
+
+		var paths []string
+		for _, item := range client.items {
+			paths = append(paths, item["gsi1_sk"].(*types.AttributeValueMemberS).Value)
+		}
+		slices.Sort(paths)
+
+		expected := []string{"root", "root/books", "root/books/electronics", "root/books/electronics/phones", "root/electronicsx"}
+		if !slices.Equal(paths, expected) {
+			t.Errorf("Expected paths %v, got %v", expected, paths)
+		}
+	})
+
+	t.Run("move beneath itself", func(t *testing.T) {
+		_, err := table.Move(ctx, client, "category", ParsePath("root"), ParsePath("root/books"))
+		if !errors.Is(err, ErrInvalidMove) {
+			t.Errorf("Expected ErrInvalidMove, got %v", err)
+		}
+	})
+}
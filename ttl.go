@@ -0,0 +1,110 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TimeToLiveClient is the subset of the DynamoDB client used to configure time to live.
+type TimeToLiveClient interface {
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// EnableTTL enables time to live on the table's expires attribute, so DynamoDB deletes
+// relationships, cursors and soft deleted items once they expire. It does nothing if
+// time to live is already enabled on the attribute, and fails if it is enabled on
+// another attribute.
+//
+// Example:
+//
+//	err := table.EnableTTL(ctx, dynamodb.NewFromConfig(cfg))
+func (t *Table) EnableTTL(ctx context.Context, client TimeToLiveClient) error {
+	name := NewMarshalOptions(t.MarshalOptions).aliasName(AttributeNameExpires)
+
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(t.TableName)})
+	if err != nil {
+		return fmt.Errorf("failed to describe time to live of table %s: %w", t.TableName, err)
+	}
+
+	if description := output.TimeToLiveDescription; description != nil {
+		switch description.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			if current := aws.ToString(description.AttributeName); current != name {
+				return fmt.Errorf("time to live of table %s is enabled on %s, not %s", t.TableName, current, name)
+			}
+			return nil
+		}
+	}
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(t.TableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(name),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable time to live on table %s: %w", t.TableName, err)
+	}
+
+	return nil
+}
+
+// NotExpired creates a condition that filters out items whose expiration has passed.
+// DynamoDB deletes expired items in the background, typically within days, so they
+// may still be returned by queries until then. Items without an expiration never expire.
+// Set [Table.FilterExpired] to apply the condition to every query.
+func NotExpired() expression.ConditionBuilder {
+	return notExpired(time.Now())
+}
+
+// notExpired creates a condition that filters out items expired at now.
+func notExpired(now time.Time) expression.ConditionBuilder {
+	return expression.Or(
+		expression.Name(AttributeNameExpires).AttributeNotExists(),
+		expression.Name(AttributeNameExpires).GreaterThan(expression.Value(now.Unix())),
+	)
+}
+
+// filterExpired adds a filter on the expires attribute to the query input that skips
+// items expired at now, keeping any existing filter.
+func filterExpired(input *dynamodb.QueryInput, now time.Time) {
+	const (
+		name        = "#dynamap_expires"
+		placeholder = ":dynamap_now"
+	)
+
+	if input.ExpressionAttributeNames == nil {
+		input.ExpressionAttributeNames = make(map[string]string)
+	}
+	if input.ExpressionAttributeValues == nil {
+		input.ExpressionAttributeValues = make(map[string]types.AttributeValue)
+	}
+	input.ExpressionAttributeNames[name] = AttributeNameExpires
+	input.ExpressionAttributeValues[placeholder] = &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)}
+
+	filter := fmt.Sprintf("(attribute_not_exists(%s) OR %s > %s)", name, name, placeholder)
+	if existing := aws.ToString(input.FilterExpression); existing != "" {
+		filter = fmt.Sprintf("(%s) AND %s", existing, filter)
+	}
+	input.FilterExpression = aws.String(filter)
+}
+
+// isExpired returns true if the item has an expiration in the expires attribute, named
+// name, that passed before now.
+func isExpired(item Item, name string, now time.Time) bool {
+	expires, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+	seconds, err := strconv.ParseInt(expires.Value, 10, 64)
+	return err == nil && seconds <= now.Unix()
+}
@@ -0,0 +1,109 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tests for time to live
+
+func TestEnableTTL(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := newAdminClient()
+
+	if err := table.EnableTTL(ctx, client); err != nil {
+		t.Fatalf("Failed to enable time to live: %v", err)
+	}
+	if client.ttl["test-table"] != AttributeNameExpires {
+		t.Errorf("Expected time to live on %s, got %q", AttributeNameExpires, client.ttl["test-table"])
+	}
+	if err := table.EnableTTL(ctx, client); err != nil {
+		t.Errorf("Expected enabling time to live again to succeed, got %v", err)
+	}
+
+	client.ttl["test-table"] = "ttl"
+	if err := table.EnableTTL(ctx, client); err == nil {
+		t.Error("Expected an error when time to live is enabled on another attribute")
+	}
+}
+
+func TestNotExpired(t *testing.T) {
+	expr, err := expression.NewBuilder().WithFilter(NotExpired()).Build()
+	if err != nil {
+		t.Fatalf("Failed to build expression: %v", err)
+	}
+	if got := aws.ToString(expr.Filter()); got != "(attribute_not_exists (#0)) OR (#0 > :0)" {
+		t.Errorf("Unexpected filter %s", got)
+	}
+}
+
+func TestFilterExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := NewTable("test-table")
+	table.FilterExpired = true
+
+	t.Run("queries", func(t *testing.T) {
+		input, err := table.MarshalQuery(&QueryList{Label: "product", ConditionFilter: ExcludeDeleted()}, fixedClock(now))
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+
+		filter := aws.ToString(input.FilterExpression)
+		if !strings.HasPrefix(filter, "(attribute_not_exists (#") || !strings.HasSuffix(filter, "AND (attribute_not_exists(#dynamap_expires) OR #dynamap_expires > :dynamap_now)") {
+			t.Errorf("Expected the existing filter and expiration filter, got %s", filter)
+		}
+		if input.ExpressionAttributeNames["#dynamap_expires"] != AttributeNameExpires {
+			t.Errorf("Expected expires attribute name, got %v", input.ExpressionAttributeNames)
+		}
+
+		input, err = table.MarshalQuery(&QueryList{Label: "product"}, fixedClock(now))
+		if err != nil {
+			t.Fatalf("Failed to marshal query: %v", err)
+		}
+		if filter := aws.ToString(input.FilterExpression); filter != "(attribute_not_exists(#dynamap_expires) OR #dynamap_expires > :dynamap_now)" {
+			t.Errorf("Expected expiration filter, got %s", filter)
+		}
+	})
+
+	t.Run("gets", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		expiring := func(mo *MarshalOptions) { mo.TimeToLive = time.Hour }
+		if err := table.Put(ctx, client, &Product{ID: "P1"}, expiring); err != nil {
+			t.Fatalf("Failed to put product: %v", err)
+		}
+
+		var product Product
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &product); err != nil {
+			t.Errorf("Expected the product before it expires, got %v", err)
+		}
+		if _, err := table.Get(ctx, client, &Product{ID: "P1"}, &product, fixedClock(time.Now().Add(2*time.Hour))); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("Expected ErrItemNotFound for the expired product, got %v", err)
+		}
+	})
+
+	t.Run("cursors", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		paginator := NewTable("test-table").Paginator(client)
+		cursor, err := paginator.PageCursor(ctx, Item{"hk": &types.AttributeValueMemberS{Value: "product#P1"}})
+		if err != nil {
+			t.Fatalf("Failed to create cursor: %v", err)
+		}
+		for _, item := range client.items {
+			item[AttributeNameExpires] = &types.AttributeValueMemberN{Value: "1"}
+		}
+
+		key, err := paginator.StartKey(ctx, cursor)
+		if !errors.Is(err, ErrCursorExpired) || key != nil {
+			t.Errorf("Expected ErrCursorExpired for the expired cursor, got %v, %v", key, err)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// unionLabels returns the distinct labels queried by [Table.QueryUnion].
+func (q *QueryList) unionLabels() []string {
+	var labels []string
+	for _, label := range append([]string{q.Label}, q.Labels...) {
+		if label != "" && !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// unionItem is an item read by a union query, along with the label result it belongs to.
+type unionItem struct {
+	item    Item
+	sortKey string
+	result  *unionResult
+}
+
+// unionResult holds the page of results of one label of a union query.
+type unionResult struct {
+	label   string
+	items   []Item
+	lastKey Item
+	done    bool
+}
+
+// QueryUnion executes the query against q.Label and each of q.Labels concurrently on the
+// ref index, and merges the results in ref sort key order, descending if q.SortDescending
+// is set. At most q.Limit items are returned per page.
+//
+// The returned start key encodes the position of each label, and is nil once every label
+// is exhausted. Pass it as q.StartKey to fetch the next page, or convert it to a client
+// cursor with [MarshalStartKey] like the last evaluated key of any other query.
+//
+// Example:
+//
+//	query := &dynamap.QueryList{Labels: []string{"order", "invoice"}, Limit: 20}
+//	items, startKey, err := table.QueryUnion(ctx, client, query)
+//	cursor, err := dynamap.MarshalStartKey(ctx, table.Paginator(client), startKey)
+func (t *Table) QueryUnion(ctx context.Context, client DynamoDBClient, q *QueryList, opts ...func(*MarshalOptions)) ([]Item, Item, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	return t.queryMerged(ctx, client, q, q.unionLabels(), opts)
+}
+
+// queryMerged executes one page of the query against each of labels concurrently, and
+// merges the results in ref sort key order, descending if q.SortDescending is set. Each
+// label reads at most q.Limit items, and at most q.Limit items are returned. The returned
+// start key holds the position of each label, keyed by label, as described by
+// [Table.QueryUnion], and is nil once every label is exhausted.
+func (t *Table) queryMerged(ctx context.Context, client DynamoDBClient, q *QueryList, labels []string, opts []func(*MarshalOptions)) ([]Item, Item, error) {
+	marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+		t.MarshalOptions(mo)
+		mo.apply(opts)
+	})
+
+	var (
+		results = make([]*unionResult, len(labels))
+		errs    = make([]error, len(labels))
+		wg      sync.WaitGroup
+	)
+
+	for i, label := range labels {
+		result := &unionResult{label: label}
+		results[i] = result
+
+		position, started := q.StartKey[label]
+		if _, ok := position.(*types.AttributeValueMemberNULL); ok {
+			result.done = true
+			continue
+		}
+
+		labelQuery := *q
+		labelQuery.Label = label
+		labelQuery.Labels = nil
+		labelQuery.StartKey = nil
+		if m, ok := position.(*types.AttributeValueMemberM); started && ok {
+			labelQuery.StartKey = m.Value
+		}
+
+		input, err := t.MarshalQuery(&labelQuery, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := client.Query(ctx, input)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to query label %s: %w", label, classify(err))
+				return
+			}
+			result.items = output.Items
+			result.lastKey = output.LastEvaluatedKey
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	// Labels with more results bound the merged page: their unread items may sort before
+	// the items already read from other labels.
+	before := func(a, b string) bool {
+		if q.SortDescending {
+			return a > b
+		}
+		return a < b
+	}
+
+	var (
+		frontier    string
+		hasFrontier bool
+		merged      []unionItem
+	)
+
+	for _, result := range results {
+		if len(result.lastKey) > 0 {
+			key := marshalOpts.refSortKey(result.lastKey)
+			if !hasFrontier || before(key, frontier) {
+				frontier, hasFrontier = key, true
+			}
+		}
+		for _, item := range result.items {
+			merged = append(merged, unionItem{item: item, sortKey: marshalOpts.refSortKey(item), result: result})
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b unionItem) int {
+		if q.SortDescending {
+			return strings.Compare(b.sortKey, a.sortKey)
+		}
+		return strings.Compare(a.sortKey, b.sortKey)
+	})
+
+	var (
+		items []Item
+		taken = make(map[*unionResult]int)
+		last  = make(map[*unionResult]Item)
+	)
+
+	for _, m := range merged {
+		if q.Limit > 0 && len(items) == q.Limit {
+			break
+		}
+		if hasFrontier && before(frontier, m.sortKey) {
+			break
+		}
+
+		taken[m.result]++
+		last[m.result] = m.item
+		items = append(items, m.item)
+	}
+
+	startKey := make(Item)
+	exhausted := true
+
+	for _, result := range results {
+		switch {
+		case result.done:
+			startKey[result.label] = &types.AttributeValueMemberNULL{Value: true}
+			continue
+		case taken[result] < len(result.items):
+			// Resume after the last item returned from the label, if any
+			if item, ok := last[result]; ok {
+				startKey[result.label] = &types.AttributeValueMemberM{Value: marshalOpts.indexKey(item)}
+			} else if position, ok := q.StartKey[result.label]; ok {
+				startKey[result.label] = position
+			}
+		case len(result.lastKey) > 0:
+			startKey[result.label] = &types.AttributeValueMemberM{Value: result.lastKey}
+		default:
+			startKey[result.label] = &types.AttributeValueMemberNULL{Value: true}
+			continue
+		}
+		exhausted = false
+	}
+
+	if exhausted {
+		return items, nil, nil
+	}
+
+	return items, startKey, nil
+}
+
+// indexKey returns the table and ref index key attributes of item, which can be used as
+// the exclusive start key of a ref index query. The ref index keys are the hash attribute
+// of the index, such as [MarshalOptions.RefIndexHashAttribute], and the ref sort key, under
+// the names written to items.
+func (mo MarshalOptions) indexKey(item Item) Item {
+	key := make(Item, 4)
+	for _, name := range []string{
+		AttributeNameSource,
+		AttributeNameTarget,
+		mo.aliasName(mo.refIndexHashKey()),
+		mo.aliasName(AttributeNameRefSortKey),
+	} {
+		if value, ok := item[name]; ok {
+			key[name] = value
+		}
+	}
+	return key
+}
@@ -0,0 +1,234 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pagedLabelClient answers ref index queries by label in ref sort key order, honoring the
+// scan direction, limit and exclusive start key. It counts the items it returns. If opts
+// are set, exclusive start keys must hold exactly the index key attributes they declare.
+type pagedLabelClient struct {
+	*mockDynamoDBClient
+	mu   sync.Mutex
+	read int
+	opts *MarshalOptions
+}
+
+func (c *pagedLabelClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	label := params.ExpressionAttributeValues[":0"].(*types.AttributeValueMemberS).Value
+	sortKey := func(item Item) string {
+		return item["gsi1_sk"].(*types.AttributeValueMemberS).Value
+	}
+
+	var items []Item
+	for _, item := range c.items {
+		if l, _ := item["label"].(*types.AttributeValueMemberS); l != nil && l.Value == label {
+			items = append(items, item)
+		}
+	}
+
+	forward := aws.ToBool(params.ScanIndexForward)
+	slices.SortFunc(items, func(a, b Item) int {
+		if forward {
+			return strings.Compare(sortKey(a), sortKey(b))
+		}
+		return strings.Compare(sortKey(b), sortKey(a))
+	})
+
+	if start := params.ExclusiveStartKey; start != nil {
+		if c.opts != nil {
+			want := []string{"hk", "sk", c.opts.refIndexHashKey(), "gsi1_sk"}
+			got := slices.Sorted(maps.Keys(start))
+			if slices.Sort(want); !slices.Equal(got, want) {
+				return nil, fmt.Errorf("invalid exclusive start key %v, want %v", got, want)
+			}
+		}
+		i := slices.IndexFunc(items, func(item Item) bool { return sortKey(item) == sortKey(start) })
+		items = items[i+1:]
+	}
+
+	output := &dynamodb.QueryOutput{Items: items}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(items) > limit {
+		output.Items = items[:limit]
+		opts := NewMarshalOptions()
+		if c.opts != nil {
+			opts = *c.opts
+		}
+		output.LastEvaluatedKey = opts.indexKey(items[limit-1])
+	}
+	c.read += len(output.Items)
+	return output, nil
+}
+
+type invoice struct {
+	ID      string    `dynamodbav:"id"`
+	Created time.Time `dynamodbav:"-"`
+}
+
+func (i *invoice) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget("invoice", i.ID)
+	opts.Created = i.Created
+	opts.RefSortKey = i.Created.Format(time.RFC3339)
+	return nil
+}
+
+// Tests for union queries
+
+func TestQueryUnion(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	client := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return created.Add(time.Duration(hours) * time.Hour) }
+
+	// Shipments at hours 0, 3, 4, 5 and invoices at hours 1, 2, 6
+	for i, hours := range []int{0, 3, 4, 5} {
+		if err := table.Put(ctx, client, &shipment{ID: fmt.Sprintf("S%d", i), Created: at(hours)}); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+	}
+	for i, hours := range []int{1, 2, 6} {
+		if err := table.Put(ctx, client, &invoice{ID: fmt.Sprintf("I%d", i), Created: at(hours)}); err != nil {
+			t.Fatalf("Failed to put invoice: %v", err)
+		}
+	}
+
+	targets := func(items []Item) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item["sk"].(*types.AttributeValueMemberS).Value)
+		}
+		return ids
+	}
+
+	paginate := func(t *testing.T, q *QueryList) [][]string {
+		var pages [][]string
+		for range 10 {
+			items, startKey, err := table.QueryUnion(ctx, client, q)
+			if err != nil {
+				t.Fatalf("Failed to query union: %v", err)
+			}
+			pages = append(pages, targets(items))
+			if startKey == nil {
+				return pages
+			}
+			q.StartKey = startKey
+		}
+		t.Fatal("Expected pagination to end")
+		return nil
+	}
+
+	t.Run("ascending", func(t *testing.T) {
+		pages := paginate(t, &QueryList{Labels: []string{"shipment", "invoice"}, Limit: 3})
+		want := [][]string{
+			{"shipment#S0", "invoice#I0", "invoice#I1"},
+			{"shipment#S1", "shipment#S2", "shipment#S3"},
+			{"invoice#I2"},
+		}
+		if fmt.Sprint(pages) != fmt.Sprint(want) {
+			t.Errorf("Expected pages %v, got %v", want, pages)
+		}
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		pages := paginate(t, &QueryList{Label: "shipment", Labels: []string{"invoice"}, Limit: 4, SortDescending: true})
+		want := [][]string{
+			{"invoice#I2", "shipment#S3", "shipment#S2", "shipment#S1"},
+			{"invoice#I1", "invoice#I0", "shipment#S0"},
+		}
+		if fmt.Sprint(pages) != fmt.Sprint(want) {
+			t.Errorf("Expected pages %v, got %v", want, pages)
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		q := &QueryList{Labels: []string{"shipment", "invoice"}, Limit: 2}
+		_, startKey, err := table.QueryUnion(ctx, client, q)
+		if err != nil {
+			t.Fatalf("Failed to query union: %v", err)
+		}
+
+		paginator := table.Paginator(client)
+		cursor, err := MarshalStartKey(ctx, paginator, startKey)
+		if err != nil {
+			t.Fatalf("Failed to marshal start key: %v", err)
+		}
+		if q.StartKey, err = UnmarshalStartKey(ctx, paginator, cursor); err != nil {
+			t.Fatalf("Failed to unmarshal start key: %v", err)
+		}
+
+		items, _, err := table.QueryUnion(ctx, client, q)
+		if err != nil {
+			t.Fatalf("Failed to query union: %v", err)
+		}
+		if got := targets(items); !slices.Equal(got, []string{"invoice#I1", "shipment#S1"}) {
+			t.Errorf("Expected second page from cursor, got %v", got)
+		}
+	})
+}
+
+func TestQueryUnionRefIndexHashAttribute(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.RefIndexHashAttribute = "gsi1_pk"
+
+	opts := NewMarshalOptions(table.MarshalOptions)
+	client := &pagedLabelClient{mockDynamoDBClient: newMockDynamoDBClient(), opts: &opts}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		if err := table.Put(ctx, client, &shipment{ID: fmt.Sprintf("S%d", i), Created: created.Add(time.Duration(2*i) * time.Hour)}); err != nil {
+			t.Fatalf("Failed to put shipment: %v", err)
+		}
+		if err := table.Put(ctx, client, &invoice{ID: fmt.Sprintf("I%d", i), Created: created.Add(time.Duration(2*i+1) * time.Hour)}); err != nil {
+			t.Fatalf("Failed to put invoice: %v", err)
+		}
+	}
+
+	var (
+		count int
+		q     = &QueryList{Labels: []string{"shipment", "invoice"}, Limit: 3}
+	)
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("Expected pagination to end")
+		}
+
+		items, startKey, err := table.QueryUnion(ctx, client, q)
+		if err != nil {
+			t.Fatalf("Failed to query union: %v", err)
+		}
+		count += len(items)
+
+		if startKey == nil {
+			break
+		}
+		for label, position := range startKey {
+			if m, ok := position.(*types.AttributeValueMemberM); ok {
+				if _, ok := m.Value["gsi1_pk"]; !ok {
+					t.Errorf("Expected the start key of %s to hold gsi1_pk, got %v", label, m.Value)
+				}
+			}
+		}
+		q.StartKey = startKey
+	}
+
+	if count != 8 {
+		t.Errorf("Expected 8 items, got %d", count)
+	}
+}
@@ -0,0 +1,257 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UniquePrefix is the key prefix and label of unique constraint marker items.
+const UniquePrefix = "unique"
+
+// ErrUniqueConstraintViolation is returned when a unique value is already taken by
+// another entity.
+var ErrUniqueConstraintViolation = newError(ErrConditionFailed, "unique constraint violation")
+
+// UniqueConstraintError is returned when saving an entity would duplicate a unique value.
+// It matches [ErrUniqueConstraintViolation] with [errors.Is].
+type UniqueConstraintError struct {
+	Field string // The unique field
+	Value string // The value that is already taken
+	Err   error  // The underlying error
+}
+
+// Error implements the error interface.
+func (e *UniqueConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s %q is taken", ErrUniqueConstraintViolation, e.Field, e.Value)
+}
+
+// Unwrap returns the underlying error.
+func (e *UniqueConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is [ErrUniqueConstraintViolation] or its category,
+// [ErrConditionFailed].
+func (e *UniqueConstraintError) Is(target error) bool {
+	return target == ErrUniqueConstraintViolation || target == ErrConditionFailed
+}
+
+// UniqueMarshaler is a Marshaler with fields whose values must be unique across the table.
+type UniqueMarshaler interface {
+	Marshaler
+	// UniqueFields returns the unique values of the entity keyed by field name. Field names
+	// are shared by all entity types, so qualify them if needed (e.g. "customer.email").
+	// Empty values are not constrained.
+	UniqueFields() map[string]string
+}
+
+// uniqueMarker is the item that reserves a unique value for its owner.
+type uniqueMarker struct {
+	Field string `dynamodbav:"field"`
+	Value string `dynamodbav:"value"`
+	Owner string `dynamodbav:"owner"`
+}
+
+// MarshalSelf implements [Marshaler].
+func (m *uniqueMarker) MarshalSelf(opts *MarshalOptions) error {
+	opts.WithSelfTarget(UniquePrefix, m.Field+opts.KeyDelimiter+m.Value)
+	return nil
+}
+
+// Uniqueness enforces unique fields by reserving each value with a marker item, such as
+// "unique#email#jane@example.com", written in the same transaction as the entity.
+//
+// Example:
+//
+//	unique := dynamap.NewUniqueness(table)
+//	err := unique.Save(ctx, client, customer, previous)
+//	if errors.Is(err, dynamap.ErrUniqueConstraintViolation) {
+//		// email is taken
+//	}
+type Uniqueness struct {
+	Table *Table // The table holding the entities and markers
+}
+
+// NewUniqueness creates a new [Uniqueness] for the table.
+func NewUniqueness(table *Table) *Uniqueness {
+	return &Uniqueness{Table: table}
+}
+
+// MarshalSave marshals the entity into a transaction that writes its items together with
+// a conditional put for each new unique value. Pass the previously saved version of the
+// entity as previous, or nil when creating it, so markers of changed values are deleted.
+func (u *Uniqueness) MarshalSave(in UniqueMarshaler, previous UniqueMarshaler, opts ...func(*MarshalOptions)) (*dynamodb.TransactWriteItemsInput, error) {
+	input, err := u.Table.MarshalTransactWrite(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, _, err := UnmarshalTableKey(input.TransactItems[0].Put.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	current, old := in.UniqueFields(), map[string]string{}
+	if previous != nil {
+		old = previous.UniqueFields()
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeNotExists(expression.Name(AttributeNameSource))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(current)) {
+		value := current[field]
+		if value == "" || old[field] == value {
+			continue
+		}
+
+		put, err := u.marshalMarker(&uniqueMarker{Field: field, Value: value, Owner: owner}, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		input.TransactItems = append(input.TransactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:                 put.TableName,
+				Item:                      put.Item,
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(old)) {
+		value := old[field]
+		if value == "" || current[field] == value {
+			continue
+		}
+
+		del, err := u.Table.MarshalDelete(&uniqueMarker{Field: field, Value: value}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		input.TransactItems = append(input.TransactItems, types.TransactWriteItem{
+			Delete: &types.Delete{TableName: del.TableName, Key: del.Key},
+		})
+	}
+
+	if len(input.TransactItems) > MaxTransactSize {
+		return nil, fmt.Errorf("%w: %d actions", ErrTransactionTooLarge, len(input.TransactItems))
+	}
+
+	return input, nil
+}
+
+// Save marshals the entity using [Uniqueness.MarshalSave] and executes the transaction.
+// If a unique value is taken, a [*UniqueConstraintError] is returned. If a target required
+// with [RefOptions.RequireTarget] does not exist, [ErrTargetNotFound] is returned.
+func (u *Uniqueness) Save(ctx context.Context, client DynamoDBClient, in UniqueMarshaler, previous UniqueMarshaler, opts ...func(*MarshalOptions)) error {
+	input, err := u.MarshalSave(in, previous, opts...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := transactWriteItems(ctx, client, input); err != nil {
+		if key, ok := failedConditionCheck(input, err); ok {
+			return fmt.Errorf("%w: %s", ErrTargetNotFound, key)
+		}
+		return u.uniqueViolation(input, fmt.Errorf("failed to save entity: %w", classify(err)))
+	}
+
+	return nil
+}
+
+// MarshalDelete marshals a transaction that deletes the entity's self relationship along
+// with the markers of its unique values.
+func (u *Uniqueness) MarshalDelete(in UniqueMarshaler, opts ...func(*MarshalOptions)) (*dynamodb.TransactWriteItemsInput, error) {
+	del, err := u.Table.MarshalDelete(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []types.TransactWriteItem{
+		{Delete: &types.Delete{TableName: del.TableName, Key: del.Key}},
+	}
+
+	fields := in.UniqueFields()
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		if fields[field] == "" {
+			continue
+		}
+
+		del, err := u.Table.MarshalDelete(&uniqueMarker{Field: field, Value: fields[field]}, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, types.TransactWriteItem{
+			Delete: &types.Delete{TableName: del.TableName, Key: del.Key},
+		})
+	}
+
+	return &dynamodb.TransactWriteItemsInput{TransactItems: actions}, nil
+}
+
+// Delete marshals the entity using [Uniqueness.MarshalDelete] and executes the transaction.
+func (u *Uniqueness) Delete(ctx context.Context, client DynamoDBClient, in UniqueMarshaler, opts ...func(*MarshalOptions)) error {
+	input, err := u.MarshalDelete(in, opts...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := transactWriteItems(ctx, client, input); err != nil {
+		return fmt.Errorf("failed to delete entity: %w", classify(err))
+	}
+
+	return nil
+}
+
+// marshalMarker marshals the marker into a put request. Markers are not validated against
+// the table's registry.
+func (u *Uniqueness) marshalMarker(marker *uniqueMarker, opts []func(*MarshalOptions)) (*dynamodb.PutItemInput, error) {
+	return u.Table.MarshalPut(marker, func(mo *MarshalOptions) {
+		mo.apply(opts)
+		mo.Registry = nil
+	})
+}
+
+// uniqueViolation translates a transaction canceled by a failed marker put into a
+// [*UniqueConstraintError]. Other errors are returned unchanged.
+func (u *Uniqueness) uniqueViolation(transaction *dynamodb.TransactWriteItemsInput, err error) error {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return err
+	}
+
+	for i, reason := range canceled.CancellationReasons {
+		if i >= len(transaction.TransactItems) || aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+			continue
+		}
+
+		put := transaction.TransactItems[i].Put
+		if put == nil {
+			continue
+		}
+
+		var marker uniqueMarker
+		if _, uerr := UnmarshalSelf(put.Item, &marker, u.Table.MarshalOptions); uerr == nil && marker.Field != "" {
+			return &UniqueConstraintError{Field: marker.Field, Value: marker.Value, Err: err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// uniqueClient cancels transactions with conditional puts of items that already exist.
+type uniqueClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *uniqueClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var (
+		reasons  []types.CancellationReason
+		canceled bool
+	)
+
+	for _, action := range params.TransactItems {
+		reason := types.CancellationReason{Code: aws.String("None")}
+		if put := action.Put; put != nil && put.ConditionExpression != nil {
+			hk := put.Item["hk"].(*types.AttributeValueMemberS).Value
+			sk := put.Item["sk"].(*types.AttributeValueMemberS).Value
+			if _, ok := c.items[hk+"#"+sk]; ok {
+				reason.Code = aws.String("ConditionalCheckFailed")
+				canceled = true
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	return c.mockDynamoDBClient.TransactWriteItems(ctx, params, optFns...)
+}
+
+func (c *customer) UniqueFields() map[string]string {
+	return map[string]string{"customer.email": c.Email}
+}
+
+// Tests for unique constraints
+
+func TestUniqueness(t *testing.T) {
+	ctx := context.Background()
+	table := newIndexedTable()
+	unique := NewUniqueness(table)
+	client := &uniqueClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+	jane := &customer{ID: "C1", Email: "jane@example.com"}
+	if err := unique.Save(ctx, client, jane, nil); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if _, ok := client.items["unique#customer.email#jane@example.com#unique#customer.email#jane@example.com"]; !ok {
+		t.Fatal("Expected unique marker for jane@example.com")
+	}
+
+	t.Run("resave unchanged", func(t *testing.T) {
+		if err := unique.Save(ctx, client, jane, jane); err != nil {
+			t.Errorf("Expected resave to succeed, got %v", err)
+		}
+	})
+
+	t.Run("violation", func(t *testing.T) {
+		err := unique.Save(ctx, client, &customer{ID: "C2", Email: "jane@example.com"}, nil)
+		if !errors.Is(err, ErrUniqueConstraintViolation) {
+			t.Fatalf("Expected ErrUniqueConstraintViolation, got %v", err)
+		}
+
+		var violation *UniqueConstraintError
+		if !errors.As(err, &violation) || violation.Field != "customer.email" {
+			t.Errorf("Expected violation on customer.email, got %v", err)
+		}
+		if _, ok := client.items["customer#C2#customer#C2"]; ok {
+			t.Error("Expected C2 not to be written")
+		}
+	})
+
+	t.Run("change moves marker", func(t *testing.T) {
+		changed := &customer{ID: "C1", Email: "jane@work.com"}
+		input, err := unique.MarshalSave(changed, jane)
+		if err != nil {
+			t.Fatalf("Failed to marshal save: %v", err)
+		}
+
+		last := input.TransactItems[len(input.TransactItems)-1]
+		if last.Delete == nil {
+			t.Fatal("Expected old marker to be deleted")
+		}
+		if hk := last.Delete.Key["hk"].(*types.AttributeValueMemberS).Value; hk != "unique#customer.email#jane@example.com" {
+			t.Errorf("Expected delete of old marker, got %s", hk)
+		}
+
+		if _, err := client.TransactWriteItems(ctx, input); err != nil {
+			t.Fatalf("Failed to save: %v", err)
+		}
+		if err := unique.Save(ctx, client, &customer{ID: "C2", Email: "jane@example.com"}, nil); err != nil {
+			t.Errorf("Expected released email to be available, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := unique.Delete(ctx, client, &customer{ID: "C1", Email: "jane@work.com"}); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		if _, ok := client.items["unique#customer.email#jane@work.com#unique#customer.email#jane@work.com"]; ok {
+			t.Error("Expected marker to be deleted")
+		}
+		if _, ok := client.items["customer#C1#customer#C1"]; ok {
+			t.Error("Expected entity to be deleted")
+		}
+	})
+}
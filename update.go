@@ -0,0 +1,211 @@
+package dynamap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UpdaterFunc is a function adapter for the [Updater] interface.
+type UpdaterFunc func(base expression.UpdateBuilder) expression.UpdateBuilder
+
+// UpdateRelationship implements [Updater] by calling f.
+func (f UpdaterFunc) UpdateRelationship(base expression.UpdateBuilder) expression.UpdateBuilder {
+	return f(base)
+}
+
+// UpdaterChain is an [Updater] that applies each of its updaters in order.
+//
+// Example:
+//
+//	updater := dynamap.UpdaterChain{
+//		dynamap.Increment("views", 1),
+//		dynamap.AddToSet("tags", "sale"),
+//		dynamap.RemoveField("draft"),
+//	}
+//	input, err := table.MarshalUpdate(product, updater)
+type UpdaterChain []Updater
+
+// UpdateRelationship implements [Updater] by applying each updater in the chain.
+// Nil updaters are skipped.
+func (c UpdaterChain) UpdateRelationship(base expression.UpdateBuilder) expression.UpdateBuilder {
+	for _, updater := range c {
+		if updater != nil {
+			base = updater.UpdateRelationship(base)
+		}
+	}
+	return base
+}
+
+// Increment creates an [Updater] that atomically adds n to the numeric data attribute.
+// A missing attribute is treated as zero; use a negative n to decrement.
+func Increment(dataField string, n int) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Add(DataAttribute(dataField), expression.Value(n))
+	})
+}
+
+// AppendToList creates an [Updater] that appends values to the end of the list data
+// attribute. A missing attribute is treated as an empty list.
+func AppendToList(dataField string, values ...any) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		name := DataAttribute(dataField)
+		return base.Set(name, expression.ListAppend(
+			name.IfNotExists(expression.Value([]any{})),
+			expression.Value(values),
+		))
+	})
+}
+
+// SetElement is the set of types that can be stored in a DynamoDB set.
+type SetElement interface {
+	string | int | int64 | float64 | []byte
+}
+
+// AddToSet creates an [Updater] that adds values to the set data attribute. A missing
+// attribute is created. Strings, numbers and binary values are stored as string,
+// number and binary sets, respectively. Without values the update is left unchanged,
+// since DynamoDB rejects empty sets.
+func AddToSet[T SetElement](dataField string, values ...T) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		if len(values) == 0 {
+			return base
+		}
+		return base.Add(DataAttribute(dataField), expression.Value(setValue(values)))
+	})
+}
+
+// RemoveField creates an [Updater] that removes the data attribute.
+func RemoveField(dataField string) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		return base.Remove(DataAttribute(dataField))
+	})
+}
+
+// SetIfNotExists creates an [Updater] that sets the data attribute to value only if the
+// attribute does not already exist.
+func SetIfNotExists(dataField string, value any) Updater {
+	return UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+		name := DataAttribute(dataField)
+		return base.Set(name, name.IfNotExists(expression.Value(value)))
+	})
+}
+
+// setValue converts values into a DynamoDB set attribute value.
+func setValue[T SetElement](values []T) types.AttributeValue {
+	var (
+		ss []string
+		ns []string
+		bs [][]byte
+	)
+
+	for _, value := range values {
+		switch v := any(value).(type) {
+		case string:
+			ss = append(ss, v)
+		case int:
+			ns = append(ns, strconv.Itoa(v))
+		case int64:
+			ns = append(ns, strconv.FormatInt(v, 10))
+		case float64:
+			ns = append(ns, strconv.FormatFloat(v, 'f', -1, 64))
+		case []byte:
+			bs = append(bs, v)
+		}
+	}
+
+	switch {
+	case len(ns) > 0:
+		return &types.AttributeValueMemberNS{Value: ns}
+	case len(bs) > 0:
+		return &types.AttributeValueMemberBS{Value: bs}
+	default:
+		return &types.AttributeValueMemberSS{Value: ss}
+	}
+}
+
+// ConditionalUpdater is an [Updater] that also conditions the update. If the condition
+// is not met, the update is rejected by DynamoDB.
+type ConditionalUpdater interface {
+	Updater
+	// UpdateCondition returns the condition that must hold for the update to succeed.
+	UpdateCondition() expression.ConditionBuilder
+}
+
+type conditionalUpdater struct {
+	Updater
+	condition expression.ConditionBuilder
+}
+
+func (c conditionalUpdater) UpdateCondition() expression.ConditionBuilder {
+	return c.condition
+}
+
+// UpdateWithCondition wraps updater so the update is only applied if condition holds.
+//
+// Example:
+//
+//	updater := dynamap.UpdateWithCondition(
+//		dynamap.Increment("stock", -1),
+//		dynamap.DataAttribute("stock").GreaterThan(expression.Value(0)),
+//	)
+//	_, err := table.Update(ctx, client, product, updater)
+//	if errors.Is(err, dynamap.ErrConditionFailed) {
+//		// out of stock
+//	}
+func UpdateWithCondition(updater Updater, condition expression.ConditionBuilder) ConditionalUpdater {
+	return conditionalUpdater{Updater: updater, condition: condition}
+}
+
+// Update marshals the input using [Table.MarshalUpdate] and executes the request. If the
+// update is rejected because its condition was not met, a [*ConditionFailedError] with
+// the entity key is returned.
+//
+// If [Table.History] is set, a revision of the updated item is written once the update
+// succeeds. Since the revision needs the new item, updates returning the updated
+// attributes return the entire new item instead, and updates returning old values are
+// rejected.
+func (t *Table) Update(ctx context.Context, client DynamoDBClient, in Marshaler, updater Updater, opts ...func(*MarshalOptions)) (*dynamodb.UpdateItemOutput, error) {
+	client = t.client(client)
+	opts = contextOptions(ctx, opts)
+	input, err := t.MarshalUpdate(in, updater, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := false
+	if t.History {
+		switch input.ReturnValues {
+		case types.ReturnValueNone:
+			requested = true
+		case types.ReturnValueUpdatedNew, types.ReturnValueAllNew:
+		default:
+			return nil, fmt.Errorf("history requires updates returning new values, got %s", input.ReturnValues)
+		}
+		input.ReturnValues = types.ReturnValueAllNew
+	}
+
+	output, err := client.UpdateItem(ctx, input)
+	if err != nil {
+		return nil, conditionFailed(input.Key, fmt.Errorf("failed to update item: %w", classify(err)))
+	}
+
+	if t.History {
+		marshalOpts := NewMarshalOptions(func(mo *MarshalOptions) {
+			t.MarshalOptions(mo)
+			mo.apply(opts)
+		})
+		if err := t.putRevision(ctx, client, marshalOpts, output.Attributes); err != nil {
+			return nil, err
+		}
+		if requested {
+			output.Attributes = nil
+		}
+	}
+
+	return output, nil
+}
@@ -0,0 +1,197 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// findValue returns the first expression attribute value of type T.
+func findValue[T types.AttributeValue](values map[string]types.AttributeValue) (T, bool) {
+	for _, value := range values {
+		if v, ok := value.(T); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Tests for prebuilt updaters
+
+func TestUpdaters(t *testing.T) {
+	table := NewTable("test-table")
+	product := &Product{ID: "P1", Category: "electronics"}
+
+	marshal := func(t *testing.T, updater Updater) (string, map[string]types.AttributeValue) {
+		t.Helper()
+		input, err := table.MarshalUpdate(product, updater)
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		return aws.ToString(input.UpdateExpression), input.ExpressionAttributeValues
+	}
+
+	t.Run("increment", func(t *testing.T) {
+		expr, values := marshal(t, Increment("views", 2))
+		if !strings.Contains(expr, "ADD ") {
+			t.Errorf("Expected ADD action, got %s", expr)
+		}
+		if n, ok := findValue[*types.AttributeValueMemberN](values); !ok || n.Value != "2" {
+			t.Errorf("Expected increment 2, got %#v", n)
+		}
+	})
+
+	t.Run("append to list", func(t *testing.T) {
+		expr, values := marshal(t, AppendToList("history", "a", "b"))
+		if !strings.Contains(expr, "list_append(if_not_exists(") {
+			t.Errorf("Expected list_append with if_not_exists, got %s", expr)
+		}
+		appended := false
+		for _, value := range values {
+			if list, ok := value.(*types.AttributeValueMemberL); ok && len(list.Value) == 2 {
+				appended = true
+			}
+		}
+		if !appended {
+			t.Errorf("Expected 2 appended values, got %#v", values)
+		}
+	})
+
+	t.Run("add to string set", func(t *testing.T) {
+		expr, values := marshal(t, AddToSet("tags", "sale", "new"))
+		if !strings.Contains(expr, "ADD ") {
+			t.Errorf("Expected ADD action, got %s", expr)
+		}
+		if set, ok := findValue[*types.AttributeValueMemberSS](values); !ok || len(set.Value) != 2 {
+			t.Errorf("Expected string set with 2 values, got %#v", values)
+		}
+	})
+
+	t.Run("add to number set", func(t *testing.T) {
+		_, values := marshal(t, AddToSet("sizes", 1.5, 2))
+		if set, ok := findValue[*types.AttributeValueMemberNS](values); !ok || set.Value[0] != "1.5" {
+			t.Errorf("Expected number set, got %#v", values)
+		}
+	})
+
+	t.Run("add to binary set", func(t *testing.T) {
+		_, values := marshal(t, AddToSet("blobs", []byte("a")))
+		if _, ok := findValue[*types.AttributeValueMemberBS](values); !ok {
+			t.Errorf("Expected binary set, got %#v", values)
+		}
+	})
+
+	t.Run("add no values to set", func(t *testing.T) {
+		expr, values := marshal(t, AddToSet[string]("tags"))
+		if strings.Contains(expr, "ADD ") {
+			t.Errorf("Expected no ADD action, got %s", expr)
+		}
+		if set, ok := findValue[*types.AttributeValueMemberSS](values); ok {
+			t.Errorf("Expected no string set, got %#v", set)
+		}
+	})
+
+	t.Run("remove field", func(t *testing.T) {
+		expr, _ := marshal(t, RemoveField("draft"))
+		if !strings.Contains(expr, "REMOVE ") {
+			t.Errorf("Expected REMOVE action, got %s", expr)
+		}
+	})
+
+	t.Run("set if not exists", func(t *testing.T) {
+		expr, _ := marshal(t, SetIfNotExists("first_seen", "2024-01-01"))
+		if !strings.Contains(expr, "if_not_exists(") {
+			t.Errorf("Expected if_not_exists, got %s", expr)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		expr, _ := marshal(t, UpdaterChain{
+			Increment("views", 1),
+			nil,
+			RemoveField("draft"),
+			UpdaterFunc(func(base expression.UpdateBuilder) expression.UpdateBuilder {
+				return base.Set(DataAttribute("category"), expression.Value("books"))
+			}),
+		})
+		for _, action := range []string{"SET ", "ADD ", "REMOVE "} {
+			if !strings.Contains(expr, action) {
+				t.Errorf("Expected %s action, got %s", action, expr)
+			}
+		}
+	})
+}
+
+// conditionFailedClient rejects every update with a conditional check failure.
+type conditionFailedClient struct {
+	*mockDynamoDBClient
+}
+
+func (c *conditionFailedClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+}
+
+func TestTableUpdateWithCondition(t *testing.T) {
+	table := NewTable("test-table")
+	ctx := context.Background()
+	product := &Product{ID: "P1"}
+	updater := UpdateWithCondition(
+		Increment("stock", -1),
+		DataAttribute("stock").GreaterThan(expression.Value(0)),
+	)
+
+	t.Run("marshals condition", func(t *testing.T) {
+		input, err := table.MarshalUpdate(product, updater)
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if !strings.Contains(aws.ToString(input.ConditionExpression), ">") {
+			t.Errorf("Expected condition expression, got %q", aws.ToString(input.ConditionExpression))
+		}
+	})
+
+	t.Run("unconditional update has no condition", func(t *testing.T) {
+		input, err := table.MarshalUpdate(product, Increment("stock", -1))
+		if err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if input.ConditionExpression != nil {
+			t.Errorf("Expected no condition expression, got %q", aws.ToString(input.ConditionExpression))
+		}
+	})
+
+	t.Run("executes update", func(t *testing.T) {
+		if _, err := table.Update(ctx, newMockDynamoDBClient(), product, updater); err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+	})
+
+	t.Run("condition failed", func(t *testing.T) {
+		client := &conditionFailedClient{mockDynamoDBClient: newMockDynamoDBClient()}
+
+		_, err := table.Update(ctx, client, product, updater)
+		if !errors.Is(err, ErrConditionFailed) {
+			t.Fatalf("Expected ErrConditionFailed, got %v", err)
+		}
+
+		var conditionErr *ConditionFailedError
+		if !errors.As(err, &conditionErr) {
+			t.Fatalf("Expected *ConditionFailedError, got %T", err)
+		}
+		if conditionErr.Source != "product#P1" || conditionErr.Target != "product#P1" {
+			t.Errorf("Expected key 'product#P1', got %s %s", conditionErr.Source, conditionErr.Target)
+		}
+
+		var ccf *types.ConditionalCheckFailedException
+		if !errors.As(err, &ccf) {
+			t.Error("Expected underlying ConditionalCheckFailedException")
+		}
+	})
+}
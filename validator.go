@@ -0,0 +1,84 @@
+package dynamap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validator checks an entity before it is written, returning an error if it is invalid.
+// When set on a [Table] or passed via [MarshalOptions], it is called with each entity
+// marshaled by [MarshalRelationships], such as by [Table.Put] and [Table.MarshalBatch],
+// and with the [Updater] of [Table.MarshalUpdate], so invalid entities are rejected before
+// any request is sent. Validators should return nil for values they do not check.
+//
+// Errors returned by the validator are wrapped in an [*InvalidEntityError]. Return
+// [FieldErrors] to report which fields are invalid; errors of validation libraries, such
+// as the ValidationErrors of go-playground/validator, remain available with [errors.As].
+//
+// Example:
+//
+//	table.Validator = func(entity any) error {
+//		if user, ok := entity.(*User); ok && !strings.Contains(user.Email, "@") {
+//			return dynamap.FieldErrors{{Field: "email", Message: "must be an email address"}}
+//		}
+//		return nil
+//	}
+type Validator func(entity any) error
+
+// FieldError describes an invalid field of an entity.
+type FieldError struct {
+	Field   string // The name of the invalid field
+	Message string // Why the field is invalid
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors is a list of invalid fields returned by a [Validator].
+type FieldErrors []FieldError
+
+// Error implements the error interface.
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, field := range e {
+		messages[i] = field.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// InvalidEntityError is returned when a [Validator] rejects an entity. It matches
+// [ErrValidation] with [errors.Is].
+type InvalidEntityError struct {
+	Source string // The hash key of the rejected entity
+	Target string // The sort key of the rejected entity
+	Err    error  // The error returned by the validator
+}
+
+// Error implements the error interface.
+func (e *InvalidEntityError) Error() string {
+	return fmt.Sprintf("invalid entity %s %s: %v", e.Source, e.Target, e.Err)
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *InvalidEntityError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is [ErrValidation].
+func (e *InvalidEntityError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validateEntity calls the validator of the options with entity, if one is set. The
+// options must hold the key of the entity.
+func (mo MarshalOptions) validateEntity(entity any) error {
+	if mo.Validator == nil {
+		return nil
+	}
+	if err := mo.Validator(entity); err != nil {
+		return &InvalidEntityError{Source: mo.sourceKey(), Target: mo.targetKey(), Err: err}
+	}
+	return nil
+}
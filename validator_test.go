@@ -0,0 +1,76 @@
+package dynamap
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Tests for entity validators
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("test-table")
+	table.Validator = func(entity any) error {
+		if product, ok := entity.(*Product); ok && product.Category == "" {
+			return FieldErrors{{Field: "category", Message: "is required"}}
+		}
+		return nil
+	}
+
+	t.Run("put rejects invalid entities", func(t *testing.T) {
+		client := newMockDynamoDBClient()
+		err := table.Put(ctx, client, &Product{ID: "P1"})
+
+		var invalid *InvalidEntityError
+		if !errors.As(err, &invalid) || invalid.Source != "product#P1" {
+			t.Fatalf("Expected an invalid entity error, got %v", err)
+		}
+		var fields FieldErrors
+		if !errors.Is(err, ErrValidation) || !errors.As(err, &fields) || fields[0].Field != "category" {
+			t.Errorf("Expected the field errors, got %v", err)
+		}
+		if len(client.items) != 0 {
+			t.Errorf("Expected nothing to be written, got %v", client.items)
+		}
+
+		if err := table.Put(ctx, client, &Product{ID: "P1", Category: "books"}); err != nil {
+			t.Errorf("Expected a valid product to be written, got %v", err)
+		}
+	})
+
+	t.Run("batch validates the entity", func(t *testing.T) {
+		order := &Order{ID: "O1", Products: []Product{{ID: "P1"}}}
+		if _, err := table.MarshalBatch(order); err != nil {
+			t.Fatalf("Expected refs not to be validated, got %v", err)
+		}
+
+		table := NewTable("test-table")
+		table.Validator = func(entity any) error {
+			if _, ok := entity.(*Order); ok {
+				return errors.New("orders are closed")
+			}
+			return nil
+		}
+		if _, err := table.MarshalBatch(order); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
+
+	t.Run("updates validate the updater", func(t *testing.T) {
+		var validated any
+		table := NewTable("test-table")
+		table.Validator = func(entity any) error {
+			validated = entity
+			return nil
+		}
+
+		updater := Increment("stock", 1)
+		if _, err := table.MarshalUpdate(&Product{ID: "P1"}, updater); err != nil {
+			t.Fatalf("Failed to marshal update: %v", err)
+		}
+		if validated == nil {
+			t.Error("Expected the updater to be validated")
+		}
+	})
+}
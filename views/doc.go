@@ -0,0 +1,37 @@
+// Package views maintains materialized views of dynamap tables: items derived from the
+// entities and relationships of the table, such as orders grouped by customer, that
+// answer queries the table's keys and indexes cannot.
+//
+// A [View] selects source items by entity prefix or relationship name and maps each of
+// them to [Row] values. Rows are written to partitions of the view whose hash keys are
+// "<view>#<key>", one row per source item, and are listed in [Row.Sort] order by
+// [Maintainer.Rows]. A view with a Reduce function also keeps a rollup of each partition,
+// such as an order count or total, as the self item of the partition:
+//
+//	ordersByCustomer := views.View{
+//		Name:   "orders-by-customer",
+//		Labels: []string{"order"},
+//		Map: func(src views.Source) ([]views.Row, error) {
+//			var order Order
+//			if err := src.Unmarshal(&order); err != nil {
+//				return nil, err
+//			}
+//			return []views.Row{{Key: order.CustomerID, Sort: order.PlacedAt.Format(time.RFC3339), Data: order}}, nil
+//		},
+//		Reduce: func(key string, rows []views.Row) (any, error) {
+//			return map[string]int{"orders": len(rows)}, nil
+//		},
+//	}
+//
+//	maintainer := views.NewMaintainer(table, client, ordersByCustomer)
+//
+// Views are maintained incrementally from stream records, either directly or through a
+// [cdc.Processor], and can be rebuilt from a scan of the table:
+//
+//	err := maintainer.ProcessRecords(ctx, records)
+//	maintainer.Attach(processor)
+//	report, err := maintainer.Rebuild(ctx, client)
+//
+// Every write is idempotent: rows are put and deleted by key, and rollups are recomputed
+// from the rows of their partition, so records may be processed more than once.
+package views
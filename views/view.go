@@ -0,0 +1,582 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/nisimpson/dynamap"
+	"github.com/nisimpson/dynamap/cdc"
+)
+
+const (
+	// RowPrefix is the key prefix of view rows, whose sort keys are "row#<source>".
+	RowPrefix = "row"
+	// RowsName is the relationship name of view rows, whose labels are
+	// "<view>/<key>/rows".
+	RowsName = "rows"
+)
+
+// View defines the rows derived from the items of a table.
+type View struct {
+	Name string // The name of the view; the key prefix of its partitions
+
+	// Labels selects the source items by the entity prefix of self relationships, such as
+	// "order", or the name of other relationships, such as "products". If empty, every
+	// self and ref relationship is a source. Soft deleted relationships are never sources.
+	Labels []string
+
+	// Filter optionally rejects source items; sources for which it returns false map to
+	// no rows.
+	Filter func(src Source) bool
+
+	// Map returns the rows derived from a source item. A source has at most one row per
+	// view key; later rows with the same key replace earlier ones.
+	Map func(src Source) ([]Row, error)
+
+	// Reduce optionally computes the rollup of a view partition from its rows. Rollups
+	// are recomputed whenever the rows of their partition change, and deleted once the
+	// partition has no rows.
+	Reduce func(key string, rows []Row) (any, error)
+}
+
+// matches returns true if the relationship is a source of the view.
+func (v *View) matches(rel dynamap.Relationship, opts func(*dynamap.MarshalOptions)) bool {
+	if rel.Deleted() {
+		return false
+	}
+
+	var label string
+	switch {
+	case rel.IsSelf():
+		prefix, _, err := dynamap.ParseKey(rel.Source, opts)
+		if err != nil {
+			return false
+		}
+		label = prefix
+	case rel.IsRef():
+		_, _, name, err := dynamap.ParseLabel(rel.Label, opts)
+		if err != nil {
+			return false
+		}
+		label = name
+	default:
+		return false
+	}
+
+	return len(v.Labels) == 0 || slices.Contains(v.Labels, label)
+}
+
+// Source is an item read by a view.
+type Source struct {
+	Relationship dynamap.Relationship // The source relationship
+	Item         dynamap.Item         // The raw source item
+
+	table *dynamap.Table
+}
+
+// Unmarshal unmarshals the data of the source item into out using the table's options.
+func (s Source) Unmarshal(out any) error {
+	_, err := dynamap.UnmarshalSelf(s.Item, out, s.table.MarshalOptions)
+	return err
+}
+
+// Row is an item of a view, derived from a source item.
+type Row struct {
+	Key    string // The view partition holding the row, such as a customer id
+	Sort   string // Optional order of the row within its partition and on the rows label
+	Data   any    // The row data
+	Source string // The key of the source item; set when rows are read
+}
+
+// Decode unmarshals the row data into out. Data of rows read from the table are generic
+// maps; use this to convert them back into their original type.
+func (r Row) Decode(out any) error {
+	av, err := attributevalue.Marshal(r.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal row data: %w", err)
+	}
+	if err := attributevalue.Unmarshal(av, out); err != nil {
+		return fmt.Errorf("failed to unmarshal row data: %w", err)
+	}
+	return nil
+}
+
+// rowItem is the item of a row.
+type rowItem struct {
+	view   string
+	source string
+	row    Row
+}
+
+// MarshalSelf implements dynamap.Marshaler.
+func (r *rowItem) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSource(r.view, r.row.Key)
+	opts.WithTarget(RowPrefix, r.source)
+	opts.Label = labelCodec(opts).EncodeLabel(r.view, r.row.Key, RowsName)
+	opts.RefSortKey = r.row.Sort
+	opts.Registry = nil // Rows are not registered entities
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue writes the row data as the data of the item.
+func (r *rowItem) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return attributevalue.Marshal(r.row.Data)
+}
+
+// rollupItem is the self item of a view partition, holding its rollup.
+type rollupItem struct {
+	view string
+	key  string
+	data any
+}
+
+// MarshalSelf implements dynamap.Marshaler.
+func (r *rollupItem) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget(r.view, r.key)
+	opts.Registry = nil // Rollups are not registered entities
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue writes the rollup as the data of the item.
+func (r *rollupItem) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return attributevalue.Marshal(r.data)
+}
+
+// labelCodec returns the label codec of the options.
+func labelCodec(opts *dynamap.MarshalOptions) dynamap.LabelCodec {
+	if opts.LabelCodec != nil {
+		return opts.LabelCodec
+	}
+	return dynamap.DelimitedLabelCodec{Delimiter: opts.LabelDelimiter}
+}
+
+// sourceKey returns the key identifying the source relationship among the rows of a
+// partition.
+func sourceKey(rel dynamap.Relationship) string {
+	if rel.IsSelf() {
+		return rel.Source
+	}
+	return rel.Source + "/" + rel.Target
+}
+
+// Maintainer keeps the views of a table up to date.
+//
+// Example:
+//
+//	maintainer := views.NewMaintainer(table, client, ordersByCustomer)
+//	maintainer.Attach(processor)
+//
+//	rows, err := maintainer.Rows(ctx, "orders-by-customer", "C1")
+//	var summary OrderSummary
+//	err = maintainer.Rollup(ctx, "orders-by-customer", "C1", &summary)
+type Maintainer struct {
+	Table  *dynamap.Table         // The table holding the sources and the views
+	Client dynamap.DynamoDBClient // The client reading and writing the views
+	Views  []View                 // The maintained views
+}
+
+// NewMaintainer creates a new [Maintainer] of the views of the table.
+func NewMaintainer(table *dynamap.Table, client dynamap.DynamoDBClient, views ...View) *Maintainer {
+	return &Maintainer{Table: table, Client: client, Views: views}
+}
+
+// Attach maintains the views from the changes reported by the processor. Handlers
+// already set on the processor run before the views are updated.
+func (m *Maintainer) Attach(p *cdc.Processor) {
+	entity := func(next func(context.Context, cdc.EntityChange) error) func(context.Context, cdc.EntityChange) error {
+		return func(ctx context.Context, change cdc.EntityChange) error {
+			if next != nil {
+				if err := next(ctx, change); err != nil {
+					return err
+				}
+			}
+			return m.Apply(ctx, []dynamap.ChangeEvent{change.Event})
+		}
+	}
+	ref := func(next func(context.Context, cdc.RefChange) error) func(context.Context, cdc.RefChange) error {
+		return func(ctx context.Context, change cdc.RefChange) error {
+			if next != nil {
+				if err := next(ctx, change); err != nil {
+					return err
+				}
+			}
+			return m.Apply(ctx, []dynamap.ChangeEvent{change.Event})
+		}
+	}
+
+	p.OnEntityCreated = entity(p.OnEntityCreated)
+	p.OnEntityUpdated = entity(p.OnEntityUpdated)
+	p.OnEntityDeleted = entity(p.OnEntityDeleted)
+	p.OnRefAdded = ref(p.OnRefAdded)
+	p.OnRefUpdated = ref(p.OnRefUpdated)
+	p.OnRefRemoved = ref(p.OnRefRemoved)
+}
+
+// ProcessRecords decodes records read from the DynamoDB Streams API and applies them.
+func (m *Maintainer) ProcessRecords(ctx context.Context, records []streamtypes.Record) error {
+	events := make([]dynamap.ChangeEvent, 0, len(records))
+	for _, record := range records {
+		event, err := dynamap.UnmarshalRecord(record, m.Table.MarshalOptions)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal record: %w", err)
+		}
+		events = append(events, event)
+	}
+	return m.Apply(ctx, events)
+}
+
+// Apply updates the views with the changes, in order. The rows derived from the old
+// image of each changed item are replaced by the rows derived from its new image, then
+// the rollups of the changed partitions are recomputed. Changes to the items of the
+// views themselves are ignored.
+func (m *Maintainer) Apply(ctx context.Context, events []dynamap.ChangeEvent) error {
+	client := dynamap.NewClient(m.Table, m.Client)
+	touched := make([]keySet, len(m.Views))
+
+	for _, event := range events {
+		if m.isViewItem(event.Relationship) {
+			continue
+		}
+
+		for i := range m.Views {
+			view := &m.Views[i]
+
+			var oldRows, newRows []Row
+			var err error
+			if event.OldRelationship != nil {
+				if oldRows, err = m.mapRows(view, *event.OldRelationship, event.OldImage); err != nil {
+					return err
+				}
+			}
+			if event.NewImage != nil {
+				if newRows, err = m.mapRows(view, event.Relationship, event.NewImage); err != nil {
+					return err
+				}
+			}
+
+			source := sourceKey(event.Relationship)
+			if err := m.writeRows(ctx, client, view, source, oldRows, newRows); err != nil {
+				return err
+			}
+			for _, row := range slices.Concat(oldRows, newRows) {
+				touched[i].add(row.Key)
+			}
+		}
+	}
+
+	for i := range m.Views {
+		for _, key := range touched[i].keys {
+			if err := m.reduce(ctx, client, &m.Views[i], key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mapRows returns the rows of the view derived from the relationship, if it is a source
+// of the view.
+func (m *Maintainer) mapRows(view *View, rel dynamap.Relationship, item dynamap.Item) ([]Row, error) {
+	if !view.matches(rel, m.Table.MarshalOptions) {
+		return nil, nil
+	}
+
+	src := Source{Relationship: rel, Item: item, table: m.Table}
+	if view.Filter != nil && !view.Filter(src) {
+		return nil, nil
+	}
+
+	rows, err := view.Map(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s %s to view %s: %w", rel.Source, rel.Target, view.Name, err)
+	}
+	return rows, nil
+}
+
+// writeRows deletes the old rows of the source that are not replaced by its new rows,
+// and puts the new rows.
+func (m *Maintainer) writeRows(ctx context.Context, client dynamap.DynamoDBClient, view *View, source string, oldRows, newRows []Row) error {
+	for _, row := range oldRows {
+		if slices.ContainsFunc(newRows, func(r Row) bool { return r.Key == row.Key }) {
+			continue
+		}
+
+		input, err := m.Table.MarshalDelete(&rowItem{view: view.Name, source: source, row: row})
+		if err != nil {
+			return fmt.Errorf("failed to marshal row: %w", err)
+		}
+		if _, err := client.DeleteItem(ctx, input); err != nil {
+			return fmt.Errorf("failed to delete row: %w", err)
+		}
+	}
+
+	for _, row := range newRows {
+		if _, err := m.putRow(ctx, client, view, source, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// putRow writes the row, returning its item.
+func (m *Maintainer) putRow(ctx context.Context, client dynamap.DynamoDBClient, view *View, source string, row Row) (dynamap.Item, error) {
+	input, err := m.Table.MarshalPut(&rowItem{view: view.Name, source: source, row: row})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal row: %w", err)
+	}
+	if _, err := client.PutItem(ctx, input); err != nil {
+		return nil, fmt.Errorf("failed to put row: %w", err)
+	}
+	return input.Item, nil
+}
+
+// reduce recomputes the rollup of the view partition, deleting it if the partition has
+// no rows.
+func (m *Maintainer) reduce(ctx context.Context, client dynamap.DynamoDBClient, view *View, key string) error {
+	if view.Reduce == nil {
+		return nil
+	}
+
+	rows, err := m.Rows(ctx, view.Name, key)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		input, err := m.Table.MarshalDelete(&rollupItem{view: view.Name, key: key})
+		if err != nil {
+			return fmt.Errorf("failed to marshal rollup: %w", err)
+		}
+		if _, err := client.DeleteItem(ctx, input); err != nil {
+			return fmt.Errorf("failed to delete rollup: %w", err)
+		}
+		return nil
+	}
+
+	data, err := view.Reduce(key, rows)
+	if err != nil {
+		return fmt.Errorf("failed to reduce view %s partition %s: %w", view.Name, key, err)
+	}
+
+	input, err := m.Table.MarshalPut(&rollupItem{view: view.Name, key: key, data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollup: %w", err)
+	}
+	if _, err := client.PutItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to put rollup: %w", err)
+	}
+	return nil
+}
+
+// Rows reads the rows of the view partition with consistent reads, in [Row.Sort] order.
+func (m *Maintainer) Rows(ctx context.Context, view, key string) ([]Row, error) {
+	partition, err := m.Table.MarshalGet(&rowItem{view: view, row: Row{Key: key}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rows query: %w", err)
+	}
+
+	keyCondition := expression.Key(dynamap.AttributeNameSource).Equal(expression.Value(partition.Key[dynamap.AttributeNameSource])).
+		And(expression.Key(dynamap.AttributeNameTarget).BeginsWith(dynamap.StringValue(partition.Key[dynamap.AttributeNameTarget])))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 partition.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	var rows []Row
+	client := dynamap.NewClient(m.Table, m.Client)
+	for {
+		output, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query rows: %w", err)
+		}
+
+		for _, item := range output.Items {
+			var data any
+			rel, err := dynamap.UnmarshalSelf(item, &data, m.Table.MarshalOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal row: %w", err)
+			}
+			_, source, err := dynamap.ParseKey(rel.Target, m.Table.MarshalOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse row key: %w", err)
+			}
+			rows = append(rows, Row{Key: key, Sort: rel.GSI1SK, Data: data, Source: source})
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	slices.SortStableFunc(rows, func(a, b Row) int { return strings.Compare(a.Sort, b.Sort) })
+	return rows, nil
+}
+
+// Rollup reads the rollup of the view partition into out. [dynamap.ErrItemNotFound] is
+// returned if the partition has no rows.
+func (m *Maintainer) Rollup(ctx context.Context, view, key string, out any) error {
+	_, err := m.Table.Get(ctx, m.Client, &rollupItem{view: view, key: key}, out)
+	return err
+}
+
+// RebuildReport describes the changes made by [Maintainer.Rebuild].
+type RebuildReport struct {
+	Scanned int // Number of items scanned
+	Rows    int // Number of rows written
+	Deleted int // Number of stale rows deleted
+	Rollups int // Number of partitions whose rollup was recomputed
+}
+
+// Rebuild recomputes the named views, or every view if no names are given, from a scan
+// of the table: the rows of every source item are written, rows of the views that no
+// source produced are deleted, and the rollups of every partition are recomputed. Changes
+// made while the table is scanned may not be reflected; apply them afterwards to converge.
+func (m *Maintainer) Rebuild(ctx context.Context, client dynamap.ScanClient, names ...string) (RebuildReport, error) {
+	var (
+		report  RebuildReport
+		writer  = dynamap.NewClient(m.Table, m.Client)
+		views   []*View
+		written = make(map[string]bool)         // keys of the rows written
+		stale   = make(map[string]dynamap.Item) // keys of the rows found
+		keys    = make(map[string]*keySet)      // partitions to reduce, by view
+	)
+
+	for i := range m.Views {
+		if len(names) == 0 || slices.Contains(names, m.Views[i].Name) {
+			views = append(views, &m.Views[i])
+			keys[m.Views[i].Name] = &keySet{}
+		}
+	}
+
+	input := &dynamodb.ScanInput{TableName: aws.String(m.Table.TableName)}
+	for {
+		output, err := client.Scan(ctx, input)
+		if err != nil {
+			return report, fmt.Errorf("failed to scan table %s: %w", m.Table.TableName, err)
+		}
+
+		for _, item := range output.Items {
+			report.Scanned++
+			event, err := dynamap.UnmarshalStreamRecord(nil, item, m.Table.MarshalOptions)
+			if err != nil {
+				return report, err
+			}
+
+			rel := event.Relationship
+			if view, key, ok := m.viewPartition(rel); ok {
+				if partitions, ok := keys[view]; ok {
+					partitions.add(key)
+					if !rel.IsSelf() {
+						stale[itemKey(item)] = dynamap.Item{dynamap.AttributeNameSource: item[dynamap.AttributeNameSource], dynamap.AttributeNameTarget: item[dynamap.AttributeNameTarget]}
+					}
+				}
+				continue
+			}
+
+			for _, view := range views {
+				rows, err := m.mapRows(view, rel, item)
+				if err != nil {
+					return report, err
+				}
+				for _, row := range rows {
+					put, err := m.putRow(ctx, writer, view, sourceKey(rel), row)
+					if err != nil {
+						return report, err
+					}
+					written[itemKey(put)] = true
+					keys[view.Name].add(row.Key)
+					report.Rows++
+				}
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	for k, key := range stale {
+		if written[k] {
+			continue
+		}
+		if _, err := writer.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String(m.Table.TableName), Key: key}); err != nil {
+			return report, fmt.Errorf("failed to delete row: %w", err)
+		}
+		report.Deleted++
+	}
+
+	for _, view := range views {
+		if view.Reduce == nil {
+			continue
+		}
+		for _, key := range keys[view.Name].keys {
+			if err := m.reduce(ctx, writer, view, key); err != nil {
+				return report, err
+			}
+			report.Rollups++
+		}
+	}
+
+	return report, nil
+}
+
+// isViewItem returns true if the relationship is a row or rollup of a view.
+func (m *Maintainer) isViewItem(rel dynamap.Relationship) bool {
+	_, _, ok := m.viewPartition(rel)
+	return ok
+}
+
+// viewPartition returns the view and partition key of a row or rollup.
+func (m *Maintainer) viewPartition(rel dynamap.Relationship) (view, key string, ok bool) {
+	prefix, id, err := dynamap.ParseKey(rel.Source, m.Table.MarshalOptions)
+	if err != nil {
+		return "", "", false
+	}
+	for _, v := range m.Views {
+		if v.Name == prefix {
+			return prefix, id, true
+		}
+	}
+	return "", "", false
+}
+
+// keySet is a set of partition keys in insertion order.
+type keySet struct {
+	keys []string
+	seen map[string]bool
+}
+
+func (s *keySet) add(key string) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if !s.seen[key] {
+		s.seen[key] = true
+		s.keys = append(s.keys, key)
+	}
+}
+
+// itemKey returns the hash and sort keys of the item as a string.
+func itemKey(item dynamap.Item) string {
+	return dynamap.StringValue(item[dynamap.AttributeNameSource]) + "\x00" + dynamap.StringValue(item[dynamap.AttributeNameTarget])
+}
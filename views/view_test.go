@@ -0,0 +1,262 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nisimpson/dynamap"
+	"github.com/nisimpson/dynamap/cdc"
+)
+
+type order struct {
+	ID         string `dynamodbav:"id"`
+	CustomerID string `dynamodbav:"customer_id"`
+	Total      int    `dynamodbav:"total"`
+}
+
+func (o *order) MarshalSelf(opts *dynamap.MarshalOptions) error {
+	opts.WithSelfTarget("order", o.ID)
+	return nil
+}
+
+type summary struct {
+	Orders int `dynamodbav:"orders"`
+	Total  int `dynamodbav:"total"`
+}
+
+// memoryClient stores items in memory. Queries match items whose hash key equals one of
+// the expression values and whose sort key begins with another; scans return every item
+// in a single page.
+type memoryClient struct {
+	dynamap.DynamoDBClient
+	mu    sync.Mutex
+	items map[string]dynamap.Item
+}
+
+func newMemoryClient() *memoryClient {
+	return &memoryClient{items: make(map[string]dynamap.Item)}
+}
+
+func (c *memoryClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[itemKey(params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *memoryClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: c.items[itemKey(params.Key)]}, nil
+}
+
+func (c *memoryClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, itemKey(params.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (c *memoryClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var values []string
+	for _, value := range params.ExpressionAttributeValues {
+		values = append(values, dynamap.StringValue(value))
+	}
+
+	var items []dynamap.Item
+	for _, item := range c.items {
+		hk, sk := dynamap.StringValue(item[dynamap.AttributeNameSource]), dynamap.StringValue(item[dynamap.AttributeNameTarget])
+		for _, prefix := range values {
+			if prefix != hk && strings.HasPrefix(sk, prefix) && containsString(values, hk) {
+				items = append(items, item)
+				break
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return itemKey(items[i]) < itemKey(items[j]) })
+	return &dynamodb.QueryOutput{Items: items}, nil
+}
+
+func (c *memoryClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := &dynamodb.ScanOutput{}
+	for _, item := range c.items {
+		output.Items = append(output.Items, item)
+	}
+	return output, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+func ordersByCustomer() View {
+	return View{
+		Name:   "orders-by-customer",
+		Labels: []string{"order"},
+		Filter: func(src Source) bool {
+			var o order
+			return src.Unmarshal(&o) == nil && o.CustomerID != ""
+		},
+		Map: func(src Source) ([]Row, error) {
+			var o order
+			if err := src.Unmarshal(&o); err != nil {
+				return nil, err
+			}
+			return []Row{{Key: o.CustomerID, Sort: o.ID, Data: o}}, nil
+		},
+		Reduce: func(key string, rows []Row) (any, error) {
+			var s summary
+			for _, row := range rows {
+				var o order
+				if err := row.Decode(&o); err != nil {
+					return nil, err
+				}
+				s.Orders++
+				s.Total += o.Total
+			}
+			return s, nil
+		},
+	}
+}
+
+// put writes the order and returns the change event of the write.
+func put(t *testing.T, table *dynamap.Table, client *memoryClient, o *order) dynamap.ChangeEvent {
+	t.Helper()
+	input, err := table.MarshalPut(o)
+	if err != nil {
+		t.Fatalf("Failed to marshal order: %v", err)
+	}
+	old := client.items[itemKey(input.Item)]
+	if _, err := client.PutItem(context.Background(), input); err != nil {
+		t.Fatalf("Failed to put order: %v", err)
+	}
+	event, err := dynamap.UnmarshalStreamRecord(old, input.Item)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal change: %v", err)
+	}
+	return event
+}
+
+func checkRollup(t *testing.T, m *Maintainer, key string, want summary) {
+	t.Helper()
+	var got summary
+	if err := m.Rollup(context.Background(), "orders-by-customer", key, &got); err != nil {
+		t.Fatalf("Failed to get rollup of %s: %v", key, err)
+	}
+	if got != want {
+		t.Errorf("Expected rollup of %s to be %+v, got %+v", key, want, got)
+	}
+}
+
+func TestMaintainer(t *testing.T) {
+	ctx := context.Background()
+	table := dynamap.NewTable("test-table")
+	client := newMemoryClient()
+	m := NewMaintainer(table, client, ordersByCustomer())
+
+	events := []dynamap.ChangeEvent{
+		put(t, table, client, &order{ID: "O2", CustomerID: "C1", Total: 5}),
+		put(t, table, client, &order{ID: "O1", CustomerID: "C1", Total: 10}),
+		put(t, table, client, &order{ID: "O3", CustomerID: "C2", Total: 7}),
+		put(t, table, client, &order{ID: "O4", Total: 1}),
+	}
+	if err := m.Apply(ctx, events); err != nil {
+		t.Fatalf("Failed to apply changes: %v", err)
+	}
+
+	rows, err := m.Rows(ctx, "orders-by-customer", "C1")
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Sort != "O1" || rows[1].Sort != "O2" || rows[0].Source != "order#O1" {
+		t.Fatalf("Expected the orders of C1 in sort order, got %+v", rows)
+	}
+	var first order
+	if err := rows[0].Decode(&first); err != nil || first.Total != 10 {
+		t.Errorf("Expected the decoded order, got %+v (%v)", first, err)
+	}
+	checkRollup(t, m, "C1", summary{Orders: 2, Total: 15})
+	checkRollup(t, m, "C2", summary{Orders: 1, Total: 7})
+
+	t.Run("moves rows between partitions", func(t *testing.T) {
+		event := put(t, table, client, &order{ID: "O2", CustomerID: "C2", Total: 5})
+		if err := m.Apply(ctx, []dynamap.ChangeEvent{event}); err != nil {
+			t.Fatalf("Failed to apply change: %v", err)
+		}
+		checkRollup(t, m, "C1", summary{Orders: 1, Total: 10})
+		checkRollup(t, m, "C2", summary{Orders: 2, Total: 12})
+	})
+
+	t.Run("is idempotent", func(t *testing.T) {
+		before := len(client.items)
+		if err := m.Apply(ctx, events[1:2]); err != nil {
+			t.Fatalf("Failed to apply change: %v", err)
+		}
+		if len(client.items) != before {
+			t.Errorf("Expected no new items, got %d, want %d", len(client.items), before)
+		}
+		checkRollup(t, m, "C1", summary{Orders: 1, Total: 10})
+	})
+
+	t.Run("deletes empty partitions", func(t *testing.T) {
+		input, _ := table.MarshalDelete(&order{ID: "O1"})
+		old := client.items[itemKey(input.Key)]
+		client.DeleteItem(ctx, input)
+		event, _ := dynamap.UnmarshalStreamRecord(old, nil)
+
+		p := cdc.NewProcessor(table)
+		var deleted int
+		p.OnEntityDeleted = func(ctx context.Context, change cdc.EntityChange) error {
+			deleted++
+			return nil
+		}
+		m.Attach(p)
+		if err := p.Process(ctx, []dynamap.ChangeEvent{event}); err != nil {
+			t.Fatalf("Failed to process change: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("Expected the previous handler to run, got %d calls", deleted)
+		}
+
+		if rows, err := m.Rows(ctx, "orders-by-customer", "C1"); err != nil || len(rows) != 0 {
+			t.Errorf("Expected no rows, got %+v (%v)", rows, err)
+		}
+		var got summary
+		if err := m.Rollup(ctx, "orders-by-customer", "C1", &got); !errors.Is(err, dynamap.ErrItemNotFound) {
+			t.Errorf("Expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rebuilds", func(t *testing.T) {
+		// Lose a row and leave a stale one behind
+		stale := &rowItem{view: "orders-by-customer", source: "order#O9", row: Row{Key: "C2", Data: order{ID: "O9", Total: 100}}}
+		staleInput, _ := table.MarshalPut(stale)
+		client.PutItem(ctx, staleInput)
+		lost, _ := table.MarshalDelete(&rowItem{view: "orders-by-customer", source: "order#O3", row: Row{Key: "C2"}})
+		client.DeleteItem(ctx, lost)
+
+		report, err := m.Rebuild(ctx, client)
+		if err != nil {
+			t.Fatalf("Failed to rebuild: %v", err)
+		}
+		if report.Rows != 2 || report.Deleted != 1 || report.Rollups != 1 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		checkRollup(t, m, "C2", summary{Orders: 2, Total: 12})
+	})
+}